@@ -34,6 +34,9 @@ func addStatusParts(vtg *vtgate.VTGate) {
 	servenv.AddStatusPart("VSchema", vtgate.VSchemaTemplate, func() any {
 		return vtg.VSchemaStats()
 	})
+	servenv.AddStatusPart("Query Memory", vtgate.QueryMemoryTemplate, func() any {
+		return vtg.QueryMemoryStats()
+	})
 	servenv.AddStatusFuncs(srvtopo.StatusFuncs)
 	servenv.AddStatusPart("Topology Cache", srvtopo.TopoTemplate, func() any {
 		return resilientServer.CacheStatus()
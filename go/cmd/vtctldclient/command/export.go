@@ -0,0 +1,327 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"vitess.io/vitess/go/cmd/vtctldclient/cli"
+	"vitess.io/vitess/go/sqlescape"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+var (
+	// Export makes an Export gRPC call to a vtctld.
+	Export = &cobra.Command{
+		Use:   "Export [--tables <tables>] [--shards <shards>] [--tablet-types <tablet_types>] [--format csv|jsonl|sql] [--output-dir <dir>] <keyspace>",
+		Short: "Exports a consistent snapshot of the tables of a keyspace to local files, one per table.",
+		Long: `Exports a consistent snapshot of the tables of a keyspace to local files, one per table.
+
+The rows of each shard are read from one of its tablets, whose replication is stopped while its tables are read, so
+that they are all read at the same position. The positions of the shards are written to vgtid.json, in the format of
+the VGtid of a VStream: a VStream started from it gets the changes made since the export.
+
+The csv format writes a header with the names of the columns, and NULL values as \N. The jsonl format writes one JSON
+object per row, with the values of the binary columns encoded in base64. The sql format writes the CREATE TABLE
+statement of the table followed by INSERT statements.`,
+		Example:               "Export --tables customer,corder --format jsonl --output-dir /tmp/commerce commerce",
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		RunE:                  commandExport,
+	}
+)
+
+var exportOptions = struct {
+	Tables      []string
+	Shards      []string
+	TabletTypes []topodatapb.TabletType
+	Format      string
+	OutputDir   string
+}{}
+
+func commandExport(cmd *cobra.Command, args []string) error {
+	switch exportOptions.Format {
+	case "csv", "jsonl", "sql":
+	default:
+		return fmt.Errorf("invalid format %q, must be one of csv, jsonl or sql", exportOptions.Format)
+	}
+	keyspace := cmd.Flags().Arg(0)
+
+	cli.FinishedParsing(cmd)
+
+	if err := os.MkdirAll(exportOptions.OutputDir, 0o755); err != nil {
+		return err
+	}
+
+	stream, err := client.Export(commandCtx, &vtctldatapb.ExportRequest{
+		Keyspace:    keyspace,
+		Tables:      exportOptions.Tables,
+		Shards:      exportOptions.Shards,
+		TabletTypes: exportOptions.TabletTypes,
+	})
+	if err != nil {
+		return err
+	}
+
+	exporter := newExporter(exportOptions.Format, func(table string) (io.WriteCloser, error) {
+		return os.Create(filepath.Join(exportOptions.OutputDir, table+"."+exportOptions.Format))
+	})
+	defer exporter.close()
+
+	for {
+		resp, err := stream.Recv()
+		switch err {
+		case nil:
+		case io.EOF:
+			return fmt.Errorf("the export of keyspace %s ended without a VGtid", keyspace)
+		default:
+			return err
+		}
+
+		if resp.Vgtid == nil {
+			if err := exporter.write(resp); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := exporter.close(); err != nil {
+			return err
+		}
+		data, err := cli.MarshalJSON(resp.Vgtid)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(exportOptions.OutputDir, "vgtid.json"), append(data, '\n'), 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("Exported %d rows of %d tables of keyspace %s to %s at VGtid %s\n", exporter.rows, len(exporter.files), keyspace, exportOptions.OutputDir, data)
+		return nil
+	}
+}
+
+// exporter writes the rows of an export to a file per table. The rows of a
+// table of all the shards are written to the same file.
+type exporter struct {
+	format string
+	create func(table string) (io.WriteCloser, error)
+
+	files map[string]*exportFile
+	// fields are keyed by shard and table, as the rows of the shards are
+	// streamed concurrently.
+	fields map[string][]*querypb.Field
+	rows   int64
+}
+
+type exportFile struct {
+	table string
+	f     io.WriteCloser
+	w     *bufio.Writer
+	csv   *csv.Writer
+}
+
+func newExporter(format string, create func(table string) (io.WriteCloser, error)) *exporter {
+	return &exporter{
+		format: format,
+		create: create,
+		files:  map[string]*exportFile{},
+		fields: map[string][]*querypb.Field{},
+	}
+}
+
+func (e *exporter) write(resp *vtctldatapb.ExportResponse) error {
+	key := resp.Shard + "/" + resp.Table
+	if resp.Fields != nil {
+		e.fields[key] = resp.Fields
+	}
+	fields, ok := e.fields[key]
+	if !ok {
+		return fmt.Errorf("received rows of table %s of shard %s before its fields", resp.Table, resp.Shard)
+	}
+
+	ef, ok := e.files[resp.Table]
+	if !ok {
+		f, err := e.create(resp.Table)
+		if err != nil {
+			return err
+		}
+		ef = &exportFile{table: resp.Table, f: f, w: bufio.NewWriter(f)}
+		e.files[resp.Table] = ef
+		if err := ef.writeHeader(e.format, fields, resp); err != nil {
+			return err
+		}
+	}
+
+	rows := make([][]sqltypes.Value, 0, len(resp.Rows))
+	for _, row := range resp.Rows {
+		rows = append(rows, sqltypes.MakeRowTrusted(fields, row))
+	}
+	e.rows += int64(len(rows))
+	return ef.writeRows(e.format, fields, rows)
+}
+
+// close flushes and closes the files of the export. It is safe to call it
+// more than once.
+func (e *exporter) close() error {
+	var firstErr error
+	for _, ef := range e.files {
+		if ef.f == nil {
+			continue
+		}
+		if ef.csv != nil {
+			ef.csv.Flush()
+			if err := ef.csv.Error(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if err := ef.w.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err := ef.f.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		ef.f = nil
+	}
+	return firstErr
+}
+
+func (ef *exportFile) writeHeader(format string, fields []*querypb.Field, resp *vtctldatapb.ExportResponse) error {
+	switch format {
+	case "csv":
+		ef.csv = csv.NewWriter(ef.w)
+		names := make([]string, len(fields))
+		for i, field := range fields {
+			names[i] = field.Name
+		}
+		return ef.csv.Write(names)
+	case "sql":
+		if resp.TableDefinition == nil || resp.TableDefinition.Schema == "" {
+			return fmt.Errorf("missing the definition of table %s", resp.Table)
+		}
+		_, err := fmt.Fprintf(ef.w, "DROP TABLE IF EXISTS %s;\n%s;\n", sqlescape.EscapeID(resp.Table), resp.TableDefinition.Schema)
+		return err
+	}
+	return nil
+}
+
+func (ef *exportFile) writeRows(format string, fields []*querypb.Field, rows [][]sqltypes.Value) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	switch format {
+	case "csv":
+		record := make([]string, len(fields))
+		for _, row := range rows {
+			for i, v := range row {
+				if v.IsNull() {
+					record[i] = `\N`
+				} else {
+					record[i] = v.ToString()
+				}
+			}
+			if err := ef.csv.Write(record); err != nil {
+				return err
+			}
+		}
+	case "jsonl":
+		var buf bytes.Buffer
+		for _, row := range rows {
+			buf.Reset()
+			buf.WriteByte('{')
+			for i, v := range row {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				name, err := json.Marshal(fields[i].Name)
+				if err != nil {
+					return err
+				}
+				value, err := json.Marshal(exportValueToJSON(v))
+				if err != nil {
+					return err
+				}
+				buf.Write(name)
+				buf.WriteByte(':')
+				buf.Write(value)
+			}
+			buf.WriteString("}\n")
+			if _, err := ef.w.Write(buf.Bytes()); err != nil {
+				return err
+			}
+		}
+	case "sql":
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "INSERT INTO %s VALUES ", sqlescape.EscapeID(ef.table))
+		for i, row := range rows {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('(')
+			for j, v := range row {
+				if j > 0 {
+					buf.WriteByte(',')
+				}
+				v.EncodeSQL(&buf)
+			}
+			buf.WriteByte(')')
+		}
+		buf.WriteString(";\n")
+		if _, err := ef.w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportValueToJSON(v sqltypes.Value) any {
+	switch {
+	case v.IsNull():
+		return nil
+	case v.IsIntegral(), v.IsFloat(), v.IsDecimal():
+		return json.Number(v.ToString())
+	case v.Type() == querypb.Type_JSON:
+		return json.RawMessage(v.Raw())
+	case sqltypes.IsBinary(v.Type()):
+		// Encoded in base64.
+		return v.Raw()
+	default:
+		return v.ToString()
+	}
+}
+
+func init() {
+	Export.Flags().StringSliceVar(&exportOptions.Tables, "tables", nil, "The tables to export. All the tables of the keyspace are exported by default.")
+	Export.Flags().StringSliceVar(&exportOptions.Shards, "shards", nil, "The shards to export. All the shards of the keyspace are exported by default.")
+	Export.Flags().Var((*topoproto.TabletTypeListFlag)(&exportOptions.TabletTypes), "tablet-types", "The types of the tablets to read the rows from, in order of preference. Their replication is stopped during the export. Defaults to RDONLY,REPLICA.")
+	Export.Flags().StringVar(&exportOptions.Format, "format", "csv", "The format of the files: csv, jsonl or sql.")
+	Export.Flags().StringVar(&exportOptions.OutputDir, "output-dir", ".", "The directory the files are written to.")
+	Root.AddCommand(Export)
+}
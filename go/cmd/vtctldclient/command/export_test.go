@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+type exportTestFile struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (f *exportTestFile) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestExporter(t *testing.T) {
+	fields := sqltypes.MakeTestFields("id|name|data|doc", "int64|varchar|varbinary|json")
+	td := &tabletmanagerdatapb.TableDefinition{
+		Name:   "t1",
+		Schema: "CREATE TABLE `t1` (\n  `id` bigint NOT NULL\n)",
+	}
+	// The rows of the two shards are interleaved, and only the first
+	// response of each shard has the fields of the table.
+	responses := []*vtctldatapb.ExportResponse{{
+		Shard:           "-80",
+		Table:           "t1",
+		TableDefinition: td,
+		Fields:          fields,
+		Rows:            sqltypes.RowsToProto3(sqltypes.MakeTestResult(fields, `1|a,"b"|ab|{"k": 1}`).Rows),
+	}, {
+		Shard:           "80-",
+		Table:           "t1",
+		TableDefinition: td,
+		Fields:          fields,
+	}, {
+		Shard: "-80",
+		Table: "t1",
+		Rows:  sqltypes.RowsToProto3(sqltypes.MakeTestResult(fields, "2|null|null|null").Rows),
+	}, {
+		Shard: "80-",
+		Table: "t1",
+		Rows:  sqltypes.RowsToProto3(sqltypes.MakeTestResult(fields, "3|c|cd|[]").Rows),
+	}}
+
+	testcases := []struct {
+		format string
+		want   string
+	}{{
+		format: "csv",
+		want: `id,name,data,doc
+1,"a,""b""",ab,"{""k"": 1}"
+2,\N,\N,\N
+3,c,cd,[]
+`,
+	}, {
+		format: "jsonl",
+		want: `{"id":1,"name":"a,\"b\"","data":"YWI=","doc":{"k":1}}
+{"id":2,"name":null,"data":null,"doc":null}
+{"id":3,"name":"c","data":"Y2Q=","doc":[]}
+`,
+	}, {
+		format: "sql",
+		want: "DROP TABLE IF EXISTS `t1`;\nCREATE TABLE `t1` (\n  `id` bigint NOT NULL\n);\n" +
+			`INSERT INTO ` + "`t1`" + ` VALUES (1,'a,\"b\"','ab','{\"k\": 1}');
+INSERT INTO ` + "`t1`" + ` VALUES (2,null,null,null);
+INSERT INTO ` + "`t1`" + ` VALUES (3,'c','cd','[]');
+`,
+	}}
+	for _, tcase := range testcases {
+		t.Run(tcase.format, func(t *testing.T) {
+			files := map[string]*exportTestFile{}
+			e := newExporter(tcase.format, func(table string) (io.WriteCloser, error) {
+				f := &exportTestFile{}
+				files[table] = f
+				return f, nil
+			})
+			for _, resp := range responses {
+				require.NoError(t, e.write(resp))
+			}
+			require.NoError(t, e.close())
+			require.NoError(t, e.close())
+
+			assert.EqualValues(t, 3, e.rows)
+			require.Len(t, files, 1)
+			assert.True(t, files["t1"].closed)
+			assert.Equal(t, tcase.want, files["t1"].String())
+		})
+	}
+
+	e := newExporter("csv", nil)
+	err := e.write(&vtctldatapb.ExportResponse{Shard: "-80", Table: "t1"})
+	assert.ErrorContains(t, err, "received rows of table t1 of shard -80 before its fields")
+}
@@ -40,6 +40,7 @@ import (
 	_ "vitess.io/vitess/go/cmd/vtctldclient/command/vreplication/movetables"
 	_ "vitess.io/vitess/go/cmd/vtctldclient/command/vreplication/reshard"
 	_ "vitess.io/vitess/go/cmd/vtctldclient/command/vreplication/vdiff"
+	_ "vitess.io/vitess/go/cmd/vtctldclient/command/vreplication/vindexswap"
 	_ "vitess.io/vitess/go/cmd/vtctldclient/command/vreplication/workflow"
 )
 
@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"vitess.io/vitess/go/cmd/vtctldclient/cli"
+
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+var (
+	// DistributedTransaction is the parent command for the commands
+	// operating on distributed (2PC) transactions.
+	DistributedTransaction = &cobra.Command{
+		Use:                   "DistributedTransaction <cmd>",
+		Short:                 "Perform commands on distributed transactions.",
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.MinimumNArgs(1),
+	}
+	// DistributedTransactionList makes a GetUnresolvedTransactions gRPC call to a vtctld.
+	DistributedTransactionList = &cobra.Command{
+		Use:                   "list [--abandon-age <duration>] <keyspace>",
+		Short:                 "Lists the unresolved distributed transactions of a keyspace.",
+		Example:               "DistributedTransaction list --abandon-age 1m commerce",
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		RunE:                  commandGetUnresolvedTransactions,
+	}
+	// DistributedTransactionConclude makes a ConcludeTransaction gRPC call to a vtctld.
+	DistributedTransactionConclude = &cobra.Command{
+		Use:                   "conclude <dtid>",
+		Short:                 "Concludes an unresolved distributed transaction, committing or rolling it back on all its participants.",
+		Long:                  "Concludes an unresolved distributed transaction. The transaction is committed if the commit decision was recorded, and rolled back otherwise.",
+		Example:               "DistributedTransaction conclude commerce:-80:1234",
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		RunE:                  commandConcludeTransaction,
+	}
+)
+
+var distributedTransactionListOptions = struct {
+	AbandonAge time.Duration
+}{}
+
+func commandGetUnresolvedTransactions(cmd *cobra.Command, args []string) error {
+	cli.FinishedParsing(cmd)
+
+	resp, err := client.GetUnresolvedTransactions(commandCtx, &vtctldatapb.GetUnresolvedTransactionsRequest{
+		Keyspace:   cmd.Flags().Arg(0),
+		AbandonAge: int64(distributedTransactionListOptions.AbandonAge.Seconds()),
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSON(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+	return nil
+}
+
+func commandConcludeTransaction(cmd *cobra.Command, args []string) error {
+	dtid := cmd.Flags().Arg(0)
+	cli.FinishedParsing(cmd)
+
+	_, err := client.ConcludeTransaction(commandCtx, &vtctldatapb.ConcludeTransactionRequest{
+		Dtid: dtid,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Successfully concluded distributed transaction %s\n", dtid)
+	return nil
+}
+
+func init() {
+	DistributedTransactionList.Flags().DurationVar(&distributedTransactionListOptions.AbandonAge, "abandon-age", 0, "Only list the transactions older than this. If not set, the abandon age configured on the tablets is used.")
+	DistributedTransaction.AddCommand(DistributedTransactionList)
+	DistributedTransaction.AddCommand(DistributedTransactionConclude)
+
+	Root.AddCommand(DistributedTransaction)
+}
@@ -28,6 +28,7 @@ import (
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/topo/topoproto"
 
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
 	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
 )
 
@@ -35,6 +36,8 @@ var (
 	createOptions = struct {
 		SourceKeyspace string
 		TableSettings  tableSettings
+		Sink           string
+		SinkParams     map[string]string
 	}{}
 
 	// create makes a MaterializeCreate gRPC call to a vtctld.
@@ -51,7 +54,10 @@ in the target-keyspace to store the results in. The second required key is 'sour
 and its value is the select query to run against the source table. An optional key/value pair
 can also be specified for 'create_ddl' which provides the DDL to create the target table if it
 does not exist -- you can alternatively specify a value of 'copy' if the target table schema
-should be copied as-is from the source keyspace. Here's an example value for table-settings:
+should be copied as-is from the source keyspace. Another optional key is 'transform' which
+renames, drops, adds computed, or converts the type of columns of a table whose source_expression
+selects all of its columns; with a create_ddl of 'copy' the target table is created reshaped.
+Here's an example value for table-settings:
 [
   {
     "target_table": "customer_one_email",
@@ -62,6 +68,15 @@ should be copied as-is from the source keyspace. Here's an example value for tab
     "source_expression": "select * from states",
     "create_ddl": "copy"
   },
+  {
+    "target_table": "users",
+    "source_expression": "select * from users",
+    "create_ddl": "copy",
+    "transform": {
+      "rename_columns": {"email": "email_address"},
+      "add_columns": [{"name": "email_lower", "type": "varchar(128)", "expression": "lower(email)"}]
+    }
+  },
   {
     "target_table": "sales_by_sku",
     "source_expression": "select sku, count(*) as orders, sum(price) as revenue from corder group by sku",
@@ -77,6 +92,9 @@ should be copied as-is from the source keyspace. Here's an example value for tab
 			if err := common.ParseAndValidateCreateOptions(cmd); err != nil {
 				return err
 			}
+			if createOptions.Sink == "" && len(createOptions.SinkParams) > 0 {
+				return fmt.Errorf("sink-params require a sink")
+			}
 			return nil
 		},
 		RunE: commandCreate,
@@ -101,6 +119,12 @@ func commandCreate(cmd *cobra.Command, args []string) error {
 		TabletTypes:               topoproto.MakeStringTypeCSV(common.CreateOptions.TabletTypes),
 		TabletSelectionPreference: tsp,
 	}
+	if createOptions.Sink != "" {
+		ms.Sink = &binlogdatapb.Sink{
+			Type:   createOptions.Sink,
+			Params: createOptions.SinkParams,
+		}
+	}
 
 	req := &vtctldatapb.MaterializeCreateRequest{
 		Settings: ms,
@@ -44,6 +44,8 @@ func registerCommands(root *cobra.Command) {
 	create.Flags().StringVar(&createOptions.SourceKeyspace, "source-keyspace", "", "Keyspace where the tables queried in the 'source_expression' values within table-settings live.")
 	create.MarkFlagRequired("source-keyspace")
 	create.Flags().Var(&createOptions.TableSettings, "table-settings", "A JSON array defining what tables to materialize using what select statements. See the --help output for more details.")
+	create.Flags().StringVar(&createOptions.Sink, "sink", "", "Write the rows to this type of sink, e.g. 'file' or 'http', instead of the target tables, which must still exist to define the shape of the rows.")
+	create.Flags().StringToStringVar(&createOptions.SinkParams, "sink-params", nil, "Parameters of the sink, e.g. 'dir=/vt/sink,format=columnar' for a file sink or 'url=http://host/hook' for an http sink.")
 	create.MarkFlagRequired("table-settings")
 	create.Flags().BoolVar(&common.CreateOptions.StopAfterCopy, "stop-after-copy", false, "Stop the workflow after it's finished copying the existing rows and before it starts replicating changes.")
 	base.AddCommand(create)
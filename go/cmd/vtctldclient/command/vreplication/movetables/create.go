@@ -17,6 +17,7 @@ limitations under the License.
 package movetables
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -39,6 +40,7 @@ var (
 		SourceTimeZone      string
 		NoRoutingRules      bool
 		AtomicCopy          bool
+		TableTransforms     tableTransforms
 	}{}
 
 	// create makes a MoveTablesCreate gRPC call to a vtctld.
@@ -109,6 +111,7 @@ func commandCreate(cmd *cobra.Command, args []string) error {
 		StopAfterCopy:             common.CreateOptions.StopAfterCopy,
 		NoRoutingRules:            createOptions.NoRoutingRules,
 		AtomicCopy:                createOptions.AtomicCopy,
+		TableTransforms:           createOptions.TableTransforms.val,
 	}
 
 	resp, err := common.GetClient().MoveTablesCreate(common.GetCommandCtx(), req)
@@ -120,3 +123,39 @@ func commandCreate(cmd *cobra.Command, args []string) error {
 	}
 	return nil
 }
+
+// tableTransforms is a wrapper around a map of table names to TableTransform
+// proto messages that implements the pflag.Value interface.
+type tableTransforms struct {
+	val map[string]*vtctldatapb.TableTransform
+}
+
+func (tt *tableTransforms) String() string {
+	if len(tt.val) == 0 {
+		return ""
+	}
+	ttj, _ := json.Marshal(tt.val)
+	return string(ttj)
+}
+
+func (tt *tableTransforms) Set(v string) error {
+	tt.val = make(map[string]*vtctldatapb.TableTransform)
+	if err := json.Unmarshal([]byte(v), &tt.val); err != nil {
+		return fmt.Errorf("table-transforms is not valid JSON")
+	}
+	for table, transform := range tt.val {
+		if transform == nil {
+			return fmt.Errorf("empty transform for table %s", table)
+		}
+		for _, col := range transform.AddColumns {
+			if col.Name == "" || col.Type == "" || col.Expression == "" {
+				return fmt.Errorf("missing name, type or expression of a computed column of table %s", table)
+			}
+		}
+	}
+	return nil
+}
+
+func (tt *tableTransforms) Type() string {
+	return "JSON"
+}
@@ -47,6 +47,7 @@ func registerCommands(root *cobra.Command) {
 	create.Flags().StringSliceVar(&createOptions.ExcludeTables, "exclude-tables", nil, "Source tables to exclude from copying.")
 	create.Flags().BoolVar(&createOptions.NoRoutingRules, "no-routing-rules", false, "(Advanced) Do not create routing rules while creating the workflow. See the reference documentation for limitations if you use this flag.")
 	create.Flags().BoolVar(&createOptions.AtomicCopy, "atomic-copy", false, "(EXPERIMENTAL) A single copy phase is run for all tables from the source. Use this, for example, if your source keyspace has tables which use foreign key constraints.")
+	create.Flags().Var(&createOptions.TableTransforms, "table-transforms", "A JSON object of the tables to reshape while they are moved, mapping each table name to its transform, e.g. '{\"customer\": {\"rename_columns\": {\"email\": \"email_address\"}, \"drop_columns\": [\"legacy_id\"], \"add_columns\": [{\"name\": \"email_lower\", \"type\": \"varchar(128)\", \"expression\": \"lower(email)\"}], \"convert_columns\": {\"name\": \"varchar(255) character set utf8mb4\"}}}'. Columns are named by their source names.")
 	base.AddCommand(create)
 
 	opts := &common.SubCommandsOpts{
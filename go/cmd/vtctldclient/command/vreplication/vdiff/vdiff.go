@@ -25,6 +25,7 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/bndr/gotabulate"
@@ -66,12 +67,20 @@ var (
 		Wait                        bool
 		WaitUpdateInterval          time.Duration
 		AutoRetry                   bool
+		ChunkRows                   uint32 // We only accept positive values but pass on an int64
+		MaxParallelChunks           uint32 // We only accept positive values but pass on an int64
+		RecordMismatches            bool
+		MismatchesDir               string
 	}{}
 
 	deleteOptions = struct {
 		Arg string
 	}{}
 
+	repairOptions = struct {
+		UUID uuid.UUID
+	}{}
+
 	resumeOptions = struct {
 		UUID uuid.UUID
 	}{}
@@ -163,6 +172,28 @@ vtctldclient --server localhost:15999 vdiff --workflow commerce2customer --targe
 		RunE: commandDelete,
 	}
 
+	// repair makes a VDiffRepair gRPC call to a vtctld.
+	repair = &cobra.Command{
+		Use:   "repair",
+		Short: "Copy the mismatched rows of a completed VDiff from the source again, and compare them again.",
+		Long: `Copy the mismatched rows of a completed VDiff from the source again, and compare them again.
+The VDiff must have been created with --record-mismatches. The rows that match once repaired
+are no longer recorded as mismatched, so the repair can be run again for the others.`,
+		Example:               `vtctldclient --server localhost:15999 vdiff --workflow commerce2customer --target-keyspace customer repair a037a9e2-5628-11ee-8c99-0242ac120002`,
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Repair"},
+		Args:                  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			uuid, err := uuid.Parse(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid UUID provided: %v", err)
+			}
+			repairOptions.UUID = uuid
+			return nil
+		},
+		RunE: commandRepair,
+	}
+
 	// resume makes a VDiffResume gRPC call to a vtctld.
 	resume = &cobra.Command{
 		Use:                   "resume",
@@ -279,6 +310,10 @@ func commandCreate(cmd *cobra.Command, args []string) error {
 		Wait:                        createOptions.Wait,
 		WaitUpdateInterval:          protoutil.DurationToProto(createOptions.WaitUpdateInterval),
 		AutoRetry:                   createOptions.AutoRetry,
+		ChunkRows:                   int64(createOptions.ChunkRows),
+		MaxParallelChunks:           int64(createOptions.MaxParallelChunks),
+		RecordMismatches:            createOptions.RecordMismatches,
+		MismatchesDir:               createOptions.MismatchesDir,
 	})
 
 	if err != nil {
@@ -351,6 +386,80 @@ func commandDelete(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func commandRepair(cmd *cobra.Command, args []string) error {
+	format, err := common.GetOutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	cli.FinishedParsing(cmd)
+
+	resp, err := common.GetClient().VDiffRepair(common.GetCommandCtx(), &vtctldatapb.VDiffRepairRequest{
+		Workflow:       common.BaseOptions.Workflow,
+		TargetKeyspace: common.BaseOptions.TargetKeyspace,
+		Uuid:           repairOptions.UUID.String(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return displayRepairResponse(cmd.OutOrStdout(), format, resp)
+}
+
+// repairSummary is the result of the repair of the mismatched rows of a
+// table on a shard.
+type repairSummary struct {
+	Shard      string
+	Table      string
+	Mismatches int64
+	Repaired   int64
+	Verified   int64
+}
+
+func buildRepairSummaries(resp *vtctldatapb.VDiffRepairResponse) []*repairSummary {
+	var summaries []*repairSummary
+	for shard, tabletResp := range resp.TabletResponses {
+		if tabletResp == nil || tabletResp.Output == nil {
+			continue
+		}
+		qr := sqltypes.Proto3ToResult(tabletResp.Output)
+		for _, row := range qr.Named().Rows {
+			summaries = append(summaries, &repairSummary{
+				Shard:      shard,
+				Table:      row.AsString("table_name", ""),
+				Mismatches: row.AsInt64("mismatches", 0),
+				Repaired:   row.AsInt64("repaired", 0),
+				Verified:   row.AsInt64("verified", 0),
+			})
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Shard != summaries[j].Shard {
+			return summaries[i].Shard < summaries[j].Shard
+		}
+		return summaries[i].Table < summaries[j].Table
+	})
+	return summaries
+}
+
+func displayRepairResponse(out io.Writer, format string, resp *vtctldatapb.VDiffRepairResponse) error {
+	summaries := buildRepairSummaries(resp)
+	if format == "json" {
+		jsonText, err := cli.MarshalJSONPretty(summaries)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(jsonText))
+		return nil
+	}
+	tw := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "Shard\tTable\tMismatches\tRepaired\tVerified")
+	for _, rs := range summaries {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\n", rs.Shard, rs.Table, rs.Mismatches, rs.Repaired, rs.Verified)
+	}
+	return tw.Flush()
+}
+
 func commandResume(cmd *cobra.Command, args []string) error {
 	format, err := common.GetOutputFormat(cmd)
 	if err != nil {
@@ -382,6 +491,9 @@ type tableSummary struct {
 	MismatchedRows  int64
 	ExtraRowsSource int64
 	ExtraRowsTarget int64
+	// Chunks and ChunksCompleted are only set for the tables diffed in chunks.
+	Chunks          int64  `json:"Chunks,omitempty"`
+	ChunksCompleted int64  `json:"ChunksCompleted,omitempty"`
 	LastUpdated     string `json:"LastUpdated,omitempty"`
 }
 
@@ -709,6 +821,9 @@ func buildSingleSummary(keyspace, workflow, uuid string, resp *vtctldatapb.VDiff
 						}
 					}
 
+					ts.Chunks += row.AsInt64("chunks", 0)
+					ts.ChunksCompleted += row.AsInt64("chunks_completed", 0)
+
 					diffReport := row.AsString("report", "")
 					dr := vdiff.DiffReport{}
 					if diffReport != "" {
@@ -869,11 +984,17 @@ func registerCommands(root *cobra.Command) {
 	create.Flags().BoolVar(&createOptions.Wait, "wait", false, "When creating or resuming a vdiff, wait for it to finish before exiting.")
 	create.Flags().DurationVar(&createOptions.WaitUpdateInterval, "wait-update-interval", time.Duration(1*time.Minute), "When waiting on a vdiff to finish, check and display the current status this often.")
 	create.Flags().BoolVar(&createOptions.AutoRetry, "auto-retry", true, "Should this vdiff automatically retry and continue in case of recoverable errors.")
+	create.Flags().Uint32Var(&createOptions.ChunkRows, "chunk-rows", 0, "Split the tables into chunks of about this many rows, which are diffed in parallel and resumed individually. 0 diffs each table in one pass.")
+	create.Flags().Uint32Var(&createOptions.MaxParallelChunks, "max-parallel-chunks", 1, "The max number of chunks of a table that are diffed in parallel on each target shard, when --chunk-rows is set.")
+	create.Flags().BoolVar(&createOptions.RecordMismatches, "record-mismatches", false, "Record the primary keys of all the mismatched and extra rows in the _vt.vdiff_mismatch table on the target tablets.")
+	create.Flags().StringVar(&createOptions.MismatchesDir, "mismatches-dir", "", "Export the primary keys of all the mismatched and extra rows as JSON lines to files in this directory on the target tablets, one file per shard and table.")
 	create.Flags().BoolVar(&createOptions.UpdateTableStats, "update-table-stats", false, "Update the table statistics, using ANALYZE TABLE, on each table involved in the VDiff during initialization. This will ensure that progress estimates are as accurate as possible -- but it does involve locks and can potentially impact query processing on the target keyspace.")
 	base.AddCommand(create)
 
 	base.AddCommand(delete)
 
+	base.AddCommand(repair)
+
 	base.AddCommand(resume)
 
 	show.Flags().BoolVar(&showOptions.Verbose, "verbose", false, "Show verbose output in summaries")
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"strings"
 	"testing"
 	"time"
 
@@ -528,3 +529,29 @@ func TestBuildProgressReport(t *testing.T) {
 		})
 	}
 }
+
+func TestDisplayRepairResponse(t *testing.T) {
+	repairFields := sqltypes.MakeTestFields("table_name|mismatches|repaired|verified", "varbinary|int64|int64|int64")
+	resp := &vtctldatapb.VDiffRepairResponse{
+		TabletResponses: map[string]*tabletmanagerdatapb.VDiffResponse{
+			"80-": {Output: sqltypes.ResultToProto3(sqltypes.MakeTestResult(repairFields, "customer|3|3|2"))},
+			"-80": {Output: sqltypes.ResultToProto3(sqltypes.MakeTestResult(repairFields, "orders|1|1|1", "customer|4|4|4"))},
+		},
+	}
+
+	summaries := buildRepairSummaries(resp)
+	require.Equal(t, []*repairSummary{
+		{Shard: "-80", Table: "customer", Mismatches: 4, Repaired: 4, Verified: 4},
+		{Shard: "-80", Table: "orders", Mismatches: 1, Repaired: 1, Verified: 1},
+		{Shard: "80-", Table: "customer", Mismatches: 3, Repaired: 3, Verified: 2},
+	}, summaries)
+
+	var out strings.Builder
+	require.NoError(t, displayRepairResponse(&out, "text", resp))
+	want := `Shard  Table     Mismatches  Repaired  Verified
+-80    customer  4           4         4
+-80    orders    1           1         1
+80-    customer  3           3         2
+`
+	require.Equal(t, want, out.String())
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vindexswap
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"vitess.io/vitess/go/cmd/vtctldclient/cli"
+	"vitess.io/vitess/go/cmd/vtctldclient/command/vreplication/common"
+	"vitess.io/vitess/go/protoutil"
+
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+	topoprotopb "vitess.io/vitess/go/vt/topo/topoproto"
+)
+
+var (
+	baseOptions = struct {
+		// This is where the table and the VReplication workflow live.
+		Keyspace string
+		// This is the name of the VReplication workflow.
+		Workflow string
+	}{}
+
+	// base is the base command for all actions related to vindex swaps.
+	base = &cobra.Command{
+		Use:                   "VindexSwap --workflow <workflow> --keyspace <keyspace> [command] [command-flags]",
+		Short:                 "Perform commands related to changing the primary vindex of a table in place using VReplication workflows.",
+		Long:                  "Perform commands related to changing the primary vindex of a table in place using VReplication workflows. The rows are re-keyed into a shadow table, which must be verified with VDiff before the table is swapped with it.",
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"vindexswap"},
+		Args:                  cobra.NoArgs,
+	}
+
+	createOptions = struct {
+		Table                        string
+		Vindex                       string
+		Columns                      []string
+		Type                         string
+		Params                       map[string]string
+		Cells                        []string
+		TabletTypes                  []topodatapb.TabletType
+		TabletTypesInPreferenceOrder bool
+	}{}
+
+	completeOptions = struct {
+		Timeout  time.Duration
+		KeepData bool
+	}{}
+
+	// cancel makes a WorkflowDelete call to a vtctld.
+	cancel = &cobra.Command{
+		Use:                   "cancel",
+		Short:                 "Cancel the VReplication workflow that re-keys the table, and drop its shadow table.",
+		Example:               `vtctldclient --server localhost:15999 VindexSwap --workflow customer_vswap --keyspace customer cancel`,
+		SilenceUsage:          true,
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Cancel"},
+		Args:                  cobra.NoArgs,
+		RunE:                  commandCancel,
+	}
+
+	// complete makes a VindexSwapComplete call to a vtctld.
+	complete = &cobra.Command{
+		Use:                   "complete",
+		Short:                 "Swap the table with its re-keyed shadow table once a VDiff found no mismatch, update its primary vindex and delete the VReplication workflow.",
+		Example:               `vtctldclient --server localhost:15999 VindexSwap --workflow customer_vswap --keyspace customer complete`,
+		SilenceUsage:          true,
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Complete"},
+		Args:                  cobra.NoArgs,
+		RunE:                  commandComplete,
+	}
+
+	// create makes a VindexSwapCreate call to a vtctld.
+	create = &cobra.Command{
+		Use:                   "create",
+		Short:                 "Create a VReplication workflow that re-keys the table into a shadow table using the new primary vindex.",
+		Example:               `vtctldclient --server localhost:15999 VindexSwap --workflow customer_vswap --keyspace customer create --table customer --vindex xxhash --columns customer_id --type xxhash`,
+		SilenceUsage:          true,
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Create"},
+		Args:                  cobra.NoArgs,
+		RunE:                  commandCreate,
+	}
+
+	// show makes a GetWorkflows call to a vtctld.
+	show = &cobra.Command{
+		Use:                   "show",
+		Short:                 "Show the status of the VReplication workflow that re-keys the table.",
+		Example:               `vtctldclient --server localhost:15999 VindexSwap --workflow customer_vswap --keyspace customer show`,
+		SilenceUsage:          true,
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"Show"},
+		Args:                  cobra.NoArgs,
+		RunE:                  commandShow,
+	}
+)
+
+func commandCancel(cmd *cobra.Command, args []string) error {
+	cli.FinishedParsing(cmd)
+
+	req := &vtctldatapb.WorkflowDeleteRequest{
+		Keyspace: baseOptions.Keyspace,
+		Workflow: baseOptions.Workflow,
+	}
+	_, err := common.GetClient().WorkflowDelete(common.GetCommandCtx(), req)
+	if err != nil {
+		return err
+	}
+
+	output := fmt.Sprintf("The %s VReplication workflow and its shadow table have been deleted", baseOptions.Workflow)
+	fmt.Println(output)
+
+	return nil
+}
+
+func commandComplete(cmd *cobra.Command, args []string) error {
+	cli.FinishedParsing(cmd)
+
+	resp, err := common.GetClient().VindexSwapComplete(common.GetCommandCtx(), &vtctldatapb.VindexSwapCompleteRequest{
+		Keyspace: baseOptions.Keyspace,
+		Workflow: baseOptions.Workflow,
+		Timeout:  protoutil.DurationToProto(completeOptions.Timeout),
+		KeepData: completeOptions.KeepData,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(resp.Summary)
+
+	return nil
+}
+
+func commandCreate(cmd *cobra.Command, args []string) error {
+	tsp := tabletmanagerdatapb.TabletSelectionPreference_ANY
+	if createOptions.TabletTypesInPreferenceOrder {
+		tsp = tabletmanagerdatapb.TabletSelectionPreference_INORDER
+	}
+	for i, cell := range createOptions.Cells {
+		createOptions.Cells[i] = strings.TrimSpace(cell)
+	}
+	req := &vtctldatapb.VindexSwapCreateRequest{
+		Keyspace: baseOptions.Keyspace,
+		Workflow: baseOptions.Workflow,
+		Table:    createOptions.Table,
+		ColumnVindex: &vschemapb.ColumnVindex{
+			Name:    createOptions.Vindex,
+			Columns: createOptions.Columns,
+		},
+		Cells:                     createOptions.Cells,
+		TabletTypes:               createOptions.TabletTypes,
+		TabletSelectionPreference: tsp,
+	}
+	if createOptions.Type != "" {
+		req.Vindex = &vschemapb.Vindex{
+			Type:   createOptions.Type,
+			Params: createOptions.Params,
+		}
+	}
+	cli.FinishedParsing(cmd)
+
+	_, err := common.GetClient().VindexSwapCreate(common.GetCommandCtx(), req)
+	if err != nil {
+		return err
+	}
+
+	output := fmt.Sprintf("The %s VReplication workflow re-keying table %s with the %s vindex has been scheduled on the %s shards, use show to view progress",
+		baseOptions.Workflow, createOptions.Table, createOptions.Vindex, baseOptions.Keyspace)
+	fmt.Println(output)
+
+	return nil
+}
+
+func commandShow(cmd *cobra.Command, args []string) error {
+	cli.FinishedParsing(cmd)
+
+	req := &vtctldatapb.GetWorkflowsRequest{
+		Keyspace: baseOptions.Keyspace,
+		Workflow: baseOptions.Workflow,
+	}
+	resp, err := common.GetClient().GetWorkflows(common.GetCommandCtx(), req)
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSONPretty(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+
+	return nil
+}
+
+func registerCommands(root *cobra.Command) {
+	base.PersistentFlags().StringVar(&baseOptions.Workflow, "workflow", "", "The name of the VReplication workflow that re-keys the table.")
+	base.MarkPersistentFlagRequired("workflow")
+	base.PersistentFlags().StringVar(&baseOptions.Keyspace, "keyspace", "", "The keyspace of the table. This is also where the VReplication workflow is created.")
+	base.MarkPersistentFlagRequired("keyspace")
+	root.AddCommand(base)
+
+	create.Flags().StringVar(&createOptions.Table, "table", "", "The table whose primary vindex is changed.")
+	create.MarkFlagRequired("table")
+	create.Flags().StringVar(&createOptions.Vindex, "vindex", "", "The name of the new primary vindex of the table.")
+	create.MarkFlagRequired("vindex")
+	create.Flags().StringSliceVar(&createOptions.Columns, "columns", nil, "The columns of the table the new primary vindex is computed from.")
+	create.MarkFlagRequired("columns")
+	create.Flags().StringVar(&createOptions.Type, "type", "", "The type of the vindex, when it is not yet defined in the keyspace.")
+	create.Flags().StringToStringVar(&createOptions.Params, "params", nil, "The parameters of the vindex, when it is not yet defined in the keyspace.")
+	// VReplication specific flags.
+	create.Flags().StringSliceVar(&createOptions.Cells, "cells", nil, "Cells to look in for source tablets to replicate from.")
+	create.Flags().Var((*topoprotopb.TabletTypeListFlag)(&createOptions.TabletTypes), "tablet-types", "Source tablet types to replicate from.")
+	create.Flags().BoolVar(&createOptions.TabletTypesInPreferenceOrder, "tablet-types-in-preference-order", true, "When performing source tablet selection, look for candidates in the type order as they are listed in the tablet-types flag.")
+	base.AddCommand(create)
+
+	// This will show the output of GetWorkflows client call
+	// for the VReplication workflow used.
+	base.AddCommand(show)
+
+	complete.Flags().DurationVar(&completeOptions.Timeout, "timeout", 30*time.Second, "Specifies the maximum time to wait, in seconds, for the VReplication workflow to catch up once the writes to the table are stopped.")
+	complete.Flags().BoolVar(&completeOptions.KeepData, "keep-data", false, "Keep the table with the old layout, renamed with the _vswap_old suffix, instead of dropping it.")
+	base.AddCommand(complete)
+
+	// The cancel command deletes the VReplication workflow and
+	// the shadow table. It ends up making a WorkflowDelete
+	// VtctldServer call.
+	base.AddCommand(cancel)
+}
+
+func init() {
+	common.RegisterCommandHandler("VindexSwap", registerCommands)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"vitess.io/vitess/go/cmd/vtctldclient/cli"
+
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+var (
+	// VStreamSubscription is the parent command for the commands operating
+	// on durable VStream subscriptions.
+	VStreamSubscription = &cobra.Command{
+		Use:                   "VStreamSubscription <cmd>",
+		Short:                 "Perform commands on durable VStream subscriptions.",
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.MinimumNArgs(1),
+	}
+	// VStreamSubscriptionList makes a GetVStreamSubscriptions gRPC call to a vtctld.
+	VStreamSubscriptionList = &cobra.Command{
+		Use:                   "list [--name <name>] <keyspace>",
+		Short:                 "Lists the durable VStream subscriptions of a keyspace, with their acknowledged positions and lag in each shard.",
+		Example:               "VStreamSubscription list --name orders-sink commerce",
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		RunE:                  commandGetVStreamSubscriptions,
+	}
+	// VStreamSubscriptionReset makes a ResetVStreamSubscription gRPC call to a vtctld.
+	VStreamSubscriptionReset = &cobra.Command{
+		Use:                   "reset [--shards <shards>] <keyspace> <name>",
+		Short:                 "Forgets the positions acknowledged for a durable VStream subscription.",
+		Long:                  "Forgets the positions acknowledged for a durable VStream subscription. Its next VStream starts from the positions it requests in the shards that were reset.",
+		Example:               "VStreamSubscription reset --shards -80,80- commerce orders-sink",
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(2),
+		RunE:                  commandResetVStreamSubscription,
+	}
+)
+
+var vstreamSubscriptionListOptions = struct {
+	Name string
+}{}
+
+func commandGetVStreamSubscriptions(cmd *cobra.Command, args []string) error {
+	cli.FinishedParsing(cmd)
+
+	resp, err := client.GetVStreamSubscriptions(commandCtx, &vtctldatapb.GetVStreamSubscriptionsRequest{
+		Keyspace: cmd.Flags().Arg(0),
+		Name:     vstreamSubscriptionListOptions.Name,
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSON(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+	return nil
+}
+
+var vstreamSubscriptionResetOptions = struct {
+	Shards []string
+}{}
+
+func commandResetVStreamSubscription(cmd *cobra.Command, args []string) error {
+	keyspace := cmd.Flags().Arg(0)
+	name := cmd.Flags().Arg(1)
+	cli.FinishedParsing(cmd)
+
+	_, err := client.ResetVStreamSubscription(commandCtx, &vtctldatapb.ResetVStreamSubscriptionRequest{
+		Keyspace: keyspace,
+		Name:     name,
+		Shards:   vstreamSubscriptionResetOptions.Shards,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Successfully reset VStream subscription %s in keyspace %s\n", name, keyspace)
+	return nil
+}
+
+func init() {
+	VStreamSubscriptionList.Flags().StringVar(&vstreamSubscriptionListOptions.Name, "name", "", "Only list the subscription with this name.")
+	VStreamSubscription.AddCommand(VStreamSubscriptionList)
+	VStreamSubscriptionReset.Flags().StringSliceVar(&vstreamSubscriptionResetOptions.Shards, "shards", nil, "Only reset the positions of these shards. All the shards of the keyspace are reset by default.")
+	VStreamSubscription.AddCommand(VStreamSubscriptionReset)
+
+	Root.AddCommand(VStreamSubscription)
+}
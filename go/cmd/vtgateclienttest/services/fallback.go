@@ -68,6 +68,10 @@ func (c fallbackClient) VStream(ctx context.Context, tabletType topodatapb.Table
 	return c.fallback.VStream(ctx, tabletType, vgtid, filter, flags, send)
 }
 
+func (c fallbackClient) VStreamAck(ctx context.Context, subscription string, vgtid *binlogdatapb.VGtid) error {
+	return c.fallback.VStreamAck(ctx, subscription, vgtid)
+}
+
 func (c fallbackClient) HandlePanic(err *error) {
 	c.fallback.HandlePanic(err)
 }
@@ -174,7 +174,7 @@ func Connect(ctx context.Context, params *ConnParams) (*Conn, error) {
 // Ping implements mysql ping command.
 func (c *Conn) Ping() error {
 	// This is a new command, need to reset the sequence.
-	c.sequence = 0
+	c.resetSequence()
 	data, pos := c.startEphemeralPacketWithHeader(1)
 	data[pos] = ComPing
 
@@ -300,6 +300,21 @@ func (c *Conn) clientHandshake(params *ConnParams) error {
 		return sqlerror.NewSQLError(sqlerror.CRSSLConnectionError, sqlerror.SSUnknownSQLState, "server doesn't support ClientSessionTrack but client asked for it")
 	}
 
+	// Compression Capability. If the server doesn't support the
+	// algorithm we asked for, we silently use an uncompressed
+	// connection, like the MySQL client does.
+	compression, err := ParseCompressionAlgorithm(params.Compression)
+	if err != nil {
+		return sqlerror.NewSQLError(sqlerror.CRUnknownError, sqlerror.SSUnknownSQLState, "%v", err)
+	}
+	if flag := compressionCapability(compression); capabilities&flag != 0 {
+		c.Capabilities |= flag
+		c.zstdCompressionLevel = params.CompressionLevel
+		if c.zstdCompressionLevel == 0 {
+			c.zstdCompressionLevel = defaultZstdCompressionLevel
+		}
+	}
+
 	// Build and send our handshake response 41.
 	// Note this one will never have SSL flag on.
 	if err := c.writeHandshakeResponse41(capabilities, scrambledPassword, charset, params); err != nil {
@@ -311,6 +326,11 @@ func (c *Conn) clientHandshake(params *ConnParams) error {
 		return err
 	}
 
+	// Everything after the final OK packet is compressed.
+	if err := c.enableCompression(c.negotiatedCompression(), c.zstdCompressionLevel); err != nil {
+		return sqlerror.NewSQLError(sqlerror.CRUnknownError, sqlerror.SSUnknownSQLState, "cannot enable compression: %v", err)
+	}
+
 	// If the server didn't support DbName in its handshake, set
 	// it now. This is what the 'mysql' client does.
 	if capabilities&CapabilityClientConnectWithDB == 0 && params.DbName != "" {
@@ -542,7 +562,9 @@ func (c *Conn) writeHandshakeResponse41(capabilities uint32, scrambledPassword [
 		CapabilityClientFoundRows&uint32(params.Flags) |
 		// If the server supported
 		// CapabilityClientSessionTrack, we also support it.
-		c.Capabilities&CapabilityClientSessionTrack
+		c.Capabilities&CapabilityClientSessionTrack |
+		// The compression algorithm we negotiated, if any.
+		c.Capabilities&(CapabilityClientCompress|CapabilityClientZstdCompressionAlgorithm)
 
 	// FIXME(alainjobart) add multi statement.
 
@@ -569,6 +591,11 @@ func (c *Conn) writeHandshakeResponse41(capabilities uint32, scrambledPassword [
 		length++
 	}
 
+	// The zstd compression level.
+	if capabilityFlags&CapabilityClientZstdCompressionAlgorithm != 0 {
+		length++
+	}
+
 	data, pos := c.startEphemeralPacketWithHeader(length)
 
 	// Client capability flags.
@@ -605,6 +632,11 @@ func (c *Conn) writeHandshakeResponse41(capabilities uint32, scrambledPassword [
 	// Assume native client during response
 	pos = writeNullString(data, pos, string(c.authPluginName))
 
+	// zstd compression level, only if we asked for zstd.
+	if capabilityFlags&CapabilityClientZstdCompressionAlgorithm != 0 {
+		pos = writeByte(data, pos, byte(c.zstdCompressionLevel))
+	}
+
 	// Sanity-check the length.
 	if pos != len(data) {
 		return sqlerror.NewSQLError(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "writeHandshakeResponse41: only packed %v bytes, out of %v allocated", pos, len(data))
@@ -0,0 +1,352 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+
+	"vitess.io/vitess/go/vt/vterrors"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// This file implements the compressed protocol, as documented in
+// https://dev.mysql.com/doc/dev/mysql-server/latest/page_protocol_basic_compression.html
+//
+// Once compression is negotiated, the regular packets (including their
+// 4 byte headers) are treated as a stream of bytes which is cut into
+// compressed packets. Each compressed packet has a 7 byte header:
+// - 3 bytes: length of the (compressed) payload.
+// - 1 byte: compressed sequence id.
+// - 3 bytes: length of the payload before compression, or 0 if the
+//   payload is sent uncompressed.
+
+const (
+	// compressedPacketHeaderSize is the size of the header of a
+	// compressed packet.
+	compressedPacketHeaderSize = 7
+
+	// minCompressLength is the payload size under which we don't bother
+	// compressing. This is the same value as MIN_COMPRESS_LENGTH in MySQL.
+	minCompressLength = 50
+
+	// defaultZstdCompressionLevel is the zstd compression level used when
+	// none was requested. This matches the MySQL default.
+	defaultZstdCompressionLevel = 3
+)
+
+// CompressionAlgorithm is the algorithm used on a compressed connection.
+type CompressionAlgorithm string
+
+// Supported compression algorithms.
+const (
+	// CompressionNone disables compression.
+	CompressionNone = CompressionAlgorithm("")
+
+	// CompressionZlib is negotiated with CLIENT_COMPRESS.
+	CompressionZlib = CompressionAlgorithm("zlib")
+
+	// CompressionZstd is negotiated with CLIENT_ZSTD_COMPRESSION_ALGORITHM.
+	CompressionZstd = CompressionAlgorithm("zstd")
+)
+
+// ParseCompressionAlgorithm parses a compression algorithm name. The empty
+// string and "uncompressed" both disable compression.
+func ParseCompressionAlgorithm(name string) (CompressionAlgorithm, error) {
+	switch name {
+	case "", "uncompressed":
+		return CompressionNone, nil
+	case string(CompressionZlib):
+		return CompressionZlib, nil
+	case string(CompressionZstd):
+		return CompressionZstd, nil
+	}
+	return CompressionNone, fmt.Errorf("unknown compression algorithm %q, must be one of zlib, zstd or uncompressed", name)
+}
+
+// compressionCapability returns the capability flag used to negotiate the
+// given compression algorithm.
+func compressionCapability(algorithm CompressionAlgorithm) uint32 {
+	switch algorithm {
+	case CompressionZlib:
+		return CapabilityClientCompress
+	case CompressionZstd:
+		return CapabilityClientZstdCompressionAlgorithm
+	}
+	return 0
+}
+
+// negotiatedCompression returns the compression algorithm agreed upon
+// during the handshake.
+func (c *Conn) negotiatedCompression() CompressionAlgorithm {
+	switch {
+	case c.Capabilities&CapabilityClientZstdCompressionAlgorithm != 0:
+		return CompressionZstd
+	case c.Capabilities&CapabilityClientCompress != 0:
+		return CompressionZlib
+	}
+	return CompressionNone
+}
+
+// enableCompression switches the connection to the compressed protocol.
+// It must be called right after the handshake completed, and before any
+// other packet is exchanged.
+func (c *Conn) enableCompression(algorithm CompressionAlgorithm, zstdLevel int) error {
+	if algorithm == CompressionNone {
+		return nil
+	}
+	if zstdLevel == 0 {
+		zstdLevel = defaultZstdCompressionLevel
+	}
+
+	var src io.Reader = c.conn
+	if c.bufferedReader != nil {
+		src = c.bufferedReader
+	}
+
+	r := &compressedReader{c: c, src: src, algorithm: algorithm}
+	w := &compressedWriter{c: c, algorithm: algorithm}
+	if algorithm == CompressionZstd {
+		var err error
+		if r.zstdDecoder, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1)); err != nil {
+			return err
+		}
+		if w.zstdEncoder, err = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(zstdLevel))); err != nil {
+			return err
+		}
+	}
+
+	c.bufMu.Lock()
+	defer c.bufMu.Unlock()
+	c.compression = algorithm
+	c.compressedReader = r
+	c.compressedWriter = w
+	c.compressedSequence = 0
+	return nil
+}
+
+// Compression returns the compression algorithm used by this connection.
+func (c *Conn) Compression() CompressionAlgorithm {
+	return c.compression
+}
+
+// compressedReader reads compressed packets from the underlying reader,
+// and returns their uncompressed content as a stream.
+type compressedReader struct {
+	c         *Conn
+	src       io.Reader
+	algorithm CompressionAlgorithm
+
+	header [compressedPacketHeaderSize]byte
+	// payload holds the content of the current compressed packet,
+	// and pos is the position of the next byte to return.
+	payload []byte
+	pos     int
+	// compressed is a scratch buffer for reading compressed payloads.
+	compressed []byte
+
+	zlibReader  io.ReadCloser
+	zstdDecoder *zstd.Decoder
+}
+
+// Read is part of the io.Reader interface.
+func (r *compressedReader) Read(p []byte) (int, error) {
+	for r.pos == len(r.payload) {
+		if err := r.readCompressedPacket(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.payload[r.pos:])
+	r.pos += n
+	return n, nil
+}
+
+func (r *compressedReader) readCompressedPacket() error {
+	if _, err := io.ReadFull(r.src, r.header[:]); err != nil {
+		// io.EOF is propagated as is, for the same reasons as in
+		// readHeaderFrom.
+		return err
+	}
+
+	length := int(uint32(r.header[0]) | uint32(r.header[1])<<8 | uint32(r.header[2])<<16)
+	sequence := r.header[3]
+	uncompressedLength := int(uint32(r.header[4]) | uint32(r.header[5])<<8 | uint32(r.header[6])<<16)
+
+	if sequence != r.c.compressedSequence {
+		return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "invalid compressed sequence, expected %v got %v", r.c.compressedSequence, sequence)
+	}
+	r.c.compressedSequence++
+
+	r.pos = 0
+	if uncompressedLength == 0 {
+		// The payload was not compressed.
+		r.payload = growBuffer(r.payload, length)
+		if _, err := io.ReadFull(r.src, r.payload); err != nil {
+			return vterrors.Wrapf(err, "io.ReadFull(compressed packet body of length %v) failed", length)
+		}
+		return nil
+	}
+
+	r.compressed = growBuffer(r.compressed, length)
+	if _, err := io.ReadFull(r.src, r.compressed); err != nil {
+		return vterrors.Wrapf(err, "io.ReadFull(compressed packet body of length %v) failed", length)
+	}
+
+	var err error
+	switch r.algorithm {
+	case CompressionZlib:
+		err = r.inflate(uncompressedLength)
+	case CompressionZstd:
+		r.payload, err = r.zstdDecoder.DecodeAll(r.compressed, r.payload[:0])
+	}
+	if err != nil {
+		return vterrors.Wrapf(err, "cannot decompress %v packet", r.algorithm)
+	}
+	if len(r.payload) != uncompressedLength {
+		return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "decompressed packet has length %v, expected %v", len(r.payload), uncompressedLength)
+	}
+	return nil
+}
+
+func (r *compressedReader) inflate(uncompressedLength int) error {
+	src := bytes.NewReader(r.compressed)
+	if r.zlibReader == nil {
+		zr, err := zlib.NewReader(src)
+		if err != nil {
+			return err
+		}
+		r.zlibReader = zr
+	} else if err := r.zlibReader.(zlib.Resetter).Reset(src, nil); err != nil {
+		return err
+	}
+
+	r.payload = growBuffer(r.payload, uncompressedLength)
+	if _, err := io.ReadFull(r.zlibReader, r.payload); err != nil {
+		return err
+	}
+	// Make sure we consumed the whole stream, so the checksum is verified.
+	var extra [1]byte
+	if n, err := r.zlibReader.Read(extra[:]); n != 0 || err != io.EOF {
+		return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "compressed payload is larger than %v bytes", uncompressedLength)
+	}
+	return nil
+}
+
+// compressedWriter wraps everything written to it into compressed packets,
+// and writes them to the underlying connection. It doesn't buffer anything:
+// a bufio.Writer is put in front of it when the connection uses
+// buffered writes.
+type compressedWriter struct {
+	c         *Conn
+	algorithm CompressionAlgorithm
+
+	// buf is a scratch buffer holding the compressed packet.
+	buf         bytes.Buffer
+	zlibWriter  *zlib.Writer
+	zstdEncoder *zstd.Encoder
+}
+
+// Write is part of the io.Writer interface.
+func (w *compressedWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > MaxPacketSize {
+			chunk = chunk[:MaxPacketSize]
+		}
+		if err := w.writeCompressedPacket(chunk); err != nil {
+			return written, err
+		}
+		written += len(chunk)
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
+
+func (w *compressedWriter) writeCompressedPacket(data []byte) error {
+	w.buf.Reset()
+	w.buf.Write(make([]byte, compressedPacketHeaderSize))
+
+	uncompressedLength := 0
+	if len(data) >= minCompressLength {
+		if err := w.compress(data); err != nil {
+			return vterrors.Wrapf(err, "cannot compress %v packet", w.algorithm)
+		}
+		uncompressedLength = len(data)
+		// If compression didn't help, send the payload as is.
+		if w.buf.Len()-compressedPacketHeaderSize >= len(data) {
+			w.buf.Truncate(compressedPacketHeaderSize)
+			uncompressedLength = 0
+		}
+	}
+	if uncompressedLength == 0 {
+		w.buf.Write(data)
+	}
+
+	packet := w.buf.Bytes()
+	length := len(packet) - compressedPacketHeaderSize
+	packet[0] = byte(length)
+	packet[1] = byte(length >> 8)
+	packet[2] = byte(length >> 16)
+	packet[3] = w.c.compressedSequence
+	packet[4] = byte(uncompressedLength)
+	packet[5] = byte(uncompressedLength >> 8)
+	packet[6] = byte(uncompressedLength >> 16)
+
+	if n, err := w.c.conn.Write(packet); err != nil {
+		return vterrors.Wrapf(err, "Write(compressed packet) failed")
+	} else if n != len(packet) {
+		return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "Write(compressed packet) returned a short write: %v < %v", n, len(packet))
+	}
+	w.c.compressedSequence++
+	return nil
+}
+
+// compress appends the compressed data to buf.
+func (w *compressedWriter) compress(data []byte) error {
+	switch w.algorithm {
+	case CompressionZlib:
+		if w.zlibWriter == nil {
+			w.zlibWriter = zlib.NewWriter(&w.buf)
+		} else {
+			w.zlibWriter.Reset(&w.buf)
+		}
+		if _, err := w.zlibWriter.Write(data); err != nil {
+			return err
+		}
+		return w.zlibWriter.Close()
+	case CompressionZstd:
+		out := w.zstdEncoder.EncodeAll(data, w.buf.AvailableBuffer())
+		w.buf.Write(out)
+		return nil
+	}
+	return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "unsupported compression algorithm %q", w.algorithm)
+}
+
+// growBuffer returns a slice of the given length, reusing buf if it is
+// large enough.
+func growBuffer(buf []byte, length int) []byte {
+	if cap(buf) >= length {
+		return buf[:length]
+	}
+	return make([]byte, length)
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+func TestParseCompressionAlgorithm(t *testing.T) {
+	for name, want := range map[string]CompressionAlgorithm{
+		"":             CompressionNone,
+		"uncompressed": CompressionNone,
+		"zlib":         CompressionZlib,
+		"zstd":         CompressionZstd,
+	} {
+		got, err := ParseCompressionAlgorithm(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, name)
+	}
+
+	_, err := ParseCompressionAlgorithm("lz4")
+	require.ErrorContains(t, err, `unknown compression algorithm "lz4"`)
+}
+
+func TestCompressedPackets(t *testing.T) {
+	random := make([]byte, 100000)
+	_, err := rand.Read(random)
+	require.NoError(t, err)
+
+	packets := [][]byte{
+		// Too small to be compressed.
+		[]byte("small"),
+		// Compressible.
+		bytes.Repeat([]byte("compress me "), 10000),
+		// Not compressible, sent as is.
+		random,
+		// Larger than a compressed packet.
+		bytes.Repeat([]byte("x"), MaxPacketSize+100),
+	}
+
+	for _, algorithm := range []CompressionAlgorithm{CompressionZlib, CompressionZstd} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			listener, sConn, cConn := createSocketPair(t)
+			defer func() {
+				listener.Close()
+				sConn.Close()
+				cConn.Close()
+			}()
+
+			require.NoError(t, sConn.enableCompression(algorithm, 0))
+			require.NoError(t, cConn.enableCompression(algorithm, 0))
+
+			for _, packet := range packets {
+				sConn.resetSequence()
+				cConn.resetSequence()
+
+				done := make(chan error)
+				go func() {
+					data := make([]byte, len(packet)+packetHeaderSize)
+					copy(data[packetHeaderSize:], packet)
+					done <- cConn.writePacket(data)
+				}()
+
+				got, err := sConn.ReadPacket()
+				require.NoError(t, err)
+				require.NoError(t, <-done)
+				assert.True(t, bytes.Equal(packet, got), "packet of length %v doesn't match", len(packet))
+			}
+		})
+	}
+}
+
+func TestCompressedServer(t *testing.T) {
+	big := strings.Repeat("vitess ", MaxPacketSize/7+10)
+	th := &testHandler{
+		result: &sqltypes.Result{
+			Fields: []*querypb.Field{{
+				Name:    "value",
+				Type:    querypb.Type_VARCHAR,
+				Charset: uint32(collations.Default()),
+			}},
+			Rows: [][]sqltypes.Value{
+				{sqltypes.MakeTrusted(querypb.Type_VARCHAR, []byte("small"))},
+				{sqltypes.MakeTrusted(querypb.Type_VARCHAR, []byte(big))},
+			},
+		},
+	}
+
+	authServer := NewAuthServerStatic("", "", 0)
+	authServer.entries["user1"] = []*AuthServerStaticEntry{{
+		Password: "password1",
+		UserData: "userData1",
+	}}
+	defer authServer.close()
+
+	for _, tcase := range []struct {
+		name              string
+		enableCompression bool
+		compression       string
+		want              CompressionAlgorithm
+	}{
+		{name: "zlib", enableCompression: true, compression: "zlib", want: CompressionZlib},
+		{name: "zstd", enableCompression: true, compression: "zstd", want: CompressionZstd},
+		{name: "not requested", enableCompression: true, compression: "", want: CompressionNone},
+		{name: "not supported by server", enableCompression: false, compression: "zstd", want: CompressionNone},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			l, err := NewListener("tcp", "127.0.0.1:", authServer, th, 0, 0, false, false, 0)
+			require.NoError(t, err)
+			l.EnableCompression = tcase.enableCompression
+			defer l.Close()
+			go l.Accept()
+
+			host, port := getHostPort(t, l.Addr())
+			params := &ConnParams{
+				Host:        host,
+				Port:        port,
+				Uname:       "user1",
+				Pass:        "password1",
+				Compression: tcase.compression,
+			}
+
+			c, err := Connect(context.Background(), params)
+			require.NoError(t, err)
+			defer c.Close()
+			assert.Equal(t, tcase.want, c.Compression())
+
+			// Run a few queries, to make sure the sequences are reset
+			// between commands.
+			for i := 0; i < 3; i++ {
+				result, err := c.ExecuteFetch("select big", 10, false)
+				require.NoError(t, err)
+				require.Len(t, result.Rows, 2)
+				assert.Equal(t, "small", result.Rows[0][0].ToString())
+				assert.True(t, result.Rows[1][0].ToString() == big, "big value doesn't match")
+			}
+			require.NoError(t, c.Ping())
+			assert.Equal(t, tcase.want, th.LastConn().Compression())
+		})
+	}
+}
@@ -156,10 +156,21 @@ type Conn struct {
 	// the client and the server, and currently in use.
 	// It is set during the initial handshake.
 	//
-	// It is only used for CapabilityClientDeprecateEOF
-	// and CapabilityClientFoundRows.
+	// It is only used for CapabilityClientDeprecateEOF,
+	// CapabilityClientFoundRows and the compression flags.
 	Capabilities uint32
 
+	// compression is the compression algorithm negotiated during the
+	// handshake. When it is set, compressedReader and compressedWriter
+	// wrap the underlying connection.
+	compression      CompressionAlgorithm
+	compressedReader *compressedReader
+	compressedWriter *compressedWriter
+
+	// zstdCompressionLevel is the zstd compression level negotiated
+	// during the handshake.
+	zstdCompressionLevel int
+
 	// closed is set to true when Close() is called on the connection.
 	closed atomic.Bool
 
@@ -190,6 +201,9 @@ type Conn struct {
 
 	// Packet encoding variables.
 	sequence uint8
+	// compressedSequence is the sequence of the compressed packets.
+	// It is only used when compression is enabled.
+	compressedSequence uint8
 
 	// ExpectSemiSyncIndicator is applicable when the connection is used for replication (ComBinlogDump).
 	// When 'true', events are assumed to be padded with 2-byte semi-sync information
@@ -314,7 +328,11 @@ func (c *Conn) startWriterBuffering() {
 	defer c.bufMu.Unlock()
 
 	c.bufferedWriter = writersPool.Get().(*bufio.Writer)
-	c.bufferedWriter.Reset(c.conn)
+	if c.compressedWriter != nil {
+		c.bufferedWriter.Reset(c.compressedWriter)
+	} else {
+		c.bufferedWriter.Reset(c.conn)
+	}
 }
 
 // endWriterBuffering must be called to terminate startWriteBuffering.
@@ -358,6 +376,9 @@ func (c *Conn) getWriter() (w io.Writer, unget func()) {
 		}
 	}
 	c.bufMu.Unlock()
+	if c.compressedWriter != nil {
+		return c.compressedWriter, func() {}
+	}
 	return c.conn, func() {}
 }
 
@@ -387,12 +408,22 @@ func (c *Conn) stopFlushTimer() {
 // getReader returns reader for connection. It can be *bufio.Reader or net.Conn
 // depending on which buffer size was passed to newServerConn.
 func (c *Conn) getReader() io.Reader {
+	if c.compressedReader != nil {
+		return c.compressedReader
+	}
 	if c.bufferedReader != nil {
 		return c.bufferedReader
 	}
 	return c.conn
 }
 
+// resetSequence resets the packet sequences. It is called when a new
+// command starts.
+func (c *Conn) resetSequence() {
+	c.sequence = 0
+	c.compressedSequence = 0
+}
+
 func (c *Conn) readHeaderFrom(r io.Reader) (int, error) {
 	// Note io.ReadFull will return two different types of errors:
 	// 1. if the socket is already closed, and the go runtime knows it,
@@ -414,7 +445,13 @@ func (c *Conn) readHeaderFrom(r io.Reader) (int, error) {
 	}
 
 	sequence := uint8(c.header[3])
-	if sequence != c.sequence {
+	if c.compressedReader != nil {
+		// Like MySQL, we don't check the sequence of the packets
+		// inside compressed packets: the compressed packets have their
+		// own sequence, and peers don't agree on how to number the
+		// packets they carry.
+		c.sequence = sequence
+	} else if sequence != c.sequence {
 		return 0, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "invalid sequence, expected %v got %v", c.sequence, sequence)
 	}
 
@@ -718,7 +755,7 @@ func (c *Conn) recycleWritePacket() {
 // Returns SQLError(CRServerGone) if it can't.
 func (c *Conn) writeComQuit() error {
 	// This is a new command, need to reset the sequence.
-	c.sequence = 0
+	c.resetSequence()
 
 	data, pos := c.startEphemeralPacketWithHeader(1)
 	data[pos] = ComQuit
@@ -924,7 +961,7 @@ func (c *Conn) writeEOFPacket(flags uint16, warnings uint16) error {
 // handleNextCommand is called in the server loop to process
 // incoming packets.
 func (c *Conn) handleNextCommand(handler Handler) bool {
-	c.sequence = 0
+	c.resetSequence()
 	data, err := c.readEphemeralPacket()
 	if err != nil {
 		// Don't log EOF errors. They cause too much spam.
@@ -57,6 +57,15 @@ type ConnParams struct {
 	// for informative purposes. It has no programmatic value. Returning this field is
 	// disabled by default.
 	EnableQueryInfo bool
+
+	// Compression is the compression algorithm to use with the server,
+	// either zlib or zstd. If the server doesn't support it, the
+	// connection is not compressed. Compression is disabled if empty.
+	Compression string `json:"compression,omitempty"`
+
+	// CompressionLevel is the zstd compression level. If not set,
+	// the MySQL default of 3 is used.
+	CompressionLevel int `json:"compression_level,omitempty"`
 }
 
 // EnableSSL will set the right flag on the parameters.
@@ -76,8 +76,11 @@ const (
 	// CLIENT_NO_SCHEMA 1 << 4
 	// Do not permit database.table.column. We do permit it.
 
-	// CLIENT_COMPRESS 1 << 5
-	// We do not support compression. CPU is usually our bottleneck.
+	// CapabilityClientCompress is CLIENT_COMPRESS.
+	// Use the zlib compressed protocol after the handshake.
+	// Only negotiated when compression is enabled, as CPU
+	// is usually our bottleneck.
+	CapabilityClientCompress = 1 << 5
 
 	// CLIENT_ODBC 1 << 6
 	// No special behavior since 3.22.
@@ -148,6 +151,15 @@ const (
 	// CapabilityClientDeprecateEOF is CLIENT_DEPRECATE_EOF
 	// Expects an OK (instead of EOF) after the resultset rows of a Text Resultset.
 	CapabilityClientDeprecateEOF = 1 << 24
+
+	// CLIENT_OPTIONAL_RESULTSET_METADATA 1 << 25
+	// The client can handle optional metadata information in the resultset.
+	// Not yet supported.
+
+	// CapabilityClientZstdCompressionAlgorithm is CLIENT_ZSTD_COMPRESSION_ALGORITHM.
+	// Use the zstd compressed protocol after the handshake. The client
+	// sends the compression level at the end of its handshake response.
+	CapabilityClientZstdCompressionAlgorithm = 1 << 26
 )
 
 // Status flags. They are returned by the server in a few cases.
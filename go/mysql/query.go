@@ -42,7 +42,7 @@ import (
 // Returns SQLError(CRServerGone) if it can't.
 func (c *Conn) WriteComQuery(query string) error {
 	// This is a new command, need to reset the sequence.
-	c.sequence = 0
+	c.resetSequence()
 
 	data, pos := c.startEphemeralPacketWithHeader(len(query) + 1)
 	data[pos] = ComQuery
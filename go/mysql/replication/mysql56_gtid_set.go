@@ -203,6 +203,17 @@ func (set Mysql56GTIDSet) Last() string {
 	return buf.String()
 }
 
+// Count returns the number of gtids in the set.
+func (set Mysql56GTIDSet) Count() int64 {
+	var count int64
+	for _, intervals := range set {
+		for _, iv := range intervals {
+			count += iv.end - iv.start + 1
+		}
+	}
+	return count
+}
+
 // Flavor implements GTIDSet.
 func (Mysql56GTIDSet) Flavor() string { return Mysql56FlavorID }
 
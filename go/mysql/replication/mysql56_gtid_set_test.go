@@ -585,6 +585,24 @@ func TestMySQL56GTIDSetLast(t *testing.T) {
 	}
 }
 
+func TestMysql56GTIDSetCount(t *testing.T) {
+	sid1 := SID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	sid2 := SID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 255}
+
+	table := []struct {
+		set  Mysql56GTIDSet
+		want int64
+	}{
+		{set: Mysql56GTIDSet{}, want: 0},
+		{set: Mysql56GTIDSet{sid1: []interval{{12, 12}}}, want: 1},
+		{set: Mysql56GTIDSet{sid1: []interval{{1, 5}, {10, 20}}}, want: 16},
+		{set: Mysql56GTIDSet{sid1: []interval{{1, 5}, {10, 20}}, sid2: []interval{{1, 5}, {50, 50}}}, want: 22},
+	}
+	for _, tcase := range table {
+		assert.Equal(t, tcase.want, tcase.set.Count(), "%s", tcase.set)
+	}
+}
+
 func TestSubtract(t *testing.T) {
 	tests := []struct {
 		name       string
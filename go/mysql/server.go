@@ -206,6 +206,11 @@ type Listener struct {
 	// RequireSecureTransport configures the server to reject connections from insecure clients
 	RequireSecureTransport bool
 
+	// EnableCompression configures the server to advertise the compressed
+	// protocol, with both zlib and zstd. Clients that ask for it get a
+	// compressed connection once authenticated.
+	EnableCompression bool
+
 	// PreHandleFunc is called for each incoming connection, immediately after
 	// accepting a new connection. By default it's no-op. Useful for custom
 	// connection inspection or TLS termination. The returned connection is
@@ -375,7 +380,7 @@ func (l *Listener) handle(conn net.Conn, connectionID uint32, acceptTime time.Ti
 	defer connCount.Add(-1)
 
 	// First build and send the server handshake packet.
-	serverAuthPluginData, err := c.writeHandshakeV10(l.ServerVersion, l.authServer, l.TLSConfig.Load() != nil)
+	serverAuthPluginData, err := c.writeHandshakeV10(l.ServerVersion, l.authServer, l.TLSConfig.Load() != nil, l.EnableCompression)
 	if err != nil {
 		if err != io.EOF {
 			log.Errorf("Cannot send HandshakeV10 packet to %s: %v", c, err)
@@ -518,6 +523,13 @@ func (l *Listener) handle(conn net.Conn, connectionID uint32, acceptTime time.Ti
 		return
 	}
 
+	// The OK packet is the last uncompressed packet, switch to the
+	// compressed protocol if the client asked for it.
+	if err := c.enableCompression(c.negotiatedCompression(), c.zstdCompressionLevel); err != nil {
+		log.Errorf("Cannot enable compression for %s: %v", c, err)
+		return
+	}
+
 	// Record how long we took to establish the connection
 	timings.Record(connectTimingKey, acceptTime)
 
@@ -556,7 +568,7 @@ func (l *Listener) Shutdown() {
 
 // writeHandshakeV10 writes the Initial Handshake Packet, server side.
 // It returns the salt data.
-func (c *Conn) writeHandshakeV10(serverVersion string, authServer AuthServer, enableTLS bool) ([]byte, error) {
+func (c *Conn) writeHandshakeV10(serverVersion string, authServer AuthServer, enableTLS bool, enableCompression bool) ([]byte, error) {
 	capabilities := CapabilityClientLongPassword |
 		CapabilityClientFoundRows |
 		CapabilityClientLongFlag |
@@ -573,6 +585,9 @@ func (c *Conn) writeHandshakeV10(serverVersion string, authServer AuthServer, en
 	if enableTLS {
 		capabilities |= CapabilityClientSSL
 	}
+	if enableCompression {
+		capabilities |= CapabilityClientCompress | CapabilityClientZstdCompressionAlgorithm
+	}
 
 	// Grab the default auth method. This can only be either
 	// mysql_native_password or caching_sha2_password. Both
@@ -790,11 +805,31 @@ func (l *Listener) parseClientHandshakePacket(c *Conn, firstTime bool, data []by
 
 	// Decode connection attributes send by the client
 	if clientFlags&CapabilityClientConnAttr != 0 {
-		if _, _, err := parseConnAttrs(data, pos); err != nil {
+		var err error
+		if _, pos, err = parseConnAttrs(data, pos); err != nil {
 			log.Warningf("Decode connection attributes send by the client: %v", err)
 		}
 	}
 
+	// Compression, only if we advertised it. zstd wins if the client
+	// asks for both.
+	if l.EnableCompression {
+		switch {
+		case clientFlags&CapabilityClientZstdCompressionAlgorithm != 0:
+			c.Capabilities |= CapabilityClientZstdCompressionAlgorithm
+			// The compression level is the last byte of the packet.
+			// If we couldn't parse the connection attributes, we
+			// don't know where it is and use the default level.
+			if pos > 0 {
+				if level, _, ok := readByte(data, pos); ok {
+					c.zstdCompressionLevel = int(level)
+				}
+			}
+		case clientFlags&CapabilityClientCompress != 0:
+			c.Capabilities |= CapabilityClientCompress
+		}
+	}
+
 	return username, AuthMethodDescription(authMethod), authResponse, nil
 }
 
@@ -33,14 +33,20 @@ func getSidecarDBTables(t *testing.T, tabletID string) (numTablets int, tables [
 }
 
 var sidecarDBTables []string
-var numSidecarDBTables int
+
+// sidecarDBTablesWithoutLog are the sidecar tables left after ddls1 drops vreplication_log.
+var sidecarDBTablesWithoutLog []string
 var ddls1, ddls2 []string
 
 func init() {
 	sidecarDBTables = []string{"copy_state", "dt_participant", "dt_state", "heartbeat", "post_copy_action", "redo_state",
 		"redo_statement", "reparent_journal", "resharding_journal", "schema_migrations", "schema_version", "schemacopy", "tables",
-		"vdiff", "vdiff_log", "vdiff_table", "views", "vreplication", "vreplication_log"}
-	numSidecarDBTables = len(sidecarDBTables)
+		"vdiff", "vdiff_chunk", "vdiff_log", "vdiff_mismatch", "vdiff_table", "views", "vreplication", "vreplication_log", "vstream_subscriptions"}
+	for _, table := range sidecarDBTables {
+		if table != "vreplication_log" {
+			sidecarDBTablesWithoutLog = append(sidecarDBTablesWithoutLog, table)
+		}
+	}
 	ddls1 = []string{
 		"drop table _vt.vreplication_log",
 		"alter table _vt.vreplication drop column defer_secondary_keys",
@@ -93,8 +99,8 @@ func TestSidecarDB(t *testing.T) {
 
 	t.Run("modify schema, prs, and self heal on primary", func(t *testing.T) {
 		numChanges := modifySidecarDBSchema(t, vc, currentPrimary, ddls1)
-		validateSidecarDBTables(t, tablet100, sidecarDBTables[0:numSidecarDBTables-1])
-		validateSidecarDBTables(t, tablet101, sidecarDBTables[0:numSidecarDBTables-1])
+		validateSidecarDBTables(t, tablet100, sidecarDBTablesWithoutLog)
+		validateSidecarDBTables(t, tablet101, sidecarDBTablesWithoutLog)
 
 		prs(t, keyspace, shard)
 		currentPrimary = tablet101
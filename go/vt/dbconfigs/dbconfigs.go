@@ -89,6 +89,7 @@ type DBConfigs struct {
 	ConnectTimeoutMilliseconds int           `json:"connectTimeoutMilliseconds,omitempty"`
 	DBName                     string        `json:"dbName,omitempty"`
 	EnableQueryInfo            bool          `json:"enableQueryInfo,omitempty"`
+	Compression                string        `json:"compression,omitempty"`
 
 	App          UserConfig `json:"app,omitempty"`
 	Dba          UserConfig `json:"dba,omitempty"`
@@ -145,6 +146,7 @@ func registerBaseFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&GlobalDBConfigs.ServerName, "db_server_name", "", "server name of the DB we are connecting to.")
 	fs.IntVar(&GlobalDBConfigs.ConnectTimeoutMilliseconds, "db_connect_timeout_ms", 0, "connection timeout to mysqld in milliseconds (0 for no timeout)")
 	fs.BoolVar(&GlobalDBConfigs.EnableQueryInfo, "db_conn_query_info", false, "enable parsing and processing of QUERY_OK info fields")
+	fs.StringVar(&GlobalDBConfigs.Compression, "db_compression", "", "Compression algorithm to use for the connections to mysqld, if it supports it. One of zlib, zstd or uncompressed.")
 }
 
 // The flags will change the global singleton
@@ -350,6 +352,7 @@ func (dbcfgs *DBConfigs) InitWithSocket(defaultSocketFile string) {
 		}
 		cp.ConnectTimeoutMs = uint64(dbcfgs.ConnectTimeoutMilliseconds)
 		cp.EnableQueryInfo = dbcfgs.EnableQueryInfo
+		cp.Compression = dbcfgs.Compression
 
 		cp.Uname = uc.User
 		cp.Pass = uc.Password
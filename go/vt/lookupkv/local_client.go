@@ -0,0 +1,54 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lookupkv
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	lookupkvpb "vitess.io/vitess/go/vt/proto/lookupkv"
+)
+
+// localClient is a LookupKVClient that calls the server of a store in
+// the same process, without going through the network.
+type localClient struct {
+	server lookupkvpb.LookupKVServer
+}
+
+// NewLocalClient returns a LookupKVClient for a store in the same
+// process. It behaves like a gRPC client of the store, and is meant to
+// stand in for one in tests.
+func NewLocalClient(store *Store) lookupkvpb.LookupKVClient {
+	return &localClient{server: NewServer(store)}
+}
+
+// Lookup is part of the LookupKVClient interface.
+func (c *localClient) Lookup(ctx context.Context, req *lookupkvpb.LookupRequest, opts ...grpc.CallOption) (*lookupkvpb.LookupResponse, error) {
+	resp, err := c.server.Lookup(ctx, req.CloneVT())
+	return resp.CloneVT(), err
+}
+
+// Create is part of the LookupKVClient interface.
+func (c *localClient) Create(ctx context.Context, req *lookupkvpb.CreateRequest, opts ...grpc.CallOption) (*lookupkvpb.CreateResponse, error) {
+	return c.server.Create(ctx, req.CloneVT())
+}
+
+// Delete is part of the LookupKVClient interface.
+func (c *localClient) Delete(ctx context.Context, req *lookupkvpb.DeleteRequest, opts ...grpc.CallOption) (*lookupkvpb.DeleteResponse, error) {
+	return c.server.Delete(ctx, req.CloneVT())
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lookupkv
+
+import (
+	"context"
+
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/servenv"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	lookupkvpb "vitess.io/vitess/go/vt/proto/lookupkv"
+)
+
+// storePath is the path of the database file of the store served by the
+// lookupkv gRPC service.
+var storePath string
+
+func registerFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&storePath, "lookupkv-store-path", storePath, "Path of the database file of the lookupkv store, which keeps the mappings of the lookup vindexes with the kv backend. It is served when lookupkv is in --service_map.")
+}
+
+func init() {
+	servenv.OnParseFor("vttablet", registerFlags)
+
+	servenv.OnRun(func() {
+		if !servenv.GRPCCheckServiceMap("lookupkv") {
+			return
+		}
+		if storePath == "" {
+			log.Exitf("--lookupkv-store-path is required to serve the lookupkv service")
+		}
+		store, err := OpenStore(storePath)
+		if err != nil {
+			log.Exitf("Failed to open the lookupkv store: %v", err)
+		}
+		StartServer(servenv.GRPCServer, store)
+		servenv.OnClose(func() {
+			if err := store.Close(); err != nil {
+				log.Errorf("Failed to close the lookupkv store: %v", err)
+			}
+		})
+	})
+}
+
+// server is the gRPC server of a Store.
+type server struct {
+	lookupkvpb.UnimplementedLookupKVServer
+	store *Store
+}
+
+// NewServer returns a LookupKVServer that serves the store.
+func NewServer(store *Store) lookupkvpb.LookupKVServer {
+	return &server{store: store}
+}
+
+// StartServer registers the gRPC server of the store with s.
+func StartServer(s *grpc.Server, store *Store) {
+	lookupkvpb.RegisterLookupKVServer(s, NewServer(store))
+}
+
+// Lookup is part of the LookupKVServer interface.
+func (s *server) Lookup(ctx context.Context, req *lookupkvpb.LookupRequest) (*lookupkvpb.LookupResponse, error) {
+	resp := &lookupkvpb.LookupResponse{}
+	for _, values := range s.store.Lookup(req.Table, req.Ids) {
+		resp.Results = append(resp.Results, &lookupkvpb.LookupResponse_Result{Values: values})
+	}
+	return resp, nil
+}
+
+// Create is part of the LookupKVServer interface.
+func (s *server) Create(ctx context.Context, req *lookupkvpb.CreateRequest) (*lookupkvpb.CreateResponse, error) {
+	if err := s.store.Create(req); err != nil {
+		return nil, vterrors.ToGRPC(err)
+	}
+	return &lookupkvpb.CreateResponse{}, nil
+}
+
+// Delete is part of the LookupKVServer interface.
+func (s *server) Delete(ctx context.Context, req *lookupkvpb.DeleteRequest) (*lookupkvpb.DeleteResponse, error) {
+	if err := s.store.Delete(req.Table, req.Mappings); err != nil {
+		return nil, vterrors.ToGRPC(err)
+	}
+	return &lookupkvpb.DeleteResponse{}, nil
+}
@@ -0,0 +1,332 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package lookupkv contains an embedded key-value store for the mappings of
+lookup vindexes, the gRPC server that serves it, and an in-process client
+to use it without going through the network.
+
+Lookup vindexes use it with the "kv" lookup backend, see
+vindexes.RegisterLookupKVClient.
+*/
+package lookupkv
+
+import (
+	"database/sql"
+	"sync"
+
+	// sqlite is the database the store persists the mappings to.
+	_ "modernc.org/sqlite"
+
+	"vitess.io/vitess/go/vt/vterrors"
+
+	lookupkvpb "vitess.io/vitess/go/vt/proto/lookupkv"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// Store is a store of lookup vindex mappings. The mappings are served
+// from memory, where they are indexed by the value of their first "from"
+// column, which is the one lookups are made with. Values are compared by
+// their bytes, regardless of their type.
+//
+// A store opened with OpenStore persists the mappings in a database file
+// before changing them in memory, and loads them back when it is opened
+// again. A store created with NewStore only keeps them in memory.
+//
+// It is safe for concurrent use.
+type Store struct {
+	mu     sync.RWMutex
+	tables map[string]map[string][]*entry
+	db     *sql.DB
+}
+
+type entry struct {
+	mapping *lookupkvpb.Mapping
+	from    []string
+	to      *querypb.Value
+}
+
+const (
+	sqlCreateMappings = `create table if not exists mappings (
+  tbl text not null,
+  id blob not null,
+  seq integer not null,
+  mapping blob not null,
+  primary key (tbl, id, seq)
+)`
+	sqlSelectMappings = "select tbl, mapping from mappings order by tbl, id, seq"
+	sqlDeleteMappings = "delete from mappings where tbl = ? and id = ?"
+	sqlInsertMapping  = "insert into mappings (tbl, id, seq, mapping) values (?, ?, ?, ?)"
+)
+
+// NewStore returns an empty Store, which keeps the mappings in memory only.
+func NewStore() *Store {
+	return &Store{
+		tables: make(map[string]map[string][]*entry),
+	}
+}
+
+// OpenStore opens the Store persisted in the database file at path,
+// which is created if it does not exist yet.
+func OpenStore(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "failed to open lookupkv store %s", path)
+	}
+	// All the writes are serialized by the store anyway.
+	db.SetMaxOpenConns(1)
+	st := NewStore()
+	st.db = db
+	if err := st.load(); err != nil {
+		db.Close()
+		return nil, vterrors.Wrapf(err, "failed to load lookupkv store %s", path)
+	}
+	return st, nil
+}
+
+// Close closes the database of the store, if any.
+func (st *Store) Close() error {
+	if st.db == nil {
+		return nil
+	}
+	return st.db.Close()
+}
+
+// load reads the mappings persisted in the database into memory.
+func (st *Store) load() error {
+	if _, err := st.db.Exec(sqlCreateMappings); err != nil {
+		return err
+	}
+	rows, err := st.db.Query(sqlSelectMappings)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			table string
+			data  []byte
+		)
+		if err := rows.Scan(&table, &data); err != nil {
+			return err
+		}
+		m := &lookupkvpb.Mapping{}
+		if err := m.UnmarshalVT(data); err != nil {
+			return err
+		}
+		entries := st.tables[table]
+		if entries == nil {
+			entries = make(map[string][]*entry)
+			st.tables[table] = entries
+		}
+		e := newEntry(m)
+		entries[e.from[0]] = append(entries[e.from[0]], e)
+	}
+	return rows.Err()
+}
+
+// persist replaces the mappings of the ids of the table in the database
+// with the given entries, in a single transaction. It is a no-op if the
+// store keeps the mappings in memory only.
+func (st *Store) persist(table string, changed map[string][]*entry) error {
+	if st.db == nil {
+		return nil
+	}
+	tx, err := st.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for id, entries := range changed {
+		if _, err := tx.Exec(sqlDeleteMappings, table, []byte(id)); err != nil {
+			return err
+		}
+		for seq, e := range entries {
+			data, err := e.mapping.MarshalVT()
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(sqlInsertMapping, table, []byte(id), seq, data); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// Lookup returns the values mapped to each of the ids in the table.
+func (st *Store) Lookup(table string, ids []*querypb.Value) [][]*querypb.Value {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	entries := st.tables[table]
+	results := make([][]*querypb.Value, len(ids))
+	for i, id := range ids {
+		for _, e := range entries[string(id.Value)] {
+			results[i] = append(results[i], e.to)
+		}
+	}
+	return results
+}
+
+// Create adds the mappings of the request. If one of them conflicts with
+// an existing mapping and the request does not ignore or replace the
+// conflicting mappings, none of them is added.
+func (st *Store) Create(req *lookupkvpb.CreateRequest) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	entries := st.tables[req.Table]
+	// The mappings are added to copies of the entries of the ids, which
+	// replace the current ones once all of them were added.
+	pending := make(map[string][]*entry)
+	for _, m := range req.Mappings {
+		if len(m.From) == 0 || m.To == nil {
+			return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid mapping for table %s: %v", req.Table, m)
+		}
+		e := newEntry(m)
+		id := e.from[0]
+		current, ok := pending[id]
+		if !ok {
+			current = append([]*entry(nil), entries[id]...)
+		}
+
+		var conflicts []int
+		for i, existing := range current {
+			if !sameFrom(existing, e) {
+				continue
+			}
+			if req.Unique || string(existing.to.Value) == string(e.to.Value) {
+				conflicts = append(conflicts, i)
+			}
+		}
+		switch {
+		case len(conflicts) == 0:
+			current = append(current, e)
+		case req.Upsert:
+			for _, i := range conflicts {
+				current[i] = e
+			}
+		case req.IgnoreExisting:
+		default:
+			return vterrors.Errorf(vtrpcpb.Code_ALREADY_EXISTS, "duplicate entry %v for table %s", e.from, req.Table)
+		}
+		pending[id] = current
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+	for id, current := range pending {
+		pending[id] = dedupEntries(current)
+	}
+	if err := st.persist(req.Table, pending); err != nil {
+		return vterrors.Wrapf(err, "failed to persist the mappings of table %s", req.Table)
+	}
+	if entries == nil {
+		entries = make(map[string][]*entry)
+		st.tables[req.Table] = entries
+	}
+	for id, current := range pending {
+		entries[id] = current
+	}
+	return nil
+}
+
+// Delete removes the mappings. The ones that do not exist are ignored.
+func (st *Store) Delete(table string, mappings []*lookupkvpb.Mapping) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	entries := st.tables[table]
+	// As in Create, the entries of the ids are replaced once all the
+	// mappings were removed from copies of them.
+	pending := make(map[string][]*entry)
+	for _, m := range mappings {
+		if len(m.From) == 0 || m.To == nil {
+			continue
+		}
+		e := newEntry(m)
+		id := e.from[0]
+		current, ok := pending[id]
+		if !ok {
+			current = entries[id]
+		}
+		var kept []*entry
+		for _, existing := range current {
+			if sameFrom(existing, e) && string(existing.to.Value) == string(e.to.Value) {
+				continue
+			}
+			kept = append(kept, existing)
+		}
+		pending[id] = kept
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+	if err := st.persist(table, pending); err != nil {
+		return vterrors.Wrapf(err, "failed to persist the mappings of table %s", table)
+	}
+	for id, kept := range pending {
+		if len(kept) == 0 {
+			delete(entries, id)
+			continue
+		}
+		entries[id] = kept
+	}
+	return nil
+}
+
+func newEntry(m *lookupkvpb.Mapping) *entry {
+	e := &entry{
+		mapping: m.CloneVT(),
+		from:    make([]string, 0, len(m.From)),
+	}
+	e.to = e.mapping.To
+	for _, from := range m.From {
+		e.from = append(e.from, string(from.Value))
+	}
+	return e
+}
+
+func sameFrom(a, b *entry) bool {
+	if len(a.from) != len(b.from) {
+		return false
+	}
+	for i := range a.from {
+		if a.from[i] != b.from[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// dedupEntries removes the entries that were replaced more than once by
+// an upsert.
+func dedupEntries(entries []*entry) []*entry {
+	out := entries[:0]
+	seen := make(map[*entry]bool, len(entries))
+	for _, e := range entries {
+		if seen[e] {
+			continue
+		}
+		seen[e] = true
+		out = append(out, e)
+	}
+	return out
+}
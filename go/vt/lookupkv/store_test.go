@@ -0,0 +1,215 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lookupkv
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	lookupkvpb "vitess.io/vitess/go/vt/proto/lookupkv"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+func mapping(to string, from ...int64) *lookupkvpb.Mapping {
+	m := &lookupkvpb.Mapping{To: sqltypes.ValueToProto(sqltypes.NewVarBinary(to))}
+	for _, f := range from {
+		m.From = append(m.From, sqltypes.ValueToProto(sqltypes.NewInt64(f)))
+	}
+	return m
+}
+
+func lookup(st *Store, ids ...int64) [][]string {
+	var values []*querypb.Value
+	for _, id := range ids {
+		values = append(values, sqltypes.ValueToProto(sqltypes.NewInt64(id)))
+	}
+	var out [][]string
+	for _, vals := range st.Lookup("t", values) {
+		var strs []string
+		for _, val := range vals {
+			strs = append(strs, string(val.Value))
+		}
+		out = append(out, strs)
+	}
+	return out
+}
+
+func TestStore(t *testing.T) {
+	st := NewStore()
+
+	err := st.Create(&lookupkvpb.CreateRequest{
+		Table:    "t",
+		Mappings: []*lookupkvpb.Mapping{mapping("a", 1), mapping("b", 1), mapping("c", 2)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}, nil}, lookup(st, 1, 2, 3))
+
+	// The mappings of other tables are separate.
+	assert.Equal(t, [][]*querypb.Value{nil}, st.Lookup("t2", []*querypb.Value{sqltypes.ValueToProto(sqltypes.NewInt64(1))}))
+
+	// None of the mappings is created when one of them already exists.
+	err = st.Create(&lookupkvpb.CreateRequest{
+		Table:    "t",
+		Mappings: []*lookupkvpb.Mapping{mapping("d", 3), mapping("a", 1)},
+	})
+	assert.Equal(t, vtrpcpb.Code_ALREADY_EXISTS, vterrors.Code(err))
+	assert.EqualError(t, err, "duplicate entry [1] for table t")
+	assert.Equal(t, [][]string{{"a", "b"}, nil}, lookup(st, 1, 3))
+
+	err = st.Create(&lookupkvpb.CreateRequest{
+		Table:          "t",
+		Mappings:       []*lookupkvpb.Mapping{mapping("d", 3), mapping("a", 1)},
+		IgnoreExisting: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "b"}, {"d"}}, lookup(st, 1, 3))
+
+	err = st.Delete("t", []*lookupkvpb.Mapping{mapping("a", 1), mapping("c", 2), mapping("x", 4)})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"b"}, nil, {"d"}}, lookup(st, 1, 2, 3))
+}
+
+func TestOpenStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lookupkv.db")
+	st, err := OpenStore(path)
+	require.NoError(t, err)
+
+	err = st.Create(&lookupkvpb.CreateRequest{
+		Table:    "t",
+		Mappings: []*lookupkvpb.Mapping{mapping("a", 1), mapping("b", 1), mapping("c", 2, 3), mapping("d", 3)},
+	})
+	require.NoError(t, err)
+	err = st.Create(&lookupkvpb.CreateRequest{
+		Table:    "t2",
+		Mappings: []*lookupkvpb.Mapping{mapping("e", 1)},
+	})
+	require.NoError(t, err)
+	err = st.Delete("t", []*lookupkvpb.Mapping{mapping("a", 1), mapping("d", 3)})
+	require.NoError(t, err)
+	// A failed create is not persisted either.
+	err = st.Create(&lookupkvpb.CreateRequest{
+		Table:    "t",
+		Mappings: []*lookupkvpb.Mapping{mapping("f", 4), mapping("b", 1)},
+	})
+	require.Error(t, err)
+	require.NoError(t, st.Close())
+
+	// The mappings are loaded back when the store is opened again.
+	st, err = OpenStore(path)
+	require.NoError(t, err)
+	defer st.Close()
+	assert.Equal(t, [][]string{{"b"}, {"c"}, nil, nil}, lookup(st, 1, 2, 3, 4))
+	assert.Equal(t, "e", string(st.Lookup("t2", []*querypb.Value{sqltypes.ValueToProto(sqltypes.NewInt64(1))})[0][0].Value))
+
+	// The "from" values of the mappings are kept as well.
+	err = st.Delete("t", []*lookupkvpb.Mapping{mapping("c", 2, 3)})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{nil}, lookup(st, 2))
+}
+
+func TestStoreUnique(t *testing.T) {
+	st := NewStore()
+
+	err := st.Create(&lookupkvpb.CreateRequest{
+		Table:    "t",
+		Mappings: []*lookupkvpb.Mapping{mapping("a", 1)},
+		Unique:   true,
+	})
+	require.NoError(t, err)
+
+	err = st.Create(&lookupkvpb.CreateRequest{
+		Table:    "t",
+		Mappings: []*lookupkvpb.Mapping{mapping("b", 1)},
+		Unique:   true,
+	})
+	assert.EqualError(t, err, "duplicate entry [1] for table t")
+
+	err = st.Create(&lookupkvpb.CreateRequest{
+		Table:    "t",
+		Mappings: []*lookupkvpb.Mapping{mapping("b", 1), mapping("c", 1)},
+		Unique:   true,
+		Upsert:   true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"c"}}, lookup(st, 1))
+
+	// The mappings with several "from" values are unique across all of
+	// them.
+	err = st.Create(&lookupkvpb.CreateRequest{
+		Table:    "t",
+		Mappings: []*lookupkvpb.Mapping{mapping("d", 1, 2)},
+		Unique:   true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"c", "d"}}, lookup(st, 1))
+}
+
+func TestServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	StartServer(s, NewStore())
+	go s.Serve(listener)
+	defer s.Stop()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	for _, client := range []lookupkvpb.LookupKVClient{lookupkvpb.NewLookupKVClient(conn), NewLocalClient(NewStore())} {
+		ctx := context.Background()
+		_, err = client.Create(ctx, &lookupkvpb.CreateRequest{
+			Table:    "t",
+			Mappings: []*lookupkvpb.Mapping{mapping("a", 1)},
+		})
+		require.NoError(t, err)
+
+		_, err = client.Create(ctx, &lookupkvpb.CreateRequest{
+			Table:    "t",
+			Mappings: []*lookupkvpb.Mapping{mapping("a", 1)},
+		})
+		assert.Equal(t, vtrpcpb.Code_ALREADY_EXISTS, vterrors.Code(vterrors.FromGRPC(err)))
+
+		ids := []*querypb.Value{sqltypes.ValueToProto(sqltypes.NewInt64(1)), sqltypes.ValueToProto(sqltypes.NewInt64(2))}
+		resp, err := client.Lookup(ctx, &lookupkvpb.LookupRequest{Table: "t", Ids: ids})
+		require.NoError(t, err)
+		require.Len(t, resp.Results, 2)
+		require.Len(t, resp.Results[0].Values, 1)
+		assert.Equal(t, "a", string(resp.Results[0].Values[0].Value))
+		assert.Empty(t, resp.Results[1].Values)
+
+		_, err = client.Delete(ctx, &lookupkvpb.DeleteRequest{
+			Table:    "t",
+			Mappings: []*lookupkvpb.Mapping{mapping("a", 1)},
+		})
+		require.NoError(t, err)
+		resp, err = client.Lookup(ctx, &lookupkvpb.LookupRequest{Table: "t", Ids: ids})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Results[0].Values)
+	}
+}
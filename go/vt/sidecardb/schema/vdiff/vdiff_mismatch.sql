@@ -0,0 +1,28 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+CREATE TABLE IF NOT EXISTS vdiff_mismatch
+(
+    `id`         bigint(20)      NOT NULL AUTO_INCREMENT,
+    `vdiff_id`   varchar(64)     NOT NULL,
+    `table_name` varbinary(128)  NOT NULL,
+    `chunk_id`   int(11)         NOT NULL DEFAULT '0',
+    `type`       varbinary(64)   NOT NULL,
+    `pk`         varbinary(2000) NOT NULL,
+    `created_at` timestamp       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `vdiff_table_idx` (`vdiff_id`, `table_name`)
+) ENGINE = InnoDB
@@ -2865,8 +2865,9 @@ type (
 	}
 
 	Count struct {
-		Args     Exprs
-		Distinct bool
+		Args       Exprs
+		Distinct   bool
+		OverClause *OverClause
 	}
 
 	CountStar struct {
@@ -2897,26 +2898,32 @@ type (
 		// The solution we employed was to add a dummy field `_ bool` to the otherwise empty struct `CountStar`.
 		// This ensures that each instance of `CountStar` is treated as a separate object,
 		// even in the context of out semantic state which uses these objects as map keys.
+
+		OverClause *OverClause
 	}
 
 	Avg struct {
-		Arg      Expr
-		Distinct bool
+		Arg        Expr
+		Distinct   bool
+		OverClause *OverClause
 	}
 
 	Max struct {
-		Arg      Expr
-		Distinct bool
+		Arg        Expr
+		Distinct   bool
+		OverClause *OverClause
 	}
 
 	Min struct {
-		Arg      Expr
-		Distinct bool
+		Arg        Expr
+		Distinct   bool
+		OverClause *OverClause
 	}
 
 	Sum struct {
-		Arg      Expr
-		Distinct bool
+		Arg        Expr
+		Distinct   bool
+		OverClause *OverClause
 	}
 
 	BitAnd struct {
@@ -789,6 +789,7 @@ func CloneRefOfAvg(n *Avg) *Avg {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -1067,6 +1068,7 @@ func CloneRefOfCount(n *Count) *Count {
 	}
 	out := *n
 	out.Args = CloneExprs(n.Args)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -1076,6 +1078,7 @@ func CloneRefOfCountStar(n *CountStar) *CountStar {
 		return nil
 	}
 	out := *n
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -2107,6 +2110,7 @@ func CloneRefOfMax(n *Max) *Max {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -2128,6 +2132,7 @@ func CloneRefOfMin(n *Min) *Min {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
@@ -3001,6 +3006,7 @@ func CloneRefOfSum(n *Sum) *Sum {
 	}
 	out := *n
 	out.Arg = CloneExpr(n.Arg)
+	out.OverClause = CloneRefOfOverClause(n.OverClause)
 	return &out
 }
 
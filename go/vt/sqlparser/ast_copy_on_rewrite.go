@@ -1052,9 +1052,11 @@ func (c *cow) copyOnRewriteRefOfAvg(n *Avg, parent SQLNode) (out SQLNode, change
 	out = n
 	if c.pre == nil || c.pre(n, parent) {
 		_Arg, changedArg := c.copyOnRewriteExpr(n.Arg, n)
-		if changedArg {
+		_OverClause, changedOverClause := c.copyOnRewriteRefOfOverClause(n.OverClause, n)
+		if changedArg || changedOverClause {
 			res := *n
 			res.Arg, _ = _Arg.(Expr)
+			res.OverClause, _ = _OverClause.(*OverClause)
 			out = &res
 			if c.cloned != nil {
 				c.cloned(n, out)
@@ -1641,9 +1643,11 @@ func (c *cow) copyOnRewriteRefOfCount(n *Count, parent SQLNode) (out SQLNode, ch
 	out = n
 	if c.pre == nil || c.pre(n, parent) {
 		_Args, changedArgs := c.copyOnRewriteExprs(n.Args, n)
-		if changedArgs {
+		_OverClause, changedOverClause := c.copyOnRewriteRefOfOverClause(n.OverClause, n)
+		if changedArgs || changedOverClause {
 			res := *n
 			res.Args, _ = _Args.(Exprs)
+			res.OverClause, _ = _OverClause.(*OverClause)
 			out = &res
 			if c.cloned != nil {
 				c.cloned(n, out)
@@ -1662,6 +1666,16 @@ func (c *cow) copyOnRewriteRefOfCountStar(n *CountStar, parent SQLNode) (out SQL
 	}
 	out = n
 	if c.pre == nil || c.pre(n, parent) {
+		_OverClause, changedOverClause := c.copyOnRewriteRefOfOverClause(n.OverClause, n)
+		if changedOverClause {
+			res := *n
+			res.OverClause, _ = _OverClause.(*OverClause)
+			out = &res
+			if c.cloned != nil {
+				c.cloned(n, out)
+			}
+			changed = true
+		}
 	}
 	if c.post != nil {
 		out, changed = c.postVisit(out, parent, changed)
@@ -3864,9 +3878,11 @@ func (c *cow) copyOnRewriteRefOfMax(n *Max, parent SQLNode) (out SQLNode, change
 	out = n
 	if c.pre == nil || c.pre(n, parent) {
 		_Arg, changedArg := c.copyOnRewriteExpr(n.Arg, n)
-		if changedArg {
+		_OverClause, changedOverClause := c.copyOnRewriteRefOfOverClause(n.OverClause, n)
+		if changedArg || changedOverClause {
 			res := *n
 			res.Arg, _ = _Arg.(Expr)
+			res.OverClause, _ = _OverClause.(*OverClause)
 			out = &res
 			if c.cloned != nil {
 				c.cloned(n, out)
@@ -3910,9 +3926,11 @@ func (c *cow) copyOnRewriteRefOfMin(n *Min, parent SQLNode) (out SQLNode, change
 	out = n
 	if c.pre == nil || c.pre(n, parent) {
 		_Arg, changedArg := c.copyOnRewriteExpr(n.Arg, n)
-		if changedArg {
+		_OverClause, changedOverClause := c.copyOnRewriteRefOfOverClause(n.OverClause, n)
+		if changedArg || changedOverClause {
 			res := *n
 			res.Arg, _ = _Arg.(Expr)
+			res.OverClause, _ = _OverClause.(*OverClause)
 			out = &res
 			if c.cloned != nil {
 				c.cloned(n, out)
@@ -5708,9 +5726,11 @@ func (c *cow) copyOnRewriteRefOfSum(n *Sum, parent SQLNode) (out SQLNode, change
 	out = n
 	if c.pre == nil || c.pre(n, parent) {
 		_Arg, changedArg := c.copyOnRewriteExpr(n.Arg, n)
-		if changedArg {
+		_OverClause, changedOverClause := c.copyOnRewriteRefOfOverClause(n.OverClause, n)
+		if changedArg || changedOverClause {
 			res := *n
 			res.Arg, _ = _Arg.(Expr)
+			res.OverClause, _ = _OverClause.(*OverClause)
 			out = &res
 			if c.cloned != nil {
 				c.cloned(n, out)
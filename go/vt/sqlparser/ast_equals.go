@@ -1899,7 +1899,8 @@ func (cmp *Comparator) RefOfAvg(a, b *Avg) bool {
 		return false
 	}
 	return a.Distinct == b.Distinct &&
-		cmp.Expr(a.Arg, b.Arg)
+		cmp.Expr(a.Arg, b.Arg) &&
+		cmp.RefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // RefOfBegin does deep equals between the two objects.
@@ -2227,7 +2228,8 @@ func (cmp *Comparator) RefOfCount(a, b *Count) bool {
 		return false
 	}
 	return a.Distinct == b.Distinct &&
-		cmp.Exprs(a.Args, b.Args)
+		cmp.Exprs(a.Args, b.Args) &&
+		cmp.RefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // RefOfCountStar does deep equals between the two objects.
@@ -2238,7 +2240,7 @@ func (cmp *Comparator) RefOfCountStar(a, b *CountStar) bool {
 	if a == nil || b == nil {
 		return false
 	}
-	return true
+	return cmp.RefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // RefOfCreateDatabase does deep equals between the two objects.
@@ -3429,7 +3431,8 @@ func (cmp *Comparator) RefOfMax(a, b *Max) bool {
 		return false
 	}
 	return a.Distinct == b.Distinct &&
-		cmp.Expr(a.Arg, b.Arg)
+		cmp.Expr(a.Arg, b.Arg) &&
+		cmp.RefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // RefOfMemberOfExpr does deep equals between the two objects.
@@ -3453,7 +3456,8 @@ func (cmp *Comparator) RefOfMin(a, b *Min) bool {
 		return false
 	}
 	return a.Distinct == b.Distinct &&
-		cmp.Expr(a.Arg, b.Arg)
+		cmp.Expr(a.Arg, b.Arg) &&
+		cmp.RefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // RefOfModifyColumn does deep equals between the two objects.
@@ -4456,7 +4460,8 @@ func (cmp *Comparator) RefOfSum(a, b *Sum) bool {
 		return false
 	}
 	return a.Distinct == b.Distinct &&
-		cmp.Expr(a.Arg, b.Arg)
+		cmp.Expr(a.Arg, b.Arg) &&
+		cmp.RefOfOverClause(a.OverClause, b.OverClause)
 }
 
 // TableExprs does deep equals between the two objects.
@@ -2680,10 +2680,16 @@ func (node *Count) Format(buf *TrackedBuffer) {
 		buf.literal(DistinctStr)
 	}
 	buf.astPrintf(node, "%v)", node.Args)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *CountStar) Format(buf *TrackedBuffer) {
 	buf.WriteString("count(*)")
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *AnyValue) Format(buf *TrackedBuffer) {
@@ -2696,6 +2702,9 @@ func (node *Avg) Format(buf *TrackedBuffer) {
 		buf.literal(DistinctStr)
 	}
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *Max) Format(buf *TrackedBuffer) {
@@ -2704,6 +2713,9 @@ func (node *Max) Format(buf *TrackedBuffer) {
 		buf.literal(DistinctStr)
 	}
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *Min) Format(buf *TrackedBuffer) {
@@ -2712,6 +2724,9 @@ func (node *Min) Format(buf *TrackedBuffer) {
 		buf.literal(DistinctStr)
 	}
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *Sum) Format(buf *TrackedBuffer) {
@@ -2720,6 +2735,9 @@ func (node *Sum) Format(buf *TrackedBuffer) {
 		buf.literal(DistinctStr)
 	}
 	buf.astPrintf(node, "%v)", node.Arg)
+	if node.OverClause != nil {
+		buf.astPrintf(node, " %v", node.OverClause)
+	}
 }
 
 func (node *BitAnd) Format(buf *TrackedBuffer) {
@@ -3552,10 +3552,18 @@ func (node *Count) formatFast(buf *TrackedBuffer) {
 	}
 	node.Args.formatFast(buf)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *CountStar) formatFast(buf *TrackedBuffer) {
 	buf.WriteString("count(*)")
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *AnyValue) formatFast(buf *TrackedBuffer) {
@@ -3571,6 +3579,10 @@ func (node *Avg) formatFast(buf *TrackedBuffer) {
 	}
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *Max) formatFast(buf *TrackedBuffer) {
@@ -3580,6 +3592,10 @@ func (node *Max) formatFast(buf *TrackedBuffer) {
 	}
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *Min) formatFast(buf *TrackedBuffer) {
@@ -3589,6 +3605,10 @@ func (node *Min) formatFast(buf *TrackedBuffer) {
 	}
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *Sum) formatFast(buf *TrackedBuffer) {
@@ -3598,6 +3618,10 @@ func (node *Sum) formatFast(buf *TrackedBuffer) {
 	}
 	buf.printExpr(node, node.Arg, true)
 	buf.WriteByte(')')
+	if node.OverClause != nil {
+		buf.WriteByte(' ')
+		node.OverClause.formatFast(buf)
+	}
 }
 
 func (node *BitAnd) formatFast(buf *TrackedBuffer) {
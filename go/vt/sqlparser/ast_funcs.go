@@ -1899,6 +1899,8 @@ func (ty ShowCommandType) ToString() string {
 		return ProcedureCStr
 	case Procedure:
 		return ProcedureStr
+	case ProcessList:
+		return ProcessListStr
 	case StatusGlobal:
 		return StatusGlobalStr
 	case StatusSession:
@@ -2057,6 +2059,11 @@ func formatAddress(address string) string {
 func ContainsAggregation(e SQLNode) bool {
 	hasAggregates := false
 	_ = Walk(func(node SQLNode) (kontinue bool, err error) {
+		if IsWindowFunc(node) {
+			// an aggregation function with an OVER clause is a window function,
+			// but the arguments of it can still contain aggregations
+			return true, nil
+		}
 		switch node.(type) {
 		case *Offset:
 			// offsets here indicate that a possible aggregation has already been handled by an input
@@ -2072,6 +2079,58 @@ func ContainsAggregation(e SQLNode) bool {
 	return hasAggregates
 }
 
+// GetOverClause returns the OVER clause of a window function call. It returns nil
+// for all other nodes, including aggregation functions used without an OVER clause.
+func GetOverClause(node SQLNode) *OverClause {
+	switch node := node.(type) {
+	case *ArgumentLessWindowExpr:
+		return node.OverClause
+	case *FirstOrLastValueExpr:
+		return node.OverClause
+	case *NtileExpr:
+		return node.OverClause
+	case *NTHValueExpr:
+		return node.OverClause
+	case *LagLeadExpr:
+		return node.OverClause
+	case *Count:
+		return node.OverClause
+	case *CountStar:
+		return node.OverClause
+	case *Sum:
+		return node.OverClause
+	case *Min:
+		return node.OverClause
+	case *Max:
+		return node.OverClause
+	case *Avg:
+		return node.OverClause
+	}
+	return nil
+}
+
+// IsWindowFunc returns true if the node is a window function call
+func IsWindowFunc(node SQLNode) bool {
+	return GetOverClause(node) != nil
+}
+
+// ContainsWindowFunc returns true if the expression contains a window function call.
+// Subqueries are not inspected, since they are evaluated on their own.
+func ContainsWindowFunc(e SQLNode) bool {
+	hasWindowFunc := false
+	_ = Walk(func(node SQLNode) (kontinue bool, err error) {
+		if _, isSubq := node.(*Subquery); isSubq {
+			return false, nil
+		}
+		if IsWindowFunc(node) {
+			hasWindowFunc = true
+			return false, io.EOF
+		}
+		return true, nil
+	}, e)
+	return hasWindowFunc
+}
+
 // GetFirstSelect gets the first select statement
 func GetFirstSelect(selStmt SelectStatement) *Select {
 	if selStmt == nil {
@@ -172,3 +172,28 @@ func TestColumns_Indexes(t *testing.T) {
 		})
 	}
 }
+
+// TestWindowFunctions verifies how window functions are told apart from aggregations.
+func TestWindowFunctions(t *testing.T) {
+	tests := []struct {
+		expr        string
+		window      bool
+		aggregation bool
+	}{
+		{expr: "sum(a)", aggregation: true},
+		{expr: "sum(a) over ()", window: true},
+		{expr: "count(*) over (partition by b order by a)", window: true},
+		{expr: "row_number() over (partition by b)", window: true},
+		{expr: "lag(a, 2) over w", window: true},
+		{expr: "sum(count(*)) over ()", window: true, aggregation: true},
+		{expr: "a + (select max(c) over () from t)", aggregation: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			expr, err := ParseExpr(tc.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tc.window, ContainsWindowFunc(expr), "ContainsWindowFunc")
+			assert.Equal(t, tc.aggregation, ContainsAggregation(expr), "ContainsAggregation")
+		})
+	}
+}
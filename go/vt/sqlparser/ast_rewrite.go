@@ -1257,6 +1257,11 @@ func (a *application) rewriteRefOfAvg(parent SQLNode, node *Avg, replacer replac
 	}) {
 		return false
 	}
+	if !a.rewriteRefOfOverClause(node, node.OverClause, func(newNode, parent SQLNode) {
+		parent.(*Avg).OverClause = newNode.(*OverClause)
+	}) {
+		return false
+	}
 	if a.post != nil {
 		a.cur.replacer = replacer
 		a.cur.parent = parent
@@ -2129,6 +2134,11 @@ func (a *application) rewriteRefOfCount(parent SQLNode, node *Count, replacer re
 	}) {
 		return false
 	}
+	if !a.rewriteRefOfOverClause(node, node.OverClause, func(newNode, parent SQLNode) {
+		parent.(*Count).OverClause = newNode.(*OverClause)
+	}) {
+		return false
+	}
 	if a.post != nil {
 		a.cur.replacer = replacer
 		a.cur.parent = parent
@@ -2156,12 +2166,15 @@ func (a *application) rewriteRefOfCountStar(parent SQLNode, node *CountStar, rep
 			return true
 		}
 	}
+	if !a.rewriteRefOfOverClause(node, node.OverClause, func(newNode, parent SQLNode) {
+		parent.(*CountStar).OverClause = newNode.(*OverClause)
+	}) {
+		return false
+	}
 	if a.post != nil {
-		if a.pre == nil {
-			a.cur.replacer = replacer
-			a.cur.parent = parent
-			a.cur.node = node
-		}
+		a.cur.replacer = replacer
+		a.cur.parent = parent
+		a.cur.node = node
 		if !a.post(&a.cur) {
 			return false
 		}
@@ -5471,6 +5484,11 @@ func (a *application) rewriteRefOfMax(parent SQLNode, node *Max, replacer replac
 	}) {
 		return false
 	}
+	if !a.rewriteRefOfOverClause(node, node.OverClause, func(newNode, parent SQLNode) {
+		parent.(*Max).OverClause = newNode.(*OverClause)
+	}) {
+		return false
+	}
 	if a.post != nil {
 		a.cur.replacer = replacer
 		a.cur.parent = parent
@@ -5540,6 +5558,11 @@ func (a *application) rewriteRefOfMin(parent SQLNode, node *Min, replacer replac
 	}) {
 		return false
 	}
+	if !a.rewriteRefOfOverClause(node, node.OverClause, func(newNode, parent SQLNode) {
+		parent.(*Min).OverClause = newNode.(*OverClause)
+	}) {
+		return false
+	}
 	if a.post != nil {
 		a.cur.replacer = replacer
 		a.cur.parent = parent
@@ -8220,6 +8243,11 @@ func (a *application) rewriteRefOfSum(parent SQLNode, node *Sum, replacer replac
 	}) {
 		return false
 	}
+	if !a.rewriteRefOfOverClause(node, node.OverClause, func(newNode, parent SQLNode) {
+		parent.(*Sum).OverClause = newNode.(*OverClause)
+	}) {
+		return false
+	}
 	if a.post != nil {
 		a.cur.replacer = replacer
 		a.cur.parent = parent
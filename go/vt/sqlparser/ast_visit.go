@@ -872,6 +872,9 @@ func VisitRefOfAvg(in *Avg, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfBegin(in *Begin, f Visit) error {
@@ -1236,6 +1239,9 @@ func VisitRefOfCount(in *Count, f Visit) error {
 	if err := VisitExprs(in.Args, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfCountStar(in *CountStar, f Visit) error {
@@ -1245,6 +1251,9 @@ func VisitRefOfCountStar(in *CountStar, f Visit) error {
 	if cont, err := f(in); err != nil || !cont {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfCreateDatabase(in *CreateDatabase, f Visit) error {
@@ -2648,6 +2657,9 @@ func VisitRefOfMax(in *Max, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfMemberOfExpr(in *MemberOfExpr, f Visit) error {
@@ -2675,6 +2687,9 @@ func VisitRefOfMin(in *Min, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitRefOfModifyColumn(in *ModifyColumn, f Visit) error {
@@ -3823,6 +3838,9 @@ func VisitRefOfSum(in *Sum, f Visit) error {
 	if err := VisitExpr(in.Arg, f); err != nil {
 		return err
 	}
+	if err := VisitRefOfOverClause(in.OverClause, f); err != nil {
+		return err
+	}
 	return nil
 }
 func VisitTableExprs(in TableExprs, f Visit) error {
@@ -19,6 +19,7 @@ package sqlparser
 import (
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
@@ -56,6 +57,9 @@ const (
 	// DirectivePriority specifies the priority of a workload. It should be an integer between 0 and MaxPriorityValue,
 	// where 0 is the highest priority, and MaxPriorityValue is the lowest one.
 	DirectivePriority = "PRIORITY"
+	// DirectiveResultCacheTTL enables the vtgate result cache for a SELECT, with the given TTL in milliseconds.
+	// A value of zero disables the result cache for the query.
+	DirectiveResultCacheTTL = "RESULT_CACHE_TTL_MS"
 
 	// MaxPriorityValue specifies the maximum value allowed for the priority query directive. Valid priority values are
 	// between zero and MaxPriorityValue.
@@ -450,6 +454,32 @@ func Consolidator(stmt Statement) querypb.ExecuteOptions_Consolidator {
 	return querypb.ExecuteOptions_CONSOLIDATOR_UNSPECIFIED
 }
 
+// ResultCacheTTL returns the TTL requested by the result cache directive of
+// a SELECT, and whether the directive is set at all.
+func ResultCacheTTL(stmt Statement) (time.Duration, bool) {
+	var comments *ParsedComments
+	switch stmt := stmt.(type) {
+	case *Select:
+		comments = stmt.Comments
+	case *Union:
+		comments = stmt.GetParsedComments()
+	default:
+		return 0, false
+	}
+	if comments == nil {
+		return 0, false
+	}
+	val, isSet := comments.Directives().GetString(DirectiveResultCacheTTL, "")
+	if !isSet {
+		return 0, false
+	}
+	ms, err := strconv.ParseUint(val, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
 // GetWorkloadNameFromStatement gets the workload name from the provided Statement, using workloadLabel as the name of
 // the query directive that specifies it.
 func GetWorkloadNameFromStatement(statement Statement) string {
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -497,6 +498,33 @@ func TestConsolidator(t *testing.T) {
 	}
 }
 
+func TestResultCacheTTL(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected time.Duration
+		isSet    bool
+	}{
+		{"select * from users", 0, false},
+		{"select /*vt+ CONSOLIDATOR=enabled */ * from users", 0, false},
+		{"select /*vt+ RESULT_CACHE_TTL_MS=1500 */ * from users", 1500 * time.Millisecond, true},
+		{"select /*vt+ RESULT_CACHE_TTL_MS=0 */ * from users", 0, true},
+		{"select /*vt+ RESULT_CACHE_TTL_MS=-1 */ * from users", 0, false},
+		{"select /*vt+ RESULT_CACHE_TTL_MS=invalid */ * from users", 0, false},
+		{"select /*vt+ RESULT_CACHE_TTL_MS=100 */ * from users union select * from customers", 100 * time.Millisecond, true},
+		{"update /*vt+ RESULT_CACHE_TTL_MS=100 */ users set name=1", 0, false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.query, func(t *testing.T) {
+			stmt, err := Parse(test.query)
+			require.NoError(t, err)
+			ttl, isSet := ResultCacheTTL(stmt)
+			assert.Equal(t, test.expected, ttl)
+			assert.Equal(t, test.isSet, isSet)
+		})
+	}
+}
+
 func TestGetPriorityFromStatement(t *testing.T) {
 	testCases := []struct {
 		query            string
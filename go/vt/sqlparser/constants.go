@@ -293,6 +293,7 @@ const (
 	PrivilegeStr               = " privileges"
 	ProcedureCStr              = " procedure code"
 	ProcedureStr               = " procedure status"
+	ProcessListStr             = " processlist"
 	StatusGlobalStr            = " global status"
 	StatusSessionStr           = " status"
 	TablesStr                  = " tables"
@@ -866,6 +867,7 @@ const (
 	Privilege
 	ProcedureC
 	Procedure
+	ProcessList
 	StatusGlobal
 	StatusSession
 	Table
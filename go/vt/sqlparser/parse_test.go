@@ -2241,8 +2241,7 @@ var (
 		input:  "show processlist",
 		output: "show processlist",
 	}, {
-		input:  "show full processlist",
-		output: "show processlist",
+		input: "show full processlist",
 	}, {
 		input:  "show profile cpu for query 1",
 		output: "show profile",
@@ -3516,6 +3515,9 @@ var (
 	}, {
 		input:  "SELECT LAG(val, 10) OVER w, LEAD('val', null) OVER w, LEAD(val, 1, ASCII(1)) OVER w FROM numbers",
 		output: "select lag(val, 10) over w, lead('val', null) over w, lead(val, 1, ASCII(1)) over w from numbers",
+	}, {
+		input:  "SELECT val, SUM(val) OVER (PARTITION BY subject ORDER BY val), COUNT(*) OVER w, COUNT(val) OVER (), AVG(val) OVER w, MIN(val) OVER w, MAX(val) OVER w FROM numbers",
+		output: "select val, sum(val) over ( partition by subject order by val asc), count(*) over w, count(val) over (), avg(val) over w, min(val) over w, max(val) over w from numbers",
 	}, {
 		input:  "SELECT val, ROW_NUMBER() OVER (ORDER BY val) AS 'row_number' FROM numbers WINDOW w AS (ORDER BY val);",
 		output: "select val, row_number() over ( order by val asc) as `row_number` from numbers window w AS ( order by val asc)",
@@ -434,7 +434,7 @@ func markBindVariable(yylex yyLexer, bvar string) {
 %type <framePoint> frame_point
 %type <frameClause> frame_clause frame_clause_opt
 %type <windowSpecification> window_spec
-%type <overClause> over_clause
+%type <overClause> over_clause over_clause_opt
 %type <nullTreatmentType> null_treatment_type
 %type <nullTreatmentClause> null_treatment_clause null_treatment_clause_opt
 %type <fromFirstLastType> from_first_last_type
@@ -4185,7 +4185,7 @@ show_statement:
   }
 | SHOW full_opt PROCESSLIST from_database_opt like_or_where_opt
   {
-    $$ = &Show{&ShowOther{Command: string($3)}}
+    $$ = &Show{&ShowBasic{Command: ProcessList, Full: $2}}
   }
 | SHOW STORAGE ddl_skip_to_end
   {
@@ -5651,6 +5651,15 @@ over_clause:
     $$ = &OverClause{WindowName: $2}
   }
 
+over_clause_opt:
+  {
+    $$ = nil
+  }
+| over_clause
+  {
+    $$ = $1
+  }
+
 null_treatment_clause_opt:
   {
     $$ = nil
@@ -5929,29 +5938,29 @@ UTC_DATE func_paren_opt
   {
     $$ = &CurTimeFuncExpr{Name:NewIdentifierCI("current_time"), Fsp: $2}
   }
-| COUNT openb '*' closeb
+| COUNT openb '*' closeb over_clause_opt
   {
-    $$ = &CountStar{}
+    $$ = &CountStar{OverClause: $5}
   }
-| COUNT openb distinct_opt expression_list closeb
+| COUNT openb distinct_opt expression_list closeb over_clause_opt
   {
-    $$ = &Count{Distinct:$3, Args:$4}
+    $$ = &Count{Distinct:$3, Args:$4, OverClause: $6}
   }
-| MAX openb distinct_opt expression closeb
+| MAX openb distinct_opt expression closeb over_clause_opt
   {
-    $$ = &Max{Distinct:$3, Arg:$4}
+    $$ = &Max{Distinct:$3, Arg:$4, OverClause: $6}
   }
-| MIN openb distinct_opt expression closeb
+| MIN openb distinct_opt expression closeb over_clause_opt
   {
-    $$ = &Min{Distinct:$3, Arg:$4}
+    $$ = &Min{Distinct:$3, Arg:$4, OverClause: $6}
   }
-| SUM openb distinct_opt expression closeb
+| SUM openb distinct_opt expression closeb over_clause_opt
   {
-    $$ = &Sum{Distinct:$3, Arg:$4}
+    $$ = &Sum{Distinct:$3, Arg:$4, OverClause: $6}
   }
-| AVG openb distinct_opt expression closeb
+| AVG openb distinct_opt expression closeb over_clause_opt
   {
-    $$ = &Avg{Distinct:$3, Arg:$4}
+    $$ = &Avg{Distinct:$3, Arg:$4, OverClause: $6}
   }
 | BIT_AND openb expression closeb
   {
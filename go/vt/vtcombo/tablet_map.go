@@ -550,6 +550,12 @@ func (itc *internalTabletConn) ReadTransaction(ctx context.Context, target *quer
 	return metadata, tabletconn.ErrorFromGRPC(vterrors.ToGRPC(err))
 }
 
+// UnresolvedTransactions is part of queryservice.QueryService
+func (itc *internalTabletConn) UnresolvedTransactions(ctx context.Context, target *querypb.Target, abandonAgeSeconds int64) (transactions []*querypb.TransactionMetadata, err error) {
+	transactions, err = itc.tablet.qsc.QueryService().UnresolvedTransactions(ctx, target, abandonAgeSeconds)
+	return transactions, tabletconn.ErrorFromGRPC(vterrors.ToGRPC(err))
+}
+
 // BeginExecute is part of queryservice.QueryService
 func (itc *internalTabletConn) BeginExecute(
 	ctx context.Context,
@@ -665,6 +671,12 @@ func (itc *internalTabletConn) Release(ctx context.Context, target *querypb.Targ
 	return tabletconn.ErrorFromGRPC(vterrors.ToGRPC(err))
 }
 
+// KillQueries is part of the QueryService interface.
+func (itc *internalTabletConn) KillQueries(ctx context.Context, target *querypb.Target, sessionUUID string) (int64, error) {
+	count, err := itc.tablet.qsc.QueryService().KillQueries(ctx, target, sessionUUID)
+	return count, tabletconn.ErrorFromGRPC(vterrors.ToGRPC(err))
+}
+
 // GetSchema is part of the QueryService interface.
 func (itc *internalTabletConn) GetSchema(ctx context.Context, target *querypb.Target, tableType querypb.SchemaTableType, tableNames []string, callback func(schemaRes *querypb.GetSchemaResponse) error) error {
 	err := itc.tablet.qsc.QueryService().GetSchema(ctx, target, tableType, tableNames, callback)
@@ -137,6 +137,15 @@ func (client *gRPCVtctldClient) CompleteSchemaMigration(ctx context.Context, in
 	return client.c.CompleteSchemaMigration(ctx, in, opts...)
 }
 
+// ConcludeTransaction is part of the vtctlservicepb.VtctldClient interface.
+func (client *gRPCVtctldClient) ConcludeTransaction(ctx context.Context, in *vtctldatapb.ConcludeTransactionRequest, opts ...grpc.CallOption) (*vtctldatapb.ConcludeTransactionResponse, error) {
+	if client.c == nil {
+		return nil, status.Error(codes.Unavailable, connClosedMsg)
+	}
+
+	return client.c.ConcludeTransaction(ctx, in, opts...)
+}
+
 // CreateKeyspace is part of the vtctlservicepb.VtctldClient interface.
 func (client *gRPCVtctldClient) CreateKeyspace(ctx context.Context, in *vtctldatapb.CreateKeyspaceRequest, opts ...grpc.CallOption) (*vtctldatapb.CreateKeyspaceResponse, error) {
 	if client.c == nil {
@@ -245,6 +254,15 @@ func (client *gRPCVtctldClient) ExecuteHook(ctx context.Context, in *vtctldatapb
 	return client.c.ExecuteHook(ctx, in, opts...)
 }
 
+// Export is part of the vtctlservicepb.VtctldClient interface.
+func (client *gRPCVtctldClient) Export(ctx context.Context, in *vtctldatapb.ExportRequest, opts ...grpc.CallOption) (vtctlservicepb.Vtctld_ExportClient, error) {
+	if client.c == nil {
+		return nil, status.Error(codes.Unavailable, connClosedMsg)
+	}
+
+	return client.c.Export(ctx, in, opts...)
+}
+
 // FindAllShardsInKeyspace is part of the vtctlservicepb.VtctldClient interface.
 func (client *gRPCVtctldClient) FindAllShardsInKeyspace(ctx context.Context, in *vtctldatapb.FindAllShardsInKeyspaceRequest, opts ...grpc.CallOption) (*vtctldatapb.FindAllShardsInKeyspaceResponse, error) {
 	if client.c == nil {
@@ -434,6 +452,15 @@ func (client *gRPCVtctldClient) GetTopologyPath(ctx context.Context, in *vtctlda
 	return client.c.GetTopologyPath(ctx, in, opts...)
 }
 
+// GetUnresolvedTransactions is part of the vtctlservicepb.VtctldClient interface.
+func (client *gRPCVtctldClient) GetUnresolvedTransactions(ctx context.Context, in *vtctldatapb.GetUnresolvedTransactionsRequest, opts ...grpc.CallOption) (*vtctldatapb.GetUnresolvedTransactionsResponse, error) {
+	if client.c == nil {
+		return nil, status.Error(codes.Unavailable, connClosedMsg)
+	}
+
+	return client.c.GetUnresolvedTransactions(ctx, in, opts...)
+}
+
 // GetVSchema is part of the vtctlservicepb.VtctldClient interface.
 func (client *gRPCVtctldClient) GetVSchema(ctx context.Context, in *vtctldatapb.GetVSchemaRequest, opts ...grpc.CallOption) (*vtctldatapb.GetVSchemaResponse, error) {
 	if client.c == nil {
@@ -443,6 +470,15 @@ func (client *gRPCVtctldClient) GetVSchema(ctx context.Context, in *vtctldatapb.
 	return client.c.GetVSchema(ctx, in, opts...)
 }
 
+// GetVStreamSubscriptions is part of the vtctlservicepb.VtctldClient interface.
+func (client *gRPCVtctldClient) GetVStreamSubscriptions(ctx context.Context, in *vtctldatapb.GetVStreamSubscriptionsRequest, opts ...grpc.CallOption) (*vtctldatapb.GetVStreamSubscriptionsResponse, error) {
+	if client.c == nil {
+		return nil, status.Error(codes.Unavailable, connClosedMsg)
+	}
+
+	return client.c.GetVStreamSubscriptions(ctx, in, opts...)
+}
+
 // GetVersion is part of the vtctlservicepb.VtctldClient interface.
 func (client *gRPCVtctldClient) GetVersion(ctx context.Context, in *vtctldatapb.GetVersionRequest, opts ...grpc.CallOption) (*vtctldatapb.GetVersionResponse, error) {
 	if client.c == nil {
@@ -686,6 +722,15 @@ func (client *gRPCVtctldClient) ReparentTablet(ctx context.Context, in *vtctldat
 	return client.c.ReparentTablet(ctx, in, opts...)
 }
 
+// ResetVStreamSubscription is part of the vtctlservicepb.VtctldClient interface.
+func (client *gRPCVtctldClient) ResetVStreamSubscription(ctx context.Context, in *vtctldatapb.ResetVStreamSubscriptionRequest, opts ...grpc.CallOption) (*vtctldatapb.ResetVStreamSubscriptionResponse, error) {
+	if client.c == nil {
+		return nil, status.Error(codes.Unavailable, connClosedMsg)
+	}
+
+	return client.c.ResetVStreamSubscription(ctx, in, opts...)
+}
+
 // ReshardCreate is part of the vtctlservicepb.VtctldClient interface.
 func (client *gRPCVtctldClient) ReshardCreate(ctx context.Context, in *vtctldatapb.ReshardCreateRequest, opts ...grpc.CallOption) (*vtctldatapb.WorkflowStatusResponse, error) {
 	if client.c == nil {
@@ -893,6 +938,15 @@ func (client *gRPCVtctldClient) VDiffDelete(ctx context.Context, in *vtctldatapb
 	return client.c.VDiffDelete(ctx, in, opts...)
 }
 
+// VDiffRepair is part of the vtctlservicepb.VtctldClient interface.
+func (client *gRPCVtctldClient) VDiffRepair(ctx context.Context, in *vtctldatapb.VDiffRepairRequest, opts ...grpc.CallOption) (*vtctldatapb.VDiffRepairResponse, error) {
+	if client.c == nil {
+		return nil, status.Error(codes.Unavailable, connClosedMsg)
+	}
+
+	return client.c.VDiffRepair(ctx, in, opts...)
+}
+
 // VDiffResume is part of the vtctlservicepb.VtctldClient interface.
 func (client *gRPCVtctldClient) VDiffResume(ctx context.Context, in *vtctldatapb.VDiffResumeRequest, opts ...grpc.CallOption) (*vtctldatapb.VDiffResumeResponse, error) {
 	if client.c == nil {
@@ -983,6 +1037,24 @@ func (client *gRPCVtctldClient) ValidateVersionShard(ctx context.Context, in *vt
 	return client.c.ValidateVersionShard(ctx, in, opts...)
 }
 
+// VindexSwapComplete is part of the vtctlservicepb.VtctldClient interface.
+func (client *gRPCVtctldClient) VindexSwapComplete(ctx context.Context, in *vtctldatapb.VindexSwapCompleteRequest, opts ...grpc.CallOption) (*vtctldatapb.VindexSwapCompleteResponse, error) {
+	if client.c == nil {
+		return nil, status.Error(codes.Unavailable, connClosedMsg)
+	}
+
+	return client.c.VindexSwapComplete(ctx, in, opts...)
+}
+
+// VindexSwapCreate is part of the vtctlservicepb.VtctldClient interface.
+func (client *gRPCVtctldClient) VindexSwapCreate(ctx context.Context, in *vtctldatapb.VindexSwapCreateRequest, opts ...grpc.CallOption) (*vtctldatapb.VindexSwapCreateResponse, error) {
+	if client.c == nil {
+		return nil, status.Error(codes.Unavailable, connClosedMsg)
+	}
+
+	return client.c.VindexSwapCreate(ctx, in, opts...)
+}
+
 // WorkflowDelete is part of the vtctlservicepb.VtctldClient interface.
 func (client *gRPCVtctldClient) WorkflowDelete(ctx context.Context, in *vtctldatapb.WorkflowDeleteRequest, opts ...grpc.CallOption) (*vtctldatapb.WorkflowDeleteResponse, error) {
 	if client.c == nil {
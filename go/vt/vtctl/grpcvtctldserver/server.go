@@ -34,6 +34,7 @@ import (
 	"google.golang.org/grpc"
 
 	"vitess.io/vitess/go/event"
+	"vitess.io/vitess/go/mysql/replication"
 	"vitess.io/vitess/go/netutil"
 	"vitess.io/vitess/go/protoutil"
 	"vitess.io/vitess/go/sets"
@@ -42,6 +43,8 @@ import (
 	"vitess.io/vitess/go/trace"
 	"vitess.io/vitess/go/vt/callerid"
 	"vitess.io/vitess/go/vt/concurrency"
+	"vitess.io/vitess/go/vt/dtids"
+	"vitess.io/vitess/go/vt/grpcclient"
 	hk "vitess.io/vitess/go/vt/hook"
 	"vitess.io/vitess/go/vt/key"
 	"vitess.io/vitess/go/vt/log"
@@ -62,8 +65,11 @@ import (
 	"vitess.io/vitess/go/vt/vtctl/workflow"
 	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
+	"vitess.io/vitess/go/vt/vttablet/queryservice"
+	"vitess.io/vitess/go/vt/vttablet/tabletconn"
 	"vitess.io/vitess/go/vt/vttablet/tmclient"
 
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
 	logutilpb "vitess.io/vitess/go/vt/proto/logutil"
 	mysqlctlpb "vitess.io/vitess/go/vt/proto/mysqlctl"
 	querypb "vitess.io/vitess/go/vt/proto/query"
@@ -79,6 +85,12 @@ import (
 const (
 	initShardPrimaryOperation = "InitShardPrimary"
 
+	// The durable VStream subscriptions are stored in the sidecar database
+	// of the primary of each shard.
+	sqlSelectVStreamSubscriptions = "select name, pos, time_updated from %s.vstream_subscriptions"
+	sqlSelectVStreamSubscription  = "select name, pos, time_updated from %s.vstream_subscriptions where name = %a"
+	sqlDeleteVStreamSubscription  = "delete from %s.vstream_subscriptions where name = %a"
+
 	// DefaultWaitReplicasTimeout is the default value for waitReplicasTimeout, which is used when calling method ApplySchema.
 	DefaultWaitReplicasTimeout = 10 * time.Second
 )
@@ -704,6 +716,87 @@ func (s *VtctldServer) CompleteSchemaMigration(ctx context.Context, req *vtctlda
 	return resp, nil
 }
 
+// ConcludeTransaction is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) ConcludeTransaction(ctx context.Context, req *vtctldatapb.ConcludeTransactionRequest) (resp *vtctldatapb.ConcludeTransactionResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.ConcludeTransaction")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("dtid", req.Dtid)
+
+	// The metadata of the transaction is stored on the shard that created it.
+	mmShard, err := dtids.ShardSession(req.Dtid)
+	if err != nil {
+		return nil, err
+	}
+	mmConn, err := s.dialShardPrimary(ctx, mmShard.Target)
+	if err != nil {
+		return nil, err
+	}
+	defer mmConn.Close(ctx)
+
+	transaction, err := mmConn.ReadTransaction(ctx, mmShard.Target, req.Dtid)
+	if err != nil {
+		return nil, err
+	}
+	if transaction == nil || transaction.Dtid == "" {
+		// It was already resolved.
+		return &vtctldatapb.ConcludeTransactionResponse{}, nil
+	}
+
+	var conclude func(conn queryservice.QueryService, target *querypb.Target) error
+	switch transaction.State {
+	case querypb.TransactionState_PREPARE:
+		// The commit decision was never made: roll back.
+		if err = mmConn.SetRollback(ctx, mmShard.Target, transaction.Dtid, mmShard.TransactionId); err != nil {
+			return nil, err
+		}
+		fallthrough
+	case querypb.TransactionState_ROLLBACK:
+		conclude = func(conn queryservice.QueryService, target *querypb.Target) error {
+			return conn.RollbackPrepared(ctx, target, transaction.Dtid, 0)
+		}
+	case querypb.TransactionState_COMMIT:
+		conclude = func(conn queryservice.QueryService, target *querypb.Target) error {
+			return conn.CommitPrepared(ctx, target, transaction.Dtid)
+		}
+	default:
+		err = vterrors.Errorf(vtrpcpb.Code_INTERNAL, "invalid state for dtid %s: %v", transaction.Dtid, transaction.State)
+		return nil, err
+	}
+
+	var (
+		wg  sync.WaitGroup
+		rec concurrency.AllErrorRecorder
+	)
+	for _, participant := range transaction.Participants {
+		wg.Add(1)
+		go func(target *querypb.Target) {
+			defer wg.Done()
+			conn, err := s.dialShardPrimary(ctx, target)
+			if err != nil {
+				rec.RecordError(err)
+				return
+			}
+			defer conn.Close(ctx)
+			if err := conclude(conn, target); err != nil {
+				rec.RecordError(fmt.Errorf("%s/%s: %w", target.Keyspace, target.Shard, err))
+			}
+		}(participant)
+	}
+	wg.Wait()
+	if rec.HasErrors() {
+		err = rec.Error()
+		return nil, err
+	}
+
+	if err = mmConn.ConcludeTransaction(ctx, mmShard.Target, transaction.Dtid); err != nil {
+		return nil, err
+	}
+	return &vtctldatapb.ConcludeTransactionResponse{}, nil
+}
+
 // CreateKeyspace is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) CreateKeyspace(ctx context.Context, req *vtctldatapb.CreateKeyspaceRequest) (resp *vtctldatapb.CreateKeyspaceResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.CreateKeyspace")
@@ -1228,6 +1321,81 @@ func (s *VtctldServer) ExecuteHook(ctx context.Context, req *vtctldatapb.Execute
 	}}, nil
 }
 
+// Export is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) Export(req *vtctldatapb.ExportRequest, stream vtctlservicepb.Vtctld_ExportServer) (err error) {
+	span, ctx := trace.NewSpan(stream.Context(), "VtctldServer.Export")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("tables", strings.Join(req.Tables, ","))
+	span.Annotate("shards", strings.Join(req.Shards, ","))
+	span.Annotate("tablet_types", topoproto.MakeStringTypeCSV(req.TabletTypes))
+
+	if req.Keyspace == "" {
+		err = vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "keyspace field is required")
+		return err
+	}
+
+	shards := req.Shards
+	if len(shards) == 0 {
+		shards, err = s.ts.GetShardNames(ctx, req.Keyspace)
+		if err != nil {
+			err = vterrors.Errorf(vtrpcpb.Code_INTERNAL, "GetShardNames(%v) failed: %v", req.Keyspace, err)
+			return err
+		}
+	}
+	sort.Strings(shards)
+
+	tabletTypes := req.TabletTypes
+	if len(tabletTypes) == 0 {
+		tabletTypes = []topodatapb.TabletType{topodatapb.TabletType_RDONLY, topodatapb.TabletType_REPLICA}
+	}
+
+	// The shards are exported in parallel, and the export stops as soon as
+	// one of them fails.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		m     sync.Mutex
+		wg    sync.WaitGroup
+		rec   concurrency.AllErrorRecorder
+		vgtid = &binlogdatapb.VGtid{ShardGtids: make([]*binlogdatapb.ShardGtid, len(shards))}
+	)
+	send := func(resp *vtctldatapb.ExportResponse) error {
+		m.Lock()
+		defer m.Unlock()
+		return stream.Send(resp)
+	}
+	for i, shard := range shards {
+		wg.Add(1)
+		go func(i int, shard string) {
+			defer wg.Done()
+			position, err := s.exportShard(ctx, req.Keyspace, shard, req.Tables, tabletTypes, send)
+			if err != nil {
+				rec.RecordError(fmt.Errorf("%s/%s: %w", req.Keyspace, shard, err))
+				cancel()
+				return
+			}
+			vgtid.ShardGtids[i] = &binlogdatapb.ShardGtid{
+				Keyspace: req.Keyspace,
+				Shard:    shard,
+				Gtid:     position,
+			}
+		}(i, shard)
+	}
+	wg.Wait()
+	if rec.HasErrors() {
+		err = rec.Error()
+		return err
+	}
+
+	err = stream.Send(&vtctldatapb.ExportResponse{Vgtid: vgtid})
+	return err
+}
+
 // FindAllShardsInKeyspace is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) FindAllShardsInKeyspace(ctx context.Context, req *vtctldatapb.FindAllShardsInKeyspaceRequest) (resp *vtctldatapb.FindAllShardsInKeyspaceResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.FindAllShardsInKeyspace")
@@ -2147,6 +2315,61 @@ func (s *VtctldServer) GetTopologyPath(ctx context.Context, req *vtctldatapb.Get
 	}, nil
 }
 
+// GetUnresolvedTransactions is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) GetUnresolvedTransactions(ctx context.Context, req *vtctldatapb.GetUnresolvedTransactionsRequest) (resp *vtctldatapb.GetUnresolvedTransactionsResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.GetUnresolvedTransactions")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("abandon_age", req.AbandonAge)
+
+	shards, err := s.ts.GetShardNames(ctx, req.Keyspace)
+	if err != nil {
+		err = vterrors.Errorf(vtrpcpb.Code_INTERNAL, "GetShardNames(%v) failed: %v", req.Keyspace, err)
+		return nil, err
+	}
+
+	var (
+		m            sync.Mutex
+		wg           sync.WaitGroup
+		rec          concurrency.AllErrorRecorder
+		transactions []*querypb.TransactionMetadata
+	)
+	for _, shard := range shards {
+		wg.Add(1)
+		go func(shard string) {
+			defer wg.Done()
+			target := &querypb.Target{Keyspace: req.Keyspace, Shard: shard, TabletType: topodatapb.TabletType_PRIMARY}
+			conn, err := s.dialShardPrimary(ctx, target)
+			if err != nil {
+				rec.RecordError(err)
+				return
+			}
+			defer conn.Close(ctx)
+			shardTransactions, err := conn.UnresolvedTransactions(ctx, target, req.AbandonAge)
+			if err != nil {
+				rec.RecordError(fmt.Errorf("%s/%s: %w", req.Keyspace, shard, err))
+				return
+			}
+			m.Lock()
+			defer m.Unlock()
+			transactions = append(transactions, shardTransactions...)
+		}(shard)
+	}
+	wg.Wait()
+	if rec.HasErrors() {
+		err = rec.Error()
+		return nil, err
+	}
+
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].Dtid < transactions[j].Dtid
+	})
+	return &vtctldatapb.GetUnresolvedTransactionsResponse{Transactions: transactions}, nil
+}
+
 // GetVersion returns the version of a tablet from its debug vars
 func (s *VtctldServer) GetVersion(ctx context.Context, req *vtctldatapb.GetVersionRequest) (resp *vtctldatapb.GetVersionResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.GetVersion")
@@ -2187,6 +2410,118 @@ func (s *VtctldServer) GetVSchema(ctx context.Context, req *vtctldatapb.GetVSche
 	}, nil
 }
 
+// GetVStreamSubscriptions is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) GetVStreamSubscriptions(ctx context.Context, req *vtctldatapb.GetVStreamSubscriptionsRequest) (resp *vtctldatapb.GetVStreamSubscriptionsResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.GetVStreamSubscriptions")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("name", req.Name)
+
+	if req.Keyspace == "" {
+		err = vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "keyspace field is required")
+		return nil, err
+	}
+
+	shards, err := s.ts.GetShardNames(ctx, req.Keyspace)
+	if err != nil {
+		err = vterrors.Errorf(vtrpcpb.Code_INTERNAL, "GetShardNames(%v) failed: %v", req.Keyspace, err)
+		return nil, err
+	}
+
+	sidecarDBIdent, err := s.sidecarDBIdentifier(ctx, req.Keyspace)
+	if err != nil {
+		return nil, err
+	}
+	query := sqlparser.BuildParsedQuery(sqlSelectVStreamSubscriptions, sidecarDBIdent).Query
+	if req.Name != "" {
+		query, err = sqlparser.BuildParsedQuery(sqlSelectVStreamSubscription, sidecarDBIdent, ":name").GenerateQuery(map[string]*querypb.BindVariable{
+			"name": sqltypes.StringBindVariable(req.Name),
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		m             sync.Mutex
+		wg            sync.WaitGroup
+		rec           concurrency.AllErrorRecorder
+		subscriptions = map[string]*vtctldatapb.VStreamSubscription{}
+	)
+	for _, shard := range shards {
+		wg.Add(1)
+		go func(shard string) {
+			defer wg.Done()
+			ti, err := s.getShardPrimary(ctx, req.Keyspace, shard)
+			if err != nil {
+				rec.RecordError(err)
+				return
+			}
+			p3qr, err := s.tmc.ExecuteFetchAsDba(ctx, ti.Tablet, true, &tabletmanagerdatapb.ExecuteFetchAsDbaRequest{
+				Query:   []byte(query),
+				MaxRows: 10_000,
+			})
+			if err != nil {
+				rec.RecordError(fmt.Errorf("%s/%s: %w", req.Keyspace, shard, err))
+				return
+			}
+			qr := sqltypes.Proto3ToResult(p3qr)
+			if len(qr.Rows) == 0 {
+				return
+			}
+			primaryPosition, err := s.tmc.PrimaryPosition(ctx, ti.Tablet)
+			if err != nil {
+				rec.RecordError(fmt.Errorf("PrimaryPosition(%s) failed: %w", topoproto.TabletAliasString(ti.Alias), err))
+				return
+			}
+
+			m.Lock()
+			defer m.Unlock()
+			for _, row := range qr.Named().Rows {
+				name := row.AsString("name", "")
+				timeUpdated, err := row.ToInt64("time_updated")
+				if err != nil {
+					rec.RecordError(fmt.Errorf("%s/%s: invalid time_updated of subscription %s: %w", req.Keyspace, shard, name, err))
+					return
+				}
+				subscription, ok := subscriptions[name]
+				if !ok {
+					subscription = &vtctldatapb.VStreamSubscription{Name: name, Keyspace: req.Keyspace}
+					subscriptions[name] = subscription
+				}
+				position := row.AsString("pos", "")
+				subscription.Shards = append(subscription.Shards, &vtctldatapb.VStreamSubscriptionShard{
+					Shard:              shard,
+					Position:           position,
+					PrimaryPosition:    primaryPosition,
+					TransactionsBehind: transactionsBehind(position, primaryPosition),
+					TimeUpdated:        protoutil.TimeToProto(time.Unix(timeUpdated, 0)),
+				})
+			}
+		}(shard)
+	}
+	wg.Wait()
+	if rec.HasErrors() {
+		err = rec.Error()
+		return nil, err
+	}
+
+	resp = &vtctldatapb.GetVStreamSubscriptionsResponse{}
+	for _, subscription := range subscriptions {
+		sort.Slice(subscription.Shards, func(i, j int) bool {
+			return subscription.Shards[i].Shard < subscription.Shards[j].Shard
+		})
+		resp.Subscriptions = append(resp.Subscriptions, subscription)
+	}
+	sort.Slice(resp.Subscriptions, func(i, j int) bool {
+		return resp.Subscriptions[i].Name < resp.Subscriptions[j].Name
+	})
+	return resp, nil
+}
+
 // GetWorkflows is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) GetWorkflows(ctx context.Context, req *vtctldatapb.GetWorkflowsRequest) (resp *vtctldatapb.GetWorkflowsResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.GetWorkflows")
@@ -3123,6 +3458,72 @@ func (s *VtctldServer) ReparentTablet(ctx context.Context, req *vtctldatapb.Repa
 	}, nil
 }
 
+// ResetVStreamSubscription is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) ResetVStreamSubscription(ctx context.Context, req *vtctldatapb.ResetVStreamSubscriptionRequest) (resp *vtctldatapb.ResetVStreamSubscriptionResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.ResetVStreamSubscription")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("name", req.Name)
+	span.Annotate("shards", strings.Join(req.Shards, ","))
+
+	if req.Keyspace == "" || req.Name == "" {
+		err = vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "keyspace and name fields are required")
+		return nil, err
+	}
+
+	shards := req.Shards
+	if len(shards) == 0 {
+		shards, err = s.ts.GetShardNames(ctx, req.Keyspace)
+		if err != nil {
+			err = vterrors.Errorf(vtrpcpb.Code_INTERNAL, "GetShardNames(%v) failed: %v", req.Keyspace, err)
+			return nil, err
+		}
+	}
+
+	sidecarDBIdent, err := s.sidecarDBIdentifier(ctx, req.Keyspace)
+	if err != nil {
+		return nil, err
+	}
+	query, err := sqlparser.BuildParsedQuery(sqlDeleteVStreamSubscription, sidecarDBIdent, ":name").GenerateQuery(map[string]*querypb.BindVariable{
+		"name": sqltypes.StringBindVariable(req.Name),
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		wg  sync.WaitGroup
+		rec concurrency.AllErrorRecorder
+	)
+	for _, shard := range shards {
+		wg.Add(1)
+		go func(shard string) {
+			defer wg.Done()
+			ti, err := s.getShardPrimary(ctx, req.Keyspace, shard)
+			if err != nil {
+				rec.RecordError(err)
+				return
+			}
+			_, err = s.tmc.ExecuteFetchAsDba(ctx, ti.Tablet, true, &tabletmanagerdatapb.ExecuteFetchAsDbaRequest{
+				Query: []byte(query),
+			})
+			if err != nil {
+				rec.RecordError(fmt.Errorf("%s/%s: %w", req.Keyspace, shard, err))
+			}
+		}(shard)
+	}
+	wg.Wait()
+	if rec.HasErrors() {
+		err = rec.Error()
+		return nil, err
+	}
+
+	return &vtctldatapb.ResetVStreamSubscriptionResponse{}, nil
+}
+
 // ReshardCreate is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) ReshardCreate(ctx context.Context, req *vtctldatapb.ReshardCreateRequest) (resp *vtctldatapb.WorkflowStatusResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.ReshardCreate")
@@ -4844,6 +5245,21 @@ func (s *VtctldServer) VDiffDelete(ctx context.Context, req *vtctldatapb.VDiffDe
 	return resp, err
 }
 
+// VDiffRepair is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) VDiffRepair(ctx context.Context, req *vtctldatapb.VDiffRepairRequest) (resp *vtctldatapb.VDiffRepairResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.VDiffRepair")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.TargetKeyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("uuid", req.Uuid)
+
+	resp, err = s.ws.VDiffRepair(ctx, req)
+	return resp, err
+}
+
 // VDiffResume is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) VDiffResume(ctx context.Context, req *vtctldatapb.VDiffResumeRequest) (resp *vtctldatapb.VDiffResumeResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.VDiffResume")
@@ -4889,6 +5305,36 @@ func (s *VtctldServer) VDiffStop(ctx context.Context, req *vtctldatapb.VDiffStop
 	return resp, err
 }
 
+// VindexSwapComplete is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) VindexSwapComplete(ctx context.Context, req *vtctldatapb.VindexSwapCompleteRequest) (resp *vtctldatapb.VindexSwapCompleteResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.VindexSwapComplete")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("keep_data", req.KeepData)
+
+	resp, err = s.ws.VindexSwapComplete(ctx, req)
+	return resp, err
+}
+
+// VindexSwapCreate is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) VindexSwapCreate(ctx context.Context, req *vtctldatapb.VindexSwapCreateRequest) (resp *vtctldatapb.VindexSwapCreateResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.VindexSwapCreate")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("table", req.Table)
+
+	resp, err = s.ws.VindexSwapCreate(ctx, req)
+	return resp, err
+}
+
 // WorkflowDelete is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) WorkflowDelete(ctx context.Context, req *vtctldatapb.WorkflowDeleteRequest) (resp *vtctldatapb.WorkflowDeleteResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.WorkflowDelete")
@@ -4957,6 +5403,187 @@ func StartServer(s *grpc.Server, ts *topo.Server) {
 	vtctlservicepb.RegisterVtctldServer(s, NewVtctldServer(ts))
 }
 
+// dialShardPrimary returns a query service connection to the primary
+// tablet of the target shard. The caller must close it.
+func (s *VtctldServer) dialShardPrimary(ctx context.Context, target *querypb.Target) (queryservice.QueryService, error) {
+	ti, err := s.getShardPrimary(ctx, target.Keyspace, target.Shard)
+	if err != nil {
+		return nil, err
+	}
+	return tabletconn.GetDialer()(ti.Tablet, grpcclient.FailFast(false))
+}
+
+// exportShard streams the rows of the tables of a shard, and returns the
+// position they were read at. The rows are read from a tablet of the shard
+// whose replication is stopped meanwhile, so the snapshots of all the tables
+// are taken at the same position.
+func (s *VtctldServer) exportShard(ctx context.Context, keyspace, shard string, tables []string, tabletTypes []topodatapb.TabletType, send func(resp *vtctldatapb.ExportResponse) error) (position string, err error) {
+	tablet, err := s.getExportTablet(ctx, keyspace, shard, tabletTypes)
+	if err != nil {
+		return "", err
+	}
+	alias := topoproto.TabletAliasString(tablet.Alias)
+
+	sd, err := s.tmc.GetSchema(ctx, tablet, &tabletmanagerdatapb.GetSchemaRequest{Tables: tables, TableSchemaOnly: true})
+	if err != nil {
+		return "", err
+	}
+	found := make(map[string]bool, len(sd.TableDefinitions))
+	for _, td := range sd.TableDefinitions {
+		found[td.Name] = true
+	}
+	for _, table := range tables {
+		if !strings.HasPrefix(table, "/") && !found[table] {
+			return "", vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "table %s not found on tablet %s", table, alias)
+		}
+	}
+
+	if err := s.tmc.StopReplication(ctx, tablet); err != nil {
+		return "", fmt.Errorf("StopReplication(%s) failed: %w", alias, err)
+	}
+	defer func() {
+		// Replication is restarted even when the export is canceled.
+		startCtx, cancel := context.WithTimeout(context.Background(), topo.RemoteOperationTimeout)
+		defer cancel()
+		if _, startErr := s.StartReplication(startCtx, &vtctldatapb.StartReplicationRequest{TabletAlias: tablet.Alias}); startErr != nil {
+			log.Errorf("Export: failed to restart replication on %s: %v", alias, startErr)
+			if err == nil {
+				err = startErr
+			}
+		}
+	}()
+
+	status, err := s.tmc.ReplicationStatus(ctx, tablet)
+	if err != nil {
+		return "", fmt.Errorf("ReplicationStatus(%s) failed: %w", alias, err)
+	}
+	pos, err := replication.DecodePosition(status.Position)
+	if err != nil {
+		return "", err
+	}
+
+	conn, err := tabletconn.GetDialer()(tablet, grpcclient.FailFast(false))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close(ctx)
+
+	target := &querypb.Target{
+		Keyspace:   keyspace,
+		Shard:      shard,
+		TabletType: tablet.Type,
+	}
+	for _, td := range sd.TableDefinitions {
+		first := true
+		err := conn.VStreamRows(ctx, &binlogdatapb.VStreamRowsRequest{
+			Target: target,
+			Query:  "select * from " + sqlescape.EscapeID(td.Name),
+		}, func(rows *binlogdatapb.VStreamRowsResponse) error {
+			resp := &vtctldatapb.ExportResponse{
+				Shard: shard,
+				Table: td.Name,
+				Rows:  rows.Rows,
+			}
+			if first {
+				// The snapshot of the table is taken at the position of the
+				// tablet, unless something restarted its replication.
+				snapshotPos, err := replication.DecodePosition(rows.Gtid)
+				if err != nil {
+					return err
+				}
+				if !snapshotPos.Equal(pos) {
+					return vterrors.Errorf(vtrpcpb.Code_ABORTED, "the snapshot of table %s was taken at position %s instead of %s on tablet %s, was its replication restarted?", td.Name, rows.Gtid, status.Position, alias)
+				}
+				resp.TableDefinition = td
+				resp.Fields = rows.Fields
+				first = false
+			} else if len(rows.Rows) == 0 {
+				return nil
+			}
+			return send(resp)
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to export table %s from tablet %s: %w", td.Name, alias, err)
+		}
+	}
+	return status.Position, nil
+}
+
+// getExportTablet returns the tablet of a shard an export reads from, which
+// is the least lagging tablet of the first type with one.
+func (s *VtctldServer) getExportTablet(ctx context.Context, keyspace, shard string, tabletTypes []topodatapb.TabletType) (*topodatapb.Tablet, error) {
+	tablets, stats, err := reparentutil.ShardReplicationStatuses(ctx, s.ts, s.tmc, keyspace, shard)
+	if err != nil {
+		return nil, err
+	}
+	for _, tabletType := range tabletTypes {
+		if tabletType == topodatapb.TabletType_PRIMARY {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "cannot export from a primary tablet, its replication cannot be stopped")
+		}
+		var (
+			exportTablet    *topodatapb.Tablet
+			exportTabletLag uint32
+		)
+		for i, tablet := range tablets {
+			if tablet.Type != tabletType || stats[i] == nil {
+				continue
+			}
+			if lag := stats[i].ReplicationLagSeconds; exportTablet == nil || lag < exportTabletLag {
+				exportTablet = tablet.Tablet
+				exportTabletLag = lag
+			}
+		}
+		if exportTablet != nil {
+			return exportTablet, nil
+		}
+	}
+	return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "no %s tablet available for export in shard %s/%s", topoproto.MakeStringTypeCSV(tabletTypes), keyspace, shard)
+}
+
+// getShardPrimary returns the primary tablet of a shard.
+func (s *VtctldServer) getShardPrimary(ctx context.Context, keyspace, shard string) (*topo.TabletInfo, error) {
+	si, err := s.ts.GetShard(ctx, keyspace, shard)
+	if err != nil {
+		return nil, err
+	}
+	if !si.HasPrimary() {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "shard %s/%s has no primary", keyspace, shard)
+	}
+	return s.ts.GetTablet(ctx, si.PrimaryAlias)
+}
+
+// sidecarDBIdentifier returns the identifier of the sidecar database of the
+// keyspace, as it is stored in the topo.
+func (s *VtctldServer) sidecarDBIdentifier(ctx context.Context, keyspace string) (string, error) {
+	name, err := s.ts.GetSidecarDBName(ctx, keyspace)
+	if err != nil {
+		return "", vterrors.Errorf(vtrpcpb.Code_INTERNAL, "GetSidecarDBName(%v) failed: %v", keyspace, err)
+	}
+	return sqlparser.String(sqlparser.NewIdentifierCS(name)), nil
+}
+
+// transactionsBehind returns the number of transactions in the primary position
+// that are not in the position, or -1 if it cannot be computed.
+func transactionsBehind(position, primaryPosition string) int64 {
+	pos, err := replication.DecodePosition(position)
+	if err != nil {
+		return -1
+	}
+	primaryPos, err := replication.DecodePosition(primaryPosition)
+	if err != nil {
+		return -1
+	}
+	gtids, ok := pos.GTIDSet.(replication.Mysql56GTIDSet)
+	if !ok {
+		return -1
+	}
+	primaryGTIDs, ok := primaryPos.GTIDSet.(replication.Mysql56GTIDSet)
+	if !ok {
+		return -1
+	}
+	return primaryGTIDs.Difference(gtids).Count()
+}
+
 // getTopologyCell is a helper method that returns a topology cell given its path.
 func (s *VtctldServer) getTopologyCell(ctx context.Context, cellPath string) (*vtctldatapb.TopologyCell, error) {
 	// extract cell and relative path
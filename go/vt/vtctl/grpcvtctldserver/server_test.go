@@ -24,6 +24,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -37,6 +38,7 @@ import (
 	"vitess.io/vitess/go/protoutil"
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/test/utils"
+	"vitess.io/vitess/go/vt/grpcclient"
 	hk "vitess.io/vitess/go/vt/hook"
 	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
 	"vitess.io/vitess/go/vt/topo"
@@ -45,9 +47,14 @@ import (
 	"vitess.io/vitess/go/vt/vtctl/grpcvtctldserver/testutil"
 	"vitess.io/vitess/go/vt/vtctl/localvtctldclient"
 	"vitess.io/vitess/go/vt/vtctl/schematools"
+	"vitess.io/vitess/go/vt/vttablet/queryservice"
+	"vitess.io/vitess/go/vt/vttablet/sandboxconn"
+	"vitess.io/vitess/go/vt/vttablet/tabletconn"
+	"vitess.io/vitess/go/vt/vttablet/tabletconntest"
 	"vitess.io/vitess/go/vt/vttablet/tmclient"
 	"vitess.io/vitess/go/vt/vttablet/tmclienttest"
 
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
 	logutilpb "vitess.io/vitess/go/vt/proto/logutil"
 	mysqlctlpb "vitess.io/vitess/go/vt/proto/mysqlctl"
 	querypb "vitess.io/vitess/go/vt/proto/query"
@@ -74,6 +81,39 @@ func init() {
 	tmclient.RegisterTabletManagerClientFactory("grpcvtctldserver.test", func() tmclient.TabletManagerClient {
 		return nil
 	})
+
+	// The tests that need a query service connection to a tablet add it
+	// to testQueryServices.
+	tabletconntest.SetProtocol("go.vt.vtctl.grpcvtctldserver.tabletconn", "grpcvtctldserver.test")
+	tabletconn.RegisterDialer("grpcvtctldserver.test", func(tablet *topodatapb.Tablet, failFast grpcclient.FailFast) (queryservice.QueryService, error) {
+		testQueryServicesMu.Lock()
+		defer testQueryServicesMu.Unlock()
+		if qs, ok := testQueryServices[topoproto.TabletAliasString(tablet.Alias)]; ok {
+			return qs, nil
+		}
+		return nil, fmt.Errorf("tablet %v not found", topoproto.TabletAliasString(tablet.Alias))
+	})
+}
+
+var (
+	testQueryServicesMu sync.Mutex
+	testQueryServices   = map[string]queryservice.QueryService{}
+)
+
+// addTestQueryService registers a sandbox query service for the tablet.
+func addTestQueryService(t *testing.T, tablet *topodatapb.Tablet) *sandboxconn.SandboxConn {
+	t.Helper()
+	sbc := sandboxconn.NewSandboxConn(tablet)
+	alias := topoproto.TabletAliasString(tablet.Alias)
+	testQueryServicesMu.Lock()
+	defer testQueryServicesMu.Unlock()
+	testQueryServices[alias] = sbc
+	t.Cleanup(func() {
+		testQueryServicesMu.Lock()
+		defer testQueryServicesMu.Unlock()
+		delete(testQueryServices, alias)
+	})
+	return sbc
 }
 
 func TestPanicHandler(t *testing.T) {
@@ -1979,6 +2019,83 @@ func TestCompleteSchemaMigration(t *testing.T) {
 	}
 }
 
+func TestConcludeTransaction(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ts := memorytopo.NewServer(ctx, "zone1")
+	vtctld := testutil.NewVtctldServerWithTabletManagerClient(t, ts, nil, func(ts *topo.Server) vtctlservicepb.VtctldServer {
+		return NewVtctldServer(ts)
+	})
+
+	tablets := []*topodatapb.Tablet{{
+		Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 8100},
+		Keyspace: "conclude",
+		Shard:    "-80",
+		Type:     topodatapb.TabletType_PRIMARY,
+	}, {
+		Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 8200},
+		Keyspace: "conclude",
+		Shard:    "80-",
+		Type:     topodatapb.TabletType_PRIMARY,
+	}}
+	testutil.AddTablets(ctx, t, ts, &testutil.AddTabletOptions{AlsoSetShardPrimary: true}, tablets...)
+	sbc0 := addTestQueryService(t, tablets[0])
+	sbc1 := addTestQueryService(t, tablets[1])
+
+	participants := []*querypb.Target{{
+		Keyspace:   "conclude",
+		Shard:      "80-",
+		TabletType: topodatapb.TabletType_PRIMARY,
+	}}
+
+	// The commit decision was not made: the transaction is rolled back.
+	dtid := "conclude:-80:1234"
+	sbc0.ReadTransactionResults = []*querypb.TransactionMetadata{{
+		Dtid:         dtid,
+		State:        querypb.TransactionState_PREPARE,
+		Participants: participants,
+	}}
+	_, err := vtctld.ConcludeTransaction(ctx, &vtctldatapb.ConcludeTransactionRequest{Dtid: dtid})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, sbc0.SetRollbackCount.Load(), "sbc0.SetRollbackCount")
+	assert.EqualValues(t, 1, sbc1.RollbackPreparedCount.Load(), "sbc1.RollbackPreparedCount")
+	assert.EqualValues(t, 0, sbc1.CommitPreparedCount.Load(), "sbc1.CommitPreparedCount")
+	assert.EqualValues(t, 1, sbc0.ConcludeTransactionCount.Load(), "sbc0.ConcludeTransactionCount")
+
+	// The commit decision was made: the transaction is committed.
+	sbc0.ReadTransactionResults = []*querypb.TransactionMetadata{{
+		Dtid:         dtid,
+		State:        querypb.TransactionState_COMMIT,
+		Participants: participants,
+	}}
+	_, err = vtctld.ConcludeTransaction(ctx, &vtctldatapb.ConcludeTransactionRequest{Dtid: dtid})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, sbc0.SetRollbackCount.Load(), "sbc0.SetRollbackCount")
+	assert.EqualValues(t, 1, sbc1.CommitPreparedCount.Load(), "sbc1.CommitPreparedCount")
+	assert.EqualValues(t, 2, sbc0.ConcludeTransactionCount.Load(), "sbc0.ConcludeTransactionCount")
+
+	// The transaction was already resolved.
+	_, err = vtctld.ConcludeTransaction(ctx, &vtctldatapb.ConcludeTransactionRequest{Dtid: dtid})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, sbc0.ConcludeTransactionCount.Load(), "sbc0.ConcludeTransactionCount")
+
+	// A participant fails: the transaction is not concluded.
+	sbc0.ReadTransactionResults = []*querypb.TransactionMetadata{{
+		Dtid:         dtid,
+		State:        querypb.TransactionState_COMMIT,
+		Participants: participants,
+	}}
+	sbc1.MustFailCommitPrepared = 1
+	_, err = vtctld.ConcludeTransaction(ctx, &vtctldatapb.ConcludeTransactionRequest{Dtid: dtid})
+	require.Error(t, err)
+	assert.EqualValues(t, 2, sbc0.ConcludeTransactionCount.Load(), "sbc0.ConcludeTransactionCount")
+
+	_, err = vtctld.ConcludeTransaction(ctx, &vtctldatapb.ConcludeTransactionRequest{Dtid: "bogus"})
+	require.ErrorContains(t, err, "invalid parts in dtid")
+}
+
 func TestCreateKeyspace(t *testing.T) {
 	t.Parallel()
 
@@ -4661,6 +4778,185 @@ func TestExecuteHook(t *testing.T) {
 	}
 }
 
+// exportQueryService is a query service streaming the rows of the tables of
+// an export.
+type exportQueryService struct {
+	*sandboxconn.SandboxConn
+	// rows are keyed by query.
+	rows map[string][]*binlogdatapb.VStreamRowsResponse
+}
+
+// VStreamRows is part of the queryservice.QueryService interface.
+func (qs *exportQueryService) VStreamRows(ctx context.Context, request *binlogdatapb.VStreamRowsRequest, send func(*binlogdatapb.VStreamRowsResponse) error) error {
+	rows, ok := qs.rows[request.Query]
+	if !ok {
+		return fmt.Errorf("unexpected query %s", request.Query)
+	}
+	for _, resp := range rows {
+		if err := send(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const (
+		pos1 = "MySQL56/16b1039f-22b6-11ed-b765-0a43f95f28a3:1-10"
+		pos2 = "MySQL56/16b1039f-22b6-11ed-b765-0a43f95f28a3:1-12"
+	)
+	fields := sqltypes.MakeTestFields("id|name", "int64|varchar")
+	t1 := &tabletmanagerdatapb.TableDefinition{
+		Name:   "t1",
+		Schema: "CREATE TABLE `t1` (`id` bigint NOT NULL, `name` varchar(64), PRIMARY KEY (`id`))",
+	}
+	tablets := []*topodatapb.Tablet{{
+		Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 100},
+		Keyspace: "ks",
+		Shard:    "-80",
+		Type:     topodatapb.TabletType_PRIMARY,
+	}, {
+		Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 101},
+		Keyspace: "ks",
+		Shard:    "-80",
+		Type:     topodatapb.TabletType_REPLICA,
+	}, {
+		Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 102},
+		Keyspace: "ks",
+		Shard:    "-80",
+		Type:     topodatapb.TabletType_RDONLY,
+	}, {
+		Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 200},
+		Keyspace: "ks",
+		Shard:    "80-",
+		Type:     topodatapb.TabletType_PRIMARY,
+	}, {
+		Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 201},
+		Keyspace: "ks",
+		Shard:    "80-",
+		Type:     topodatapb.TabletType_REPLICA,
+	}}
+	tmc := &testutil.TabletManagerClient{
+		GetSchemaResults: map[string]struct {
+			Schema *tabletmanagerdatapb.SchemaDefinition
+			Error  error
+		}{
+			"zone1-0000000102": {Schema: &tabletmanagerdatapb.SchemaDefinition{TableDefinitions: []*tabletmanagerdatapb.TableDefinition{t1}}},
+			"zone1-0000000201": {Schema: &tabletmanagerdatapb.SchemaDefinition{TableDefinitions: []*tabletmanagerdatapb.TableDefinition{t1}}},
+		},
+		PrimaryPositionResults: map[string]struct {
+			Position string
+			Error    error
+		}{
+			"zone1-0000000100": {Position: pos1},
+			"zone1-0000000200": {Position: pos2},
+		},
+		ReplicationStatusResults: map[string]struct {
+			Position *replicationdatapb.Status
+			Error    error
+		}{
+			"zone1-0000000101": {Position: &replicationdatapb.Status{Position: pos1}},
+			"zone1-0000000102": {Position: &replicationdatapb.Status{Position: pos1}},
+			"zone1-0000000201": {Position: &replicationdatapb.Status{Position: pos2}},
+		},
+		StartReplicationResults: map[string]error{
+			"zone1-0000000102": nil,
+			"zone1-0000000201": nil,
+		},
+		StopReplicationResults: map[string]error{
+			"zone1-0000000102": nil,
+			"zone1-0000000201": nil,
+		},
+	}
+
+	ts := memorytopo.NewServer(ctx, "zone1")
+	defer ts.Close()
+	testutil.AddTablets(ctx, t, ts, &testutil.AddTabletOptions{AlsoSetShardPrimary: true}, tablets...)
+	vtctld := testutil.NewVtctldServerWithTabletManagerClient(t, ts, tmc, func(ts *topo.Server) vtctlservicepb.VtctldServer {
+		return NewVtctldServer(ts)
+	})
+
+	addExportQueryService := func(tablet *topodatapb.Tablet, rows ...*binlogdatapb.VStreamRowsResponse) {
+		sbc := addTestQueryService(t, tablet)
+		testQueryServicesMu.Lock()
+		defer testQueryServicesMu.Unlock()
+		testQueryServices[topoproto.TabletAliasString(tablet.Alias)] = &exportQueryService{
+			SandboxConn: sbc,
+			rows:        map[string][]*binlogdatapb.VStreamRowsResponse{"select * from `t1`": rows},
+		}
+	}
+	export := func(req *vtctldatapb.ExportRequest) ([]*vtctldatapb.ExportResponse, error) {
+		stream, err := localvtctldclient.New(vtctld).Export(ctx, req)
+		require.NoError(t, err)
+		var responses []*vtctldatapb.ExportResponse
+		for {
+			resp, err := stream.Recv()
+			if err == io.EOF {
+				return responses, nil
+			}
+			if err != nil {
+				return responses, err
+			}
+			responses = append(responses, resp)
+		}
+	}
+
+	addExportQueryService(tablets[2], &binlogdatapb.VStreamRowsResponse{
+		Fields: fields,
+		Gtid:   pos1,
+	}, &binlogdatapb.VStreamRowsResponse{
+		Rows: sqltypes.RowsToProto3(sqltypes.MakeTestResult(fields, "1|a", "2|b").Rows),
+	}, &binlogdatapb.VStreamRowsResponse{
+		Heartbeat: true,
+	})
+	addExportQueryService(tablets[4], &binlogdatapb.VStreamRowsResponse{
+		Fields: fields,
+		Gtid:   pos2,
+		Rows:   sqltypes.RowsToProto3(sqltypes.MakeTestResult(fields, "9|z").Rows),
+	})
+
+	responses, err := export(&vtctldatapb.ExportRequest{Keyspace: "ks", Tables: []string{"t1"}})
+	require.NoError(t, err)
+	require.Len(t, responses, 4)
+
+	// The rows are read from the RDONLY tablet of a shard when it has one.
+	var shardRows = map[string]int{}
+	for _, resp := range responses[:3] {
+		assert.Equal(t, "t1", resp.Table)
+		if resp.TableDefinition != nil {
+			assert.Equal(t, t1.Schema, resp.TableDefinition.Schema)
+			assert.Len(t, resp.Fields, 2)
+		}
+		shardRows[resp.Shard] += len(resp.Rows)
+	}
+	assert.Equal(t, map[string]int{"-80": 2, "80-": 1}, shardRows)
+	utils.MustMatch(t, &binlogdatapb.VGtid{ShardGtids: []*binlogdatapb.ShardGtid{
+		{Keyspace: "ks", Shard: "-80", Gtid: pos1},
+		{Keyspace: "ks", Shard: "80-", Gtid: pos2},
+	}}, responses[3].Vgtid)
+
+	_, err = export(&vtctldatapb.ExportRequest{Keyspace: "ks", Tables: []string{"t2"}})
+	assert.ErrorContains(t, err, "table t2 not found")
+
+	_, err = export(&vtctldatapb.ExportRequest{Keyspace: "ks", Shards: []string{"80-"}, TabletTypes: []topodatapb.TabletType{topodatapb.TabletType_RDONLY}})
+	assert.ErrorContains(t, err, "no rdonly tablet available for export in shard ks/80-")
+
+	_, err = export(&vtctldatapb.ExportRequest{Keyspace: "ks", TabletTypes: []topodatapb.TabletType{topodatapb.TabletType_PRIMARY}})
+	assert.ErrorContains(t, err, "cannot export from a primary tablet")
+
+	// The export fails when the snapshot is not taken at the position the
+	// replication of the tablet is stopped at.
+	addExportQueryService(tablets[2], &binlogdatapb.VStreamRowsResponse{
+		Fields: fields,
+		Gtid:   pos2,
+	})
+	_, err = export(&vtctldatapb.ExportRequest{Keyspace: "ks", Shards: []string{"-80"}})
+	assert.ErrorContains(t, err, "was its replication restarted?")
+}
+
 func TestFindAllShardsInKeyspace(t *testing.T) {
 	t.Parallel()
 
@@ -6851,6 +7147,60 @@ func TestGetTablets(t *testing.T) {
 	}
 }
 
+func TestGetUnresolvedTransactions(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ts := memorytopo.NewServer(ctx, "zone1")
+	vtctld := testutil.NewVtctldServerWithTabletManagerClient(t, ts, nil, func(ts *topo.Server) vtctlservicepb.VtctldServer {
+		return NewVtctldServer(ts)
+	})
+
+	tablets := []*topodatapb.Tablet{{
+		Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 9100},
+		Keyspace: "unresolved",
+		Shard:    "-80",
+		Type:     topodatapb.TabletType_PRIMARY,
+	}, {
+		Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 9200},
+		Keyspace: "unresolved",
+		Shard:    "80-",
+		Type:     topodatapb.TabletType_PRIMARY,
+	}}
+	testutil.AddTablets(ctx, t, ts, &testutil.AddTabletOptions{AlsoSetShardPrimary: true}, tablets...)
+	sbc0 := addTestQueryService(t, tablets[0])
+	sbc1 := addTestQueryService(t, tablets[1])
+
+	sbc0.UnresolvedTransactionsResult = []*querypb.TransactionMetadata{{
+		Dtid:  "unresolved:-80:2",
+		State: querypb.TransactionState_COMMIT,
+	}}
+	sbc1.UnresolvedTransactionsResult = []*querypb.TransactionMetadata{{
+		Dtid:  "unresolved:80-:1",
+		State: querypb.TransactionState_PREPARE,
+	}}
+
+	resp, err := vtctld.GetUnresolvedTransactions(ctx, &vtctldatapb.GetUnresolvedTransactionsRequest{
+		Keyspace:   "unresolved",
+		AbandonAge: 60,
+	})
+	require.NoError(t, err)
+	want := []*querypb.TransactionMetadata{{
+		Dtid:  "unresolved:-80:2",
+		State: querypb.TransactionState_COMMIT,
+	}, {
+		Dtid:  "unresolved:80-:1",
+		State: querypb.TransactionState_PREPARE,
+	}}
+	utils.MustMatch(t, want, resp.Transactions)
+
+	_, err = vtctld.GetUnresolvedTransactions(ctx, &vtctldatapb.GetUnresolvedTransactionsRequest{
+		Keyspace: "unknown",
+	})
+	assert.Error(t, err)
+}
+
 func TestGetTopologyPath(t *testing.T) {
 	t.Parallel()
 
@@ -6989,6 +7339,102 @@ func TestGetVSchema(t *testing.T) {
 	})
 }
 
+func TestGetVStreamSubscriptions(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ts := memorytopo.NewServer(ctx, "zone1")
+
+	tablets := []*topodatapb.Tablet{{
+		Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 100},
+		Keyspace: "testkeyspace",
+		Shard:    "-80",
+		Type:     topodatapb.TabletType_PRIMARY,
+	}, {
+		Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 200},
+		Keyspace: "testkeyspace",
+		Shard:    "80-",
+		Type:     topodatapb.TabletType_PRIMARY,
+	}}
+	testutil.AddTablets(ctx, t, ts, &testutil.AddTabletOptions{AlsoSetShardPrimary: true}, tablets...)
+
+	fields := sqltypes.MakeTestFields("name|pos|time_updated", "varbinary|varbinary|int64")
+	tmc := &testutil.TabletManagerClient{
+		ExecuteFetchAsDbaResults: map[string]struct {
+			Response *querypb.QueryResult
+			Error    error
+		}{
+			"zone1-0000000100": {
+				Response: sqltypes.ResultToProto3(sqltypes.MakeTestResult(fields,
+					"orders|MySQL56/89ab1ee5-1be6-11ee-9a4b-0242ac110002:1-90|1700000000",
+					"payments|MySQL56/89ab1ee5-1be6-11ee-9a4b-0242ac110002:1-100|1700000060",
+				)),
+			},
+			"zone1-0000000200": {
+				Response: sqltypes.ResultToProto3(sqltypes.MakeTestResult(fields,
+					"orders|FilePos/binlog.000001:4|1700000030",
+				)),
+			},
+		},
+		PrimaryPositionResults: map[string]struct {
+			Position string
+			Error    error
+		}{
+			"zone1-0000000100": {
+				Position: "MySQL56/89ab1ee5-1be6-11ee-9a4b-0242ac110002:1-100",
+			},
+			"zone1-0000000200": {
+				Position: "FilePos/binlog.000001:400",
+			},
+		},
+	}
+	vtctld := testutil.NewVtctldServerWithTabletManagerClient(t, ts, tmc, func(ts *topo.Server) vtctlservicepb.VtctldServer {
+		return NewVtctldServer(ts)
+	})
+
+	resp, err := vtctld.GetVStreamSubscriptions(ctx, &vtctldatapb.GetVStreamSubscriptionsRequest{
+		Keyspace: "testkeyspace",
+	})
+	require.NoError(t, err)
+	want := []*vtctldatapb.VStreamSubscription{{
+		Name:     "orders",
+		Keyspace: "testkeyspace",
+		Shards: []*vtctldatapb.VStreamSubscriptionShard{{
+			Shard:              "-80",
+			Position:           "MySQL56/89ab1ee5-1be6-11ee-9a4b-0242ac110002:1-90",
+			PrimaryPosition:    "MySQL56/89ab1ee5-1be6-11ee-9a4b-0242ac110002:1-100",
+			TransactionsBehind: 10,
+			TimeUpdated:        protoutil.TimeToProto(time.Unix(1700000000, 0)),
+		}, {
+			Shard:              "80-",
+			Position:           "FilePos/binlog.000001:4",
+			PrimaryPosition:    "FilePos/binlog.000001:400",
+			TransactionsBehind: -1,
+			TimeUpdated:        protoutil.TimeToProto(time.Unix(1700000030, 0)),
+		}},
+	}, {
+		Name:     "payments",
+		Keyspace: "testkeyspace",
+		Shards: []*vtctldatapb.VStreamSubscriptionShard{{
+			Shard:              "-80",
+			Position:           "MySQL56/89ab1ee5-1be6-11ee-9a4b-0242ac110002:1-100",
+			PrimaryPosition:    "MySQL56/89ab1ee5-1be6-11ee-9a4b-0242ac110002:1-100",
+			TransactionsBehind: 0,
+			TimeUpdated:        protoutil.TimeToProto(time.Unix(1700000060, 0)),
+		}},
+	}}
+	utils.MustMatch(t, want, resp.Subscriptions)
+
+	_, err = vtctld.GetVStreamSubscriptions(ctx, &vtctldatapb.GetVStreamSubscriptionsRequest{})
+	assert.Error(t, err)
+
+	_, err = vtctld.GetVStreamSubscriptions(ctx, &vtctldatapb.GetVStreamSubscriptionsRequest{
+		Keyspace: "unknown",
+	})
+	assert.Error(t, err)
+}
+
 func TestLaunchSchemaMigration(t *testing.T) {
 	t.Parallel()
 
@@ -9377,6 +9823,62 @@ func TestReparentTablet(t *testing.T) {
 	}
 }
 
+func TestResetVStreamSubscription(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ts := memorytopo.NewServer(ctx, "zone1")
+
+	tablets := []*topodatapb.Tablet{{
+		Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 100},
+		Keyspace: "testkeyspace",
+		Shard:    "-80",
+		Type:     topodatapb.TabletType_PRIMARY,
+	}, {
+		Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 200},
+		Keyspace: "testkeyspace",
+		Shard:    "80-",
+		Type:     topodatapb.TabletType_PRIMARY,
+	}}
+	testutil.AddTablets(ctx, t, ts, &testutil.AddTabletOptions{AlsoSetShardPrimary: true}, tablets...)
+
+	tmc := &testutil.TabletManagerClient{
+		ExecuteFetchAsDbaResults: map[string]struct {
+			Response *querypb.QueryResult
+			Error    error
+		}{
+			"zone1-0000000100": {
+				Response: &querypb.QueryResult{RowsAffected: 1},
+			},
+			"zone1-0000000200": {
+				Error: assert.AnError,
+			},
+		},
+	}
+	vtctld := testutil.NewVtctldServerWithTabletManagerClient(t, ts, tmc, func(ts *topo.Server) vtctlservicepb.VtctldServer {
+		return NewVtctldServer(ts)
+	})
+
+	_, err := vtctld.ResetVStreamSubscription(ctx, &vtctldatapb.ResetVStreamSubscriptionRequest{
+		Keyspace: "testkeyspace",
+		Name:     "orders",
+		Shards:   []string{"-80"},
+	})
+	require.NoError(t, err)
+
+	_, err = vtctld.ResetVStreamSubscription(ctx, &vtctldatapb.ResetVStreamSubscriptionRequest{
+		Keyspace: "testkeyspace",
+		Name:     "orders",
+	})
+	assert.ErrorContains(t, err, "testkeyspace/80-")
+
+	_, err = vtctld.ResetVStreamSubscription(ctx, &vtctldatapb.ResetVStreamSubscriptionRequest{
+		Keyspace: "testkeyspace",
+	})
+	assert.Error(t, err)
+}
+
 func TestRestoreFromBackup(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
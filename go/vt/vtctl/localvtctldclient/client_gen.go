@@ -181,6 +181,11 @@ func (client *localVtctldClient) CompleteSchemaMigration(ctx context.Context, in
 	return client.s.CompleteSchemaMigration(ctx, in)
 }
 
+// ConcludeTransaction is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) ConcludeTransaction(ctx context.Context, in *vtctldatapb.ConcludeTransactionRequest, opts ...grpc.CallOption) (*vtctldatapb.ConcludeTransactionResponse, error) {
+	return client.s.ConcludeTransaction(ctx, in)
+}
+
 // CreateKeyspace is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) CreateKeyspace(ctx context.Context, in *vtctldatapb.CreateKeyspaceRequest, opts ...grpc.CallOption) (*vtctldatapb.CreateKeyspaceResponse, error) {
 	return client.s.CreateKeyspace(ctx, in)
@@ -241,6 +246,57 @@ func (client *localVtctldClient) ExecuteHook(ctx context.Context, in *vtctldatap
 	return client.s.ExecuteHook(ctx, in)
 }
 
+type exportStreamAdapter struct {
+	*grpcshim.BidiStream
+	ch chan *vtctldatapb.ExportResponse
+}
+
+func (stream *exportStreamAdapter) Recv() (*vtctldatapb.ExportResponse, error) {
+	select {
+	case <-stream.Context().Done():
+		return nil, stream.Context().Err()
+	case <-stream.Closed():
+		// Stream has been closed for future sends. If there are messages that
+		// have already been sent, receive them until there are no more. After
+		// all sent messages have been received, Recv will return the CloseErr.
+		select {
+		case msg := <-stream.ch:
+			return msg, nil
+		default:
+			return nil, stream.CloseErr()
+		}
+	case err := <-stream.ErrCh:
+		return nil, err
+	case msg := <-stream.ch:
+		return msg, nil
+	}
+}
+
+func (stream *exportStreamAdapter) Send(msg *vtctldatapb.ExportResponse) error {
+	select {
+	case <-stream.Context().Done():
+		return stream.Context().Err()
+	case <-stream.Closed():
+		return grpcshim.ErrStreamClosed
+	case stream.ch <- msg:
+		return nil
+	}
+}
+
+// Export is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) Export(ctx context.Context, in *vtctldatapb.ExportRequest, opts ...grpc.CallOption) (vtctlservicepb.Vtctld_ExportClient, error) {
+	stream := &exportStreamAdapter{
+		BidiStream: grpcshim.NewBidiStream(ctx),
+		ch:         make(chan *vtctldatapb.ExportResponse, 1),
+	}
+	go func() {
+		err := client.s.Export(in, stream)
+		stream.CloseWithError(err)
+	}()
+
+	return stream, nil
+}
+
 // FindAllShardsInKeyspace is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) FindAllShardsInKeyspace(ctx context.Context, in *vtctldatapb.FindAllShardsInKeyspaceRequest, opts ...grpc.CallOption) (*vtctldatapb.FindAllShardsInKeyspaceResponse, error) {
 	return client.s.FindAllShardsInKeyspace(ctx, in)
@@ -346,11 +402,21 @@ func (client *localVtctldClient) GetTopologyPath(ctx context.Context, in *vtctld
 	return client.s.GetTopologyPath(ctx, in)
 }
 
+// GetUnresolvedTransactions is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) GetUnresolvedTransactions(ctx context.Context, in *vtctldatapb.GetUnresolvedTransactionsRequest, opts ...grpc.CallOption) (*vtctldatapb.GetUnresolvedTransactionsResponse, error) {
+	return client.s.GetUnresolvedTransactions(ctx, in)
+}
+
 // GetVSchema is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) GetVSchema(ctx context.Context, in *vtctldatapb.GetVSchemaRequest, opts ...grpc.CallOption) (*vtctldatapb.GetVSchemaResponse, error) {
 	return client.s.GetVSchema(ctx, in)
 }
 
+// GetVStreamSubscriptions is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) GetVStreamSubscriptions(ctx context.Context, in *vtctldatapb.GetVStreamSubscriptionsRequest, opts ...grpc.CallOption) (*vtctldatapb.GetVStreamSubscriptionsResponse, error) {
+	return client.s.GetVStreamSubscriptions(ctx, in)
+}
+
 // GetVersion is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) GetVersion(ctx context.Context, in *vtctldatapb.GetVersionRequest, opts ...grpc.CallOption) (*vtctldatapb.GetVersionResponse, error) {
 	return client.s.GetVersion(ctx, in)
@@ -486,6 +552,11 @@ func (client *localVtctldClient) ReparentTablet(ctx context.Context, in *vtctlda
 	return client.s.ReparentTablet(ctx, in)
 }
 
+// ResetVStreamSubscription is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) ResetVStreamSubscription(ctx context.Context, in *vtctldatapb.ResetVStreamSubscriptionRequest, opts ...grpc.CallOption) (*vtctldatapb.ResetVStreamSubscriptionResponse, error) {
+	return client.s.ResetVStreamSubscription(ctx, in)
+}
+
 // ReshardCreate is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) ReshardCreate(ctx context.Context, in *vtctldatapb.ReshardCreateRequest, opts ...grpc.CallOption) (*vtctldatapb.WorkflowStatusResponse, error) {
 	return client.s.ReshardCreate(ctx, in)
@@ -647,6 +718,11 @@ func (client *localVtctldClient) VDiffDelete(ctx context.Context, in *vtctldatap
 	return client.s.VDiffDelete(ctx, in)
 }
 
+// VDiffRepair is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) VDiffRepair(ctx context.Context, in *vtctldatapb.VDiffRepairRequest, opts ...grpc.CallOption) (*vtctldatapb.VDiffRepairResponse, error) {
+	return client.s.VDiffRepair(ctx, in)
+}
+
 // VDiffResume is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) VDiffResume(ctx context.Context, in *vtctldatapb.VDiffResumeRequest, opts ...grpc.CallOption) (*vtctldatapb.VDiffResumeResponse, error) {
 	return client.s.VDiffResume(ctx, in)
@@ -697,6 +773,16 @@ func (client *localVtctldClient) ValidateVersionShard(ctx context.Context, in *v
 	return client.s.ValidateVersionShard(ctx, in)
 }
 
+// VindexSwapComplete is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) VindexSwapComplete(ctx context.Context, in *vtctldatapb.VindexSwapCompleteRequest, opts ...grpc.CallOption) (*vtctldatapb.VindexSwapCompleteResponse, error) {
+	return client.s.VindexSwapComplete(ctx, in)
+}
+
+// VindexSwapCreate is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) VindexSwapCreate(ctx context.Context, in *vtctldatapb.VindexSwapCreateRequest, opts ...grpc.CallOption) (*vtctldatapb.VindexSwapCreateResponse, error) {
+	return client.s.VindexSwapCreate(ctx, in)
+}
+
 // WorkflowDelete is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) WorkflowDelete(ctx context.Context, in *vtctldatapb.WorkflowDeleteRequest, opts ...grpc.CallOption) (*vtctldatapb.WorkflowDeleteResponse, error) {
 	return client.s.WorkflowDelete(ctx, in)
@@ -62,6 +62,8 @@ type materializer struct {
 	isPartial             bool
 	primaryVindexesDiffer bool
 	workflowType          binlogdatapb.VReplicationWorkflowType
+	// transforms are the transforms of the tables, by target table name.
+	transforms map[string]*tableTransform
 }
 
 func (mz *materializer) getWorkflowSubType() (binlogdatapb.VReplicationWorkflowSubType, error) {
@@ -164,27 +166,33 @@ func (mz *materializer) generateInserts(ctx context.Context, sourceShards []*top
 			SourceTimeZone:  mz.ms.SourceTimeZone,
 			TargetTimeZone:  mz.ms.TargetTimeZone,
 			OnDdl:           binlogdatapb.OnDDLAction(binlogdatapb.OnDDLAction_value[mz.ms.OnDdl]),
+			Sink:            mz.ms.Sink,
 		}
 		for _, ts := range mz.ms.TableSettings {
 			rule := &binlogdatapb.Rule{
 				Match: ts.TargetTable,
 			}
 
-			if ts.SourceExpression == "" {
+			sourceExpression := ts.SourceExpression
+			if tt := mz.transforms[ts.TargetTable]; tt != nil {
+				sourceExpression = tt.filter
+				rule.ConvertCharset = tt.convertCharset
+			}
+			if sourceExpression == "" {
 				bls.Filter.Rules = append(bls.Filter.Rules, rule)
 				continue
 			}
 
 			// Validate non-empty query.
-			stmt, err := sqlparser.Parse(ts.SourceExpression)
+			stmt, err := sqlparser.Parse(sourceExpression)
 			if err != nil {
 				return "", err
 			}
 			sel, ok := stmt.(*sqlparser.Select)
 			if !ok {
-				return "", fmt.Errorf("unrecognized statement: %s", ts.SourceExpression)
+				return "", fmt.Errorf("unrecognized statement: %s", sourceExpression)
 			}
-			filter := ts.SourceExpression
+			filter := sourceExpression
 			if mz.targetVSchema.Keyspace.Sharded && mz.targetVSchema.Tables[ts.TargetTable].Type != vindexes.TypeReference {
 				cv, err := vindexes.FindBestColVindex(mz.targetVSchema.Tables[ts.TargetTable])
 				if err != nil {
@@ -263,27 +271,33 @@ func (mz *materializer) generateBinlogSources(ctx context.Context, targetShard *
 			SourceTimeZone:  mz.ms.SourceTimeZone,
 			TargetTimeZone:  mz.ms.TargetTimeZone,
 			OnDdl:           binlogdatapb.OnDDLAction(binlogdatapb.OnDDLAction_value[mz.ms.OnDdl]),
+			Sink:            mz.ms.Sink,
 		}
 		for _, ts := range mz.ms.TableSettings {
 			rule := &binlogdatapb.Rule{
 				Match: ts.TargetTable,
 			}
 
-			if ts.SourceExpression == "" {
+			sourceExpression := ts.SourceExpression
+			if tt := mz.transforms[ts.TargetTable]; tt != nil {
+				sourceExpression = tt.filter
+				rule.ConvertCharset = tt.convertCharset
+			}
+			if sourceExpression == "" {
 				bls.Filter.Rules = append(bls.Filter.Rules, rule)
 				continue
 			}
 
 			// Validate non-empty query.
-			stmt, err := sqlparser.Parse(ts.SourceExpression)
+			stmt, err := sqlparser.Parse(sourceExpression)
 			if err != nil {
 				return nil, err
 			}
 			sel, ok := stmt.(*sqlparser.Select)
 			if !ok {
-				return nil, fmt.Errorf("unrecognized statement: %s", ts.SourceExpression)
+				return nil, fmt.Errorf("unrecognized statement: %s", sourceExpression)
 			}
-			filter := ts.SourceExpression
+			filter := sourceExpression
 			if mz.targetVSchema.Keyspace.Sharded && mz.targetVSchema.Tables[ts.TargetTable].Type != vindexes.TypeReference {
 				cv, err := vindexes.FindBestColVindex(mz.targetVSchema.Tables[ts.TargetTable])
 				if err != nil {
@@ -399,6 +413,9 @@ func (mz *materializer) deploySchema() error {
 				if !ok {
 					return fmt.Errorf("source table %v does not exist", ts.TargetTable)
 				}
+				if tt := mz.transforms[ts.TargetTable]; tt != nil {
+					ddl = tt.createDDL
+				}
 
 				if createDDL == createDDLAsCopyDropConstraint {
 					strippedDDL, err := stripTableConstraints(ddl)
@@ -521,6 +538,43 @@ func (mz *materializer) buildMaterializer() error {
 	mz.targetShards = targetShards
 	mz.isPartial = isPartial
 	mz.primaryVindexesDiffer = differentPVs
+	return mz.buildTransforms()
+}
+
+// buildTransforms builds the transforms of the tables for the schema of the
+// source tables.
+func (mz *materializer) buildTransforms() error {
+	var sourceDDLs map[string]string
+	for _, ts := range mz.ms.TableSettings {
+		if ts.Transform == nil {
+			continue
+		}
+		if sourceDDLs == nil {
+			var err error
+			sourceDDLs, err = getSourceTableDDLs(mz.ctx, mz.sourceTs, mz.tmc, mz.sourceShards)
+			if err != nil {
+				return err
+			}
+			mz.transforms = make(map[string]*tableTransform)
+		}
+		sourceTable := ts.TargetTable
+		if ts.SourceExpression != "" {
+			tableName, err := sqlparser.TableFromStatement(ts.SourceExpression)
+			if err != nil {
+				return err
+			}
+			sourceTable = tableName.Name.String()
+		}
+		ddl, ok := sourceDDLs[sourceTable]
+		if !ok {
+			return fmt.Errorf("source table %v does not exist", sourceTable)
+		}
+		tt, err := buildTableTransform(ddl, ts.SourceExpression, ts.Transform)
+		if err != nil {
+			return vterrors.Wrapf(err, "invalid transform of table %s", ts.TargetTable)
+		}
+		mz.transforms[ts.TargetTable] = tt
+	}
 	return nil
 }
 
@@ -2769,6 +2769,53 @@ func TestMaterializerCopySchema(t *testing.T) {
 
 }
 
+func TestMaterializerTransform(t *testing.T) {
+	ms := &vtctldatapb.MaterializeSettings{
+		Workflow:       "workflow",
+		SourceKeyspace: "sourceks",
+		TargetKeyspace: "targetks",
+		TableSettings: []*vtctldatapb.TableMaterializeSettings{{
+			TargetTable:      "t1",
+			SourceExpression: "select * from t1",
+			CreateDdl:        "copy",
+			Transform: &vtctldatapb.TableTransform{
+				RenameColumns:  map[string]string{"c2": "c3"},
+				ConvertColumns: map[string]string{"c2": "varchar(64) character set utf8mb4"},
+				AddColumns:     []*vtctldatapb.ComputedColumn{{Name: "c4", Type: "bigint", Expression: "c1 * 2"}},
+			},
+		}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	env := newTestMaterializerEnv(t, ctx, ms, []string{"0"}, []string{"0"})
+	defer env.close()
+
+	env.tmc.schema["sourceks.t1"] = &tabletmanagerdatapb.SchemaDefinition{
+		TableDefinitions: []*tabletmanagerdatapb.TableDefinition{{
+			Name:   "t1",
+			Schema: "create table t1 (c1 int not null primary key, c2 varchar(64) character set latin1)",
+		}},
+	}
+	delete(env.tmc.schema, "targetks.t1")
+
+	env.tmc.expectVRQuery(200, mzSelectFrozenQuery, &sqltypes.Result{})
+	env.tmc.expectVRQuery(200, "create table t1 (\n\tc1 int not null primary key,\n\tc3 varchar(64) character set utf8mb4,\n\tc4 bigint\n)", &sqltypes.Result{})
+	env.tmc.expectVRQuery(
+		200,
+		insertPrefix+
+			`\('workflow', 'keyspace:\\"sourceks\\" shard:\\"0\\" filter:{rules:{match:\\"t1\\" filter:\\"select c1, convert\(c2 using utf8mb4\) as c3, c1 \* 2 as c4 from t1\\" `+
+			`convert_charset:{key:\\"c3\\" value:{from_charset:\\"latin1\\" to_charset:\\"utf8mb4\\"}}}}', '', [0-9]*, [0-9]*, '', '', [0-9]*, 0, 'Stopped', 'vt_targetks', 0, 0, false\)`+
+			eol,
+		&sqltypes.Result{},
+	)
+	env.tmc.expectVRQuery(200, mzUpdateQuery, &sqltypes.Result{})
+
+	err := env.ws.Materialize(ctx, ms)
+	require.NoError(t, err)
+	env.tmc.verifyQueries(t)
+}
+
 func TestMaterializerExplicitColumns(t *testing.T) {
 	ms := &vtctldatapb.MaterializeSettings{
 		Workflow:       "workflow",
@@ -1224,6 +1224,11 @@ func (s *Server) moveTablesCreate(ctx context.Context, req *vtctldatapb.MoveTabl
 		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "no tables to move")
 	}
 	log.Infof("Found tables to move: %s", strings.Join(tables, ","))
+	for table := range req.TableTransforms {
+		if !slices.Contains(tables, table) {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "table %s has a transform but is not moved", table)
+		}
+	}
 
 	if !vschema.Sharded {
 		// Save the original in case we need to restore it for a late failure
@@ -1265,6 +1270,7 @@ func (s *Server) moveTablesCreate(ctx context.Context, req *vtctldatapb.MoveTabl
 			TargetTable:      table,
 			SourceExpression: buf.String(),
 			CreateDdl:        createDDLMode,
+			Transform:        req.TableTransforms[table],
 		})
 	}
 	mz := &materializer{
@@ -1530,10 +1536,14 @@ func (s *Server) VDiffCreate(ctx context.Context, req *vtctldatapb.VDiffCreateRe
 			TimeoutSeconds:        req.FilteredReplicationWaitTime.Seconds,
 			MaxExtraRowsToCompare: req.MaxExtraRowsToCompare,
 			UpdateTableStats:      req.UpdateTableStats,
+			ChunkRows:             req.ChunkRows,
+			MaxParallelChunks:     req.MaxParallelChunks,
 		},
 		ReportOptions: &tabletmanagerdatapb.VDiffReportOptions{
-			OnlyPks:    req.OnlyPKs,
-			DebugQuery: req.DebugQuery,
+			OnlyPks:          req.OnlyPKs,
+			DebugQuery:       req.DebugQuery,
+			RecordMismatches: req.RecordMismatches,
+			MismatchesDir:    req.MismatchesDir,
 		},
 	}
 
@@ -1601,6 +1611,48 @@ func (s *Server) VDiffDelete(ctx context.Context, req *vtctldatapb.VDiffDeleteRe
 	return &vtctldatapb.VDiffDeleteResponse{}, nil
 }
 
+// VDiffRepair is part of the vtctlservicepb.VtctldServer interface.
+func (s *Server) VDiffRepair(ctx context.Context, req *vtctldatapb.VDiffRepairRequest) (*vtctldatapb.VDiffRepairResponse, error) {
+	span, ctx := trace.NewSpan(ctx, "workflow.Server.VDiffRepair")
+	defer span.Finish()
+
+	span.Annotate("keyspace", req.TargetKeyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("uuid", req.Uuid)
+
+	tabletreq := &tabletmanagerdatapb.VDiffRequest{
+		Keyspace:  req.TargetKeyspace,
+		Workflow:  req.Workflow,
+		Action:    string(vdiff.RepairAction),
+		VdiffUuid: req.Uuid,
+	}
+
+	ts, err := s.buildTrafficSwitcher(ctx, req.TargetKeyspace, req.Workflow)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &vdiffOutput{
+		responses: make(map[string]*tabletmanagerdatapb.VDiffResponse, len(ts.targets)),
+		err:       nil,
+	}
+	output.err = ts.ForAllTargets(func(target *MigrationTarget) error {
+		resp, err := s.tmc.VDiff(ctx, target.GetPrimary().Tablet, tabletreq)
+		output.mu.Lock()
+		defer output.mu.Unlock()
+		output.responses[target.GetShard().ShardName()] = resp
+		return err
+	})
+	if output.err != nil {
+		log.Errorf("Error executing vdiff repair action: %v", output.err)
+		return nil, output.err
+	}
+
+	return &vtctldatapb.VDiffRepairResponse{
+		TabletResponses: output.responses,
+	}, nil
+}
+
 // VDiffResume is part of the vtctlservicepb.VtctldServer interface.
 func (s *Server) VDiffResume(ctx context.Context, req *vtctldatapb.VDiffResumeRequest) (*vtctldatapb.VDiffResumeResponse, error) {
 	span, ctx := trace.NewSpan(ctx, "workflow.Server.VDiffResume")
@@ -845,12 +845,26 @@ func (ts *trafficSwitcher) createReverseVReplication(ctx context.Context) error
 				reverseBls.Filter.Rules = append(reverseBls.Filter.Rules, rule)
 				continue
 			}
-			var filter string
+			var (
+				filter         string
+				convertCharset map[string]*binlogdatapb.CharsetConversion
+			)
 			if strings.HasPrefix(rule.Match, "/") {
 				if ts.SourceKeyspaceSchema().Keyspace.Sharded {
 					filter = key.KeyRangeString(source.GetShard().KeyRange)
 				}
 			} else {
+				// A transformed table is copied back with the inverse of its
+				// column renames and charset conversions.
+				selectExprs, reverseCharset, targetNames, err := reverseTransform(rule)
+				if err != nil {
+					return vterrors.Wrapf(err, "cannot reverse the filter of table %s", rule.Match)
+				}
+				convertCharset = reverseCharset
+				columns := "*"
+				if selectExprs != nil {
+					columns = sqlparser.String(selectExprs)
+				}
 				var inKeyrange string
 				if ts.SourceKeyspaceSchema().Keyspace.Sharded {
 					vtable, ok := ts.SourceKeyspaceSchema().Tables[rule.Match]
@@ -867,7 +881,16 @@ func (ts *trafficSwitcher) createReverseVReplication(ctx context.Context) error
 						// For non-reference tables we return an error if there's no primary
 						// vindex as it's not clear what to do.
 						if len(vtable.ColumnVindexes) > 0 && len(vtable.ColumnVindexes[0].Columns) > 0 {
-							inKeyrange = fmt.Sprintf(" where in_keyrange(%s, '%s.%s', '%s')", sqlparser.String(vtable.ColumnVindexes[0].Columns[0]),
+							vindexColumn := vtable.ColumnVindexes[0].Columns[0]
+							if selectExprs != nil {
+								targetName, ok := targetNames[vindexColumn.Lowered()]
+								if !ok {
+									return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "the primary vindex column %s of the %s table is not copied to the target",
+										vindexColumn.String(), vtable.Name.String())
+								}
+								vindexColumn = targetName
+							}
+							inKeyrange = fmt.Sprintf(" where in_keyrange(%s, '%s.%s', '%s')", sqlparser.String(vindexColumn),
 								ts.SourceKeyspaceName(), vtable.ColumnVindexes[0].Name, key.KeyRangeString(source.GetShard().KeyRange))
 						} else {
 							return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "no primary vindex found for the %s table in the %s keyspace",
@@ -875,11 +898,12 @@ func (ts *trafficSwitcher) createReverseVReplication(ctx context.Context) error
 						}
 					}
 				}
-				filter = fmt.Sprintf("select * from %s%s", sqlescape.EscapeID(rule.Match), inKeyrange)
+				filter = fmt.Sprintf("select %s from %s%s", columns, sqlescape.EscapeID(rule.Match), inKeyrange)
 			}
 			reverseBls.Filter.Rules = append(reverseBls.Filter.Rules, &binlogdatapb.Rule{
-				Match:  rule.Match,
-				Filter: filter,
+				Match:          rule.Match,
+				Filter:         filter,
+				ConvertCharset: convertCharset,
 			})
 		}
 		log.Infof("Creating reverse workflow vreplication stream on tablet %s: workflow %s, startPos %s",
@@ -0,0 +1,387 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"fmt"
+	"strings"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/mysql/collations/charset"
+	"vitess.io/vitess/go/mysql/collations/colldata"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// tableTransform is a TableTransform built for the schema of its source table.
+// The transform is applied by vreplication through the filter of the stream,
+// which selects the columns of the target table from the source table, so that
+// VDiff compares the tables the same way.
+type tableTransform struct {
+	// filter selects the columns of the target table from the source table.
+	filter string
+	// convertCharset are the charset conversions of the target columns.
+	convertCharset map[string]*binlogdatapb.CharsetConversion
+	// createDDL creates the target table.
+	createDDL string
+}
+
+// buildTableTransform builds a transform for the source table created by
+// sourceDDL. The filter of the transform keeps the WHERE clause of
+// sourceExpression, which must select all the columns of the table.
+func buildTableTransform(sourceDDL, sourceExpression string, transform *vtctldatapb.TableTransform) (*tableTransform, error) {
+	stmt, err := sqlparser.ParseStrictDDL(sourceDDL)
+	if err != nil {
+		return nil, err
+	}
+	sourceTable, ok := stmt.(*sqlparser.CreateTable)
+	if !ok || sourceTable.TableSpec == nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "unexpected table definition: %s", sourceDDL)
+	}
+	tableName := sourceTable.Table.Name.String()
+	targetTable := sqlparser.CloneRefOfCreateTable(sourceTable)
+	spec := targetTable.TableSpec
+
+	columns := make(map[string]*sqlparser.ColumnDefinition, len(spec.Columns))
+	for _, col := range spec.Columns {
+		columns[col.Name.Lowered()] = col
+	}
+	findColumn := func(name string) (*sqlparser.ColumnDefinition, error) {
+		col, ok := columns[strings.ToLower(name)]
+		if !ok {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "column %s not found in table %s", name, tableName)
+		}
+		return col, nil
+	}
+
+	renames := make(map[string]sqlparser.IdentifierCI)
+	for from, to := range transform.RenameColumns {
+		col, err := findColumn(from)
+		if err != nil {
+			return nil, err
+		}
+		renames[col.Name.Lowered()] = sqlparser.NewIdentifierCI(to)
+	}
+	drops := make(map[string]bool)
+	for _, name := range transform.DropColumns {
+		col, err := findColumn(name)
+		if err != nil {
+			return nil, err
+		}
+		drops[col.Name.Lowered()] = true
+	}
+	for _, col := range primaryKeyColumns(spec) {
+		if drops[col] {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "cannot drop column %s of the primary key of table %s", col, tableName)
+		}
+	}
+	conversions := make(map[string]*sqlparser.ColumnType)
+	for name, typ := range transform.ConvertColumns {
+		col, err := findColumn(name)
+		if err != nil {
+			return nil, err
+		}
+		colType, err := parseColumnType(typ)
+		if err != nil {
+			return nil, err
+		}
+		conversions[col.Name.Lowered()] = colType
+	}
+
+	tt := &tableTransform{convertCharset: make(map[string]*binlogdatapb.CharsetConversion)}
+	var selectExprs sqlparser.SelectExprs
+	tableCharset := tableCharset(spec)
+	targetColumns := make(map[string]bool)
+	var targetDefs []*sqlparser.ColumnDefinition
+	for _, col := range spec.Columns {
+		name := col.Name.Lowered()
+		if drops[name] {
+			continue
+		}
+		sourceName := col.Name
+		targetName := col.Name
+		if to, ok := renames[name]; ok {
+			targetName = to
+		}
+		var expr sqlparser.Expr = &sqlparser.ColName{Name: sourceName}
+		if colType, ok := conversions[name]; ok {
+			// The column keeps its attributes, only its type changes.
+			colType.Options = sqlparser.CloneRefOfColumnTypeOptions(col.Type.Options)
+			fromCharset := columnCharset(col.Type, tableCharset)
+			toCharset := columnCharset(colType, tableCharset)
+			if fromCharset != "" && toCharset != "" && !strings.EqualFold(fromCharset, toCharset) {
+				if colType.Options != nil {
+					colType.Options.Collate = ""
+				}
+				if !trivialCharset(fromCharset) || !trivialCharset(toCharset) {
+					tt.convertCharset[targetName.String()] = &binlogdatapb.CharsetConversion{
+						FromCharset: fromCharset,
+						ToCharset:   toCharset,
+					}
+					expr = &sqlparser.ConvertUsingExpr{Expr: expr, Type: "utf8mb4"}
+				}
+			}
+			col.Type = colType
+		}
+		aliased := &sqlparser.AliasedExpr{Expr: expr}
+		if _, ok := expr.(*sqlparser.ColName); !ok || !targetName.Equal(sourceName) {
+			aliased.As = targetName
+		}
+		if targetColumns[targetName.Lowered()] {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "duplicate column %s in the transform of table %s", targetName.String(), tableName)
+		}
+		targetColumns[targetName.Lowered()] = true
+		col.Name = targetName
+		targetDefs = append(targetDefs, col)
+		selectExprs = append(selectExprs, aliased)
+	}
+	for _, added := range transform.AddColumns {
+		if added.Name == "" || added.Expression == "" || added.Type == "" {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "a computed column of table %s needs a name, a type and an expression", tableName)
+		}
+		name := sqlparser.NewIdentifierCI(added.Name)
+		if targetColumns[name.Lowered()] {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "duplicate column %s in the transform of table %s", added.Name, tableName)
+		}
+		targetColumns[name.Lowered()] = true
+		expr, err := sqlparser.ParseExpr(added.Expression)
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "invalid expression of computed column %s", added.Name)
+		}
+		colType, err := parseColumnType(added.Type)
+		if err != nil {
+			return nil, err
+		}
+		targetDefs = append(targetDefs, &sqlparser.ColumnDefinition{Name: name, Type: colType})
+		selectExprs = append(selectExprs, &sqlparser.AliasedExpr{Expr: expr, As: name})
+	}
+	spec.Columns = targetDefs
+
+	// The indexes and foreign keys follow the renames, and the ones on
+	// dropped columns are dropped.
+	targetColumn := func(col sqlparser.IdentifierCI) (sqlparser.IdentifierCI, bool) {
+		if drops[col.Lowered()] {
+			return col, false
+		}
+		if to, ok := renames[col.Lowered()]; ok {
+			return to, true
+		}
+		return col, true
+	}
+	var indexes []*sqlparser.IndexDefinition
+nextIndex:
+	for _, index := range spec.Indexes {
+		for _, indexCol := range index.Columns {
+			if indexCol.Expression != nil {
+				continue
+			}
+			name, ok := targetColumn(indexCol.Column)
+			if !ok {
+				continue nextIndex
+			}
+			indexCol.Column = name
+		}
+		indexes = append(indexes, index)
+	}
+	spec.Indexes = indexes
+	var constraints []*sqlparser.ConstraintDefinition
+nextConstraint:
+	for _, constraint := range spec.Constraints {
+		if fk, ok := constraint.Details.(*sqlparser.ForeignKeyDefinition); ok {
+			for i, col := range fk.Source {
+				name, ok := targetColumn(col)
+				if !ok {
+					continue nextConstraint
+				}
+				fk.Source[i] = name
+			}
+		}
+		constraints = append(constraints, constraint)
+	}
+	spec.Constraints = constraints
+
+	if len(tt.convertCharset) == 0 {
+		tt.convertCharset = nil
+	}
+	tt.createDDL = sqlparser.String(targetTable)
+
+	sel := &sqlparser.Select{
+		From: sqlparser.TableExprs{&sqlparser.AliasedTableExpr{Expr: sqlparser.TableName{Name: sourceTable.Table.Name}}},
+	}
+	if sourceExpression != "" {
+		stmt, err := sqlparser.Parse(sourceExpression)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		sel, ok = stmt.(*sqlparser.Select)
+		if !ok {
+			return nil, fmt.Errorf("unrecognized statement: %s", sourceExpression)
+		}
+		if !selectsAllColumns(sel) {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "the source expression of a transformed table must select all the columns of the table: %s", sourceExpression)
+		}
+	}
+	sel.SelectExprs = selectExprs
+	tt.filter = sqlparser.String(sel)
+	return tt, nil
+}
+
+func selectsAllColumns(sel *sqlparser.Select) bool {
+	if len(sel.SelectExprs) != 1 || len(sel.From) != 1 || sel.GroupBy != nil || sel.Having != nil || sel.Distinct {
+		return false
+	}
+	_, ok := sel.SelectExprs[0].(*sqlparser.StarExpr)
+	return ok
+}
+
+// reverseTransform returns the select expressions of the reverse of a rule,
+// the charset conversions of its columns and the names of the columns of the
+// source table in the target table. It returns nil expressions if the rule
+// does not transform the table. The computed columns are not reversed.
+func reverseTransform(rule *binlogdatapb.Rule) (sqlparser.SelectExprs, map[string]*binlogdatapb.CharsetConversion, map[string]sqlparser.IdentifierCI, error) {
+	if rule.Filter == "" || !strings.HasPrefix(strings.ToLower(strings.TrimSpace(rule.Filter)), "select") {
+		return nil, nil, nil, nil
+	}
+	stmt, err := sqlparser.Parse(rule.Filter)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("unrecognized statement: %s", rule.Filter)
+	}
+	if selectsAllColumns(sel) {
+		return nil, nil, nil, nil
+	}
+	var (
+		selectExprs    sqlparser.SelectExprs
+		convertCharset map[string]*binlogdatapb.CharsetConversion
+		targetNames    = make(map[string]sqlparser.IdentifierCI)
+	)
+	for _, selectExpr := range sel.SelectExprs {
+		aliased, ok := selectExpr.(*sqlparser.AliasedExpr)
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("unsupported select expression: %v", sqlparser.String(selectExpr))
+		}
+		expr := aliased.Expr
+		convert, isConvert := expr.(*sqlparser.ConvertUsingExpr)
+		if isConvert {
+			expr = convert.Expr
+		}
+		sourceCol, ok := expr.(*sqlparser.ColName)
+		if !ok {
+			// A computed column.
+			continue
+		}
+		targetName := sourceCol.Name
+		if !aliased.As.IsEmpty() {
+			targetName = aliased.As
+		}
+		targetNames[sourceCol.Name.Lowered()] = targetName
+		var reversed sqlparser.Expr = &sqlparser.ColName{Name: targetName}
+		if conversion, ok := rule.ConvertCharset[targetName.String()]; ok && isConvert {
+			if convertCharset == nil {
+				convertCharset = make(map[string]*binlogdatapb.CharsetConversion)
+			}
+			convertCharset[sourceCol.Name.String()] = &binlogdatapb.CharsetConversion{
+				FromCharset: conversion.ToCharset,
+				ToCharset:   conversion.FromCharset,
+			}
+			reversed = &sqlparser.ConvertUsingExpr{Expr: reversed, Type: "utf8mb4"}
+		}
+		reversedExpr := &sqlparser.AliasedExpr{Expr: reversed}
+		if isConvert || !targetName.Equal(sourceCol.Name) {
+			reversedExpr.As = sourceCol.Name
+		}
+		selectExprs = append(selectExprs, reversedExpr)
+	}
+	return selectExprs, convertCharset, targetNames, nil
+}
+
+func primaryKeyColumns(spec *sqlparser.TableSpec) []string {
+	var cols []string
+	for _, col := range spec.Columns {
+		if col.Type.Options != nil && col.Type.Options.KeyOpt == sqlparser.ColKeyPrimary {
+			cols = append(cols, col.Name.Lowered())
+		}
+	}
+	for _, index := range spec.Indexes {
+		if index.Info.Type != sqlparser.IndexTypePrimary {
+			continue
+		}
+		for _, col := range index.Columns {
+			if col.Expression == nil {
+				cols = append(cols, col.Column.Lowered())
+			}
+		}
+	}
+	return cols
+}
+
+// parseColumnType parses a column type like "varchar(64) character set utf8mb4".
+func parseColumnType(typ string) (*sqlparser.ColumnType, error) {
+	stmt, err := sqlparser.ParseStrictDDL(fmt.Sprintf("create table t (c %s)", typ))
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "invalid column type %q", typ)
+	}
+	create, ok := stmt.(*sqlparser.CreateTable)
+	if !ok || len(create.TableSpec.Columns) != 1 {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid column type %q", typ)
+	}
+	return create.TableSpec.Columns[0].Type, nil
+}
+
+func tableCharset(spec *sqlparser.TableSpec) string {
+	for _, option := range spec.Options {
+		switch strings.ToLower(option.Name) {
+		case "charset", "character set", "default charset", "default character set":
+			return option.String
+		}
+	}
+	return ""
+}
+
+// columnCharset returns the charset of a textual column, or an empty string
+// for the other columns.
+func columnCharset(colType *sqlparser.ColumnType, tableCharset string) string {
+	if !sqltypes.IsText(colType.SQLType()) {
+		return ""
+	}
+	if colType.Charset.Name != "" {
+		return colType.Charset.Name
+	}
+	if tableCharset != "" {
+		return tableCharset
+	}
+	return "utf8mb4"
+}
+
+// trivialCharset returns true for the charsets vreplication needs no conversion for.
+func trivialCharset(cs string) bool {
+	c := collations.Local().DefaultCollationForCharset(cs)
+	if c == collations.Unknown {
+		return true
+	}
+	utf8mb4Charset := charset.Charset_utf8mb4{}
+	return utf8mb4Charset.IsSuperset(colldata.Lookup(c).Charset()) || c == collations.CollationBinaryID
+}
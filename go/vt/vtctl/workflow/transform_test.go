@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+func TestBuildTableTransform(t *testing.T) {
+	sourceDDL := "create table t1 (id int not null, email varchar(128), name varchar(64) character set latin1, legacy_id int, price int, qty int, primary key (id), key email_idx (email), key legacy_idx (legacy_id)) default charset=utf8mb4"
+	testcases := []struct {
+		name             string
+		sourceExpression string
+		transform        *vtctldatapb.TableTransform
+		wantFilter       string
+		wantCreateDDL    string
+		wantCharset      map[string]*binlogdatapb.CharsetConversion
+		wantErr          string
+	}{{
+		name:          "rename",
+		transform:     &vtctldatapb.TableTransform{RenameColumns: map[string]string{"email": "email_address"}},
+		wantFilter:    "select id, email as email_address, `name`, legacy_id, price, qty from t1",
+		wantCreateDDL: "create table t1 (\n\tid int not null,\n\temail_address varchar(128),\n\t`name` varchar(64) character set latin1,\n\tlegacy_id int,\n\tprice int,\n\tqty int,\n\tprimary key (id),\n\tindex email_idx (email_address),\n\tindex legacy_idx (legacy_id)\n) charset utf8mb4",
+	}, {
+		name:             "drop and keep the where clause",
+		sourceExpression: "select * from t1 where in_keyrange(id, 'ks.hash', '-80')",
+		transform:        &vtctldatapb.TableTransform{DropColumns: []string{"legacy_id"}},
+		wantFilter:       "select id, email, `name`, price, qty from t1 where in_keyrange(id, 'ks.hash', '-80')",
+		wantCreateDDL:    "create table t1 (\n\tid int not null,\n\temail varchar(128),\n\t`name` varchar(64) character set latin1,\n\tprice int,\n\tqty int,\n\tprimary key (id),\n\tindex email_idx (email)\n) charset utf8mb4",
+	}, {
+		name: "computed column",
+		transform: &vtctldatapb.TableTransform{AddColumns: []*vtctldatapb.ComputedColumn{{
+			Name:       "total",
+			Type:       "bigint",
+			Expression: "price * qty",
+		}}},
+		wantFilter:    "select id, email, `name`, legacy_id, price, qty, price * qty as total from t1",
+		wantCreateDDL: "create table t1 (\n\tid int not null,\n\temail varchar(128),\n\t`name` varchar(64) character set latin1,\n\tlegacy_id int,\n\tprice int,\n\tqty int,\n\ttotal bigint,\n\tprimary key (id),\n\tindex email_idx (email),\n\tindex legacy_idx (legacy_id)\n) charset utf8mb4",
+	}, {
+		name:          "int to bigint",
+		transform:     &vtctldatapb.TableTransform{ConvertColumns: map[string]string{"id": "bigint unsigned"}},
+		wantFilter:    "select id, email, `name`, legacy_id, price, qty from t1",
+		wantCreateDDL: "create table t1 (\n\tid bigint unsigned not null,\n\temail varchar(128),\n\t`name` varchar(64) character set latin1,\n\tlegacy_id int,\n\tprice int,\n\tqty int,\n\tprimary key (id),\n\tindex email_idx (email),\n\tindex legacy_idx (legacy_id)\n) charset utf8mb4",
+	}, {
+		name: "latin1 to utf8mb4",
+		transform: &vtctldatapb.TableTransform{
+			RenameColumns:  map[string]string{"name": "full_name"},
+			ConvertColumns: map[string]string{"name": "varchar(255) character set utf8mb4"},
+		},
+		wantFilter:    "select id, email, convert(`name` using utf8mb4) as full_name, legacy_id, price, qty from t1",
+		wantCreateDDL: "create table t1 (\n\tid int not null,\n\temail varchar(128),\n\tfull_name varchar(255) character set utf8mb4,\n\tlegacy_id int,\n\tprice int,\n\tqty int,\n\tprimary key (id),\n\tindex email_idx (email),\n\tindex legacy_idx (legacy_id)\n) charset utf8mb4",
+		wantCharset: map[string]*binlogdatapb.CharsetConversion{
+			"full_name": {FromCharset: "latin1", ToCharset: "utf8mb4"},
+		},
+	}, {
+		name:      "unknown column",
+		transform: &vtctldatapb.TableTransform{RenameColumns: map[string]string{"nope": "yes"}},
+		wantErr:   "column nope not found in table t1",
+	}, {
+		name:      "drop primary key",
+		transform: &vtctldatapb.TableTransform{DropColumns: []string{"id"}},
+		wantErr:   "cannot drop column id of the primary key of table t1",
+	}, {
+		name:      "duplicate column",
+		transform: &vtctldatapb.TableTransform{RenameColumns: map[string]string{"email": "qty"}},
+		wantErr:   "duplicate column qty in the transform of table t1",
+	}, {
+		name:      "invalid type",
+		transform: &vtctldatapb.TableTransform{ConvertColumns: map[string]string{"id": "not a type"}},
+		wantErr:   `invalid column type "not a type"`,
+	}, {
+		name:      "incomplete computed column",
+		transform: &vtctldatapb.TableTransform{AddColumns: []*vtctldatapb.ComputedColumn{{Name: "total"}}},
+		wantErr:   "a computed column of table t1 needs a name, a type and an expression",
+	}, {
+		name:             "partial source expression",
+		sourceExpression: "select id, email from t1",
+		transform:        &vtctldatapb.TableTransform{DropColumns: []string{"legacy_id"}},
+		wantErr:          "the source expression of a transformed table must select all the columns of the table",
+	}}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tt, err := buildTableTransform(sourceDDL, tc.sourceExpression, tc.transform)
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantFilter, tt.filter)
+			assert.Equal(t, tc.wantCreateDDL, tt.createDDL)
+			assert.Equal(t, tc.wantCharset, tt.convertCharset)
+		})
+	}
+}
+
+func TestReverseTransform(t *testing.T) {
+	testcases := []struct {
+		name        string
+		rule        *binlogdatapb.Rule
+		wantExprs   string
+		wantCharset map[string]*binlogdatapb.CharsetConversion
+		wantNames   map[string]string
+	}{{
+		name: "not transformed",
+		rule: &binlogdatapb.Rule{Match: "t1", Filter: "select * from t1 where in_keyrange(id, 'ks.hash', '-80')"},
+	}, {
+		name: "transformed",
+		rule: &binlogdatapb.Rule{
+			Match:  "t1",
+			Filter: "select id, email as email_address, convert(`name` using utf8mb4) as full_name, price * qty as total from t1",
+			ConvertCharset: map[string]*binlogdatapb.CharsetConversion{
+				"full_name": {FromCharset: "latin1", ToCharset: "utf8mb4"},
+			},
+		},
+		wantExprs: "id, email_address as email, convert(full_name using utf8mb4) as `name`",
+		wantCharset: map[string]*binlogdatapb.CharsetConversion{
+			"name": {FromCharset: "utf8mb4", ToCharset: "latin1"},
+		},
+		wantNames: map[string]string{"id": "id", "email": "email_address", "name": "full_name"},
+	}}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			exprs, convertCharset, names, err := reverseTransform(tc.rule)
+			require.NoError(t, err)
+			if tc.wantExprs == "" {
+				assert.Nil(t, exprs)
+				return
+			}
+			assert.Equal(t, tc.wantExprs, sqlparser.String(exprs))
+			assert.Equal(t, tc.wantCharset, convertCharset)
+			gotNames := make(map[string]string, len(names))
+			for source, target := range names {
+				gotNames[source] = target.String()
+			}
+			assert.Equal(t, tc.wantNames, gotNames)
+		})
+	}
+}
+
+func TestColumnCharset(t *testing.T) {
+	colType := func(typ string) *sqlparser.ColumnType {
+		ct, err := parseColumnType(typ)
+		require.NoError(t, err)
+		return ct
+	}
+	assert.Equal(t, "", columnCharset(colType("bigint"), "latin1"))
+	assert.Equal(t, "latin1", columnCharset(colType("varchar(10)"), "latin1"))
+	assert.Equal(t, "utf8mb4", columnCharset(colType("varchar(10)"), ""))
+	assert.Equal(t, "latin1", columnCharset(colType("text character set latin1"), "utf8mb4"))
+}
@@ -0,0 +1,539 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"vitess.io/vitess/go/protoutil"
+	"vitess.io/vitess/go/sqlescape"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/trace"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+	"vitess.io/vitess/go/vt/topotools"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+	"vitess.io/vitess/go/vt/vttablet/tabletmanager/vdiff"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+const (
+	// vindexSwapTableSuffix names the shadow table the rows of a table
+	// are re-keyed into.
+	vindexSwapTableSuffix = "_vswap"
+	// vindexSwapOldTableSuffix names the table holding the rows with the
+	// old layout once the shadow table has replaced it.
+	vindexSwapOldTableSuffix = "_vswap_old"
+)
+
+// VindexSwapCreate creates a workflow changing the primary vindex of a
+// table. The rows are copied into a shadow table of the same keyspace,
+// which uses the new primary vindex. Each target shard streams from all
+// the shards and keeps the rows whose new keyspace id it owns, so the
+// rows are re-keyed in place. The shadow table is disabled by routing
+// rules, so that queries can't reach it. The result must be verified
+// with VDiff before VindexSwapComplete swaps the tables.
+func (s *Server) VindexSwapCreate(ctx context.Context, req *vtctldatapb.VindexSwapCreateRequest) (*vtctldatapb.VindexSwapCreateResponse, error) {
+	span, ctx := trace.NewSpan(ctx, "workflow.Server.VindexSwapCreate")
+	defer span.Finish()
+
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("table", req.Table)
+	span.Annotate("cells", req.Cells)
+	span.Annotate("tablet_types", req.TabletTypes)
+
+	ms, vschema, err := s.prepareVindexSwap(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	originalVSchema, err := s.ts.GetVSchema(ctx, req.Keyspace)
+	if err != nil {
+		return nil, err
+	}
+	shadowTable := req.Table + vindexSwapTableSuffix
+	if err := s.disableVindexSwapTable(ctx, req.Keyspace, shadowTable); err != nil {
+		return nil, err
+	}
+	// The shadow table must be in the vschema for the workflow to use
+	// its primary vindex.
+	if err := s.ts.SaveVSchema(ctx, req.Keyspace, vschema); err != nil {
+		s.deleteVindexSwapRoutingRules(ctx, req.Keyspace, shadowTable)
+		return nil, err
+	}
+	if err := s.Materialize(ctx, ms); err != nil {
+		if verr := s.ts.SaveVSchema(ctx, req.Keyspace, originalVSchema); verr != nil {
+			log.Errorf("Failed to restore the vschema of the %s keyspace: %v", req.Keyspace, verr)
+		}
+		s.deleteVindexSwapRoutingRules(ctx, req.Keyspace, shadowTable)
+		return nil, err
+	}
+	if err := s.ts.RebuildSrvVSchema(ctx, nil); err != nil {
+		return nil, err
+	}
+
+	return &vtctldatapb.VindexSwapCreateResponse{}, nil
+}
+
+// prepareVindexSwap validates the request and returns the settings of
+// the workflow along with the vschema including the shadow table.
+func (s *Server) prepareVindexSwap(ctx context.Context, req *vtctldatapb.VindexSwapCreateRequest) (*vtctldatapb.MaterializeSettings, *vschemapb.Keyspace, error) {
+	if req.Workflow == "" || req.Table == "" {
+		return nil, nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "a workflow and a table must be specified")
+	}
+	cv := req.ColumnVindex
+	if cv == nil || cv.Name == "" {
+		return nil, nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "the new primary vindex of table %s must be specified", req.Table)
+	}
+
+	vschema, err := s.ts.GetVSchema(ctx, req.Keyspace)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !vschema.Sharded {
+		return nil, nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "the %s keyspace is not sharded", req.Keyspace)
+	}
+	table := vschema.Tables[req.Table]
+	if table == nil || table.Type == vindexes.TypeReference || len(table.ColumnVindexes) == 0 {
+		return nil, nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "table %s has no primary vindex in the %s keyspace", req.Table, req.Keyspace)
+	}
+	if proto.Equal(table.ColumnVindexes[0], cv) {
+		return nil, nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "vindex %s is already the primary vindex of table %s", cv.Name, req.Table)
+	}
+	shadowTable := req.Table + vindexSwapTableSuffix
+	if _, ok := vschema.Tables[shadowTable]; ok {
+		return nil, nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "table %s already exists in the %s keyspace", shadowTable, req.Keyspace)
+	}
+
+	vindex := vschema.Vindexes[cv.Name]
+	switch {
+	case req.Vindex != nil && vindex != nil && !proto.Equal(vindex, req.Vindex):
+		return nil, nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "vindex %s already exists in the %s keyspace with a different definition", cv.Name, req.Keyspace)
+	case req.Vindex != nil:
+		vindex = req.Vindex
+	case vindex == nil:
+		return nil, nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "vindex %s not found in the %s keyspace", cv.Name, req.Keyspace)
+	}
+	if vindex.Owner != "" {
+		return nil, nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "vindex %s has an owner and cannot be a primary vindex", cv.Name)
+	}
+
+	vschema = proto.Clone(vschema).(*vschemapb.Keyspace)
+	if vschema.Vindexes == nil {
+		vschema.Vindexes = make(map[string]*vschemapb.Vindex)
+	}
+	vschema.Vindexes[cv.Name] = vindex
+	vschema.Tables[shadowTable] = &vschemapb.Table{
+		ColumnVindexes: []*vschemapb.ColumnVindex{cv},
+	}
+	// This also checks that the new vindex can be a primary vindex.
+	if _, err := vindexes.BuildKeyspaceSchema(vschema, req.Keyspace); err != nil {
+		return nil, nil, err
+	}
+
+	shards, err := s.ts.GetServingShards(ctx, req.Keyspace)
+	if err != nil {
+		return nil, nil, err
+	}
+	ddls, err := getSourceTableDDLs(ctx, s.ts, s.tmc, shards)
+	if err != nil {
+		return nil, nil, err
+	}
+	ddl, ok := ddls[req.Table]
+	if !ok {
+		return nil, nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "table %s not found in the %s keyspace", req.Table, req.Keyspace)
+	}
+	createDDL, err := renameCreateTable(ddl, shadowTable)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ms := &vtctldatapb.MaterializeSettings{
+		Workflow:       req.Workflow,
+		SourceKeyspace: req.Keyspace,
+		TargetKeyspace: req.Keyspace,
+		Cell:           strings.Join(req.Cells, ","),
+		TableSettings: []*vtctldatapb.TableMaterializeSettings{{
+			TargetTable:      shadowTable,
+			SourceExpression: fmt.Sprintf("select * from %s", sqlescape.EscapeID(req.Table)),
+			CreateDdl:        createDDL,
+		}},
+		TabletTypes:               topoproto.MakeStringTypeCSV(req.TabletTypes),
+		TabletSelectionPreference: req.TabletSelectionPreference,
+	}
+	return ms, vschema, nil
+}
+
+// vindexSwapRoutingRuleNames returns the names of the routing rules
+// disabling a table during a vindex swap: the shadow table while it is
+// being copied, and the table itself while it is being cut over.
+func vindexSwapRoutingRuleNames(keyspace, table string) []string {
+	var names []string
+	for _, name := range []string{table, keyspace + "." + table} {
+		names = append(names, name, name+"@replica", name+"@rdonly")
+	}
+	return names
+}
+
+// disableVindexSwapTable saves the routing rules disabling the table, so
+// that vtgate rejects the queries on it once the SrvVSchema is rebuilt.
+// The existing routing rules of the table must be the ones of a previous
+// vindex swap.
+func (s *Server) disableVindexSwapTable(ctx context.Context, keyspace, table string) error {
+	rules, err := topotools.GetRoutingRules(ctx, s.ts)
+	if err != nil {
+		return err
+	}
+	for _, name := range vindexSwapRoutingRuleNames(keyspace, table) {
+		if len(rules[name]) > 0 {
+			return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "table %s in the %s keyspace has a routing rule to %s",
+				table, keyspace, strings.Join(rules[name], ","))
+		}
+		rules[name] = []string{}
+	}
+	return topotools.SaveRoutingRules(ctx, s.ts, rules)
+}
+
+// deleteVindexSwapRoutingRules deletes the routing rules disabling the
+// tables during a vindex swap, logging the failures.
+func (s *Server) deleteVindexSwapRoutingRules(ctx context.Context, keyspace string, tables ...string) {
+	rules, err := topotools.GetRoutingRules(ctx, s.ts)
+	if err == nil {
+		for _, table := range tables {
+			for _, name := range vindexSwapRoutingRuleNames(keyspace, table) {
+				delete(rules, name)
+			}
+		}
+		err = topotools.SaveRoutingRules(ctx, s.ts, rules)
+	}
+	if err != nil {
+		log.Errorf("Failed to delete the routing rules of tables %s in the %s keyspace: %v", strings.Join(tables, ", "), keyspace, err)
+	}
+}
+
+// renameCreateTable returns the CREATE TABLE statement ddl for the table
+// name instead.
+func renameCreateTable(ddl, name string) (string, error) {
+	stmt, err := sqlparser.ParseStrictDDL(ddl)
+	if err != nil {
+		return "", err
+	}
+	create, ok := stmt.(*sqlparser.CreateTable)
+	if !ok {
+		return "", vterrors.Errorf(vtrpcpb.Code_INTERNAL, "unexpected table definition: %s", ddl)
+	}
+	create.Table = sqlparser.NewTableName(name)
+	return sqlparser.String(create), nil
+}
+
+// VindexSwapComplete cuts a table over to the shadow table created by
+// VindexSwapCreate, once the last VDiff of the workflow completed without
+// finding mismatched rows. Like the writes switch of MoveTables, it stops
+// the writes to the table and waits for the workflow to catch up. The
+// table is then disabled by routing rules, so that vtgate doesn't route
+// any query to it with the old primary vindex while the tables are
+// swapped on every shard. Once the primary vindex of the table is updated
+// in the vschema, the table is enabled and the writes are allowed again.
+// The workflow is deleted, and the table with the old layout is dropped
+// unless KeepData is set.
+//
+// If the tables can't be swapped on some shards, they are swapped back on
+// the others and the table is enabled again. If they can't be swapped
+// back either, the table stays disabled and VindexSwapComplete can be run
+// again: it skips the shards where the tables were already swapped.
+func (s *Server) VindexSwapComplete(ctx context.Context, req *vtctldatapb.VindexSwapCompleteRequest) (resp *vtctldatapb.VindexSwapCompleteResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "workflow.Server.VindexSwapComplete")
+	defer span.Finish()
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("workflow", req.Workflow)
+	span.Annotate("keep_data", req.KeepData)
+
+	timeout, set, err := protoutil.DurationFromProto(req.Timeout)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "unable to parse Timeout into a valid duration")
+	}
+	if !set {
+		timeout = defaultDuration
+	}
+
+	ts, err := s.buildTrafficSwitcher(ctx, req.Keyspace, req.Workflow)
+	if err != nil {
+		return nil, err
+	}
+	table, shadowTable, err := vindexSwapTables(ts)
+	if err != nil {
+		return nil, err
+	}
+	oldTable := table + vindexSwapOldTableSuffix
+	if err := s.checkVindexSwapVDiff(ctx, req.Keyspace, req.Workflow); err != nil {
+		return nil, err
+	}
+	// The writes are stopped on the table being re-keyed, not on the
+	// target of the workflow.
+	ts.tables = []string{table}
+
+	// Consistently handle errors by logging and returning them.
+	handleError := func(message string, err error) (*vtctldatapb.VindexSwapCompleteResponse, error) {
+		werr := vterrors.Errorf(vtrpcpb.Code_INTERNAL, fmt.Sprintf("%s: %v", message, err))
+		ts.Logger().Error(werr)
+		return nil, werr
+	}
+	// Until the tables are swapped, a failure leaves the table and the
+	// workflow as they were.
+	cancelSwap := func() {
+		s.deleteVindexSwapRoutingRules(ctx, req.Keyspace, table)
+		// This also rebuilds the SrvVSchema without the routing rules.
+		if err := ts.changeTableSourceWrites(ctx, allowWrites); err != nil {
+			ts.Logger().Errorf("Cancel vindex swap failed: could not allow the writes to %s: %v", table, err)
+		}
+		err := ts.ForAllTargets(func(target *MigrationTarget) error {
+			query := fmt.Sprintf("update _vt.vreplication set state='Running', message='' where db_name=%s and workflow=%s",
+				encodeString(target.GetPrimary().DbName()), encodeString(ts.WorkflowName()))
+			_, err := ts.TabletManagerClient().VReplicationExec(ctx, target.GetPrimary().Tablet, query)
+			return err
+		})
+		if err != nil {
+			ts.Logger().Errorf("Cancel vindex swap failed: could not restart vreplication: %v", err)
+		}
+	}
+
+	lockCtx, unlock, lockErr := s.ts.LockKeyspace(ctx, req.Keyspace, "VindexSwapComplete")
+	if lockErr != nil {
+		return handleError(fmt.Sprintf("failed to lock the %s keyspace", req.Keyspace), lockErr)
+	}
+	ctx = lockCtx
+	defer unlock(&err)
+
+	ts.Logger().Infof("Stopping the writes to %s", table)
+	if err := ts.stopSourceWrites(ctx); err != nil {
+		cancelSwap()
+		return handleError(fmt.Sprintf("failed to stop the writes to %s", table), err)
+	}
+	for cnt := 1; cnt <= lockTablesCycles; cnt++ {
+		if err := ts.executeLockTablesOnSource(ctx); err != nil {
+			cancelSwap()
+			return handleError(fmt.Sprintf("failed to execute LOCK TABLES (attempt %d of %d) on sources", cnt, lockTablesCycles), err)
+		}
+		time.Sleep(lockTablesCycleDelay)
+	}
+	ts.Logger().Infof("Waiting for streams to catchup")
+	if err := ts.waitForCatchup(ctx, timeout); err != nil {
+		cancelSwap()
+		return handleError("failed to sync up replication between the table and its shadow table", err)
+	}
+
+	// The reads of the table, on any tablet type, must not be routed with
+	// the old primary vindex once the tables are swapped.
+	ts.Logger().Infof("Disabling the queries on %s", table)
+	if err := s.disableVindexSwapTable(ctx, req.Keyspace, table); err != nil {
+		cancelSwap()
+		return handleError(fmt.Sprintf("failed to disable the queries on %s", table), err)
+	}
+	if err := s.ts.RebuildSrvVSchema(ctx, nil); err != nil {
+		cancelSwap()
+		return handleError(fmt.Sprintf("failed to disable the queries on %s", table), err)
+	}
+
+	// Once the tables are swapped on every shard, this is the point of no
+	// return.
+	if rolledBack, err := s.swapVindexSwapTables(ctx, ts, table, shadowTable, oldTable); err != nil {
+		if rolledBack {
+			cancelSwap()
+			return handleError(fmt.Sprintf("failed to swap %s and %s", table, shadowTable), err)
+		}
+		return handleError(fmt.Sprintf("failed to swap %s and %s, and to swap them back on the shards where they were swapped: the queries on %s stay disabled until VindexSwapComplete is run again",
+			table, shadowTable, table), err)
+	}
+
+	vschema, err := s.ts.GetVSchema(ctx, req.Keyspace)
+	if err != nil {
+		return handleError(fmt.Sprintf("failed to get the vschema of the %s keyspace", req.Keyspace), err)
+	}
+	if vschema.Tables[table] == nil || vschema.Tables[shadowTable] == nil || len(vschema.Tables[shadowTable].ColumnVindexes) == 0 {
+		return handleError("failed to update the vschema", vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION,
+			"tables %s and %s not found in the vschema of the %s keyspace", table, shadowTable, req.Keyspace))
+	}
+	vindex := vschema.Tables[shadowTable].ColumnVindexes[0]
+	vschema.Tables[table].ColumnVindexes[0] = vindex
+	delete(vschema.Tables, shadowTable)
+	if err := s.ts.SaveVSchema(ctx, req.Keyspace, vschema); err != nil {
+		return handleError(fmt.Sprintf("failed to save the vschema of the %s keyspace", req.Keyspace), err)
+	}
+	s.deleteVindexSwapRoutingRules(ctx, req.Keyspace, table, shadowTable)
+	// This also rebuilds the SrvVSchema with the new primary vindex, and
+	// without the routing rules.
+	if err := ts.changeTableSourceWrites(ctx, allowWrites); err != nil {
+		return handleError(fmt.Sprintf("failed to allow the writes to %s", table), err)
+	}
+
+	if err := ts.dropTargetVReplicationStreams(ctx); err != nil {
+		return handleError(fmt.Sprintf("failed to delete the %s workflow", req.Workflow), err)
+	}
+	if !req.KeepData {
+		drop := fmt.Sprintf("drop table if exists %s", sqlescape.EscapeID(oldTable))
+		err := ts.ForAllTargets(func(target *MigrationTarget) error {
+			_, err := s.executeVindexSwapQuery(ctx, target, drop)
+			return err
+		})
+		if err != nil {
+			return handleError(fmt.Sprintf("failed to drop %s", oldTable), err)
+		}
+	}
+
+	return &vtctldatapb.VindexSwapCompleteResponse{
+		Summary: fmt.Sprintf("Table %s in the %s keyspace now uses the %s primary vindex", table, req.Keyspace, vindex.Name),
+	}, nil
+}
+
+// checkVindexSwapVDiff returns an error unless the last VDiff of the
+// workflow completed on every shard without finding mismatched rows.
+func (s *Server) checkVindexSwapVDiff(ctx context.Context, keyspace, workflow string) error {
+	resp, err := s.VDiffShow(ctx, &vtctldatapb.VDiffShowRequest{
+		TargetKeyspace: keyspace,
+		Workflow:       workflow,
+		Arg:            vdiff.LastActionArg,
+	})
+	if err != nil {
+		return vterrors.Wrapf(err, "failed to get the last vdiff of the %s workflow", workflow)
+	}
+	return vindexSwapVDiffError(workflow, resp)
+}
+
+// vindexSwapVDiffError returns an error unless the last VDiff of the
+// workflow, in resp, completed on every shard without finding mismatched
+// rows.
+func vindexSwapVDiffError(workflow string, resp *vtctldatapb.VDiffShowResponse) error {
+	uuid := ""
+	for shard, tabletResp := range resp.TabletResponses {
+		if tabletResp == nil || tabletResp.VdiffUuid == "" || tabletResp.Output == nil {
+			return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "no vdiff of the %s workflow found on shard %s, the workflow must be verified with VDiff before the cutover",
+				workflow, shard)
+		}
+		if uuid != "" && tabletResp.VdiffUuid != uuid {
+			return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "the last vdiffs of the %s workflow differ across shards: %s and %s",
+				workflow, uuid, tabletResp.VdiffUuid)
+		}
+		uuid = tabletResp.VdiffUuid
+		qr := sqltypes.Proto3ToResult(tabletResp.Output)
+		for _, row := range qr.Named().Rows {
+			if state := vdiff.VDiffState(strings.ToLower(row.AsString("vdiff_state", ""))); state != vdiff.CompletedState {
+				return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "vdiff %s of the %s workflow is %s on shard %s, it must be completed before the cutover",
+					uuid, workflow, state, shard)
+			}
+			if mismatch, _ := row.ToBool("has_mismatch"); mismatch {
+				return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "vdiff %s of the %s workflow found mismatched rows in table %s on shard %s",
+					uuid, workflow, row.AsString("table_name", ""), shard)
+			}
+		}
+	}
+	return nil
+}
+
+// swapVindexSwapTables swaps the table and its shadow table on every
+// shard, skipping the shards where a previous attempt already swapped
+// them. If they can't be swapped on some shards, they are swapped back on
+// the others, and rolledBack is true if that succeeded.
+func (s *Server) swapVindexSwapTables(ctx context.Context, ts *trafficSwitcher, table, shadowTable, oldTable string) (rolledBack bool, err error) {
+	rename := fmt.Sprintf("rename table %s to %s, %s to %s", sqlescape.EscapeID(table), sqlescape.EscapeID(oldTable),
+		sqlescape.EscapeID(shadowTable), sqlescape.EscapeID(table))
+	var mu sync.Mutex
+	var swapped []*MigrationTarget
+	err = ts.ForAllTargets(func(target *MigrationTarget) error {
+		done, err := s.vindexSwapTablesSwapped(ctx, target, shadowTable, oldTable)
+		if err != nil {
+			return err
+		}
+		if !done {
+			if _, err := s.executeVindexSwapQuery(ctx, target, rename); err != nil {
+				return err
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		swapped = append(swapped, target)
+		return nil
+	})
+	if err == nil {
+		return false, nil
+	}
+
+	revert := fmt.Sprintf("rename table %s to %s, %s to %s", sqlescape.EscapeID(table), sqlescape.EscapeID(shadowTable),
+		sqlescape.EscapeID(oldTable), sqlescape.EscapeID(table))
+	for _, target := range swapped {
+		ts.Logger().Infof("Swapping back %s and %s on shard %s", table, shadowTable, target.GetShard().ShardName())
+		if _, rerr := s.executeVindexSwapQuery(ctx, target, revert); rerr != nil {
+			ts.Logger().Errorf("Failed to swap back %s and %s on shard %s: %v", table, shadowTable, target.GetShard().ShardName(), rerr)
+			return false, err
+		}
+	}
+	return true, err
+}
+
+// vindexSwapTablesSwapped returns true if the table was already swapped
+// with its shadow table on the shard of the target.
+func (s *Server) vindexSwapTablesSwapped(ctx context.Context, target *MigrationTarget, shadowTable, oldTable string) (bool, error) {
+	query := fmt.Sprintf("select table_name from information_schema.tables where table_schema = database() and table_name in (%s, %s)",
+		encodeString(shadowTable), encodeString(oldTable))
+	p3qr, err := s.executeVindexSwapQuery(ctx, target, query)
+	if err != nil {
+		return false, err
+	}
+	tables := make(map[string]bool)
+	for _, row := range sqltypes.Proto3ToResult(p3qr).Rows {
+		tables[row[0].ToString()] = true
+	}
+	return tables[oldTable] && !tables[shadowTable], nil
+}
+
+// executeVindexSwapQuery executes the query on the primary of the target.
+func (s *Server) executeVindexSwapQuery(ctx context.Context, target *MigrationTarget, query string) (*querypb.QueryResult, error) {
+	return s.tmc.ExecuteFetchAsDba(ctx, target.GetPrimary().Tablet, false, &tabletmanagerdatapb.ExecuteFetchAsDbaRequest{
+		Query:        []byte(query),
+		MaxRows:      2,
+		ReloadSchema: true,
+	})
+}
+
+// vindexSwapTables returns the table re-keyed by a workflow created by
+// VindexSwapCreate, and its shadow table.
+func vindexSwapTables(ts *trafficSwitcher) (table, shadowTable string, err error) {
+	if ts.workflowType != binlogdatapb.VReplicationWorkflowType_Materialize ||
+		ts.sourceKeyspace != ts.targetKeyspace || len(ts.tables) != 1 {
+		return "", "", vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "%s is not a vindex swap workflow", ts.workflow)
+	}
+	shadowTable = ts.tables[0]
+	table, ok := strings.CutSuffix(shadowTable, vindexSwapTableSuffix)
+	if !ok || table == "" {
+		return "", "", vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "%s is not a vindex swap workflow", ts.workflow)
+	}
+	return table, shadowTable, nil
+}
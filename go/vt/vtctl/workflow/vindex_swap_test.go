@@ -0,0 +1,372 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/test/utils"
+	"vitess.io/vitess/go/vt/logutil"
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/topotools"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+func newTestVindexSwapEnv(t *testing.T, ctx context.Context) *testMaterializerEnv {
+	ms := &vtctldatapb.MaterializeSettings{
+		SourceKeyspace: "ks",
+		TargetKeyspace: "ks",
+	}
+	env := newTestMaterializerEnv(t, ctx, ms, []string{"-80", "80-"}, []string{"-80", "80-"})
+	t.Cleanup(env.close)
+
+	env.tmc.schema["ks.t1"] = &tabletmanagerdatapb.SchemaDefinition{
+		TableDefinitions: []*tabletmanagerdatapb.TableDefinition{{
+			Name: "t1",
+			Schema: "CREATE TABLE `t1` (\n" +
+				"  `id` bigint NOT NULL,\n" +
+				"  `c1` varchar(20) DEFAULT NULL,\n" +
+				"  PRIMARY KEY (`id`)\n" +
+				") ENGINE=InnoDB",
+		}},
+	}
+	vschema := &vschemapb.Keyspace{
+		Sharded: true,
+		Vindexes: map[string]*vschemapb.Vindex{
+			"hash": {
+				Type: "hash",
+			},
+		},
+		Tables: map[string]*vschemapb.Table{
+			"t1": {
+				ColumnVindexes: []*vschemapb.ColumnVindex{{
+					Name:   "hash",
+					Column: "id",
+				}},
+			},
+		},
+	}
+	require.NoError(t, env.topoServ.SaveVSchema(ctx, "ks", vschema))
+	return env
+}
+
+func TestVindexSwapCreate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env := newTestVindexSwapEnv(t, ctx)
+
+	for _, tabletID := range []int{100, 110} {
+		env.tmc.expectVRQuery(tabletID, "select 1 from _vt.vreplication where db_name='vt_ks' and workflow='t1_swap'", &sqltypes.Result{})
+		env.tmc.expectVRQuery(tabletID, "select 1 from _vt.vreplication where db_name='vt_ks' and message='FROZEN' and workflow_sub_type != 1", &sqltypes.Result{})
+		env.tmc.expectVRQuery(tabletID, "/create table t1_vswap", &sqltypes.Result{})
+		env.tmc.expectVRQuery(tabletID, insertPrefix+`.*shard:\\"-80\\".*shard:\\"80-\\"`, &sqltypes.Result{})
+		env.tmc.expectVRQuery(tabletID, "update _vt.vreplication set state='Running' where db_name='vt_ks' and workflow='t1_swap'", &sqltypes.Result{})
+	}
+
+	_, err := env.ws.VindexSwapCreate(ctx, &vtctldatapb.VindexSwapCreateRequest{
+		Keyspace: "ks",
+		Workflow: "t1_swap",
+		Table:    "t1",
+		ColumnVindex: &vschemapb.ColumnVindex{
+			Name:    "xxhash",
+			Columns: []string{"c1"},
+		},
+		Vindex: &vschemapb.Vindex{
+			Type: "xxhash",
+		},
+	})
+	require.NoError(t, err)
+	env.tmc.verifyQueries(t)
+
+	want := &vschemapb.Keyspace{
+		Sharded: true,
+		Vindexes: map[string]*vschemapb.Vindex{
+			"hash": {
+				Type: "hash",
+			},
+			"xxhash": {
+				Type: "xxhash",
+			},
+		},
+		Tables: map[string]*vschemapb.Table{
+			"t1": {
+				ColumnVindexes: []*vschemapb.ColumnVindex{{
+					Name:   "hash",
+					Column: "id",
+				}},
+			},
+			"t1_vswap": {
+				ColumnVindexes: []*vschemapb.ColumnVindex{{
+					Name:    "xxhash",
+					Columns: []string{"c1"},
+				}},
+			},
+		},
+	}
+	got, err := env.topoServ.GetVSchema(ctx, "ks")
+	require.NoError(t, err)
+	utils.MustMatch(t, want, got)
+
+	// The shadow table is disabled by routing rules.
+	rules, err := topotools.GetRoutingRules(ctx, env.topoServ)
+	require.NoError(t, err)
+	require.Len(t, rules, 6)
+	for _, name := range []string{"t1_vswap", "t1_vswap@replica", "t1_vswap@rdonly", "ks.t1_vswap", "ks.t1_vswap@replica", "ks.t1_vswap@rdonly"} {
+		toTables, ok := rules[name]
+		require.True(t, ok, name)
+		require.Empty(t, toTables, name)
+	}
+}
+
+func TestDisableVindexSwapTable(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env := newTestVindexSwapEnv(t, ctx)
+	names := []string{"t1", "t1@replica", "t1@rdonly", "ks.t1", "ks.t1@replica", "ks.t1@rdonly"}
+
+	// Disabling the table again, when VindexSwapComplete is run again,
+	// keeps it disabled.
+	for i := 0; i < 2; i++ {
+		require.NoError(t, env.ws.disableVindexSwapTable(ctx, "ks", "t1"))
+		rules, err := topotools.GetRoutingRules(ctx, env.topoServ)
+		require.NoError(t, err)
+		require.Len(t, rules, len(names))
+		for _, name := range names {
+			toTables, ok := rules[name]
+			require.True(t, ok, name)
+			require.Empty(t, toTables, name)
+		}
+	}
+
+	env.ws.deleteVindexSwapRoutingRules(ctx, "ks", "t1", "t1_vswap")
+	rules, err := topotools.GetRoutingRules(ctx, env.topoServ)
+	require.NoError(t, err)
+	require.Empty(t, rules)
+
+	// The routing rules of another workflow are not overwritten.
+	require.NoError(t, topotools.SaveRoutingRules(ctx, env.topoServ, map[string][]string{"t1@replica": {"other.t1"}}))
+	err = env.ws.disableVindexSwapTable(ctx, "ks", "t1")
+	require.ErrorContains(t, err, "table t1 in the ks keyspace has a routing rule to other.t1")
+}
+
+func TestVindexSwapCreateFailures(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env := newTestVindexSwapEnv(t, ctx)
+
+	testcases := []struct {
+		description string
+		req         *vtctldatapb.VindexSwapCreateRequest
+		err         string
+	}{{
+		description: "no vindex",
+		req:         &vtctldatapb.VindexSwapCreateRequest{Table: "t1"},
+		err:         "the new primary vindex of table t1 must be specified",
+	}, {
+		description: "unknown table",
+		req: &vtctldatapb.VindexSwapCreateRequest{
+			Table:        "t2",
+			ColumnVindex: &vschemapb.ColumnVindex{Name: "hash", Column: "id"},
+		},
+		err: "table t2 has no primary vindex in the ks keyspace",
+	}, {
+		description: "same vindex",
+		req: &vtctldatapb.VindexSwapCreateRequest{
+			Table:        "t1",
+			ColumnVindex: &vschemapb.ColumnVindex{Name: "hash", Column: "id"},
+		},
+		err: "vindex hash is already the primary vindex of table t1",
+	}, {
+		description: "unknown vindex",
+		req: &vtctldatapb.VindexSwapCreateRequest{
+			Table:        "t1",
+			ColumnVindex: &vschemapb.ColumnVindex{Name: "xxhash", Column: "id"},
+		},
+		err: "vindex xxhash not found in the ks keyspace",
+	}, {
+		description: "different definition",
+		req: &vtctldatapb.VindexSwapCreateRequest{
+			Table:        "t1",
+			ColumnVindex: &vschemapb.ColumnVindex{Name: "hash", Column: "c1"},
+			Vindex:       &vschemapb.Vindex{Type: "xxhash"},
+		},
+		err: "vindex hash already exists in the ks keyspace with a different definition",
+	}, {
+		description: "non-unique vindex",
+		req: &vtctldatapb.VindexSwapCreateRequest{
+			Table:        "t1",
+			ColumnVindex: &vschemapb.ColumnVindex{Name: "lkp", Column: "c1"},
+			Vindex: &vschemapb.Vindex{
+				Type:   "lookup",
+				Params: map[string]string{"table": "ks.lkp", "from": "c1", "to": "keyspace_id"},
+			},
+		},
+		err: "primary vindex lkp is not Unique for table t1_vswap",
+	}, {
+		description: "owned vindex",
+		req: &vtctldatapb.VindexSwapCreateRequest{
+			Table:        "t1",
+			ColumnVindex: &vschemapb.ColumnVindex{Name: "lkp", Column: "c1"},
+			Vindex: &vschemapb.Vindex{
+				Type:   "lookup_unique",
+				Params: map[string]string{"table": "ks.lkp", "from": "c1", "to": "keyspace_id"},
+				Owner:  "t1",
+			},
+		},
+		err: "vindex lkp has an owner and cannot be a primary vindex",
+	}}
+	for _, tcase := range testcases {
+		t.Run(tcase.description, func(t *testing.T) {
+			tcase.req.Keyspace = "ks"
+			tcase.req.Workflow = "t1_swap"
+			_, err := env.ws.VindexSwapCreate(ctx, tcase.req)
+			require.ErrorContains(t, err, tcase.err)
+		})
+	}
+}
+
+func TestVindexSwapVDiffError(t *testing.T) {
+	fields := sqltypes.MakeTestFields("vdiff_state|table_name|has_mismatch", "varchar|varchar|int64")
+	response := func(uuid string, rows ...string) *tabletmanagerdatapb.VDiffResponse {
+		return &tabletmanagerdatapb.VDiffResponse{
+			VdiffUuid: uuid,
+			Output:    sqltypes.ResultToProto3(sqltypes.MakeTestResult(fields, rows...)),
+		}
+	}
+	testcases := []struct {
+		description string
+		responses   map[string]*tabletmanagerdatapb.VDiffResponse
+		err         string
+	}{{
+		description: "clean",
+		responses: map[string]*tabletmanagerdatapb.VDiffResponse{
+			"-80": response("u1", "completed|t1_vswap|0"),
+			"80-": response("u1", "completed|t1_vswap|0"),
+		},
+	}, {
+		description: "no vdiff",
+		responses: map[string]*tabletmanagerdatapb.VDiffResponse{
+			"-80": response("u1", "completed|t1_vswap|0"),
+			"80-": {},
+		},
+		err: "no vdiff of the t1_swap workflow found on shard 80-",
+	}, {
+		description: "different vdiffs",
+		responses: map[string]*tabletmanagerdatapb.VDiffResponse{
+			"-80": response("u1", "completed|t1_vswap|0"),
+			"80-": response("u2", "completed|t1_vswap|0"),
+		},
+		err: "the last vdiffs of the t1_swap workflow differ across shards",
+	}, {
+		description: "running",
+		responses: map[string]*tabletmanagerdatapb.VDiffResponse{
+			"-80": response("u1", "started|t1_vswap|0"),
+		},
+		err: "vdiff u1 of the t1_swap workflow is started on shard -80",
+	}, {
+		description: "mismatch",
+		responses: map[string]*tabletmanagerdatapb.VDiffResponse{
+			"-80": response("u1", "completed|t1_vswap|1"),
+		},
+		err: "vdiff u1 of the t1_swap workflow found mismatched rows in table t1_vswap on shard -80",
+	}}
+	for _, tcase := range testcases {
+		t.Run(tcase.description, func(t *testing.T) {
+			err := vindexSwapVDiffError("t1_swap", &vtctldatapb.VDiffShowResponse{TabletResponses: tcase.responses})
+			if tcase.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tcase.err)
+		})
+	}
+}
+
+func TestSwapVindexSwapTables(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env := newTestVindexSwapEnv(t, ctx)
+	ts := &trafficSwitcher{
+		logger:  logutil.NewMemoryLogger(),
+		targets: make(map[string]*MigrationTarget),
+	}
+	for i, shard := range []string{"-80", "80-"} {
+		ts.targets[shard] = &MigrationTarget{
+			si:      topo.NewShardInfo("ks", shard, &topodatapb.Shard{}, nil),
+			primary: &topo.TabletInfo{Tablet: env.tablets[100+10*i]},
+		}
+	}
+	const (
+		check  = "select table_name from information_schema.tables where table_schema = database() and table_name in ('t1_vswap', 't1_vswap_old')"
+		rename = "rename table `t1` to `t1_vswap_old`, `t1_vswap` to `t1`"
+		revert = "rename table `t1` to `t1_vswap`, `t1_vswap_old` to `t1`"
+	)
+	notSwapped := sqltypes.MakeTestResult(sqltypes.MakeTestFields("table_name", "varchar"), "t1_vswap")
+	swapped := sqltypes.MakeTestResult(sqltypes.MakeTestFields("table_name", "varchar"), "t1_vswap_old")
+
+	// The tables are swapped back where they were swapped when the swap
+	// fails on a shard.
+	env.tmc.expectVRQuery(100, check, notSwapped)
+	env.tmc.expectVRQuery(100, rename, &sqltypes.Result{})
+	env.tmc.expectVRQuery(100, revert, &sqltypes.Result{})
+	env.tmc.expectVRQuery(110, check, notSwapped)
+	rolledBack, err := env.ws.swapVindexSwapTables(ctx, ts, "t1", "t1_vswap", "t1_vswap_old")
+	require.Error(t, err)
+	require.True(t, rolledBack)
+	env.tmc.verifyQueries(t)
+
+	// The shards where the tables were already swapped are skipped.
+	env.tmc.expectVRQuery(100, check, swapped)
+	env.tmc.expectVRQuery(110, check, notSwapped)
+	env.tmc.expectVRQuery(110, rename, &sqltypes.Result{})
+	rolledBack, err = env.ws.swapVindexSwapTables(ctx, ts, "t1", "t1_vswap", "t1_vswap_old")
+	require.NoError(t, err)
+	require.False(t, rolledBack)
+	env.tmc.verifyQueries(t)
+}
+
+func TestVindexSwapTables(t *testing.T) {
+	ts := &trafficSwitcher{
+		workflow:       "t1_swap",
+		workflowType:   binlogdatapb.VReplicationWorkflowType_Materialize,
+		sourceKeyspace: "ks",
+		targetKeyspace: "ks",
+		tables:         []string{"t1_vswap"},
+	}
+	table, shadowTable, err := vindexSwapTables(ts)
+	require.NoError(t, err)
+	require.Equal(t, "t1", table)
+	require.Equal(t, "t1_vswap", shadowTable)
+
+	ts.tables = []string{"t1"}
+	_, _, err = vindexSwapTables(ts)
+	require.ErrorContains(t, err, "t1_swap is not a vindex swap workflow")
+
+	ts.tables = []string{"t1_vswap"}
+	ts.workflowType = binlogdatapb.VReplicationWorkflowType_MoveTables
+	_, _, err = vindexSwapTables(ts)
+	require.ErrorContains(t, err, "t1_swap is not a vindex swap workflow")
+}
@@ -0,0 +1,371 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package debezium converts the events of a VStream to Debezium change events,
+// so that consumers that understand Debezium can read the changes of Vitess
+// without running the Debezium connector.
+//
+// The records follow the layout of the Debezium JSON converter with schemas
+// disabled: the value of a data change record is the envelope with the before
+// and after images of the row, the source block and the operation, and the
+// value of a schema change record holds the DDL statement.
+package debezium
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"vitess.io/vitess/go/json2"
+	"vitess.io/vitess/go/sqltypes"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+// Connector is the name of the connector in the source block of the events.
+const Connector = "vitess"
+
+// Operations of the data change events.
+const (
+	OpCreate = "c"
+	OpUpdate = "u"
+	OpDelete = "d"
+	OpRead   = "r"
+)
+
+// Record is a change event, with the topic and the key it would be published with.
+type Record struct {
+	Topic string `json:"topic"`
+	Key   any    `json:"key"`
+	Value any    `json:"value"`
+}
+
+// Envelope is the value of a data change event.
+type Envelope struct {
+	Before *Row   `json:"before"`
+	After  *Row   `json:"after"`
+	Source Source `json:"source"`
+	Op     string `json:"op"`
+	TsMs   int64  `json:"ts_ms"`
+}
+
+// SchemaChange is the value of a schema change event.
+type SchemaChange struct {
+	Source       Source `json:"source"`
+	DatabaseName string `json:"databaseName"`
+	DDL          string `json:"ddl"`
+	TableChanges []any  `json:"tableChanges"`
+}
+
+// Source describes where a change event comes from. Vgtid is the position
+// to resume the VStream from after the event's transaction.
+type Source struct {
+	Version   string `json:"version"`
+	Connector string `json:"connector"`
+	Name      string `json:"name"`
+	TsMs      int64  `json:"ts_ms"`
+	Snapshot  string `json:"snapshot"`
+	DB        string `json:"db"`
+	Keyspace  string `json:"keyspace"`
+	Table     string `json:"table"`
+	Shard     string `json:"shard"`
+	Vgtid     string `json:"vgtid"`
+}
+
+// Row is the image of a row, marshaled as a JSON object with the columns in
+// the order of the table.
+type Row struct {
+	Fields []*querypb.Field
+	Values []sqltypes.Value
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r *Row) MarshalJSON() ([]byte, error) {
+	buf := []byte{'{'}
+	for i, field := range r.Fields {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		name, err := json.Marshal(field.Name)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, name...)
+		buf = append(buf, ':')
+		value, err := json.Marshal(columnValue(r.Values[i]))
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, value...)
+	}
+	return append(buf, '}'), nil
+}
+
+// columnValue returns the value of a column the way Debezium represents it
+// in JSON: numbers for the integral and float types, base64 for the binary
+// types and strings for everything else, including decimals and temporal types.
+func columnValue(v sqltypes.Value) any {
+	switch {
+	case v.IsNull():
+		return nil
+	case v.IsSigned():
+		if i, err := v.ToInt64(); err == nil {
+			return i
+		}
+	case v.IsUnsigned():
+		if u, err := v.ToUint64(); err == nil {
+			return u
+		}
+	case v.IsFloat():
+		if f, err := v.ToFloat64(); err == nil {
+			return f
+		}
+	case v.IsBinary(), v.Type() == sqltypes.Bit, v.Type() == sqltypes.Geometry:
+		return v.Raw()
+	}
+	return v.ToString()
+}
+
+// Converter converts the events of a VStream to change events. The records
+// of a transaction are returned once it commits, so that all of them carry
+// the position to resume from after the transaction.
+type Converter struct {
+	topicPrefix string
+	version     string
+
+	// fields are the fields of the tables, by qualified table name
+	fields map[string][]*querypb.Field
+	// vgtid is the latest position of the stream
+	vgtid string
+	// shards are the states of the transactions being streamed, by shard
+	shards map[string]*shardState
+}
+
+type shardState struct {
+	// tablePKs are the last primary keys copied of the tables the VStream
+	// is copying, by table name, as of the latest position of the shard.
+	tablePKs map[string]string
+	// copied are the tables whose rows the current transaction copied, as
+	// opposed to replicated: their last primary key copied moved forward.
+	copied  map[string]bool
+	pending []*Record
+}
+
+// NewConverter returns a Converter for a stream. The topics of the records
+// start with topicPrefix, and version is reported in the source block.
+func NewConverter(topicPrefix, version string) *Converter {
+	return &Converter{
+		topicPrefix: topicPrefix,
+		version:     version,
+		fields:      map[string][]*querypb.Field{},
+		shards:      map[string]*shardState{},
+	}
+}
+
+// SetVgtid sets the position the stream starts from.
+func (c *Converter) SetVgtid(vgtid *binlogdatapb.VGtid) error {
+	b, err := json2.MarshalPB(vgtid)
+	if err != nil {
+		return err
+	}
+	c.vgtid = string(b)
+	c.trackCopy(vgtid)
+	return nil
+}
+
+// trackCopy records the progress of the copy of the tables of the position.
+// During the copy phase, the VStream wraps the rows it copies in transactions
+// like the replicated ones, but the position at the end of such a transaction
+// has the last primary key copied of the table move forward.
+func (c *Converter) trackCopy(vgtid *binlogdatapb.VGtid) {
+	for _, sgtid := range vgtid.GetShardGtids() {
+		shard := c.shard(sgtid.Keyspace, sgtid.Shard)
+		tablePKs := make(map[string]string, len(sgtid.TablePKs))
+		for _, tablePK := range sgtid.TablePKs {
+			lastPK, _ := tablePK.Lastpk.MarshalVT()
+			tablePKs[tablePK.TableName] = string(lastPK)
+			if last, ok := shard.tablePKs[tablePK.TableName]; !ok || last != string(lastPK) {
+				if shard.copied == nil {
+					shard.copied = map[string]bool{}
+				}
+				shard.copied[tablePK.TableName] = true
+			}
+		}
+		shard.tablePKs = tablePKs
+	}
+}
+
+// Convert converts events, as sent by the VStream, to the records that are
+// ready to be published.
+func (c *Converter) Convert(events []*binlogdatapb.VEvent) ([]*Record, error) {
+	var records []*Record
+	for _, event := range events {
+		shard := c.shard(event.Keyspace, event.Shard)
+		switch event.Type {
+		case binlogdatapb.VEventType_BEGIN:
+			shard.copied = nil
+		case binlogdatapb.VEventType_VGTID:
+			if err := c.SetVgtid(event.Vgtid); err != nil {
+				return nil, err
+			}
+		case binlogdatapb.VEventType_FIELD:
+			c.fields[event.FieldEvent.TableName] = event.FieldEvent.Fields
+		case binlogdatapb.VEventType_ROW:
+			rows, err := c.convertRows(event)
+			if err != nil {
+				return nil, err
+			}
+			shard.pending = append(shard.pending, rows...)
+		case binlogdatapb.VEventType_DDL:
+			records = append(records, c.flush(shard)...)
+			records = append(records, c.convertDDL(event))
+		case binlogdatapb.VEventType_COMMIT, binlogdatapb.VEventType_OTHER:
+			records = append(records, c.flush(shard)...)
+		}
+	}
+	return records, nil
+}
+
+func (c *Converter) shard(keyspace, shard string) *shardState {
+	key := keyspace + "/" + shard
+	state, ok := c.shards[key]
+	if !ok {
+		state = &shardState{}
+		c.shards[key] = state
+	}
+	return state
+}
+
+// flush returns the pending records of the shard, stamped with the current
+// position. The rows copied by the transaction are snapshot reads.
+func (c *Converter) flush(shard *shardState) []*Record {
+	records := shard.pending
+	for _, record := range records {
+		envelope := record.Value.(*Envelope)
+		envelope.Source.Vgtid = c.vgtid
+		if shard.copied[envelope.Source.Table] {
+			envelope.Source.Snapshot = "true"
+			envelope.Op = OpRead
+		}
+	}
+	shard.pending = nil
+	shard.copied = nil
+	return records
+}
+
+func (c *Converter) convertRows(event *binlogdatapb.VEvent) ([]*Record, error) {
+	re := event.RowEvent
+	fields, ok := c.fields[re.TableName]
+	if !ok {
+		return nil, fmt.Errorf("no field event received for table %s", re.TableName)
+	}
+	keyspace := re.Keyspace
+	if keyspace == "" {
+		keyspace = event.Keyspace
+	}
+	shard := re.Shard
+	if shard == "" {
+		shard = event.Shard
+	}
+	table := strings.TrimPrefix(re.TableName, keyspace+".")
+
+	source := c.source(event, keyspace, shard)
+	source.Table = table
+
+	records := make([]*Record, 0, len(re.RowChanges))
+	for _, change := range re.RowChanges {
+		envelope := &Envelope{
+			Source: source,
+			TsMs:   eventTime(event),
+		}
+		if change.Before != nil {
+			envelope.Before = &Row{Fields: fields, Values: sqltypes.MakeRowTrusted(fields, change.Before)}
+		}
+		if change.After != nil {
+			envelope.After = &Row{Fields: fields, Values: sqltypes.MakeRowTrusted(fields, change.After)}
+		}
+		image := envelope.After
+		switch {
+		case envelope.Before == nil:
+			envelope.Op = OpCreate
+		case envelope.After == nil:
+			envelope.Op = OpDelete
+			image = envelope.Before
+		default:
+			envelope.Op = OpUpdate
+		}
+		records = append(records, &Record{
+			Topic: c.topicPrefix + "." + keyspace + "." + table,
+			Key:   primaryKey(image),
+			Value: envelope,
+		})
+	}
+	return records, nil
+}
+
+func (c *Converter) convertDDL(event *binlogdatapb.VEvent) *Record {
+	source := c.source(event, event.Keyspace, event.Shard)
+	source.Vgtid = c.vgtid
+	return &Record{
+		Topic: c.topicPrefix,
+		Key:   map[string]string{"databaseName": event.Keyspace},
+		Value: &SchemaChange{
+			Source:       source,
+			DatabaseName: event.Keyspace,
+			DDL:          event.Statement,
+			TableChanges: []any{},
+		},
+	}
+}
+
+func (c *Converter) source(event *binlogdatapb.VEvent, keyspace, shard string) Source {
+	return Source{
+		Version:   c.version,
+		Connector: Connector,
+		Name:      c.topicPrefix,
+		TsMs:      event.Timestamp * 1000,
+		Snapshot:  "false",
+		DB:        keyspace,
+		Keyspace:  keyspace,
+		Shard:     shard,
+	}
+}
+
+// primaryKey returns the key of a data change event: the primary key columns
+// of the row, or nil if the table has no primary key.
+func primaryKey(row *Row) any {
+	key := &Row{}
+	for i, field := range row.Fields {
+		if field.Flags&uint32(querypb.MySqlFlag_PRI_KEY_FLAG) != 0 {
+			key.Fields = append(key.Fields, field)
+			key.Values = append(key.Values, row.Values[i])
+		}
+	}
+	if len(key.Fields) == 0 {
+		return nil
+	}
+	return key
+}
+
+// eventTime returns the time at which the event was processed, in milliseconds.
+func eventTime(event *binlogdatapb.VEvent) int64 {
+	if event.CurrentTime != 0 {
+		return event.CurrentTime / int64(time.Millisecond)
+	}
+	return time.Now().UnixMilli()
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debezium
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/json2"
+	"vitess.io/vitess/go/sqltypes"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+var testFields = []*querypb.Field{{
+	Name:  "id",
+	Type:  sqltypes.Int64,
+	Flags: uint32(querypb.MySqlFlag_PRI_KEY_FLAG | querypb.MySqlFlag_NOT_NULL_FLAG),
+}, {
+	Name: "name",
+	Type: sqltypes.VarChar,
+}, {
+	Name: "price",
+	Type: sqltypes.Decimal,
+}, {
+	Name: "data",
+	Type: sqltypes.VarBinary,
+}}
+
+func testRow(values ...sqltypes.Value) *querypb.Row {
+	return sqltypes.RowToProto3(values)
+}
+
+func testVgtid(gtid string) *binlogdatapb.VGtid {
+	return &binlogdatapb.VGtid{ShardGtids: []*binlogdatapb.ShardGtid{{
+		Keyspace: "ks",
+		Shard:    "-80",
+		Gtid:     gtid,
+	}}}
+}
+
+// testCopyVgtid returns the position of the shard while it copies the
+// fruits table, up to the id lastPK.
+func testCopyVgtid(gtid string, lastPK int64) *binlogdatapb.VGtid {
+	vgtid := testVgtid(gtid)
+	vgtid.ShardGtids[0].TablePKs = []*binlogdatapb.TableLastPK{{
+		TableName: "fruits",
+		Lastpk:    sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"), strconv.FormatInt(lastPK, 10))),
+	}}
+	return vgtid
+}
+
+func mustMarshalVgtid(t *testing.T, vgtid *binlogdatapb.VGtid) string {
+	b, err := json2.MarshalPB(vgtid)
+	require.NoError(t, err)
+	s, err := json.Marshal(string(b))
+	require.NoError(t, err)
+	return string(s)
+}
+
+func marshalRecords(t *testing.T, records []*Record) []string {
+	var out []string
+	for _, record := range records {
+		b, err := json.Marshal(record)
+		require.NoError(t, err)
+		out = append(out, string(b))
+	}
+	return out
+}
+
+func TestConverter(t *testing.T) {
+	c := NewConverter("cdc", "19.0.0")
+	require.NoError(t, c.SetVgtid(testVgtid("")))
+
+	row1 := testRow(sqltypes.NewInt64(1), sqltypes.NewVarChar("apple"), sqltypes.NewDecimal("1.50"), sqltypes.NewVarBinary("\x01\x02"))
+	row1b := testRow(sqltypes.NewInt64(1), sqltypes.NewVarChar("apple"), sqltypes.NewDecimal("2.00"), sqltypes.NULL)
+	row2 := testRow(sqltypes.NewInt64(2), sqltypes.NewVarChar("pear"), sqltypes.NULL, sqltypes.NULL)
+
+	// the rows copied by the vstream are snapshot reads: like in the copy
+	// phase of the vstreamer, they are sent in a transaction, at the end of
+	// which the last primary key copied of the table moves forward
+	records, err := c.Convert([]*binlogdatapb.VEvent{{
+		Type:     binlogdatapb.VEventType_BEGIN,
+		Keyspace: "ks",
+		Shard:    "-80",
+	}, {
+		Type:       binlogdatapb.VEventType_FIELD,
+		Keyspace:   "ks",
+		Shard:      "-80",
+		FieldEvent: &binlogdatapb.FieldEvent{TableName: "ks.fruits", Fields: testFields},
+	}, {
+		Type:     binlogdatapb.VEventType_VGTID,
+		Keyspace: "ks",
+		Shard:    "-80",
+		Vgtid:    testVgtid("gtid0"),
+	}, {
+		Type:     binlogdatapb.VEventType_ROW,
+		Keyspace: "ks",
+		Shard:    "-80",
+		RowEvent: &binlogdatapb.RowEvent{
+			TableName:  "ks.fruits",
+			Keyspace:   "ks",
+			Shard:      "-80",
+			RowChanges: []*binlogdatapb.RowChange{{After: row1}},
+		},
+		CurrentTime: 1700000000123000000,
+	}, {
+		Type:     binlogdatapb.VEventType_VGTID,
+		Keyspace: "ks",
+		Shard:    "-80",
+		Vgtid:    testCopyVgtid("gtid0", 1),
+	}, {
+		Type:     binlogdatapb.VEventType_COMMIT,
+		Keyspace: "ks",
+		Shard:    "-80",
+	}})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	envelope := records[0].Value.(*Envelope)
+	assert.Equal(t, OpRead, envelope.Op)
+	assert.Equal(t, "true", envelope.Source.Snapshot)
+	assert.Contains(t, envelope.Source.Vgtid, `"tablePKs"`)
+	assert.Equal(t, []string{
+		`{"topic":"cdc.ks.fruits","key":{"id":1},"value":{"before":null,"after":{"id":1,"name":"apple","price":"1.50","data":"AQI="},"source":{"version":"19.0.0","connector":"vitess","name":"cdc","ts_ms":0,"snapshot":"true","db":"ks","keyspace":"ks","table":"fruits","shard":"-80","vgtid":` + mustMarshalVgtid(t, testCopyVgtid("gtid0", 1)) + `},"op":"r","ts_ms":1700000000123}}`,
+	}, marshalRecords(t, records))
+
+	// the changes replicated while the table is being copied are not
+	// snapshot reads, the last primary key copied does not move
+	records, err = c.Convert([]*binlogdatapb.VEvent{{
+		Type:     binlogdatapb.VEventType_BEGIN,
+		Keyspace: "ks",
+		Shard:    "-80",
+	}, {
+		Type:     binlogdatapb.VEventType_ROW,
+		Keyspace: "ks",
+		Shard:    "-80",
+		RowEvent: &binlogdatapb.RowEvent{
+			TableName:  "ks.fruits",
+			RowChanges: []*binlogdatapb.RowChange{{Before: row1, After: row1}},
+		},
+	}, {
+		Type:     binlogdatapb.VEventType_VGTID,
+		Keyspace: "ks",
+		Shard:    "-80",
+		Vgtid:    testCopyVgtid("gtid0b", 1),
+	}, {
+		Type:     binlogdatapb.VEventType_COMMIT,
+		Keyspace: "ks",
+		Shard:    "-80",
+	}, {
+		// the copy of the table completes
+		Type:     binlogdatapb.VEventType_BEGIN,
+		Keyspace: "ks",
+		Shard:    "-80",
+	}, {
+		Type:     binlogdatapb.VEventType_VGTID,
+		Keyspace: "ks",
+		Shard:    "-80",
+		Vgtid:    testVgtid("gtid0b"),
+	}, {
+		Type:     binlogdatapb.VEventType_COMMIT,
+		Keyspace: "ks",
+		Shard:    "-80",
+	}, {
+		Type:     binlogdatapb.VEventType_COPY_COMPLETED,
+		Keyspace: "ks",
+		Shard:    "-80",
+	}})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	envelope = records[0].Value.(*Envelope)
+	assert.Equal(t, OpUpdate, envelope.Op)
+	assert.Equal(t, "false", envelope.Source.Snapshot)
+
+	// the records of a transaction are returned when it commits
+	records, err = c.Convert([]*binlogdatapb.VEvent{{
+		Type:     binlogdatapb.VEventType_BEGIN,
+		Keyspace: "ks",
+		Shard:    "-80",
+	}, {
+		Type:     binlogdatapb.VEventType_ROW,
+		Keyspace: "ks",
+		Shard:    "-80",
+		RowEvent: &binlogdatapb.RowEvent{
+			TableName: "ks.fruits",
+			RowChanges: []*binlogdatapb.RowChange{
+				{After: row2},
+				{Before: row1, After: row1b},
+			},
+		},
+		Timestamp:   1700000000,
+		CurrentTime: 1700000001000000000,
+	}})
+	require.NoError(t, err)
+	require.Empty(t, records)
+
+	records, err = c.Convert([]*binlogdatapb.VEvent{{
+		Type:     binlogdatapb.VEventType_ROW,
+		Keyspace: "ks",
+		Shard:    "-80",
+		RowEvent: &binlogdatapb.RowEvent{
+			TableName:  "ks.fruits",
+			RowChanges: []*binlogdatapb.RowChange{{Before: row2}},
+		},
+		Timestamp:   1700000000,
+		CurrentTime: 1700000001000000000,
+	}, {
+		Type:     binlogdatapb.VEventType_VGTID,
+		Keyspace: "ks",
+		Shard:    "-80",
+		Vgtid:    testVgtid("gtid1"),
+	}, {
+		Type:     binlogdatapb.VEventType_COMMIT,
+		Keyspace: "ks",
+		Shard:    "-80",
+	}})
+	require.NoError(t, err)
+	source := `"source":{"version":"19.0.0","connector":"vitess","name":"cdc","ts_ms":1700000000000,"snapshot":"false","db":"ks","keyspace":"ks","table":"fruits","shard":"-80","vgtid":"{\"shardGtids\":[{\"keyspace\":\"ks\",\"shard\":\"-80\",\"gtid\":\"gtid1\"}]}"}`
+	assert.Equal(t, []string{
+		`{"topic":"cdc.ks.fruits","key":{"id":2},"value":{"before":null,"after":{"id":2,"name":"pear","price":null,"data":null},` + source + `,"op":"c","ts_ms":1700000001000}}`,
+		`{"topic":"cdc.ks.fruits","key":{"id":1},"value":{"before":{"id":1,"name":"apple","price":"1.50","data":"AQI="},"after":{"id":1,"name":"apple","price":"2.00","data":null},` + source + `,"op":"u","ts_ms":1700000001000}}`,
+		`{"topic":"cdc.ks.fruits","key":{"id":2},"value":{"before":{"id":2,"name":"pear","price":null,"data":null},"after":null,` + source + `,"op":"d","ts_ms":1700000001000}}`,
+	}, marshalRecords(t, records))
+
+	// schema changes go to the schema change topic
+	records, err = c.Convert([]*binlogdatapb.VEvent{{
+		Type:     binlogdatapb.VEventType_VGTID,
+		Keyspace: "ks",
+		Shard:    "-80",
+		Vgtid:    testVgtid("gtid2"),
+	}, {
+		Type:      binlogdatapb.VEventType_DDL,
+		Keyspace:  "ks",
+		Shard:     "-80",
+		Statement: "alter table fruits add column color varchar(32)",
+		Timestamp: 1700000002,
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`{"topic":"cdc","key":{"databaseName":"ks"},"value":{"source":{"version":"19.0.0","connector":"vitess","name":"cdc","ts_ms":1700000002000,"snapshot":"false","db":"ks","keyspace":"ks","table":"","shard":"-80","vgtid":"{\"shardGtids\":[{\"keyspace\":\"ks\",\"shard\":\"-80\",\"gtid\":\"gtid2\"}]}"},"databaseName":"ks","ddl":"alter table fruits add column color varchar(32)","tableChanges":[]}}`,
+	}, marshalRecords(t, records))
+}
+
+func TestConverterErrors(t *testing.T) {
+	c := NewConverter("cdc", "")
+	_, err := c.Convert([]*binlogdatapb.VEvent{{
+		Type:     binlogdatapb.VEventType_ROW,
+		Keyspace: "ks",
+		Shard:    "-80",
+		RowEvent: &binlogdatapb.RowEvent{TableName: "ks.fruits"},
+	}})
+	require.EqualError(t, err, "no field event received for table ks.fruits")
+}
+
+func TestConverterNoPrimaryKey(t *testing.T) {
+	c := NewConverter("cdc", "")
+	records, err := c.Convert([]*binlogdatapb.VEvent{{
+		Type:       binlogdatapb.VEventType_FIELD,
+		Keyspace:   "ks",
+		Shard:      "0",
+		FieldEvent: &binlogdatapb.FieldEvent{TableName: "ks.log", Fields: []*querypb.Field{{Name: "msg", Type: sqltypes.VarChar}}},
+	}, {
+		Type:     binlogdatapb.VEventType_BEGIN,
+		Keyspace: "ks",
+		Shard:    "0",
+	}, {
+		Type:     binlogdatapb.VEventType_ROW,
+		Keyspace: "ks",
+		Shard:    "0",
+		RowEvent: &binlogdatapb.RowEvent{
+			TableName:  "ks.log",
+			RowChanges: []*binlogdatapb.RowChange{{After: testRow(sqltypes.NewVarChar("hello"))}},
+		},
+	}, {
+		Type:     binlogdatapb.VEventType_COMMIT,
+		Keyspace: "ks",
+		Shard:    "0",
+	}})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Nil(t, records[0].Key)
+	assert.Equal(t, "cdc.ks.log", records[0].Topic)
+	assert.Equal(t, OpCreate, records[0].Value.(*Envelope).Op)
+}
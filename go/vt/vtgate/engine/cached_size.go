@@ -1376,6 +1376,61 @@ func (cached *VitessMetadata) CachedSize(alloc bool) int64 {
 	size += hack.RuntimeAllocSize(int64(len(cached.Value)))
 	return size
 }
+func (cached *Window) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(96)
+	}
+	// field Funcs []*vitess.io/vitess/go/vt/vtgate/engine.WindowFuncParams
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.Funcs)) * int64(8))
+		for _, elem := range cached.Funcs {
+			size += elem.CachedSize(true)
+		}
+	}
+	// field PartitionBy []*vitess.io/vitess/go/vt/vtgate/engine.GroupByParams
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.PartitionBy)) * int64(8))
+		for _, elem := range cached.PartitionBy {
+			size += elem.CachedSize(true)
+		}
+	}
+	// field OrderBy []*vitess.io/vitess/go/vt/vtgate/engine.GroupByParams
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.OrderBy)) * int64(8))
+		for _, elem := range cached.OrderBy {
+			size += elem.CachedSize(true)
+		}
+	}
+	// field Input vitess.io/vitess/go/vt/vtgate/engine.Primitive
+	if cc, ok := cached.Input.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	return size
+}
+func (cached *WindowFuncParams) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(80)
+	}
+	// field Offset vitess.io/vitess/go/vt/vtgate/evalengine.Expr
+	if cc, ok := cached.Offset.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	// field Alias string
+	size += hack.RuntimeAllocSize(int64(len(cached.Alias)))
+	// field Expr vitess.io/vitess/go/vt/sqlparser.Expr
+	if cc, ok := cached.Expr.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	return size
+}
 
 //go:nocheckptr
 func (cached *shardRoute) CachedSize(alloc bool) int64 {
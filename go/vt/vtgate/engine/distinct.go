@@ -46,6 +46,8 @@ type (
 	probeTable struct {
 		seenRows  map[evalengine.HashCode][]sqltypes.Row
 		checkCols []CheckCol
+		// size is the estimated memory used by the seen rows
+		size int64
 	}
 )
 
@@ -57,10 +59,22 @@ func (pt *probeTable) exists(inputRow sqltypes.Row) (bool, error) {
 		return false, err
 	}
 
+	exists, err := pt.contains(code, inputRow)
+	if err != nil || exists {
+		return exists, err
+	}
+
+	pt.seenRows[code] = append(pt.seenRows[code], inputRow)
+	pt.size += rowMemorySize(inputRow)
+
+	return false, nil
+}
+
+// contains returns true if the row, with the given hash code, has already been seen
+func (pt *probeTable) contains(code evalengine.HashCode, inputRow sqltypes.Row) (bool, error) {
 	existingRows, found := pt.seenRows[code]
 	if !found {
 		// nothing with this hash code found, we can be sure it's a not seen sqltypes.Row
-		pt.seenRows[code] = []sqltypes.Row{inputRow}
 		return false, nil
 	}
 
@@ -75,9 +89,6 @@ func (pt *probeTable) exists(inputRow sqltypes.Row) (bool, error) {
 			return true, nil
 		}
 	}
-
-	pt.seenRows[code] = append(existingRows, inputRow)
-
 	return false, nil
 }
 
@@ -197,8 +208,16 @@ func (d *Distinct) TryExecute(ctx context.Context, vcursor VCursor, bindVars map
 }
 
 // TryStreamExecute implements the Primitive interface
+// Once the rows seen so far go over the memory budget of the query, the rows that
+// have not been seen yet are spilled to disk in partitions, by hash code. Each of
+// the partitions is then uniqueified on its own after the whole input has been read.
 func (d *Distinct) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
 	pt := newProbeTable(d.CheckCols)
+	memory := newMemoryTracker(vcursor)
+	defer memory.release()
+	spill := newSpiller(vcursor, "Distinct")
+	defer spill.close()
+	var partitions *spillPartitioner
 
 	err := vcursor.StreamExecutePrimitive(ctx, d.Source, bindVars, wantfields, func(input *sqltypes.Result) error {
 		result := &sqltypes.Result{
@@ -206,18 +225,77 @@ func (d *Distinct) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVa
 			InsertID: input.InsertID,
 		}
 		for _, row := range input.Rows {
+			if partitions != nil {
+				if err := spillUnseenRow(pt, partitions, row); err != nil {
+					return err
+				}
+				continue
+			}
 			exists, err := pt.exists(row)
 			if err != nil {
 				return err
 			}
 			if !exists {
 				result.Rows = append(result.Rows, row)
+				if err := memory.growRows(row); err != nil {
+					return err
+				}
+			}
+		}
+		if partitions == nil && spill.overBudget(pt.size) {
+			var err error
+			if partitions, err = newSpillPartitioner(spill); err != nil {
+				return err
 			}
 		}
 		return callback(result.Truncate(len(d.CheckCols)))
 	})
+	if err != nil || partitions == nil {
+		return err
+	}
 
-	return err
+	for _, partition := range partitions.partitions {
+		ppt := newProbeTable(d.CheckCols)
+		err := partition.readBatches(func(rows []sqltypes.Row) error {
+			result := &sqltypes.Result{}
+			for _, row := range rows {
+				exists, err := ppt.exists(row)
+				if err != nil {
+					return err
+				}
+				if !exists {
+					result.Rows = append(result.Rows, row)
+					if err := memory.growRows(row); err != nil {
+						return err
+					}
+				}
+			}
+			if len(result.Rows) == 0 {
+				return nil
+			}
+			return callback(result.Truncate(len(d.CheckCols)))
+		})
+		if err != nil {
+			return err
+		}
+		spill.release(partition)
+		memory.shrink(ppt.size)
+	}
+	return nil
+}
+
+// spillUnseenRow writes the row to its partition, unless it is one of the rows
+// that have already been seen and sent.
+func spillUnseenRow(pt *probeTable, partitions *spillPartitioner, row sqltypes.Row) error {
+	code, err := pt.hashCodeForRow(row)
+	if err != nil {
+		return err
+	}
+	seen, err := pt.contains(code, row)
+	if err != nil || seen {
+		return err
+	}
+	return partitions.write(code, row)
 }
 
 // RouteType implements the Primitive interface
@@ -126,6 +126,23 @@ func TestDistinct(t *testing.T) {
 				require.EqualError(t, err, tc.expectedError)
 			}
 		})
+		t.Run(tc.testName+"-StreamExecuteSpill", func(t *testing.T) {
+			distinct := &Distinct{
+				Source:    &fakePrimitive{results: []*sqltypes.Result{tc.inputs}},
+				CheckCols: checkCols,
+			}
+
+			// the rows after the first two are spilled to disk, and come back in a different order
+			vc := &loggingVCursor{spillConfig: SpillConfig{MemoryBudget: 1, Dir: t.TempDir()}}
+			result, err := wrapStreamExecute(distinct, vc, nil, true)
+
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+				utils.MustMatch(t, sortedRowStrings(tc.expectedResult.Rows), sortedRowStrings(result.Rows), "result not what correct")
+			} else {
+				require.EqualError(t, err, tc.expectedError)
+			}
+		})
 	}
 }
 
@@ -113,7 +113,7 @@ func (t *noopVCursor) ThrottleApp(ctx context.Context, throttleAppRule *topodata
 	panic("implement me")
 }
 
-func (t *noopVCursor) ShowExec(ctx context.Context, command sqlparser.ShowCommandType, filter *sqlparser.ShowFilter) (*sqltypes.Result, error) {
+func (t *noopVCursor) ShowExec(ctx context.Context, command sqlparser.ShowCommandType, filter *sqlparser.ShowFilter, full bool) (*sqltypes.Result, error) {
 	panic("implement me")
 }
 
@@ -227,6 +227,10 @@ func (t *noopVCursor) InTransactionAndIsDML() bool {
 	panic("implement me")
 }
 
+func (t *noopVCursor) InUserTransaction() bool {
+	panic("implement me")
+}
+
 func (t *noopVCursor) FindRoutedTable(sqlparser.TableName) (*vindexes.Table, error) {
 	panic("implement me")
 }
@@ -321,6 +325,20 @@ func (t *noopVCursor) ExceedsMaxMemoryRows(numRows int) bool {
 	return !testIgnoreMaxMemoryRows && numRows > testMaxMemoryRows
 }
 
+func (t *noopVCursor) SpillConfig() SpillConfig {
+	return SpillConfig{}
+}
+
+func (t *noopVCursor) RecordSpill(string, int64) {
+}
+
+func (t *noopVCursor) GrowMemory(int64) error {
+	return nil
+}
+
+func (t *noopVCursor) ShrinkMemory(int64) {
+}
+
 func (t *noopVCursor) GetKeyspace() string {
 	return ""
 }
@@ -403,6 +421,36 @@ type loggingVCursor struct {
 	ksShardMap map[string][]string
 
 	shardSession []*srvtopo.ResolvedShard
+
+	spillConfig SpillConfig
+	spilled     map[string]int64
+
+	// memoryLimit makes GrowMemory fail once more bytes are held.
+	memoryLimit int64
+	memoryHeld  int64
+}
+
+func (f *loggingVCursor) SpillConfig() SpillConfig {
+	return f.spillConfig
+}
+
+func (f *loggingVCursor) RecordSpill(operator string, bytes int64) {
+	if f.spilled == nil {
+		f.spilled = map[string]int64{}
+	}
+	f.spilled[operator] += bytes
+}
+
+func (f *loggingVCursor) GrowMemory(bytes int64) error {
+	f.memoryHeld += bytes
+	if f.memoryLimit > 0 && f.memoryHeld > f.memoryLimit {
+		return fmt.Errorf("query memory limit of %d bytes exceeded", f.memoryLimit)
+	}
+	return nil
+}
+
+func (f *loggingVCursor) ShrinkMemory(bytes int64) {
+	f.memoryHeld -= bytes
 }
 
 func (f *loggingVCursor) HasCreatedTempTable() {
@@ -459,6 +507,10 @@ func (f *loggingVCursor) InTransactionAndIsDML() bool {
 	return false
 }
 
+func (f *loggingVCursor) InUserTransaction() bool {
+	return false
+}
+
 func (f *loggingVCursor) LookupRowLockShardSession() vtgatepb.CommitOrder {
 	panic("implement me")
 }
@@ -19,7 +19,6 @@ package engine
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"vitess.io/vitess/go/mysql/collations"
 	"vitess.io/vitess/go/sqltypes"
@@ -35,6 +34,9 @@ var _ Primitive = (*HashJoin)(nil)
 // The key to the map is the hashcode of the value for column that we are joining by.
 // Then the RHS is fetched, and we can check if the rows from the RHS matches any from the LHS.
 // When they match by hash code, we double-check that we are not working with a false positive by comparing the values.
+// For left joins, the LHS rows that matched no RHS row are returned with NULLs once all the RHS has been read.
+// The probe table is held in memory, so the join fails when it has more rows than vtgate allows in memory,
+// unless the join is streamed with a memory budget, in which case the inputs are spilled to disk.
 type HashJoin struct {
 	Opcode JoinOpcode
 
@@ -71,10 +73,13 @@ func (hj *HashJoin) TryExecute(ctx context.Context, vcursor VCursor, bindVars ma
 	}
 
 	// build the probe table from the LHS result
-	probeTable, err := hj.buildProbeTable(lresult)
-	if err != nil {
+	pt := hj.newProbeTable()
+	if err := pt.addLeftRows(lresult.Rows); err != nil {
 		return nil, err
 	}
+	if vcursor.ExceedsMaxMemoryRows(pt.size) {
+		return nil, fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+	}
 
 	rresult, err := vcursor.ExecutePrimitive(ctx, hj.Right, bindVars, wantfields)
 	if err != nil {
@@ -85,112 +90,326 @@ func (hj *HashJoin) TryExecute(ctx context.Context, vcursor VCursor, bindVars ma
 		Fields: joinFields(lresult.Fields, rresult.Fields, hj.Cols),
 	}
 
-	for _, currentRHSRow := range rresult.Rows {
-		joinVal := currentRHSRow[hj.RHSKey]
+	result.Rows, err = pt.probe(rresult.Rows)
+	if err != nil {
+		return nil, err
+	}
+	result.Rows = append(result.Rows, pt.unmatchedLeftRows()...)
+	if vcursor.ExceedsMaxMemoryRows(len(result.Rows)) {
+		return nil, fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+	}
+	return result, nil
+}
+
+// TryStreamExecute implements the Primitive interface
+// When the probe table goes over the memory budget of the query, both inputs are
+// spilled to disk in partitions by the hash code of their keys, and each pair of
+// partitions is joined on its own once the RHS has been read.
+func (hj *HashJoin) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
+	memory := newMemoryTracker(vcursor)
+	defer memory.release()
+	spill := newSpiller(vcursor, "HashJoin")
+	defer spill.close()
+
+	// build the probe table from the LHS result
+	pt := hj.newProbeTable()
+	var partitions *hashJoinPartitions
+	var lfields []*querypb.Field
+	err := vcursor.StreamExecutePrimitive(ctx, hj.Left, bindVars, wantfields, func(result *sqltypes.Result) error {
+		if len(lfields) == 0 && len(result.Fields) != 0 {
+			lfields = result.Fields
+		}
+		if partitions != nil {
+			return partitions.addLeftRows(result.Rows)
+		}
+		held := pt.bytes
+		if err := pt.addLeftRows(result.Rows); err != nil {
+			return err
+		}
+		if err := memory.grow(pt.bytes - held); err != nil {
+			return err
+		}
+		if spill.overBudget(pt.bytes) {
+			var err error
+			partitions, err = hj.spillProbeTable(spill, pt)
+			memory.release()
+			pt = nil
+			return err
+		}
+		if !spill.enabled() && vcursor.ExceedsMaxMemoryRows(pt.size) {
+			return fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = vcursor.StreamExecutePrimitive(ctx, hj.Right, bindVars, wantfields, func(result *sqltypes.Result) error {
+		// compare the results coming from the RHS with the probe-table
+		res := &sqltypes.Result{}
+		if len(result.Fields) != 0 {
+			res = &sqltypes.Result{
+				Fields: joinFields(lfields, result.Fields, hj.Cols),
+			}
+		}
+		if partitions != nil {
+			if err := partitions.addRightRows(result.Rows); err != nil {
+				return err
+			}
+		} else {
+			rows, err := pt.probe(result.Rows)
+			if err != nil {
+				return err
+			}
+			res.Rows = rows
+		}
+		if len(res.Rows) != 0 || len(res.Fields) != 0 {
+			return callback(res)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if partitions != nil {
+		return partitions.join(spill, memory, func(rows []sqltypes.Row) error {
+			return callback(&sqltypes.Result{Rows: rows})
+		})
+	}
+
+	// the LHS rows of a left join that matched nothing can only be sent
+	// once the whole RHS has been probed
+	if unmatched := pt.unmatchedLeftRows(); len(unmatched) > 0 {
+		return callback(&sqltypes.Result{Rows: unmatched})
+	}
+	return nil
+}
+
+// hashJoinProbeTable is the hash table built from the rows of the LHS, which
+// the rows of the RHS are looked up in.
+type hashJoinProbeTable struct {
+	hj   *HashJoin
+	rows map[evalengine.HashCode][]*hashJoinProbeRow
+
+	// all holds every LHS row of a left join, in order, so that the rows
+	// that did not match can be sent with NULLs for the RHS columns.
+	all []*hashJoinProbeRow
+	// size is the number of rows kept in memory, and bytes their estimated memory usage.
+	size  int
+	bytes int64
+}
+
+type hashJoinProbeRow struct {
+	row     sqltypes.Row
+	matched bool
+}
+
+func (hj *HashJoin) newProbeTable() *hashJoinProbeTable {
+	return &hashJoinProbeTable{
+		hj:   hj,
+		rows: map[evalengine.HashCode][]*hashJoinProbeRow{},
+	}
+}
+
+func (hj *HashJoin) hashcode(joinVal sqltypes.Value) (evalengine.HashCode, error) {
+	return evalengine.NullsafeHashcode(joinVal, hj.Collation, hj.ComparisonType)
+}
+
+// addLeftRows adds the rows to the probe table.
+func (pt *hashJoinProbeTable) addLeftRows(rows []sqltypes.Row) error {
+	for _, current := range rows {
+		joinVal := current[pt.hj.LHSKey]
+		if joinVal.IsNull() && pt.hj.Opcode != LeftJoin {
+			// the rows with a NULL key can't match anything
+			continue
+		}
+		pr := &hashJoinProbeRow{row: current}
+		pt.size++
+		pt.bytes += rowMemorySize(current)
+		if pt.hj.Opcode == LeftJoin {
+			pt.all = append(pt.all, pr)
+		}
+		if joinVal.IsNull() {
+			continue
+		}
+		hashcode, err := pt.hj.hashcode(joinVal)
+		if err != nil {
+			return err
+		}
+		pt.rows[hashcode] = append(pt.rows[hashcode], pr)
+	}
+	return nil
+}
+
+// probe returns the joined rows for the RHS rows that match the LHS.
+func (pt *hashJoinProbeTable) probe(rrows []sqltypes.Row) ([]sqltypes.Row, error) {
+	var out []sqltypes.Row
+	for _, currentRHSRow := range rrows {
+		joinVal := currentRHSRow[pt.hj.RHSKey]
 		if joinVal.IsNull() {
 			continue
 		}
-		hashcode, err := evalengine.NullsafeHashcode(joinVal, hj.Collation, hj.ComparisonType)
+		hashcode, err := pt.hj.hashcode(joinVal)
 		if err != nil {
 			return nil, err
 		}
-		lftRows := probeTable[hashcode]
-		for _, currentLHSRow := range lftRows {
-			lhsVal := currentLHSRow[hj.LHSKey]
+		for _, lhs := range pt.rows[hashcode] {
 			// hash codes can give false positives, so we need to check with a real comparison as well
-			cmp, err := evalengine.NullsafeCompare(joinVal, lhsVal, hj.Collation)
+			cmp, err := evalengine.NullsafeCompare(joinVal, lhs.row[pt.hj.LHSKey], pt.hj.Collation)
 			if err != nil {
 				return nil, err
 			}
 
 			if cmp == 0 {
 				// we have a match!
-				result.Rows = append(result.Rows, joinRows(currentLHSRow, currentRHSRow, hj.Cols))
+				lhs.matched = true
+				out = append(out, joinRows(lhs.row, currentRHSRow, pt.hj.Cols))
 			}
 		}
 	}
-
-	return result, nil
+	return out, nil
 }
 
-func (hj *HashJoin) buildProbeTable(lresult *sqltypes.Result) (map[evalengine.HashCode][]sqltypes.Row, error) {
-	probeTable := map[evalengine.HashCode][]sqltypes.Row{}
-	for _, current := range lresult.Rows {
-		joinVal := current[hj.LHSKey]
-		if joinVal.IsNull() {
-			continue
-		}
-		hashcode, err := evalengine.NullsafeHashcode(joinVal, hj.Collation, hj.ComparisonType)
-		if err != nil {
-			return nil, err
+// unmatchedLeftRows returns the LHS rows of a left join that did not
+// match any RHS row, with NULLs for the RHS columns.
+func (pt *hashJoinProbeTable) unmatchedLeftRows() []sqltypes.Row {
+	var out []sqltypes.Row
+	for _, lhs := range pt.all {
+		if !lhs.matched {
+			out = append(out, joinRows(lhs.row, nil, pt.hj.Cols))
 		}
-		probeTable[hashcode] = append(probeTable[hashcode], current)
 	}
-	return probeTable, nil
+	return out
 }
 
-// TryStreamExecute implements the Primitive interface
-func (hj *HashJoin) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
-	// build the probe table from the LHS result
-	probeTable := map[evalengine.HashCode][]sqltypes.Row{}
-	var lfields []*querypb.Field
-	err := vcursor.StreamExecutePrimitive(ctx, hj.Left, bindVars, wantfields, func(result *sqltypes.Result) error {
-		if len(lfields) == 0 && len(result.Fields) != 0 {
-			lfields = result.Fields
+// hashJoinPartitions holds the rows of a hash join that went over the memory budget,
+// split in partitions by the hash code of their keys.
+type hashJoinPartitions struct {
+	hj          *HashJoin
+	left, right *spillPartitioner
+
+	// nullKeys holds the LHS rows of a left join with a NULL key, which can't match anything
+	nullKeys *spillFile
+}
+
+// spillProbeTable moves the rows of the probe table to disk.
+func (hj *HashJoin) spillProbeTable(spill *spiller, pt *hashJoinProbeTable) (*hashJoinPartitions, error) {
+	var err error
+	p := &hashJoinPartitions{hj: hj}
+	if p.left, err = newSpillPartitioner(spill); err != nil {
+		return nil, err
+	}
+	if p.right, err = newSpillPartitioner(spill); err != nil {
+		return nil, err
+	}
+	if hj.Opcode == LeftJoin {
+		if p.nullKeys, err = spill.newFile(); err != nil {
+			return nil, err
 		}
-		for _, current := range result.Rows {
-			joinVal := current[hj.LHSKey]
-			if joinVal.IsNull() {
-				continue
+		for _, pr := range pt.all {
+			if err := p.addLeftRow(pr.row); err != nil {
+				return nil, err
 			}
-			hashcode, err := evalengine.NullsafeHashcode(joinVal, hj.Collation, hj.ComparisonType)
-			if err != nil {
-				return err
+		}
+		return p, nil
+	}
+	for _, rows := range pt.rows {
+		for _, pr := range rows {
+			if err := p.addLeftRow(pr.row); err != nil {
+				return nil, err
 			}
-			probeTable[hashcode] = append(probeTable[hashcode], current)
 		}
-		return nil
-	})
+	}
+	return p, nil
+}
+
+func (p *hashJoinPartitions) addLeftRows(rows []sqltypes.Row) error {
+	for _, row := range rows {
+		if err := p.addLeftRow(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *hashJoinPartitions) addLeftRow(row sqltypes.Row) error {
+	joinVal := row[p.hj.LHSKey]
+	if joinVal.IsNull() {
+		if p.nullKeys == nil {
+			return nil
+		}
+		return p.nullKeys.write(row)
+	}
+	hashcode, err := p.hj.hashcode(joinVal)
 	if err != nil {
 		return err
 	}
+	return p.left.write(hashcode, row)
+}
 
-	return vcursor.StreamExecutePrimitive(ctx, hj.Right, bindVars, wantfields, func(result *sqltypes.Result) error {
-		// compare the results coming from the RHS with the probe-table
-		res := &sqltypes.Result{}
-		if len(result.Fields) != 0 {
-			res = &sqltypes.Result{
-				Fields: joinFields(lfields, result.Fields, hj.Cols),
-			}
+func (p *hashJoinPartitions) addRightRows(rows []sqltypes.Row) error {
+	for _, row := range rows {
+		joinVal := row[p.hj.RHSKey]
+		if joinVal.IsNull() {
+			continue
 		}
-		for _, currentRHSRow := range result.Rows {
-			joinVal := currentRHSRow[hj.RHSKey]
-			if joinVal.IsNull() {
-				continue
-			}
-			hashcode, err := evalengine.NullsafeHashcode(joinVal, hj.Collation, hj.ComparisonType)
-			if err != nil {
+		hashcode, err := p.hj.hashcode(joinVal)
+		if err != nil {
+			return err
+		}
+		if err := p.right.write(hashcode, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// join joins every LHS partition with the RHS partition of the same hash codes,
+// and sends the resulting rows to the callback.
+func (p *hashJoinPartitions) join(spill *spiller, memory *memoryTracker, callback func([]sqltypes.Row) error) error {
+	for i, left := range p.left.partitions {
+		right := p.right.partitions[i]
+
+		pt := p.hj.newProbeTable()
+		if err := left.readBatches(pt.addLeftRows); err != nil {
+			return err
+		}
+		spill.release(left)
+		if err := memory.grow(pt.bytes); err != nil {
+			return err
+		}
+
+		err := right.readBatches(func(rows []sqltypes.Row) error {
+			out, err := pt.probe(rows)
+			if err != nil || len(out) == 0 {
 				return err
 			}
-			lftRows := probeTable[hashcode]
-			for _, currentLHSRow := range lftRows {
-				lhsVal := currentLHSRow[hj.LHSKey]
-				// hash codes can give false positives, so we need to check with a real comparison as well
-				cmp, err := evalengine.NullsafeCompare(joinVal, lhsVal, hj.Collation)
-				if err != nil {
-					return err
-				}
-
-				if cmp == 0 {
-					// we have a match!
-					res.Rows = append(res.Rows, joinRows(currentLHSRow, currentRHSRow, hj.Cols))
-				}
-			}
+			return callback(out)
+		})
+		if err != nil {
+			return err
 		}
-		if len(res.Rows) != 0 || len(res.Fields) != 0 {
-			return callback(res)
+		spill.release(right)
+
+		if unmatched := pt.unmatchedLeftRows(); len(unmatched) > 0 {
+			if err := callback(unmatched); err != nil {
+				return err
+			}
 		}
+		memory.shrink(pt.bytes)
+	}
+
+	if p.nullKeys == nil {
 		return nil
+	}
+	return p.nullKeys.readBatches(func(rows []sqltypes.Row) error {
+		out := make([]sqltypes.Row, 0, len(rows))
+		for _, row := range rows {
+			out = append(out, joinRows(row, nil, p.hj.Cols))
+		}
+		return callback(out)
 	})
 }
 
@@ -242,7 +461,7 @@ func (hj *HashJoin) Inputs() ([]Primitive, []map[string]any) {
 func (hj *HashJoin) description() PrimitiveDescription {
 	other := map[string]any{
 		"TableName":         hj.GetTableName(),
-		"JoinColumnIndexes": strings.Trim(strings.Join(strings.Fields(fmt.Sprint(hj.Cols)), ","), "[]"),
+		"JoinColumnIndexes": joinColsDescription(hj.Cols),
 		"Predicate":         sqlparser.String(hj.ASTPred),
 		"ComparisonType":    hj.ComparisonType.String(),
 	}
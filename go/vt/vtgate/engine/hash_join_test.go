@@ -18,10 +18,12 @@ package engine
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"vitess.io/vitess/go/mysql/collations"
 	"vitess.io/vitess/go/sqltypes"
 	querypb "vitess.io/vitess/go/vt/proto/query"
 )
@@ -145,3 +147,246 @@ func TestHashJoinExecuteDifferentType(t *testing.T) {
 		"5|c| 5.0toto|g",
 	))
 }
+
+func TestHashJoinLeftJoin(t *testing.T) {
+	leftPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col1|col2",
+					"int64|varchar",
+				),
+				"1|a",
+				"2|b",
+				"null|c",
+				"3|d",
+			),
+		},
+	}
+	rightPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col3|col4",
+					"int64|varchar",
+				),
+				"3|e",
+				"1|f",
+				"null|g",
+			),
+		},
+	}
+
+	jn := &HashJoin{
+		Opcode: LeftJoin,
+		Left:   leftPrim,
+		Right:  rightPrim,
+		Cols:   []int{-1, -2, 1, 2},
+		LHSKey: 0,
+		RHSKey: 0,
+	}
+	want := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"col1|col2|col3|col4",
+			"int64|varchar|int64|varchar",
+		),
+		"3|d|3|e",
+		"1|a|1|f",
+		"2|b|null|null",
+		"null|c|null|null",
+	)
+	r, err := jn.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	expectResult(t, "jn.Execute", r, want)
+
+	leftPrim.rewind()
+	rightPrim.rewind()
+	r, err = wrapStreamExecute(jn, &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	expectResult(t, "jn.StreamExecute", r, want)
+}
+
+func TestHashJoinCollation(t *testing.T) {
+	leftPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col1",
+					"varchar",
+				),
+				"abc",
+				"Def",
+			),
+		},
+	}
+	rightPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col2",
+					"varchar",
+				),
+				"ABC",
+				"DEF",
+				"ghi",
+			),
+		},
+	}
+
+	jn := &HashJoin{
+		Opcode:         InnerJoin,
+		Left:           leftPrim,
+		Right:          rightPrim,
+		Cols:           []int{-1, 1},
+		LHSKey:         0,
+		RHSKey:         0,
+		Collation:      collations.CollationUtf8mb4ID,
+		ComparisonType: querypb.Type_VARCHAR,
+	}
+	r, err := jn.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	expectResult(t, "jn.Execute", r, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"col1|col2",
+			"varchar|varchar",
+		),
+		"abc|ABC",
+		"Def|DEF",
+	))
+}
+
+func TestHashJoinMaxMemoryRows(t *testing.T) {
+	saveMax := testMaxMemoryRows
+	saveIgnore := testIgnoreMaxMemoryRows
+	testMaxMemoryRows = 2
+	defer func() {
+		testMaxMemoryRows = saveMax
+		testIgnoreMaxMemoryRows = saveIgnore
+	}()
+
+	leftPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col1",
+					"int64",
+				),
+				"1",
+				"2",
+				"3",
+			),
+		},
+	}
+	rightPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col2",
+					"int64",
+				),
+				"1",
+			),
+		},
+	}
+	jn := &HashJoin{
+		Opcode: InnerJoin,
+		Left:   leftPrim,
+		Right:  rightPrim,
+		Cols:   []int{-1, 1},
+	}
+
+	for _, ignoreMaxMemoryRows := range []bool{false, true} {
+		t.Run(fmt.Sprintf("ignore %v", ignoreMaxMemoryRows), func(t *testing.T) {
+			testIgnoreMaxMemoryRows = ignoreMaxMemoryRows
+			leftPrim.rewind()
+			rightPrim.rewind()
+			_, err := jn.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+			if ignoreMaxMemoryRows {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, "in-memory row count exceeded allowed limit of 2")
+			}
+
+			leftPrim.rewind()
+			rightPrim.rewind()
+			_, err = wrapStreamExecute(jn, &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+			if ignoreMaxMemoryRows {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, "in-memory row count exceeded allowed limit of 2")
+			}
+		})
+	}
+}
+
+func TestHashJoinStreamExecuteSpill(t *testing.T) {
+	leftPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col1|col2",
+					"int64|varchar",
+				),
+				"1|a",
+				"2|b",
+				"null|c",
+				"3|d",
+				"1|e",
+				"4|f",
+			),
+		},
+	}
+	rightPrim := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col3|col4",
+					"int64|varchar",
+				),
+				"3|g",
+				"1|h",
+				"null|i",
+				"5|j",
+			),
+		},
+	}
+
+	tcases := []struct {
+		opcode JoinOpcode
+		want   []string
+	}{{
+		opcode: InnerJoin,
+		want:   []string{"1|a|1|h", "1|e|1|h", "3|d|3|g"},
+	}, {
+		opcode: LeftJoin,
+		want:   []string{"1|a|1|h", "1|e|1|h", "3|d|3|g", "2|b|null|null", "null|c|null|null", "4|f|null|null"},
+	}}
+	for _, tc := range tcases {
+		t.Run(tc.opcode.String(), func(t *testing.T) {
+			leftPrim.rewind()
+			rightPrim.rewind()
+			jn := &HashJoin{
+				Opcode: tc.opcode,
+				Left:   leftPrim,
+				Right:  rightPrim,
+				Cols:   []int{-1, -2, 1, 2},
+			}
+
+			// the probe table goes over the budget as soon as the first LHS rows are added
+			vc := &loggingVCursor{spillConfig: SpillConfig{MemoryBudget: 1, Dir: t.TempDir()}}
+			r, err := wrapStreamExecute(jn, vc, map[string]*querypb.BindVariable{}, true)
+			require.NoError(t, err)
+
+			want := sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields(
+					"col1|col2|col3|col4",
+					"int64|varchar|int64|varchar",
+				),
+				tc.want...,
+			)
+			require.Equal(t, want.Fields, r.Fields)
+			require.Equal(t, sortedRowStrings(want.Rows), sortedRowStrings(r.Rows))
+			require.NotZero(t, vc.spilled["HashJoin"])
+		})
+	}
+}
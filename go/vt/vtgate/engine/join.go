@@ -283,7 +283,7 @@ func combineVars(bv1, bv2 map[string]*querypb.BindVariable) map[string]*querypb.
 func (jn *Join) description() PrimitiveDescription {
 	other := map[string]any{
 		"TableName":         jn.GetTableName(),
-		"JoinColumnIndexes": jn.joinColsDescription(),
+		"JoinColumnIndexes": joinColsDescription(jn.Cols),
 	}
 	if len(jn.Vars) > 0 {
 		other["JoinVars"] = orderedStringIntMap(jn.Vars)
@@ -295,9 +295,9 @@ func (jn *Join) description() PrimitiveDescription {
 	}
 }
 
-func (jn *Join) joinColsDescription() string {
+func joinColsDescription(cols []int) string {
 	var joinCols []string
-	for _, col := range jn.Cols {
+	for _, col := range cols {
 		if col < 0 {
 			joinCols = append(joinCols, fmt.Sprintf("L:%d", -col-1))
 		} else {
@@ -35,6 +35,8 @@ import (
 var _ Primitive = (*MemorySort)(nil)
 
 // MemorySort is a primitive that performs in-memory sorting.
+// When streaming without a limit, the rows are spilled to disk and sorted
+// with an external merge sort once they go over the memory budget of the query.
 type MemorySort struct {
 	UpperLimit evalengine.Expr
 	OrderBy    []OrderByParams
@@ -103,6 +105,14 @@ func (ms *MemorySort) TryStreamExecute(ctx context.Context, vcursor VCursor, bin
 		return callback(qr.Truncate(ms.TruncateColumnCount))
 	}
 
+	memory := newMemoryTracker(vcursor)
+	defer memory.release()
+	spill := newSpiller(vcursor, "MemorySort")
+	defer spill.close()
+	if ms.UpperLimit == nil && spill.enabled() {
+		return ms.streamExternalSort(ctx, vcursor, bindVars, wantfields, spill, memory, cb)
+	}
+
 	// You have to reverse the ordering because the highest values
 	// must be dropped once the upper limit is reached.
 	sh := &sortHeap{
@@ -117,10 +127,14 @@ func (ms *MemorySort) TryStreamExecute(ctx context.Context, vcursor VCursor, bin
 		}
 		for _, row := range qr.Rows {
 			heap.Push(sh, row)
+			if err := memory.growRows(row); err != nil {
+				return err
+			}
 			// Remove the highest element from the heap if the size is more than the count
 			// This optimization means that the maximum size of the heap is going to be (count + 1)
 			for len(sh.rows) > count {
-				_ = heap.Pop(sh)
+				popped := heap.Pop(sh).([]sqltypes.Value)
+				memory.shrink(rowMemorySize(popped))
 			}
 		}
 		if vcursor.ExceedsMaxMemoryRows(len(sh.rows)) {
@@ -144,6 +158,31 @@ func (ms *MemorySort) TryStreamExecute(ctx context.Context, vcursor VCursor, bin
 	return cb(&sqltypes.Result{Rows: sh.rows})
 }
 
+// streamExternalSort sorts the rows using an external merge sort, which spills
+// the rows to disk when they go over the memory budget of the query.
+func (ms *MemorySort) streamExternalSort(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, spill *spiller, memory *memoryTracker, cb func(*sqltypes.Result) error) error {
+	sorter := newSpillSorter(spill, memory, ms.OrderBy)
+	err := vcursor.StreamExecutePrimitive(ctx, ms.Input, bindVars, wantfields, func(qr *sqltypes.Result) error {
+		if len(qr.Fields) != 0 {
+			if err := cb(&sqltypes.Result{Fields: qr.Fields}); err != nil {
+				return err
+			}
+		}
+		for _, row := range qr.Rows {
+			if err := sorter.add(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return sorter.finish(math.MaxInt, func(rows []sqltypes.Row) error {
+		return cb(&sqltypes.Result{Rows: rows})
+	})
+}
+
 // GetFields satisfies the Primitive interface.
 func (ms *MemorySort) GetFields(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
 	return ms.Input.GetFields(ctx, vcursor, bindVars)
@@ -414,6 +414,51 @@ func TestMemorySortStreamExecute(t *testing.T) {
 	utils.MustMatch(t, wantResults, results)
 }
 
+func TestMemorySortStreamExecuteSpill(t *testing.T) {
+	fields := sqltypes.MakeTestFields(
+		"c1|c2",
+		"varbinary|decimal",
+	)
+	fp := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			fields,
+			"a|1",
+			"g|2",
+			"a|1",
+			"c|4",
+			"c|3",
+		)},
+	}
+
+	ms := &MemorySort{
+		OrderBy: []OrderByParams{{
+			WeightStringCol: -1,
+			Col:             1,
+		}},
+		Input: fp,
+	}
+
+	// every row is spilled in a sorted run of its own
+	vc := &loggingVCursor{spillConfig: SpillConfig{MemoryBudget: 1, Dir: t.TempDir()}}
+	var results []*sqltypes.Result
+	err := ms.TryStreamExecute(context.Background(), vc, nil, true, func(qr *sqltypes.Result) error {
+		results = append(results, qr)
+		return nil
+	})
+	require.NoError(t, err)
+
+	wantResults := sqltypes.MakeTestStreamingResults(
+		fields,
+		"a|1",
+		"a|1",
+		"g|2",
+		"c|3",
+		"c|4",
+	)
+	utils.MustMatch(t, wantResults, results)
+	require.NotZero(t, vc.spilled["MemorySort"])
+}
+
 func TestMemorySortGetFields(t *testing.T) {
 	result := sqltypes.MakeTestResult(
 		sqltypes.MakeTestFields(
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"unsafe"
+
+	"vitess.io/vitess/go/sqltypes"
+)
+
+const (
+	valueMemoryOverhead = int64(unsafe.Sizeof(sqltypes.Value{}))
+	rowMemoryOverhead   = int64(unsafe.Sizeof(sqltypes.Row{}))
+)
+
+// rowMemorySize returns an estimate of the memory used by a row.
+func rowMemorySize(row sqltypes.Row) int64 {
+	size := rowMemoryOverhead
+	for _, v := range row {
+		size += valueMemoryOverhead + int64(len(v.Raw()))
+	}
+	return size
+}
+
+// rowsMemorySize returns an estimate of the memory used by the rows.
+func rowsMemorySize(rows []sqltypes.Row) int64 {
+	var size int64
+	for _, row := range rows {
+		size += rowMemorySize(row)
+	}
+	return size
+}
+
+// memoryTracker accounts for the memory a primitive holds while it executes,
+// so that it counts towards the memory limits of the query.
+type memoryTracker struct {
+	vcursor VCursor
+	held    int64
+}
+
+func newMemoryTracker(vcursor VCursor) *memoryTracker {
+	return &memoryTracker{vcursor: vcursor}
+}
+
+// grow accounts for bytes newly held by the primitive. It fails when the
+// query has to stop because of its memory usage.
+func (mt *memoryTracker) grow(bytes int64) error {
+	mt.held += bytes
+	return mt.vcursor.GrowMemory(bytes)
+}
+
+// growRows accounts for the memory of rows newly held by the primitive.
+func (mt *memoryTracker) growRows(rows ...sqltypes.Row) error {
+	return mt.grow(rowsMemorySize(rows))
+}
+
+// shrink releases bytes the primitive no longer holds.
+func (mt *memoryTracker) shrink(bytes int64) {
+	mt.held -= bytes
+	mt.vcursor.ShrinkMemory(bytes)
+}
+
+// release releases all the memory held by the primitive.
+func (mt *memoryTracker) release() {
+	if mt.held != 0 {
+		mt.shrink(mt.held)
+	}
+}
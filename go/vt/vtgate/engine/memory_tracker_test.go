@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/vtgate/engine/opcode"
+)
+
+func TestStreamingPrimitivesTrackMemory(t *testing.T) {
+	input := func() *fakePrimitive {
+		return &fakePrimitive{
+			results: []*sqltypes.Result{sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields("a|b", "int64|varbinary"),
+				"3|c",
+				"1|a",
+				"2|b",
+				"1|a",
+			)},
+		}
+	}
+	primitives := map[string]func() Primitive{
+		"MemorySort": func() Primitive {
+			return &MemorySort{
+				OrderBy: []OrderByParams{{Col: 0, WeightStringCol: -1}},
+				Input:   input(),
+			}
+		},
+		"Distinct": func() Primitive {
+			return &Distinct{
+				Source: input(),
+				CheckCols: []CheckCol{
+					{Col: 0, Type: sqltypes.Int64, Collation: collations.CollationBinaryID},
+					{Col: 1, Type: sqltypes.VarBinary, Collation: collations.CollationBinaryID},
+				},
+			}
+		},
+		"HashJoin": func() Primitive {
+			return &HashJoin{
+				Opcode:         InnerJoin,
+				Left:           input(),
+				Right:          input(),
+				Cols:           []int{-1, 2},
+				ComparisonType: sqltypes.Int64,
+			}
+		},
+		"Window": func() Primitive {
+			return &Window{
+				Funcs:       []*WindowFuncParams{NewWindowFuncParam(opcode.WindowRowNumber, 1, "rn")},
+				PartitionBy: []*GroupByParams{{KeyCol: 0, WeightStringCol: -1}},
+				Input:       input(),
+			}
+		},
+	}
+
+	for name, primitive := range primitives {
+		t.Run(name, func(t *testing.T) {
+			vc := &loggingVCursor{}
+			_, err := wrapStreamExecute(primitive(), vc, nil, true)
+			require.NoError(t, err)
+			assert.Zero(t, vc.memoryHeld, "all the memory must be released once the primitive is done")
+
+			vc = &loggingVCursor{memoryLimit: 1}
+			_, err = wrapStreamExecute(primitive(), vc, nil, true)
+			require.EqualError(t, err, "query memory limit of 1 bytes exceeded")
+			assert.Zero(t, vc.memoryHeld, "all the memory must be released when the primitive fails")
+		})
+	}
+}
@@ -176,3 +176,111 @@ func (code AggregateOpcode) IsDistinct() bool {
 		return false
 	}
 }
+
+// WindowOpcode is the opcode of a window function evaluated by the Window primitive.
+type WindowOpcode int
+
+// These constants list the window functions that can be evaluated at the vtgate level.
+const (
+	WindowUnassigned = WindowOpcode(iota)
+	WindowRowNumber
+	WindowRank
+	WindowDenseRank
+	WindowLag
+	WindowLead
+	WindowCount
+	WindowCountStar
+	WindowSum
+	WindowMin
+	WindowMax
+	WindowAvg
+	_NumOfWindowOpCodes // This line must be last of the opcodes!
+)
+
+// SupportedWindowFunctions maps the names of the window functions
+// that can be evaluated at the vtgate level to their opcodes.
+var SupportedWindowFunctions = map[string]WindowOpcode{
+	"row_number": WindowRowNumber,
+	"rank":       WindowRank,
+	"dense_rank": WindowDenseRank,
+	"lag":        WindowLag,
+	"lead":       WindowLead,
+	"count":      WindowCount,
+	"count_star": WindowCountStar,
+	"sum":        WindowSum,
+	"min":        WindowMin,
+	"max":        WindowMax,
+	"avg":        WindowAvg,
+}
+
+var WindowName = map[WindowOpcode]string{
+	WindowRowNumber: "row_number",
+	WindowRank:      "rank",
+	WindowDenseRank: "dense_rank",
+	WindowLag:       "lag",
+	WindowLead:      "lead",
+	WindowCount:     "count",
+	WindowCountStar: "count_star",
+	WindowSum:       "sum",
+	WindowMin:       "min",
+	WindowMax:       "max",
+	WindowAvg:       "avg",
+}
+
+func (code WindowOpcode) String() string {
+	name := WindowName[code]
+	if name == "" {
+		name = "ERROR"
+	}
+	return name
+}
+
+// MarshalJSON serializes the WindowOpcode as a JSON string.
+// It's used for testing and diagnostics.
+func (code WindowOpcode) MarshalJSON() ([]byte, error) {
+	return ([]byte)(fmt.Sprintf("\"%s\"", code.String())), nil
+}
+
+// Type returns the type of the values produced by the window function, given the type of its argument
+func (code WindowOpcode) Type(typ querypb.Type) querypb.Type {
+	switch code {
+	case WindowUnassigned:
+		return sqltypes.Null
+	case WindowRowNumber, WindowRank, WindowDenseRank:
+		return sqltypes.Uint64
+	case WindowLag, WindowLead, WindowMin, WindowMax:
+		return typ
+	case WindowCount, WindowCountStar:
+		return sqltypes.Int64
+	case WindowSum:
+		return AggregateSum.Type(typ)
+	case WindowAvg:
+		if sqltypes.IsIntegral(typ) || sqltypes.IsDecimal(typ) {
+			return sqltypes.Decimal
+		}
+		return sqltypes.Float64
+	default:
+		panic(code.String()) // we have a unit test checking we never reach here
+	}
+}
+
+// IsRanking returns true for the window functions that only depend on the position
+// of the row inside its partition, and not on the value of an argument
+func (code WindowOpcode) IsRanking() bool {
+	switch code {
+	case WindowRowNumber, WindowRank, WindowDenseRank:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsAggregation returns true for the aggregation functions used as window functions
+func (code WindowOpcode) IsAggregation() bool {
+	switch code {
+	case WindowCount, WindowCountStar, WindowSum, WindowMin, WindowMax, WindowAvg:
+		return true
+	default:
+		return false
+	}
+}
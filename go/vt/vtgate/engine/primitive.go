@@ -55,6 +55,21 @@ type (
 		// if the max memory rows override directive is set to true
 		ExceedsMaxMemoryRows(numRows int) bool
 
+		// SpillConfig returns how the primitives holding rows in memory
+		// can spill them to disk.
+		SpillConfig() SpillConfig
+
+		// RecordSpill records the number of bytes an operator spilled to disk.
+		RecordSpill(operator string, bytes int64)
+
+		// GrowMemory accounts for bytes newly held in memory by the query. It fails when
+		// the query went over its memory limit, or was killed to relieve the memory
+		// pressure on vtgate.
+		GrowMemory(bytes int64) error
+
+		// ShrinkMemory releases bytes previously accounted for with GrowMemory.
+		ShrinkMemory(bytes int64)
+
 		Execute(ctx context.Context, method string, query string, bindVars map[string]*querypb.BindVariable, rollbackOnError bool, co vtgatepb.CommitOrder) (*sqltypes.Result, error)
 		AutocommitApproval() bool
 
@@ -93,6 +108,10 @@ type (
 
 		InTransactionAndIsDML() bool
 
+		// InUserTransaction returns true if the statement runs in a transaction
+		// the application opened, explicitly or by disabling autocommit.
+		InUserTransaction() bool
+
 		LookupRowLockShardSession() vtgatepb.CommitOrder
 
 		FindRoutedTable(tablename sqlparser.TableName) (*vindexes.Table, error)
@@ -108,7 +127,8 @@ type (
 		VStream(ctx context.Context, rss []*srvtopo.ResolvedShard, filter *binlogdatapb.Filter, gtid string, callback func(evs []*binlogdatapb.VEvent) error) error
 
 		// ShowExec takes in show command and use executor to execute the query, they are used when topo access is involved.
-		ShowExec(ctx context.Context, command sqlparser.ShowCommandType, filter *sqlparser.ShowFilter) (*sqltypes.Result, error)
+		// full is set when the FULL keyword was given.
+		ShowExec(ctx context.Context, command sqlparser.ShowCommandType, filter *sqlparser.ShowFilter, full bool) (*sqltypes.Result, error)
 		// SetExec takes in k,v pair and use executor to set them in topo metadata.
 		SetExec(ctx context.Context, name string, value string) error
 		// ThrottleApp sets a ThrottlerappRule in topo
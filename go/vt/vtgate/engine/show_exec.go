@@ -29,6 +29,7 @@ var _ Primitive = (*ShowExec)(nil)
 // ShowExec is a primitive to call into executor via vcursor.
 type ShowExec struct {
 	Command    sqlparser.ShowCommandType
+	Full       bool
 	ShowFilter *sqlparser.ShowFilter
 
 	noInputs
@@ -57,7 +58,7 @@ func (s *ShowExec) GetFields(ctx context.Context, vcursor VCursor, bindVars map[
 }
 
 func (s *ShowExec) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*query.BindVariable, wantfields bool) (*sqltypes.Result, error) {
-	return vcursor.ShowExec(ctx, s.Command, s.ShowFilter)
+	return vcursor.ShowExec(ctx, s.Command, s.ShowFilter, s.Full)
 }
 
 func (s *ShowExec) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*query.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
@@ -70,6 +71,9 @@ func (s *ShowExec) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVa
 
 func (s *ShowExec) description() PrimitiveDescription {
 	other := map[string]any{}
+	if s.Full {
+		other["Full"] = true
+	}
 	if s.ShowFilter != nil {
 		other["Filter"] = sqlparser.String(s.ShowFilter)
 	}
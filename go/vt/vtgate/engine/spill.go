@@ -0,0 +1,479 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"io"
+	"os"
+	"sort"
+
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+const (
+	// spillPartitions is the number of partitions the rows of a hash table
+	// are split in when they have to be spilled to disk.
+	spillPartitions = 16
+
+	// spillMergeWidth is the maximum number of sorted runs merged at once.
+	spillMergeWidth = 64
+
+	// spillBatchRows is the number of rows sent at once when the rows are read back from disk.
+	spillBatchRows = 1024
+)
+
+// SpillConfig configures the primitives that can spill the rows they
+// hold in memory to local temporary files.
+type SpillConfig struct {
+	// MemoryBudget is the number of bytes a primitive can hold in memory
+	// for a query before it starts spilling to disk. Spilling is disabled
+	// when it is zero.
+	MemoryBudget int64
+
+	// Dir is the directory the temporary files are created in. The default
+	// temporary directory is used when it is empty.
+	Dir string
+}
+
+// spiller manages the temporary files of a single execution of a primitive.
+// All of them are removed by close, which also records how much was spilled.
+type spiller struct {
+	vcursor  VCursor
+	config   SpillConfig
+	operator string
+
+	files   []*spillFile
+	spilled int64
+}
+
+func newSpiller(vcursor VCursor, operator string) *spiller {
+	return &spiller{
+		vcursor:  vcursor,
+		config:   vcursor.SpillConfig(),
+		operator: operator,
+	}
+}
+
+// enabled returns true if the primitive is allowed to spill to disk.
+func (s *spiller) enabled() bool {
+	return s.config.MemoryBudget > 0
+}
+
+// overBudget returns true if size bytes held in memory must be spilled.
+func (s *spiller) overBudget(size int64) bool {
+	return s.enabled() && size > s.config.MemoryBudget
+}
+
+func (s *spiller) newFile() (*spillFile, error) {
+	f, err := os.CreateTemp(s.config.Dir, "vtgate-spill-")
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot create a file to spill %s rows to", s.operator)
+	}
+	sf := &spillFile{file: f, w: bufio.NewWriter(f)}
+	s.files = append(s.files, sf)
+	return sf, nil
+}
+
+// release closes and removes a file before the spiller is closed.
+func (s *spiller) release(sf *spillFile) {
+	for i, f := range s.files {
+		if f == sf {
+			s.files = append(s.files[:i], s.files[i+1:]...)
+			break
+		}
+	}
+	s.spilled += sf.size
+	sf.close()
+}
+
+func (s *spiller) close() {
+	for _, sf := range s.files {
+		s.spilled += sf.size
+		sf.close()
+	}
+	s.files = nil
+	if s.spilled > 0 {
+		s.vcursor.RecordSpill(s.operator, s.spilled)
+		s.spilled = 0
+	}
+}
+
+// spillFile is a temporary file rows are written to, and then read back from.
+type spillFile struct {
+	file *os.File
+	w    *bufio.Writer
+	r    *bufio.Reader
+	buf  []byte
+
+	// rows and size are the number of rows and bytes written to the file.
+	rows int
+	size int64
+}
+
+// write encodes a row at the end of the file. Every value is written as
+// its type and length followed by its raw bytes.
+func (sf *spillFile) write(row sqltypes.Row) error {
+	buf := binary.AppendUvarint(sf.buf[:0], uint64(len(row)))
+	for _, v := range row {
+		buf = binary.AppendUvarint(buf, uint64(v.Type()))
+		buf = binary.AppendUvarint(buf, uint64(len(v.Raw())))
+		buf = append(buf, v.Raw()...)
+	}
+	sf.buf = buf
+
+	var header [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(header[:], uint64(len(buf)))
+	if _, err := sf.w.Write(header[:n]); err != nil {
+		return vterrors.Wrapf(err, "cannot write to spill file")
+	}
+	if _, err := sf.w.Write(buf); err != nil {
+		return vterrors.Wrapf(err, "cannot write to spill file")
+	}
+	sf.rows++
+	sf.size += int64(n + len(buf))
+	return nil
+}
+
+// rewind flushes the rows written so far, and starts reading from the beginning of the file.
+func (sf *spillFile) rewind() error {
+	if err := sf.w.Flush(); err != nil {
+		return vterrors.Wrapf(err, "cannot write to spill file")
+	}
+	if _, err := sf.file.Seek(0, io.SeekStart); err != nil {
+		return vterrors.Wrapf(err, "cannot read spill file")
+	}
+	sf.r = bufio.NewReader(sf.file)
+	return nil
+}
+
+// read returns the next row of the file, or io.EOF once all the rows have been read.
+func (sf *spillFile) read() (sqltypes.Row, error) {
+	length, err := binary.ReadUvarint(sf.r)
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot read spill file")
+	}
+	// every row gets its own buffer, since the values of the row keep pointing into it
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(sf.r, buf); err != nil {
+		return nil, vterrors.Wrapf(err, "cannot read spill file")
+	}
+
+	cols, n := binary.Uvarint(buf)
+	if n <= 0 {
+		return nil, vterrors.VT13001("corrupted spill file")
+	}
+	buf = buf[n:]
+	row := make(sqltypes.Row, 0, cols)
+	for i := uint64(0); i < cols; i++ {
+		typ, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return nil, vterrors.VT13001("corrupted spill file")
+		}
+		buf = buf[n:]
+		size, n := binary.Uvarint(buf)
+		if n <= 0 || size > uint64(len(buf)-n) {
+			return nil, vterrors.VT13001("corrupted spill file")
+		}
+		buf = buf[n:]
+		row = append(row, sqltypes.MakeTrusted(querypb.Type(typ), buf[:size:size]))
+		buf = buf[size:]
+	}
+	return row, nil
+}
+
+// readBatches sends all the rows of the file to the callback, a batch at a time.
+func (sf *spillFile) readBatches(callback func([]sqltypes.Row) error) error {
+	if err := sf.rewind(); err != nil {
+		return err
+	}
+	var batch []sqltypes.Row
+	for {
+		row, err := sf.read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		batch = append(batch, row)
+		if len(batch) == spillBatchRows {
+			if err := callback(batch); err != nil {
+				return err
+			}
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		return callback(batch)
+	}
+	return nil
+}
+
+func (sf *spillFile) close() {
+	name := sf.file.Name()
+	_ = sf.file.Close()
+	_ = os.Remove(name)
+}
+
+// spillSorter sorts rows with an external merge sort. The rows are kept
+// in memory until they go over the memory budget, at which point they are
+// sorted and written to disk as a run. The runs are merged once all the
+// rows have been added.
+type spillSorter struct {
+	spiller   *spiller
+	memory    *memoryTracker
+	comparers []*comparer
+
+	rows []sqltypes.Row
+	size int64
+	runs []*spillFile
+}
+
+func newSpillSorter(spiller *spiller, memory *memoryTracker, orderBy []OrderByParams) *spillSorter {
+	return &spillSorter{
+		spiller:   spiller,
+		memory:    memory,
+		comparers: extractSlices(orderBy),
+	}
+}
+
+func (s *spillSorter) add(row sqltypes.Row) error {
+	size := rowMemorySize(row)
+	s.rows = append(s.rows, row)
+	s.size += size
+	if err := s.memory.grow(size); err != nil {
+		return err
+	}
+	if s.spiller.overBudget(s.size) {
+		return s.flushRun()
+	}
+	return nil
+}
+
+func (s *spillSorter) sortRows() error {
+	sh := &sortHeap{
+		rows:      s.rows,
+		comparers: s.comparers,
+	}
+	sort.Sort(sh)
+	return sh.err
+}
+
+// flushRun writes the rows held in memory to disk as a sorted run.
+func (s *spillSorter) flushRun() error {
+	if err := s.sortRows(); err != nil {
+		return err
+	}
+	run, err := s.spiller.newFile()
+	if err != nil {
+		return err
+	}
+	for _, row := range s.rows {
+		if err := run.write(row); err != nil {
+			return err
+		}
+	}
+	s.runs = append(s.runs, run)
+	s.memory.shrink(s.size)
+	s.rows = nil
+	s.size = 0
+	return nil
+}
+
+// finish sends the first limit sorted rows to the callback.
+func (s *spillSorter) finish(limit int, callback func([]sqltypes.Row) error) error {
+	if len(s.runs) == 0 {
+		// everything fit in memory
+		if err := s.sortRows(); err != nil {
+			return err
+		}
+		rows := s.rows
+		if len(rows) > limit {
+			rows = rows[:limit]
+		}
+		return callback(rows)
+	}
+
+	if len(s.rows) > 0 {
+		if err := s.flushRun(); err != nil {
+			return err
+		}
+	}
+
+	// merge the runs until there are few enough of them to be merged at once
+	for len(s.runs) > spillMergeWidth {
+		merged, err := s.spiller.newFile()
+		if err != nil {
+			return err
+		}
+		inputs := s.runs[:spillMergeWidth]
+		err = s.merge(inputs, -1, func(rows []sqltypes.Row) error {
+			for _, row := range rows {
+				if err := merged.write(row); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, run := range inputs {
+			s.spiller.release(run)
+		}
+		s.runs = append(s.runs[spillMergeWidth:], merged)
+	}
+	return s.merge(s.runs, limit, callback)
+}
+
+// merge sends the rows of the sorted runs to the callback in order. All the rows
+// are sent when the limit is negative.
+func (s *spillSorter) merge(runs []*spillFile, limit int, callback func([]sqltypes.Row) error) error {
+	mh := &spillMergeHeap{comparers: s.comparers}
+	for _, run := range runs {
+		if err := run.rewind(); err != nil {
+			return err
+		}
+		row, err := run.read()
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		mh.runs = append(mh.runs, &spillMergeRun{file: run, row: row})
+	}
+	heap.Init(mh)
+
+	var batch []sqltypes.Row
+	for sent := 0; mh.Len() > 0 && sent != limit; sent++ {
+		if mh.err != nil {
+			return mh.err
+		}
+		next := mh.runs[0]
+		batch = append(batch, next.row)
+		if len(batch) == spillBatchRows {
+			if err := callback(batch); err != nil {
+				return err
+			}
+			batch = nil
+		}
+
+		row, err := next.file.read()
+		switch {
+		case err == io.EOF:
+			heap.Pop(mh)
+		case err != nil:
+			return err
+		default:
+			next.row = row
+			heap.Fix(mh, 0)
+		}
+	}
+	if mh.err != nil {
+		return mh.err
+	}
+	if len(batch) > 0 {
+		return callback(batch)
+	}
+	return nil
+}
+
+type spillMergeRun struct {
+	file *spillFile
+	row  sqltypes.Row
+}
+
+// spillMergeHeap orders the sorted runs by their current row.
+type spillMergeHeap struct {
+	runs      []*spillMergeRun
+	comparers []*comparer
+	err       error
+}
+
+// Len satisfies sort.Interface and heap.Interface.
+func (mh *spillMergeHeap) Len() int {
+	return len(mh.runs)
+}
+
+// Less satisfies sort.Interface and heap.Interface.
+func (mh *spillMergeHeap) Less(i, j int) bool {
+	for _, c := range mh.comparers {
+		if mh.err != nil {
+			return true
+		}
+		cmp, err := c.compare(mh.runs[i].row, mh.runs[j].row)
+		if err != nil {
+			mh.err = err
+			return true
+		}
+		if cmp == 0 {
+			continue
+		}
+		return cmp < 0
+	}
+	return false
+}
+
+// Swap satisfies sort.Interface and heap.Interface.
+func (mh *spillMergeHeap) Swap(i, j int) {
+	mh.runs[i], mh.runs[j] = mh.runs[j], mh.runs[i]
+}
+
+// Push satisfies heap.Interface.
+func (mh *spillMergeHeap) Push(x any) {
+	mh.runs = append(mh.runs, x.(*spillMergeRun))
+}
+
+// Pop satisfies heap.Interface.
+func (mh *spillMergeHeap) Pop() any {
+	n := len(mh.runs)
+	x := mh.runs[n-1]
+	mh.runs = mh.runs[:n-1]
+	return x
+}
+
+// spillPartitioner splits rows into partition files by the hash code of a column.
+type spillPartitioner struct {
+	spiller    *spiller
+	partitions []*spillFile
+}
+
+func newSpillPartitioner(spiller *spiller) (*spillPartitioner, error) {
+	sp := &spillPartitioner{spiller: spiller}
+	for i := 0; i < spillPartitions; i++ {
+		f, err := spiller.newFile()
+		if err != nil {
+			return nil, err
+		}
+		sp.partitions = append(sp.partitions, f)
+	}
+	return sp, nil
+}
+
+func (sp *spillPartitioner) write(code evalengine.HashCode, row sqltypes.Row) error {
+	return sp.partitions[code%spillPartitions].write(row)
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/test/utils"
+)
+
+func newTestSpiller(t *testing.T, budget int64) (*spiller, *loggingVCursor) {
+	vc := &loggingVCursor{spillConfig: SpillConfig{MemoryBudget: budget, Dir: t.TempDir()}}
+	return newSpiller(vc, "Test"), vc
+}
+
+func TestSpillFile(t *testing.T) {
+	spill, vc := newTestSpiller(t, 1)
+
+	rows := []sqltypes.Row{
+		{sqltypes.NewInt64(1), sqltypes.NewVarChar("a"), sqltypes.NULL},
+		{sqltypes.NewInt64(-2), sqltypes.NewVarChar(""), sqltypes.NewVarBinary("\x00\xff")},
+		{},
+		{sqltypes.NewFloat64(1.5), sqltypes.NewDecimal("3.14"), sqltypes.NewDatetime("2024-01-01 00:00:00")},
+	}
+
+	f, err := spill.newFile()
+	require.NoError(t, err)
+	for _, row := range rows {
+		require.NoError(t, f.write(row))
+	}
+	require.NoError(t, f.rewind())
+	for _, want := range rows {
+		got, err := f.read()
+		require.NoError(t, err)
+		require.Len(t, got, len(want))
+		for i := range want {
+			assert.Equal(t, want[i].Type(), got[i].Type())
+			assert.Equal(t, want[i].Raw(), got[i].Raw())
+			assert.Equal(t, want[i].IsNull(), got[i].IsNull())
+		}
+	}
+	_, err = f.read()
+	require.Equal(t, io.EOF, err)
+
+	spill.close()
+	entries, err := os.ReadDir(spill.config.Dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the spill files must be removed")
+	assert.Equal(t, f.size, vc.spilled["Test"])
+}
+
+func TestSpillSorter(t *testing.T) {
+	var rows []sqltypes.Row
+	for i := 0; i < 500; i++ {
+		rows = append(rows, sqltypes.Row{sqltypes.NewInt64(rand.Int63n(100)), sqltypes.NewVarChar(strconv.Itoa(i))})
+	}
+	orderBy := []OrderByParams{{Col: 0, WeightStringCol: -1, Desc: true}}
+
+	for _, budget := range []int64{0, 1, 2000} {
+		t.Run(strconv.FormatInt(budget, 10), func(t *testing.T) {
+			spill, vc := newTestSpiller(t, budget)
+			defer spill.close()
+
+			sorter := newSpillSorter(spill, newMemoryTracker(vc), orderBy)
+			for _, row := range rows {
+				require.NoError(t, sorter.add(row))
+			}
+			if budget == 1 {
+				// every row is a run of its own
+				require.Len(t, sorter.runs, len(rows))
+			}
+
+			var got []sqltypes.Row
+			err := sorter.finish(math.MaxInt, func(rows []sqltypes.Row) error {
+				got = append(got, rows...)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Len(t, got, len(rows))
+			for i := 1; i < len(got); i++ {
+				prev, _ := got[i-1][0].ToInt64()
+				cur, _ := got[i][0].ToInt64()
+				require.GreaterOrEqual(t, prev, cur)
+			}
+
+			spill.close()
+			if budget != 0 {
+				assert.NotZero(t, vc.spilled["Test"])
+			} else {
+				assert.Zero(t, vc.spilled["Test"])
+			}
+		})
+	}
+}
+
+func TestSpillSorterLimit(t *testing.T) {
+	spill, vc := newTestSpiller(t, 1)
+	defer spill.close()
+
+	sorter := newSpillSorter(spill, newMemoryTracker(vc), []OrderByParams{{Col: 0, WeightStringCol: -1}})
+	for _, v := range []int64{5, 3, 4, 1, 2} {
+		require.NoError(t, sorter.add(sqltypes.Row{sqltypes.NewInt64(v)}))
+	}
+	var got []sqltypes.Row
+	err := sorter.finish(3, func(rows []sqltypes.Row) error {
+		got = append(got, rows...)
+		return nil
+	})
+	require.NoError(t, err)
+	utils.MustMatch(t, []sqltypes.Row{
+		{sqltypes.NewInt64(1)},
+		{sqltypes.NewInt64(2)},
+		{sqltypes.NewInt64(3)},
+	}, got)
+}
+
+// sortedRowStrings returns the rows as sorted strings, to compare the
+// results of the primitives that do not keep the order of the rows when spilling.
+func sortedRowStrings(rows []sqltypes.Row) []string {
+	out := make([]string, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, fmt.Sprintf("%v", row))
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,551 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/slice"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+var _ Primitive = (*Window)(nil)
+
+// Window is a primitive that evaluates window functions at the vtgate level.
+// It expects the underlying primitive to feed results sorted by the PartitionBy
+// keys, followed by the ORDER BY of the window, which is usually done by sending
+// the ordering down to a scatter route and merge sorting the results.
+// All the window functions evaluated by a single Window share the same window
+// specification. The rows are returned in the same order and with the same shape
+// as they were received: the column of every window function is expected to hold
+// its argument, and is replaced by the value the function computed for that row.
+type Window struct {
+	// Funcs specifies the window functions to evaluate.
+	Funcs []*WindowFuncParams
+
+	// PartitionBy specifies the input values that split the rows into partitions.
+	PartitionBy []*GroupByParams
+
+	// OrderBy specifies the input values of the window ORDER BY clause.
+	// Rows with equal values for all of them are peers.
+	OrderBy []*GroupByParams
+
+	// TruncateColumnCount specifies the number of columns to return
+	// in the final result. Rest of the columns are truncated
+	// from the result received. If 0, no truncation happens.
+	TruncateColumnCount int `json:",omitempty"`
+
+	// Input is the primitive that will feed into this Primitive.
+	Input Primitive
+}
+
+// WindowFuncParams specify the parameters for each window function.
+type WindowFuncParams struct {
+	Opcode opcode.WindowOpcode
+
+	// Col is the input column holding the argument of the function,
+	// and the output column where the result of the function is returned.
+	Col int
+
+	// DefaultCol is the input column holding the default value of LAG and LEAD, -1 if there is none.
+	DefaultCol int
+
+	// Offset is the number of rows LAG and LEAD look behind or ahead. Defaults to one row if nil.
+	Offset evalengine.Expr
+
+	// Type and CollationID are the type of the argument, and are needed to compare values for MIN and MAX.
+	Type        sqltypes.Type
+	CollationID collations.ID
+
+	Alias string `json:",omitempty"`
+	Expr  sqlparser.Expr
+}
+
+// NewWindowFuncParam creates the parameters for a window function reading its argument from col
+func NewWindowFuncParam(opcode opcode.WindowOpcode, col int, alias string) *WindowFuncParams {
+	return &WindowFuncParams{
+		Opcode:     opcode,
+		Col:        col,
+		DefaultCol: -1,
+		Alias:      alias,
+		Type:       sqltypes.Unknown,
+	}
+}
+
+func (wf *WindowFuncParams) String() string {
+	out := fmt.Sprintf("%s(%d", wf.Opcode.String(), wf.Col)
+	if wf.Opcode == opcode.WindowLag || wf.Opcode == opcode.WindowLead {
+		offset := "1"
+		if wf.Offset != nil {
+			offset = evalengine.FormatExpr(wf.Offset)
+		}
+		out += ", " + offset
+		if wf.DefaultCol >= 0 {
+			out += fmt.Sprintf(", %d", wf.DefaultCol)
+		}
+	}
+	out += ")"
+	if sqltypes.IsText(wf.Type) && wf.CollationID != collations.Unknown {
+		out += " COLLATE " + collations.Local().LookupName(wf.CollationID)
+	}
+	if wf.Alias != "" {
+		out += " AS " + wf.Alias
+	}
+	return out
+}
+
+// RouteType returns a description of the query routing type used by the primitive
+func (w *Window) RouteType() string {
+	return w.Input.RouteType()
+}
+
+// GetKeyspaceName specifies the Keyspace that this primitive routes to.
+func (w *Window) GetKeyspaceName() string {
+	return w.Input.GetKeyspaceName()
+}
+
+// GetTableName specifies the table that this primitive routes to.
+func (w *Window) GetTableName() string {
+	return w.Input.GetTableName()
+}
+
+// SetTruncateColumnCount sets the truncate column count.
+func (w *Window) SetTruncateColumnCount(count int) {
+	w.TruncateColumnCount = count
+}
+
+// TryExecute is a Primitive function.
+func (w *Window) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, _ bool) (*sqltypes.Result, error) {
+	result, err := vcursor.ExecutePrimitive(
+		ctx,
+		w.Input,
+		bindVars,
+		true, /*wantFields - we need the input fields types to correctly calculate the output types*/
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := w.newWindowState(ctx, vcursor, bindVars, result.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &sqltypes.Result{
+		Fields: state.fields,
+		Rows:   make([][]sqltypes.Value, 0, len(result.Rows)),
+	}
+	for _, row := range result.Rows {
+		rows, err := state.add(row)
+		if err != nil {
+			return nil, err
+		}
+		out.Rows = append(out.Rows, rows...)
+	}
+
+	rows, err := state.flush()
+	if err != nil {
+		return nil, err
+	}
+	out.Rows = append(out.Rows, rows...)
+
+	return out.Truncate(w.TruncateColumnCount), nil
+}
+
+// TryStreamExecute is a Primitive function.
+func (w *Window) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, _ bool, callback func(*sqltypes.Result) error) error {
+	cb := func(qr *sqltypes.Result) error {
+		return callback(qr.Truncate(w.TruncateColumnCount))
+	}
+
+	var state *windowState
+	memory := newMemoryTracker(vcursor)
+	defer memory.release()
+	visitor := func(qr *sqltypes.Result) error {
+		var err error
+
+		if state == nil && len(qr.Fields) != 0 {
+			state, err = w.newWindowState(ctx, vcursor, bindVars, qr.Fields)
+			if err != nil {
+				return err
+			}
+			state.memory = memory
+			if err = cb(&sqltypes.Result{Fields: state.fields}); err != nil {
+				return err
+			}
+		}
+
+		var out [][]sqltypes.Value
+		for _, row := range qr.Rows {
+			rows, err := state.add(row)
+			if err != nil {
+				return err
+			}
+			out = append(out, rows...)
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return cb(&sqltypes.Result{Rows: out})
+	}
+
+	/* we need the input fields types to correctly calculate the output types */
+	err := vcursor.StreamExecutePrimitive(ctx, w.Input, bindVars, true, visitor)
+	if err != nil {
+		return err
+	}
+
+	if state == nil {
+		return nil
+	}
+	rows, err := state.flush()
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+	return cb(&sqltypes.Result{Rows: rows})
+}
+
+// GetFields is a Primitive function.
+func (w *Window) GetFields(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
+	qr, err := w.Input.GetFields(ctx, vcursor, bindVars)
+	if err != nil {
+		return nil, err
+	}
+
+	qr = &sqltypes.Result{Fields: w.outputFields(qr.Fields)}
+	return qr.Truncate(w.TruncateColumnCount), nil
+}
+
+// Inputs returns the Primitive input for this window
+func (w *Window) Inputs() ([]Primitive, []map[string]any) {
+	return []Primitive{w.Input}, nil
+}
+
+// NeedsTransaction implements the Primitive interface
+func (w *Window) NeedsTransaction() bool {
+	return w.Input.NeedsTransaction()
+}
+
+func (w *Window) outputFields(fields []*querypb.Field) []*querypb.Field {
+	fields = slice.Map(fields, func(from *querypb.Field) *querypb.Field { return from.CloneVT() })
+	for _, wf := range w.Funcs {
+		fields[wf.Col].Type = wf.Opcode.Type(fields[wf.Col].Type)
+		if wf.Alias != "" {
+			fields[wf.Col].Name = wf.Alias
+		}
+	}
+	return fields
+}
+
+func (w *Window) newWindowState(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, fields []*querypb.Field) (*windowState, error) {
+	state := &windowState{
+		window:  w,
+		vcursor: vcursor,
+		fields:  w.outputFields(fields),
+		offsets: make([]int, len(w.Funcs)),
+		aggrs:   make([]windowAggregator, len(w.Funcs)),
+	}
+
+	env := evalengine.NewExpressionEnv(ctx, bindVars, vcursor)
+	for i, wf := range w.Funcs {
+		switch {
+		case wf.Opcode == opcode.WindowLag || wf.Opcode == opcode.WindowLead:
+			state.offsets[i] = 1
+			if wf.Offset == nil {
+				continue
+			}
+			offset, err := getIntFrom(env, vcursor, wf.Offset)
+			if err != nil {
+				return nil, err
+			}
+			state.offsets[i] = offset
+		case wf.Opcode.IsAggregation():
+			aggr, err := newWindowAggregator(wf, fields[wf.Col].Type)
+			if err != nil {
+				return nil, err
+			}
+			state.aggrs[i] = aggr
+		}
+	}
+	return state, nil
+}
+
+// windowState buffers the rows of the partition being read, and evaluates
+// the window functions on all of them once the partition is complete.
+type windowState struct {
+	window  *Window
+	vcursor VCursor
+	fields  []*querypb.Field
+
+	// offsets holds the evaluated offset of every LAG and LEAD function
+	offsets []int
+	// aggrs holds the state of every aggregation used as a window function
+	aggrs []windowAggregator
+
+	partition [][]sqltypes.Value
+	// memory accounts for the rows of the partition when streaming, nil otherwise
+	memory *memoryTracker
+}
+
+// add buffers a new input row. If the row starts a new partition, the rows
+// of the previous partition are returned with all window functions evaluated.
+func (ws *windowState) add(row []sqltypes.Value) ([][]sqltypes.Value, error) {
+	var out [][]sqltypes.Value
+	if len(ws.partition) > 0 {
+		samePartition, err := peerRows(ws.window.PartitionBy, ws.partition[0], row)
+		if err != nil {
+			return nil, err
+		}
+		if !samePartition {
+			out, err = ws.flush()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	ws.partition = append(ws.partition, row)
+	if ws.memory != nil {
+		if err := ws.memory.growRows(row); err != nil {
+			return nil, err
+		}
+	}
+	if ws.vcursor.ExceedsMaxMemoryRows(len(ws.partition)) {
+		return nil, fmt.Errorf("in-memory row count exceeded allowed limit of %d", ws.vcursor.MaxMemoryRows())
+	}
+	return out, nil
+}
+
+// flush evaluates the window functions over the buffered partition and returns its rows
+func (ws *windowState) flush() ([][]sqltypes.Value, error) {
+	rows := ws.partition
+	ws.partition = nil
+	if ws.memory != nil {
+		ws.memory.release()
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	peers, err := ws.peerGroups(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([][]sqltypes.Value, len(rows))
+	for i, row := range rows {
+		out[i] = slices.Clone(row)
+	}
+
+	for i, wf := range ws.window.Funcs {
+		switch wf.Opcode {
+		case opcode.WindowRowNumber:
+			for idx := range rows {
+				out[idx][wf.Col] = sqltypes.NewUint64(uint64(idx + 1))
+			}
+		case opcode.WindowRank:
+			for _, group := range peers {
+				for idx := group[0]; idx < group[1]; idx++ {
+					out[idx][wf.Col] = sqltypes.NewUint64(uint64(group[0] + 1))
+				}
+			}
+		case opcode.WindowDenseRank:
+			for rank, group := range peers {
+				for idx := group[0]; idx < group[1]; idx++ {
+					out[idx][wf.Col] = sqltypes.NewUint64(uint64(rank + 1))
+				}
+			}
+		case opcode.WindowLag, opcode.WindowLead:
+			offset := ws.offsets[i]
+			if wf.Opcode == opcode.WindowLag {
+				offset = -offset
+			}
+			for idx, row := range rows {
+				switch from := idx + offset; {
+				case from >= 0 && from < len(rows):
+					out[idx][wf.Col] = rows[from][wf.Col]
+				case wf.DefaultCol >= 0:
+					out[idx][wf.Col] = row[wf.DefaultCol]
+				default:
+					out[idx][wf.Col] = sqltypes.NULL
+				}
+			}
+		default:
+			if err := ws.aggregate(ws.aggrs[i], wf, rows, peers, out); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
+
+// aggregate evaluates an aggregation function used as a window function. Without a
+// window ORDER BY the whole partition is aggregated, otherwise the value for every
+// row is the aggregation of all rows up to and including the peers of that row.
+func (ws *windowState) aggregate(aggr windowAggregator, wf *WindowFuncParams, rows [][]sqltypes.Value, peers [][2]int, out [][]sqltypes.Value) error {
+	aggr.reset()
+	for _, group := range peers {
+		for idx := group[0]; idx < group[1]; idx++ {
+			if err := aggr.add(rows[idx]); err != nil {
+				return err
+			}
+		}
+		value, err := aggr.result()
+		if err != nil {
+			return err
+		}
+		for idx := group[0]; idx < group[1]; idx++ {
+			out[idx][wf.Col] = value
+		}
+	}
+	return nil
+}
+
+// peerGroups returns the [start, end) ranges of rows in the partition that are peers
+func (ws *windowState) peerGroups(rows [][]sqltypes.Value) ([][2]int, error) {
+	var groups [][2]int
+	start := 0
+	for idx := 1; idx < len(rows); idx++ {
+		peer, err := peerRows(ws.window.OrderBy, rows[start], rows[idx])
+		if err != nil {
+			return nil, err
+		}
+		if !peer {
+			groups = append(groups, [2]int{start, idx})
+			start = idx
+		}
+	}
+	return append(groups, [2]int{start, len(rows)}), nil
+}
+
+// peerRows returns true if both rows have the same values for all the given keys
+func peerRows(keys []*GroupByParams, a, b []sqltypes.Value) (bool, error) {
+	for _, key := range keys {
+		cmp, err := evalengine.NullsafeCompare(a[key.KeyCol], b[key.KeyCol], key.CollationID)
+		if err != nil {
+			_, isComparisonErr := err.(evalengine.UnsupportedComparisonError)
+			_, isCollationErr := err.(evalengine.UnsupportedCollationError)
+			if !isComparisonErr && !isCollationErr || key.WeightStringCol == -1 {
+				return false, err
+			}
+			cmp, err = evalengine.NullsafeCompare(a[key.WeightStringCol], b[key.WeightStringCol], key.CollationID)
+			if err != nil {
+				return false, err
+			}
+		}
+		if cmp != 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// windowAggregator evaluates an aggregation function over a window frame
+type windowAggregator interface {
+	add(row []sqltypes.Value) error
+	result() (sqltypes.Value, error)
+	reset()
+}
+
+// windowAggregatorAdapter evaluates window functions using the aggregators of OrderedAggregate
+type windowAggregatorAdapter struct {
+	aggregator
+}
+
+func (a *windowAggregatorAdapter) result() (sqltypes.Value, error) {
+	return a.finish(), nil
+}
+
+type windowAggregatorAvg struct {
+	from  int
+	sum   evalengine.Sum
+	count int64
+}
+
+func (a *windowAggregatorAvg) add(row []sqltypes.Value) error {
+	if row[a.from].IsNull() {
+		return nil
+	}
+	a.count++
+	return a.sum.Add(row[a.from])
+}
+
+func (a *windowAggregatorAvg) result() (sqltypes.Value, error) {
+	if a.count == 0 {
+		return sqltypes.NULL, nil
+	}
+	return evalengine.Divide(a.sum.Result(), sqltypes.NewInt64(a.count))
+}
+
+func (a *windowAggregatorAvg) reset() {
+	a.sum.Reset()
+	a.count = 0
+}
+
+func newWindowAggregator(wf *WindowFuncParams, sourceType sqltypes.Type) (windowAggregator, error) {
+	noDistinct := aggregatorDistinct{column: -1}
+
+	var aggr aggregator
+	switch wf.Opcode {
+	case opcode.WindowCountStar:
+		aggr = &aggregatorCountStar{}
+	case opcode.WindowCount:
+		aggr = &aggregatorCount{from: wf.Col, distinct: noDistinct}
+	case opcode.WindowSum:
+		aggr = &aggregatorSum{from: wf.Col, sum: evalengine.NewAggregationSum(sourceType), distinct: noDistinct}
+	case opcode.WindowMin:
+		aggr = &aggregatorMin{aggregatorMinMax{from: wf.Col, minmax: evalengine.NewAggregationMinMax(sourceType, wf.CollationID)}}
+	case opcode.WindowMax:
+		aggr = &aggregatorMax{aggregatorMinMax{from: wf.Col, minmax: evalengine.NewAggregationMinMax(sourceType, wf.CollationID)}}
+	case opcode.WindowAvg:
+		return &windowAggregatorAvg{from: wf.Col, sum: evalengine.NewAggregationSum(sourceType)}, nil
+	default:
+		return nil, vterrors.VT13001(fmt.Sprintf("unexpected window aggregation: %s", wf.Opcode.String()))
+	}
+	return &windowAggregatorAdapter{aggregator: aggr}, nil
+}
+
+func windowFuncParamsToString(i any) string {
+	return i.(*WindowFuncParams).String()
+}
+
+func (w *Window) description() PrimitiveDescription {
+	other := map[string]any{
+		"Functions": GenericJoin(w.Funcs, windowFuncParamsToString),
+	}
+	if len(w.PartitionBy) > 0 {
+		other["PartitionBy"] = GenericJoin(w.PartitionBy, groupByParamsToString)
+	}
+	if len(w.OrderBy) > 0 {
+		other["OrderBy"] = GenericJoin(w.OrderBy, groupByParamsToString)
+	}
+	if w.TruncateColumnCount > 0 {
+		other["ResultColumns"] = w.TruncateColumnCount
+	}
+	return PrimitiveDescription{
+		OperatorType: "Window",
+		Other:        other,
+	}
+}
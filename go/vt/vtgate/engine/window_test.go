@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/test/utils"
+	. "vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+func newWindowTestPrimitive() *fakePrimitive {
+	return &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"p|o|1|1|1|o|o|o|o",
+				"varbinary|int64|int64|int64|int64|int64|int64|int64|int64",
+			),
+			"a|1|1|1|1|1|1|1|1",
+			"a|1|1|1|1|1|1|1|1",
+			"a|2|1|1|1|2|2|2|2",
+			"b|5|1|1|1|5|5|5|5",
+			"b|6|1|1|1|6|6|6|6",
+		)},
+	}
+}
+
+func newTestWindow(input Primitive) *Window {
+	return &Window{
+		Funcs: []*WindowFuncParams{
+			NewWindowFuncParam(WindowRowNumber, 2, "rn"),
+			NewWindowFuncParam(WindowRank, 3, "rk"),
+			NewWindowFuncParam(WindowDenseRank, 4, "drk"),
+			NewWindowFuncParam(WindowLag, 5, "prev"),
+			NewWindowFuncParam(WindowSum, 6, "running"),
+			NewWindowFuncParam(WindowCount, 7, "cnt"),
+			NewWindowFuncParam(WindowMax, 8, "mx"),
+		},
+		PartitionBy: []*GroupByParams{{KeyCol: 0, WeightStringCol: -1}},
+		OrderBy:     []*GroupByParams{{KeyCol: 1, WeightStringCol: -1}},
+		Input:       input,
+	}
+}
+
+var windowTestResultFields = sqltypes.MakeTestFields(
+	"p|o|rn|rk|drk|prev|running|cnt|mx",
+	"varbinary|int64|uint64|uint64|uint64|int64|decimal|int64|int64",
+)
+
+func TestWindowExecute(t *testing.T) {
+	w := newTestWindow(newWindowTestPrimitive())
+
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+
+	wantResult := sqltypes.MakeTestResult(
+		windowTestResultFields,
+		"a|1|1|1|1|null|2|2|1",
+		"a|1|2|1|1|1|2|2|1",
+		"a|2|3|3|2|1|4|3|2",
+		"b|5|1|1|1|null|5|1|5",
+		"b|6|2|2|2|5|11|2|6",
+	)
+	utils.MustMatch(t, wantResult, result)
+}
+
+func TestWindowStreamExecute(t *testing.T) {
+	w := newTestWindow(newWindowTestPrimitive())
+
+	var results []*sqltypes.Result
+	err := w.TryStreamExecute(context.Background(), &noopVCursor{}, nil, true, func(qr *sqltypes.Result) error {
+		results = append(results, qr)
+		return nil
+	})
+	require.NoError(t, err)
+
+	wantResults := sqltypes.MakeTestStreamingResults(
+		windowTestResultFields,
+		"a|1|1|1|1|null|2|2|1",
+		"a|1|2|1|1|1|2|2|1",
+		"a|2|3|3|2|1|4|3|2",
+		"---",
+		"b|5|1|1|1|null|5|1|5",
+		"b|6|2|2|2|5|11|2|6",
+	)
+	utils.MustMatch(t, wantResults, results)
+}
+
+func TestWindowWholePartitionAndLead(t *testing.T) {
+	fp := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"p|v|v|v|d",
+				"int64|int64|int64|int64|int64",
+			),
+			"1|10|10|10|0",
+			"1|20|20|20|0",
+			"1|30|30|30|0",
+			"2|40|40|40|0",
+		)},
+	}
+
+	lead := NewWindowFuncParam(WindowLead, 1, "nxt")
+	lead.Offset = evalengine.NewLiteralInt(2)
+	lead.DefaultCol = 4
+
+	w := &Window{
+		Funcs: []*WindowFuncParams{
+			lead,
+			NewWindowFuncParam(WindowAvg, 2, "average"),
+			NewWindowFuncParam(WindowMin, 3, "least"),
+		},
+		PartitionBy:         []*GroupByParams{{KeyCol: 0, WeightStringCol: -1}},
+		TruncateColumnCount: 4,
+		Input:               fp,
+	}
+
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+
+	wantResult := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"p|nxt|average|least",
+			"int64|int64|decimal|int64",
+		),
+		"1|30|20.0000|10",
+		"1|0|20.0000|10",
+		"1|0|20.0000|10",
+		"2|0|40.0000|40",
+	)
+	utils.MustMatch(t, wantResult, result)
+}
+
+func TestWindowMaxMemoryRows(t *testing.T) {
+	saveMax := testMaxMemoryRows
+	saveIgnore := testIgnoreMaxMemoryRows
+	testMaxMemoryRows = 2
+	defer func() {
+		testMaxMemoryRows = saveMax
+		testIgnoreMaxMemoryRows = saveIgnore
+	}()
+
+	testCases := []struct {
+		ignoreMaxMemoryRows bool
+		err                 string
+	}{
+		{true, ""},
+		{false, "in-memory row count exceeded allowed limit of 2"},
+	}
+	for _, test := range testCases {
+		w := newTestWindow(newWindowTestPrimitive())
+
+		testIgnoreMaxMemoryRows = test.ignoreMaxMemoryRows
+		_, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+		if test.err == "" {
+			require.NoError(t, err)
+		} else {
+			require.EqualError(t, err, test.err)
+		}
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -108,6 +109,13 @@ type Executor struct {
 	plans *PlanCache
 	epoch atomic.Uint32
 
+	// resultCache caches the results of the queries reading the tables
+	// it is enabled for. It is nil when the result cache is disabled.
+	resultCache *resultCache
+
+	// memory tracks the memory held by the queries being executed
+	memory *memoryAccountant
+
 	normalize       bool
 	warnShardedOnly bool
 
@@ -168,6 +176,7 @@ func NewExecutor(
 		plans:               plans,
 		warmingReadsPercent: warmingReadsPercent,
 		warmingReadsChannel: make(chan bool, warmingReadsConcurrency),
+		memory:              newMemoryAccountant(queryMemoryLimit, globalQueryMemoryLimit),
 	}
 
 	vschemaacl.Init()
@@ -199,6 +208,9 @@ func NewExecutor(
 		stats.NewCounterFunc("QueryPlanCacheMisses", "Query plan cache misses", func() int64 {
 			return e.plans.Metrics.Hits()
 		})
+		stats.NewGaugeFunc("VtgateQueryMemory", "Bytes of memory held by the queries being executed", func() int64 {
+			return e.memory.used.Load()
+		})
 		servenv.HTTPHandle(pathQueryPlans, e)
 		servenv.HTTPHandle(pathScatterStats, e)
 		servenv.HTTPHandle(pathVSchema, e)
@@ -666,7 +678,7 @@ func (e *Executor) handleKill(ctx context.Context, mysqlCtx vtgateservice.MySQLC
 	killStmt := stmt.(*sqlparser.Kill)
 	switch killStmt.Type {
 	case sqlparser.QueryType:
-		err = mysqlCtx.KillQuery(uint32(killStmt.ProcesslistID))
+		err = e.killQuery(ctx, mysqlCtx, uint32(killStmt.ProcesslistID))
 	default:
 		err = mysqlCtx.KillConnection(ctx, uint32(killStmt.ProcesslistID))
 	}
@@ -676,6 +688,24 @@ func (e *Executor) handleKill(ctx context.Context, mysqlCtx vtgateservice.MySQLC
 	return &sqltypes.Result{}, nil
 }
 
+// killQuery cancels the query executed by the connection, and kills all the
+// queries it spawned on the tablets.
+func (e *Executor) killQuery(ctx context.Context, mysqlCtx vtgateservice.MySQLConnection, connID uint32) error {
+	sessionUUID, err := mysqlCtx.SessionUUID(connID)
+	if err != nil {
+		return err
+	}
+	if err := mysqlCtx.KillQuery(connID); err != nil {
+		return err
+	}
+	if sessionUUID == "" {
+		// The connection has not executed any query yet.
+		return nil
+	}
+	_, err = e.scatterConn.KillQueries(ctx, sessionUUID)
+	return err
+}
+
 // CloseSession releases the current connection, which rollbacks open transactions and closes reserved connections.
 // It is called then the MySQL servers closes the connection to its client.
 func (e *Executor) CloseSession(ctx context.Context, safeSession *SafeSession) error {
@@ -891,6 +921,48 @@ func (e *Executor) showTablets(filter *sqlparser.ShowFilter) (*sqltypes.Result,
 	}, nil
 }
 
+// processListInfoLength is the length the statements are truncated to in
+// SHOW PROCESSLIST, unless the FULL keyword is given, as MySQL does.
+const processListInfoLength = 100
+
+func (e *Executor) showProcessList(ctx context.Context, mysqlCtx vtgateservice.MySQLConnection, full bool) (*sqltypes.Result, error) {
+	if mysqlCtx == nil {
+		return nil, vterrors.VT12001("show processlist works with access through mysql protocol")
+	}
+	// Like MySQL without the PROCESS privilege, the users only see their own
+	// connections, unless they are allowed to administer vitess.
+	user := callerid.EffectiveCallerIDFromContext(ctx).GetPrincipal()
+	allUsers := vschemaacl.Authorized(callerid.ImmediateCallerIDFromContext(ctx))
+	rows := [][]sqltypes.Value{}
+	for _, p := range mysqlCtx.ProcessList() {
+		if !allUsers && p.User != user {
+			continue
+		}
+		state := ""
+		if p.Info != "" {
+			state = "executing"
+		}
+		info := p.Info
+		if !full && len(info) > processListInfoLength {
+			info = info[:processListInfoLength]
+		}
+		rows = append(rows, buildVarCharRow(
+			strconv.FormatUint(uint64(p.ID), 10),
+			p.User,
+			p.Host,
+			p.DB,
+			p.Command,
+			strconv.FormatInt(int64(p.Time/time.Second), 10),
+			state,
+			info,
+		))
+	}
+	return &sqltypes.Result{
+		Fields: buildVarCharFields("Id", "User", "Host", "db", "Command", "Time", "State", "Info"),
+		Rows:   rows,
+	}, nil
+}
+
 func (e *Executor) showVitessReplicationStatus(ctx context.Context, filter *sqlparser.ShowFilter) (*sqltypes.Result, error) {
 	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
 	defer cancel()
@@ -991,6 +1063,10 @@ func (e *Executor) SaveVSchema(vschema *vindexes.VSchema, stats *VSchemaStats) {
 	}
 	e.vschemaStats = stats
 	e.ClearPlans()
+	if e.resultCache != nil {
+		// The routing of the tables may have changed.
+		e.resultCache.Clear()
+	}
 
 	if vschemaCounters != nil {
 		vschemaCounters.Add("Reload", 1)
@@ -1047,6 +1123,9 @@ func (e *Executor) getPlan(
 		return nil, err
 	}
 	vcursor.SetPriority(priority)
+	if e.resultCache != nil {
+		vcursor.resultCacheOptions = getResultCacheOptions(stmt)
+	}
 
 	setVarComment, err := prepareSetVarComment(vcursor, stmt)
 	if err != nil {
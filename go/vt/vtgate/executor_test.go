@@ -55,6 +55,7 @@ import (
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
 	"vitess.io/vitess/go/vt/vtgate/vschemaacl"
 	"vitess.io/vitess/go/vt/vtgate/vtgateservice"
+	"vitess.io/vitess/go/vt/vttablet/sandboxconn"
 )
 
 func TestExecutorResultsExceeded(t *testing.T) {
@@ -2868,8 +2869,10 @@ func TestExecutorKillStmt(t *testing.T) {
 }
 
 type fakeMysqlConnection struct {
-	ErrMsg string
-	Log    []string
+	ErrMsg    string
+	Log       []string
+	Sessions  map[uint32]string
+	Processes []*vtgateservice.Process
 }
 
 func (f *fakeMysqlConnection) KillQuery(connID uint32) error {
@@ -2888,8 +2891,107 @@ func (f *fakeMysqlConnection) KillConnection(ctx context.Context, connID uint32)
 	return nil
 }
 
+func (f *fakeMysqlConnection) SessionUUID(connID uint32) (string, error) {
+	if f.ErrMsg != "" {
+		return "", errors.New(f.ErrMsg)
+	}
+	return f.Sessions[connID], nil
+}
+
+func (f *fakeMysqlConnection) ProcessList() []*vtgateservice.Process {
+	return f.Processes
+}
+
 var _ vtgateservice.MySQLConnection = (*fakeMysqlConnection)(nil)
 
+// TestExecutorKillQueryOnTablets tests that kill query kills the queries of
+// the session on all the tablets.
+func TestExecutorKillQueryOnTablets(t *testing.T) {
+	executor, sbc1, sbc2, sbclookup, ctx := createExecutorEnv(t)
+	allowKillStmt = true
+
+	mysqlCtx := &fakeMysqlConnection{Sessions: map[uint32]string{42: "session-uuid"}}
+	_, err := executor.Execute(ctx, mysqlCtx, "TestExecutorKillQueryOnTablets", NewAutocommitSession(&vtgatepb.Session{}), "kill query 42", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kill query: 42"}, mysqlCtx.Log)
+	for _, sbc := range []*sandboxconn.SandboxConn{sbc1, sbc2, sbclookup} {
+		assert.EqualValues(t, 1, sbc.KillQueriesCount.Load())
+		assert.Equal(t, []string{"session-uuid"}, sbc.KilledSessionUUIDs)
+	}
+
+	// A connection which did not execute any query has no query to kill on the tablets.
+	_, err = executor.Execute(ctx, mysqlCtx, "TestExecutorKillQueryOnTablets", NewAutocommitSession(&vtgatepb.Session{}), "kill query 24", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, sbc1.KillQueriesCount.Load())
+
+	// The tablets which do not support killing queries, or stopped serving, are skipped.
+	sbc1.MustFailCodes[vtrpcpb.Code_UNIMPLEMENTED] = 1
+	sbc2.MustFailCodes[vtrpcpb.Code_CLUSTER_EVENT] = 1
+	_, err = executor.Execute(ctx, mysqlCtx, "TestExecutorKillQueryOnTablets", NewAutocommitSession(&vtgatepb.Session{}), "kill query 42", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, sbclookup.KillQueriesCount.Load())
+
+	// The other errors of the tablets are returned.
+	sbc1.MustFailCodes[vtrpcpb.Code_UNAVAILABLE] = 1
+	_, err = executor.Execute(ctx, mysqlCtx, "TestExecutorKillQueryOnTablets", NewAutocommitSession(&vtgatepb.Session{}), "kill query 42", nil)
+	require.ErrorContains(t, err, "failed to kill queries on tablet")
+	assert.EqualValues(t, 3, sbc2.KillQueriesCount.Load())
+}
+
+func TestExecutorShowProcessList(t *testing.T) {
+	executor, _, _, _, _ := createExecutorEnv(t)
+
+	longQuery := "select " + strings.Repeat("a", 200) + " from user"
+	mysqlCtx := &fakeMysqlConnection{Processes: []*vtgateservice.Process{{
+		ID:      1,
+		User:    "user1",
+		Host:    "127.0.0.1:1234",
+		DB:      "TestExecutor",
+		Command: "Query",
+		Time:    3500 * time.Millisecond,
+		Info:    longQuery,
+	}, {
+		ID:      2,
+		User:    "user2",
+		Host:    "127.0.0.1:5678",
+		Command: "Sleep",
+		Time:    time.Minute,
+	}}}
+	wantFields := buildVarCharFields("Id", "User", "Host", "db", "Command", "Time", "State", "Info")
+	user1Ctx := callerid.NewContext(context.Background(), callerid.NewEffectiveCallerID("user1", "", ""), &querypb.VTGateCallerID{Username: "user1"})
+
+	// The statements are truncated unless FULL is given.
+	qr, err := executor.Execute(user1Ctx, mysqlCtx, "TestExecutorShowProcessList", NewSafeSession(&vtgatepb.Session{}), "show processlist", nil)
+	require.NoError(t, err)
+	assert.Equal(t, wantFields, qr.Fields)
+	assert.Equal(t, [][]sqltypes.Value{
+		buildVarCharRow("1", "user1", "127.0.0.1:1234", "TestExecutor", "Query", "3", "executing", longQuery[:100]),
+	}, qr.Rows)
+
+	qr, err = executor.Execute(user1Ctx, mysqlCtx, "TestExecutorShowProcessList", NewSafeSession(&vtgatepb.Session{}), "show full processlist", nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]sqltypes.Value{
+		buildVarCharRow("1", "user1", "127.0.0.1:1234", "TestExecutor", "Query", "3", "executing", longQuery),
+	}, qr.Rows)
+
+	// The users allowed to administer vitess see the connections of all the users.
+	vschemaacl.AuthorizedDDLUsers = "user1"
+	vschemaacl.Init()
+	defer func() {
+		vschemaacl.AuthorizedDDLUsers = ""
+		vschemaacl.Init()
+	}()
+	qr, err = executor.Execute(user1Ctx, mysqlCtx, "TestExecutorShowProcessList", NewSafeSession(&vtgatepb.Session{}), "show full processlist", nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]sqltypes.Value{
+		buildVarCharRow("1", "user1", "127.0.0.1:1234", "TestExecutor", "Query", "3", "executing", longQuery),
+		buildVarCharRow("2", "user2", "127.0.0.1:5678", "", "Sleep", "60", "", ""),
+	}, qr.Rows)
+
+	_, err = executor.Execute(user1Ctx, nil, "TestExecutorShowProcessList", NewSafeSession(&vtgatepb.Session{}), "show processlist", nil)
+	require.EqualError(t, err, "VT12001: unsupported: show processlist works with access through mysql protocol")
+}
+
 func exec(executor *Executor, session *SafeSession, sql string) (*sqltypes.Result, error) {
 	return executor.Execute(context.Background(), nil, "TestExecute", session, sql, nil)
 }
@@ -194,6 +194,11 @@ func (conn *FakeVTGateConn) VStream(ctx context.Context, tabletType topodatapb.T
 	return nil, fmt.Errorf("NYI")
 }
 
+// VStreamAck please see vtgateconn.Impl.VStreamAck
+func (conn *FakeVTGateConn) VStreamAck(ctx context.Context, subscription string, vgtid *binlogdatapb.VGtid) error {
+	return nil
+}
+
 // Close please see vtgateconn.Impl.Close
 func (conn *FakeVTGateConn) Close() {
 }
@@ -274,6 +274,16 @@ func (conn *vtgateConn) VStream(ctx context.Context, tabletType topodatapb.Table
 	}, nil
 }
 
+func (conn *vtgateConn) VStreamAck(ctx context.Context, subscription string, vgtid *binlogdatapb.VGtid) error {
+	request := &vtgatepb.VStreamAckRequest{
+		CallerId:     callerid.EffectiveCallerIDFromContext(ctx),
+		Subscription: subscription,
+		Vgtid:        vgtid,
+	}
+	_, err := conn.c.VStreamAck(ctx, request)
+	return vterrors.FromGRPC(err)
+}
+
 func (conn *vtgateConn) Close() {
 	conn.cc.Close()
 }
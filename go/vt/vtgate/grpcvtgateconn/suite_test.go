@@ -250,6 +250,11 @@ func (f *fakeVTGateService) VStream(ctx context.Context, tabletType topodatapb.T
 	panic("unimplemented")
 }
 
+// VStreamAck is part of the VTGateService interface
+func (f *fakeVTGateService) VStreamAck(ctx context.Context, subscription string, vgtid *binlogdatapb.VGtid) error {
+	panic("unimplemented")
+}
+
 // CreateFakeServer returns the fake server for the tests
 func CreateFakeServer(t *testing.T) vtgateservice.VTGateService {
 	return &fakeVTGateService{
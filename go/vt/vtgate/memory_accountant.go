@@ -0,0 +1,254 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/logstats"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+var queryMemoryKills = stats.NewCountersWithSingleLabel(
+	"VtgateQueryMemoryKills",
+	"Number of queries killed because of their memory usage",
+	"Reason")
+
+// QueryMemoryTemplate is the status page template listing the queries that hold the most memory.
+const QueryMemoryTemplate = `
+<table>
+  <tr>
+    <th colspan="4">Memory held by queries: {{.Used}} bytes{{if .GlobalLimit}} (limit: {{.GlobalLimit}} bytes){{end}}{{if .QueryLimit}}, per query limit: {{.QueryLimit}} bytes{{end}}</th>
+  </tr>
+  <tr>
+    <th>Query</th>
+    <th>Memory (bytes)</th>
+    <th>Peak Memory (bytes)</th>
+    <th>Running Time</th>
+  </tr>
+  {{range .Queries}}
+  <tr>
+    <td>{{.SQL}}</td>
+    <td>{{.Used}}</td>
+    <td>{{.Peak}}</td>
+    <td>{{.Duration}}</td>
+  </tr>
+  {{end}}
+</table>
+`
+
+// memoryAccountant tracks the memory held by the queries executed by vtgate.
+// A query fails when it holds more than the per-query limit. When all the
+// queries together hold more than the global limit, the query holding the
+// most memory is killed, so that vtgate does not run out of memory.
+type memoryAccountant struct {
+	queryLimit  int64
+	globalLimit int64
+
+	used atomic.Int64
+
+	mu      sync.Mutex
+	queries map[*queryMemory]struct{}
+}
+
+// queryMemory is the memory held by a single query.
+type queryMemory struct {
+	accountant *memoryAccountant
+	logStats   *logstats.LogStats
+	cancel     context.CancelCauseFunc
+
+	used atomic.Int64
+	peak atomic.Int64
+
+	// mu guards killed, and keeps the memory of the query consistent with the
+	// memory it accounts for in the accountant: the memory of a killed query
+	// is released from the accountant right away, as it is going to be freed,
+	// so that it does not get more queries killed while it finishes.
+	mu     sync.Mutex
+	killed error
+}
+
+func newMemoryAccountant(queryLimit, globalLimit int64) *memoryAccountant {
+	return &memoryAccountant{
+		queryLimit:  queryLimit,
+		globalLimit: globalLimit,
+		queries:     map[*queryMemory]struct{}{},
+	}
+}
+
+// startQuery starts tracking the memory of a query. The returned context is
+// canceled if the query is killed to relieve the memory pressure.
+func (ma *memoryAccountant) startQuery(ctx context.Context, logStats *logstats.LogStats) (context.Context, *queryMemory) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	qm := &queryMemory{
+		accountant: ma,
+		logStats:   logStats,
+		cancel:     cancel,
+	}
+	ma.mu.Lock()
+	ma.queries[qm] = struct{}{}
+	ma.mu.Unlock()
+	return ctx, qm
+}
+
+// relievePressure kills the query holding the most memory.
+func (ma *memoryAccountant) relievePressure() {
+	ma.mu.Lock()
+	var largest *queryMemory
+	for qm := range ma.queries {
+		if qm.killedErr() != nil {
+			continue
+		}
+		if largest == nil || qm.used.Load() > largest.used.Load() {
+			largest = qm
+		}
+	}
+	ma.mu.Unlock()
+
+	if largest != nil {
+		largest.kill("MemoryPressure", vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED,
+			"query killed because vtgate went over its memory limit of %d bytes, while this query was holding %d bytes", ma.globalLimit, largest.used.Load()))
+	}
+}
+
+// grow accounts for bytes newly held by the query.
+func (qm *queryMemory) grow(bytes int64) error {
+	qm.mu.Lock()
+	used := qm.used.Add(bytes)
+	if used > qm.peak.Load() {
+		qm.peak.Store(used)
+	}
+	killed := qm.killed
+	var global int64
+	if killed == nil {
+		global = qm.accountant.used.Add(bytes)
+	}
+	qm.mu.Unlock()
+
+	if killed != nil {
+		return killed
+	}
+	if limit := qm.accountant.queryLimit; limit > 0 && used > limit {
+		err := vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "query went over its memory limit of %d bytes", limit)
+		qm.kill("QueryLimit", err)
+		return err
+	}
+	if limit := qm.accountant.globalLimit; limit > 0 && global > limit {
+		qm.accountant.relievePressure()
+		return qm.killedErr()
+	}
+	return nil
+}
+
+// shrink releases bytes the query no longer holds.
+func (qm *queryMemory) shrink(bytes int64) {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+	qm.used.Add(-bytes)
+	if qm.killed == nil {
+		qm.accountant.used.Add(-bytes)
+	}
+}
+
+func (qm *queryMemory) kill(reason string, err error) {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+	if qm.killed != nil {
+		return
+	}
+	qm.killed = err
+	qm.accountant.used.Add(-qm.used.Load())
+	qm.cancel(err)
+	queryMemoryKills.Add(reason, 1)
+}
+
+// killedErr returns the reason why the query was killed, or nil.
+func (qm *queryMemory) killedErr() error {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+	return qm.killed
+}
+
+// finish stops tracking the query, releasing all the memory it still holds.
+func (qm *queryMemory) finish() {
+	qm.accountant.mu.Lock()
+	delete(qm.accountant.queries, qm)
+	qm.accountant.mu.Unlock()
+
+	qm.mu.Lock()
+	used := qm.used.Swap(0)
+	if qm.killed == nil {
+		qm.accountant.used.Add(-used)
+	}
+	qm.mu.Unlock()
+	qm.logStats.PeakMemory = uint64(qm.peak.Load())
+	qm.cancel(context.Canceled)
+}
+
+// QueryMemoryStatus is the memory held by a running query, as shown on the status page.
+type QueryMemoryStatus struct {
+	SQL      string
+	Used     int64
+	Peak     int64
+	Duration time.Duration
+}
+
+// QueryMemoryStats is the memory held by the running queries, as shown on the status page.
+type QueryMemoryStats struct {
+	Used        int64
+	QueryLimit  int64
+	GlobalLimit int64
+	Queries     []QueryMemoryStatus
+}
+
+// maxQueryMemoryStatuses is the number of queries listed on the status page
+const maxQueryMemoryStatuses = 20
+
+func (ma *memoryAccountant) stats() *QueryMemoryStats {
+	st := &QueryMemoryStats{
+		Used:        ma.used.Load(),
+		QueryLimit:  ma.queryLimit,
+		GlobalLimit: ma.globalLimit,
+	}
+
+	ma.mu.Lock()
+	for qm := range ma.queries {
+		st.Queries = append(st.Queries, QueryMemoryStatus{
+			SQL:      sqlparser.TruncateForUI(qm.logStats.SQL),
+			Used:     qm.used.Load(),
+			Peak:     qm.peak.Load(),
+			Duration: time.Since(qm.logStats.StartTime),
+		})
+	}
+	ma.mu.Unlock()
+
+	sort.Slice(st.Queries, func(i, j int) bool {
+		return st.Queries[i].Used > st.Queries[j].Used
+	})
+	if len(st.Queries) > maxQueryMemoryStatuses {
+		st.Queries = st.Queries[:maxQueryMemoryStatuses]
+	}
+	return st
+}
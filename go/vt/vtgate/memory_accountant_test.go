@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/logstats"
+
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+func TestMemoryAccountantQueryLimit(t *testing.T) {
+	ma := newMemoryAccountant(100, 0)
+	ls := logstats.NewLogStats(context.Background(), "Execute", "select 1", "", nil)
+	ctx, qm := ma.startQuery(context.Background(), ls)
+
+	require.NoError(t, qm.grow(60))
+	qm.shrink(20)
+	require.NoError(t, qm.grow(50))
+	assert.EqualValues(t, 90, ma.used.Load())
+
+	err := qm.grow(20)
+	require.ErrorContains(t, err, "query went over its memory limit of 100 bytes")
+	assert.Equal(t, vtrpcpb.Code_RESOURCE_EXHAUSTED, vterrors.Code(err))
+	assert.Equal(t, err, context.Cause(ctx))
+
+	// once killed, the query cannot hold more memory
+	require.Equal(t, err, qm.grow(1))
+
+	qm.finish()
+	assert.Zero(t, ma.used.Load())
+	assert.EqualValues(t, 111, ls.PeakMemory)
+	assert.Empty(t, ma.stats().Queries)
+}
+
+func TestMemoryAccountantGlobalLimit(t *testing.T) {
+	ma := newMemoryAccountant(0, 100)
+
+	newQuery := func(sql string) (context.Context, *queryMemory) {
+		return ma.startQuery(context.Background(), logstats.NewLogStats(context.Background(), "Execute", sql, "", nil))
+	}
+	largeCtx, large := newQuery("select large")
+	smallCtx, small := newQuery("select small")
+
+	require.NoError(t, large.grow(70))
+	require.NoError(t, small.grow(20))
+
+	// the small query pushes vtgate over the limit, the large query is killed
+	require.NoError(t, small.grow(20))
+	err := large.killedErr()
+	require.ErrorContains(t, err, "query killed because vtgate went over its memory limit of 100 bytes")
+	assert.Equal(t, err, context.Cause(largeCtx))
+	require.NoError(t, smallCtx.Err())
+
+	// the memory of the killed query is released right away, so that the
+	// other queries are not killed while it finishes
+	assert.EqualValues(t, 40, ma.used.Load())
+	require.NoError(t, small.grow(50))
+	_, another := newQuery("select another")
+	require.NoError(t, another.grow(5))
+	require.NoError(t, smallCtx.Err())
+
+	require.Error(t, large.grow(10))
+	large.shrink(30)
+	large.finish()
+	assert.EqualValues(t, 95, ma.used.Load())
+	small.shrink(50)
+	another.finish()
+
+	// the query going over the limit is killed when it is the largest one
+	_, other := newQuery("select other")
+	require.NoError(t, other.grow(10))
+	require.Error(t, small.grow(60))
+	require.Error(t, context.Cause(smallCtx))
+	require.NoError(t, other.killedErr())
+
+	small.finish()
+	other.finish()
+	assert.Zero(t, ma.used.Load())
+}
+
+func TestMemoryAccountantStats(t *testing.T) {
+	ma := newMemoryAccountant(1000, 2000)
+
+	var queries []*queryMemory
+	for i, size := range []int64{10, 30, 20} {
+		_, qm := ma.startQuery(context.Background(), logstats.NewLogStats(context.Background(), "Execute", "select "+string(rune('a'+i)), "", nil))
+		require.NoError(t, qm.grow(size))
+		queries = append(queries, qm)
+	}
+
+	st := ma.stats()
+	assert.EqualValues(t, 60, st.Used)
+	assert.EqualValues(t, 1000, st.QueryLimit)
+	assert.EqualValues(t, 2000, st.GlobalLimit)
+	require.Len(t, st.Queries, 3)
+	assert.Equal(t, "select b", st.Queries[0].SQL)
+	assert.Equal(t, "select c", st.Queries[1].SQL)
+	assert.Equal(t, "select a", st.Queries[2].SQL)
+
+	for _, qm := range queries {
+		qm.finish()
+	}
+	assert.Zero(t, ma.stats().Used)
+}
+
+func TestExecutorQueryMemoryLimit(t *testing.T) {
+	executor, _, _, _, ctx := createExecutorEnv(t)
+	logChan := executor.queryLogger.Subscribe("Test")
+	defer executor.queryLogger.Unsubscribe(logChan)
+
+	session := &vtgatepb.Session{TargetString: "@primary"}
+
+	executor.memory = newMemoryAccountant(1<<20, 0)
+	_, err := executorExec(ctx, executor, session, "select id from `user`", nil)
+	require.NoError(t, err)
+	ls := getQueryLog(logChan)
+	require.NotNil(t, ls)
+	assert.NotZero(t, ls.PeakMemory)
+	assert.Zero(t, executor.memory.used.Load())
+
+	executor.memory = newMemoryAccountant(1, 0)
+	_, err = executorExec(ctx, executor, session, "select id from `user`", nil)
+	require.ErrorContains(t, err, "query went over its memory limit of 1 bytes")
+	assert.Equal(t, vtrpcpb.Code_RESOURCE_EXHAUSTED, vterrors.Code(err))
+	assert.Zero(t, executor.memory.used.Load())
+}
@@ -64,11 +64,21 @@ func (e *Executor) newExecute(
 	logStats *logstats.LogStats,
 	execPlan planExec, // used when there is a plan to execute
 	recResult txResult, // used when it's something simple like begin/commit/rollback/savepoint
-) error {
+) (err error) {
 	// 1: Prepare before planning and execution
 
+	// Track the memory held by the query, a query killed because of
+	// its memory usage reports why it was killed.
+	ctx, memory := e.memory.startQuery(ctx, logStats)
+	defer func() {
+		if killedErr := memory.killedErr(); killedErr != nil {
+			err = killedErr
+		}
+		memory.finish()
+	}()
+
 	// Start an implicit transaction if necessary.
-	err := e.startTxIfNecessary(ctx, safeSession)
+	err = e.startTxIfNecessary(ctx, safeSession)
 	if err != nil {
 		return err
 	}
@@ -101,6 +111,8 @@ func (e *Executor) newExecute(
 		if err != nil {
 			return err
 		}
+		vcursor.memory = memory
+		vcursor.mysqlCtx = mysqlCtx
 
 		// 3: Create a plan for the query
 		// If we are retrying, it is likely that the routing rules have changed and hence we need to
@@ -277,6 +289,16 @@ func (e *Executor) executePlan(
 	execStart time.Time,
 ) (*sqltypes.Result, error) {
 
+	var pending *pendingResult
+	if e.resultCache != nil {
+		var cached *sqltypes.Result
+		cached, pending = e.resultCache.lookup(ctx, plan, vcursor, bindVars)
+		if cached != nil {
+			e.setLogStats(logStats, plan, vcursor, execStart, nil, cached)
+			return cached, nil
+		}
+	}
+
 	// 4: Execute!
 	qr, err := vcursor.ExecutePrimitive(ctx, plan.Instructions, bindVars, true)
 
@@ -287,6 +309,9 @@ func (e *Executor) executePlan(
 	if err != nil {
 		return nil, e.rollbackExecIfNeeded(ctx, safeSession, bindVars, logStats, err)
 	}
+	if pending != nil {
+		e.resultCache.store(pending, qr)
+	}
 	return qr, nil
 }
 
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package planbuilder
+
+import (
+	"fmt"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+	"vitess.io/vitess/go/vt/vtgate/semantics"
+)
+
+var _ logicalPlan = (*hashJoin)(nil)
+
+// hashJoin is used to build a HashJoin primitive.
+type hashJoin struct {
+	// Left and Right are the nodes for the join.
+	Left, Right logicalPlan
+
+	// The Opcode tells us if this is an inner or outer join
+	Opcode engine.JoinOpcode
+
+	// These are the columns that will be produced by this plan.
+	// Negative offsets come from the LHS, and positive from the RHS
+	Cols []int
+
+	// LHSKey and RHSKey are the offsets of the values compared by the join
+	LHSKey, RHSKey int
+
+	// Predicate is the comparison of the join, used for the plan description
+	Predicate sqlparser.Expr
+
+	// ComparisonType and Collation are used to hash the values of the keys
+	ComparisonType sqltypes.Type
+	Collation      collations.ID
+}
+
+// Wireup implements the logicalPlan interface
+func (hj *hashJoin) Wireup(ctx *plancontext.PlanningContext) error {
+	err := hj.Left.Wireup(ctx)
+	if err != nil {
+		return err
+	}
+	return hj.Right.Wireup(ctx)
+}
+
+// Primitive implements the logicalPlan interface
+func (hj *hashJoin) Primitive() engine.Primitive {
+	return &engine.HashJoin{
+		Opcode:         hj.Opcode,
+		Left:           hj.Left.Primitive(),
+		Right:          hj.Right.Primitive(),
+		Cols:           hj.Cols,
+		LHSKey:         hj.LHSKey,
+		RHSKey:         hj.RHSKey,
+		ASTPred:        hj.Predicate,
+		Collation:      hj.Collation,
+		ComparisonType: hj.ComparisonType,
+	}
+}
+
+// Inputs implements the logicalPlan interface
+func (hj *hashJoin) Inputs() []logicalPlan {
+	return []logicalPlan{hj.Left, hj.Right}
+}
+
+// Rewrite implements the logicalPlan interface
+func (hj *hashJoin) Rewrite(inputs ...logicalPlan) error {
+	if len(inputs) != 2 {
+		return vterrors.VT13001(fmt.Sprintf("wrong number of children in hash join rewrite, got: %d, expect: 2", len(inputs)))
+	}
+	hj.Left = inputs[0]
+	hj.Right = inputs[1]
+	return nil
+}
+
+// ContainsTables implements the logicalPlan interface
+func (hj *hashJoin) ContainsTables() semantics.TableSet {
+	return hj.Left.ContainsTables().Merge(hj.Right.ContainsTables())
+}
+
+// OutputColumns implements the logicalPlan interface
+func (hj *hashJoin) OutputColumns() []sqlparser.SelectExpr {
+	return getOutputColumnsFromJoin(hj.Cols, hj.Left.OutputColumns(), hj.Right.OutputColumns())
+}
@@ -42,6 +42,8 @@ func transformToLogicalPlan(ctx *plancontext.PlanningContext, op ops.Operator) (
 		return transformRoutePlan(ctx, op)
 	case *operators.ApplyJoin:
 		return transformApplyJoinPlan(ctx, op)
+	case *operators.HashJoin:
+		return transformHashJoin(ctx, op)
 	case *operators.Union:
 		return transformUnionPlan(ctx, op)
 	case *operators.Vindex:
@@ -60,6 +62,8 @@ func transformToLogicalPlan(ctx *plancontext.PlanningContext, op ops.Operator) (
 		return transformOrdering(ctx, op)
 	case *operators.Aggregator:
 		return transformAggregator(ctx, op)
+	case *operators.Window:
+		return transformWindow(ctx, op)
 	case *operators.Distinct:
 		return transformDistinct(ctx, op)
 	case *operators.FkCascade:
@@ -203,6 +207,53 @@ func transformAggregator(ctx *plancontext.PlanningContext, op *operators.Aggrega
 	return oa, nil
 }
 
+func transformWindow(ctx *plancontext.PlanningContext, op *operators.Window) (logicalPlan, error) {
+	plan, err := transformToLogicalPlan(ctx, op.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	primitive := &engine.Window{
+		TruncateColumnCount: op.ResultColumns,
+	}
+
+	for _, wf := range op.Funcs {
+		param := engine.NewWindowFuncParam(wf.OpCode, wf.ColOffset, wf.Alias)
+		param.Expr = wf.Func
+		param.DefaultCol = wf.DefaultOffset
+		if arg := wf.GetArg(); arg != nil {
+			param.Type, param.CollationID, _ = ctx.SemTable.TypeForExpr(arg)
+		}
+		if n, _ := wf.GetOffsetAndDefault(); n != nil {
+			param.Offset, err = evalengine.Translate(n, nil)
+			if err != nil {
+				return nil, vterrors.Wrap(err, "unexpected expression in LAG/LEAD offset")
+			}
+		}
+		primitive.Funcs = append(primitive.Funcs, param)
+	}
+
+	toGroupByParams := func(keys []operators.WindowKey) []*engine.GroupByParams {
+		return slice.Map(keys, func(key operators.WindowKey) *engine.GroupByParams {
+			typ, col, _ := ctx.SemTable.TypeForExpr(key.Expr)
+			return &engine.GroupByParams{
+				KeyCol:          key.ColOffset,
+				WeightStringCol: key.WSOffset,
+				Expr:            key.Expr,
+				Type:            typ,
+				CollationID:     col,
+			}
+		})
+	}
+	primitive.PartitionBy = toGroupByParams(op.PartitionBy)
+	primitive.OrderBy = toGroupByParams(op.OrderBy)
+
+	return &window{
+		resultsBuilder: newResultsBuilder(plan, primitive),
+		eWindow:        primitive,
+	}, nil
+}
+
 func transformDistinct(ctx *plancontext.PlanningContext, op *operators.Distinct) (logicalPlan, error) {
 	src, err := transformToLogicalPlan(ctx, op.Source)
 	if err != nil {
@@ -377,6 +428,58 @@ func transformApplyJoinPlan(ctx *plancontext.PlanningContext, n *operators.Apply
 	}, nil
 }
 
+func transformHashJoin(ctx *plancontext.PlanningContext, op *operators.HashJoin) (logicalPlan, error) {
+	lhs, err := transformToLogicalPlan(ctx, op.LHS)
+	if err != nil {
+		return nil, err
+	}
+	rhs, err := transformToLogicalPlan(ctx, op.RHS)
+	if err != nil {
+		return nil, err
+	}
+	opCode := engine.InnerJoin
+	if op.LeftJoin {
+		opCode = engine.LeftJoin
+	}
+
+	var plan logicalPlan = &hashJoin{
+		Left:           lhs,
+		Right:          rhs,
+		Opcode:         opCode,
+		Cols:           op.ColumnOffsets,
+		LHSKey:         op.LHSKeyOffset,
+		RHSKey:         op.RHSKeyOffset,
+		Predicate:      op.JoinComparison,
+		ComparisonType: op.ComparisonType,
+		Collation:      op.Collation,
+	}
+	if op.EvalColumns == nil {
+		return plan, nil
+	}
+
+	// some of the columns use both sides, so they are evaluated on the
+	// output of the join
+	columns, err := op.GetColumns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var exprs []sqlparser.Expr
+	var columnNames []string
+	for _, col := range columns {
+		exprs = append(exprs, col.Expr)
+		columnNames = append(columnNames, col.ColumnName())
+	}
+	return &projection{
+		source:      plan,
+		columnNames: columnNames,
+		columns:     exprs,
+		primitive: &engine.Projection{
+			Cols:  columnNames,
+			Exprs: op.EvalColumns,
+		},
+	}, nil
+}
+
 func routeToEngineRoute(ctx *plancontext.PlanningContext, op *operators.Route) (*engine.Route, error) {
 	tableNames, err := getAllTableNames(op)
 	if err != nil {
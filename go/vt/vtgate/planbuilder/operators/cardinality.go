@@ -0,0 +1,276 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operators
+
+import (
+	"io"
+	"math"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators/ops"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators/rewrite"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+)
+
+// The cardinality estimation uses the table statistics collected by the
+// tablets. When a predicate can't be estimated from them, the classic
+// default selectivities are used instead.
+const (
+	defaultEqualitySelectivity = 0.1
+	defaultSelectivity         = 1.0 / 3
+
+	// routeCallCost is the cost of sending a query to the tablets, in rows,
+	// per unit of the cost of the routing. It makes the plans that send many
+	// queries, or scatter them to all the shards, more expensive than the
+	// ones that read a few more rows.
+	routeCallCost = 10
+)
+
+// estimatedCost returns the cost of executing the operator, based on the
+// table statistics. It returns false when the cost can't be estimated,
+// because the statistics of a table are not known.
+func estimatedCost(ctx *plancontext.PlanningContext, op ops.Operator) (float64, bool) {
+	switch op := op.(type) {
+	case *Route:
+		rows, ok := estimatedRows(ctx, op)
+		return routeCallCost*math.Max(float64(op.Cost()), 1) + rows, ok
+	case *ApplyJoin:
+		// The RHS is executed once for each row of the LHS, so the smaller
+		// side should be driving the join.
+		lhsCost, ok := estimatedCost(ctx, op.LHS)
+		if !ok {
+			return 0, false
+		}
+		lhsRows, ok := estimatedRows(ctx, op.LHS)
+		if !ok {
+			return 0, false
+		}
+		rhsCost, ok := estimatedCost(ctx, op.RHS)
+		if !ok {
+			return 0, false
+		}
+		return lhsCost + lhsRows*rhsCost, true
+	case *HashJoin:
+		// Both sides are executed once.
+		lhsCost, ok := estimatedCost(ctx, op.LHS)
+		if !ok {
+			return 0, false
+		}
+		rhsCost, ok := estimatedCost(ctx, op.RHS)
+		if !ok {
+			return 0, false
+		}
+		return lhsCost + rhsCost, true
+	case *Filter:
+		return estimatedCost(ctx, op.Source)
+	default:
+		return 0, false
+	}
+}
+
+// estimatedRows returns the number of rows produced by the operator, based
+// on the table statistics. It returns false when the number can't be
+// estimated.
+func estimatedRows(ctx *plancontext.PlanningContext, op ops.Operator) (float64, bool) {
+	switch op := op.(type) {
+	case *Route:
+		return estimatedRouteRows(ctx, op)
+	case *ApplyJoin:
+		lhs, ok := estimatedRows(ctx, op.LHS)
+		if !ok {
+			return 0, false
+		}
+		// The predicates of the join are pushed to the RHS, so this is the
+		// number of rows produced for each row of the LHS.
+		rhs, ok := estimatedRows(ctx, op.RHS)
+		if !ok {
+			return 0, false
+		}
+		if op.LeftJoin {
+			return lhs * math.Max(rhs, 1), true
+		}
+		return lhs * rhs, true
+	case *HashJoin:
+		lhs, ok := estimatedRows(ctx, op.LHS)
+		if !ok {
+			return 0, false
+		}
+		rhs, ok := estimatedRows(ctx, op.RHS)
+		if !ok {
+			return 0, false
+		}
+		rows := lhs * rhs * equalitySelectivity(ctx, op.LHSKey, op.RHSKey)
+		if op.LeftJoin {
+			return math.Max(rows, lhs), true
+		}
+		return rows, true
+	case *Filter:
+		rows, ok := estimatedRows(ctx, op.Source)
+		return rows * selectivityOf(ctx, op.Predicates), ok
+	default:
+		return 0, false
+	}
+}
+
+// estimatedRouteRows returns the number of rows produced by the tables of
+// the route, once filtered by the predicates pushed to it.
+func estimatedRouteRows(ctx *plancontext.PlanningContext, route *Route) (float64, bool) {
+	rows := 1.0
+	var predicates []sqlparser.Expr
+	addPredicates := func(exprs ...sqlparser.Expr) {
+		for _, expr := range exprs {
+			if !ctx.SemTable.ContainsExpr(expr, predicates) {
+				predicates = append(predicates, expr)
+			}
+		}
+	}
+
+	ok := true
+	_ = rewrite.Visit(route.Source, func(op ops.Operator) error {
+		switch op := op.(type) {
+		case *Table:
+			if op.VTable == nil || op.VTable.Statistics == nil {
+				ok = false
+				return io.EOF
+			}
+			rows *= math.Max(float64(op.VTable.Statistics.RowCount), 1)
+			addPredicates(op.QTable.Predicates...)
+		case *Filter:
+			addPredicates(op.Predicates...)
+		case *Join:
+			if op.Predicate != nil {
+				addPredicates(sqlparser.SplitAndExpression(nil, op.Predicate)...)
+			}
+		default:
+			ok = false
+			return io.EOF
+		}
+		return nil
+	})
+	if !ok {
+		return 0, false
+	}
+	return rows * selectivityOf(ctx, predicates), true
+}
+
+// selectivityOf returns the fraction of the rows that satisfy all the
+// predicates.
+func selectivityOf(ctx *plancontext.PlanningContext, predicates []sqlparser.Expr) float64 {
+	s := 1.0
+	for _, pred := range predicates {
+		s *= selectivity(ctx, pred)
+	}
+	return s
+}
+
+func selectivity(ctx *plancontext.PlanningContext, expr sqlparser.Expr) float64 {
+	switch expr := expr.(type) {
+	case *sqlparser.AndExpr:
+		return selectivity(ctx, expr.Left) * selectivity(ctx, expr.Right)
+	case *sqlparser.OrExpr:
+		l, r := selectivity(ctx, expr.Left), selectivity(ctx, expr.Right)
+		return l + r - l*r
+	case *sqlparser.NotExpr:
+		return 1 - selectivity(ctx, expr.Expr)
+	case *sqlparser.ComparisonExpr:
+		return comparisonSelectivity(ctx, expr)
+	case *sqlparser.IsExpr:
+		return defaultEqualitySelectivity
+	default:
+		return defaultSelectivity
+	}
+}
+
+func comparisonSelectivity(ctx *plancontext.PlanningContext, cmp *sqlparser.ComparisonExpr) float64 {
+	switch cmp.Operator {
+	case sqlparser.EqualOp, sqlparser.NullSafeEqualOp:
+		return equalitySelectivity(ctx, cmp.Left, cmp.Right)
+	case sqlparser.NotEqualOp:
+		return 1 - equalitySelectivity(ctx, cmp.Left, cmp.Right)
+	case sqlparser.InOp, sqlparser.NotInOp:
+		s := equalitySelectivity(ctx, cmp.Left, nil)
+		if tuple, ok := cmp.Right.(sqlparser.ValTuple); ok {
+			s = math.Min(s*float64(len(tuple)), 1)
+		}
+		if cmp.Operator == sqlparser.NotInOp {
+			return 1 - s
+		}
+		return s
+	default:
+		return defaultSelectivity
+	}
+}
+
+// equalitySelectivity returns the selectivity of the equality of two
+// expressions: one over the number of distinct values of the columns.
+func equalitySelectivity(ctx *plancontext.PlanningContext, left, right sqlparser.Expr) float64 {
+	ndv := 0.0
+	for _, expr := range []sqlparser.Expr{left, right} {
+		if n, ok := distinctValues(ctx, expr); ok {
+			ndv = math.Max(ndv, n)
+		}
+	}
+	if ndv == 0 {
+		return defaultEqualitySelectivity
+	}
+	return 1 / ndv
+}
+
+// distinctValues returns the number of distinct values of the column, from
+// the cardinality of the indexes that start with it.
+func distinctValues(ctx *plancontext.PlanningContext, expr sqlparser.Expr) (float64, bool) {
+	col, ok := expr.(*sqlparser.ColName)
+	if !ok {
+		return 0, false
+	}
+	ti, err := ctx.SemTable.TableInfoForExpr(col)
+	if err != nil {
+		return 0, false
+	}
+	vtable := ti.GetVindexTable()
+	if vtable == nil || vtable.Statistics == nil {
+		return 0, false
+	}
+
+	stats := vtable.Statistics
+	var ndv uint64
+	for _, index := range stats.Indexes {
+		if len(index.Columns) == 0 || !col.Name.EqualString(index.Columns[0]) {
+			continue
+		}
+		if index.Unique && len(index.Columns) == 1 {
+			ndv = max(ndv, stats.RowCount)
+		}
+		if len(index.Cardinality) > 0 {
+			ndv = max(ndv, index.Cardinality[0])
+		}
+	}
+	return float64(ndv), ndv > 0
+}
+
+// cheaperPlan returns true if the plan a is cheaper than the plan b. When
+// the table statistics are known for both, their estimated costs, which
+// include the routing costs, are compared, and the routing costs only break
+// the ties. Otherwise, only the routing costs are compared.
+func cheaperPlan(ctx *plancontext.PlanningContext, a ops.Operator, aCost int, b ops.Operator, bCost int) bool {
+	aEstimate, aOK := estimatedCost(ctx, a)
+	bEstimate, bOK := estimatedCost(ctx, b)
+	if !aOK || !bOK || aEstimate == bEstimate {
+		return aCost < bCost
+	}
+	return aEstimate < bEstimate
+}
@@ -0,0 +1,422 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operators
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/slice"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators/ops"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators/rewrite"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+)
+
+type (
+	// HashJoin is a join that reads all the rows of the LHS into a hash table,
+	// and then matches the rows of the RHS against it. Unlike the ApplyJoin,
+	// both sides are executed only once, so it's used for the cross-shard
+	// joins where neither side is selective.
+	HashJoin struct {
+		LHS, RHS ops.Operator
+
+		// LeftJoin will be true in the case of an outer join
+		LeftJoin bool
+
+		// JoinComparison is the equality the rows of the two sides are
+		// matched on. LHSKey and RHSKey are its two sides.
+		JoinComparison *sqlparser.ComparisonExpr
+		LHSKey, RHSKey sqlparser.Expr
+
+		// ComparisonType and Collation are used to hash the values of the
+		// keys, so that the values MySQL considers equal have the same hash.
+		ComparisonType sqltypes.Type
+		Collation      collations.ID
+
+		columns []hashJoinColumn
+
+		// After offset planning
+
+		// ColumnOffsets are the columns fetched from the inputs: negative
+		// values come from the LHS and positive values from the RHS.
+		ColumnOffsets []int
+
+		// LHSKeyOffset and RHSKeyOffset are the offsets of the keys in the
+		// results of the LHS and RHS.
+		LHSKeyOffset, RHSKeyOffset int
+
+		// EvalColumns is only set when some of the columns use both sides
+		// of the join. It then has an expression for each column, evaluated
+		// on the rows made of ColumnOffsets.
+		EvalColumns []evalengine.Expr
+	}
+
+	hashJoinColumn struct {
+		expr    *sqlparser.AliasedExpr
+		groupBy bool
+	}
+)
+
+var _ JoinOp = (*HashJoin)(nil)
+
+// tryHashJoin returns a hash join of the two operators, or nil if the apply
+// join should be used. The hash join is used for the cross-shard joins on a
+// single equality where neither side is selective: the apply join would
+// send a scatter query to the RHS for each row of the LHS, while the hash
+// join sends one query to each side. When the table statistics are known,
+// the hash join is only used if it's estimated to be cheaper. Otherwise
+// it's not used when predicates were pushed to the LHS, which can then
+// return few rows.
+func tryHashJoin(ctx *plancontext.PlanningContext, lhs, rhs ops.Operator, joinPredicates []sqlparser.Expr, inner bool, applyJoin *ApplyJoin) ops.Operator {
+	if len(joinPredicates) != 1 || !isScatterRoute(applyJoin.LHS) || !isScatterRoute(applyJoin.RHS) {
+		return nil
+	}
+	cmp, ok := joinPredicates[0].(*sqlparser.ComparisonExpr)
+	if !ok || cmp.Operator != sqlparser.EqualOp {
+		return nil
+	}
+
+	lhsKey, rhsKey := cmp.Left, cmp.Right
+	lhsID, rhsID := TableID(lhs), TableID(rhs)
+	if !ctx.SemTable.RecursiveDeps(lhsKey).IsSolvedBy(lhsID) {
+		lhsKey, rhsKey = rhsKey, lhsKey
+	}
+	if !ctx.SemTable.RecursiveDeps(lhsKey).IsSolvedBy(lhsID) || !ctx.SemTable.RecursiveDeps(rhsKey).IsSolvedBy(rhsID) {
+		return nil
+	}
+
+	typ, coll, ok := hashJoinComparisonType(ctx, lhsKey, rhsKey)
+	if !ok {
+		return nil
+	}
+
+	hj := &HashJoin{
+		LHS:            Clone(lhs),
+		RHS:            Clone(rhs),
+		LeftJoin:       !inner,
+		JoinComparison: cmp,
+		LHSKey:         lhsKey,
+		RHSKey:         rhsKey,
+		ComparisonType: typ,
+		Collation:      coll,
+	}
+	hashCost, hashOK := estimatedCost(ctx, hj)
+	applyCost, applyOK := estimatedCost(ctx, applyJoin)
+	switch {
+	case hashOK && applyOK:
+		if applyCost <= hashCost {
+			return nil
+		}
+	case hasPushedPredicates(applyJoin.LHS):
+		return nil
+	}
+	return hj
+}
+
+func isScatterRoute(op ops.Operator) bool {
+	route, ok := op.(*Route)
+	return ok && route.Routing.OpCode() == engine.Scatter
+}
+
+// hasPushedPredicates returns true if some predicates were pushed to the
+// tables of the route.
+func hasPushedPredicates(op ops.Operator) bool {
+	route, ok := op.(*Route)
+	if !ok {
+		return false
+	}
+	found := false
+	_ = rewrite.Visit(route.Source, func(op ops.Operator) error {
+		switch op := op.(type) {
+		case *Table:
+			found = len(op.QTable.Predicates) > 0
+		case *Filter:
+			found = len(op.Predicates) > 0
+		}
+		if found {
+			return io.EOF
+		}
+		return nil
+	})
+	return found
+}
+
+// hashJoinComparisonType returns the type and collation the keys of the two
+// sides are compared as, following the comparison rules of MySQL. It
+// returns false when the types of the keys are not known, or when they
+// can't be hashed in a way that matches these rules.
+func hashJoinComparisonType(ctx *plancontext.PlanningContext, lhsKey, rhsKey sqlparser.Expr) (sqltypes.Type, collations.ID, bool) {
+	lt, lcoll, ok := ctx.SemTable.TypeForExpr(lhsKey)
+	if !ok {
+		return 0, 0, false
+	}
+	rt, rcoll, ok := ctx.SemTable.TypeForExpr(rhsKey)
+	if !ok {
+		return 0, 0, false
+	}
+
+	switch {
+	case sqltypes.IsQuoted(lt) && sqltypes.IsQuoted(rt):
+		// the strings are only equal if they are compared with the same
+		// collation, and the coercion between collations is not supported
+		if lcoll != rcoll || lcoll == collations.Unknown {
+			return 0, 0, false
+		}
+		if sqltypes.IsBinary(lt) || sqltypes.IsBinary(rt) {
+			return sqltypes.VarBinary, collations.CollationBinaryID, true
+		}
+		return sqltypes.VarChar, lcoll, true
+	case sqltypes.IsIntegral(lt) && sqltypes.IsIntegral(rt):
+		if sqltypes.IsUnsigned(lt) != sqltypes.IsUnsigned(rt) {
+			return sqltypes.Decimal, collations.Unknown, true
+		}
+		if sqltypes.IsUnsigned(lt) {
+			return sqltypes.Uint64, collations.Unknown, true
+		}
+		return sqltypes.Int64, collations.Unknown, true
+	case (sqltypes.IsIntegral(lt) || sqltypes.IsDecimal(lt)) && (sqltypes.IsIntegral(rt) || sqltypes.IsDecimal(rt)):
+		return sqltypes.Decimal, collations.Unknown, true
+	case sqltypes.IsNumber(lt) && (sqltypes.IsNumber(rt) || sqltypes.IsQuoted(rt)),
+		sqltypes.IsQuoted(lt) && sqltypes.IsNumber(rt):
+		// numbers are compared to other numbers and to strings as floats
+		return sqltypes.Float64, collations.Unknown, true
+	case sqltypes.IsDate(lt) && sqltypes.IsDate(rt):
+		if lt == rt {
+			return lt, collations.Unknown, true
+		}
+		return sqltypes.Datetime, collations.Unknown, true
+	case lt == rt && lt == sqltypes.Time:
+		return lt, collations.Unknown, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// Clone implements the Operator interface
+func (hj *HashJoin) Clone(inputs []ops.Operator) ops.Operator {
+	kopy := *hj
+	kopy.LHS = inputs[0]
+	kopy.RHS = inputs[1]
+	kopy.columns = slices.Clone(hj.columns)
+	kopy.ColumnOffsets = slices.Clone(hj.ColumnOffsets)
+	kopy.EvalColumns = slices.Clone(hj.EvalColumns)
+	return &kopy
+}
+
+// Inputs implements the Operator interface
+func (hj *HashJoin) Inputs() []ops.Operator {
+	return []ops.Operator{hj.LHS, hj.RHS}
+}
+
+// SetInputs implements the Operator interface
+func (hj *HashJoin) SetInputs(inputs []ops.Operator) {
+	hj.LHS, hj.RHS = inputs[0], inputs[1]
+}
+
+// AddPredicate implements the Operator interface. The predicates that
+// need the values of both sides are evaluated on the output of the join.
+func (hj *HashJoin) AddPredicate(ctx *plancontext.PlanningContext, expr sqlparser.Expr) (ops.Operator, error) {
+	deps := ctx.SemTable.RecursiveDeps(expr)
+	if deps.IsSolvedBy(TableID(hj.LHS)) || deps.IsSolvedBy(TableID(hj.RHS)) {
+		return AddPredicate(ctx, hj, expr, false, newFilter)
+	}
+	return newFilter(hj, expr), nil
+}
+
+func (hj *HashJoin) GetLHS() ops.Operator {
+	return hj.LHS
+}
+
+func (hj *HashJoin) GetRHS() ops.Operator {
+	return hj.RHS
+}
+
+func (hj *HashJoin) SetLHS(operator ops.Operator) {
+	hj.LHS = operator
+}
+
+func (hj *HashJoin) SetRHS(operator ops.Operator) {
+	hj.RHS = operator
+}
+
+func (hj *HashJoin) MakeInner() {
+	hj.LeftJoin = false
+}
+
+func (hj *HashJoin) IsInner() bool {
+	return !hj.LeftJoin
+}
+
+// AddJoinPredicate implements the JoinOp interface. The hash join only
+// matches the rows on its comparison, see AddPredicate for the others.
+func (hj *HashJoin) AddJoinPredicate(_ *plancontext.PlanningContext, expr sqlparser.Expr) error {
+	return vterrors.VT13001(fmt.Sprintf("cannot add the join predicate %s to a hash join", sqlparser.String(expr)))
+}
+
+func (hj *HashJoin) AddColumn(ctx *plancontext.PlanningContext, reuse bool, groupBy bool, expr *sqlparser.AliasedExpr) (int, error) {
+	if reuse {
+		offset, err := hj.FindCol(ctx, expr.Expr, false)
+		if err != nil {
+			return 0, err
+		}
+		if offset != -1 {
+			return offset, nil
+		}
+	}
+	hj.columns = append(hj.columns, hashJoinColumn{expr: expr, groupBy: groupBy})
+	return len(hj.columns) - 1, nil
+}
+
+func (hj *HashJoin) FindCol(ctx *plancontext.PlanningContext, expr sqlparser.Expr, _ bool) (int, error) {
+	offset, found := canReuseColumn(ctx, hj.columns, expr, func(col hashJoinColumn) sqlparser.Expr {
+		return col.expr.Expr
+	})
+	if !found {
+		return -1, nil
+	}
+	return offset, nil
+}
+
+func (hj *HashJoin) GetColumns(*plancontext.PlanningContext) ([]*sqlparser.AliasedExpr, error) {
+	return slice.Map(hj.columns, func(col hashJoinColumn) *sqlparser.AliasedExpr {
+		return col.expr
+	}), nil
+}
+
+func (hj *HashJoin) GetSelectExprs(ctx *plancontext.PlanningContext) (sqlparser.SelectExprs, error) {
+	return transformColumnsToSelectExprs(ctx, hj)
+}
+
+// GetOrdering implements the Operator interface. The rows are produced in
+// the order of the RHS, but the ordering of the RHS is not kept for left
+// joins, so no ordering is guaranteed.
+func (hj *HashJoin) GetOrdering() ([]ops.OrderBy, error) {
+	return nil, nil
+}
+
+func (hj *HashJoin) planOffsets(ctx *plancontext.PlanningContext) error {
+	offset, err := hj.LHS.AddColumn(ctx, true, false, aeWrap(hj.LHSKey))
+	if err != nil {
+		return err
+	}
+	hj.LHSKeyOffset = offset
+	offset, err = hj.RHS.AddColumn(ctx, true, false, aeWrap(hj.RHSKey))
+	if err != nil {
+		return err
+	}
+	hj.RHSKeyOffset = offset
+
+	rewritten := make([]sqlparser.Expr, 0, len(hj.columns))
+	pure := true
+	for _, col := range hj.columns {
+		expr, err := hj.useInputColumns(ctx, col.expr.Expr, col.groupBy)
+		if err != nil {
+			return err
+		}
+		if _, ok := expr.(*sqlparser.Offset); !ok {
+			pure = false
+		}
+		rewritten = append(rewritten, expr)
+	}
+
+	if pure {
+		// all the columns come from one of the sides, so the join can
+		// produce them directly
+		hj.ColumnOffsets = slice.Map(rewritten, func(expr sqlparser.Expr) int {
+			return hj.ColumnOffsets[expr.(*sqlparser.Offset).V]
+		})
+		return nil
+	}
+
+	cfg := &evalengine.Config{
+		ResolveType: ctx.SemTable.TypeForExpr,
+		Collation:   ctx.SemTable.Collation,
+	}
+	for _, expr := range rewritten {
+		eexpr, err := evalengine.Translate(expr, cfg)
+		if err != nil {
+			return err
+		}
+		hj.EvalColumns = append(hj.EvalColumns, eexpr)
+	}
+	return nil
+}
+
+// useInputColumns rewrites the expression to use the offsets of the columns
+// fetched from the inputs. The parts of the expression that only need one of
+// the sides are fetched from it, and the rest is evaluated by vtgate.
+func (hj *HashJoin) useInputColumns(ctx *plancontext.PlanningContext, expr sqlparser.Expr, groupBy bool) (sqlparser.Expr, error) {
+	lhsID, rhsID := TableID(hj.LHS), TableID(hj.RHS)
+	var (
+		err         error
+		replacement sqlparser.Expr
+	)
+	pre := func(node, _ sqlparser.SQLNode) bool {
+		e, ok := node.(sqlparser.Expr)
+		if !ok || err != nil {
+			return err == nil
+		}
+		deps := ctx.SemTable.RecursiveDeps(e)
+		var offset int
+		switch {
+		case deps.IsSolvedBy(lhsID):
+			offset, err = hj.LHS.AddColumn(ctx, true, groupBy, aeWrap(e))
+			offset = -offset - 1
+		case deps.IsSolvedBy(rhsID):
+			offset, err = hj.RHS.AddColumn(ctx, true, groupBy, aeWrap(e))
+			offset++
+		default:
+			return true
+		}
+		if err != nil {
+			return false
+		}
+		idx := slices.Index(hj.ColumnOffsets, offset)
+		if idx == -1 {
+			idx = len(hj.ColumnOffsets)
+			hj.ColumnOffsets = append(hj.ColumnOffsets, offset)
+		}
+		replacement = sqlparser.NewOffset(idx, e)
+		return false
+	}
+	post := func(cursor *sqlparser.CopyOnWriteCursor) {
+		if replacement != nil {
+			cursor.Replace(replacement)
+			replacement = nil
+		}
+	}
+	rewritten := sqlparser.CopyOnRewrite(expr, pre, post, ctx.SemTable.CopySemanticInfo)
+	if err != nil {
+		return nil, err
+	}
+	return rewritten.(sqlparser.Expr), nil
+}
+
+func (hj *HashJoin) ShortDescription() string {
+	columns := slice.Map(hj.columns, func(col hashJoinColumn) string {
+		return sqlparser.String(col.expr)
+	})
+	return fmt.Sprintf("on %s columns: %s", sqlparser.String(hj.JoinComparison), strings.Join(columns, ", "))
+}
@@ -104,7 +104,7 @@ func (h *Horizon) AddPredicate(ctx *plancontext.PlanningContext, expr sqlparser.
 	}
 
 	newExpr := semantics.RewriteDerivedTableExpression(expr, tableInfo)
-	if sqlparser.ContainsAggregation(newExpr) {
+	if sqlparser.ContainsAggregation(newExpr) || sqlparser.ContainsWindowFunc(newExpr) {
 		return &Filter{Source: h, Predicates: []sqlparser.Expr{expr}}, nil
 	}
 	h.Source, err = h.Source.AddPredicate(ctx, newExpr)
@@ -90,9 +90,12 @@ func expandSelectHorizon(ctx *plancontext.PlanningContext, horizon *Horizon, sel
 	}
 
 	var extracted []string
-	if qp.HasAggr {
+	switch {
+	case qp.HasWindow:
+		extracted = append(extracted, "Window")
+	case qp.HasAggr:
 		extracted = append(extracted, "Aggregation")
-	} else {
+	default:
 		extracted = append(extracted, "Projection")
 	}
 
@@ -147,6 +150,10 @@ func createProjectionFromSelect(ctx *plancontext.PlanningContext, horizon *Horiz
 		}
 	}
 
+	if qp.HasWindow {
+		return createWindow(ctx, qp, horizon.src(), dt)
+	}
+
 	if !qp.NeedsAggregation() {
 		projX, err := createProjectionWithoutAggr(ctx, qp, horizon.src())
 		if err != nil {
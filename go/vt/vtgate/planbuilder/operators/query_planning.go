@@ -188,7 +188,8 @@ func pushOrExpandHorizon(ctx *plancontext.PlanningContext, in *Horizon) (ops.Ope
 		!needsOrdering &&
 		!qp.NeedsAggregation() &&
 		!in.selectStatement().IsDistinct() &&
-		in.selectStatement().GetLimit() == nil
+		in.selectStatement().GetLimit() == nil &&
+		(!qp.HasWindow || isSel && canPushWindows(ctx, sel))
 
 	if canPush {
 		return rewrite.Swap(in, rb, "push horizon into route")
@@ -489,7 +490,7 @@ func tryPushLimit(in *Limit) (ops.Operator, *rewrite.ApplyResult, error) {
 	switch src := in.Source.(type) {
 	case *Route:
 		return tryPushingDownLimitInRoute(in, src)
-	case *Aggregator:
+	case *Aggregator, *Window:
 		return in, rewrite.SameTree, nil
 	default:
 		return setUpperLimit(in)
@@ -515,9 +516,12 @@ func setUpperLimit(in *Limit) (ops.Operator, *rewrite.ApplyResult, error) {
 	var result *rewrite.ApplyResult
 	shouldVisit := func(op ops.Operator) rewrite.VisitRule {
 		switch op := op.(type) {
-		case *Join, *ApplyJoin, *SubQueryContainer, *SubQuery:
+		case *Join, *ApplyJoin, *HashJoin, *SubQueryContainer, *SubQuery:
 			// we can't push limits down on either side
 			return rewrite.SkipChildren
+		case *Window:
+			// the window functions need to see all the rows of the input
+			return rewrite.SkipChildren
 		case *Route:
 			newSrc := &Limit{
 				Source: op.Source,
@@ -730,6 +734,10 @@ func tryPushFilter(ctx *plancontext.PlanningContext, in *Filter) (ops.Operator,
 }
 
 func pushFilterUnderProjection(ctx *plancontext.PlanningContext, filter *Filter, projection *Projection) (ops.Operator, *rewrite.ApplyResult, error) {
+	if _, isWindow := projection.Source.(*Window); isWindow {
+		// the predicates have to be evaluated on the output of the window functions
+		return filter, rewrite.SameTree, nil
+	}
 	for _, p := range filter.Predicates {
 		cantPush := false
 		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (kontinue bool, err error) {
@@ -50,6 +50,7 @@ type (
 		groupByExprs []GroupBy
 		OrderExprs   []ops.OrderBy
 		HasStar      bool
+		HasWindow    bool
 
 		// AddedColumn keeps a counter for expressions added to solve HAVING expressions the user is not selecting
 		AddedColumn int
@@ -295,6 +296,9 @@ func (qp *QueryProjection) addSelectExpressions(sel *sqlparser.Select) error {
 				col.Aggr = true
 				qp.HasAggr = true
 			}
+			if sqlparser.ContainsWindowFunc(selExp.Expr) {
+				qp.HasWindow = true
+			}
 
 			qp.SelectExprs = append(qp.SelectExprs, col)
 		case *sqlparser.StarExpr:
@@ -318,6 +322,10 @@ func containsAggr(e sqlparser.SQLNode) (hasAggr bool) {
 			// so we don't need to worry about aggregation in the original
 			return false, nil
 		case sqlparser.AggrFunc:
+			if sqlparser.IsWindowFunc(node) {
+				// window functions are evaluated per row, but their arguments can still contain aggregations
+				return true, nil
+			}
 			hasAggr = true
 			return false, io.EOF
 		case *sqlparser.Subquery:
@@ -303,6 +303,7 @@ func findBestJoin(
 	planCache opCacheMap,
 	crossJoinsOK bool,
 ) (bestPlan ops.Operator, lIdx int, rIdx int, err error) {
+	var bestCost int
 	for i, lhs := range plans {
 		for j, rhs := range plans {
 			if i == j {
@@ -319,8 +320,10 @@ func findBestJoin(
 			if err != nil {
 				return nil, 0, 0, err
 			}
-			if bestPlan == nil || CostOf(plan) < CostOf(bestPlan) {
+			cost := CostOf(plan)
+			if bestPlan == nil || cheaperPlan(ctx, plan, cost, bestPlan, bestCost) {
 				bestPlan = plan
+				bestCost = cost
 				// remember which plans we based on, so we can remove them later
 				lIdx = i
 				rIdx = j
@@ -395,6 +398,9 @@ func mergeOrJoin(ctx *plancontext.PlanningContext, lhs, rhs ops.Operator, joinPr
 	if err != nil {
 		return nil, nil, err
 	}
+	if hashJoin := tryHashJoin(ctx, lhs, rhs, joinPredicates, inner, join); hashJoin != nil {
+		return hashJoin, rewrite.NewTree("logical join to hashJoin", hashJoin), nil
+	}
 	return newOp, rewrite.NewTree("logical join to applyJoin ", newOp), nil
 }
 
@@ -46,6 +46,11 @@ func isMergeable(ctx *plancontext.PlanningContext, query sqlparser.SelectStateme
 		return false
 	}
 
+	if !windowsPartitionedBy(sel, validVindex) {
+		// window functions can only be evaluated inside a single shard if all rows of a partition live on it
+		return false
+	}
+
 	if len(sel.GroupBy) > 0 {
 		// iff we are grouping, we need to check that we can perform the grouping inside a single shard, and we check that
 		// by checking that one of the grouping expressions used is a unique single column vindex.
@@ -257,6 +262,39 @@ func tryPushSubQueryInJoin(
 	return nil, rewrite.SameTree, nil
 }
 
+// tryPushSubQueryInHashJoin pushes the subquery to the side of the hash join
+// that it depends on. Unlike the RHS of an ApplyJoin, the RHS of a hash join
+// is executed only once, so the subquery can be pushed to either side.
+func tryPushSubQueryInHashJoin(
+	ctx *plancontext.PlanningContext,
+	inner *SubQuery,
+	outer *HashJoin,
+) (ops.Operator, *rewrite.ApplyResult, error) {
+	deps := semantics.EmptyTableSet()
+	for _, predicate := range inner.GetMergePredicates() {
+		deps = deps.Merge(ctx.SemTable.RecursiveDeps(predicate))
+	}
+	deps = deps.Remove(TableID(inner.Subquery))
+
+	if _, ok := inner.Subquery.(*Projection); ok {
+		// let the projection be pushed down first, see tryPushSubQueryInJoin
+		return nil, rewrite.SameTree, nil
+	}
+
+	if deps.IsSolvedBy(TableID(outer.LHS)) {
+		outer.LHS = addSubQuery(outer.LHS, inner)
+		return outer, rewrite.NewTree("push subquery into LHS of hash join", inner), nil
+	}
+
+	if !outer.LeftJoin && deps.IsSolvedBy(TableID(outer.RHS)) {
+		// we can't push any filters on the RHS of an outer join
+		outer.RHS = addSubQuery(outer.RHS, inner)
+		return outer, rewrite.NewTree("push subquery into RHS of hash join", inner), nil
+	}
+
+	return nil, rewrite.SameTree, nil
+}
+
 // extractLHSExpr will return a function that extracts any ColName coming from the LHS table,
 // adding them to the ExtraLHSVars on the join if they are not already known
 func extractLHSExpr(
@@ -561,6 +599,15 @@ func pushOrMerge(ctx *plancontext.PlanningContext, outer ops.Operator, inner *Su
 			return outer, rewrite.SameTree, nil
 		}
 		return join, applyResult, nil
+	case *HashJoin:
+		join, applyResult, err := tryPushSubQueryInHashJoin(ctx, inner, o)
+		if err != nil {
+			return nil, nil, err
+		}
+		if join == nil {
+			return outer, rewrite.SameTree, nil
+		}
+		return join, applyResult, nil
 	default:
 		return outer, rewrite.SameTree, nil
 	}
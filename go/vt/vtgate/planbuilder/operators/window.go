@@ -0,0 +1,544 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operators
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"vitess.io/vitess/go/slice"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators/ops"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+	"vitess.io/vitess/go/vt/vtgate/semantics"
+)
+
+type (
+	// Window evaluates window functions at the vtgate level.
+	// All window functions of a Window share the same window specification,
+	// and the operator expects its input to be sorted by the PARTITION BY
+	// expressions followed by the ORDER BY expressions of the window.
+	// The columns of the operator are the columns of the input, with the window
+	// functions replacing the column their argument was fetched into.
+	Window struct {
+		Source  ops.Operator
+		Columns []*sqlparser.AliasedExpr
+
+		Funcs       []WindowFunc
+		PartitionBy []WindowKey
+		OrderBy     []WindowKey
+
+		offsetPlanned bool
+		ResultColumns int
+
+		DT *DerivedTable
+	}
+
+	// WindowFunc encodes all information needed to evaluate a window function
+	WindowFunc struct {
+		Original *sqlparser.AliasedExpr
+		Func     sqlparser.Expr
+		OpCode   opcode.WindowOpcode
+		Alias    string
+
+		// the offsets point to columns on the same window operator
+		ColOffset     int
+		DefaultOffset int
+	}
+
+	// WindowKey is a PARTITION BY or an ORDER BY expression of the window specification
+	WindowKey struct {
+		Expr sqlparser.Expr
+
+		// the offsets point to columns on the same window operator
+		ColOffset int
+		WSOffset  int
+	}
+)
+
+// newWindowFunc validates that the window function can be evaluated at the vtgate level
+func newWindowFunc(fnc sqlparser.Expr, original *sqlparser.AliasedExpr) (WindowFunc, error) {
+	wf := WindowFunc{
+		Original:      original,
+		Func:          fnc,
+		Alias:         original.ColumnName(),
+		ColOffset:     -1,
+		DefaultOffset: -1,
+	}
+
+	unsupported := func() (WindowFunc, error) {
+		return WindowFunc{}, vterrors.VT12001(fmt.Sprintf("in scatter query: window function '%s'", sqlparser.String(fnc)))
+	}
+
+	switch fnc := fnc.(type) {
+	case *sqlparser.ArgumentLessWindowExpr:
+		switch fnc.Type {
+		case sqlparser.RowNumberExprType:
+			wf.OpCode = opcode.WindowRowNumber
+		case sqlparser.RankExprType:
+			wf.OpCode = opcode.WindowRank
+		case sqlparser.DenseRankExprType:
+			wf.OpCode = opcode.WindowDenseRank
+		default:
+			return unsupported()
+		}
+	case *sqlparser.LagLeadExpr:
+		wf.OpCode = opcode.WindowLag
+		if fnc.Type == sqlparser.LeadExprType {
+			wf.OpCode = opcode.WindowLead
+		}
+	case *sqlparser.CountStar:
+		wf.OpCode = opcode.WindowCountStar
+	case sqlparser.AggrFunc:
+		if distinct, ok := fnc.(sqlparser.DistinctableAggr); ok && distinct.IsDistinct() {
+			return unsupported()
+		}
+		code, ok := opcode.SupportedWindowFunctions[fnc.AggrName()]
+		if !ok {
+			return unsupported()
+		}
+		wf.OpCode = code
+	default:
+		return unsupported()
+	}
+	return wf, nil
+}
+
+// getPushColumn returns the expression that needs to be fetched from the input for this window function
+func (wf WindowFunc) getPushColumn() sqlparser.Expr {
+	switch fnc := wf.Func.(type) {
+	case *sqlparser.LagLeadExpr:
+		return fnc.Expr
+	case sqlparser.AggrFunc:
+		if arg := fnc.GetArg(); arg != nil {
+			return arg
+		}
+	}
+	return sqlparser.NewIntLiteral("1")
+}
+
+// GetArg returns the argument of the window function, or nil if it has none
+func (wf WindowFunc) GetArg() sqlparser.Expr {
+	switch fnc := wf.Func.(type) {
+	case *sqlparser.LagLeadExpr:
+		return fnc.Expr
+	case sqlparser.AggrFunc:
+		return fnc.GetArg()
+	}
+	return nil
+}
+
+// GetOffsetAndDefault returns the N and default arguments of LAG and LEAD
+func (wf WindowFunc) GetOffsetAndDefault() (n sqlparser.Expr, def sqlparser.Expr) {
+	if fnc, ok := wf.Func.(*sqlparser.LagLeadExpr); ok {
+		return fnc.N, fnc.Default
+	}
+	return nil, nil
+}
+
+// createWindow builds the Window operator for the window functions in the select expressions.
+// When the window functions are nested in other select expressions, a projection
+// on top of the window operator is returned.
+func createWindow(ctx *plancontext.PlanningContext, qp *QueryProjection, src ops.Operator, dt *DerivedTable) (ops.Operator, error) {
+	if qp.NeedsAggregation() {
+		return nil, vterrors.VT12001("in scatter query: window functions combined with aggregation")
+	}
+
+	aes, err := slice.MapWithError(qp.SelectExprs, func(from SelectExpr) (*sqlparser.AliasedExpr, error) {
+		return from.GetAliasedExpr()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Window{}
+	var spec *sqlparser.WindowSpecification
+	complexExpr := false
+	for _, ae := range aes {
+		err := sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			if _, isSubq := node.(*sqlparser.Subquery); isSubq {
+				return false, nil
+			}
+			over := sqlparser.GetOverClause(node)
+			if over == nil {
+				return true, nil
+			}
+			if node != ae.Expr {
+				complexExpr = true
+			}
+			if !over.WindowName.IsEmpty() || over.WindowSpec == nil || !over.WindowSpec.Name.IsEmpty() {
+				return false, vterrors.VT12001("in scatter query: named windows")
+			}
+			if over.WindowSpec.FrameClause != nil {
+				return false, vterrors.VT12001("in scatter query: window frame clause")
+			}
+			if spec == nil {
+				spec = over.WindowSpec
+			} else if !sqlparser.Equals.RefOfWindowSpecification(spec, over.WindowSpec) {
+				return false, vterrors.VT12001("in scatter query: window functions with different window specifications")
+			}
+
+			original := ae
+			if node != ae.Expr {
+				original = aeWrap(node.(sqlparser.Expr))
+			}
+			wf, err := newWindowFunc(node.(sqlparser.Expr), original)
+			if err != nil {
+				return false, err
+			}
+			w.Funcs = append(w.Funcs, wf)
+			return false, nil
+		}, ae.Expr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var order []ops.OrderBy
+	for _, expr := range spec.PartitionClause {
+		w.PartitionBy = append(w.PartitionBy, newWindowKey(expr))
+		order = append(order, ops.OrderBy{
+			Inner:          &sqlparser.Order{Expr: expr, Direction: sqlparser.AscOrder},
+			SimplifiedExpr: expr,
+		})
+	}
+	for _, o := range spec.OrderClause {
+		w.OrderBy = append(w.OrderBy, newWindowKey(o.Expr))
+		order = append(order, ops.OrderBy{
+			Inner:          sqlparser.CloneRefOfOrder(o),
+			SimplifiedExpr: o.Expr,
+		})
+	}
+
+	w.Source = src
+	if len(order) > 0 {
+		w.Source = &Ordering{
+			Source: src,
+			Order:  order,
+		}
+	}
+
+	if !complexExpr {
+		// the window functions are plain select expressions, so the window operator can produce all the columns
+		w.Columns = aes
+		w.DT = dt
+		for i, wf := range w.Funcs {
+			w.Funcs[i].ColOffset = slices.Index(aes, wf.Original)
+		}
+		return w, nil
+	}
+
+	for i, wf := range w.Funcs {
+		w.Funcs[i].ColOffset = len(w.Columns)
+		w.Columns = append(w.Columns, wf.Original)
+	}
+	p := newAliasedProjection(w)
+	p.DT = dt
+	for _, ae := range aes {
+		_, err := p.addProjExpr(newProjExpr(ae))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// canPushWindows returns true if every window function partitions its rows by a unique vindex
+// column. All the rows of a partition then live on the same shard, so the window functions
+// can be evaluated by the shards themselves.
+func canPushWindows(ctx *plancontext.PlanningContext, sel *sqlparser.Select) bool {
+	return windowsPartitionedBy(sel, func(expr sqlparser.Expr) bool {
+		return exprHasUniqueVindex(ctx, expr)
+	})
+}
+
+// windowsPartitionedBy returns true if the PARTITION BY clause of every window function
+// in the select expressions contains an expression accepted by validVindex
+func windowsPartitionedBy(sel *sqlparser.Select, validVindex func(sqlparser.Expr) bool) bool {
+	partitioned := true
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if _, isSubq := node.(*sqlparser.Subquery); isSubq {
+			return false, nil
+		}
+		over := sqlparser.GetOverClause(node)
+		if over == nil {
+			return true, nil
+		}
+		if over.WindowSpec == nil || !slices.ContainsFunc(over.WindowSpec.PartitionClause, validVindex) {
+			partitioned = false
+			return false, io.EOF
+		}
+		return false, nil
+	}, sel.SelectExprs)
+	return partitioned
+}
+
+func newWindowKey(expr sqlparser.Expr) WindowKey {
+	return WindowKey{
+		Expr:      expr,
+		ColOffset: -1,
+		WSOffset:  -1,
+	}
+}
+
+func (w *Window) Clone(inputs []ops.Operator) ops.Operator {
+	kopy := *w
+	kopy.Source = inputs[0]
+	kopy.Columns = slices.Clone(w.Columns)
+	kopy.Funcs = slices.Clone(w.Funcs)
+	kopy.PartitionBy = slices.Clone(w.PartitionBy)
+	kopy.OrderBy = slices.Clone(w.OrderBy)
+	return &kopy
+}
+
+func (w *Window) Inputs() []ops.Operator {
+	return []ops.Operator{w.Source}
+}
+
+func (w *Window) SetInputs(operators []ops.Operator) {
+	if len(operators) != 1 {
+		panic(fmt.Sprintf("unexpected number of operators as input in window: %d", len(operators)))
+	}
+	w.Source = operators[0]
+}
+
+func (w *Window) AddPredicate(_ *plancontext.PlanningContext, expr sqlparser.Expr) (ops.Operator, error) {
+	// predicates can't be pushed below the window, since that would change the rows of the window
+	return &Filter{
+		Source:     w,
+		Predicates: []sqlparser.Expr{expr},
+	}, nil
+}
+
+func (w *Window) AddColumn(ctx *plancontext.PlanningContext, reuse bool, groupBy bool, ae *sqlparser.AliasedExpr) (int, error) {
+	rewritten, err := w.DT.RewriteExpression(ctx, ae.Expr)
+	if err != nil {
+		return 0, err
+	}
+
+	ae = &sqlparser.AliasedExpr{
+		Expr: rewritten,
+		As:   ae.As,
+	}
+
+	// the window needs its input offsets to match its own columns,
+	// so offset planning needs to be done before we can push new columns
+	err = w.planOffsets(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if reuse {
+		offset, err := w.FindCol(ctx, ae.Expr, false)
+		if err != nil || offset >= 0 {
+			return offset, err
+		}
+	}
+
+	if ws, isWS := ae.Expr.(*sqlparser.WeightStringFuncExpr); isWS {
+		offset, found, err := w.addWeightStringOfFunc(ctx, ws)
+		if err != nil || found {
+			return offset, err
+		}
+	}
+
+	if sqlparser.ContainsWindowFunc(ae.Expr) {
+		return 0, vterrors.VT12001(fmt.Sprintf("in scatter query: window function in '%s'", sqlparser.String(ae.Expr)))
+	}
+
+	offset := len(w.Columns)
+	incomingOffset, err := w.Source.AddColumn(ctx, false, groupBy, ae)
+	if err != nil {
+		return 0, err
+	}
+	if offset != incomingOffset {
+		return 0, errFailedToPlanWindow(ae)
+	}
+	w.Columns = append(w.Columns, ae)
+	return offset, nil
+}
+
+// addWeightStringOfFunc adds the weight string of a window function result, which is needed when sorting on it.
+// The functions that return one of the values of their argument can produce it by being evaluated
+// over the weight string of the argument, e.g. weight_string(lag(x)) is the same as lag(weight_string(x))
+func (w *Window) addWeightStringOfFunc(ctx *plancontext.PlanningContext, ws *sqlparser.WeightStringFuncExpr) (int, bool, error) {
+	idx := slices.IndexFunc(w.Funcs, func(wf WindowFunc) bool {
+		return ctx.SemTable.EqualsExprWithDeps(wf.Func, ws.Expr)
+	})
+	if idx < 0 {
+		return 0, false, nil
+	}
+
+	wsFunc := w.Funcs[idx]
+	switch fnc := sqlparser.CloneExpr(wsFunc.Func).(type) {
+	case *sqlparser.LagLeadExpr:
+		fnc.Expr = weightStringFor(fnc.Expr)
+		if fnc.Default != nil {
+			fnc.Default = weightStringFor(fnc.Default)
+		}
+		wsFunc.Func = fnc
+	case *sqlparser.Min:
+		fnc.Arg = weightStringFor(fnc.Arg)
+		wsFunc.Func = fnc
+	case *sqlparser.Max:
+		fnc.Arg = weightStringFor(fnc.Arg)
+		wsFunc.Func = fnc
+	default:
+		return 0, false, nil
+	}
+	wsFunc.Original = aeWrap(ws)
+	wsFunc.Alias = ""
+	wsFunc.ColOffset = len(w.Columns)
+	wsFunc.DefaultOffset = -1
+
+	offset, err := w.Source.AddColumn(ctx, false, false, aeWrap(wsFunc.getPushColumn()))
+	if err != nil {
+		return 0, false, err
+	}
+	if offset != wsFunc.ColOffset {
+		return 0, false, errFailedToPlanWindow(wsFunc.Original)
+	}
+	w.Columns = append(w.Columns, wsFunc.Original)
+
+	if _, def := wsFunc.GetOffsetAndDefault(); def != nil {
+		wsFunc.DefaultOffset, err = w.internalAddColumn(ctx, aeWrap(def))
+		if err != nil {
+			return 0, false, err
+		}
+	}
+	w.Funcs = append(w.Funcs, wsFunc)
+	return offset, true, nil
+}
+
+func (w *Window) FindCol(ctx *plancontext.PlanningContext, in sqlparser.Expr, _ bool) (int, error) {
+	expr, err := w.DT.RewriteExpression(ctx, in)
+	if err != nil {
+		return 0, err
+	}
+	if offset, found := canReuseColumn(ctx, w.Columns, expr, extractExpr); found {
+		return offset, nil
+	}
+	return -1, nil
+}
+
+func (w *Window) GetColumns(*plancontext.PlanningContext) ([]*sqlparser.AliasedExpr, error) {
+	return w.Columns, nil
+}
+
+func (w *Window) GetSelectExprs(ctx *plancontext.PlanningContext) (sqlparser.SelectExprs, error) {
+	return transformColumnsToSelectExprs(ctx, w)
+}
+
+func (w *Window) ShortDescription() string {
+	funcs := slice.Map(w.Funcs, func(from WindowFunc) string {
+		return sqlparser.String(from.Func)
+	})
+	if w.DT != nil {
+		funcs = append([]string{w.DT.String()}, funcs...)
+	}
+	return strings.Join(funcs, ", ")
+}
+
+func (w *Window) GetOrdering() ([]ops.OrderBy, error) {
+	return w.Source.GetOrdering()
+}
+
+func (w *Window) planOffsets(ctx *plancontext.PlanningContext) error {
+	if w.offsetPlanned {
+		return nil
+	}
+	w.offsetPlanned = true
+
+	w.Source = newAliasedProjection(w.Source)
+	// we need to keep things in the column order, since the window functions replace their argument in place
+	for colIdx, col := range w.Columns {
+		ae := col
+		if idx := slices.IndexFunc(w.Funcs, func(wf WindowFunc) bool { return wf.ColOffset == colIdx }); idx >= 0 {
+			ae = aeWrap(w.Funcs[idx].getPushColumn())
+		}
+		offset, err := w.Source.AddColumn(ctx, false, false, ae)
+		if err != nil {
+			return err
+		}
+		if offset != colIdx {
+			return errFailedToPlanWindow(col)
+		}
+	}
+
+	for idx, wf := range w.Funcs {
+		_, def := wf.GetOffsetAndDefault()
+		if def == nil {
+			continue
+		}
+		offset, err := w.internalAddColumn(ctx, aeWrap(def))
+		if err != nil {
+			return err
+		}
+		w.Funcs[idx].DefaultOffset = offset
+	}
+
+	for _, keys := range [][]WindowKey{w.PartitionBy, w.OrderBy} {
+		for idx, key := range keys {
+			offset, err := w.internalAddColumn(ctx, aeWrap(key.Expr))
+			if err != nil {
+				return err
+			}
+			keys[idx].ColOffset = offset
+
+			if !ctx.SemTable.NeedsWeightString(key.Expr) {
+				continue
+			}
+			offset, err = w.internalAddColumn(ctx, aeWrap(weightStringFor(key.Expr)))
+			if err != nil {
+				return err
+			}
+			keys[idx].WSOffset = offset
+		}
+	}
+	return nil
+}
+
+func (w *Window) internalAddColumn(ctx *plancontext.PlanningContext, aliasedExpr *sqlparser.AliasedExpr) (int, error) {
+	offset, err := w.Source.AddColumn(ctx, true, false, aliasedExpr)
+	if err != nil {
+		return 0, err
+	}
+
+	if offset == len(w.Columns) {
+		// if we get an offset at the end of our current column list, it means we added a new column
+		w.Columns = append(w.Columns, aliasedExpr)
+	}
+	return offset, nil
+}
+
+func (w *Window) setTruncateColumnCount(offset int) {
+	w.ResultColumns = offset
+}
+
+func (w *Window) introducesTableID() semantics.TableSet {
+	return w.DT.introducesTableID()
+}
+
+func errFailedToPlanWindow(original *sqlparser.AliasedExpr) *vterrors.VitessError {
+	return vterrors.VT12001(fmt.Sprintf("failed to plan window function on: %s", sqlparser.String(original)))
+}
+
+var _ ops.Operator = (*Window)(nil)
@@ -35,6 +35,7 @@ import (
 	"vitess.io/vitess/go/test/utils"
 	"vitess.io/vitess/go/test/vschemawrapper"
 	"vitess.io/vitess/go/vt/key"
+	querypb "vitess.io/vitess/go/vt/proto/query"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	"vitess.io/vitess/go/vt/servenv"
 	"vitess.io/vitess/go/vt/sidecardb"
@@ -95,6 +96,7 @@ func TestPlan(t *testing.T) {
 	testFile(t, "reference_cases.json", testOutputTempDir, vschemaWrapper, false)
 	testFile(t, "vexplain_cases.json", testOutputTempDir, vschemaWrapper, false)
 	testFile(t, "misc_cases.json", testOutputTempDir, vschemaWrapper, false)
+	testFile(t, "window_cases.json", testOutputTempDir, vschemaWrapper, false)
 }
 
 // TestForeignKeyPlanning tests the planning of foreign keys in a managed mode by Vitess.
@@ -176,6 +178,49 @@ func setFks(t *testing.T, vschema *vindexes.VSchema) {
 	}
 }
 
+// TestTableStatisticsPlanning tests the cost-based planning of joins using the table statistics.
+func TestTableStatisticsPlanning(t *testing.T) {
+	testOutputTempDir := makeTestOutput(t)
+	for _, tcase := range []struct {
+		file                    string
+		userRows, userExtraRows uint64
+	}{
+		{file: "statistics_cases.json", userRows: 1000000, userExtraRows: 1000},
+		// The same joins are planned the other way around when the row
+		// counts of the tables are swapped.
+		{file: "statistics_swapped_cases.json", userRows: 1000, userExtraRows: 1000000},
+	} {
+		vschema := loadSchema(t, "vschemas/schema.json", true)
+		setStatistics(vschema, tcase.userRows, tcase.userExtraRows)
+		vschemaWrapper := &vschemawrapper.VSchemaWrapper{
+			V:           vschema,
+			TestBuilder: TestBuilder,
+		}
+		testFile(t, tcase.file, testOutputTempDir, vschemaWrapper, false)
+	}
+}
+
+// setStatistics sets the statistics of the user and user_extra tables, with
+// the given row counts.
+func setStatistics(vschema *vindexes.VSchema, userRows, userExtraRows uint64) {
+	tables := vschema.Keyspaces["user"].Tables
+	tables["user"].Statistics = &querypb.TableStatistics{
+		RowCount: userRows,
+		Indexes: []*querypb.IndexStatistics{
+			{Name: "PRIMARY", Columns: []string{"id"}, Cardinality: []uint64{1000000}, Unique: true},
+			{Name: "foo_idx", Columns: []string{"foo"}, Cardinality: []uint64{500000}},
+			{Name: "intcol_idx", Columns: []string{"intcol"}, Cardinality: []uint64{1000000}},
+		},
+	}
+	tables["user_extra"].Statistics = &querypb.TableStatistics{
+		RowCount: userExtraRows,
+		Indexes: []*querypb.IndexStatistics{
+			{Name: "bar_idx", Columns: []string{"bar"}, Cardinality: []uint64{1000}},
+			{Name: "baz_idx", Columns: []string{"baz"}, Cardinality: []uint64{100}},
+		},
+	}
+}
+
 func TestSystemTables57(t *testing.T) {
 	// first we move everything to use 5.7 logic
 	oldVer := servenv.MySQLServerVersion()
@@ -82,6 +82,12 @@ func (rb *route) Wireup(ctx *plancontext.PlanningContext) error {
 	}
 
 	query, args := planableVindex.Query()
+	if query == "" {
+		// The lookup is not made with a query, the vindex maps the values
+		// when the route is executed.
+		rb.enginePrimitive = rb.eroute
+		return nil
+	}
 	stmt, reserved, err := sqlparser.Parse2(query)
 	if err != nil {
 		return err
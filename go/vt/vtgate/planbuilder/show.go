@@ -107,9 +107,10 @@ func buildShowBasicPlan(show *sqlparser.ShowBasic, vschema plancontext.VSchema)
 		return buildPluginsPlan()
 	case sqlparser.Engines:
 		return buildEnginesPlan()
-	case sqlparser.VitessReplicationStatus, sqlparser.VitessShards, sqlparser.VitessTablets, sqlparser.VitessVariables:
+	case sqlparser.ProcessList, sqlparser.VitessReplicationStatus, sqlparser.VitessShards, sqlparser.VitessTablets, sqlparser.VitessVariables:
 		return &engine.ShowExec{
 			Command:    show.Command,
+			Full:       show.Full,
 			ShowFilter: show.Filter,
 		}, nil
 	case sqlparser.VitessTarget:
@@ -0,0 +1,45 @@
+/*
+Copyright 2023 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package planbuilder
+
+import (
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+)
+
+var _ logicalPlan = (*window)(nil)
+
+// window is the logicalPlan for engine.Window.
+// This gets built if window functions are used in a query
+// that can't be sent to a single shard. The primitive requests
+// the underlying route to order the results by the partition
+// and order by expressions of the window, which allows the
+// engine to evaluate the window functions as the rows come in.
+type window struct {
+	resultsBuilder
+	eWindow *engine.Window
+}
+
+// Primitive implements the logicalPlan interface
+func (w *window) Primitive() engine.Primitive {
+	w.eWindow.Input = w.input.Primitive()
+	return w.eWindow
+}
+
+func (w *window) Wireup(ctx *plancontext.PlanningContext) error {
+	return w.input.Wireup(ctx)
+}
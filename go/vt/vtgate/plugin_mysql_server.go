@@ -23,6 +23,7 @@ import (
 	"os"
 	"os/signal"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -46,6 +47,7 @@ import (
 	"vitess.io/vitess/go/vt/servenv"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/vtgateservice"
 	"vitess.io/vitess/go/vt/vttls"
 )
 
@@ -71,6 +73,7 @@ var (
 	mysqlQueryTimeout             time.Duration
 	mysqlSlowConnectWarnThreshold time.Duration
 	mysqlConnBufferPooling        bool
+	mysqlServerEnableCompression  bool
 
 	mysqlDefaultWorkloadName = "OLTP"
 	mysqlDefaultWorkload     int32
@@ -97,6 +100,7 @@ func registerPluginFlags(fs *pflag.FlagSet) {
 	fs.DurationVar(&mysqlQueryTimeout, "mysql_server_query_timeout", mysqlQueryTimeout, "mysql query timeout")
 	fs.BoolVar(&mysqlConnBufferPooling, "mysql-server-pool-conn-read-buffers", mysqlConnBufferPooling, "If set, the server will pool incoming connection read buffers")
 	fs.DurationVar(&mysqlKeepAlivePeriod, "mysql-server-keepalive-period", mysqlKeepAlivePeriod, "TCP period between keep-alives")
+	fs.BoolVar(&mysqlServerEnableCompression, "mysql-server-enable-compression", mysqlServerEnableCompression, "If set, the server will accept compressed (zlib or zstd) connections from clients asking for it")
 	fs.StringVar(&mysqlDefaultWorkloadName, "mysql_default_workload", mysqlDefaultWorkloadName, "Default session workload (OLTP, OLAP, DBA)")
 }
 
@@ -108,14 +112,25 @@ type vtgateHandler struct {
 
 	vtg         *VTGate
 	connections map[uint32]*mysql.Conn
+	processes   map[uint32]*processState
 
 	busyConnections atomic.Int32
 }
 
+// processState is what a connection executes, as listed by SHOW PROCESSLIST.
+type processState struct {
+	sessionUUID string
+	db          string
+	// query is the query in execution, empty when the connection is idle.
+	query string
+	start time.Time
+}
+
 func newVtgateHandler(vtg *VTGate) *vtgateHandler {
 	return &vtgateHandler{
 		vtg:         vtg,
 		connections: make(map[uint32]*mysql.Conn),
+		processes:   make(map[uint32]*processState),
 	}
 }
 
@@ -123,6 +138,7 @@ func (vh *vtgateHandler) NewConnection(c *mysql.Conn) {
 	vh.mu.Lock()
 	defer vh.mu.Unlock()
 	vh.connections[c.ConnectionID] = c
+	vh.processes[c.ConnectionID] = &processState{start: time.Now()}
 }
 
 func (vh *vtgateHandler) numConnections() int {
@@ -148,6 +164,7 @@ func (vh *vtgateHandler) ConnectionClosed(c *mysql.Conn) {
 	defer func() {
 		vh.mu.Lock()
 		delete(vh.connections, c.ConnectionID)
+		delete(vh.processes, c.ConnectionID)
 		vh.mu.Unlock()
 	}()
 
@@ -239,6 +256,9 @@ func (vh *vtgateHandler) ComQuery(c *mysql.Conn, query string, callback func(*sq
 		}
 	}()
 
+	vh.startQuery(c, session, query)
+	defer vh.endQuery(c)
+
 	if session.Options.Workload == querypb.ExecuteOptions_OLAP {
 		session, err := vh.vtg.StreamExecute(ctx, vh, session, query, make(map[string]*querypb.BindVariable), callback)
 		if err != nil {
@@ -345,6 +365,9 @@ func (vh *vtgateHandler) ComStmtExecute(c *mysql.Conn, prepare *mysql.PrepareDat
 		}
 	}()
 
+	vh.startQuery(c, session, prepare.PrepareStmt)
+	defer vh.endQuery(c)
+
 	if session.Options.Workload == querypb.ExecuteOptions_OLAP {
 		_, err := vh.vtg.StreamExecute(ctx, vh, session, prepare.PrepareStmt, prepare.BindVars, callback)
 		if err != nil {
@@ -412,6 +435,77 @@ func (vh *vtgateHandler) KillQuery(connectionID uint32) error {
 	return nil
 }
 
+// SessionUUID returns the UUID of the vtgate session of the connection. It is
+// empty if the connection has not executed any query yet.
+func (vh *vtgateHandler) SessionUUID(connectionID uint32) (string, error) {
+	vh.mu.Lock()
+	defer vh.mu.Unlock()
+	p, exists := vh.processes[connectionID]
+	if !exists {
+		return "", sqlerror.NewSQLError(sqlerror.ERNoSuchThread, sqlerror.SSUnknownSQLState, "Unknown thread id: %d", connectionID)
+	}
+	return p.sessionUUID, nil
+}
+
+// ProcessList returns the open connections, sorted by connection ID.
+func (vh *vtgateHandler) ProcessList() []*vtgateservice.Process {
+	vh.mu.Lock()
+	defer vh.mu.Unlock()
+	now := time.Now()
+	processes := make([]*vtgateservice.Process, 0, len(vh.connections))
+	for id, c := range vh.connections {
+		process := &vtgateservice.Process{
+			ID:      id,
+			User:    c.User,
+			Command: "Sleep",
+		}
+		if addr := c.RemoteAddr(); addr != nil {
+			process.Host = addr.String()
+		}
+		if p, ok := vh.processes[id]; ok {
+			process.DB = p.db
+			process.Time = now.Sub(p.start)
+			if p.query != "" {
+				process.Command = "Query"
+				process.Info = p.query
+			}
+		}
+		processes = append(processes, process)
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].ID < processes[j].ID
+	})
+	return processes
+}
+
+// startQuery records the query executed by the connection.
+func (vh *vtgateHandler) startQuery(c *mysql.Conn, session *vtgatepb.Session, query string) {
+	vh.mu.Lock()
+	defer vh.mu.Unlock()
+	p, exists := vh.processes[c.ConnectionID]
+	if !exists {
+		return
+	}
+	p.sessionUUID = session.SessionUUID
+	p.db = session.TargetString
+	p.query = query
+	p.start = time.Now()
+}
+
+// endQuery records that the connection is idle.
+func (vh *vtgateHandler) endQuery(c *mysql.Conn) {
+	session := vh.session(c)
+	vh.mu.Lock()
+	defer vh.mu.Unlock()
+	p, exists := vh.processes[c.ConnectionID]
+	if !exists {
+		return
+	}
+	p.db = session.TargetString
+	p.query = ""
+	p.start = time.Now()
+}
+
 func (vh *vtgateHandler) session(c *mysql.Conn) *vtgatepb.Session {
 	session, _ := c.ClientData.(*vtgatepb.Session)
 	if session == nil {
@@ -420,6 +514,9 @@ func (vh *vtgateHandler) session(c *mysql.Conn) *vtgatepb.Session {
 			Options: &querypb.ExecuteOptions{
 				IncludedFields: querypb.ExecuteOptions_ALL,
 				Workload:       querypb.ExecuteOptions_Workload(mysqlDefaultWorkload),
+				// The tablets record the session of the queries, so that
+				// KILL QUERY can kill them.
+				SessionUuid: u.String(),
 
 				// The collation field of ExecuteOption is set right before an execution.
 			},
@@ -535,6 +632,7 @@ func initMySQLProtocol(vtgate *VTGate) *mysqlServer {
 			_ = initTLSConfig(context.Background(), srv, mysqlSslCert, mysqlSslKey, mysqlSslCa, mysqlSslCrl, mysqlSslServerCA, mysqlServerRequireSecureTransport, tlsVersion)
 		}
 		srv.tcpListener.AllowClearTextWithoutTLS.Store(mysqlAllowClearTextWithoutTLS)
+		srv.tcpListener.EnableCompression = mysqlServerEnableCompression
 		// Check for the connection threshold
 		if mysqlSlowConnectWarnThreshold != 0 {
 			log.Infof("setting mysql slow connection threshold to %v", mysqlSlowConnectWarnThreshold)
@@ -342,3 +342,51 @@ func TestKillMethods(t *testing.T) {
 	require.EqualError(t, cancelCtx.Err(), "context canceled")
 	require.True(t, mysqlConn.IsMarkedForClose())
 }
+
+// TestProcessList tests the connections listed by the mysql plugin for show processlist.
+func TestProcessList(t *testing.T) {
+	executor, _, _, _, _ := createExecutorEnv(t)
+	vh := newVtgateHandler(&VTGate{executor: executor})
+
+	_, err := vh.SessionUUID(12345)
+	assert.ErrorContains(t, err, "Unknown thread id: 12345 (errno 1094) (sqlstate HY000)")
+
+	mysqlConn := mysql.GetTestConn()
+	mysqlConn.ConnectionID = 1
+	mysqlConn.User = "user1"
+	vh.NewConnection(mysqlConn)
+
+	// The connection did not execute any query yet.
+	sessionUUID, err := vh.SessionUUID(1)
+	require.NoError(t, err)
+	assert.Empty(t, sessionUUID)
+	processes := vh.ProcessList()
+	require.Len(t, processes, 1)
+	assert.Equal(t, uint32(1), processes[0].ID)
+	assert.Equal(t, "user1", processes[0].User)
+	assert.Equal(t, "a", processes[0].Host)
+	assert.Equal(t, "Sleep", processes[0].Command)
+	assert.Empty(t, processes[0].Info)
+
+	session := vh.session(mysqlConn)
+	session.TargetString = "ks"
+	assert.Equal(t, session.SessionUUID, session.Options.SessionUuid)
+	vh.startQuery(mysqlConn, session, "select 1 from dual")
+	sessionUUID, err = vh.SessionUUID(1)
+	require.NoError(t, err)
+	assert.Equal(t, session.SessionUUID, sessionUUID)
+	processes = vh.ProcessList()
+	require.Len(t, processes, 1)
+	assert.Equal(t, "ks", processes[0].DB)
+	assert.Equal(t, "Query", processes[0].Command)
+	assert.Equal(t, "select 1 from dual", processes[0].Info)
+
+	vh.endQuery(mysqlConn)
+	processes = vh.ProcessList()
+	require.Len(t, processes, 1)
+	assert.Equal(t, "Sleep", processes[0].Command)
+	assert.Empty(t, processes[0].Info)
+
+	vh.ConnectionClosed(mysqlConn)
+	assert.Empty(t, vh.ProcessList())
+}
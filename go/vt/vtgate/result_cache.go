@@ -0,0 +1,564 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"vitess.io/vitess/go/cache/theine"
+	"vitess.io/vitess/go/hack"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/callerid"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+	"vitess.io/vitess/go/vt/vthash"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+)
+
+var (
+	// resultCacheMemory is the capacity of the result cache in bytes.
+	// The result cache is disabled when it is zero.
+	resultCacheMemory int64
+
+	// resultCacheRetryDelay is the default retryDelay of the result cache.
+	resultCacheRetryDelay = 5 * time.Second
+
+	resultCacheHits          = stats.NewCounter("QueryResultCacheHits", "Query result cache hits")
+	resultCacheMisses        = stats.NewCounter("QueryResultCacheMisses", "Query result cache misses")
+	resultCacheInvalidations = stats.NewCountersWithSingleLabel("QueryResultCacheInvalidations", "Query result cache invalidations, by keyspace", "Keyspace")
+)
+
+// nonDeterministicFuncs are the functions whose results change between
+// executions of the same query.
+var nonDeterministicFuncs = map[string]bool{
+	"connection_id":  true,
+	"found_rows":     true,
+	"last_insert_id": true,
+	"rand":           true,
+	"row_count":      true,
+	"sleep":          true,
+	"uuid":           true,
+	"uuid_short":     true,
+	"unix_timestamp": true,
+}
+
+// vstreamFunc is the signature of vstreamManager.VStream.
+type vstreamFunc func(ctx context.Context, tabletType topodatapb.TabletType, vgtid *binlogdatapb.VGtid,
+	filter *binlogdatapb.Filter, flags *vtgatepb.VStreamFlags, send func(events []*binlogdatapb.VEvent) error) error
+
+// resultCacheOptions holds what the statement of a query tells about
+// the caching of its results.
+type resultCacheOptions struct {
+	// cacheable is false for the statements whose results depend on
+	// more than the data they read.
+	cacheable bool
+	// ttl is the TTL requested by the query comment directive, if ttlSet.
+	ttl    time.Duration
+	ttlSet bool
+}
+
+func getResultCacheOptions(stmt sqlparser.Statement) resultCacheOptions {
+	ttl, ttlSet := sqlparser.ResultCacheTTL(stmt)
+	return resultCacheOptions{
+		cacheable: resultCacheable(stmt),
+		ttl:       ttl,
+		ttlSet:    ttlSet,
+	}
+}
+
+// resultCacheable returns whether the results of a statement only depend
+// on the data it reads, and therefore can be cached.
+func resultCacheable(stmt sqlparser.Statement) bool {
+	switch stmt := stmt.(type) {
+	case *sqlparser.Select:
+		if stmt.Lock != sqlparser.NoLock || stmt.Into != nil || stmt.SQLCalcFoundRows {
+			return false
+		}
+	case *sqlparser.Union:
+		if stmt.Lock != sqlparser.NoLock || stmt.Into != nil {
+			return false
+		}
+	default:
+		return false
+	}
+
+	cacheable := true
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.LockingFunc, *sqlparser.CurTimeFuncExpr, *sqlparser.Variable:
+			cacheable = false
+		case *sqlparser.FuncExpr:
+			if nonDeterministicFuncs[node.Name.Lowered()] {
+				cacheable = false
+			}
+		}
+		return cacheable, nil
+	}, stmt)
+	return cacheable
+}
+
+// resultCache caches the results of the SELECT queries reading the tables
+// for which it is enabled, either in the VSchema or through a query comment
+// directive.
+//
+// The results are cached for a TTL at most. They are invalidated earlier
+// when the tables they were read from change: for every keyspace and tablet
+// type it serves cached results for, the cache runs a VStream of the tables
+// involved, and bumps the generation of a table for each row event it gets.
+// A cached result is only served if the generations of its tables are still
+// the ones it was read with.
+type resultCache struct {
+	cache   *theine.Store[theine.HashKey256, *cachedResult]
+	epoch   atomic.Uint32
+	vstream vstreamFunc
+	// retryDelay is the time to wait before restarting a failed
+	// invalidation stream.
+	retryDelay time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu sync.Mutex
+	// generations is keyed by keyspace and by keyspace-qualified table.
+	generations map[string]uint64
+	// invalidators is keyed by keyspace and tablet type.
+	invalidators map[string]*cacheInvalidator
+}
+
+// cachedResult is an entry of the result cache.
+type cachedResult struct {
+	result *sqltypes.Result
+	expiry time.Time
+	// sources are the keyspaces and tables the result was read from,
+	// with their generations at the time the query was sent.
+	sources     []string
+	generations []uint64
+}
+
+// CachedSize returns the approximate memory used by the entry.
+func (cr *cachedResult) CachedSize(alloc bool) int64 {
+	if cr == nil {
+		return 0
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(80)
+	}
+	size += cr.result.CachedSize(true)
+	size += hack.RuntimeAllocSize(int64(cap(cr.sources)) * int64(16))
+	for _, source := range cr.sources {
+		size += hack.RuntimeAllocSize(int64(len(source)))
+	}
+	size += hack.RuntimeAllocSize(int64(cap(cr.generations)) * int64(8))
+	return size
+}
+
+// pendingResult is a cacheable query that missed the cache. Its result
+// is stored once it is executed.
+type pendingResult struct {
+	key         theine.HashKey256
+	epoch       uint32
+	ttl         time.Duration
+	sources     []string
+	generations []uint64
+}
+
+func newResultCache(maxMemory int64, vstream vstreamFunc) *resultCache {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &resultCache{
+		cache:        theine.NewStore[theine.HashKey256, *cachedResult](maxMemory, false),
+		vstream:      vstream,
+		retryDelay:   resultCacheRetryDelay,
+		ctx:          ctx,
+		cancel:       cancel,
+		generations:  make(map[string]uint64),
+		invalidators: make(map[string]*cacheInvalidator),
+	}
+}
+
+// Close stops the invalidation streams and releases the cache.
+func (rc *resultCache) Close() {
+	rc.cancel()
+	rc.wg.Wait()
+	rc.cache.Close()
+}
+
+// Clear invalidates all the cached results.
+func (rc *resultCache) Clear() {
+	rc.epoch.Add(1)
+}
+
+// lookup returns the cached result of the query if there is one. Otherwise,
+// if the result of the query can be cached, it returns the pendingResult to
+// store it with once it is executed.
+func (rc *resultCache) lookup(ctx context.Context, plan *engine.Plan, vcursor *vcursorImpl, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, *pendingResult) {
+	ttl := rc.ttl(plan, vcursor)
+	if ttl <= 0 {
+		return nil, nil
+	}
+
+	key := rc.key(ctx, plan, vcursor, bindVars)
+	epoch := rc.epoch.Load()
+	if entry, ok := rc.cache.Get(key, epoch); ok {
+		if time.Now().Before(entry.expiry) && rc.valid(entry.sources, entry.generations) {
+			resultCacheHits.Add(1)
+			return entry.result.ShallowCopy(), nil
+		}
+		rc.cache.Delete(key)
+	}
+	resultCacheMisses.Add(1)
+
+	sources, generations, ok := rc.watch(vcursor.tabletType, plan.TablesUsed)
+	if !ok {
+		return nil, nil
+	}
+	return nil, &pendingResult{
+		key:         key,
+		epoch:       epoch,
+		ttl:         ttl,
+		sources:     sources,
+		generations: generations,
+	}
+}
+
+// store caches the result of a pending query.
+func (rc *resultCache) store(pending *pendingResult, qr *sqltypes.Result) {
+	entry := &cachedResult{
+		result:      qr.Copy(),
+		expiry:      time.Now().Add(pending.ttl),
+		sources:     pending.sources,
+		generations: pending.generations,
+	}
+	rc.cache.Set(pending.key, entry, 0, pending.epoch)
+}
+
+// ttl returns the TTL with which the result of the plan can be cached,
+// or zero if it can't be cached. The TTL of a plan is the one requested
+// by its query comment directive if there is one, and the lowest of the
+// TTLs of its tables otherwise.
+func (rc *resultCache) ttl(plan *engine.Plan, vcursor *vcursorImpl) time.Duration {
+	opts := vcursor.resultCacheOptions
+	if plan.Type != sqlparser.StmtSelect || !opts.cacheable || len(plan.TablesUsed) == 0 {
+		return 0
+	}
+	if vcursor.safeSession.InTransaction() || vcursor.safeSession.InReservedConn() {
+		return 0
+	}
+
+	var ttl time.Duration
+	for _, table := range plan.TablesUsed {
+		keyspace, name, ok := strings.Cut(table, ".")
+		if !ok {
+			return 0
+		}
+		if opts.ttlSet {
+			continue
+		}
+		ks := vcursor.vschema.Keyspaces[keyspace]
+		if ks == nil || ks.Tables[name] == nil {
+			return 0
+		}
+		tableTTL := ks.Tables[name].ResultCacheTTL
+		if tableTTL <= 0 {
+			return 0
+		}
+		if ttl == 0 || tableTTL < ttl {
+			ttl = tableTTL
+		}
+	}
+	if opts.ttlSet {
+		return opts.ttl
+	}
+	return ttl
+}
+
+// key returns the cache key of a query, which is made of its normalized
+// form, its bind variables and everything else its result depends on.
+func (rc *resultCache) key(ctx context.Context, plan *engine.Plan, vcursor *vcursorImpl, bindVars map[string]*querypb.BindVariable) theine.HashKey256 {
+	hasher := vthash.New256()
+	vcursor.keyForPlan(ctx, plan.Original, hasher)
+
+	// Access to the tables is checked by the tablets, so results are
+	// not shared between users.
+	_, _ = hasher.WriteString("+User:")
+	_, _ = hasher.WriteString(callerid.ImmediateCallerIDFromContext(ctx).GetUsername())
+
+	_, _ = hasher.WriteString("+BindVars:")
+	names := make([]string, 0, len(bindVars))
+	for name := range bindVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		bv := bindVars[name]
+		writeLengthPrefixed(hasher, name)
+		_, _ = hasher.WriteString(bv.Type.String())
+		writeLengthPrefixed(hasher, hack.String(bv.Value))
+		_, _ = hasher.WriteString(strconv.Itoa(len(bv.Values)))
+		for _, value := range bv.Values {
+			_, _ = hasher.WriteString(value.Type.String())
+			writeLengthPrefixed(hasher, hack.String(value.Value))
+		}
+	}
+
+	_, _ = hasher.WriteString("+SysVars:")
+	sysVars := map[string]string{}
+	vcursor.safeSession.GetSystemVariables(func(name string, value string) {
+		sysVars[name] = value
+	})
+	names = names[:0]
+	for name := range sysVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeLengthPrefixed(hasher, name)
+		writeLengthPrefixed(hasher, sysVars[name])
+	}
+
+	var key theine.HashKey256
+	hasher.Sum(key[:0])
+	return key
+}
+
+func writeLengthPrefixed(hasher *vthash.Hasher256, s string) {
+	_, _ = hasher.WriteString(strconv.Itoa(len(s)))
+	_, _ = hasher.WriteString(":")
+	_, _ = hasher.WriteString(s)
+}
+
+// valid returns whether none of the sources changed since the given generations.
+func (rc *resultCache) valid(sources []string, generations []uint64) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for i, source := range sources {
+		if rc.generations[source] != generations[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// watch makes sure the changes to the tables are streamed from the tablets
+// of the given type, and returns the current generations of the tables and
+// of their keyspaces. It returns false if one of the streams is down, in
+// which case results read from the tables can't be cached.
+func (rc *resultCache) watch(tabletType topodatapb.TabletType, tables []string) ([]string, []uint64, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.ctx.Err() != nil {
+		return nil, nil, false
+	}
+
+	var sources []string
+	for _, table := range tables {
+		keyspace, name, _ := strings.Cut(table, ".")
+		invalidatorKey := keyspace + vindexes.TabletTypeSuffix[tabletType]
+		ci := rc.invalidators[invalidatorKey]
+		if ci == nil {
+			ci = newCacheInvalidator(rc, keyspace, tabletType)
+			rc.invalidators[invalidatorKey] = ci
+			rc.wg.Add(1)
+			go func() {
+				defer rc.wg.Done()
+				ci.run(rc.ctx)
+			}()
+		}
+		if !ci.watch(name) {
+			return nil, nil, false
+		}
+		if !slices.Contains(sources, keyspace) {
+			sources = append(sources, keyspace)
+		}
+		sources = append(sources, table)
+	}
+
+	generations := make([]uint64, len(sources))
+	for i, source := range sources {
+		generations[i] = rc.generations[source]
+	}
+	return sources, generations, true
+}
+
+// invalidate invalidates the cached results read from the given keyspace
+// or keyspace-qualified table.
+func (rc *resultCache) invalidate(keyspace, source string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.generations[source]++
+	resultCacheInvalidations.Add(keyspace, 1)
+}
+
+// cacheInvalidator streams the changes to the watched tables of a keyspace
+// from the tablets of one type, and invalidates the cached results read
+// from them.
+type cacheInvalidator struct {
+	rc         *resultCache
+	keyspace   string
+	tabletType topodatapb.TabletType
+
+	// restart is signaled when the stream needs to be restarted to
+	// include newly watched tables.
+	restart chan struct{}
+
+	mu     sync.Mutex
+	tables map[string]bool
+	// vgtid is the position of the stream, from which it is restarted.
+	vgtid *binlogdatapb.VGtid
+	// down is set while the stream is waiting to be restarted after a failure.
+	down bool
+}
+
+func newCacheInvalidator(rc *resultCache, keyspace string, tabletType topodatapb.TabletType) *cacheInvalidator {
+	return &cacheInvalidator{
+		rc:         rc,
+		keyspace:   keyspace,
+		tabletType: tabletType,
+		restart:    make(chan struct{}, 1),
+		tables:     make(map[string]bool),
+	}
+}
+
+// watch adds a table to the stream. It returns false if the stream is down.
+func (ci *cacheInvalidator) watch(table string) bool {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	if ci.down {
+		return false
+	}
+	if ci.tables[table] {
+		return true
+	}
+	ci.tables[table] = true
+	select {
+	case ci.restart <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// run streams the changes until ctx is done, restarting the stream when
+// tables are added and after failures.
+func (ci *cacheInvalidator) run(ctx context.Context) {
+	for {
+		vgtid, filter := ci.streamParams()
+		streamCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() {
+			done <- ci.rc.vstream(streamCtx, ci.tabletType, vgtid, filter, &vtgatepb.VStreamFlags{}, ci.handleEvents)
+		}()
+
+		select {
+		case <-ctx.Done():
+			cancel()
+			<-done
+			return
+		case <-ci.restart:
+			// Resume from the current position with the new tables.
+			cancel()
+			<-done
+			continue
+		case err := <-done:
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+			log.Warningf("Result cache invalidation stream for %s%s failed, retrying in %v: %v",
+				ci.keyspace, vindexes.TabletTypeSuffix[ci.tabletType], ci.rc.retryDelay, err)
+		}
+
+		// The changes made while the stream is down are lost: invalidate
+		// everything read from the keyspace, and don't cache new results
+		// until the stream is restarted.
+		ci.mu.Lock()
+		ci.down = true
+		ci.vgtid = nil
+		ci.mu.Unlock()
+		ci.rc.invalidate(ci.keyspace, ci.keyspace)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(ci.rc.retryDelay):
+		}
+		ci.mu.Lock()
+		ci.down = false
+		ci.mu.Unlock()
+	}
+}
+
+// streamParams returns the position and the filter to start the stream with.
+func (ci *cacheInvalidator) streamParams() (*binlogdatapb.VGtid, *binlogdatapb.Filter) {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
+	vgtid := ci.vgtid
+	if vgtid == nil {
+		vgtid = &binlogdatapb.VGtid{
+			ShardGtids: []*binlogdatapb.ShardGtid{{
+				Keyspace: ci.keyspace,
+				Gtid:     "current",
+			}},
+		}
+	}
+
+	tables := make([]string, 0, len(ci.tables))
+	for table := range ci.tables {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	filter := &binlogdatapb.Filter{}
+	for _, table := range tables {
+		filter.Rules = append(filter.Rules, &binlogdatapb.Rule{Match: table})
+	}
+	return vgtid, filter
+}
+
+// handleEvents invalidates the tables changed by the events.
+func (ci *cacheInvalidator) handleEvents(events []*binlogdatapb.VEvent) error {
+	for _, event := range events {
+		switch event.Type {
+		case binlogdatapb.VEventType_ROW:
+			// The table names of the row events are qualified by the
+			// vstream manager.
+			ci.rc.invalidate(ci.keyspace, event.RowEvent.TableName)
+		case binlogdatapb.VEventType_DDL:
+			ci.rc.invalidate(ci.keyspace, ci.keyspace)
+		case binlogdatapb.VEventType_VGTID:
+			ci.mu.Lock()
+			ci.vgtid = event.Vgtid
+			ci.mu.Unlock()
+		}
+	}
+	return nil
+}
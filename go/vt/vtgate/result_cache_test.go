@@ -0,0 +1,215 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+)
+
+// fakeInvalidationStream records the streams started by the result cache.
+type fakeInvalidationStream struct {
+	mu      sync.Mutex
+	filters []*binlogdatapb.Filter
+	vgtids  []*binlogdatapb.VGtid
+	send    func(events []*binlogdatapb.VEvent) error
+	err     error
+}
+
+func (f *fakeInvalidationStream) vstream(ctx context.Context, tabletType topodatapb.TabletType, vgtid *binlogdatapb.VGtid,
+	filter *binlogdatapb.Filter, flags *vtgatepb.VStreamFlags, send func(events []*binlogdatapb.VEvent) error) error {
+	f.mu.Lock()
+	f.filters = append(f.filters, filter)
+	f.vgtids = append(f.vgtids, vgtid)
+	f.send = send
+	err := f.err
+	f.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (f *fakeInvalidationStream) lastFilter() *binlogdatapb.Filter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.filters) == 0 {
+		return nil
+	}
+	return f.filters[len(f.filters)-1]
+}
+
+func (f *fakeInvalidationStream) sendEvents(t *testing.T, events ...*binlogdatapb.VEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	require.NoError(t, f.send(events))
+}
+
+func TestResultCache(t *testing.T) {
+	executor, _, _, sbclookup, ctx := createExecutorEnv(t)
+	executor.normalize = true
+	stream := &fakeInvalidationStream{}
+	executor.resultCache = newResultCache(1024*1024, stream.vstream)
+	t.Cleanup(executor.resultCache.Close)
+
+	session := &vtgatepb.Session{TargetString: "@primary", Autocommit: true}
+	exec := func(sql string) {
+		t.Helper()
+		_, err := executorExec(ctx, executor, session, sql, nil)
+		require.NoError(t, err)
+	}
+	execCount := func() int64 {
+		return sbclookup.ExecCount.Load()
+	}
+
+	query := "select /*vt+ RESULT_CACHE_TTL_MS=60000 */ id from main1 where id = 1"
+	exec(query)
+	require.EqualValues(t, 1, execCount())
+	hits := resultCacheHits.Get()
+	exec(query)
+	assert.EqualValues(t, 1, execCount(), "the result should be served from the cache")
+	assert.EqualValues(t, hits+1, resultCacheHits.Get())
+
+	// The bind variables are part of the key.
+	exec("select /*vt+ RESULT_CACHE_TTL_MS=60000 */ id from main1 where id = 2")
+	assert.EqualValues(t, 2, execCount())
+
+	// The changes to the table invalidate its cached results.
+	require.Eventually(t, func() bool {
+		filter := stream.lastFilter()
+		return filter != nil && len(filter.Rules) == 1 && filter.Rules[0].Match == "main1"
+	}, 5*time.Second, 10*time.Millisecond)
+	stream.sendEvents(t, &binlogdatapb.VEvent{
+		Type:     binlogdatapb.VEventType_ROW,
+		RowEvent: &binlogdatapb.RowEvent{TableName: KsTestUnsharded + ".main1"},
+	})
+	exec(query)
+	assert.EqualValues(t, 3, execCount(), "the result should have been invalidated")
+	exec(query)
+	assert.EqualValues(t, 3, execCount())
+
+	// The results are not cached without the directive or the VSchema setting.
+	exec("select id from main1 where id = 1")
+	exec("select id from main1 where id = 1")
+	assert.EqualValues(t, 5, execCount())
+
+	// The directive disables the cache with a zero TTL.
+	exec("select /*vt+ RESULT_CACHE_TTL_MS=0 */ id from main1 where id = 1")
+	assert.EqualValues(t, 6, execCount())
+
+	// The results of the tables with a TTL in the VSchema are cached.
+	executor.VSchema().Keyspaces[KsTestUnsharded].Tables["simple"].ResultCacheTTL = time.Minute
+	exec("select id from simple where id = 1")
+	exec("select id from simple where id = 1")
+	assert.EqualValues(t, 7, execCount())
+
+	// A change to the VSchema invalidates everything.
+	executor.SaveVSchema(nil, executor.vschemaStats)
+	exec("select id from simple where id = 1")
+	assert.EqualValues(t, 8, execCount())
+
+	// Results are not cached inside transactions.
+	exec("begin")
+	exec("select id from simple where id = 2")
+	exec("select id from simple where id = 2")
+	exec("rollback")
+	assert.EqualValues(t, 10, execCount())
+}
+
+func TestResultCacheExpiry(t *testing.T) {
+	executor, _, _, sbclookup, ctx := createExecutorEnv(t)
+	stream := &fakeInvalidationStream{}
+	executor.resultCache = newResultCache(1024*1024, stream.vstream)
+	t.Cleanup(executor.resultCache.Close)
+
+	session := &vtgatepb.Session{TargetString: "@primary", Autocommit: true}
+	query := "select /*vt+ RESULT_CACHE_TTL_MS=100 */ id from main1 where id = 1"
+	for i := 0; i < 2; i++ {
+		_, err := executorExec(ctx, executor, session, query, nil)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 1, sbclookup.ExecCount.Load())
+
+	time.Sleep(150 * time.Millisecond)
+	_, err := executorExec(ctx, executor, session, query, nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, sbclookup.ExecCount.Load(), "the result should have expired")
+}
+
+func TestResultCacheStreamFailure(t *testing.T) {
+	executor, _, _, sbclookup, ctx := createExecutorEnv(t)
+	stream := &fakeInvalidationStream{err: assert.AnError}
+	executor.resultCache = newResultCache(1024*1024, stream.vstream)
+	executor.resultCache.retryDelay = time.Hour
+	t.Cleanup(executor.resultCache.Close)
+
+	session := &vtgatepb.Session{TargetString: "@primary", Autocommit: true}
+	query := "select /*vt+ RESULT_CACHE_TTL_MS=60000 */ id from main1 where id = 1"
+	_, err := executorExec(ctx, executor, session, query, nil)
+	require.NoError(t, err)
+
+	// Once the stream failed, the results are no longer cached until it
+	// is restarted.
+	require.Eventually(t, func() bool {
+		_, err := executorExec(ctx, executor, session, query, nil)
+		require.NoError(t, err)
+		count := sbclookup.ExecCount.Load()
+		_, err = executorExec(ctx, executor, session, query, nil)
+		require.NoError(t, err)
+		return sbclookup.ExecCount.Load() == count+1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestResultCacheable(t *testing.T) {
+	testCases := []struct {
+		query     string
+		cacheable bool
+	}{
+		{"select id from user where id = 1", true},
+		{"select id from user union select id from music", true},
+		{"select count(*), lower(name) from user", true},
+		{"select id from user where id = 1 for update", false},
+		{"select id from user where id = 1 lock in share mode", false},
+		{"select sql_calc_found_rows id from user", false},
+		{"select id from user into outfile 'x'", false},
+		{"select now() from user", false},
+		{"select rand() from user", false},
+		{"select get_lock('x', 10) from user", false},
+		{"select @x from user", false},
+		{"insert into user(id) values (1)", false},
+		{"update user set name = 'x'", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			stmt, err := sqlparser.Parse(tc.query)
+			require.NoError(t, err)
+			assert.Equal(t, tc.cacheable, resultCacheable(stmt))
+		})
+	}
+}
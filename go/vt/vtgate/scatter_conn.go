@@ -20,6 +20,7 @@ import (
 	"context"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"vitess.io/vitess/go/mysql/sqlerror"
@@ -563,6 +564,48 @@ func (stc *ScatterConn) GetHealthCheckCacheStatus() discovery.TabletsCacheStatus
 	return stc.gateway.TabletsCacheStatus()
 }
 
+// killQueriesTimeout bounds the time spent killing the queries of a session
+// on the tablets.
+var killQueriesTimeout = 10 * time.Second
+
+// KillQueries kills the queries in flight of the vtgate session with the given
+// UUID on all the serving tablets known to the health check, and returns how
+// many were killed. Tablets which cannot kill queries, because they stopped
+// serving in the meantime or are too old to support it, are skipped.
+func (stc *ScatterConn) KillQueries(ctx context.Context, sessionUUID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, killQueriesTimeout)
+	defer cancel()
+
+	var (
+		wg        sync.WaitGroup
+		count     atomic.Int64
+		allErrors concurrency.AllErrorRecorder
+	)
+	for _, tcs := range stc.GetHealthCheckCacheStatus() {
+		for _, th := range tcs.TabletsStats {
+			if th.Conn == nil || !th.Serving {
+				continue
+			}
+			wg.Add(1)
+			go func(th *discovery.TabletHealth) {
+				defer wg.Done()
+				alias := topoproto.TabletAliasString(th.Tablet.Alias)
+				n, err := th.Conn.KillQueries(ctx, th.Target, sessionUUID)
+				switch vterrors.Code(err) {
+				case vtrpcpb.Code_OK:
+					count.Add(n)
+				case vtrpcpb.Code_UNIMPLEMENTED, vtrpcpb.Code_CLUSTER_EVENT, vtrpcpb.Code_FAILED_PRECONDITION:
+					log.Infof("Skipping tablet %s to kill the queries of session %s: %v", alias, sessionUUID, err)
+				default:
+					allErrors.RecordError(vterrors.Wrapf(err, "failed to kill queries on tablet %s", alias))
+				}
+			}(th)
+		}
+	}
+	wg.Wait()
+	return count.Load(), allErrors.AggrError(vterrors.Aggregate)
+}
+
 // multiGo performs the requested 'action' on the specified
 // shards in parallel. This does not handle any transaction state.
 // The action function must match the shardActionFunc2 signature.
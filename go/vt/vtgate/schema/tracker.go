@@ -26,6 +26,7 @@ import (
 	"vitess.io/vitess/go/vt/discovery"
 	"vitess.io/vitess/go/vt/log"
 	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
 	"vitess.io/vitess/go/vt/vttablet/queryservice"
@@ -33,6 +34,7 @@ import (
 
 type (
 	keyspaceStr  = string
+	shardStr     = string
 	tableNameStr = string
 	viewNameStr  = string
 
@@ -41,9 +43,13 @@ type (
 		ch     chan *discovery.TabletHealth
 		cancel context.CancelFunc
 
-		mu     sync.Mutex
-		tables *tableMap
-		views  *viewMap
+		mu         sync.Mutex
+		tables     *tableMap
+		views      *viewMap
+		statistics map[keyspaceStr]map[shardStr]map[tableNameStr]*querypb.TableStatistics
+		// shards are the shards of each keyspace whose primary was seen in the
+		// health stream, which may not all have sent their statistics yet.
+		shards map[keyspaceStr]map[shardStr]bool
 		ctx    context.Context
 		signal func() // a function that we'll call whenever we have new schema data
 
@@ -62,6 +68,8 @@ func NewTracker(ch chan *discovery.TabletHealth, enableViews bool) *Tracker {
 		ctx:          context.Background(),
 		ch:           ch,
 		tables:       &tableMap{m: make(map[keyspaceStr]map[tableNameStr]*vindexes.TableInfo)},
+		statistics:   make(map[keyspaceStr]map[shardStr]map[tableNameStr]*querypb.TableStatistics),
+		shards:       make(map[keyspaceStr]map[shardStr]bool),
 		tracked:      map[keyspaceStr]*updateController{},
 		consumeDelay: defaultConsumeDelay,
 	}
@@ -82,6 +90,11 @@ func (t *Tracker) LoadKeyspace(conn queryservice.QueryService, target *querypb.T
 	if err != nil {
 		return err
 	}
+	// The statistics are optional: the tablets only collect them when they
+	// are configured to, and the planner falls back to its defaults.
+	if err := t.loadStatistics(conn, target); err != nil {
+		log.Warningf("error fetching the table statistics of keyspace %s: %v", target.Keyspace, err)
+	}
 
 	t.tracked[target.Keyspace].setLoaded(true)
 	return nil
@@ -142,6 +155,115 @@ func (t *Tracker) loadViews(conn queryservice.QueryService, target *querypb.Targ
 	return nil
 }
 
+// loadStatistics loads the table statistics of the shard of the target. Each
+// shard only knows about its own rows, so they are kept per shard and
+// aggregated for the keyspace in Tables.
+func (t *Tracker) loadStatistics(conn queryservice.QueryService, target *querypb.Target) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := make(map[tableNameStr]*querypb.TableStatistics)
+	err := conn.GetSchema(t.ctx, target, querypb.SchemaTableType_STATISTICS, nil, func(schemaRes *querypb.GetSchemaResponse) error {
+		for tableName, ts := range schemaRes.TableStatistics {
+			stats[tableName] = ts
+		}
+		return nil
+	})
+	ksStats := t.statistics[target.Keyspace]
+	if err != nil {
+		delete(ksStats, target.Shard)
+		return err
+	}
+	if ksStats == nil {
+		ksStats = make(map[shardStr]map[tableNameStr]*querypb.TableStatistics)
+		t.statistics[target.Keyspace] = ksStats
+	}
+	ksStats[target.Shard] = stats
+	log.Infof("finished loading table statistics for keyspace %s shard %s. Found %d tables", target.Keyspace, target.Shard, len(stats))
+	return nil
+}
+
+// addShard records the shard of a primary tablet, so that the statistics of
+// the shards that were loaded can be scaled to the whole keyspace.
+func (t *Tracker) addShard(target *querypb.Target) {
+	if target.TabletType != topodatapb.TabletType_PRIMARY {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	shards := t.shards[target.Keyspace]
+	if shards == nil {
+		shards = make(map[shardStr]bool)
+		t.shards[target.Keyspace] = shards
+	}
+	shards[target.Shard] = true
+}
+
+// keyspaceStatistics aggregates the statistics of the given table loaded from
+// the shards of the keyspace. The row counts and the cardinalities of the
+// unique indexes are summed and scaled by the number of shards that did not
+// report them yet. The rows of the other indexes may share their values across
+// shards, so their cardinality is the largest one of the shards.
+func (t *Tracker) keyspaceStatistics(ks, tbl string) *querypb.TableStatistics {
+	var (
+		agg      *querypb.TableStatistics
+		reported int
+		indexes  = make(map[string]*querypb.IndexStatistics)
+	)
+	for _, shardStats := range t.statistics[ks] {
+		ts, ok := shardStats[tbl]
+		if !ok {
+			continue
+		}
+		reported++
+		if agg == nil {
+			agg = &querypb.TableStatistics{}
+		}
+		agg.RowCount += ts.RowCount
+		for _, idx := range ts.Indexes {
+			aggIdx, ok := indexes[idx.Name]
+			if !ok {
+				aggIdx = &querypb.IndexStatistics{Name: idx.Name, Columns: idx.Columns, Unique: idx.Unique}
+				indexes[idx.Name] = aggIdx
+				agg.Indexes = append(agg.Indexes, aggIdx)
+			}
+			for i, card := range idx.Cardinality {
+				if i == len(aggIdx.Cardinality) {
+					aggIdx.Cardinality = append(aggIdx.Cardinality, 0)
+				}
+				switch {
+				case aggIdx.Unique:
+					aggIdx.Cardinality[i] += card
+				case card > aggIdx.Cardinality[i]:
+					aggIdx.Cardinality[i] = card
+				}
+			}
+		}
+	}
+	if agg == nil {
+		return nil
+	}
+
+	shards := max(len(t.shards[ks]), len(t.statistics[ks]))
+	if shards <= reported {
+		return agg
+	}
+	scale := func(n uint64) uint64 {
+		return n * uint64(shards) / uint64(reported)
+	}
+	agg.RowCount = scale(agg.RowCount)
+	for _, idx := range agg.Indexes {
+		if !idx.Unique {
+			continue
+		}
+		for i := range idx.Cardinality {
+			idx.Cardinality[i] = scale(idx.Cardinality[i])
+		}
+	}
+	return agg
+}
+
 // Start starts the schema tracking.
 func (t *Tracker) Start() {
 	log.Info("Starting schema tracking")
@@ -155,6 +277,7 @@ func (t *Tracker) Start() {
 					// channel closed
 					return
 				}
+				t.addShard(th.Target)
 				ksUpdater := t.getKeyspaceUpdateController(th)
 				ksUpdater.add(th)
 			case <-ctx.Done():
@@ -226,7 +349,17 @@ func (t *Tracker) Tables(ks string) map[string]*vindexes.TableInfo {
 		return map[string]*vindexes.TableInfo{} // we know nothing about this KS, so that is the info we can give out
 	}
 
-	return maps.Clone(m)
+	if len(t.statistics[ks]) == 0 {
+		return maps.Clone(m)
+	}
+	stats := make(map[string]*vindexes.TableInfo, len(m))
+	for tbl, tblInfo := range m {
+		if ts := t.keyspaceStatistics(ks, tbl); ts != nil {
+			tblInfo = &vindexes.TableInfo{Columns: tblInfo.Columns, ForeignKeys: tblInfo.ForeignKeys, Statistics: ts}
+		}
+		stats[tbl] = tblInfo
+	}
+	return stats
 }
 
 // Views returns all known views in the keyspace with their definition.
@@ -247,6 +380,11 @@ func (t *Tracker) updateSchema(th *discovery.TabletHealth) bool {
 	if th.Stats.TableSchemaChanged != nil {
 		success = t.updatedTableSchema(th)
 	}
+	if success && th.Stats.TableStatisticsChanged {
+		if err := t.loadStatistics(th.Conn, th.Target); err != nil {
+			log.Warningf("error fetching the new table statistics of keyspace %s: %v", th.Target.Keyspace, err)
+		}
+	}
 	if !success || th.Stats.ViewSchemaChanged == nil {
 		return success
 	}
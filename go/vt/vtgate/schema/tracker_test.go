@@ -217,6 +217,102 @@ func TestTableTracking(t *testing.T) {
 	testTracker(t, schemaDefResult, testcases)
 }
 
+// TestTableStatisticsTracking tests that the tracker loads the table statistics, and reloads them when they change.
+func TestTableStatisticsTracking(t *testing.T) {
+	ch := make(chan *discovery.TabletHealth)
+	tracker := NewTracker(ch, false)
+	tracker.consumeDelay = 1 * time.Millisecond
+	tracker.Start()
+	defer tracker.Stop()
+
+	wg := sync.WaitGroup{}
+	tracker.RegisterSignalReceiver(func() {
+		wg.Done()
+	})
+
+	target := &querypb.Target{Cell: cell, Keyspace: keyspace, Shard: "-80", TabletType: topodatapb.TabletType_PRIMARY}
+	tablet := &topodatapb.Tablet{Keyspace: target.Keyspace, Shard: target.Shard, Type: target.TabletType}
+	sbc := sandboxconn.NewSandboxConn(tablet)
+	sbc.SetSchemaResult([]map[string]string{{
+		"t1": "create table t1(id bigint primary key)",
+		"t2": "create table t2(id bigint primary key)",
+	}})
+	sbc.TableStatistics = map[string]*querypb.TableStatistics{
+		"t1": {RowCount: 10},
+	}
+
+	for _, tcase := range []struct {
+		stats *querypb.RealtimeStats
+		want  map[string]*querypb.TableStatistics
+	}{{
+		stats: &querypb.RealtimeStats{},
+		want:  map[string]*querypb.TableStatistics{"t1": {RowCount: 10}},
+	}, {
+		stats: &querypb.RealtimeStats{TableStatisticsChanged: true},
+		want:  map[string]*querypb.TableStatistics{"t1": {RowCount: 20}, "t2": {RowCount: 5}},
+	}} {
+		wg.Add(1)
+		ch <- &discovery.TabletHealth{
+			Conn:    sbc,
+			Tablet:  tablet,
+			Target:  target,
+			Serving: true,
+			Stats:   tcase.stats,
+		}
+		require.False(t, waitTimeout(&wg, time.Second), "statistics were updated but received no signal")
+
+		got := make(map[string]*querypb.TableStatistics)
+		for name, tblInfo := range tracker.Tables(keyspace) {
+			if tblInfo.Statistics != nil {
+				got[name] = tblInfo.Statistics
+			}
+		}
+		utils.MustMatch(t, tcase.want, got)
+
+		sbc.TableStatistics = map[string]*querypb.TableStatistics{
+			"t1": {RowCount: 20},
+			"t2": {RowCount: 5},
+		}
+	}
+	// The statistics are not fetched with the table definitions.
+	require.EqualValues(t, 1, sbc.GetSchemaCount.Load())
+}
+
+// TestTableStatisticsAcrossShards tests that the table statistics of the shards are aggregated for the keyspace.
+func TestTableStatisticsAcrossShards(t *testing.T) {
+	tracker := NewTracker(nil, false)
+	tracker.tables.m[keyspace] = map[string]*vindexes.TableInfo{"t1": {}, "t2": {}}
+	for _, shard := range []string{"-40", "40-80", "80-c0", "c0-"} {
+		tracker.addShard(&querypb.Target{Keyspace: keyspace, Shard: shard, TabletType: topodatapb.TabletType_PRIMARY})
+	}
+	// Only the primaries count as shards.
+	tracker.addShard(&querypb.Target{Keyspace: keyspace, Shard: "x", TabletType: topodatapb.TabletType_REPLICA})
+
+	index := func(unique bool, cardinality ...uint64) []*querypb.IndexStatistics {
+		return []*querypb.IndexStatistics{{Name: "idx", Columns: []string{"a", "b"}, Cardinality: cardinality, Unique: unique}}
+	}
+	tracker.statistics[keyspace] = map[string]map[string]*querypb.TableStatistics{
+		"-40": {
+			"t1": {RowCount: 100, Indexes: index(true, 10, 100)},
+			"t2": {RowCount: 30, Indexes: index(false, 3, 20)},
+		},
+		"40-80": {
+			"t1": {RowCount: 300, Indexes: index(true, 20, 300)},
+			"t2": {RowCount: 50, Indexes: index(false, 5, 10)},
+		},
+	}
+
+	got := make(map[string]*querypb.TableStatistics)
+	for name, tblInfo := range tracker.Tables(keyspace) {
+		got[name] = tblInfo.Statistics
+	}
+	// Two of the four shards reported their statistics, so the sums are doubled.
+	utils.MustMatch(t, map[string]*querypb.TableStatistics{
+		"t1": {RowCount: 800, Indexes: index(true, 60, 800)},
+		"t2": {RowCount: 160, Indexes: index(false, 5, 20)},
+	}, got)
+}
+
 // TestViewsTracking tests that the tracker is able to track views.
 func TestViewsTracking(t *testing.T) {
 	schemaDefResult := []map[string]string{{
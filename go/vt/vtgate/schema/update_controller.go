@@ -100,6 +100,9 @@ func (u *updateController) getItemFromQueueLocked() *discovery.TabletHealth {
 		// We are trying to minimize the vttablet calls here by merging all the table/view changes received into a single changed item
 		// with all the table and view names.
 		for i := 1; i < itemsCount; i++ {
+			if u.queue.items[i].Stats.TableStatisticsChanged {
+				item.Stats.TableStatisticsChanged = true
+			}
 			for _, table := range u.queue.items[i].Stats.TableSchemaChanged {
 				found := false
 				for _, itemTable := range item.Stats.TableSchemaChanged {
@@ -147,8 +150,8 @@ func (u *updateController) add(th *discovery.TabletHealth) {
 		return
 	}
 
-	// If the keyspace schema is loaded and there is no schema change detected. Then there is nothing to process.
-	if len(th.Stats.TableSchemaChanged) == 0 && len(th.Stats.ViewSchemaChanged) == 0 && u.loaded {
+	// If the keyspace schema is loaded and there is no schema or statistics change detected. Then there is nothing to process.
+	if len(th.Stats.TableSchemaChanged) == 0 && len(th.Stats.ViewSchemaChanged) == 0 && !th.Stats.TableStatisticsChanged && u.loaded {
 		return
 	}
 
@@ -308,7 +308,11 @@ func (a *analyzer) noteQuerySignature(node sqlparser.SQLNode) {
 			a.sig.Aggregation = true
 		}
 	case sqlparser.AggrFunc:
-		a.sig.Aggregation = true
+		// aggregation functions used with an OVER clause are window functions,
+		// and do not turn the query into an aggregating query
+		if !sqlparser.IsWindowFunc(node) {
+			a.sig.Aggregation = true
+		}
 	}
 }
 
@@ -64,6 +64,17 @@ func (t *typer) up(cursor *sqlparser.Cursor) error {
 		}
 		type_ := code.Type(inputType)
 		t.exprTypes[node] = Type{Type: type_, Collation: collations.DefaultCollationForType(type_)}
+	case *sqlparser.ArgumentLessWindowExpr:
+		code, ok := opcode.SupportedWindowFunctions[node.Type.ToString()]
+		if !ok {
+			return nil
+		}
+		type_ := code.Type(sqltypes.Unknown)
+		t.exprTypes[node] = Type{Type: type_, Collation: collations.DefaultCollationForType(type_)}
+	case *sqlparser.LagLeadExpr:
+		if typ, ok := t.exprTypes[node.Expr]; ok && node.Default == nil {
+			t.exprTypes[node] = typ
+		}
 	}
 	return nil
 }
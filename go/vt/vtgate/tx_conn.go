@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"sync"
 
+	"vitess.io/vitess/go/stats"
 	"vitess.io/vitess/go/vt/concurrency"
 	"vitess.io/vitess/go/vt/dtids"
 	"vitess.io/vitess/go/vt/log"
@@ -34,6 +35,8 @@ import (
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 )
 
+var commitUnresolved = stats.NewCounter("CommitUnresolved", "Distributed transactions left unresolved after a failure during their commit")
+
 // TxConn is used for executing transactional requests.
 type TxConn struct {
 	tabletGateway *TabletGateway
@@ -182,6 +185,7 @@ func (txc *TxConn) commit2PC(ctx context.Context, session *SafeSession) error {
 		// TODO(sougou): Perform a more fine-grained cleanup
 		// including unprepared transactions.
 		if resumeErr := txc.Resolve(ctx, dtid); resumeErr != nil {
+			commitUnresolved.Add(1)
 			log.Warningf("Rollback failed after Prepare failure: %v", resumeErr)
 		}
 		// Return the original error even if the previous operation fails.
@@ -190,6 +194,9 @@ func (txc *TxConn) commit2PC(ctx context.Context, session *SafeSession) error {
 
 	err = txc.tabletGateway.StartCommit(ctx, mmShard.Target, mmShard.TransactionId, dtid)
 	if err != nil {
+		// The outcome of StartCommit is unknown: the transaction is left
+		// to the resolver.
+		commitUnresolved.Add(1)
 		return err
 	}
 
@@ -197,10 +204,15 @@ func (txc *TxConn) commit2PC(ctx context.Context, session *SafeSession) error {
 		return txc.tabletGateway.CommitPrepared(ctx, s.Target, dtid)
 	})
 	if err != nil {
+		commitUnresolved.Add(1)
 		return err
 	}
 
-	return txc.tabletGateway.ConcludeTransaction(ctx, mmShard.Target, dtid)
+	if err := txc.tabletGateway.ConcludeTransaction(ctx, mmShard.Target, dtid); err != nil {
+		commitUnresolved.Add(1)
+		return err
+	}
+	return nil
 }
 
 // Rollback rolls back the current transaction. There are no retries on this operation.
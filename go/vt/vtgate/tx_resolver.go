@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"sync"
+
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/discovery"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+var txResolutions = stats.NewCountersWithSingleLabel("TransactionsResolved", "Distributed transactions resolved after a failure, by result", "result", "Success", "Failure")
+
+// TxResolver resolves the distributed transactions left unresolved
+// by a vtgate or a tablet failure. The primaries signal on their health
+// stream when they have such transactions, and the resolver then drives
+// them to completion.
+type TxResolver struct {
+	ch     chan *discovery.TabletHealth
+	cancel context.CancelFunc
+	txConn *TxConn
+
+	mu sync.Mutex
+	// resolving tracks the shards for which a resolution is in progress,
+	// so that repeated signals don't start another one.
+	resolving map[string]bool
+}
+
+// newTxResolver creates the resolver, listening for the signals on ch.
+func newTxResolver(ch chan *discovery.TabletHealth, txConn *TxConn) *TxResolver {
+	return &TxResolver{
+		ch:        ch,
+		txConn:    txConn,
+		resolving: map[string]bool{},
+	}
+}
+
+// Start starts listening for the signals of the primaries.
+func (tr *TxResolver) Start() {
+	log.Info("Starting transaction resolver")
+	ctx, cancel := context.WithCancel(context.Background())
+	tr.cancel = cancel
+	go func() {
+		for {
+			select {
+			case th := <-tr.ch:
+				if th == nil {
+					// channel closed
+					return
+				}
+				if !th.Stats.GetTxUnresolved() || th.Target.TabletType != topodatapb.TabletType_PRIMARY {
+					continue
+				}
+				go tr.resolveTransactions(ctx, th.Target)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the transaction resolver.
+func (tr *TxResolver) Stop() {
+	log.Info("Stopping transaction resolver")
+	if tr.cancel != nil {
+		tr.cancel()
+	}
+}
+
+// resolveTransactions resolves the unresolved transactions whose
+// metadata is stored on the target shard.
+func (tr *TxResolver) resolveTransactions(ctx context.Context, target *querypb.Target) {
+	key := topoproto.KeyspaceShardString(target.Keyspace, target.Shard)
+	tr.mu.Lock()
+	if tr.resolving[key] {
+		tr.mu.Unlock()
+		return
+	}
+	tr.resolving[key] = true
+	tr.mu.Unlock()
+	defer func() {
+		tr.mu.Lock()
+		delete(tr.resolving, key)
+		tr.mu.Unlock()
+	}()
+
+	transactions, err := tr.txConn.tabletGateway.UnresolvedTransactions(ctx, target, 0 /* abandonAgeSeconds */)
+	if err != nil {
+		log.Errorf("Error reading the unresolved transactions of %s: %v", key, err)
+		return
+	}
+	for _, transaction := range transactions {
+		if err := tr.txConn.Resolve(ctx, transaction.Dtid); err != nil {
+			txResolutions.Add("Failure", 1)
+			log.Errorf("Error resolving the distributed transaction %s: %v", transaction.Dtid, err)
+			continue
+		}
+		txResolutions.Add("Success", 1)
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"vitess.io/vitess/go/test/utils"
+	"vitess.io/vitess/go/vt/discovery"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+func TestTxResolver(t *testing.T) {
+	ctx := utils.LeakCheckContext(t)
+
+	sc, sbc0, sbc1, _, _, _ := newTestTxConnEnv(t, ctx, "TestTxResolver")
+
+	dtid := "TestTxResolver:0:1234"
+	transaction := &querypb.TransactionMetadata{
+		Dtid:  dtid,
+		State: querypb.TransactionState_COMMIT,
+		Participants: []*querypb.Target{{
+			Keyspace:   "TestTxResolver",
+			Shard:      "1",
+			TabletType: topodatapb.TabletType_PRIMARY,
+		}},
+	}
+	sbc0.UnresolvedTransactionsResult = []*querypb.TransactionMetadata{transaction}
+	sbc0.ReadTransactionResults = []*querypb.TransactionMetadata{transaction}
+
+	ch := make(chan *discovery.TabletHealth)
+	tr := newTxResolver(ch, sc.txConn)
+	tr.Start()
+	defer tr.Stop()
+
+	target := &querypb.Target{Keyspace: "TestTxResolver", Shard: "0", TabletType: topodatapb.TabletType_PRIMARY}
+	successes := txResolutions.Counts()["Success"]
+
+	// Health updates without the signal are ignored.
+	ch <- &discovery.TabletHealth{Target: target, Stats: &querypb.RealtimeStats{}}
+	ch <- &discovery.TabletHealth{Target: target, Stats: &querypb.RealtimeStats{TxUnresolved: true}}
+
+	assert.Eventually(t, func() bool {
+		return sbc0.ConcludeTransactionCount.Load() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.EqualValues(t, 1, sbc0.UnresolvedTransactionsCount.Load(), "sbc0.UnresolvedTransactionsCount")
+	assert.EqualValues(t, 1, sbc1.CommitPreparedCount.Load(), "sbc1.CommitPreparedCount")
+	assert.EqualValues(t, 0, sbc1.RollbackPreparedCount.Load(), "sbc1.RollbackPreparedCount")
+	assert.Eventually(t, func() bool {
+		return txResolutions.Counts()["Success"] == successes+1
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -73,6 +73,7 @@ type iExecute interface {
 	ExecuteVStream(ctx context.Context, rss []*srvtopo.ResolvedShard, filter *binlogdatapb.Filter, gtid string, callback func(evs []*binlogdatapb.VEvent) error) error
 	ReleaseLock(ctx context.Context, session *SafeSession) error
 
+	showProcessList(ctx context.Context, mysqlCtx vtgateservice.MySQLConnection, full bool) (*sqltypes.Result, error)
 	showVitessReplicationStatus(ctx context.Context, filter *sqlparser.ShowFilter) (*sqltypes.Result, error)
 	showShards(ctx context.Context, filter *sqlparser.ShowFilter, destTabletType topodatapb.TabletType) (*sqltypes.Result, error)
 	showTablets(filter *sqlparser.ShowFilter) (*sqltypes.Result, error)
@@ -116,6 +117,18 @@ type vcursorImpl struct {
 
 	warmingReadsPercent int
 	warmingReadsChannel chan bool
+
+	resultCacheOptions resultCacheOptions
+
+	// memory tracks the memory held by the query, it is nil when the query is not tracked
+	memory *queryMemory
+
+	// mysqlCtx is the MySQL connection which sent the query, it is nil for other protocols
+	mysqlCtx vtgateservice.MySQLConnection
+
+	// inUserTransaction is set when the query runs in a transaction of the application,
+	// rather than in one vtgate opens to autocommit it
+	inUserTransaction bool
 }
 
 // newVcursorImpl creates a vcursorImpl. Before creating this object, you have to separate out any marginComments that came with
@@ -183,6 +196,7 @@ func newVCursorImpl(
 		pv:                  pv,
 		warmingReadsPercent: warmingReadsPct,
 		warmingReadsChannel: warmingReadsChan,
+		inUserTransaction:   safeSession.InTransaction(),
 	}, nil
 }
 
@@ -216,6 +230,49 @@ func (vc *vcursorImpl) ExceedsMaxMemoryRows(numRows int) bool {
 	return !vc.ignoreMaxMemoryRows && numRows > maxMemoryRows
 }
 
+// SpillConfig implements the VCursor interface
+func (vc *vcursorImpl) SpillConfig() engine.SpillConfig {
+	return engine.SpillConfig{
+		MemoryBudget: spillMemoryBudget,
+		Dir:          spillDir,
+	}
+}
+
+// RecordSpill implements the VCursor interface
+func (vc *vcursorImpl) RecordSpill(operator string, bytes int64) {
+	atomic.AddUint64(&vc.logStats.SpilledBytes, uint64(bytes))
+	spillCount.Add(operator, 1)
+	spilledBytes.Add(operator, bytes)
+}
+
+// GrowMemory implements the VCursor interface
+func (vc *vcursorImpl) GrowMemory(bytes int64) error {
+	if vc.memory == nil {
+		return nil
+	}
+	return vc.memory.grow(bytes)
+}
+
+// ShrinkMemory implements the VCursor interface
+func (vc *vcursorImpl) ShrinkMemory(bytes int64) {
+	if vc.memory == nil {
+		return
+	}
+	vc.memory.shrink(bytes)
+}
+
+// holdResult accounts for the memory of a result fetched from the tablets,
+// which is held until the end of the query.
+func (vc *vcursorImpl) holdResult(qr *sqltypes.Result, errs []error) []error {
+	if qr == nil {
+		return errs
+	}
+	if err := vc.GrowMemory(qr.CachedSize(true)); err != nil {
+		return append(errs, err)
+	}
+	return errs
+}
+
 // SetIgnoreMaxMemoryRows sets the ignoreMaxMemoryRows value.
 func (vc *vcursorImpl) SetIgnoreMaxMemoryRows(ignoreMaxMemoryRows bool) {
 	vc.ignoreMaxMemoryRows = ignoreMaxMemoryRows
@@ -561,7 +618,7 @@ func (vc *vcursorImpl) ExecuteMultiShard(ctx context.Context, primitive engine.P
 	qr, errs := vc.executor.ExecuteMultiShard(ctx, primitive, rss, commentedShardQueries(queries, vc.marginComments), vc.safeSession, canAutocommit, vc.ignoreMaxMemoryRows)
 	vc.setRollbackOnPartialExecIfRequired(len(errs) != len(rss), rollbackOnError)
 
-	return qr, errs
+	return qr, vc.holdResult(qr, errs)
 }
 
 // StreamExecuteMulti is the streaming version of ExecuteMultiShard.
@@ -597,7 +654,7 @@ func (vc *vcursorImpl) ExecuteStandalone(ctx context.Context, primitive engine.P
 	// The autocommit flag is always set to false because we currently don't
 	// execute DMLs through ExecuteStandalone.
 	qr, errs := vc.executor.ExecuteMultiShard(ctx, primitive, rss, bqs, NewAutocommitSession(vc.safeSession.Session), false /* autocommit */, vc.ignoreMaxMemoryRows)
-	return qr, vterrors.Aggregate(errs)
+	return qr, vterrors.Aggregate(vc.holdResult(qr, errs))
 }
 
 // ExecuteKeyspaceID is part of the engine.VCursor interface.
@@ -627,6 +684,11 @@ func (vc *vcursorImpl) ExecuteKeyspaceID(ctx context.Context, keyspace string, k
 	return qr, vterrors.Aggregate(errs)
 }
 
+// InUserTransaction implements the vindexes.VCursor interface.
+func (vc *vcursorImpl) InUserTransaction() bool {
+	return vc.inUserTransaction
+}
+
 func (vc *vcursorImpl) InTransactionAndIsDML() bool {
 	if !vc.safeSession.InTransaction() {
 		return false
@@ -1144,8 +1206,10 @@ func (vc *vcursorImpl) VStream(ctx context.Context, rss []*srvtopo.ResolvedShard
 	return vc.executor.ExecuteVStream(ctx, rss, filter, gtid, callback)
 }
 
-func (vc *vcursorImpl) ShowExec(ctx context.Context, command sqlparser.ShowCommandType, filter *sqlparser.ShowFilter) (*sqltypes.Result, error) {
+func (vc *vcursorImpl) ShowExec(ctx context.Context, command sqlparser.ShowCommandType, filter *sqlparser.ShowFilter, full bool) (*sqltypes.Result, error) {
 	switch command {
+	case sqlparser.ProcessList:
+		return vc.executor.showProcessList(ctx, vc.mysqlCtx, full)
 	case sqlparser.VitessReplicationStatus:
 		return vc.executor.showVitessReplicationStatus(ctx, filter)
 	case sqlparser.VitessShards:
@@ -1246,6 +1310,7 @@ func (vc *vcursorImpl) cloneWithAutocommitSession() *vcursorImpl {
 		topoServer:      vc.topoServer,
 		warnShardedOnly: vc.warnShardedOnly,
 		pv:              vc.pv,
+		memory:          vc.memory,
 	}
 }
 
@@ -181,7 +181,7 @@ func (cached *LookupHash) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(192)
+		size += int64(160)
 	}
 	// field name string
 	size += hack.RuntimeAllocSize(int64(len(cached.name)))
@@ -202,7 +202,7 @@ func (cached *LookupHashUnique) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(192)
+		size += int64(160)
 	}
 	// field name string
 	size += hack.RuntimeAllocSize(int64(len(cached.name)))
@@ -223,7 +223,7 @@ func (cached *LookupNonUnique) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(192)
+		size += int64(160)
 	}
 	// field name string
 	size += hack.RuntimeAllocSize(int64(len(cached.name)))
@@ -244,7 +244,7 @@ func (cached *LookupUnicodeLooseMD5Hash) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(192)
+		size += int64(160)
 	}
 	// field name string
 	size += hack.RuntimeAllocSize(int64(len(cached.name)))
@@ -265,7 +265,7 @@ func (cached *LookupUnicodeLooseMD5HashUnique) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(192)
+		size += int64(160)
 	}
 	// field name string
 	size += hack.RuntimeAllocSize(int64(len(cached.name)))
@@ -286,7 +286,7 @@ func (cached *LookupUnique) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(192)
+		size += int64(160)
 	}
 	// field name string
 	size += hack.RuntimeAllocSize(int64(len(cached.name)))
@@ -575,7 +575,7 @@ func (cached *clCommon) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(288)
+		size += int64(256)
 	}
 	// field name string
 	size += hack.RuntimeAllocSize(int64(len(cached.name)))
@@ -602,13 +602,27 @@ func (cached *clCommon) CachedSize(alloc bool) int64 {
 	size += hack.RuntimeAllocSize(int64(len(cached.updateLookupQuery)))
 	return size
 }
+func (cached *kvLookupBackend) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(48)
+	}
+	// field table string
+	size += hack.RuntimeAllocSize(int64(len(cached.table)))
+	// field address string
+	size += hack.RuntimeAllocSize(int64(len(cached.address)))
+	return size
+}
 func (cached *lookupInternal) CachedSize(alloc bool) int64 {
 	if cached == nil {
 		return int64(0)
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(144)
+		size += int64(112)
 	}
 	// field Table string
 	size += hack.RuntimeAllocSize(int64(len(cached.Table)))
@@ -623,14 +637,12 @@ func (cached *lookupInternal) CachedSize(alloc bool) int64 {
 	size += hack.RuntimeAllocSize(int64(len(cached.To)))
 	// field ReadLock string
 	size += hack.RuntimeAllocSize(int64(len(cached.ReadLock)))
-	// field sel string
-	size += hack.RuntimeAllocSize(int64(len(cached.sel)))
-	// field selTxDml string
-	size += hack.RuntimeAllocSize(int64(len(cached.selTxDml)))
-	// field ver string
-	size += hack.RuntimeAllocSize(int64(len(cached.ver)))
-	// field del string
-	size += hack.RuntimeAllocSize(int64(len(cached.del)))
+	// field Backend string
+	size += hack.RuntimeAllocSize(int64(len(cached.Backend)))
+	// field backend vitess.io/vitess/go/vt/vtgate/vindexes.LookupBackend
+	if cc, ok := cached.backend.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
 	return size
 }
 func (cached *prefixCFC) CachedSize(alloc bool) int64 {
@@ -645,3 +657,32 @@ func (cached *prefixCFC) CachedSize(alloc bool) int64 {
 	size += cached.cfcCommon.CachedSize(true)
 	return size
 }
+func (cached *tableLookupBackend) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(128)
+	}
+	// field table string
+	size += hack.RuntimeAllocSize(int64(len(cached.table)))
+	// field fromColumns []string
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.fromColumns)) * int64(16))
+		for _, elem := range cached.fromColumns {
+			size += hack.RuntimeAllocSize(int64(len(elem)))
+		}
+	}
+	// field to string
+	size += hack.RuntimeAllocSize(int64(len(cached.to)))
+	// field sel string
+	size += hack.RuntimeAllocSize(int64(len(cached.sel)))
+	// field selTxDml string
+	size += hack.RuntimeAllocSize(int64(len(cached.selTxDml)))
+	// field ver string
+	size += hack.RuntimeAllocSize(int64(len(cached.ver)))
+	// field del string
+	size += hack.RuntimeAllocSize(int64(len(cached.del)))
+	return size
+}
@@ -26,11 +26,13 @@ import (
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/key"
 	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vtgate/evalengine"
 
 	querypb "vitess.io/vitess/go/vt/proto/query"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 )
 
 const (
@@ -298,7 +300,12 @@ func newCLCommon(name string, m map[string]string) (*clCommon, error) {
 		return nil, err
 	}
 
-	if err := lu.lkp.Init(m, false /* autocommit */, false /* upsert */, false /* multiShardAutocommit */); err != nil {
+	// The consistent lookup vindexes lock and update the rows of their
+	// lookup table, so their mappings can only be stored in MySQL.
+	if backend := m[lookupCommonParamBackend]; backend != "" && backend != lookupBackendTable {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "consistent lookup vindexes do not support the %s lookup backend", backend)
+	}
+	if err := lu.lkp.Init(m, false /* autocommit */, false /* upsert */, false /* multiShardAutocommit */, false /* unique */); err != nil {
 		return nil, err
 	}
 	return lu, nil
@@ -527,6 +527,10 @@ func (vc *loggingVCursor) InTransactionAndIsDML() bool {
 	return false
 }
 
+func (vc *loggingVCursor) InUserTransaction() bool {
+	return false
+}
+
 func (vc *loggingVCursor) ConnCollation() collations.ID {
 	return collations.Default()
 }
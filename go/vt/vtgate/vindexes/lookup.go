@@ -23,8 +23,11 @@ import (
 
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/key"
+	"vitess.io/vitess/go/vt/vterrors"
+
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 )
 
 const (
@@ -203,6 +206,11 @@ func (ln *LookupNonUnique) UnknownParams() []string {
 //	autocommit: setting this to "true" will cause inserts to upsert and deletes to be ignored.
 //	write_only: in this mode, Map functions return the full keyrange causing a full scatter.
 //	no_verify: in this mode, Verify will always succeed.
+//	backend: the LookupBackend that stores the mappings instead of the table, such as "kv".
+//	kv_address: the address of the lookupkv store of the "kv" backend.
+//
+// The mappings of the "kv" backend are not transactional, so they can only be
+// written by autocommitted statements.
 func newLookup(name string, m map[string]string) (Vindex, error) {
 	lookup := &LookupNonUnique{
 		name:          name,
@@ -225,7 +233,7 @@ func newLookup(name string, m map[string]string) (Vindex, error) {
 
 	// if autocommit is on for non-unique lookup, upsert should also be on.
 	upsert := cc.autocommit || cc.multiShardAutocommit
-	if err := lookup.lkp.Init(m, cc.autocommit, upsert, cc.multiShardAutocommit); err != nil {
+	if err := lookup.lkp.Init(m, cc.autocommit, upsert, cc.multiShardAutocommit, false /* unique */); err != nil {
 		return nil, err
 	}
 	return lookup, nil
@@ -275,6 +283,11 @@ func (lu *LookupUnique) AutoCommitEnabled() bool {
 //
 //	autocommit: setting this to "true" will cause deletes to be ignored.
 //	write_only: in this mode, Map functions return the full keyrange causing a full scatter.
+//	backend: the LookupBackend that stores the mappings instead of the table, such as "kv".
+//	kv_address: the address of the lookupkv store of the "kv" backend.
+//
+// The mappings of the "kv" backend are not transactional, so they can only be
+// written by autocommitted statements.
 func newLookupUnique(name string, m map[string]string) (Vindex, error) {
 	lu := &LookupUnique{
 		name:          name,
@@ -296,7 +309,7 @@ func newLookupUnique(name string, m map[string]string) (Vindex, error) {
 	}
 
 	// Don't allow upserts for unique vindexes.
-	if err := lu.lkp.Init(m, cc.autocommit, false /* upsert */, cc.multiShardAutocommit); err != nil {
+	if err := lu.lkp.Init(m, cc.autocommit, false /* upsert */, cc.multiShardAutocommit, true /* unique */); err != nil {
 		return nil, err
 	}
 	return lu, nil
@@ -395,7 +408,11 @@ func (lu *LookupUnique) IsBackfilling() bool {
 }
 
 func (lu *LookupUnique) LookupQuery() (string, error) {
-	return lu.lkp.sel, nil
+	query, _ := lu.lkp.query()
+	if query == "" {
+		return "", vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "the mappings of vindex %s are not stored in a table", lu.name)
+	}
+	return query, nil
 }
 
 func (lu *LookupUnique) Query() (string, []string) {
@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vindexes
+
+import (
+	"context"
+	"fmt"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+const (
+	// lookupBackendTable is the name of the default lookup backend, which
+	// stores the mappings in a MySQL table.
+	lookupBackendTable = "table"
+)
+
+type (
+	// LookupBackend stores the mappings of a lookup vindex, from the values
+	// of its "from" columns to the values of its "to" column.
+	LookupBackend interface {
+		// Lookup returns the values mapped to each of the ids, which are
+		// values of the first "from" column. Each row of a result holds
+		// one value.
+		Lookup(ctx context.Context, vcursor VCursor, ids []sqltypes.Value, co vtgatepb.CommitOrder) ([]*sqltypes.Result, error)
+		// Verify returns whether each of the ids is mapped to the value
+		// at the same index.
+		Verify(ctx context.Context, vcursor VCursor, ids, values []sqltypes.Value, co vtgatepb.CommitOrder) ([]bool, error)
+		// Create maps the "from" values of each row to the value at the
+		// same index. The rows have no null value, have one value per
+		// "from" column, and are sorted. In ignoreMode, the rows whose
+		// "from" values are already mapped are skipped.
+		Create(ctx context.Context, vcursor VCursor, rowsColValues [][]sqltypes.Value, toValues []sqltypes.Value, ignoreMode bool, co vtgatepb.CommitOrder) error
+		// Delete removes the mappings of the "from" values of each row
+		// to the value.
+		Delete(ctx context.Context, vcursor VCursor, rowsColValues [][]sqltypes.Value, value sqltypes.Value, co vtgatepb.CommitOrder) error
+	}
+
+	// LookupBackendConfig is the configuration of a lookup vindex that a
+	// LookupBackend is created for.
+	LookupBackendConfig struct {
+		// Table is the "table" parameter of the vindex, which names
+		// the set of mappings of the vindex in the backend.
+		Table       string
+		FromColumns []string
+		To          string
+		// Unique is true if the "from" values can only be mapped to one
+		// value.
+		Unique bool
+		// Upsert is true if the mappings of existing "from" values must
+		// be replaced instead of failing.
+		Upsert bool
+		// Params are all the parameters of the vindex, including the
+		// ones specific to the backend.
+		Params map[string]string
+	}
+
+	// NewLookupBackendFunc creates a LookupBackend for a lookup vindex.
+	NewLookupBackendFunc func(cfg *LookupBackendConfig) (LookupBackend, error)
+)
+
+var lookupBackends = make(map[string]NewLookupBackendFunc)
+
+// RegisterLookupBackend registers a lookup backend under the name lookup
+// vindexes select it with in their "backend" parameter.
+func RegisterLookupBackend(name string, newBackendFunc NewLookupBackendFunc) {
+	if _, ok := lookupBackends[name]; ok || name == lookupBackendTable {
+		panic(fmt.Sprintf("%s is already registered", name))
+	}
+	lookupBackends[name] = newBackendFunc
+}
+
+func newLookupBackend(name string, cfg *LookupBackendConfig) (LookupBackend, error) {
+	newBackendFunc, ok := lookupBackends[name]
+	if !ok {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "lookup backend not found: %s", name)
+	}
+	return newBackendFunc(cfg)
+}
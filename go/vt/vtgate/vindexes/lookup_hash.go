@@ -93,7 +93,7 @@ func newLookupHash(name string, m map[string]string) (Vindex, error) {
 
 	// if autocommit is on for non-unique lookup, upsert should also be on.
 	upsert := cc.autocommit || cc.multiShardAutocommit
-	if err := lh.lkp.Init(m, cc.autocommit, upsert, cc.multiShardAutocommit); err != nil {
+	if err := lh.lkp.Init(m, cc.autocommit, upsert, cc.multiShardAutocommit, false /* unique */); err != nil {
 		return nil, err
 	}
 	return lh, nil
@@ -302,7 +302,7 @@ func newLookupHashUnique(name string, m map[string]string) (Vindex, error) {
 	}
 
 	// Don't allow upserts for unique vindexes.
-	if err := lhu.lkp.Init(m, cc.autocommit, false /* upsert */, cc.multiShardAutocommit); err != nil {
+	if err := lhu.lkp.Init(m, cc.autocommit, false /* upsert */, cc.multiShardAutocommit, true /* unique */); err != nil {
 		return nil, err
 	}
 	return lhu, nil
@@ -41,6 +41,7 @@ const (
 
 	lookupCommonParamAutocommit           = "autocommit"
 	lookupCommonParamMultiShardAutocommit = "multi_shard_autocommit"
+	lookupCommonParamBackend              = "backend"
 
 	lookupInternalParamTable       = "table"
 	lookupInternalParamFrom        = "from"
@@ -62,6 +63,8 @@ var (
 		append(make([]string, 0), lookupInternalParams...),
 		lookupCommonParamAutocommit,
 		lookupCommonParamMultiShardAutocommit,
+		lookupCommonParamBackend,
+		lookupKVParamAddress,
 	)
 
 	// lookupInternalParams are used by both lookup_* vindexes and the newer
@@ -78,19 +81,20 @@ var (
 
 // lookupInternal implements the functions for the Lookup vindexes.
 type lookupInternal struct {
-	Table                   string   `json:"table"`
-	FromColumns             []string `json:"from_columns"`
-	To                      string   `json:"to"`
-	Autocommit              bool     `json:"autocommit,omitempty"`
-	MultiShardAutocommit    bool     `json:"multi_shard_autocommit,omitempty"`
-	Upsert                  bool     `json:"upsert,omitempty"`
-	IgnoreNulls             bool     `json:"ignore_nulls,omitempty"`
-	BatchLookup             bool     `json:"batch_lookup,omitempty"`
-	ReadLock                string   `json:"read_lock,omitempty"`
-	sel, selTxDml, ver, del string   // sel: map query, ver: verify query, del: delete query
+	Table                string   `json:"table"`
+	FromColumns          []string `json:"from_columns"`
+	To                   string   `json:"to"`
+	Autocommit           bool     `json:"autocommit,omitempty"`
+	MultiShardAutocommit bool     `json:"multi_shard_autocommit,omitempty"`
+	Upsert               bool     `json:"upsert,omitempty"`
+	IgnoreNulls          bool     `json:"ignore_nulls,omitempty"`
+	BatchLookup          bool     `json:"batch_lookup,omitempty"`
+	ReadLock             string   `json:"read_lock,omitempty"`
+	Backend              string   `json:"backend,omitempty"`
+	backend              LookupBackend
 }
 
-func (lkp *lookupInternal) Init(lookupQueryParams map[string]string, autocommit, upsert, multiShardAutocommit bool) error {
+func (lkp *lookupInternal) Init(lookupQueryParams map[string]string, autocommit, upsert, multiShardAutocommit, unique bool) error {
 	lkp.Table = lookupQueryParams[lookupInternalParamTable]
 	lkp.To = lookupQueryParams[lookupInternalParamTo]
 	var fromColumns []string
@@ -122,22 +126,62 @@ func (lkp *lookupInternal) Init(lookupQueryParams map[string]string, autocommit,
 		lkp.MultiShardAutocommit = true
 	}
 
+	if backend := lookupQueryParams[lookupCommonParamBackend]; backend != "" && backend != lookupBackendTable {
+		lkp.Backend = backend
+		lkp.backend, err = newLookupBackend(backend, &LookupBackendConfig{
+			Table:       lkp.Table,
+			FromColumns: lkp.FromColumns,
+			To:          lkp.To,
+			Unique:      unique,
+			Upsert:      lkp.Upsert,
+			Params:      lookupQueryParams,
+		})
+		return err
+	}
+	lkp.backend = newTableLookupBackend(lkp)
+	return nil
+}
+
+var _ LookupBackend = (*tableLookupBackend)(nil)
+
+// tableLookupBackend is the default LookupBackend, which stores the
+// mappings in a MySQL table.
+type tableLookupBackend struct {
+	table                   string
+	fromColumns             []string
+	to                      string
+	upsert                  bool
+	multiShardAutocommit    bool
+	batchLookup             bool
+	sel, selTxDml, ver, del string // sel: map query, ver: verify query, del: delete query
+}
+
+func newTableLookupBackend(lkp *lookupInternal) *tableLookupBackend {
+	tb := &tableLookupBackend{
+		table:                lkp.Table,
+		fromColumns:          lkp.FromColumns,
+		to:                   lkp.To,
+		upsert:               lkp.Upsert,
+		multiShardAutocommit: lkp.MultiShardAutocommit,
+		batchLookup:          lkp.BatchLookup,
+	}
+
 	// TODO @rafael: update sel and ver to support multi column vindexes. This will be done
 	// as part of face 2 of https://github.com/vitessio/vitess/issues/3481
 	// For now multi column behaves as a single column for Map and Verify operations
-	lkp.sel = fmt.Sprintf("select %s, %s from %s where %s in ::%s", lkp.FromColumns[0], lkp.To, lkp.Table, lkp.FromColumns[0], lkp.FromColumns[0])
+	tb.sel = fmt.Sprintf("select %s, %s from %s where %s in ::%s", tb.fromColumns[0], tb.to, tb.table, tb.fromColumns[0], tb.fromColumns[0])
 	if lkp.ReadLock != readLockNone {
 		lockExpr, ok := readLockExprs[lkp.ReadLock]
 		if !ok {
 			lockExpr = readLockExprs[readLockDefault]
 		}
-		lkp.selTxDml = fmt.Sprintf("%s %s", lkp.sel, lockExpr)
+		tb.selTxDml = fmt.Sprintf("%s %s", tb.sel, lockExpr)
 	} else {
-		lkp.selTxDml = lkp.sel
+		tb.selTxDml = tb.sel
 	}
-	lkp.ver = fmt.Sprintf("select %s from %s where %s = :%s and %s = :%s", lkp.FromColumns[0], lkp.Table, lkp.FromColumns[0], lkp.FromColumns[0], lkp.To, lkp.To)
-	lkp.del = lkp.initDelStmt()
-	return nil
+	tb.ver = fmt.Sprintf("select %s from %s where %s = :%s and %s = :%s", tb.fromColumns[0], tb.table, tb.fromColumns[0], tb.fromColumns[0], tb.to, tb.to)
+	tb.del = tb.initDelStmt()
+	return tb
 }
 
 // Lookup performs a lookup for the ids.
@@ -145,24 +189,29 @@ func (lkp *lookupInternal) Lookup(ctx context.Context, vcursor VCursor, ids []sq
 	if vcursor == nil {
 		return nil, fmt.Errorf("cannot perform lookup: no vcursor provided")
 	}
-	results := make([]*sqltypes.Result, 0, len(ids))
 	if lkp.Autocommit {
 		co = vtgatepb.CommitOrder_AUTOCOMMIT
 	}
+	return lkp.backend.Lookup(ctx, vcursor, ids, co)
+}
+
+// Lookup is part of the LookupBackend interface.
+func (tb *tableLookupBackend) Lookup(ctx context.Context, vcursor VCursor, ids []sqltypes.Value, co vtgatepb.CommitOrder) ([]*sqltypes.Result, error) {
+	results := make([]*sqltypes.Result, 0, len(ids))
 	var sel string
 	if vcursor.InTransactionAndIsDML() {
-		sel = lkp.selTxDml
+		sel = tb.selTxDml
 	} else {
-		sel = lkp.sel
+		sel = tb.sel
 	}
-	if ids[0].IsIntegral() || lkp.BatchLookup {
+	if ids[0].IsIntegral() || tb.batchLookup {
 		// for integral types, batch query all ids and then map them back to the input order
 		vars, err := sqltypes.BuildBindVariable(ids)
 		if err != nil {
 			return nil, fmt.Errorf("lookup.Map: %v", err)
 		}
 		bindVars := map[string]*querypb.BindVariable{
-			lkp.FromColumns[0]: vars,
+			tb.fromColumns[0]: vars,
 		}
 		result, err := vcursor.Execute(ctx, "VindexLookup", sel, bindVars, false /* rollbackOnError */, co)
 		if err != nil {
@@ -186,7 +235,7 @@ func (lkp *lookupInternal) Lookup(ctx context.Context, vcursor VCursor, ids []sq
 				return nil, fmt.Errorf("lookup.Map: %v", err)
 			}
 			bindVars := map[string]*querypb.BindVariable{
-				lkp.FromColumns[0]: vars,
+				tb.fromColumns[0]: vars,
 			}
 			var result *sqltypes.Result
 			result, err = vcursor.Execute(ctx, "VindexLookup", sel, bindVars, false /* rollbackOnError */, co)
@@ -215,13 +264,18 @@ func (lkp *lookupInternal) Verify(ctx context.Context, vcursor VCursor, ids, val
 }
 
 func (lkp *lookupInternal) VerifyCustom(ctx context.Context, vcursor VCursor, ids, values []sqltypes.Value, co vtgatepb.CommitOrder) ([]bool, error) {
+	return lkp.backend.Verify(ctx, vcursor, ids, values, co)
+}
+
+// Verify is part of the LookupBackend interface.
+func (tb *tableLookupBackend) Verify(ctx context.Context, vcursor VCursor, ids, values []sqltypes.Value, co vtgatepb.CommitOrder) ([]bool, error) {
 	out := make([]bool, len(ids))
 	for i, id := range ids {
 		bindVars := map[string]*querypb.BindVariable{
-			lkp.FromColumns[0]: sqltypes.ValueBindVariable(id),
-			lkp.To:             sqltypes.ValueBindVariable(values[i]),
+			tb.fromColumns[0]: sqltypes.ValueBindVariable(id),
+			tb.to:             sqltypes.ValueBindVariable(values[i]),
 		}
-		result, err := vcursor.Execute(ctx, "VindexVerify", lkp.ver, bindVars, false /* rollbackOnError */, co)
+		result, err := vcursor.Execute(ctx, "VindexVerify", tb.ver, bindVars, false /* rollbackOnError */, co)
 		if err != nil {
 			return nil, fmt.Errorf("lookup.Verify: %v", err)
 		}
@@ -307,44 +361,48 @@ nextRow:
 		return fmt.Errorf("lookup.Create: column vindex count does not match the columns in the lookup: %d vs %v", len(trimmedRowsCols[0]), lkp.FromColumns)
 	}
 	sort.Sort(&sorter{rowsColValues: trimmedRowsCols, toValues: trimmedToValues})
+	return lkp.backend.Create(ctx, vcursor, trimmedRowsCols, trimmedToValues, ignoreMode, co)
+}
 
+// Create is part of the LookupBackend interface.
+func (tb *tableLookupBackend) Create(ctx context.Context, vcursor VCursor, rowsColValues [][]sqltypes.Value, toValues []sqltypes.Value, ignoreMode bool, co vtgatepb.CommitOrder) error {
 	insStmt := "insert"
-	if lkp.MultiShardAutocommit {
+	if tb.multiShardAutocommit {
 		insStmt = "insert /*vt+ MULTI_SHARD_AUTOCOMMIT=1 */"
 	}
 	buf := new(bytes.Buffer)
 	if ignoreMode {
-		fmt.Fprintf(buf, "%s ignore into %s(", insStmt, lkp.Table)
+		fmt.Fprintf(buf, "%s ignore into %s(", insStmt, tb.table)
 	} else {
-		fmt.Fprintf(buf, "%s into %s(", insStmt, lkp.Table)
+		fmt.Fprintf(buf, "%s into %s(", insStmt, tb.table)
 	}
-	for _, col := range lkp.FromColumns {
+	for _, col := range tb.fromColumns {
 		fmt.Fprintf(buf, "%s, ", col)
 	}
-	fmt.Fprintf(buf, "%s) values(", lkp.To)
+	fmt.Fprintf(buf, "%s) values(", tb.to)
 
-	bindVars := make(map[string]*querypb.BindVariable, 2*len(trimmedRowsCols))
-	for rowIdx := range trimmedToValues {
-		colIds := trimmedRowsCols[rowIdx]
+	bindVars := make(map[string]*querypb.BindVariable, 2*len(rowsColValues))
+	for rowIdx := range toValues {
+		colIds := rowsColValues[rowIdx]
 		if rowIdx != 0 {
 			buf.WriteString(", (")
 		}
 		for colIdx, colID := range colIds {
-			fromStr := lkp.FromColumns[colIdx] + "_" + strconv.Itoa(rowIdx)
+			fromStr := tb.fromColumns[colIdx] + "_" + strconv.Itoa(rowIdx)
 			bindVars[fromStr] = sqltypes.ValueBindVariable(colID)
 			buf.WriteString(":" + fromStr + ", ")
 		}
-		toStr := lkp.To + "_" + strconv.Itoa(rowIdx)
+		toStr := tb.to + "_" + strconv.Itoa(rowIdx)
 		buf.WriteString(":" + toStr + ")")
-		bindVars[toStr] = sqltypes.ValueBindVariable(trimmedToValues[rowIdx])
+		bindVars[toStr] = sqltypes.ValueBindVariable(toValues[rowIdx])
 	}
 
-	if lkp.Upsert {
+	if tb.upsert {
 		fmt.Fprintf(buf, " on duplicate key update ")
-		for _, col := range lkp.FromColumns {
+		for _, col := range tb.fromColumns {
 			fmt.Fprintf(buf, "%s=values(%s), ", col, col)
 		}
-		fmt.Fprintf(buf, "%s=values(%s)", lkp.To, lkp.To)
+		fmt.Fprintf(buf, "%s=values(%s)", tb.to, tb.to)
 	}
 
 	if _, err := vcursor.Execute(ctx, "VindexCreate", buf.String(), bindVars, true /* rollbackOnError */, co); err != nil {
@@ -382,13 +440,18 @@ func (lkp *lookupInternal) Delete(ctx context.Context, vcursor VCursor, rowsColV
 	if len(rowsColValues[0]) != len(lkp.FromColumns) {
 		return fmt.Errorf("lookup.Delete: column vindex count does not match the columns in the lookup: %d vs %v", len(rowsColValues[0]), lkp.FromColumns)
 	}
+	return lkp.backend.Delete(ctx, vcursor, rowsColValues, value, co)
+}
+
+// Delete is part of the LookupBackend interface.
+func (tb *tableLookupBackend) Delete(ctx context.Context, vcursor VCursor, rowsColValues [][]sqltypes.Value, value sqltypes.Value, co vtgatepb.CommitOrder) error {
 	for _, column := range rowsColValues {
 		bindVars := make(map[string]*querypb.BindVariable, len(rowsColValues))
 		for colIdx, columnValue := range column {
-			bindVars[lkp.FromColumns[colIdx]] = sqltypes.ValueBindVariable(columnValue)
+			bindVars[tb.fromColumns[colIdx]] = sqltypes.ValueBindVariable(columnValue)
 		}
-		bindVars[lkp.To] = sqltypes.ValueBindVariable(value)
-		_, err := vcursor.Execute(ctx, "VindexDelete", lkp.del, bindVars, true /* rollbackOnError */, co)
+		bindVars[tb.to] = sqltypes.ValueBindVariable(value)
+		_, err := vcursor.Execute(ctx, "VindexDelete", tb.del, bindVars, true /* rollbackOnError */, co)
 		if err != nil {
 			return fmt.Errorf("lookup.Delete: %v", err)
 		}
@@ -404,21 +467,27 @@ func (lkp *lookupInternal) Update(ctx context.Context, vcursor VCursor, oldValue
 	return lkp.Create(ctx, vcursor, [][]sqltypes.Value{newValues}, []sqltypes.Value{toValue}, false /* ignoreMode */)
 }
 
-func (lkp *lookupInternal) initDelStmt() string {
+func (tb *tableLookupBackend) initDelStmt() string {
 	var delBuffer bytes.Buffer
-	fmt.Fprintf(&delBuffer, "delete from %s where ", lkp.Table)
-	for colIdx, column := range lkp.FromColumns {
+	fmt.Fprintf(&delBuffer, "delete from %s where ", tb.table)
+	for colIdx, column := range tb.fromColumns {
 		if colIdx != 0 {
 			delBuffer.WriteString(" and ")
 		}
 		delBuffer.WriteString(column + " = :" + column)
 	}
-	delBuffer.WriteString(" and " + lkp.To + " = :" + lkp.To)
+	delBuffer.WriteString(" and " + tb.to + " = :" + tb.to)
 	return delBuffer.String()
 }
 
+// query returns the query that maps the ids to their values, or an empty
+// query if the mappings are not stored in a MySQL table.
 func (lkp *lookupInternal) query() (selQuery string, arguments []string) {
-	return lkp.sel, lkp.FromColumns
+	tb, ok := lkp.backend.(*tableLookupBackend)
+	if !ok {
+		return "", nil
+	}
+	return tb.sel, lkp.FromColumns
 }
 
 type commonConfig struct {
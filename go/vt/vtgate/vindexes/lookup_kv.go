@@ -0,0 +1,244 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vindexes
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/grpcclient"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	lookupkvpb "vitess.io/vitess/go/vt/proto/lookupkv"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+const (
+	// lookupBackendKV is the lookup backend that stores the mappings in
+	// a key-value store served over gRPC, see the lookupkv package.
+	lookupBackendKV = "kv"
+
+	lookupKVParamAddress = "kv_address"
+)
+
+var (
+	_ LookupBackend = (*kvLookupBackend)(nil)
+
+	lookupKVClientsMu sync.Mutex
+	lookupKVClients   = make(map[string]lookupkvpb.LookupKVClient)
+)
+
+func init() {
+	RegisterLookupBackend(lookupBackendKV, newKVLookupBackend)
+}
+
+// RegisterLookupKVClient makes the lookup vindexes with the "kv" backend
+// use the client for the address in their "kv_address" parameter,
+// instead of dialing it. This allows serving the mappings from a store
+// in the same process, see lookupkv.NewLocalClient.
+func RegisterLookupKVClient(address string, client lookupkvpb.LookupKVClient) {
+	lookupKVClientsMu.Lock()
+	defer lookupKVClientsMu.Unlock()
+	lookupKVClients[address] = client
+}
+
+// lookupKVClient returns the client for the address, which is shared by
+// all the vindexes that use it.
+func lookupKVClient(address string) (lookupkvpb.LookupKVClient, error) {
+	lookupKVClientsMu.Lock()
+	defer lookupKVClientsMu.Unlock()
+	if client, ok := lookupKVClients[address]; ok {
+		return client, nil
+	}
+	conn, err := grpcclient.Dial(address, grpcclient.FailFast(false), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	client := lookupkvpb.NewLookupKVClient(conn)
+	lookupKVClients[address] = client
+	return client, nil
+}
+
+// kvLookupBackend is a LookupBackend that stores the mappings in a
+// lookupkv store. The store is not transactional: the mappings are
+// created and deleted right away, whatever the commit order, and are
+// not rolled back with the vtgate transaction. Therefore they can only
+// be written by autocommitted statements, and a statement that fails
+// after writing them can leave orphaned mappings behind.
+type kvLookupBackend struct {
+	table   string
+	address string
+	unique  bool
+	upsert  bool
+}
+
+func newKVLookupBackend(cfg *LookupBackendConfig) (LookupBackend, error) {
+	address := cfg.Params[lookupKVParamAddress]
+	if address == "" {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "missing %s for the %s lookup backend", lookupKVParamAddress, lookupBackendKV)
+	}
+	return &kvLookupBackend{
+		table:   cfg.Table,
+		address: address,
+		unique:  cfg.Unique,
+		upsert:  cfg.Upsert,
+	}, nil
+}
+
+// Lookup is part of the LookupBackend interface.
+func (kb *kvLookupBackend) Lookup(ctx context.Context, vcursor VCursor, ids []sqltypes.Value, co vtgatepb.CommitOrder) ([]*sqltypes.Result, error) {
+	values, err := kb.lookup(ctx, ids)
+	if err != nil {
+		return nil, vterrors.Wrap(err, "lookup.Map")
+	}
+	results := make([]*sqltypes.Result, 0, len(ids))
+	for _, vals := range values {
+		rows := make([][]sqltypes.Value, 0, len(vals))
+		for _, val := range vals {
+			rows = append(rows, []sqltypes.Value{val})
+		}
+		results = append(results, &sqltypes.Result{
+			Rows: rows,
+		})
+	}
+	return results, nil
+}
+
+// Verify is part of the LookupBackend interface.
+func (kb *kvLookupBackend) Verify(ctx context.Context, vcursor VCursor, ids, values []sqltypes.Value, co vtgatepb.CommitOrder) ([]bool, error) {
+	mapped, err := kb.lookup(ctx, ids)
+	if err != nil {
+		return nil, vterrors.Wrap(err, "lookup.Verify")
+	}
+	out := make([]bool, len(ids))
+	for i, vals := range mapped {
+		for _, val := range vals {
+			if bytes.Equal(val.Raw(), values[i].Raw()) {
+				out[i] = true
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// Create is part of the LookupBackend interface.
+func (kb *kvLookupBackend) Create(ctx context.Context, vcursor VCursor, rowsColValues [][]sqltypes.Value, toValues []sqltypes.Value, ignoreMode bool, co vtgatepb.CommitOrder) error {
+	if err := kb.checkAutocommit(vcursor); err != nil {
+		return vterrors.Wrap(err, "lookup.Create")
+	}
+	client, err := lookupKVClient(kb.address)
+	if err != nil {
+		return vterrors.Wrap(err, "lookup.Create")
+	}
+	_, err = client.Create(ctx, &lookupkvpb.CreateRequest{
+		Table:          kb.table,
+		Mappings:       kvMappings(rowsColValues, toValues),
+		IgnoreExisting: ignoreMode,
+		Upsert:         kb.upsert,
+		Unique:         kb.unique,
+	})
+	if err != nil {
+		return vterrors.Wrap(vterrors.FromGRPC(err), "lookup.Create")
+	}
+	return nil
+}
+
+// Delete is part of the LookupBackend interface.
+func (kb *kvLookupBackend) Delete(ctx context.Context, vcursor VCursor, rowsColValues [][]sqltypes.Value, value sqltypes.Value, co vtgatepb.CommitOrder) error {
+	if err := kb.checkAutocommit(vcursor); err != nil {
+		return vterrors.Wrap(err, "lookup.Delete")
+	}
+	client, err := lookupKVClient(kb.address)
+	if err != nil {
+		return vterrors.Wrap(err, "lookup.Delete")
+	}
+	toValues := make([]sqltypes.Value, len(rowsColValues))
+	for i := range toValues {
+		toValues[i] = value
+	}
+	_, err = client.Delete(ctx, &lookupkvpb.DeleteRequest{
+		Table:    kb.table,
+		Mappings: kvMappings(rowsColValues, toValues),
+	})
+	if err != nil {
+		return vterrors.Wrap(vterrors.FromGRPC(err), "lookup.Delete")
+	}
+	return nil
+}
+
+// checkAutocommit rejects the writes made in a transaction of the
+// application, which the mappings could not be rolled back with.
+func (kb *kvLookupBackend) checkAutocommit(vcursor VCursor) error {
+	if vcursor.InUserTransaction() {
+		return vterrors.VT12001("writing to lookup vindexes with the kv backend in a transaction, their mappings cannot be rolled back")
+	}
+	return nil
+}
+
+// lookup returns the values mapped to each of the ids.
+func (kb *kvLookupBackend) lookup(ctx context.Context, ids []sqltypes.Value) ([][]sqltypes.Value, error) {
+	client, err := lookupKVClient(kb.address)
+	if err != nil {
+		return nil, err
+	}
+	req := &lookupkvpb.LookupRequest{
+		Table: kb.table,
+		Ids:   make([]*querypb.Value, 0, len(ids)),
+	}
+	for _, id := range ids {
+		req.Ids = append(req.Ids, sqltypes.ValueToProto(id))
+	}
+	resp, err := client.Lookup(ctx, req)
+	if err != nil {
+		return nil, vterrors.FromGRPC(err)
+	}
+	if len(resp.Results) != len(ids) {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "got %d results for %d ids", len(resp.Results), len(ids))
+	}
+	values := make([][]sqltypes.Value, 0, len(ids))
+	for _, result := range resp.Results {
+		vals := make([]sqltypes.Value, 0, len(result.Values))
+		for _, val := range result.Values {
+			vals = append(vals, sqltypes.ProtoToValue(val))
+		}
+		values = append(values, vals)
+	}
+	return values, nil
+}
+
+func kvMappings(rowsColValues [][]sqltypes.Value, toValues []sqltypes.Value) []*lookupkvpb.Mapping {
+	mappings := make([]*lookupkvpb.Mapping, 0, len(rowsColValues))
+	for i, row := range rowsColValues {
+		m := &lookupkvpb.Mapping{
+			From: make([]*querypb.Value, 0, len(row)),
+			To:   sqltypes.ValueToProto(toValues[i]),
+		}
+		for _, col := range row {
+			m.From = append(m.From, sqltypes.ValueToProto(col))
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings
+}
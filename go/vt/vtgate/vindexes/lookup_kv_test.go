@@ -0,0 +1,158 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vindexes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/test/utils"
+	"vitess.io/vitess/go/vt/key"
+	"vitess.io/vitess/go/vt/lookupkv"
+)
+
+func createKVLookup(t *testing.T, vindexType, address string) Vindex {
+	t.Helper()
+	RegisterLookupKVClient(address, lookupkv.NewLocalClient(lookupkv.NewStore()))
+	vindex, err := CreateVindex(vindexType, vindexType, map[string]string{
+		"table":      "t",
+		"from":       "fromc",
+		"to":         "toc",
+		"backend":    "kv",
+		"kv_address": address,
+	})
+	require.NoError(t, err)
+	require.Empty(t, vindex.(ParamValidating).UnknownParams())
+	return vindex
+}
+
+func TestLookupKVNonUnique(t *testing.T) {
+	ctx := context.Background()
+	lnu := createKVLookup(t, "lookup", "non_unique")
+	vc := &vcursor{}
+
+	err := lnu.(Lookup).Create(ctx, vc, [][]sqltypes.Value{{sqltypes.NewInt64(1)}, {sqltypes.NewInt64(1)}, {sqltypes.NewInt64(2)}}, [][]byte{[]byte("test1"), []byte("test2"), []byte("test3")}, false /* ignoreMode */)
+	require.NoError(t, err)
+
+	got, err := lnu.(SingleColumn).Map(ctx, vc, []sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewInt64(2), sqltypes.NewInt64(3)})
+	require.NoError(t, err)
+	want := []key.Destination{
+		key.DestinationKeyspaceIDs([][]byte{[]byte("test1"), []byte("test2")}),
+		key.DestinationKeyspaceIDs([][]byte{[]byte("test3")}),
+		key.DestinationNone{},
+	}
+	utils.MustMatch(t, want, got)
+
+	verified, err := lnu.(SingleColumn).Verify(ctx, vc, []sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewInt64(2)}, [][]byte{[]byte("test2"), []byte("test1")})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, verified)
+
+	// The existing mappings are only skipped in ignore mode.
+	err = lnu.(Lookup).Create(ctx, vc, [][]sqltypes.Value{{sqltypes.NewInt64(2)}}, [][]byte{[]byte("test3")}, false /* ignoreMode */)
+	require.ErrorContains(t, err, "lookup.Create: rpc error: code = AlreadyExists desc = duplicate entry [2] for table t")
+	err = lnu.(Lookup).Create(ctx, vc, [][]sqltypes.Value{{sqltypes.NewInt64(2)}}, [][]byte{[]byte("test3")}, true /* ignoreMode */)
+	require.NoError(t, err)
+
+	err = lnu.(Lookup).Update(ctx, vc, []sqltypes.Value{sqltypes.NewInt64(1)}, []byte("test1"), []sqltypes.Value{sqltypes.NewInt64(3)})
+	require.NoError(t, err)
+	err = lnu.(Lookup).Delete(ctx, vc, [][]sqltypes.Value{{sqltypes.NewInt64(2)}}, []byte("test3"))
+	require.NoError(t, err)
+
+	got, err = lnu.(SingleColumn).Map(ctx, vc, []sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewInt64(2), sqltypes.NewInt64(3)})
+	require.NoError(t, err)
+	want = []key.Destination{
+		key.DestinationKeyspaceIDs([][]byte{[]byte("test2")}),
+		key.DestinationNone{},
+		key.DestinationKeyspaceIDs([][]byte{[]byte("test1")}),
+	}
+	utils.MustMatch(t, want, got)
+
+	// The mappings are never read or written with queries.
+	assert.Empty(t, vc.queries)
+	query, _ := lnu.(LookupPlanable).Query()
+	assert.Empty(t, query)
+}
+
+func TestLookupKVUnique(t *testing.T) {
+	ctx := context.Background()
+	lu := createKVLookup(t, "lookup_unique", "unique")
+	vc := &vcursor{}
+
+	err := lu.(Lookup).Create(ctx, vc, [][]sqltypes.Value{{sqltypes.NewInt64(1)}}, [][]byte{[]byte("test1")}, false /* ignoreMode */)
+	require.NoError(t, err)
+	err = lu.(Lookup).Create(ctx, vc, [][]sqltypes.Value{{sqltypes.NewInt64(1)}}, [][]byte{[]byte("test2")}, false /* ignoreMode */)
+	require.ErrorContains(t, err, "lookup.Create: rpc error: code = AlreadyExists desc = duplicate entry [1] for table t")
+
+	got, err := lu.(SingleColumn).Map(ctx, vc, []sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewInt64(2)})
+	require.NoError(t, err)
+	want := []key.Destination{
+		key.DestinationKeyspaceID([]byte("test1")),
+		key.DestinationNone{},
+	}
+	utils.MustMatch(t, want, got)
+
+	_, err = lu.(*LookupUnique).LookupQuery()
+	require.EqualError(t, err, "the mappings of vindex lookup_unique are not stored in a table")
+}
+
+func TestLookupKVUserTransaction(t *testing.T) {
+	ctx := context.Background()
+	lu := createKVLookup(t, "lookup_unique", "user_transaction")
+	vc := &vcursor{inUserTx: true}
+
+	// The mappings cannot be rolled back with the transaction, so they
+	// cannot be written in it.
+	err := lu.(Lookup).Create(ctx, vc, [][]sqltypes.Value{{sqltypes.NewInt64(1)}}, [][]byte{[]byte("test1")}, false /* ignoreMode */)
+	require.EqualError(t, err, "lookup.Create: VT12001: unsupported: writing to lookup vindexes with the kv backend in a transaction, their mappings cannot be rolled back")
+	err = lu.(Lookup).Delete(ctx, vc, [][]sqltypes.Value{{sqltypes.NewInt64(1)}}, []byte("test1"))
+	require.EqualError(t, err, "lookup.Delete: VT12001: unsupported: writing to lookup vindexes with the kv backend in a transaction, their mappings cannot be rolled back")
+
+	// They can still be read.
+	got, err := lu.(SingleColumn).Map(ctx, vc, []sqltypes.Value{sqltypes.NewInt64(1)})
+	require.NoError(t, err)
+	utils.MustMatch(t, []key.Destination{key.DestinationNone{}}, got)
+}
+
+func TestLookupBackendErrors(t *testing.T) {
+	params := map[string]string{
+		"table":   "t",
+		"from":    "fromc",
+		"to":      "toc",
+		"backend": "unknown",
+	}
+	_, err := CreateVindex("lookup", "lookup", params)
+	require.EqualError(t, err, "lookup backend not found: unknown")
+
+	params["backend"] = "kv"
+	_, err = CreateVindex("lookup", "lookup", params)
+	require.EqualError(t, err, "missing kv_address for the kv lookup backend")
+
+	params["kv_address"] = "localhost:1"
+	_, err = CreateVindex("consistent_lookup", "consistent_lookup", params)
+	require.EqualError(t, err, "consistent lookup vindexes do not support the kv lookup backend")
+
+	// The default backend can be selected explicitly.
+	params["backend"] = "table"
+	vindex, err := CreateVindex("lookup", "lookup", params)
+	require.NoError(t, err)
+	query, _ := vindex.(LookupPlanable).Query()
+	assert.Equal(t, "select fromc, toc from t where fromc in ::fromc", query)
+}
@@ -51,6 +51,7 @@ type vcursor struct {
 	autocommits int
 	pre, post   int
 	keys        []sqltypes.Value
+	inUserTx    bool
 }
 
 func (vc *vcursor) LookupRowLockShardSession() vtgatepb.CommitOrder {
@@ -61,6 +62,10 @@ func (vc *vcursor) InTransactionAndIsDML() bool {
 	return false
 }
 
+func (vc *vcursor) InUserTransaction() bool {
+	return vc.inUserTx
+}
+
 func (vc *vcursor) Execute(ctx context.Context, method string, query string, bindvars map[string]*querypb.BindVariable, rollbackOnError bool, co vtgatepb.CommitOrder) (*sqltypes.Result, error) {
 	switch co {
 	case vtgatepb.CommitOrder_PRE:
@@ -91,7 +91,7 @@ func newLookupUnicodeLooseMD5Hash(name string, m map[string]string) (Vindex, err
 	}
 
 	// if autocommit is on for non-unique lookup, upsert should also be on.
-	if err := lh.lkp.Init(m, cc.autocommit, cc.autocommit || cc.multiShardAutocommit, cc.multiShardAutocommit); err != nil {
+	if err := lh.lkp.Init(m, cc.autocommit, cc.autocommit || cc.multiShardAutocommit, cc.multiShardAutocommit, false /* unique */); err != nil {
 		return nil, err
 	}
 	return lh, nil
@@ -281,7 +281,7 @@ func newLookupUnicodeLooseMD5HashUnique(name string, m map[string]string) (Vinde
 	}
 
 	// Don't allow upserts for unique vindexes.
-	if err := lhu.lkp.Init(m, cc.autocommit, false /* upsert */, cc.multiShardAutocommit); err != nil {
+	if err := lhu.lkp.Init(m, cc.autocommit, false /* upsert */, cc.multiShardAutocommit, true /* unique */); err != nil {
 		return nil, err
 	}
 	return lhu, nil
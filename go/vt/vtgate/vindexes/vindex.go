@@ -42,6 +42,9 @@ type (
 		Execute(ctx context.Context, method string, query string, bindvars map[string]*querypb.BindVariable, rollbackOnError bool, co vtgatepb.CommitOrder) (*sqltypes.Result, error)
 		ExecuteKeyspaceID(ctx context.Context, keyspace string, ksid []byte, query string, bindVars map[string]*querypb.BindVariable, rollbackOnError, autocommit bool) (*sqltypes.Result, error)
 		InTransactionAndIsDML() bool
+		// InUserTransaction returns true if the statement runs in a transaction
+		// the application opened, explicitly or by disabling autocommit.
+		InUserTransaction() bool
 		LookupRowLockShardSession() vtgatepb.CommitOrder
 		ConnCollation() collations.ID
 	}
@@ -155,7 +158,9 @@ type (
 		Update(ctx context.Context, vcursor VCursor, oldValues []sqltypes.Value, ksid []byte, newValues []sqltypes.Value) error
 	}
 
-	// LookupPlanable are for lookup vindexes where we can extract the lookup query at plan time
+	// LookupPlanable are for lookup vindexes where we can extract the lookup query at plan time.
+	// Query returns an empty query when the lookup cannot be made with one, such as when the
+	// mappings are not stored in a MySQL table.
 	LookupPlanable interface {
 		String() string
 		Query() (selQuery string, arguments []string)
@@ -118,6 +118,14 @@ type Table struct {
 
 	ChildForeignKeys  []ChildFKInfo  `json:"child_foreign_keys,omitempty"`
 	ParentForeignKeys []ParentFKInfo `json:"parent_foreign_keys,omitempty"`
+
+	// ResultCacheTTL is the TTL of the results cached by vtgate for the
+	// queries reading this table. Zero means the results are not cached.
+	ResultCacheTTL time.Duration `json:"result_cache_ttl,omitempty"`
+
+	// Statistics are the row estimate and index cardinalities of the table,
+	// as reported by the schema tracker. They are nil when unknown.
+	Statistics *querypb.TableStatistics `json:"statistics,omitempty"`
 }
 
 // GetTableName gets the sqlparser.TableName for the vindex Table.
@@ -144,10 +152,11 @@ type ColumnVindex struct {
 	backfill bool
 }
 
-// TableInfo contains column and foreign key info for a table.
+// TableInfo contains column, foreign key and statistics info for a table.
 type TableInfo struct {
 	Columns     []Column
 	ForeignKeys []*sqlparser.ForeignKeyDefinition
+	Statistics  *querypb.TableStatistics
 }
 
 // IsUnique is used to tell whether the ColumnVindex
@@ -587,6 +596,9 @@ func buildTables(ks *vschemapb.Keyspace, vschema *VSchema, ksvschema *KeyspaceSc
 			}
 			t.Pinned = decoded
 		}
+		if table.ResultCacheTtlMs > 0 {
+			t.ResultCacheTTL = time.Duration(table.ResultCacheTtlMs) * time.Millisecond
+		}
 
 		// If keyspace is sharded, then any table that's not a reference or pinned must have vindexes.
 		if keyspace.Sharded && t.Type != TypeReference && table.Pinned == "" && len(table.ColumnVindexes) == 0 {
@@ -24,6 +24,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -512,6 +513,27 @@ func TestVSchemaPinned(t *testing.T) {
 	assert.Equal(t, "\x80", string(t1.Pinned))
 }
 
+func TestVSchemaResultCacheTTL(t *testing.T) {
+	good := vschemapb.SrvVSchema{
+		Keyspaces: map[string]*vschemapb.Keyspace{
+			"unsharded": {
+				Tables: map[string]*vschemapb.Table{
+					"t1": {
+						ResultCacheTtlMs: 1500},
+					"t2": {}}}}}
+
+	got := BuildVSchema(&good)
+	require.NoError(t, got.Keyspaces["unsharded"].Error)
+
+	t1, err := got.FindTable("unsharded", "t1")
+	require.NoError(t, err)
+	assert.Equal(t, 1500*time.Millisecond, t1.ResultCacheTTL)
+
+	t2, err := got.FindTable("unsharded", "t2")
+	require.NoError(t, err)
+	assert.Zero(t, t2.ResultCacheTTL)
+}
+
 func TestShardedVSchemaOwned(t *testing.T) {
 	good := vschemapb.SrvVSchema{
 		Keyspaces: map[string]*vschemapb.Keyspace{
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"vitess.io/vitess/go/acl"
+	"vitess.io/vitess/go/json2"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/servenv"
+	"vitess.io/vitess/go/vt/vtgate/debezium"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+)
+
+const (
+	// DebeziumVStreamPath is the path of the endpoint streaming Debezium change events.
+	DebeziumVStreamPath = "/vstream/debezium"
+
+	defaultDebeziumTopicPrefix = "vitess"
+
+	ndjsonContentType = "application/x-ndjson"
+)
+
+// debeziumVStreamHandler serves a VStream as Debezium change events. The request
+// body is a vtgate.VStreamRequest in JSON, and the response is a stream of
+// records, one JSON object per line. Each record carries the VGTID to resume
+// the stream from in its source block.
+type debeziumVStreamHandler struct {
+	vstream vstreamFunc
+	version string
+}
+
+func (vtg *VTGate) registerDebeziumVStreamHandler() {
+	servenv.HTTPHandle(DebeziumVStreamPath, &debeziumVStreamHandler{
+		vstream: vtg.VStream,
+		version: servenv.AppVersion.ToStringMap()["version"],
+	})
+}
+
+func (h *debeziumVStreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := acl.CheckAccessHTTP(r, acl.ADMIN); err != nil {
+		acl.SendError(w, err)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "the VStream request must be POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &vtgatepb.VStreamRequest{}
+	if err := json2.Unmarshal(body, req); err != nil {
+		http.Error(w, "cannot parse the VStream request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	topicPrefix := r.URL.Query().Get("topic_prefix")
+	if topicPrefix == "" {
+		topicPrefix = defaultDebeziumTopicPrefix
+	}
+	converter := debezium.NewConverter(topicPrefix, h.version)
+	if err := converter.SetVgtid(req.Vgtid); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", ndjsonContentType)
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	sent := false
+	err = h.vstream(r.Context(), req.TabletType, req.Vgtid, req.Filter, req.Flags, func(events []*binlogdatapb.VEvent) error {
+		records, err := converter.Convert(events)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := enc.Encode(record); err != nil {
+				return err
+			}
+			sent = true
+		}
+		if flusher != nil && len(records) > 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err == nil || r.Context().Err() != nil {
+		return
+	}
+	log.Errorf("Debezium VStream ended: %v", err)
+	if !sent {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// The stream has started, so the error is reported as the last line.
+	enc.Encode(map[string]string{"error": err.Error()})
+}
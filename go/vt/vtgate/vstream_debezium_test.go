@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/test/utils"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+)
+
+func TestDebeziumVStreamHandler(t *testing.T) {
+	wantVgtid := &binlogdatapb.VGtid{ShardGtids: []*binlogdatapb.ShardGtid{{
+		Keyspace: "ks",
+		Shard:    "-80",
+		Gtid:     "current",
+	}}}
+	var gotTabletType topodatapb.TabletType
+	var gotVgtid *binlogdatapb.VGtid
+	h := &debeziumVStreamHandler{
+		version: "test",
+		vstream: func(ctx context.Context, tabletType topodatapb.TabletType, vgtid *binlogdatapb.VGtid, filter *binlogdatapb.Filter, flags *vtgatepb.VStreamFlags, send func(events []*binlogdatapb.VEvent) error) error {
+			gotTabletType = tabletType
+			gotVgtid = vgtid
+			fields := []*querypb.Field{{Name: "id", Type: sqltypes.Int64, Flags: uint32(querypb.MySqlFlag_PRI_KEY_FLAG)}}
+			err := send([]*binlogdatapb.VEvent{
+				{Type: binlogdatapb.VEventType_FIELD, Keyspace: "ks", Shard: "-80", FieldEvent: &binlogdatapb.FieldEvent{TableName: "ks.t1", Fields: fields}},
+				{Type: binlogdatapb.VEventType_BEGIN, Keyspace: "ks", Shard: "-80"},
+				{Type: binlogdatapb.VEventType_ROW, Keyspace: "ks", Shard: "-80", RowEvent: &binlogdatapb.RowEvent{
+					TableName:  "ks.t1",
+					RowChanges: []*binlogdatapb.RowChange{{After: sqltypes.RowToProto3([]sqltypes.Value{sqltypes.NewInt64(1)})}},
+				}},
+				{Type: binlogdatapb.VEventType_VGTID, Keyspace: "ks", Shard: "-80", Vgtid: wantVgtid},
+				{Type: binlogdatapb.VEventType_COMMIT, Keyspace: "ks", Shard: "-80"},
+			})
+			if err != nil {
+				return err
+			}
+			return errors.New("stream broken")
+		},
+	}
+
+	body := `{"tablet_type": "REPLICA", "vgtid": {"shard_gtids": [{"keyspace": "ks", "shard": "-80", "gtid": "start"}]}}`
+	req := httptest.NewRequest(http.MethodPost, DebeziumVStreamPath+"?topic_prefix=cdc", strings.NewReader(body))
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, ndjsonContentType, resp.Header().Get("Content-Type"))
+	assert.Equal(t, topodatapb.TabletType_REPLICA, gotTabletType)
+	utils.MustMatch(t, &binlogdatapb.VGtid{ShardGtids: []*binlogdatapb.ShardGtid{{Keyspace: "ks", Shard: "-80", Gtid: "start"}}}, gotVgtid)
+
+	lines := strings.Split(strings.TrimSpace(resp.Body.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var record struct {
+		Topic string
+		Key   map[string]any
+		Value struct {
+			After  map[string]any
+			Op     string
+			Source map[string]any
+		}
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "cdc.ks.t1", record.Topic)
+	assert.Equal(t, map[string]any{"id": float64(1)}, record.Key)
+	assert.Equal(t, "c", record.Value.Op)
+	assert.Equal(t, "test", record.Value.Source["version"])
+	assert.Equal(t, `{"shardGtids":[{"keyspace":"ks","shard":"-80","gtid":"current"}]}`, record.Value.Source["vgtid"])
+
+	// the error that ends a stream that has started is the last line
+	assert.Equal(t, `{"error":"stream broken"}`, lines[1])
+}
+
+func TestDebeziumVStreamHandlerErrors(t *testing.T) {
+	h := &debeziumVStreamHandler{
+		vstream: func(ctx context.Context, tabletType topodatapb.TabletType, vgtid *binlogdatapb.VGtid, filter *binlogdatapb.Filter, flags *vtgatepb.VStreamFlags, send func(events []*binlogdatapb.VEvent) error) error {
+			return errors.New("no keyspace")
+		},
+	}
+
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, DebeziumVStreamPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, DebeziumVStreamPath, strings.NewReader("{bad")))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "cannot parse the VStream request")
+
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, DebeziumVStreamPath, strings.NewReader("{}")))
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Equal(t, "no keyspace\n", resp.Body.String())
+}
@@ -78,6 +78,10 @@ type vstream struct {
 	resolver   *srvtopo.Resolver
 	optCells   string
 
+	// rowImages are the images of the rows requested for the tables, by table name.
+	// The names may be qualified with their keyspace.
+	rowImages map[string]binlogdatapb.RowImage
+
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
 
@@ -145,6 +149,12 @@ func (vsm *vstreamManager) VStream(ctx context.Context, tabletType topodatapb.Ta
 	if err != nil {
 		return err
 	}
+	if flags.Subscription != "" {
+		vgtid, err = vsm.resumeSubscription(ctx, flags.Subscription, vgtid)
+		if err != nil {
+			return err
+		}
+	}
 	ts, err := vsm.toposerv.GetTopoServer()
 	if err != nil {
 		return err
@@ -158,6 +168,7 @@ func (vsm *vstreamManager) VStream(ctx context.Context, tabletType topodatapb.Ta
 		tabletType:         tabletType,
 		optCells:           flags.Cells,
 		filter:             filter,
+		rowImages:          flags.GetRowImages(),
 		send:               send,
 		resolver:           vsm.resolver,
 		journaler:          make(map[int64]*journalEvent),
@@ -468,6 +479,33 @@ func (vs *vstream) getCells() []string {
 	return cells
 }
 
+// keyspaceFilter returns the filter to stream a keyspace with. It carries the
+// images of the rows requested for the tables of the keyspace: a table name
+// qualified with the keyspace takes precedence over the bare table name.
+func (vs *vstream) keyspaceFilter(keyspace string) *binlogdatapb.Filter {
+	if len(vs.rowImages) == 0 {
+		return vs.filter
+	}
+	rowImages := make(map[string]binlogdatapb.RowImage)
+	for name, image := range vs.rowImages {
+		if !strings.Contains(name, ".") {
+			if _, ok := rowImages[name]; !ok {
+				rowImages[name] = image
+			}
+			continue
+		}
+		if ks, table, _ := strings.Cut(name, "."); ks == keyspace {
+			rowImages[table] = image
+		}
+	}
+	if len(rowImages) == 0 {
+		return vs.filter
+	}
+	filter := vs.filter.CloneVT()
+	filter.RowImages = rowImages
+	return filter
+}
+
 // streamFromTablet streams from one shard. If transactions come in separate chunks, they are grouped and sent.
 func (vs *vstream) streamFromTablet(ctx context.Context, sgtid *binlogdatapb.ShardGtid) error {
 	// journalDone is assigned a channel when a journal event is encountered.
@@ -542,7 +580,7 @@ func (vs *vstream) streamFromTablet(ctx context.Context, sgtid *binlogdatapb.Sha
 		req := &binlogdatapb.VStreamRequest{
 			Target:       target,
 			Position:     sgtid.Gtid,
-			Filter:       vs.filter,
+			Filter:       vs.keyspaceFilter(sgtid.Keyspace),
 			TableLastPKs: sgtid.TablePKs,
 		}
 		var vstreamCreatedOnce sync.Once
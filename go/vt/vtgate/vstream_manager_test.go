@@ -1135,6 +1135,38 @@ func TestResolveVStreamParams(t *testing.T) {
 
 }
 
+func TestVStreamKeyspaceFilter(t *testing.T) {
+	filter := &binlogdatapb.Filter{Rules: []*binlogdatapb.Rule{{Match: "/.*"}}}
+
+	vs := &vstream{filter: filter}
+	assert.Same(t, filter, vs.keyspaceFilter("ks1"))
+
+	vs.rowImages = map[string]binlogdatapb.RowImage{
+		"t1":     binlogdatapb.RowImage_FULL,
+		"t2":     binlogdatapb.RowImage_MINIMAL,
+		"ks1.t2": binlogdatapb.RowImage_NOBLOB,
+		"ks1.t3": binlogdatapb.RowImage_FULL,
+	}
+	got := vs.keyspaceFilter("ks1")
+	utils.MustMatch(t, &binlogdatapb.Filter{
+		Rules: []*binlogdatapb.Rule{{Match: "/.*"}},
+		RowImages: map[string]binlogdatapb.RowImage{
+			"t1": binlogdatapb.RowImage_FULL,
+			"t2": binlogdatapb.RowImage_NOBLOB,
+			"t3": binlogdatapb.RowImage_FULL,
+		},
+	}, got)
+	utils.MustMatch(t, map[string]binlogdatapb.RowImage{
+		"t1": binlogdatapb.RowImage_FULL,
+		"t2": binlogdatapb.RowImage_MINIMAL,
+	}, vs.keyspaceFilter("ks2").RowImages)
+	// the filter of the request is left as is
+	assert.Nil(t, filter.RowImages)
+
+	vs.rowImages = map[string]binlogdatapb.RowImage{"ks1.t1": binlogdatapb.RowImage_FULL}
+	assert.Same(t, filter, vs.keyspaceFilter("ks2"))
+}
+
 func TestVStreamIdleHeartbeat(t *testing.T) {
 	ctx := utils.LeakCheckContext(t)
 
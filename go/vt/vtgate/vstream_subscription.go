@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/protobuf/encoding/prototext"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/sidecardb"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// The positions acknowledged for the durable VStream subscriptions are stored
+// in the sidecar database of the primary of each shard, one row per subscription.
+const (
+	sqlReadVStreamSubscription = "select shard_gtid from %s.vstream_subscriptions where name = %a"
+	sqlAckVStreamSubscription  = "insert into %s.vstream_subscriptions(name, pos, shard_gtid, time_updated) values (%a, %a, %a, %a) " +
+		"on duplicate key update pos = values(pos), shard_gtid = values(shard_gtid), time_updated = values(time_updated)"
+)
+
+// resumeSubscription returns vgtid with the positions acknowledged for the
+// subscription in place of the requested ones, for the shards that have some.
+func (vsm *vstreamManager) resumeSubscription(ctx context.Context, subscription string, vgtid *binlogdatapb.VGtid) (*binlogdatapb.VGtid, error) {
+	resumed := &binlogdatapb.VGtid{}
+	for _, sgtid := range vgtid.ShardGtids {
+		acked, err := vsm.readSubscription(ctx, subscription, sgtid.Keyspace, sgtid.Shard)
+		if err != nil {
+			return nil, err
+		}
+		if acked == nil {
+			resumed.ShardGtids = append(resumed.ShardGtids, sgtid)
+			continue
+		}
+		resumed.ShardGtids = append(resumed.ShardGtids, &binlogdatapb.ShardGtid{
+			Keyspace: sgtid.Keyspace,
+			Shard:    sgtid.Shard,
+			Gtid:     acked.Gtid,
+			TablePKs: acked.TablePKs,
+		})
+	}
+	return resumed, nil
+}
+
+// readSubscription returns the position acknowledged for the subscription in
+// a shard, or nil if there is none.
+func (vsm *vstreamManager) readSubscription(ctx context.Context, subscription, keyspace, shard string) (*binlogdatapb.ShardGtid, error) {
+	sidecarDB, err := sidecardb.GetIdentifierForKeyspace(keyspace)
+	if err != nil {
+		return nil, err
+	}
+	query := sqlparser.BuildParsedQuery(sqlReadVStreamSubscription, sidecarDB, ":name").Query
+	qr, err := vsm.resolver.GetGateway().Execute(ctx, primaryTarget(keyspace, shard), query, map[string]*querypb.BindVariable{
+		"name": sqltypes.StringBindVariable(subscription),
+	}, 0, 0, nil)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot read the position of subscription %s in %s/%s", subscription, keyspace, shard)
+	}
+	if len(qr.Rows) == 0 {
+		return nil, nil
+	}
+	acked := &binlogdatapb.ShardGtid{}
+	if err := prototext.Unmarshal(qr.Rows[0][0].Raw(), acked); err != nil {
+		return nil, vterrors.Wrapf(err, "cannot parse the position of subscription %s in %s/%s", subscription, keyspace, shard)
+	}
+	return acked, nil
+}
+
+// ackSubscription stores the positions of vgtid as the positions the
+// subscription has processed the events up to.
+func (vsm *vstreamManager) ackSubscription(ctx context.Context, subscription string, vgtid *binlogdatapb.VGtid) error {
+	if subscription == "" {
+		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "the subscription name is required")
+	}
+	if len(vgtid.GetShardGtids()) == 0 {
+		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "vgtid must have at least one position to acknowledge")
+	}
+	for _, sgtid := range vgtid.ShardGtids {
+		if sgtid.Keyspace == "" || sgtid.Shard == "" || sgtid.Gtid == "" || sgtid.Gtid == "current" {
+			return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "cannot acknowledge a position without a keyspace, a shard and a gtid: %v", sgtid)
+		}
+	}
+	now := time.Now().Unix()
+	for _, sgtid := range vgtid.ShardGtids {
+		sidecarDB, err := sidecardb.GetIdentifierForKeyspace(sgtid.Keyspace)
+		if err != nil {
+			return err
+		}
+		state, err := prototext.Marshal(sgtid)
+		if err != nil {
+			return err
+		}
+		query := sqlparser.BuildParsedQuery(sqlAckVStreamSubscription, sidecarDB, ":name", ":pos", ":shard_gtid", ":time_updated").Query
+		_, err = vsm.resolver.GetGateway().Execute(ctx, primaryTarget(sgtid.Keyspace, sgtid.Shard), query, map[string]*querypb.BindVariable{
+			"name":         sqltypes.StringBindVariable(subscription),
+			"pos":          sqltypes.StringBindVariable(sgtid.Gtid),
+			"shard_gtid":   sqltypes.BytesBindVariable(state),
+			"time_updated": sqltypes.Int64BindVariable(now),
+		}, 0, 0, nil)
+		if err != nil {
+			return vterrors.Wrapf(err, "cannot acknowledge the position of subscription %s in %s/%s", subscription, sgtid.Keyspace, sgtid.Shard)
+		}
+	}
+	return nil
+}
+
+func primaryTarget(keyspace, shard string) *querypb.Target {
+	return &querypb.Target{
+		Keyspace:   keyspace,
+		Shard:      shard,
+		TabletType: topodatapb.TabletType_PRIMARY,
+	}
+}
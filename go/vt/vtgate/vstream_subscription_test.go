@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/prototext"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/test/utils"
+	"vitess.io/vitess/go/vt/discovery"
+	"vitess.io/vitess/go/vt/sidecardb"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vttablet/sandboxconn"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+func newTestSubscriptionManager(t *testing.T, ctx context.Context, ks string) (*vstreamManager, *sandboxconn.SandboxConn, *sandboxconn.SandboxConn) {
+	t.Helper()
+	if sdbc, _ := sidecardb.GetIdentifierCache(); sdbc != nil {
+		sdbc.Destroy()
+	}
+	_, created := sidecardb.NewIdentifierCache(func(ctx context.Context, keyspace string) (string, error) {
+		return "_vt", nil
+	})
+	require.True(t, created)
+
+	cell := "aa"
+	_ = createSandbox(ks)
+	hc := discovery.NewFakeHealthCheck(nil)
+	st := getSandboxTopo(ctx, cell, ks, []string{"-20", "20-40"})
+	vsm := newTestVStreamManager(ctx, hc, st, cell)
+	sbc0 := hc.AddTestTablet(cell, "1.1.1.1", 1001, ks, "-20", topodatapb.TabletType_PRIMARY, true, 1, nil)
+	sbc1 := hc.AddTestTablet(cell, "1.1.1.1", 1002, ks, "20-40", topodatapb.TabletType_PRIMARY, true, 1, nil)
+	return vsm, sbc0, sbc1
+}
+
+func TestVStreamAckSubscription(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ks := "TestVStreamAck"
+	vsm, sbc0, sbc1 := newTestSubscriptionManager(t, ctx, ks)
+
+	sgtid := &binlogdatapb.ShardGtid{Keyspace: ks, Shard: "-20", Gtid: "MySQL56/89ab1ee5-1be6-11ee-9a4b-0242ac110002:1-10"}
+	err := vsm.ackSubscription(ctx, "orders", &binlogdatapb.VGtid{ShardGtids: []*binlogdatapb.ShardGtid{sgtid}})
+	require.NoError(t, err)
+
+	require.Len(t, sbc0.Queries, 1)
+	assert.Empty(t, sbc1.Queries)
+	query := sbc0.Queries[0]
+	assert.Equal(t, "insert into _vt.vstream_subscriptions(name, pos, shard_gtid, time_updated) values (:name, :pos, :shard_gtid, :time_updated) "+
+		"on duplicate key update pos = values(pos), shard_gtid = values(shard_gtid), time_updated = values(time_updated)", query.Sql)
+	assert.Equal(t, "orders", string(query.BindVariables["name"].Value))
+	assert.Equal(t, sgtid.Gtid, string(query.BindVariables["pos"].Value))
+	stored := &binlogdatapb.ShardGtid{}
+	require.NoError(t, prototext.Unmarshal(query.BindVariables["shard_gtid"].Value, stored))
+	utils.MustMatch(t, sgtid, stored)
+}
+
+func TestVStreamAckSubscriptionErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ks := "TestVStreamAckErrors"
+	vsm, sbc0, _ := newTestSubscriptionManager(t, ctx, ks)
+
+	testcases := []struct {
+		name         string
+		subscription string
+		vgtid        *binlogdatapb.VGtid
+		wantErr      string
+	}{{
+		name:    "no name",
+		vgtid:   &binlogdatapb.VGtid{ShardGtids: []*binlogdatapb.ShardGtid{{Keyspace: ks, Shard: "-20", Gtid: "pos"}}},
+		wantErr: "the subscription name is required",
+	}, {
+		name:         "no position",
+		subscription: "orders",
+		vgtid:        &binlogdatapb.VGtid{},
+		wantErr:      "vgtid must have at least one position to acknowledge",
+	}, {
+		name:         "current position",
+		subscription: "orders",
+		vgtid:        &binlogdatapb.VGtid{ShardGtids: []*binlogdatapb.ShardGtid{{Keyspace: ks, Shard: "-20", Gtid: "current"}}},
+		wantErr:      "cannot acknowledge a position without a keyspace, a shard and a gtid",
+	}, {
+		name:         "no shard",
+		subscription: "orders",
+		vgtid:        &binlogdatapb.VGtid{ShardGtids: []*binlogdatapb.ShardGtid{{Keyspace: ks, Gtid: "pos"}}},
+		wantErr:      "cannot acknowledge a position without a keyspace, a shard and a gtid",
+	}}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := vsm.ackSubscription(ctx, tc.subscription, tc.vgtid)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+			assert.Equal(t, vtrpcpb.Code_INVALID_ARGUMENT, vterrors.Code(err))
+		})
+	}
+	assert.Empty(t, sbc0.Queries)
+}
+
+func TestVStreamResumeSubscription(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ks := "TestVStreamResume"
+	vsm, sbc0, sbc1 := newTestSubscriptionManager(t, ctx, ks)
+
+	acked := &binlogdatapb.ShardGtid{
+		Keyspace: ks,
+		Shard:    "-20",
+		Gtid:     "acked",
+		TablePKs: []*binlogdatapb.TableLastPK{{TableName: "t1"}},
+	}
+	state, err := prototext.Marshal(acked)
+	require.NoError(t, err)
+	sbc0.SetResults([]*sqltypes.Result{{
+		Fields: []*querypb.Field{{Name: "shard_gtid", Type: sqltypes.Blob}},
+		Rows:   [][]sqltypes.Value{{sqltypes.MakeTrusted(sqltypes.Blob, state)}},
+	}})
+	sbc1.SetResults([]*sqltypes.Result{{}})
+
+	vgtid := &binlogdatapb.VGtid{
+		ShardGtids: []*binlogdatapb.ShardGtid{{
+			Keyspace: ks,
+			Shard:    "-20",
+			Gtid:     "current",
+		}, {
+			Keyspace: ks,
+			Shard:    "20-40",
+			Gtid:     "current",
+		}},
+	}
+	got, err := vsm.resumeSubscription(ctx, "orders", vgtid)
+	require.NoError(t, err)
+	utils.MustMatch(t, &binlogdatapb.VGtid{
+		ShardGtids: []*binlogdatapb.ShardGtid{acked, {
+			Keyspace: ks,
+			Shard:    "20-40",
+			Gtid:     "current",
+		}},
+	}, got)
+
+	require.Len(t, sbc0.Queries, 1)
+	assert.Equal(t, "select shard_gtid from _vt.vstream_subscriptions where name = :name", sbc0.Queries[0].Sql)
+	assert.Equal(t, "orders", string(sbc0.Queries[0].BindVariables["name"].Value))
+}
@@ -71,6 +71,17 @@ var (
 	maxPayloadSize  int
 	warnPayloadSize int
 
+	// spill related flags
+	spillMemoryBudget int64
+	spillDir          string
+
+	// query memory related flags
+	queryMemoryLimit       int64
+	globalQueryMemoryLimit int64
+
+	// enableDebeziumVStream serves the VStream API as Debezium change events over HTTP
+	enableDebeziumVStream bool
+
 	noScatter          bool
 	enableShardRouting bool
 
@@ -124,7 +135,13 @@ func registerFlags(fs *pflag.FlagSet) {
 	fs.IntVar(&truncateErrorLen, "truncate-error-len", truncateErrorLen, "truncate errors sent to client if they are longer than this value (0 means do not truncate)")
 	fs.IntVar(&streamBufferSize, "stream_buffer_size", streamBufferSize, "the number of bytes sent from vtgate for each stream call. It's recommended to keep this value in sync with vttablet's query-server-config-stream-buffer-size.")
 	fs.Int64Var(&queryPlanCacheMemory, "gate_query_cache_memory", queryPlanCacheMemory, "gate server query cache size in bytes, maximum amount of memory to be cached. vtgate analyzes every incoming query and generate a query plan, these plans are being cached in a lru cache. This config controls the capacity of the lru cache.")
+	fs.Int64Var(&resultCacheMemory, "result-cache-memory", resultCacheMemory, "vtgate result cache size in bytes. The results of the SELECT queries reading tables with a result_cache_ttl_ms in the VSchema, or using the RESULT_CACHE_TTL_MS query comment directive, are cached up to this amount of memory. The result cache is disabled when zero.")
 	fs.IntVar(&maxMemoryRows, "max_memory_rows", maxMemoryRows, "Maximum number of rows that will be held in memory for intermediate results as well as the final result.")
+	fs.Int64Var(&spillMemoryBudget, "spill-memory-budget", spillMemoryBudget, "Maximum number of bytes of rows that a sort, distinct or hash join of a streaming query can hold in vtgate memory before spilling them to temporary files. Spilling is disabled when zero.")
+	fs.BoolVar(&enableDebeziumVStream, "vstream-debezium-endpoint", enableDebeziumVStream, "If set, vtgate streams VStream changes as Debezium-style JSON change events over HTTP at "+DebeziumVStreamPath+".")
+	fs.StringVar(&spillDir, "spill-dir", spillDir, "Directory in which vtgate creates the temporary files of the queries that go over the spill-memory-budget. Defaults to the temporary directory of the OS.")
+	fs.Int64Var(&queryMemoryLimit, "query-memory-limit", queryMemoryLimit, "Maximum number of bytes of results and intermediate rows a single query can hold in vtgate memory. A query going over this limit fails. Unlimited when zero.")
+	fs.Int64Var(&globalQueryMemoryLimit, "global-query-memory-limit", globalQueryMemoryLimit, "Maximum number of bytes of results and intermediate rows all the queries can hold in vtgate memory. Going over this limit kills the query holding the most memory. Unlimited when zero.")
 	fs.IntVar(&warnMemoryRows, "warn_memory_rows", warnMemoryRows, "Warning threshold for in-memory results. A row count higher than this amount will cause the VtGateWarnings.ResultsExceeded counter to be incremented.")
 	fs.StringVar(&defaultDDLStrategy, "ddl_strategy", defaultDDLStrategy, "Set default strategy for DDL statements. Override with @@ddl_strategy session variable")
 	fs.StringVar(&dbDDLPlugin, "dbddl_plugin", dbDDLPlugin, "controls how to handle CREATE/DROP DATABASE. use it if you are using your own database provisioning service")
@@ -214,6 +231,16 @@ var (
 		"VtgateApiRowsAffected",
 		"Rows affected by a write (DML) operation through the VTgate API",
 		[]string{"Operation", "Keyspace", "DbType"})
+
+	spillCount = stats.NewCountersWithSingleLabel(
+		"VtgateSpills",
+		"Number of times a vtgate operator spilled rows to disk",
+		"Operator")
+
+	spilledBytes = stats.NewCountersWithSingleLabel(
+		"VtgateSpilledBytes",
+		"Bytes of rows spilled to disk by vtgate operators",
+		"Operator")
 )
 
 // VTGate is the rpc interface to vtgate. Only one instance
@@ -329,6 +356,22 @@ func Init(
 		warmingReadsPercent,
 	)
 
+	if resultCacheMemory > 0 {
+		executor.resultCache = newResultCache(resultCacheMemory, vsm.VStream)
+		stats.NewGaugeFunc("QueryResultCacheLength", "Query result cache length", func() int64 {
+			return int64(executor.resultCache.cache.Len())
+		})
+		stats.NewGaugeFunc("QueryResultCacheSize", "Query result cache size", func() int64 {
+			return int64(executor.resultCache.cache.UsedCapacity())
+		})
+		stats.NewGaugeFunc("QueryResultCacheCapacity", "Query result cache capacity", func() int64 {
+			return int64(executor.resultCache.cache.MaxCapacity())
+		})
+		stats.NewCounterFunc("QueryResultCacheEvictions", "Query result cache evictions", func() int64 {
+			return executor.resultCache.cache.Metrics.Evicted()
+		})
+	}
+
 	if err := executor.defaultQueryLogger(); err != nil {
 		log.Fatalf("error initializing query logger: %v", err)
 	}
@@ -340,6 +383,10 @@ func Init(
 
 	// TODO: call serv.WatchSrvVSchema here
 
+	// The transaction resolver drives the distributed transactions left
+	// unresolved by failures to completion.
+	txResolver := newTxResolver(gw.hc.Subscribe(), tc)
+
 	vtgateInst := newVTGate(executor, resolver, vsm, tc, gw)
 	_ = stats.NewRates("QPSByOperation", stats.CounterForDimension(vtgateInst.timings, "Operation"), 15, 1*time.Minute)
 	_ = stats.NewRates("QPSByKeyspace", stats.CounterForDimension(vtgateInst.timings, "Keyspace"), 15, 1*time.Minute)
@@ -357,6 +404,7 @@ func Init(
 		if st != nil && enableSchemaChangeSignal {
 			st.Start()
 		}
+		txResolver.Start()
 		srv := initMySQLProtocol(vtgateInst)
 		servenv.OnTermSync(srv.shutdownMysqlProtocolAndDrain)
 		servenv.OnClose(srv.rollbackAtShutdown)
@@ -365,9 +413,16 @@ func Init(
 		if st != nil && enableSchemaChangeSignal {
 			st.Stop()
 		}
+		txResolver.Stop()
+		if executor.resultCache != nil {
+			executor.resultCache.Close()
+		}
 	})
 	vtgateInst.registerDebugHealthHandler()
 	vtgateInst.registerDebugEnvHandler()
+	if enableDebeziumVStream {
+		vtgateInst.registerDebeziumVStreamHandler()
+	}
 
 	initAPI(gw.hc)
 	return vtgateInst
@@ -583,6 +638,11 @@ func (vtg *VTGate) VStream(ctx context.Context, tabletType topodatapb.TabletType
 	return vtg.vsm.VStream(ctx, tabletType, vgtid, filter, flags, send)
 }
 
+// VStreamAck stores the position a durable VStream subscription has processed the events up to.
+func (vtg *VTGate) VStreamAck(ctx context.Context, subscription string, vgtid *binlogdatapb.VGtid) error {
+	return formatError(vtg.vsm.ackSubscription(ctx, subscription, vgtid))
+}
+
 // GetGatewayCacheStatus returns a displayable version of the Gateway cache.
 func (vtg *VTGate) GetGatewayCacheStatus() TabletCacheStatusList {
 	return vtg.gw.CacheStatus()
@@ -593,6 +653,11 @@ func (vtg *VTGate) VSchemaStats() *VSchemaStats {
 	return vtg.executor.VSchemaStats()
 }
 
+// QueryMemoryStats returns the memory held by the running queries, for the status page.
+func (vtg *VTGate) QueryMemoryStats() *QueryMemoryStats {
+	return vtg.executor.memory.stats()
+}
+
 func truncateErrorStrings(data map[string]any) map[string]any {
 	ret := map[string]any{}
 	if terseErrors {
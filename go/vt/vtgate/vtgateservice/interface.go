@@ -20,6 +20,7 @@ package vtgateservice
 
 import (
 	"context"
+	"time"
 
 	"vitess.io/vitess/go/sqltypes"
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
@@ -47,6 +48,7 @@ type VTGateService interface {
 
 	// Update Stream methods
 	VStream(ctx context.Context, tabletType topodatapb.TabletType, vgtid *binlogdatapb.VGtid, filter *binlogdatapb.Filter, flags *vtgatepb.VStreamFlags, send func([]*binlogdatapb.VEvent) error) error
+	VStreamAck(ctx context.Context, subscription string, vgtid *binlogdatapb.VGtid) error
 
 	// HandlePanic should be called with defer at the beginning of each
 	// RPC implementation method, before calling any of the previous methods
@@ -60,4 +62,23 @@ type MySQLConnection interface {
 	KillQuery(uint32) error
 	// KillConnection closes the connection and also stops any executing query on it.
 	KillConnection(context.Context, uint32) error
+	// SessionUUID returns the UUID of the vtgate session of the connection.
+	SessionUUID(uint32) (string, error)
+	// ProcessList returns the open connections and the command they execute.
+	ProcessList() []*Process
+}
+
+// Process describes an open MySQL connection to vtgate, as listed by SHOW PROCESSLIST.
+type Process struct {
+	ID   uint32
+	User string
+	Host string
+	// DB is the target of the session.
+	DB string
+	// Command is "Query" while the connection executes a query, and "Sleep" otherwise.
+	Command string
+	// Time is how long the connection has been in its current command.
+	Time time.Duration
+	// Info is the query in execution, if any.
+	Info string
 }
@@ -233,6 +233,21 @@ func (q *query) ReadTransaction(ctx context.Context, request *querypb.ReadTransa
 	return &querypb.ReadTransactionResponse{Metadata: result}, nil
 }
 
+// UnresolvedTransactions is part of the queryservice.QueryServer interface
+func (q *query) UnresolvedTransactions(ctx context.Context, request *querypb.UnresolvedTransactionsRequest) (response *querypb.UnresolvedTransactionsResponse, err error) {
+	defer q.server.HandlePanic(&err)
+	ctx = callerid.NewContext(callinfo.GRPCCallInfo(ctx),
+		request.EffectiveCallerId,
+		request.ImmediateCallerId,
+	)
+	transactions, err := q.server.UnresolvedTransactions(ctx, request.Target, request.AbandonAge)
+	if err != nil {
+		return nil, vterrors.ToGRPC(err)
+	}
+
+	return &querypb.UnresolvedTransactionsResponse{Transactions: transactions}, nil
+}
+
 // BeginExecute is part of the queryservice.QueryServer interface
 func (q *query) BeginExecute(ctx context.Context, request *querypb.BeginExecuteRequest) (response *querypb.BeginExecuteResponse, err error) {
 	defer q.server.HandlePanic(&err)
@@ -493,6 +508,20 @@ func (q *query) Release(ctx context.Context, request *querypb.ReleaseRequest) (r
 	return &querypb.ReleaseResponse{}, nil
 }
 
+// KillQueries is part of the queryservice.QueryServer interface
+func (q *query) KillQueries(ctx context.Context, request *querypb.KillQueriesRequest) (response *querypb.KillQueriesResponse, err error) {
+	defer q.server.HandlePanic(&err)
+	ctx = callerid.NewContext(callinfo.GRPCCallInfo(ctx),
+		request.EffectiveCallerId,
+		request.ImmediateCallerId,
+	)
+	count, err := q.server.KillQueries(ctx, request.Target, request.SessionUuid)
+	if err != nil {
+		return nil, vterrors.ToGRPC(err)
+	}
+	return &querypb.KillQueriesResponse{Count: count}, nil
+}
+
 // GetSchema implements the QueryServer interface
 func (q *query) GetSchema(request *querypb.GetSchemaRequest, stream queryservicepb.Query_GetSchemaServer) (err error) {
 	defer q.server.HandlePanic(&err)
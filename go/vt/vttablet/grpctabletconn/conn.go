@@ -438,6 +438,27 @@ func (conn *gRPCQueryClient) ReadTransaction(ctx context.Context, target *queryp
 	return response.Metadata, nil
 }
 
+// UnresolvedTransactions returns the distributed transactions that need to be resolved.
+func (conn *gRPCQueryClient) UnresolvedTransactions(ctx context.Context, target *querypb.Target, abandonAgeSeconds int64) ([]*querypb.TransactionMetadata, error) {
+	conn.mu.RLock()
+	defer conn.mu.RUnlock()
+	if conn.cc == nil {
+		return nil, tabletconn.ConnClosed
+	}
+
+	req := &querypb.UnresolvedTransactionsRequest{
+		Target:            target,
+		EffectiveCallerId: callerid.EffectiveCallerIDFromContext(ctx),
+		ImmediateCallerId: callerid.ImmediateCallerIDFromContext(ctx),
+		AbandonAge:        abandonAgeSeconds,
+	}
+	response, err := conn.c.UnresolvedTransactions(ctx, req)
+	if err != nil {
+		return nil, tabletconn.ErrorFromGRPC(err)
+	}
+	return response.Transactions, nil
+}
+
 // BeginExecute starts a transaction and runs an Execute.
 func (conn *gRPCQueryClient) BeginExecute(ctx context.Context, target *querypb.Target, preQueries []string, query string, bindVars map[string]*querypb.BindVariable, reservedID int64, options *querypb.ExecuteOptions) (state queryservice.TransactionState, result *sqltypes.Result, err error) {
 	conn.mu.RLock()
@@ -1058,6 +1079,27 @@ func (conn *gRPCQueryClient) Release(ctx context.Context, target *querypb.Target
 	return nil
 }
 
+// KillQueries implements the queryservice interface
+func (conn *gRPCQueryClient) KillQueries(ctx context.Context, target *querypb.Target, sessionUUID string) (int64, error) {
+	conn.mu.RLock()
+	defer conn.mu.RUnlock()
+	if conn.cc == nil {
+		return 0, tabletconn.ConnClosed
+	}
+
+	req := &querypb.KillQueriesRequest{
+		EffectiveCallerId: callerid.EffectiveCallerIDFromContext(ctx),
+		ImmediateCallerId: callerid.ImmediateCallerIDFromContext(ctx),
+		Target:            target,
+		SessionUuid:       sessionUUID,
+	}
+	res, err := conn.c.KillQueries(ctx, req)
+	if err != nil {
+		return 0, tabletconn.ErrorFromGRPC(err)
+	}
+	return res.Count, nil
+}
+
 // GetSchema implements the queryservice interface
 func (conn *gRPCQueryClient) GetSchema(ctx context.Context, target *querypb.Target, tableType querypb.SchemaTableType, tableNames []string, callback func(schemaRes *querypb.GetSchemaResponse) error) error {
 	conn.mu.RLock()
@@ -76,6 +76,11 @@ type QueryService interface {
 	// ReadTransaction returns the metadata for the specified dtid.
 	ReadTransaction(ctx context.Context, target *querypb.Target, dtid string) (metadata *querypb.TransactionMetadata, err error)
 
+	// UnresolvedTransactions returns the distributed transactions that are
+	// older than abandonAgeSeconds. If abandonAgeSeconds is zero, the tablet
+	// uses its configured abandon age.
+	UnresolvedTransactions(ctx context.Context, target *querypb.Target, abandonAgeSeconds int64) (transactions []*querypb.TransactionMetadata, err error)
+
 	// Execute for query execution
 	Execute(ctx context.Context, target *querypb.Target, sql string, bindVariables map[string]*querypb.BindVariable, transactionID, reservedID int64, options *querypb.ExecuteOptions) (*sqltypes.Result, error)
 	// StreamExecute for query execution with streaming
@@ -121,6 +126,10 @@ type QueryService interface {
 
 	Release(ctx context.Context, target *querypb.Target, transactionID, reservedID int64) error
 
+	// KillQueries kills the queries in flight of the vtgate session with the
+	// given UUID, and returns how many were killed.
+	KillQueries(ctx context.Context, target *querypb.Target, sessionUUID string) (count int64, err error)
+
 	// GetSchema returns the table definition for the specified tables.
 	GetSchema(ctx context.Context, target *querypb.Target, tableType querypb.SchemaTableType, tableNames []string, callback func(schemaRes *querypb.GetSchemaResponse) error) error
 
@@ -176,6 +176,15 @@ func (ws *wrappedService) ReadTransaction(ctx context.Context, target *querypb.T
 	return metadata, err
 }
 
+func (ws *wrappedService) UnresolvedTransactions(ctx context.Context, target *querypb.Target, abandonAgeSeconds int64) (transactions []*querypb.TransactionMetadata, err error) {
+	err = ws.wrapper(ctx, target, ws.impl, "UnresolvedTransactions", false, func(ctx context.Context, target *querypb.Target, conn QueryService) (bool, error) {
+		var innerErr error
+		transactions, innerErr = conn.UnresolvedTransactions(ctx, target, abandonAgeSeconds)
+		return canRetry(ctx, innerErr), innerErr
+	})
+	return transactions, err
+}
+
 func (ws *wrappedService) Execute(ctx context.Context, target *querypb.Target, query string, bindVars map[string]*querypb.BindVariable, transactionID, reservedID int64, options *querypb.ExecuteOptions) (qr *sqltypes.Result, err error) {
 	inDedicatedConn := transactionID != 0 || reservedID != 0
 	err = ws.wrapper(ctx, target, ws.impl, "Execute", inDedicatedConn, func(ctx context.Context, target *querypb.Target, conn QueryService) (bool, error) {
@@ -331,6 +340,16 @@ func (ws *wrappedService) Release(ctx context.Context, target *querypb.Target, t
 	})
 }
 
+func (ws *wrappedService) KillQueries(ctx context.Context, target *querypb.Target, sessionUUID string) (count int64, err error) {
+	err = ws.wrapper(ctx, target, ws.impl, "KillQueries", false, func(ctx context.Context, target *querypb.Target, conn QueryService) (bool, error) {
+		// No point retrying KillQueries: the queries run on this tablet only.
+		var innerErr error
+		count, innerErr = conn.KillQueries(ctx, target, sessionUUID)
+		return false, innerErr
+	})
+	return count, err
+}
+
 func (ws *wrappedService) GetSchema(ctx context.Context, target *querypb.Target, tableType querypb.SchemaTableType, tableNames []string, callback func(schemaRes *querypb.GetSchemaResponse) error) (err error) {
 	err = ws.wrapper(ctx, target, ws.impl, "GetSchema", false, func(ctx context.Context, target *querypb.Target, conn QueryService) (bool, error) {
 		innerErr := conn.GetSchema(ctx, target, tableType, tableNames, callback)
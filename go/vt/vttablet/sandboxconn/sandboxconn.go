@@ -65,22 +65,24 @@ type SandboxConn struct {
 
 	// These Count vars report how often the corresponding
 	// functions were called.
-	ExecCount                atomic.Int64
-	BeginCount               atomic.Int64
-	CommitCount              atomic.Int64
-	RollbackCount            atomic.Int64
-	AsTransactionCount       atomic.Int64
-	PrepareCount             atomic.Int64
-	CommitPreparedCount      atomic.Int64
-	RollbackPreparedCount    atomic.Int64
-	CreateTransactionCount   atomic.Int64
-	StartCommitCount         atomic.Int64
-	SetRollbackCount         atomic.Int64
-	ConcludeTransactionCount atomic.Int64
-	ReadTransactionCount     atomic.Int64
-	ReserveCount             atomic.Int64
-	ReleaseCount             atomic.Int64
-	GetSchemaCount           atomic.Int64
+	ExecCount                   atomic.Int64
+	BeginCount                  atomic.Int64
+	CommitCount                 atomic.Int64
+	RollbackCount               atomic.Int64
+	AsTransactionCount          atomic.Int64
+	PrepareCount                atomic.Int64
+	CommitPreparedCount         atomic.Int64
+	RollbackPreparedCount       atomic.Int64
+	CreateTransactionCount      atomic.Int64
+	StartCommitCount            atomic.Int64
+	SetRollbackCount            atomic.Int64
+	ConcludeTransactionCount    atomic.Int64
+	ReadTransactionCount        atomic.Int64
+	UnresolvedTransactionsCount atomic.Int64
+	ReserveCount                atomic.Int64
+	ReleaseCount                atomic.Int64
+	KillQueriesCount            atomic.Int64
+	GetSchemaCount              atomic.Int64
 
 	queriesRequireLocking bool
 	queriesMu             sync.Mutex
@@ -102,8 +104,18 @@ type SandboxConn struct {
 	// ReadTransactionResults is used for returning results for ReadTransaction.
 	ReadTransactionResults []*querypb.TransactionMetadata
 
+	// UnresolvedTransactionsResult is returned by UnresolvedTransactions.
+	UnresolvedTransactionsResult []*querypb.TransactionMetadata
+
+	// TableStatistics is returned by GetSchema for the STATISTICS table type.
+	// These calls do not consume the schema results, nor count in GetSchemaCount.
+	TableStatistics map[string]*querypb.TableStatistics
+
 	MessageIDs []*querypb.Value
 
+	// KilledSessionUUIDs stores the session UUIDs received by KillQueries.
+	KilledSessionUUIDs []string
+
 	// vstream expectations.
 	StartPos      string
 	VStreamEvents [][]*binlogdatapb.VEvent
@@ -405,6 +417,15 @@ func (sbc *SandboxConn) ReadTransaction(ctx context.Context, target *querypb.Tar
 	return nil, nil
 }
 
+// UnresolvedTransactions returns the unresolved distributed transactions.
+func (sbc *SandboxConn) UnresolvedTransactions(ctx context.Context, target *querypb.Target, abandonAgeSeconds int64) (transactions []*querypb.TransactionMetadata, err error) {
+	sbc.UnresolvedTransactionsCount.Add(1)
+	if err := sbc.getError(); err != nil {
+		return nil, err
+	}
+	return sbc.UnresolvedTransactionsResult, nil
+}
+
 // BeginExecute is part of the QueryService interface.
 func (sbc *SandboxConn) BeginExecute(ctx context.Context, target *querypb.Target, preQueries []string, query string, bindVars map[string]*querypb.BindVariable, reservedID int64, options *querypb.ExecuteOptions) (queryservice.TransactionState, *sqltypes.Result, error) {
 	state, err := sbc.begin(ctx, target, preQueries, reservedID, options)
@@ -633,8 +654,18 @@ func (sbc *SandboxConn) Release(ctx context.Context, target *querypb.Target, tra
 	return sbc.getError()
 }
 
+// KillQueries implements the QueryService interface
+func (sbc *SandboxConn) KillQueries(ctx context.Context, target *querypb.Target, sessionUUID string) (int64, error) {
+	sbc.KillQueriesCount.Add(1)
+	sbc.KilledSessionUUIDs = append(sbc.KilledSessionUUIDs, sessionUUID)
+	return 0, sbc.getError()
+}
+
 // GetSchema implements the QueryService interface
 func (sbc *SandboxConn) GetSchema(ctx context.Context, target *querypb.Target, tableType querypb.SchemaTableType, tableNames []string, callback func(schemaRes *querypb.GetSchemaResponse) error) error {
+	if tableType == querypb.SchemaTableType_STATISTICS {
+		return callback(&querypb.GetSchemaResponse{TableStatistics: sbc.TableStatistics})
+	}
 	sbc.GetSchemaCount.Add(1)
 	if len(sbc.getSchemaResult) == 0 {
 		return nil
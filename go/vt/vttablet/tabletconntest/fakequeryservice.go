@@ -359,6 +359,24 @@ func (f *FakeQueryService) ReadTransaction(ctx context.Context, target *querypb.
 	return Metadata, nil
 }
 
+// AbandonAge is a test abandon age for unresolved transactions.
+const AbandonAge = int64(60)
+
+// UnresolvedTransactions is part of the queryservice.QueryService interface
+func (f *FakeQueryService) UnresolvedTransactions(ctx context.Context, target *querypb.Target, abandonAgeSeconds int64) (transactions []*querypb.TransactionMetadata, err error) {
+	if f.HasError {
+		return nil, f.TabletError
+	}
+	if f.Panics {
+		panic(fmt.Errorf("test-triggered panic"))
+	}
+	f.checkTargetCallerID(ctx, "UnresolvedTransactions", target)
+	if abandonAgeSeconds != AbandonAge {
+		f.t.Errorf("UnresolvedTransactions: invalid abandon age: got %d expected %d", abandonAgeSeconds, AbandonAge)
+	}
+	return []*querypb.TransactionMetadata{Metadata}, nil
+}
+
 // ExecuteQuery is a fake test query.
 const ExecuteQuery = "executeQuery"
 
@@ -760,6 +778,27 @@ func (f *FakeQueryService) Release(ctx context.Context, target *querypb.Target,
 	panic("implement me")
 }
 
+// SessionUUID is a test vtgate session UUID.
+const SessionUUID = "test-session-uuid"
+
+// KilledQueriesCount is a test count of killed queries.
+const KilledQueriesCount = int64(3)
+
+// KillQueries is part of the queryservice.QueryService interface
+func (f *FakeQueryService) KillQueries(ctx context.Context, target *querypb.Target, sessionUUID string) (int64, error) {
+	if f.HasError {
+		return 0, f.TabletError
+	}
+	if f.Panics {
+		panic(fmt.Errorf("test-triggered panic"))
+	}
+	f.checkTargetCallerID(ctx, "KillQueries", target)
+	if sessionUUID != SessionUUID {
+		f.t.Errorf("KillQueries: invalid session UUID: got %s expected %s", sessionUUID, SessionUUID)
+	}
+	return KilledQueriesCount, nil
+}
+
 // GetSchema implements the QueryService interface
 func (f *FakeQueryService) GetSchema(ctx context.Context, target *querypb.Target, tableType querypb.SchemaTableType, tableNames []string, callback func(schemaRes *querypb.GetSchemaResponse) error) error {
 	panic("implement me")
@@ -399,6 +399,68 @@ func testReadTransactionPanics(t *testing.T, conn queryservice.QueryService, f *
 	})
 }
 
+func testKillQueries(t *testing.T, conn queryservice.QueryService, f *FakeQueryService) {
+	t.Log("testKillQueries")
+	ctx := context.Background()
+	ctx = callerid.NewContext(ctx, TestCallerID, TestVTGateCallerID)
+	count, err := conn.KillQueries(ctx, TestTarget, SessionUUID)
+	if err != nil {
+		t.Fatalf("KillQueries failed: %v", err)
+	}
+	if count != KilledQueriesCount {
+		t.Errorf("Unexpected result from KillQueries: got %v wanted %v", count, KilledQueriesCount)
+	}
+}
+
+func testKillQueriesError(t *testing.T, conn queryservice.QueryService, f *FakeQueryService) {
+	t.Log("testKillQueriesError")
+	f.HasError = true
+	testErrorHelper(t, f, "KillQueries", func(ctx context.Context) error {
+		_, err := conn.KillQueries(ctx, TestTarget, SessionUUID)
+		return err
+	})
+	f.HasError = false
+}
+
+func testKillQueriesPanics(t *testing.T, conn queryservice.QueryService, f *FakeQueryService) {
+	t.Log("testKillQueriesPanics")
+	testPanicHelper(t, f, "KillQueries", func(ctx context.Context) error {
+		_, err := conn.KillQueries(ctx, TestTarget, SessionUUID)
+		return err
+	})
+}
+
+func testUnresolvedTransactions(t *testing.T, conn queryservice.QueryService, f *FakeQueryService) {
+	t.Log("testUnresolvedTransactions")
+	ctx := context.Background()
+	ctx = callerid.NewContext(ctx, TestCallerID, TestVTGateCallerID)
+	transactions, err := conn.UnresolvedTransactions(ctx, TestTarget, AbandonAge)
+	if err != nil {
+		t.Fatalf("UnresolvedTransactions failed: %v", err)
+	}
+	if len(transactions) != 1 || !proto.Equal(transactions[0], Metadata) {
+		t.Errorf("Unexpected result from UnresolvedTransactions: got %v wanted %v", transactions, Metadata)
+	}
+}
+
+func testUnresolvedTransactionsError(t *testing.T, conn queryservice.QueryService, f *FakeQueryService) {
+	t.Log("testUnresolvedTransactionsError")
+	f.HasError = true
+	testErrorHelper(t, f, "UnresolvedTransactions", func(ctx context.Context) error {
+		_, err := conn.UnresolvedTransactions(ctx, TestTarget, AbandonAge)
+		return err
+	})
+	f.HasError = false
+}
+
+func testUnresolvedTransactionsPanics(t *testing.T, conn queryservice.QueryService, f *FakeQueryService) {
+	t.Log("testUnresolvedTransactionsPanics")
+	testPanicHelper(t, f, "UnresolvedTransactions", func(ctx context.Context) error {
+		_, err := conn.UnresolvedTransactions(ctx, TestTarget, AbandonAge)
+		return err
+	})
+}
+
 func testExecute(t *testing.T, conn queryservice.QueryService, f *FakeQueryService) {
 	t.Log("testExecute")
 	f.ExpectedTransactionID = ExecuteTransactionID
@@ -936,6 +998,7 @@ func TestSuite(t *testing.T, protocol string, tablet *topodatapb.Tablet, fake *F
 		testSetRollback,
 		testConcludeTransaction,
 		testReadTransaction,
+		testUnresolvedTransactions,
 		testExecute,
 		testBeginExecute,
 		testStreamExecute,
@@ -943,6 +1006,7 @@ func TestSuite(t *testing.T, protocol string, tablet *topodatapb.Tablet, fake *F
 		testMessageStream,
 		testMessageAck,
 		testReserveStreamExecute,
+		testKillQueries,
 
 		// error test cases
 		testBeginError,
@@ -956,6 +1020,7 @@ func TestSuite(t *testing.T, protocol string, tablet *topodatapb.Tablet, fake *F
 		testSetRollbackError,
 		testConcludeTransactionError,
 		testReadTransactionError,
+		testUnresolvedTransactionsError,
 		testExecuteError,
 		testBeginExecuteErrorInBegin,
 		testBeginExecuteErrorInExecute,
@@ -966,6 +1031,7 @@ func TestSuite(t *testing.T, protocol string, tablet *topodatapb.Tablet, fake *F
 		testReserveStreamExecuteErrorInExecute,
 		testMessageStreamError,
 		testMessageAckError,
+		testKillQueriesError,
 
 		// panic test cases
 		testBeginPanics,
@@ -979,12 +1045,14 @@ func TestSuite(t *testing.T, protocol string, tablet *topodatapb.Tablet, fake *F
 		testSetRollbackPanics,
 		testConcludeTransactionPanics,
 		testReadTransactionPanics,
+		testUnresolvedTransactionsPanics,
 		testExecutePanics,
 		testBeginExecutePanics,
 		testStreamExecutePanics,
 		testBeginStreamExecutePanics,
 		testMessageStreamPanics,
 		testMessageAckPanics,
+		testKillQueriesPanics,
 	}
 
 	if !fake.TestingGateway {
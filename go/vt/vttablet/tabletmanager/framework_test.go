@@ -258,6 +258,11 @@ func (ftc *fakeTabletConn) ReadTransaction(ctx context.Context, target *querypb.
 	return nil, nil
 }
 
+// fakeTabletConn implements the QueryService interface.
+func (ftc *fakeTabletConn) UnresolvedTransactions(ctx context.Context, target *querypb.Target, abandonAgeSeconds int64) (transactions []*querypb.TransactionMetadata, err error) {
+	return nil, nil
+}
+
 // fakeTabletConn implements the QueryService interface.
 func (ftc *fakeTabletConn) Execute(ctx context.Context, target *querypb.Target, sql string, bindVariables map[string]*querypb.BindVariable, transactionID, reservedID int64, options *querypb.ExecuteOptions) (*sqltypes.Result, error) {
 	return nil, nil
@@ -344,6 +349,11 @@ func (ftc *fakeTabletConn) Release(ctx context.Context, target *querypb.Target,
 	return nil
 }
 
+// fakeTabletConn implements the QueryService interface.
+func (ftc *fakeTabletConn) KillQueries(ctx context.Context, target *querypb.Target, sessionUUID string) (int64, error) {
+	return 0, nil
+}
+
 // fakeTabletConn implements the QueryService interface.
 func (ftc *fakeTabletConn) GetSchema(ctx context.Context, target *querypb.Target, tableType querypb.SchemaTableType, tableNames []string, callback func(schemaRes *querypb.GetSchemaResponse) error) error {
 	return nil
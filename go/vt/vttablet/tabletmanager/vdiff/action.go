@@ -45,6 +45,7 @@ const (
 	StopAction    VDiffAction = "stop"
 	ResumeAction  VDiffAction = "resume"
 	DeleteAction  VDiffAction = "delete"
+	RepairAction  VDiffAction = "repair"
 	AllActionArg              = "all"
 	LastActionArg             = "last"
 )
@@ -98,6 +99,10 @@ func (vde *Engine) PerformVDiffAction(ctx context.Context, req *tabletmanagerdat
 		if err := vde.handleDeleteAction(ctx, dbClient, action, req, resp); err != nil {
 			return nil, err
 		}
+	case RepairAction:
+		if err := vde.handleRepairAction(ctx, dbClient, action, req, resp); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, fmt.Errorf("action %s not supported", action)
 	}
@@ -379,7 +384,11 @@ func (vde *Engine) handleDeleteAction(ctx context.Context, dbClient binlogplayer
 			return err
 		}
 		for _, row := range res.Named().Rows {
-			cleanupController(vde.controllers[row.AsInt64("id", -1)])
+			id := row.AsInt64("id", -1)
+			cleanupController(vde.controllers[id])
+			if err := deleteVDiffChunksAndMismatches(dbClient, id); err != nil {
+				return err
+			}
 		}
 		deleteQuery, err = sqlparser.ParseAndBind(sqlDeleteVDiffs,
 			sqltypes.StringBindVariable(req.Keyspace),
@@ -410,7 +419,11 @@ func (vde *Engine) handleDeleteAction(ctx context.Context, dbClient binlogplayer
 			return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "no vdiff found for UUID %s on tablet %v",
 				uuid, vde.thisTablet.Alias)
 		}
-		cleanupController(vde.controllers[row.AsInt64("id", -1)])
+		id := row.AsInt64("id", -1)
+		cleanupController(vde.controllers[id])
+		if err := deleteVDiffChunksAndMismatches(dbClient, id); err != nil {
+			return err
+		}
 		deleteQuery, err = sqlparser.ParseAndBind(sqlDeleteVDiffByUUID,
 			sqltypes.StringBindVariable(uuid.String()),
 		)
@@ -425,3 +438,19 @@ func (vde *Engine) handleDeleteAction(ctx context.Context, dbClient binlogplayer
 
 	return nil
 }
+
+// deleteVDiffChunksAndMismatches deletes the chunks and the mismatched rows
+// of a vdiff, which are not deleted along with its other records as they can
+// be many.
+func deleteVDiffChunksAndMismatches(dbClient binlogplayer.DBClient, vdiffID int64) error {
+	for _, sql := range []string{sqlDeleteVDiffChunks, sqlDeleteVDiffMismatches} {
+		query, err := sqlparser.ParseAndBind(sql, sqltypes.Int64BindVariable(vdiffID))
+		if err != nil {
+			return err
+		}
+		if _, err := dbClient.ExecuteFetch(query, -1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -142,6 +142,12 @@ func TestPerformVDiffAction(t *testing.T) {
 						"1",
 					),
 				},
+				{
+					query: "delete from _vt.vdiff_chunk where vdiff_id = 1",
+				},
+				{
+					query: "delete from _vt.vdiff_mismatch where vdiff_id = 1",
+				},
 				{
 					query: fmt.Sprintf(`delete from vd, vdt using _vt.vdiff as vd left join _vt.vdiff_table as vdt on (vd.id = vdt.vdiff_id)
 							where vd.vdiff_uuid = %s`, encodeString(uuid)),
@@ -168,6 +174,18 @@ func TestPerformVDiffAction(t *testing.T) {
 						"2",
 					),
 				},
+				{
+					query: "delete from _vt.vdiff_chunk where vdiff_id = 1",
+				},
+				{
+					query: "delete from _vt.vdiff_mismatch where vdiff_id = 1",
+				},
+				{
+					query: "delete from _vt.vdiff_chunk where vdiff_id = 2",
+				},
+				{
+					query: "delete from _vt.vdiff_mismatch where vdiff_id = 2",
+				},
 				{
 					query: fmt.Sprintf(`delete from vd, vdt, vdl using _vt.vdiff as vd left join _vt.vdiff_table as vdt on (vd.id = vdt.vdiff_id)
 										left join _vt.vdiff_log as vdl on (vd.id = vdl.vdiff_id)
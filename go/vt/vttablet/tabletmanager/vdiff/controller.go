@@ -69,9 +69,8 @@ type controller struct {
 	sourceKeyspace string
 	tmc            tmclient.TabletManagerClient
 
-	targetShardStreamer *shardStreamer
-	filter              *binlogdatapb.Filter            // vreplication row filter
-	options             *tabletmanagerdata.VDiffOptions // options initially from vtctld command and later from _vt.vdiff
+	filter  *binlogdatapb.Filter            // vreplication row filter
+	options *tabletmanagerdata.VDiffOptions // options initially from vtctld command and later from _vt.vdiff
 
 	sourceTimeZone, targetTimeZone string // named time zones if conversions are necessary for datetime values
 
@@ -187,6 +186,27 @@ func (ct *controller) start(ctx context.Context, dbClient binlogplayer.DBClient)
 		return vterrors.Errorf(vtrpcpb.Code_CANCELED, "vdiff was stopped")
 	default:
 	}
+	if err := ct.loadSources(ctx, dbClient); err != nil {
+		return err
+	}
+
+	wd, err := newWorkflowDiffer(ct, ct.options)
+	if err != nil {
+		return err
+	}
+	if err := ct.updateState(dbClient, StartedState, nil); err != nil {
+		return err
+	}
+	if err := wd.diff(ctx); err != nil {
+		log.Errorf("Encountered an error performing workflow diff for vdiff %s: %v", ct.uuid, err)
+		return err
+	}
+
+	return nil
+}
+
+// loadSources loads the source shards of the workflow from its streams.
+func (ct *controller) loadSources(ctx context.Context, dbClient binlogplayer.DBClient) error {
 	ct.workflowFilter = fmt.Sprintf("where workflow = %s and db_name = %s", encodeString(ct.workflow),
 		encodeString(ct.vde.dbName))
 	query := sqlparser.BuildParsedQuery(sqlGetVReplicationEntry, ct.workflowFilter)
@@ -229,23 +249,7 @@ func (ct *controller) start(ctx context.Context, dbClient binlogplayer.DBClient)
 		}
 	}
 
-	if err := ct.validate(); err != nil {
-		return err
-	}
-
-	wd, err := newWorkflowDiffer(ct, ct.options)
-	if err != nil {
-		return err
-	}
-	if err := ct.updateState(dbClient, StartedState, nil); err != nil {
-		return err
-	}
-	if err := wd.diff(ctx); err != nil {
-		log.Errorf("Encountered an error performing workflow diff for vdiff %s: %v", ct.uuid, err)
-		return err
-	}
-
-	return nil
+	return ct.validate()
 }
 
 // markStoppedByRequest records the fact that this VDiff was stopped via user
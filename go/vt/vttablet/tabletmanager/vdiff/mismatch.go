@@ -0,0 +1,176 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vdiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// The types of the mismatched rows recorded in _vt.vdiff_mismatch.
+const (
+	// mismatchRow is a row with the same primary key on the source and the
+	// target, but different values.
+	mismatchRow = "mismatch"
+	// mismatchExtraSource is a row only found on the source.
+	mismatchExtraSource = "extra_source"
+	// mismatchExtraTarget is a row only found on the target.
+	mismatchExtraTarget = "extra_target"
+)
+
+// mismatchesBatchSize is the max number of mismatched rows inserted in
+// _vt.vdiff_mismatch with one statement.
+const mismatchesBatchSize = 500
+
+// mismatchedRow is the primary key of a row that differs between the source
+// and the target.
+type mismatchedRow struct {
+	typ string
+	// pk is the primary key, in the format of the lastpk of the table.
+	pk []byte
+	// values are the values of the primary key columns.
+	values []sqltypes.Value
+}
+
+// recordMismatches returns true if the mismatched rows are saved, and not
+// only the sample rows of the report.
+func (td *tableDiffer) recordMismatches() bool {
+	ro := td.wd.opts.ReportOptions
+	return ro != nil && (ro.RecordMismatches || ro.MismatchesDir != "")
+}
+
+// recordMismatch buffers the primary key of a mismatched row, until it is
+// saved along with the progress of the diff. Mismatched rows can be recorded
+// again when a vdiff is retried, or resumed after it found new rows since the
+// last time, so the consumers of the mismatches should expect duplicates.
+func (td *tableDiffer) recordMismatch(typ string, row []sqltypes.Value) error {
+	if !td.recordMismatches() {
+		return nil
+	}
+	pk, err := td.lastPKFromRow(row)
+	if err != nil {
+		return err
+	}
+	values := make([]sqltypes.Value, len(td.tablePlan.pkCols))
+	for i, colIndex := range td.tablePlan.pkCols {
+		values[i] = row[colIndex]
+	}
+	td.mismatches = append(td.mismatches, &mismatchedRow{typ: typ, pk: pk, values: values})
+	return nil
+}
+
+// flushMismatches saves the buffered mismatched rows in _vt.vdiff_mismatch
+// when RecordMismatches is set, and appends them to the mismatches file of
+// the table when MismatchesDir is set.
+func (td *tableDiffer) flushMismatches(dbClient binlogplayer.DBClient) error {
+	ro := td.wd.opts.ReportOptions
+	if ro.RecordMismatches {
+		var chunkID int64
+		if td.chunk != nil {
+			chunkID = td.chunk.id
+		}
+		for start := 0; start < len(td.mismatches); start += mismatchesBatchSize {
+			end := min(start+mismatchesBatchSize, len(td.mismatches))
+			values := make([]string, 0, end-start)
+			for _, mr := range td.mismatches[start:end] {
+				value, err := sqlparser.ParseAndBind(sqlNewVDiffMismatchValues,
+					sqltypes.Int64BindVariable(td.wd.ct.id),
+					sqltypes.StringBindVariable(td.table.Name),
+					sqltypes.Int64BindVariable(chunkID),
+					sqltypes.StringBindVariable(mr.typ),
+					sqltypes.StringBindVariable(string(mr.pk)),
+				)
+				if err != nil {
+					return err
+				}
+				values = append(values, value)
+			}
+			query := fmt.Sprintf(sqlNewVDiffMismatches, strings.Join(values, ", "))
+			if _, err := dbClient.ExecuteFetch(query, 0); err != nil {
+				return err
+			}
+		}
+	}
+	if ro.MismatchesDir != "" {
+		if err := td.writeMismatches(ro.MismatchesDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mismatchesFile returns the path of the file the mismatched rows of the
+// table are exported to.
+func (td *tableDiffer) mismatchesFile(dir string) string {
+	name := fmt.Sprintf("%s.%s.%s.jsonl", td.wd.ct.uuid, td.wd.ct.vde.thisTablet.Shard, td.table.Name)
+	return filepath.Join(dir, name)
+}
+
+// writeMismatches appends the buffered mismatched rows to the mismatches file
+// of the table, one JSON object per line with the type and the primary key of
+// the row.
+func (td *tableDiffer) writeMismatches(dir string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, mr := range td.mismatches {
+		pk := make(map[string]any, len(mr.values))
+		for i, colIndex := range td.tablePlan.pkCols {
+			pk[td.tablePlan.table.Fields[colIndex].Name] = mismatchValueToJSON(mr.values[i])
+		}
+		if err := enc.Encode(struct {
+			Type string         `json:"type"`
+			PK   map[string]any `json:"pk"`
+		}{mr.typ, pk}); err != nil {
+			return err
+		}
+	}
+
+	// The chunks of a table are diffed in parallel, and share its file.
+	td.wd.mismatchesMu.Lock()
+	defer td.wd.mismatchesMu.Unlock()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(td.mismatchesFile(dir), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func mismatchValueToJSON(v sqltypes.Value) any {
+	switch {
+	case v.IsNull():
+		return nil
+	case v.IsIntegral(), v.IsFloat(), v.IsDecimal():
+		return json.Number(v.ToString())
+	default:
+		return v.ToString()
+	}
+}
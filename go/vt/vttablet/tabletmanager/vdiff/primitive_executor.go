@@ -47,6 +47,10 @@ type primitiveExecutor struct {
 	rows     [][]sqltypes.Value
 	resultch chan *sqltypes.Result
 	err      error
+	// past, if set, returns true for the rows that are past the end of the
+	// range being diffed. The stream ends at the first such row.
+	past func(row []sqltypes.Value) (bool, error)
+	done bool
 
 	name string // for debug purposes only
 }
@@ -78,6 +82,9 @@ func newPrimitiveExecutor(ctx context.Context, prim vtgateEngine.Primitive, name
 // next gets the next row in the stream for this shard, if there's currently no rows to process in the stream then wait on the
 // result channel for the shard streamer to produce them.
 func (pe *primitiveExecutor) next() ([]sqltypes.Value, error) {
+	if pe.done {
+		return nil, nil
+	}
 	for len(pe.rows) == 0 {
 		qr, ok := <-pe.resultch
 		if !ok {
@@ -87,13 +94,23 @@ func (pe *primitiveExecutor) next() ([]sqltypes.Value, error) {
 	}
 
 	row := pe.rows[0]
+	if pe.past != nil {
+		past, err := pe.past(row)
+		if err != nil {
+			return nil, err
+		}
+		if past {
+			pe.done = true
+			return nil, nil
+		}
+	}
 	pe.rows = pe.rows[1:]
 	return row, nil
 }
 
 // drain fastforward's a shard to process (and ignore) everything from its results stream and return a count of the
-// discarded rows.
-func (pe *primitiveExecutor) drain(ctx context.Context) (int64, error) {
+// discarded rows. If onRow is set, it's called for each discarded row.
+func (pe *primitiveExecutor) drain(ctx context.Context, onRow func(row []sqltypes.Value) error) (int64, error) {
 	var count int64
 	for {
 		row, err := pe.next()
@@ -103,6 +120,11 @@ func (pe *primitiveExecutor) drain(ctx context.Context) (int64, error) {
 		if row == nil {
 			return count, nil
 		}
+		if onRow != nil {
+			if err := onRow(row); err != nil {
+				return 0, err
+			}
+		}
 		count++
 	}
 }
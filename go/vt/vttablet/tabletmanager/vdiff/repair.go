@@ -0,0 +1,557 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vdiff
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtctl/schematools"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+/*
+	A completed vdiff that recorded its mismatched rows in _vt.vdiff_mismatch
+	can be repaired: the mismatched rows of each table are copied again from
+	the source to the target, and then compared again.
+
+	The mismatched rows are repaired in batches of rows of the same chunk, to
+	bound the time the target keyspace is locked. The rows of a batch are read
+	from the source with a consistent snapshot, taken like the snapshot of a
+	diff, and written to the target while the streams of the workflow are
+	stopped at the position of the snapshot, so that the events replicated
+	after it apply to the repaired rows. The source rows are streamed from the
+	start of the chunk of the batch, or of the table when it was not diffed in
+	chunks, or from the last row of the previous batch of the chunk.
+
+	Once all the batches are written and the streams restarted, the rows of
+	each batch are compared again with new snapshots of the source and the
+	target. The rows that match are deleted from _vt.vdiff_mismatch, so a
+	repair can be run again for the others.
+*/
+
+// repairKey is a mismatched primary key to repair.
+type repairKey struct {
+	// ids are the ids of the rows of _vt.vdiff_mismatch of the key, which can
+	// be recorded more than once.
+	ids   []int64
+	chunk int64
+	// lastPK is the primary key, to stream the rows after it.
+	lastPK *querypb.QueryResult
+	// pk are the values of the primary key, and row a row of the table with
+	// these values, to compare it with the rows of the table.
+	pk  []sqltypes.Value
+	row []sqltypes.Value
+}
+
+// repairBatch is a batch of keys of the same chunk, repaired while the
+// streams of the workflow are stopped once.
+type repairBatch struct {
+	keys []*repairKey
+	// lastPK is the primary key the rows of the batch are streamed after.
+	lastPK *querypb.QueryResult
+}
+
+// repairResult is the result of the repair of the mismatched rows of a table.
+type repairResult struct {
+	mismatches int64
+	repaired   int64
+	verified   int64
+}
+
+func (vde *Engine) handleRepairAction(ctx context.Context, dbClient binlogplayer.DBClient, action VDiffAction, req *tabletmanagerdatapb.VDiffRequest, resp *tabletmanagerdatapb.VDiffResponse) error {
+	vdiffUUID, err := uuid.Parse(req.VdiffUuid)
+	if err != nil {
+		return fmt.Errorf("invalid vdiff UUID %s: %v", req.VdiffUuid, err)
+	}
+	query, err := sqlparser.ParseAndBind(sqlGetVDiffByKeyspaceWorkflowUUID,
+		sqltypes.StringBindVariable(req.Keyspace),
+		sqltypes.StringBindVariable(req.Workflow),
+		sqltypes.StringBindVariable(vdiffUUID.String()),
+	)
+	if err != nil {
+		return err
+	}
+	qr, err := dbClient.ExecuteFetch(query, 1)
+	if err != nil {
+		return err
+	}
+	row := qr.Named().Row()
+	if row == nil {
+		return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "no vdiff found for UUID %s on tablet %v",
+			vdiffUUID, vde.thisTablet.Alias)
+	}
+	if state := VDiffState(strings.ToLower(row.AsString("state", ""))); state != CompletedState {
+		return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "vdiff %s is %s on tablet %v, only completed vdiffs can be repaired",
+			vdiffUUID, state, vde.thisTablet.Alias)
+	}
+	id := row.AsInt64("id", 0)
+	resp.Id = id
+	resp.VdiffUuid = vdiffUUID.String()
+
+	query, err = sqlparser.ParseAndBind(sqlGetVDiffMismatchTables, sqltypes.Int64BindVariable(id))
+	if err != nil {
+		return err
+	}
+	if qr, err = dbClient.ExecuteFetch(query, -1); err != nil {
+		return err
+	}
+	if len(qr.Rows) == 0 {
+		return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "no mismatched rows are recorded for vdiff %s on tablet %v, it must be created with --record-mismatches to be repaired",
+			vdiffUUID, vde.thisTablet.Alias)
+	}
+	mismatches := make(map[string]int64, len(qr.Rows))
+	var tables []string
+	for _, row := range qr.Named().Rows {
+		table := row.AsString("table_name", "")
+		tables = append(tables, table)
+		mismatches[table] = row.AsInt64("mismatches", 0)
+	}
+
+	options := proto.Clone(optionsZeroVal).(*tabletmanagerdatapb.VDiffOptions)
+	if err := protojson.Unmarshal(row.AsBytes("options", []byte("{}")), options); err != nil {
+		return err
+	}
+	options.CoreOptions.Tables = strings.Join(tables, ",")
+
+	vde.mu.Lock()
+	if ct := vde.controllers[id]; ct != nil {
+		select {
+		case <-ct.done:
+		default:
+			vde.mu.Unlock()
+			return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "vdiff %s is running on tablet %v", vdiffUUID, vde.thisTablet.Alias)
+		}
+	}
+	vde.mu.Unlock()
+
+	// The controller of a repair is not run, it only holds the state of the
+	// workflow for the differs.
+	ct := &controller{
+		id:              id,
+		uuid:            vdiffUUID.String(),
+		workflow:        req.Workflow,
+		dbClientFactory: vde.dbClientFactoryDba,
+		ts:              vde.ts,
+		vde:             vde,
+		done:            make(chan struct{}),
+		tmc:             vde.tmClientFactory(),
+		sources:         make(map[string]*migrationSource),
+		options:         options,
+	}
+	if err := ct.loadSources(ctx, dbClient); err != nil {
+		return err
+	}
+	if ct.sourceTimeZone != "" {
+		return vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "vdiff %s can't be repaired as workflow %s converts time zones",
+			vdiffUUID, req.Workflow)
+	}
+	wd, err := newWorkflowDiffer(ct, options)
+	if err != nil {
+		return err
+	}
+	schm, err := schematools.GetSchema(ctx, vde.ts, ct.tmc, vde.thisTablet.Alias, &tabletmanagerdatapb.GetSchemaRequest{Tables: tables})
+	if err != nil {
+		return vterrors.Wrap(err, "GetSchema")
+	}
+	if err := wd.buildPlan(dbClient, ct.filter, schm); err != nil {
+		return vterrors.Wrap(err, "buildPlan")
+	}
+
+	result := &sqltypes.Result{
+		Fields: []*querypb.Field{
+			{Name: "table_name", Type: sqltypes.VarBinary},
+			{Name: "mismatches", Type: sqltypes.Int64},
+			{Name: "repaired", Type: sqltypes.Int64},
+			{Name: "verified", Type: sqltypes.Int64},
+		},
+	}
+	for _, table := range tables {
+		td, ok := wd.tableDiffers[table]
+		if !ok {
+			return fmt.Errorf("table %s of vdiff %s is no longer in workflow %s", table, vdiffUUID, req.Workflow)
+		}
+		log.Infof("Repairing %d mismatched rows of table %s for vdiff %s", mismatches[table], table, vdiffUUID)
+		rr, err := td.repair(ctx, dbClient)
+		if err != nil {
+			return vterrors.Wrapf(err, "failed to repair table %s", table)
+		}
+		insertVDiffLog(ctx, dbClient, id, fmt.Sprintf("Repaired %d rows of table %s, %d of them match",
+			rr.repaired, encodeString(table), rr.verified))
+		result.Rows = append(result.Rows, []sqltypes.Value{
+			sqltypes.NewVarBinary(table),
+			sqltypes.NewInt64(rr.mismatches),
+			sqltypes.NewInt64(rr.repaired),
+			sqltypes.NewInt64(rr.verified),
+		})
+	}
+	resp.Output = sqltypes.ResultToProto3(result)
+	return nil
+}
+
+// repair copies the mismatched rows of the table from the source to the
+// target, a batch at a time, and then compares them again.
+func (td *tableDiffer) repair(ctx context.Context, dbClient binlogplayer.DBClient) (*repairResult, error) {
+	if len(td.tablePlan.aggregates) != 0 {
+		return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "tables with aggregates can't be repaired")
+	}
+	keys, err := td.getRepairKeys(dbClient)
+	if err != nil {
+		return nil, err
+	}
+	rr := &repairResult{}
+	for _, key := range keys {
+		rr.mismatches += int64(len(key.ids))
+	}
+	if len(keys) == 0 {
+		return rr, nil
+	}
+	batches, err := td.getRepairBatches(dbClient, keys)
+	if err != nil {
+		return nil, err
+	}
+	for _, batch := range batches {
+		if err := td.repairBatch(ctx, dbClient, batch); err != nil {
+			return nil, err
+		}
+		rr.repaired += int64(len(batch.keys))
+	}
+	// The repaired rows are compared once the streams of the workflow
+	// replicated the changes made since they were written, with a new
+	// snapshot of the source and the target.
+	for _, batch := range batches {
+		verified, err := td.verifyRepairBatch(ctx, dbClient, batch)
+		if err != nil {
+			return nil, err
+		}
+		rr.verified += verified
+	}
+	return rr, nil
+}
+
+// getRepairKeys returns the mismatched keys of the table, in the order of the
+// diff and without duplicates.
+func (td *tableDiffer) getRepairKeys(dbClient binlogplayer.DBClient) ([]*repairKey, error) {
+	query, err := sqlparser.ParseAndBind(sqlGetVDiffMismatches,
+		sqltypes.Int64BindVariable(td.wd.ct.id),
+		sqltypes.StringBindVariable(td.table.Name),
+	)
+	if err != nil {
+		return nil, err
+	}
+	qr, err := dbClient.ExecuteFetch(query, -1)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]*repairKey, 0, len(qr.Rows))
+	for _, row := range qr.Named().Rows {
+		pk, err := unmarshalChunkPK(row.AsBytes("pk", nil))
+		if err != nil {
+			return nil, err
+		}
+		if pk == nil || len(pk.Rows) != 1 || len(pk.Fields) != len(td.tablePlan.pkCols) {
+			return nil, fmt.Errorf("invalid mismatched primary key %d of vdiff table %s", row.AsInt64("id", 0), td.table.Name)
+		}
+		key := &repairKey{
+			ids:    []int64{row.AsInt64("id", 0)},
+			chunk:  row.AsInt64("chunk_id", 0),
+			lastPK: pk,
+			pk:     sqltypes.MakeRowTrusted(pk.Fields, pk.Rows[0]),
+			row:    make([]sqltypes.Value, len(td.tablePlan.compareCols)),
+		}
+		for i, colIndex := range td.tablePlan.pkCols {
+			key.row[colIndex] = key.pk[i]
+		}
+		keys = append(keys, key)
+	}
+
+	var sortErr error
+	sort.SliceStable(keys, func(i, j int) bool {
+		c, err := td.comparePK(keys[i].row, keys[j].pk)
+		if err != nil && sortErr == nil {
+			sortErr = err
+		}
+		return c < 0
+	})
+	if sortErr != nil {
+		return nil, sortErr
+	}
+	deduped := keys[:0]
+	for _, key := range keys {
+		if len(deduped) > 0 {
+			last := deduped[len(deduped)-1]
+			c, err := td.comparePK(last.row, key.pk)
+			if err != nil {
+				return nil, err
+			}
+			if c == 0 {
+				last.ids = append(last.ids, key.ids...)
+				last.chunk = min(last.chunk, key.chunk)
+				continue
+			}
+		}
+		deduped = append(deduped, key)
+	}
+	return deduped, nil
+}
+
+// getRepairBatches splits the keys in batches of at most mismatchesBatchSize
+// keys of the same chunk. The rows of a batch are streamed from the start of
+// its chunk, or from the last key of the previous batch of the chunk.
+func (td *tableDiffer) getRepairBatches(dbClient binlogplayer.DBClient, keys []*repairKey) ([]*repairBatch, error) {
+	lowerPKs := make(map[int64]*querypb.QueryResult)
+	for _, key := range keys {
+		if key.chunk == 0 {
+			continue
+		}
+		chunks, err := td.getChunks(dbClient)
+		if err != nil {
+			return nil, err
+		}
+		for _, chunk := range chunks {
+			lowerPKs[chunk.id] = chunk.lowerPK
+		}
+		break
+	}
+	var batches []*repairBatch
+	var batch *repairBatch
+	for i, key := range keys {
+		if batch == nil || len(batch.keys) == mismatchesBatchSize || key.chunk != batch.keys[0].chunk {
+			batch = &repairBatch{lastPK: lowerPKs[key.chunk]}
+			if i > 0 && key.chunk == keys[i-1].chunk {
+				batch.lastPK = keys[i-1].lastPK
+			}
+			batches = append(batches, batch)
+		}
+		batch.keys = append(batch.keys, key)
+	}
+	return batches, nil
+}
+
+// repairBatch writes the source rows of the keys of the batch on the target,
+// while the streams of the workflow are stopped at the position of the
+// snapshot of the source.
+func (td *tableDiffer) repairBatch(ctx context.Context, dbClient binlogplayer.DBClient, batch *repairBatch) error {
+	td.lastPK = batch.lastPK
+	return td.pauseTargetStreams(ctx, func(ctx context.Context, _ binlogplayer.DBClient) error {
+		if err := td.selectTablets(ctx); err != nil {
+			return err
+		}
+		if err := td.syncSourceStreams(ctx); err != nil {
+			return err
+		}
+		// The source streams are stopped once the last key is read.
+		streamCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		if err := td.startSourceDataStreams(streamCtx); err != nil {
+			return err
+		}
+		if err := td.syncTargetStreams(ctx); err != nil {
+			return err
+		}
+		td.setupRowSorters()
+		sources, err := td.readRepairRows(streamCtx, td.sourcePrimitive, "source", batch.keys)
+		if err != nil {
+			return err
+		}
+		cancel()
+		return td.writeRepairRows(dbClient, batch.keys, sources)
+	})
+}
+
+// verifyRepairBatch compares the source and target rows of the keys of the
+// batch, with new snapshots taken like the snapshots of a diff, deletes the
+// keys that match from _vt.vdiff_mismatch, and returns their number.
+func (td *tableDiffer) verifyRepairBatch(ctx context.Context, dbClient binlogplayer.DBClient, batch *repairBatch) (int64, error) {
+	td.lastPK = batch.lastPK
+	// The streams are stopped once the last key is read.
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := td.initialize(streamCtx); err != nil {
+		return 0, err
+	}
+	sources, err := td.readRepairRows(streamCtx, td.sourcePrimitive, "source", batch.keys)
+	if err != nil {
+		return 0, err
+	}
+	targets, err := td.readRepairRows(streamCtx, td.targetPrimitive, "target", batch.keys)
+	if err != nil {
+		return 0, err
+	}
+	cancel()
+	return td.verifyRepairRows(dbClient, batch.keys, sources, targets)
+}
+
+// readRepairRows reads the rows of the keys from the rows of the primitive,
+// sorted by primary key. It returns them in the order of the keys, nil for
+// the keys without a row.
+func (td *tableDiffer) readRepairRows(ctx context.Context, primitive engine.Primitive, name string, keys []*repairKey) ([][]sqltypes.Value, error) {
+	rows := make([][]sqltypes.Value, len(keys))
+	last := keys[len(keys)-1]
+	executor := newPrimitiveExecutor(ctx, primitive, name)
+	executor.past = func(row []sqltypes.Value) (bool, error) {
+		c, err := td.comparePK(row, last.pk)
+		return c > 0, err
+	}
+	i := 0
+	for i < len(keys) {
+		row, err := executor.next()
+		if err != nil {
+			return nil, err
+		}
+		if row == nil {
+			return rows, nil
+		}
+		for i < len(keys) {
+			c, err := td.comparePK(row, keys[i].pk)
+			if err != nil {
+				return nil, err
+			}
+			if c < 0 {
+				break
+			}
+			if c == 0 {
+				rows[i] = row
+			}
+			i++
+			if c == 0 {
+				break
+			}
+		}
+	}
+	return rows, nil
+}
+
+// writeRepairRows writes the source rows of the keys on the target in a
+// transaction, or deletes the target rows of the keys without a source row.
+// The compared columns of the target rows are updated, the others keep their
+// values.
+func (td *tableDiffer) writeRepairRows(dbClient binlogplayer.DBClient, keys []*repairKey, sources [][]sqltypes.Value) error {
+	if err := dbClient.Begin(); err != nil {
+		return err
+	}
+	defer dbClient.Rollback()
+	for i, key := range keys {
+		if _, err := dbClient.ExecuteFetch(td.repairQuery(key, sources[i]), 1); err != nil {
+			return err
+		}
+	}
+	return dbClient.Commit()
+}
+
+// repairQuery returns the query that writes the source row of the key on the
+// target, or deletes the target row of the key if source is nil.
+func (td *tableDiffer) repairQuery(key *repairKey, source []sqltypes.Value) string {
+	buf := sqlparser.NewTrackedBuffer(nil)
+	if source == nil {
+		buf.Myprintf("delete from %v.%v where ", sqlparser.NewIdentifierCS(td.wd.ct.vde.dbName), sqlparser.NewIdentifierCS(td.table.Name))
+		td.writePKCondition(buf, key.pk)
+		return buf.String()
+	}
+	buf.Myprintf("insert into %v.%v(", sqlparser.NewIdentifierCS(td.wd.ct.vde.dbName), sqlparser.NewIdentifierCS(td.table.Name))
+	for i, col := range td.tablePlan.compareCols {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.Myprintf("%v", sqlparser.NewIdentifierCI(col.colName))
+	}
+	buf.WriteString(") values (")
+	for i, col := range td.tablePlan.compareCols {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		source[col.colIndex].EncodeSQL(buf)
+	}
+	// The primary key columns are updated too, their values can differ
+	// while being equal with their collation.
+	buf.WriteString(") on duplicate key update ")
+	for i, col := range td.tablePlan.compareCols {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.Myprintf("%v = values(%v)", sqlparser.NewIdentifierCI(col.colName), sqlparser.NewIdentifierCI(col.colName))
+	}
+	return buf.String()
+}
+
+// verifyRepairRows compares the target rows of the keys with their source
+// rows, deletes the keys that match from _vt.vdiff_mismatch, and returns
+// their number.
+func (td *tableDiffer) verifyRepairRows(dbClient binlogplayer.DBClient, keys []*repairKey, sources, targets [][]sqltypes.Value) (int64, error) {
+	var verified int64
+	var ids []int64
+	for i, key := range keys {
+		match := sources[i] == nil && targets[i] == nil
+		if sources[i] != nil && targets[i] != nil {
+			c, err := td.compare(sources[i], targets[i], td.tablePlan.compareCols, false)
+			if err != nil {
+				return 0, err
+			}
+			match = c == 0
+		}
+		if !match {
+			log.Warningf("Row %v of table %s still differs after the repair of vdiff %s", key.pk, td.table.Name, td.wd.ct.uuid)
+			continue
+		}
+		verified++
+		ids = append(ids, key.ids...)
+	}
+	if len(ids) == 0 {
+		return verified, nil
+	}
+	idsBV, err := sqltypes.BuildBindVariable(ids)
+	if err != nil {
+		return 0, err
+	}
+	query, err := sqlparser.ParseAndBind(sqlDeleteVDiffMismatchesByID, sqltypes.Int64BindVariable(td.wd.ct.id), idsBV)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := dbClient.ExecuteFetch(query, -1); err != nil {
+		return 0, err
+	}
+	return verified, nil
+}
+
+// writePKCondition writes the condition on the primary key columns of the
+// target table that matches the row with the values of the primary key.
+func (td *tableDiffer) writePKCondition(buf *sqlparser.TrackedBuffer, pk []sqltypes.Value) {
+	buf.WriteString("(")
+	for i, col := range td.tablePlan.comparePKs {
+		if i > 0 {
+			buf.WriteString(" and ")
+		}
+		buf.Myprintf("%v = ", sqlparser.NewIdentifierCI(col.colName))
+		pk[i].EncodeSQL(buf)
+	}
+	buf.WriteString(")")
+}
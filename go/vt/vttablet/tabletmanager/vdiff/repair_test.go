@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vdiff
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+func TestGetRepairKeys(t *testing.T) {
+	td := newChunkTestDiffer(nil)
+	td.tablePlan.compareCols = append(td.tablePlan.comparePKs, compareColInfo{colIndex: 2, colName: "val"})
+
+	fields := sqltypes.MakeTestFields("id|chunk_id|type|pk", "int64|int64|varbinary|varbinary")
+	var rows []string
+	for i, row := range []string{"10|b|x", "2|a|x", "10|B|x", "10|a|x"} {
+		pk, err := td.lastPKFromRow(chunkTestRow(row))
+		require.NoError(t, err)
+		rows = append(rows, fmt.Sprintf("%d|%d|mismatch|%s", i+1, 3-i, pk))
+	}
+	dbClient := binlogplayer.NewMockDBClient(t)
+	dbClient.ExpectRequestRE("select id as id, chunk_id as chunk_id, type as type, pk as pk from _vt.vdiff_mismatch.*", sqltypes.MakeTestResult(fields, rows...), nil)
+
+	keys, err := td.getRepairKeys(dbClient)
+	require.NoError(t, err)
+	dbClient.Wait()
+
+	// The keys are sorted, and the keys equal with the collation of the
+	// column are merged.
+	require.Len(t, keys, 3)
+	assert.Equal(t, []int64{2}, keys[0].ids)
+	assert.Equal(t, []int64{4}, keys[1].ids)
+	assert.Equal(t, []int64{1, 3}, keys[2].ids)
+	assert.EqualValues(t, 1, keys[2].chunk)
+	assert.Equal(t, []sqltypes.Value{sqltypes.NewInt64(10), sqltypes.NewVarChar("b")}, keys[2].pk)
+
+	buf := sqlparser.NewTrackedBuffer(nil)
+	td.writePKCondition(buf, keys[2].pk)
+	assert.Equal(t, "(c1 = 10 and c2 = 'b')", buf.String())
+}
+
+func TestGetRepairBatches(t *testing.T) {
+	td := newChunkTestDiffer(nil)
+	td.tablePlan.compareCols = append(td.tablePlan.comparePKs, compareColInfo{colIndex: 2, colName: "val"})
+
+	// One key in the first chunk, and more than a batch of keys in the
+	// second one.
+	var keys []*repairKey
+	for i := 0; i < mismatchesBatchSize+3; i++ {
+		chunk := int64(2)
+		if i == 0 {
+			chunk = 1
+		}
+		pk, err := unmarshalChunkPK(mustLastPKFromRow(t, td, fmt.Sprintf("%d|a|x", i)))
+		require.NoError(t, err)
+		keys = append(keys, &repairKey{ids: []int64{int64(i)}, chunk: chunk, lastPK: pk})
+	}
+	lowerPK := mustLastPKFromRow(t, td, "0|z|x")
+	fields := sqltypes.MakeTestFields("chunk_id|state|lower_pk|upper_pk|lastpk|report", "int64|varbinary|varbinary|varbinary|varbinary|varbinary")
+	dbClient := binlogplayer.NewMockDBClient(t)
+	dbClient.ExpectRequestRE("select chunk_id as chunk_id, state as state, lower_pk as lower_pk.*", sqltypes.MakeTestResult(fields,
+		"1|completed||||",
+		fmt.Sprintf("2|completed|%s|||", lowerPK),
+	), nil)
+
+	batches, err := td.getRepairBatches(dbClient, keys)
+	require.NoError(t, err)
+	dbClient.Wait()
+
+	// The batches don't span chunks, and the rows of a batch are streamed
+	// from the start of its chunk or after the previous batch of the chunk.
+	require.Len(t, batches, 3)
+	assert.Len(t, batches[0].keys, 1)
+	assert.Nil(t, batches[0].lastPK)
+	assert.Len(t, batches[1].keys, mismatchesBatchSize)
+	assert.Equal(t, sqltypes.NewVarChar("z"), sqltypes.MakeRowTrusted(batches[1].lastPK.Fields, batches[1].lastPK.Rows[0])[1])
+	assert.Len(t, batches[2].keys, 2)
+	assert.Equal(t, keys[mismatchesBatchSize].lastPK, batches[2].lastPK)
+}
+
+func TestRepairQuery(t *testing.T) {
+	td := newChunkTestDiffer(nil)
+	td.tablePlan.compareCols = append(td.tablePlan.comparePKs, compareColInfo{colIndex: 2, colName: "val"})
+	key := &repairKey{pk: []sqltypes.Value{sqltypes.NewInt64(10), sqltypes.NewVarChar("b")}}
+
+	// The columns that are not compared keep their values.
+	assert.Equal(t, "insert into vt_customer.t1(c1, c2, val) values (10, 'B', 'x') on duplicate key update c1 = values(c1), c2 = values(c2), val = values(val)",
+		td.repairQuery(key, chunkTestRow("10|B|x")))
+	assert.Equal(t, "delete from vt_customer.t1 where (c1 = 10 and c2 = 'b')", td.repairQuery(key, nil))
+}
+
+func mustLastPKFromRow(t *testing.T, td *tableDiffer, row string) []byte {
+	pk, err := td.lastPKFromRow(chunkTestRow(row))
+	require.NoError(t, err)
+	return pk
+}
@@ -35,7 +35,10 @@ const (
 	sqlVDiffSummary = `select vd.state as vdiff_state, vd.last_error as last_error, vdt.table_name as table_name,
 						vd.vdiff_uuid as 'uuid', vdt.state as table_state, vdt.table_rows as table_rows,
 						vd.started_at as started_at, vdt.rows_compared as rows_compared, vd.completed_at as completed_at,
-						IF(vdt.mismatch = 1, 1, 0) as has_mismatch, vdt.report as report
+						IF(vdt.mismatch = 1, 1, 0) as has_mismatch, vdt.report as report,
+						(select count(*) from _vt.vdiff_chunk as vdc where vdc.vdiff_id = vd.id and vdc.table_name = vdt.table_name) as chunks,
+						(select count(*) from _vt.vdiff_chunk as vdc where vdc.vdiff_id = vd.id and vdc.table_name = vdt.table_name
+						and vdc.state = 'completed') as chunks_completed
 						from _vt.vdiff as vd left join _vt.vdiff_table as vdt on (vd.id = vdt.vdiff_id)
 						where vd.id = %a`
 	// sqlUpdateVDiffState has a penultimate placeholder for any additional columns you want to update, e.g. `, foo = 1`
@@ -63,4 +66,23 @@ const (
 	sqlUpdateTableMismatch       = "update _vt.vdiff_table set mismatch = true where vdiff_id = %a and table_name = %a"
 
 	sqlGetIncompleteTables = "select table_name as table_name from _vt.vdiff_table where vdiff_id = %a and state != 'completed'"
+
+	sqlNewVDiffChunk  = "insert into _vt.vdiff_chunk(vdiff_id, table_name, chunk_id, state, lower_pk, upper_pk) values(%a, %a, %a, 'pending', %a, %a)"
+	sqlGetVDiffChunks = `select chunk_id as chunk_id, state as state, lower_pk as lower_pk, upper_pk as upper_pk, lastpk as lastpk,
+						report as report from _vt.vdiff_chunk where vdiff_id = %a and table_name = %a order by chunk_id`
+	sqlGetVDiffChunk = `select lastpk as lastpk, mismatch as mismatch, report as report from _vt.vdiff_chunk
+						where vdiff_id = %a and table_name = %a and chunk_id = %a`
+	sqlUpdateChunkProgress   = "update _vt.vdiff_chunk set rows_compared = %a, lastpk = %a, report = %a where vdiff_id = %a and table_name = %a and chunk_id = %a"
+	sqlUpdateChunkNoProgress = "update _vt.vdiff_chunk set rows_compared = %a, report = %a where vdiff_id = %a and table_name = %a and chunk_id = %a"
+	sqlUpdateChunkState      = "update _vt.vdiff_chunk set state = %a, mismatch = %a where vdiff_id = %a and table_name = %a and chunk_id = %a"
+	sqlDeleteVDiffChunks     = "delete from _vt.vdiff_chunk where vdiff_id = %a"
+
+	sqlNewVDiffMismatches     = "insert into _vt.vdiff_mismatch(vdiff_id, table_name, chunk_id, type, pk) values %s"
+	sqlNewVDiffMismatchValues = "(%a, %a, %a, %a, %a)"
+	sqlDeleteVDiffMismatches  = "delete from _vt.vdiff_mismatch where vdiff_id = %a"
+	sqlGetVDiffMismatchTables = `select table_name as table_name, count(*) as mismatches from _vt.vdiff_mismatch
+								where vdiff_id = %a group by table_name order by table_name`
+	sqlGetVDiffMismatches = `select id as id, chunk_id as chunk_id, type as type, pk as pk from _vt.vdiff_mismatch
+							where vdiff_id = %a and table_name = %a order by id`
+	sqlDeleteVDiffMismatchesByID = "delete from _vt.vdiff_mismatch where vdiff_id = %a and id in %a"
 )
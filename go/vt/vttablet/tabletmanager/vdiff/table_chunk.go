@@ -0,0 +1,473 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vdiff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/encoding/prototext"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+/*
+	When the ChunkRows option is set, a table is split into chunks: ranges of
+	primary keys of about ChunkRows rows each, computed from the target table
+	when the table is first diffed and saved in _vt.vdiff_chunk. Each chunk is
+	diffed with its own consistent snapshot of the source and the target,
+	taken like the snapshot of a whole table, so up to MaxParallelChunks chunks
+	are compared at the same time once their snapshots are taken. A chunk saves
+	its progress in its row, and a resumed or retried vdiff only diffs the
+	chunks that are not completed, from where they left off, and the last chunk
+	to compare the rows inserted since. The report of the table is the sum of
+	the reports of its chunks.
+*/
+
+// tableChunk is a range of primary keys of a table, diffed separately: the
+// rows after lowerPK and up to upperPK, inclusive.
+type tableChunk struct {
+	id    int64
+	state VDiffState
+	// lowerPK is nil for the first chunk, and upperPK for the last one.
+	lowerPK *querypb.QueryResult
+	upperPK *querypb.QueryResult
+	// lastPK is the last row compared, if the chunk was diffed already.
+	lastPK *querypb.QueryResult
+	report *DiffReport
+
+	// upper are the values of upperPK.
+	upper []sqltypes.Value
+}
+
+func unmarshalChunkPK(buf []byte) (*querypb.QueryResult, error) {
+	if len(buf) == 0 {
+		return nil, nil
+	}
+	var pk querypb.QueryResult
+	if err := prototext.Unmarshal(buf, &pk); err != nil {
+		return nil, err
+	}
+	return &pk, nil
+}
+
+func marshalChunkPK(pk *querypb.QueryResult) (*querypb.BindVariable, error) {
+	if pk == nil {
+		return sqltypes.NullBindVariable, nil
+	}
+	buf, err := prototext.Marshal(pk)
+	if err != nil {
+		return nil, err
+	}
+	return sqltypes.StringBindVariable(string(buf)), nil
+}
+
+// getChunks returns the chunks of the table, in order.
+func (td *tableDiffer) getChunks(dbClient binlogplayer.DBClient) ([]*tableChunk, error) {
+	query, err := sqlparser.ParseAndBind(sqlGetVDiffChunks,
+		sqltypes.Int64BindVariable(td.wd.ct.id),
+		sqltypes.StringBindVariable(td.table.Name),
+	)
+	if err != nil {
+		return nil, err
+	}
+	qr, err := dbClient.ExecuteFetch(query, -1)
+	if err != nil {
+		return nil, err
+	}
+	chunks := make([]*tableChunk, 0, len(qr.Rows))
+	for _, row := range qr.Named().Rows {
+		chunk := &tableChunk{
+			id:     row.AsInt64("chunk_id", 0),
+			state:  VDiffState(strings.ToLower(row.AsString("state", ""))),
+			report: &DiffReport{},
+		}
+		if chunk.lowerPK, err = unmarshalChunkPK(row.AsBytes("lower_pk", nil)); err != nil {
+			return nil, err
+		}
+		if chunk.upperPK, err = unmarshalChunkPK(row.AsBytes("upper_pk", nil)); err != nil {
+			return nil, err
+		}
+		if chunk.lastPK, err = unmarshalChunkPK(row.AsBytes("lastpk", nil)); err != nil {
+			return nil, err
+		}
+		if chunk.upperPK != nil {
+			if len(chunk.upperPK.Rows) != 1 {
+				return nil, fmt.Errorf("invalid upper bound of chunk %d of vdiff table %s", chunk.id, td.table.Name)
+			}
+			chunk.upper = sqltypes.MakeRowTrusted(chunk.upperPK.Fields, chunk.upperPK.Rows[0])
+		}
+		if rpt := row.AsBytes("report", nil); json.Valid(rpt) {
+			if err := json.Unmarshal(rpt, chunk.report); err != nil {
+				return nil, err
+			}
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
+
+// createChunks splits the table into chunks of about chunkRows rows, and
+// saves them. The bounds of the chunks are the primary keys of every
+// chunkRows rows of the target table.
+func (td *tableDiffer) createChunks(dbClient binlogplayer.DBClient, chunkRows int64) ([]*tableChunk, error) {
+	var bounds []*querypb.QueryResult
+	var lower *querypb.QueryResult
+	for {
+		qr, err := dbClient.ExecuteFetch(td.chunkBoundQuery(lower, chunkRows), 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(qr.Rows) == 0 {
+			break
+		}
+		lower = sqltypes.ResultToProto3(qr)
+		bounds = append(bounds, lower)
+	}
+	// The bounds are the upper bounds of all the chunks but the last one.
+	bounds = append(bounds, nil)
+	var lowerPK *querypb.QueryResult
+	for i, upperPK := range bounds {
+		lowerBV, err := marshalChunkPK(lowerPK)
+		if err != nil {
+			return nil, err
+		}
+		upperBV, err := marshalChunkPK(upperPK)
+		if err != nil {
+			return nil, err
+		}
+		query, err := sqlparser.ParseAndBind(sqlNewVDiffChunk,
+			sqltypes.Int64BindVariable(td.wd.ct.id),
+			sqltypes.StringBindVariable(td.table.Name),
+			sqltypes.Int64BindVariable(int64(i+1)),
+			lowerBV,
+			upperBV,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := dbClient.ExecuteFetch(query, 1); err != nil {
+			return nil, err
+		}
+		lowerPK = upperPK
+	}
+	log.Infof("Split table %s into %d chunks of %d rows for vdiff %s", td.table.Name, len(bounds), chunkRows, td.wd.ct.uuid)
+	return td.getChunks(dbClient)
+}
+
+// chunkBoundQuery returns the query of the primary key of the last row of
+// the chunk of chunkRows rows that starts after lower.
+func (td *tableDiffer) chunkBoundQuery(lower *querypb.QueryResult, chunkRows int64) string {
+	pkCols := td.table.PrimaryKeyColumns
+	writeCols := func(buf *sqlparser.TrackedBuffer) {
+		for i, col := range pkCols {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.Myprintf("%v", sqlparser.NewIdentifierCI(col))
+		}
+	}
+	buf := sqlparser.NewTrackedBuffer(nil)
+	buf.WriteString("select ")
+	writeCols(buf)
+	buf.Myprintf(" from %v.%v", sqlparser.NewIdentifierCS(td.wd.ct.vde.dbName), sqlparser.NewIdentifierCS(td.table.Name))
+	if lower != nil {
+		buf.WriteString(" where (")
+		writeCols(buf)
+		buf.WriteString(") > (")
+		for i, val := range sqltypes.MakeRowTrusted(lower.Fields, lower.Rows[0]) {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			val.EncodeSQL(buf)
+		}
+		buf.WriteString(")")
+	}
+	buf.WriteString(" order by ")
+	writeCols(buf)
+	buf.Myprintf(" limit 1 offset %d", chunkRows-1)
+	return buf.String()
+}
+
+// forChunk returns a differ of a chunk of the table.
+func (td *tableDiffer) forChunk(chunk *tableChunk) *tableDiffer {
+	lastPK := chunk.lastPK
+	if lastPK == nil {
+		lastPK = chunk.lowerPK
+	}
+	return &tableDiffer{
+		wd:          td.wd,
+		tablePlan:   td.tablePlan,
+		sourceQuery: td.sourceQuery,
+		table:       td.table,
+		lastPK:      lastPK,
+		chunk:       chunk,
+	}
+}
+
+// pastChunk returns true if the row is after the upper bound of the chunk.
+func (td *tableDiffer) pastChunk(row []sqltypes.Value) (bool, error) {
+	c, err := td.comparePK(row, td.chunk.upper)
+	return c > 0, err
+}
+
+// comparePK compares the primary key of the row with the values of a
+// primary key, in the order of the diff.
+func (td *tableDiffer) comparePK(row []sqltypes.Value, pk []sqltypes.Value) (int, error) {
+	for i, col := range td.tablePlan.comparePKs {
+		collationID := col.collation
+		if collationID == collations.Unknown {
+			collationID = collations.CollationBinaryID
+		}
+		c, err := evalengine.NullsafeCompare(row[col.colIndex], pk[i], collationID)
+		if err != nil {
+			return 0, err
+		}
+		if c != 0 {
+			return c, nil
+		}
+	}
+	return 0, nil
+}
+
+// getChunkState returns the saved progress of the diff of the chunk.
+func (td *tableDiffer) getChunkState(dbClient binlogplayer.DBClient) (sqltypes.RowNamedValues, error) {
+	query, err := sqlparser.ParseAndBind(sqlGetVDiffChunk,
+		sqltypes.Int64BindVariable(td.wd.ct.id),
+		sqltypes.StringBindVariable(td.table.Name),
+		sqltypes.Int64BindVariable(td.chunk.id),
+	)
+	if err != nil {
+		return nil, err
+	}
+	qr, err := dbClient.ExecuteFetch(query, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(qr.Rows) != 1 {
+		return nil, fmt.Errorf("no state found for chunk %d of vdiff table %s for vdiff_id %d on tablet %v",
+			td.chunk.id, td.table.Name, td.wd.ct.id, td.wd.ct.vde.thisTablet.Alias)
+	}
+	return qr.Named().Row(), nil
+}
+
+func (td *tableDiffer) updateChunkProgress(dbClient binlogplayer.DBClient, dr *DiffReport, lastRow []sqltypes.Value) error {
+	rpt, err := json.Marshal(dr)
+	if err != nil {
+		return err
+	}
+	var query string
+	if lastRow != nil {
+		lastPK, err := td.lastPKFromRow(lastRow)
+		if err != nil {
+			return err
+		}
+		query, err = sqlparser.ParseAndBind(sqlUpdateChunkProgress,
+			sqltypes.Int64BindVariable(dr.ProcessedRows),
+			sqltypes.StringBindVariable(string(lastPK)),
+			sqltypes.StringBindVariable(string(rpt)),
+			sqltypes.Int64BindVariable(td.wd.ct.id),
+			sqltypes.StringBindVariable(td.table.Name),
+			sqltypes.Int64BindVariable(td.chunk.id),
+		)
+		if err != nil {
+			return err
+		}
+	} else {
+		query, err = sqlparser.ParseAndBind(sqlUpdateChunkNoProgress,
+			sqltypes.Int64BindVariable(dr.ProcessedRows),
+			sqltypes.StringBindVariable(string(rpt)),
+			sqltypes.Int64BindVariable(td.wd.ct.id),
+			sqltypes.StringBindVariable(td.table.Name),
+			sqltypes.Int64BindVariable(td.chunk.id),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	if len(td.mismatches) > 0 {
+		if err := dbClient.Begin(); err != nil {
+			return err
+		}
+		defer dbClient.Rollback()
+		if err := td.flushMismatches(dbClient); err != nil {
+			return err
+		}
+	}
+	if _, err := dbClient.ExecuteFetch(query, 1); err != nil {
+		return err
+	}
+	if len(td.mismatches) > 0 {
+		if err := dbClient.Commit(); err != nil {
+			return err
+		}
+		td.mismatches = nil
+	}
+	return nil
+}
+
+func (td *tableDiffer) updateChunkState(dbClient binlogplayer.DBClient, state VDiffState, mismatch bool) error {
+	query, err := sqlparser.ParseAndBind(sqlUpdateChunkState,
+		sqltypes.StringBindVariable(string(state)),
+		sqltypes.BoolBindVariable(mismatch),
+		sqltypes.Int64BindVariable(td.wd.ct.id),
+		sqltypes.StringBindVariable(td.table.Name),
+		sqltypes.Int64BindVariable(td.chunk.id),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = dbClient.ExecuteFetch(query, 1)
+	return err
+}
+
+// updateChunkedTableProgress saves the sum of the reports of the chunks of
+// the table as its progress.
+func (td *tableDiffer) updateChunkedTableProgress(dbClient binlogplayer.DBClient) error {
+	chunks, err := td.getChunks(dbClient)
+	if err != nil {
+		return err
+	}
+	dr := mergeChunkReports(td.table.Name, chunks, td.wd.opts.CoreOptions.MaxExtraRowsToCompare)
+	rpt, err := json.Marshal(dr)
+	if err != nil {
+		return err
+	}
+	query, err := sqlparser.ParseAndBind(sqlUpdateTableNoProgress,
+		sqltypes.Int64BindVariable(dr.ProcessedRows),
+		sqltypes.StringBindVariable(string(rpt)),
+		sqltypes.Int64BindVariable(td.wd.ct.id),
+		sqltypes.StringBindVariable(td.table.Name),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = dbClient.ExecuteFetch(query, 1)
+	return err
+}
+
+// diffTableChunks diffs the chunks of a table, and returns the report of the
+// table.
+func (wd *workflowDiffer) diffTableChunks(ctx context.Context, dbClient binlogplayer.DBClient, td *tableDiffer) (*DiffReport, error) {
+	chunks, err := td.getChunks(dbClient)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 {
+		if chunks, err = td.createChunks(dbClient, wd.opts.CoreOptions.ChunkRows); err != nil {
+			return nil, err
+		}
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(int(max(wd.opts.CoreOptions.MaxParallelChunks, 1)))
+	for i, chunk := range chunks {
+		// The last chunk is diffed again when the vdiff is resumed, to
+		// compare the rows inserted since it was diffed.
+		if chunk.state == CompletedState && i != len(chunks)-1 {
+			continue
+		}
+		chunk := chunk
+		g.Go(func() error {
+			return wd.diffChunk(gctx, td.forChunk(chunk))
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	if chunks, err = td.getChunks(dbClient); err != nil {
+		return nil, err
+	}
+	return mergeChunkReports(td.table.Name, chunks, wd.opts.CoreOptions.MaxExtraRowsToCompare), nil
+}
+
+func (wd *workflowDiffer) diffChunk(ctx context.Context, td *tableDiffer) error {
+	// The streams of the chunk would otherwise read the rest of the table.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	dbClient := wd.ct.dbClientFactory()
+	if err := dbClient.Connect(); err != nil {
+		return err
+	}
+	defer dbClient.Close()
+
+	log.Infof("Starting diff of chunk %d of table %s for vdiff %s", td.chunk.id, td.table.Name, wd.ct.uuid)
+	if err := td.updateChunkState(dbClient, StartedState, false); err != nil {
+		return err
+	}
+	dr, err := func() (*DiffReport, error) {
+		if err := td.initialize(ctx); err != nil {
+			return nil, err
+		}
+		return td.diff(ctx, wd.opts.CoreOptions.MaxRows, wd.opts.ReportOptions.DebugQuery, wd.opts.ReportOptions.OnlyPks, wd.opts.CoreOptions.MaxExtraRowsToCompare)
+	}()
+	if err != nil {
+		log.Errorf("Encountered an error diffing chunk %d of table %s for vdiff %s: %v", td.chunk.id, td.table.Name, wd.ct.uuid, err)
+		if err := td.updateChunkState(dbClient, ErrorState, false); err != nil {
+			log.Errorf("Failed to update the state of chunk %d of table %s for vdiff %s: %v", td.chunk.id, td.table.Name, wd.ct.uuid, err)
+		}
+		return err
+	}
+	mismatch := dr.MismatchedRows > 0 || dr.ExtraRowsSource > 0 || dr.ExtraRowsTarget > 0
+	if err := td.updateChunkState(dbClient, CompletedState, mismatch); err != nil {
+		return err
+	}
+	log.Infof("Completed diff of chunk %d of table %s for vdiff %s", td.chunk.id, td.table.Name, wd.ct.uuid)
+	return td.updateChunkedTableProgress(dbClient)
+}
+
+// mergeChunkReports returns the report of a table diffed in chunks. The
+// extra rows are kept for reconcileExtraRows.
+func mergeChunkReports(table string, chunks []*tableChunk, maxExtraRowsToCompare int64) *DiffReport {
+	dr := &DiffReport{TableName: table}
+	for _, chunk := range chunks {
+		cr := chunk.report
+		dr.ProcessedRows += cr.ProcessedRows
+		dr.MatchingRows += cr.MatchingRows
+		dr.MismatchedRows += cr.MismatchedRows
+		dr.ExtraRowsSource += cr.ExtraRowsSource
+		dr.ExtraRowsTarget += cr.ExtraRowsTarget
+		for _, diff := range cr.ExtraRowsSourceDiffs {
+			if int64(len(dr.ExtraRowsSourceDiffs)) < maxExtraRowsToCompare {
+				dr.ExtraRowsSourceDiffs = append(dr.ExtraRowsSourceDiffs, diff)
+			}
+		}
+		for _, diff := range cr.ExtraRowsTargetDiffs {
+			if int64(len(dr.ExtraRowsTargetDiffs)) < maxExtraRowsToCompare {
+				dr.ExtraRowsTargetDiffs = append(dr.ExtraRowsTargetDiffs, diff)
+			}
+		}
+		for _, diff := range cr.MismatchedRowsDiffs {
+			if len(dr.MismatchedRowsDiffs) < maxVDiffReportSampleRows {
+				dr.MismatchedRowsDiffs = append(dr.MismatchedRowsDiffs, diff)
+			}
+		}
+	}
+	return dr
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vdiff
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+func newChunkTestDiffer(opts *tabletmanagerdatapb.VDiffOptions) *tableDiffer {
+	fields := sqltypes.MakeTestFields("c1|c2|val", "int64|varchar|varchar")
+	wd := &workflowDiffer{
+		ct: &controller{
+			id:   1,
+			uuid: "7b04699f-f5e9-11e9-bf88-9cb6d089e1c3",
+			vde:  &Engine{dbName: "vt_customer", thisTablet: &topodatapb.Tablet{Shard: "-80"}},
+		},
+		opts: opts,
+	}
+	return &tableDiffer{
+		wd: wd,
+		table: &tabletmanagerdatapb.TableDefinition{
+			Name:              "t1",
+			Columns:           []string{"c1", "c2", "val"},
+			PrimaryKeyColumns: []string{"c1", "c2"},
+		},
+		tablePlan: &tablePlan{
+			table: &tabletmanagerdatapb.TableDefinition{Name: "t1", Fields: fields},
+			comparePKs: []compareColInfo{
+				{colIndex: 0, isPK: true, colName: "c1"},
+				{colIndex: 1, collation: collations.CollationUtf8mb4ID, isPK: true, colName: "c2"},
+			},
+			pkCols: []int{0, 1},
+		},
+	}
+}
+
+func chunkTestRow(values string) []sqltypes.Value {
+	fields := sqltypes.MakeTestFields("c1|c2|val", "int64|varchar|varchar")
+	return sqltypes.MakeTestResult(fields, values).Rows[0]
+}
+
+func TestChunkBoundQuery(t *testing.T) {
+	td := newChunkTestDiffer(nil)
+	assert.Equal(t, "select c1, c2 from vt_customer.t1 order by c1, c2 limit 1 offset 999", td.chunkBoundQuery(nil, 1000))
+
+	lower := sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields("c1|c2", "int64|varchar"), "10|abc"))
+	assert.Equal(t, "select c1, c2 from vt_customer.t1 where (c1, c2) > (10, 'abc') order by c1, c2 limit 1 offset 999", td.chunkBoundQuery(lower, 1000))
+}
+
+func TestPastChunk(t *testing.T) {
+	td := newChunkTestDiffer(nil)
+	td.chunk = &tableChunk{upper: []sqltypes.Value{sqltypes.NewInt64(10), sqltypes.NewVarChar("b")}}
+
+	testcases := []struct {
+		row  string
+		past bool
+	}{
+		{row: "9|z|x", past: false},
+		{row: "10|a|x", past: false},
+		{row: "10|b|x", past: false},
+		// The upper bound is compared with the collation of the column.
+		{row: "10|B|x", past: false},
+		{row: "10|c|x", past: true},
+		{row: "11|a|x", past: true},
+	}
+	for _, tcase := range testcases {
+		past, err := td.pastChunk(chunkTestRow(tcase.row))
+		require.NoError(t, err)
+		assert.Equal(t, tcase.past, past, tcase.row)
+	}
+}
+
+func TestMergeChunkReports(t *testing.T) {
+	chunks := []*tableChunk{{
+		report: &DiffReport{
+			ProcessedRows:        10,
+			MatchingRows:         7,
+			MismatchedRows:       1,
+			ExtraRowsSource:      2,
+			ExtraRowsSourceDiffs: []*RowDiff{{}, {}},
+			MismatchedRowsDiffs:  []*DiffMismatch{{}},
+		},
+	}, {
+		report: &DiffReport{},
+	}, {
+		report: &DiffReport{
+			ProcessedRows:        5,
+			MatchingRows:         4,
+			ExtraRowsSource:      1,
+			ExtraRowsSourceDiffs: []*RowDiff{{}},
+			ExtraRowsTarget:      1,
+			ExtraRowsTargetDiffs: []*RowDiff{{}},
+		},
+	}}
+	dr := mergeChunkReports("t1", chunks, 2)
+	assert.Equal(t, "t1", dr.TableName)
+	assert.EqualValues(t, 15, dr.ProcessedRows)
+	assert.EqualValues(t, 11, dr.MatchingRows)
+	assert.EqualValues(t, 1, dr.MismatchedRows)
+	assert.EqualValues(t, 3, dr.ExtraRowsSource)
+	assert.EqualValues(t, 1, dr.ExtraRowsTarget)
+	// The extra rows to reconcile are capped.
+	assert.Len(t, dr.ExtraRowsSourceDiffs, 2)
+	assert.Len(t, dr.ExtraRowsTargetDiffs, 1)
+	assert.Len(t, dr.MismatchedRowsDiffs, 1)
+}
+
+func TestRecordMismatches(t *testing.T) {
+	td := newChunkTestDiffer(&tabletmanagerdatapb.VDiffOptions{ReportOptions: &tabletmanagerdatapb.VDiffReportOptions{}})
+	require.NoError(t, td.recordMismatch(mismatchRow, chunkTestRow("1|a|x")))
+	assert.Empty(t, td.mismatches)
+
+	dir := t.TempDir()
+	td.wd.opts.ReportOptions.MismatchesDir = dir
+	require.NoError(t, td.recordMismatch(mismatchRow, chunkTestRow("1|a|x")))
+	require.NoError(t, td.recordMismatch(mismatchExtraTarget, chunkTestRow("2|null|y")))
+	require.Len(t, td.mismatches, 2)
+	assert.Equal(t, mismatchExtraTarget, td.mismatches[1].typ)
+
+	pk, err := unmarshalChunkPK(td.mismatches[0].pk)
+	require.NoError(t, err)
+	assert.Equal(t, []sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewVarChar("a")}, sqltypes.MakeRowTrusted(pk.Fields, pk.Rows[0]))
+
+	// The mismatches are exported without a dbClient when only the dir is set.
+	require.NoError(t, td.flushMismatches(nil))
+	require.NoError(t, td.flushMismatches(nil))
+	buf, err := os.ReadFile(td.mismatchesFile(dir))
+	require.NoError(t, err)
+	line := `{"type":"mismatch","pk":{"c1":1,"c2":"a"}}` + "\n" + `{"type":"extra_target","pk":{"c1":2,"c2":null}}` + "\n"
+	assert.Equal(t, line+line, string(buf))
+	assert.Equal(t, dir+"/7b04699f-f5e9-11e9-bf88-9cb6d089e1c3.-80.t1.jsonl", td.mismatchesFile(dir))
+}
+
+// chunkTestDBClient answers the queries of the differs of chunks, which can
+// run in any order.
+type chunkTestDBClient struct {
+	binlogplayer.DBClient
+	mu      sync.Mutex
+	queries []string
+}
+
+func (dc *chunkTestDBClient) Connect() error { return nil }
+func (dc *chunkTestDBClient) Close()         {}
+
+func (dc *chunkTestDBClient) ExecuteFetch(query string, maxrows int) (*sqltypes.Result, error) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.queries = append(dc.queries, query)
+	if strings.HasPrefix(query, "select lastpk") {
+		return sqltypes.MakeTestResult(sqltypes.MakeTestFields("lastpk|mismatch|report", "varbinary|int64|json"), "|0|{}"), nil
+	}
+	return &sqltypes.Result{RowsAffected: 1}, nil
+}
+
+func TestDiffChunksConcurrently(t *testing.T) {
+	td := newChunkTestDiffer(&tabletmanagerdatapb.VDiffOptions{ReportOptions: &tabletmanagerdatapb.VDiffReportOptions{}})
+	td.tablePlan.sourceQuery = "select c1, c2, val from t1 order by c1 asc, c2 asc"
+	td.tablePlan.targetQuery = td.tablePlan.sourceQuery
+	td.tablePlan.compareCols = []compareColInfo{
+		{colIndex: 0, isPK: true, colName: "c1"},
+		{colIndex: 1, collation: collations.CollationUtf8mb4ID, isPK: true, colName: "c2"},
+		{colIndex: 2, collation: collations.CollationUtf8mb4ID, colName: "val"},
+	}
+	dbClient := &chunkTestDBClient{}
+	td.wd.ct.dbClientFactory = func() binlogplayer.DBClient { return dbClient }
+	td.wd.ct.sources = map[string]*migrationSource{"-80": {shardStreamer: &shardStreamer{shard: "-80"}}}
+
+	fields := sqltypes.MakeTestFields("c1|c2|val", "int64|varchar|varchar")
+	upperPK := sqltypes.MakeTestResult(sqltypes.MakeTestFields("c1|c2", "int64|varchar"), "2|b")
+	chunks := []*tableChunk{{
+		id:      1,
+		upperPK: sqltypes.ResultToProto3(upperPK),
+		upper:   upperPK.Rows[0],
+	}, {
+		id:      2,
+		lowerPK: sqltypes.ResultToProto3(upperPK),
+	}}
+	// The rows of each chunk, on the source and on the target. The second
+	// chunk has a mismatched row.
+	rows := [][2][]string{
+		{{"1|a|x", "2|b|y"}, {"1|a|x", "2|b|y"}},
+		{{"3|c|z", "4|d|w", "5|e|v"}, {"3|c|z", "4|d|mismatch", "5|e|v"}},
+	}
+
+	// As in initialize, the streams of each chunk are started, and its row
+	// sorters are set up, one chunk at a time.
+	differs := make([]*tableDiffer, len(chunks))
+	streamers := make([][2]*shardStreamer, len(chunks))
+	for i, chunk := range chunks {
+		differs[i] = td.forChunk(chunk)
+		source := &shardStreamer{shard: "-80", result: make(chan *sqltypes.Result, 1)}
+		td.wd.ct.sources["-80"].shardStreamer = source
+		differs[i].targetShardStreamer = &shardStreamer{shard: "-80", result: make(chan *sqltypes.Result, 1)}
+		differs[i].setupRowSorters()
+		streamers[i] = [2]*shardStreamer{source, differs[i].targetShardStreamer}
+	}
+	require.NotSame(t, differs[0].targetShardStreamer, differs[1].targetShardStreamer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	reports := make([]*DiffReport, len(chunks))
+	errs := make([]error, len(chunks))
+	for i := range differs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reports[i], errs[i] = differs[i].diff(ctx, 100, false, false, 10)
+		}(i)
+	}
+	// The streams of both chunks are fed at the same time, a row at a time.
+	for r := 0; r < 5; r++ {
+		for i := range chunks {
+			for j, streamer := range streamers[i] {
+				switch {
+				case r == 0:
+					streamer.result <- &sqltypes.Result{Fields: fields}
+				case r <= len(rows[i][j]):
+					streamer.result <- &sqltypes.Result{Rows: sqltypes.MakeTestResult(fields, rows[i][j][r-1]).Rows}
+				case r == len(rows[i][j])+1:
+					close(streamer.result)
+				}
+			}
+		}
+	}
+	wg.Wait()
+
+	for i := range chunks {
+		require.NoError(t, errs[i])
+	}
+	assert.EqualValues(t, 2, reports[0].ProcessedRows)
+	assert.EqualValues(t, 2, reports[0].MatchingRows)
+	assert.EqualValues(t, 0, reports[0].MismatchedRows)
+	assert.EqualValues(t, 3, reports[1].ProcessedRows)
+	assert.EqualValues(t, 2, reports[1].MatchingRows)
+	assert.EqualValues(t, 1, reports[1].MismatchedRows)
+	assert.Zero(t, reports[0].ExtraRowsSource+reports[0].ExtraRowsTarget+reports[1].ExtraRowsSource+reports[1].ExtraRowsTarget)
+
+	// The progress of each chunk is saved.
+	var progress int
+	for _, query := range dbClient.queries {
+		if strings.HasPrefix(query, "update _vt.vdiff_chunk set rows_compared") {
+			progress++
+		}
+	}
+	assert.Equal(t, len(chunks), progress)
+}
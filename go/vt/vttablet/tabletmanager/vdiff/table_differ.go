@@ -72,6 +72,18 @@ type tableDiffer struct {
 	sourceQuery string
 	table       *tabletmanagerdatapb.TableDefinition
 	lastPK      *querypb.QueryResult
+
+	// targetShardStreamer streams the rows of this differ from the target
+	// tablet. The chunks of a table are diffed in parallel, so each differ
+	// has its own.
+	targetShardStreamer *shardStreamer
+
+	// chunk is the range of primary keys this differ compares, if the
+	// table is diffed in chunks.
+	chunk *tableChunk
+	// mismatches are the mismatched and extra rows found since the progress
+	// was last saved, when they are recorded.
+	mismatches []*mismatchedRow
 }
 
 func newTableDiffer(wd *workflowDiffer, table *tabletmanagerdatapb.TableDefinition, sourceQuery string) *tableDiffer {
@@ -80,6 +92,30 @@ func newTableDiffer(wd *workflowDiffer, table *tabletmanagerdatapb.TableDefiniti
 
 // initialize
 func (td *tableDiffer) initialize(ctx context.Context) error {
+	return td.pauseTargetStreams(ctx, func(ctx context.Context, dbClient binlogplayer.DBClient) error {
+		if err := td.selectTablets(ctx); err != nil {
+			return err
+		}
+		if err := td.syncSourceStreams(ctx); err != nil {
+			return err
+		}
+		if err := td.startSourceDataStreams(ctx); err != nil {
+			return err
+		}
+		if err := td.syncTargetStreams(ctx); err != nil {
+			return err
+		}
+		if err := td.startTargetDataStream(ctx); err != nil {
+			return err
+		}
+		td.setupRowSorters()
+		return nil
+	})
+}
+
+// pauseTargetStreams locks the target keyspace and stops the streams of the
+// workflow on this tablet while cb runs, and then restarts them.
+func (td *tableDiffer) pauseTargetStreams(ctx context.Context, cb func(ctx context.Context, dbClient binlogplayer.DBClient) error) error {
 	vdiffEngine := td.wd.ct.vde
 	vdiffEngine.snapshotMu.Lock()
 	defer vdiffEngine.snapshotMu.Unlock()
@@ -121,23 +157,7 @@ func (td *tableDiffer) initialize(ctx context.Context) error {
 		}
 	}()
 
-	if err := td.selectTablets(ctx); err != nil {
-		return err
-	}
-	if err := td.syncSourceStreams(ctx); err != nil {
-		return err
-	}
-	if err := td.startSourceDataStreams(ctx); err != nil {
-		return err
-	}
-	if err := td.syncTargetStreams(ctx); err != nil {
-		return err
-	}
-	if err := td.startTargetDataStream(ctx); err != nil {
-		return err
-	}
-	td.setupRowSorters()
-	return nil
+	return cb(ctx, dbClient)
 }
 
 func (td *tableDiffer) stopTargetVReplicationStreams(ctx context.Context, dbClient binlogplayer.DBClient) error {
@@ -241,7 +261,7 @@ func (td *tableDiffer) selectTablets(ctx context.Context) error {
 		if targetErr != nil {
 			return
 		}
-		td.wd.ct.targetShardStreamer = &shardStreamer{
+		td.targetShardStreamer = &shardStreamer{
 			tablet: targetTablet,
 			shard:  targetTablet.Shard,
 		}
@@ -303,23 +323,28 @@ func (td *tableDiffer) syncTargetStreams(ctx context.Context) error {
 }
 
 func (td *tableDiffer) startTargetDataStream(ctx context.Context) error {
-	ct := td.wd.ct
 	gtidch := make(chan string, 1)
-	ct.targetShardStreamer.result = make(chan *sqltypes.Result, 1)
-	go td.streamOneShard(ctx, ct.targetShardStreamer, td.tablePlan.targetQuery, td.lastPK, gtidch)
+	td.targetShardStreamer.result = make(chan *sqltypes.Result, 1)
+	go td.streamOneShard(ctx, td.targetShardStreamer, td.tablePlan.targetQuery, td.lastPK, gtidch)
 	gtid, ok := <-gtidch
 	if !ok {
-		log.Infof("streaming error: %v", ct.targetShardStreamer.err)
-		return ct.targetShardStreamer.err
+		log.Infof("streaming error: %v", td.targetShardStreamer.err)
+		return td.targetShardStreamer.err
 	}
-	ct.targetShardStreamer.snapshotPosition = gtid
+	td.targetShardStreamer.snapshotPosition = gtid
 	return nil
 }
 
 func (td *tableDiffer) startSourceDataStreams(ctx context.Context) error {
 	if err := td.forEachSource(func(source *migrationSource) error {
+		// The chunks of a table are diffed in parallel, each with its own
+		// streams, so the streamer of the previous differ must not be reused.
+		source.shardStreamer = &shardStreamer{
+			tablet: source.tablet,
+			shard:  source.shard,
+			result: make(chan *sqltypes.Result, 1),
+		}
 		gtidch := make(chan string, 1)
-		source.result = make(chan *sqltypes.Result, 1)
 		go td.streamOneShard(ctx, source.shardStreamer, td.tablePlan.sourceQuery, td.lastPK, gtidch)
 
 		gtid, ok := <-gtidch
@@ -425,7 +450,7 @@ func (td *tableDiffer) setupRowSorters() {
 
 	// Create a merge sorter for the target.
 	targets := make(map[string]*shardStreamer)
-	targets[td.wd.ct.targetShardStreamer.shard] = td.wd.ct.targetShardStreamer
+	targets[td.targetShardStreamer.shard] = td.targetShardStreamer
 	td.targetPrimitive = newMergeSorter(targets, td.tablePlan.comparePKs)
 
 	// If there were aggregate expressions, we have to re-aggregate
@@ -445,29 +470,20 @@ func (td *tableDiffer) diff(ctx context.Context, rowsToCompare int64, debug, onl
 		return nil, err
 	}
 	defer dbClient.Close()
+	var err error
 
 	// We need to continue were we left off when appropriate. This can be an
 	// auto-retry on error, or a manual retry via the resume command.
 	// Otherwise the existing state will be empty and we start from scratch.
-	query, err := sqlparser.ParseAndBind(sqlGetVDiffTable,
-		sqltypes.Int64BindVariable(td.wd.ct.id),
-		sqltypes.StringBindVariable(td.table.Name),
-	)
-	if err != nil {
-		return nil, err
+	var curState sqltypes.RowNamedValues
+	if td.chunk != nil {
+		curState, err = td.getChunkState(dbClient)
+	} else {
+		curState, err = td.getTableState(dbClient)
 	}
-	cs, err := dbClient.ExecuteFetch(query, -1)
 	if err != nil {
 		return nil, err
 	}
-	if len(cs.Rows) == 0 {
-		return nil, fmt.Errorf("no state found for vdiff table %s for vdiff_id %d on tablet %v",
-			td.table.Name, td.wd.ct.id, td.wd.ct.vde.thisTablet.Alias)
-	} else if len(cs.Rows) > 1 {
-		return nil, fmt.Errorf("invalid state found for vdiff table %s (multiple records) for vdiff_id %d on tablet %v",
-			td.table.Name, td.wd.ct.id, td.wd.ct.vde.thisTablet.Alias)
-	}
-	curState := cs.Named().Row()
 	mismatch := curState.AsBool("mismatch", false)
 	dr := &DiffReport{}
 	if rpt := curState.AsBytes("report", []byte("{}")); json.Valid(rpt) {
@@ -479,6 +495,10 @@ func (td *tableDiffer) diff(ctx context.Context, rowsToCompare int64, debug, onl
 
 	sourceExecutor := newPrimitiveExecutor(ctx, td.sourcePrimitive, "source")
 	targetExecutor := newPrimitiveExecutor(ctx, td.targetPrimitive, "target")
+	if td.chunk != nil && td.chunk.upperPK != nil {
+		sourceExecutor.past = td.pastChunk
+		targetExecutor.past = td.pastChunk
+	}
 	var sourceRow, lastProcessedRow, targetRow []sqltypes.Value
 	advanceSource := true
 	advanceTarget := true
@@ -540,9 +560,14 @@ func (td *tableDiffer) diff(ctx context.Context, rowsToCompare int64, debug, onl
 				return nil, vterrors.Wrap(err, "unexpected error generating diff")
 			}
 			dr.ExtraRowsTargetDiffs = append(dr.ExtraRowsTargetDiffs, diffRow)
+			if err := td.recordMismatch(mismatchExtraTarget, targetRow); err != nil {
+				return nil, err
+			}
 
 			// drain target, update count
-			count, err := targetExecutor.drain(ctx)
+			count, err := targetExecutor.drain(ctx, func(row []sqltypes.Value) error {
+				return td.recordMismatch(mismatchExtraTarget, row)
+			})
 			if err != nil {
 				return nil, err
 			}
@@ -558,7 +583,12 @@ func (td *tableDiffer) diff(ctx context.Context, rowsToCompare int64, debug, onl
 				return nil, vterrors.Wrap(err, "unexpected error generating diff")
 			}
 			dr.ExtraRowsSourceDiffs = append(dr.ExtraRowsSourceDiffs, diffRow)
-			count, err := sourceExecutor.drain(ctx)
+			if err := td.recordMismatch(mismatchExtraSource, sourceRow); err != nil {
+				return nil, err
+			}
+			count, err := sourceExecutor.drain(ctx, func(row []sqltypes.Value) error {
+				return td.recordMismatch(mismatchExtraSource, row)
+			})
 			if err != nil {
 				return nil, err
 			}
@@ -582,6 +612,9 @@ func (td *tableDiffer) diff(ctx context.Context, rowsToCompare int64, debug, onl
 				}
 				dr.ExtraRowsSourceDiffs = append(dr.ExtraRowsSourceDiffs, diffRow)
 			}
+			if err := td.recordMismatch(mismatchExtraSource, sourceRow); err != nil {
+				return nil, err
+			}
 			dr.ExtraRowsSource++
 			advanceTarget = false
 			continue
@@ -593,6 +626,9 @@ func (td *tableDiffer) diff(ctx context.Context, rowsToCompare int64, debug, onl
 				}
 				dr.ExtraRowsTargetDiffs = append(dr.ExtraRowsTargetDiffs, diffRow)
 			}
+			if err := td.recordMismatch(mismatchExtraTarget, targetRow); err != nil {
+				return nil, err
+			}
 			dr.ExtraRowsTarget++
 			advanceSource = false
 			continue
@@ -617,6 +653,9 @@ func (td *tableDiffer) diff(ctx context.Context, rowsToCompare int64, debug, onl
 				}
 				dr.MismatchedRowsDiffs = append(dr.MismatchedRowsDiffs, &DiffMismatch{Source: sourceDiffRow, Target: targetDiffRow})
 			}
+			if err := td.recordMismatch(mismatchRow, sourceRow); err != nil {
+				return nil, err
+			}
 			dr.MismatchedRows++
 		default:
 			dr.MatchingRows++
@@ -664,6 +703,20 @@ func (td *tableDiffer) updateTableProgress(dbClient binlogplayer.DBClient, dr *D
 	if dr == nil {
 		return fmt.Errorf("cannot update progress with a nil diff report")
 	}
+	if td.chunk != nil {
+		return td.updateChunkProgress(dbClient, dr, lastRow)
+	}
+	if len(td.mismatches) > 0 {
+		// The mismatched rows are saved along with the progress, so that
+		// they are not recorded again when the diff is resumed.
+		if err := dbClient.Begin(); err != nil {
+			return err
+		}
+		defer dbClient.Rollback()
+		if err := td.flushMismatches(dbClient); err != nil {
+			return err
+		}
+	}
 	var lastPK []byte
 	var err error
 	var query string
@@ -701,9 +754,38 @@ func (td *tableDiffer) updateTableProgress(dbClient binlogplayer.DBClient, dr *D
 	if _, err := dbClient.ExecuteFetch(query, 1); err != nil {
 		return err
 	}
+	if len(td.mismatches) > 0 {
+		if err := dbClient.Commit(); err != nil {
+			return err
+		}
+		td.mismatches = nil
+	}
 	return nil
 }
 
+// getTableState returns the saved progress of the diff of the table.
+func (td *tableDiffer) getTableState(dbClient binlogplayer.DBClient) (sqltypes.RowNamedValues, error) {
+	query, err := sqlparser.ParseAndBind(sqlGetVDiffTable,
+		sqltypes.Int64BindVariable(td.wd.ct.id),
+		sqltypes.StringBindVariable(td.table.Name),
+	)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := dbClient.ExecuteFetch(query, -1)
+	if err != nil {
+		return nil, err
+	}
+	if len(cs.Rows) == 0 {
+		return nil, fmt.Errorf("no state found for vdiff table %s for vdiff_id %d on tablet %v",
+			td.table.Name, td.wd.ct.id, td.wd.ct.vde.thisTablet.Alias)
+	} else if len(cs.Rows) > 1 {
+		return nil, fmt.Errorf("invalid state found for vdiff table %s (multiple records) for vdiff_id %d on tablet %v",
+			td.table.Name, td.wd.ct.id, td.wd.ct.vde.thisTablet.Alias)
+	}
+	return cs.Named().Row(), nil
+}
+
 func (td *tableDiffer) updateTableState(ctx context.Context, dbClient binlogplayer.DBClient, state VDiffState) error {
 	query, err := sqlparser.ParseAndBind(sqlUpdateTableState,
 		sqltypes.StringBindVariable(string(state)),
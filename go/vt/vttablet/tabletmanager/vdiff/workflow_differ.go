@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 
 	"google.golang.org/protobuf/encoding/prototext"
 
@@ -49,6 +50,9 @@ type workflowDiffer struct {
 
 	tableDiffers map[string]*tableDiffer // key is table name
 	opts         *tabletmanagerdatapb.VDiffOptions
+
+	// mismatchesMu serializes the writes to the mismatches files.
+	mismatchesMu sync.Mutex
 }
 
 func newWorkflowDiffer(ct *controller, opts *tabletmanagerdatapb.VDiffOptions) (*workflowDiffer, error) {
@@ -146,11 +150,19 @@ func (wd *workflowDiffer) diffTable(ctx context.Context, dbClient binlogplayer.D
 	if err := td.updateTableState(ctx, dbClient, StartedState); err != nil {
 		return err
 	}
-	if err := td.initialize(ctx); err != nil {
-		return err
+	var dr *DiffReport
+	var err error
+	// The tables with aggregates are not sorted by primary key on the
+	// target, so they can't be split into chunks.
+	if wd.opts.CoreOptions.ChunkRows > 0 && len(td.tablePlan.aggregates) == 0 {
+		dr, err = wd.diffTableChunks(ctx, dbClient, td)
+	} else {
+		if err := td.initialize(ctx); err != nil {
+			return err
+		}
+		log.Infof("Table initialization done on table %s for vdiff %s", td.table.Name, wd.ct.uuid)
+		dr, err = td.diff(ctx, wd.opts.CoreOptions.MaxRows, wd.opts.ReportOptions.DebugQuery, wd.opts.ReportOptions.OnlyPks, wd.opts.CoreOptions.MaxExtraRowsToCompare)
 	}
-	log.Infof("Table initialization done on table %s for vdiff %s", td.table.Name, wd.ct.uuid)
-	dr, err := td.diff(ctx, wd.opts.CoreOptions.MaxRows, wd.opts.ReportOptions.DebugQuery, wd.opts.ReportOptions.OnlyPks, wd.opts.CoreOptions.MaxExtraRowsToCompare)
 	if err != nil {
 		log.Errorf("Encountered an error diffing table %s for vdiff %s: %v", td.table.Name, wd.ct.uuid, err)
 		return err
@@ -0,0 +1,231 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vreplication
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"vitess.io/vitess/go/bytes2"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// applier writes the rows streamed by vcopier and vplayer to the target of
+// a workflow. The default applier writes them to the target database with
+// the queries of the table plans; the other appliers write them to a Sink.
+type applier interface {
+	// applyRows writes the rows copied from a table.
+	applyRows(ctx context.Context, tplan *TablePlan, rows []*querypb.Row) (*sqltypes.Result, error)
+	// applyChanges writes the changes of the rows of a table.
+	applyChanges(ctx context.Context, tplan *TablePlan, changes []*binlogdatapb.RowChange) error
+}
+
+// dbApplier is the applier that writes to the target database.
+type dbApplier struct {
+	dbClient  *vdbClient
+	sqlbuffer bytes2.Buffer
+	// onQuery, if set, is called after each query of a change is executed.
+	onQuery func(query string, start time.Time)
+}
+
+func newDBApplier(dbClient *vdbClient, onQuery func(query string, start time.Time)) *dbApplier {
+	return &dbApplier{
+		dbClient: dbClient,
+		onQuery:  onQuery,
+	}
+}
+
+func (da *dbApplier) applyRows(ctx context.Context, tplan *TablePlan, rows []*querypb.Row) (*sqltypes.Result, error) {
+	return tplan.applyBulkInsert(&da.sqlbuffer, rows, func(sql string) (*sqltypes.Result, error) {
+		return da.dbClient.ExecuteWithRetry(ctx, sql)
+	})
+}
+
+func (da *dbApplier) applyChanges(ctx context.Context, tplan *TablePlan, changes []*binlogdatapb.RowChange) error {
+	for _, change := range changes {
+		_, err := tplan.applyChange(change, func(sql string) (*sqltypes.Result, error) {
+			start := time.Now()
+			qr, err := da.dbClient.ExecuteWithRetry(ctx, sql)
+			if da.onQuery != nil {
+				da.onQuery(sql, start)
+			}
+			return qr, err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SinkOp is the operation of a SinkEvent.
+type SinkOp string
+
+const (
+	// SinkOpCopy is a row copied from the source table.
+	SinkOpCopy SinkOp = "copy"
+	// SinkOpInsert is a row inserted in the source table.
+	SinkOpInsert SinkOp = "insert"
+	// SinkOpUpdate is a row updated in the source table.
+	SinkOpUpdate SinkOp = "update"
+	// SinkOpDelete is a row deleted from the source table.
+	SinkOpDelete SinkOp = "delete"
+)
+
+// SinkEvent is a row written to a Sink. The values are those of the fields
+// of the stream, which are the columns selected by the filter of the table.
+type SinkEvent struct {
+	Table  string
+	Op     SinkOp
+	Fields []*querypb.Field
+	// Before is the row before an update or a delete.
+	Before []sqltypes.Value
+	// After is the row after a copy, an insert or an update.
+	After []sqltypes.Value
+}
+
+// Sink is a destination, other than MySQL, for the rows of a workflow.
+//
+// The rows of a batch are written before the position of the stream is saved,
+// so a sink receives each row at least once: the rows written since the last
+// saved position are written again when the stream is restarted. During the
+// copy phase, the changes of rows that are not copied yet are also written,
+// before the rows are copied. The copy phase of a workflow can write to a
+// sink from several goroutines.
+type Sink interface {
+	// Write writes a batch of rows. The batch is either rows copied from a
+	// table or the changes of a row event.
+	Write(ctx context.Context, events []*SinkEvent) error
+	// Close releases the resources of the sink.
+	Close() error
+}
+
+// SinkParams are the parameters a Sink is created with.
+type SinkParams struct {
+	// Workflow is the name of the workflow of the stream.
+	Workflow string
+	// StreamID is the id of the stream in the vreplication table.
+	StreamID int32
+	// Params are the parameters of the sink in the stream settings.
+	Params map[string]string
+}
+
+// SinkFactory creates a Sink.
+type SinkFactory func(params SinkParams) (Sink, error)
+
+var (
+	sinkFactoriesMu sync.Mutex
+	sinkFactories   = make(map[string]SinkFactory)
+)
+
+// RegisterSink registers a Sink type. It panics if the type is already
+// registered. It's meant to be called from init functions.
+func RegisterSink(sinkType string, factory SinkFactory) {
+	sinkFactoriesMu.Lock()
+	defer sinkFactoriesMu.Unlock()
+	if _, ok := sinkFactories[sinkType]; ok {
+		panic(fmt.Sprintf("sink %s is already registered", sinkType))
+	}
+	sinkFactories[sinkType] = factory
+}
+
+// SinkTypes returns the registered Sink types, in order.
+func SinkTypes() []string {
+	sinkFactoriesMu.Lock()
+	defer sinkFactoriesMu.Unlock()
+	types := make([]string, 0, len(sinkFactories))
+	for sinkType := range sinkFactories {
+		types = append(types, sinkType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// newSink creates the Sink of a stream.
+func newSink(settings *binlogdatapb.Sink, params SinkParams) (Sink, error) {
+	sinkFactoriesMu.Lock()
+	factory, ok := sinkFactories[settings.Type]
+	sinkFactoriesMu.Unlock()
+	if !ok {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "unknown sink type %q, the registered types are %v", settings.Type, SinkTypes())
+	}
+	params.Params = settings.Params
+	return factory(params)
+}
+
+// sinkApplier is the applier that writes to a Sink.
+type sinkApplier struct {
+	sink Sink
+}
+
+func (sa *sinkApplier) applyRows(ctx context.Context, tplan *TablePlan, rows []*querypb.Row) (*sqltypes.Result, error) {
+	events := make([]*SinkEvent, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, &SinkEvent{
+			Table:  tplan.TargetName,
+			Op:     SinkOpCopy,
+			Fields: tplan.Fields,
+			After:  sqltypes.MakeRowTrusted(tplan.Fields, row),
+		})
+	}
+	if err := sa.sink.Write(ctx, events); err != nil {
+		return nil, err
+	}
+	return &sqltypes.Result{RowsAffected: uint64(len(rows))}, nil
+}
+
+func (sa *sinkApplier) applyChanges(ctx context.Context, tplan *TablePlan, changes []*binlogdatapb.RowChange) error {
+	events := make([]*SinkEvent, 0, len(changes))
+	for _, change := range changes {
+		if tplan.isPartial(change) {
+			return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "partial row images of table %s cannot be written to a sink", tplan.TargetName)
+		}
+		event := &SinkEvent{
+			Table:  tplan.TargetName,
+			Fields: tplan.Fields,
+		}
+		if change.Before != nil {
+			event.Before = sqltypes.MakeRowTrusted(tplan.Fields, change.Before)
+		}
+		if change.After != nil {
+			event.After = sqltypes.MakeRowTrusted(tplan.Fields, change.After)
+		}
+		switch {
+		case event.Before == nil && event.After != nil:
+			event.Op = SinkOpInsert
+		case event.Before != nil && event.After != nil:
+			event.Op = SinkOpUpdate
+		case event.Before != nil:
+			event.Op = SinkOpDelete
+		default:
+			continue
+		}
+		events = append(events, event)
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	return sa.sink.Write(ctx, events)
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vreplication
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+// testSink records the batches written to it.
+type testSink struct {
+	batches [][]*SinkEvent
+}
+
+func (ts *testSink) Write(ctx context.Context, events []*SinkEvent) error {
+	ts.batches = append(ts.batches, events)
+	return nil
+}
+
+func (ts *testSink) Close() error {
+	return nil
+}
+
+func sinkTestFields() []*querypb.Field {
+	return []*querypb.Field{
+		{Name: "id", Type: querypb.Type_INT64},
+		{Name: "name", Type: querypb.Type_VARCHAR},
+		{Name: "data", Type: querypb.Type_BLOB},
+		{Name: "doc", Type: querypb.Type_JSON},
+	}
+}
+
+func sinkTestEvents() []*SinkEvent {
+	fields := sinkTestFields()
+	return []*SinkEvent{{
+		Table:  "t1",
+		Op:     SinkOpInsert,
+		Fields: fields,
+		After: []sqltypes.Value{
+			sqltypes.NewInt64(1),
+			sqltypes.NewVarChar("a"),
+			sqltypes.MakeTrusted(sqltypes.Blob, []byte("abc")),
+			sqltypes.MakeTrusted(sqltypes.TypeJSON, []byte(`{"k": 1}`)),
+		},
+	}, {
+		Table:  "t1",
+		Op:     SinkOpDelete,
+		Fields: fields,
+		Before: []sqltypes.Value{
+			sqltypes.NewInt64(2),
+			sqltypes.NULL,
+			sqltypes.NULL,
+			sqltypes.NULL,
+		},
+	}}
+}
+
+func TestSinkApplier(t *testing.T) {
+	ctx := context.Background()
+	sink := &testSink{}
+	sa := &sinkApplier{sink: sink}
+	tplan := &TablePlan{
+		TargetName: "t1",
+		Fields:     sqltypes.MakeTestFields("id|name", "int64|varchar"),
+	}
+	row := func(id, name string) *querypb.Row {
+		return sqltypes.RowToProto3(sqltypes.MakeTestResult(tplan.Fields, id+"|"+name).Rows[0])
+	}
+
+	qr, err := sa.applyRows(ctx, tplan, []*querypb.Row{row("1", "a"), row("2", "b")})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, qr.RowsAffected)
+
+	err = sa.applyChanges(ctx, tplan, []*binlogdatapb.RowChange{
+		{After: row("3", "c")},
+		{Before: row("3", "c"), After: row("3", "d")},
+		{Before: row("1", "a")},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, sink.batches, 2)
+	var ops []SinkOp
+	for _, batch := range sink.batches {
+		for _, event := range batch {
+			assert.Equal(t, "t1", event.Table)
+			ops = append(ops, event.Op)
+		}
+	}
+	assert.Equal(t, []SinkOp{SinkOpCopy, SinkOpCopy, SinkOpInsert, SinkOpUpdate, SinkOpDelete}, ops)
+	update := sink.batches[1][1]
+	assert.Equal(t, "c", update.Before[1].ToString())
+	assert.Equal(t, "d", update.After[1].ToString())
+}
+
+func TestEncodeSinkEvents(t *testing.T) {
+	buf, err := encodeSinkEvents(sinkFormatJSON, sinkTestEvents())
+	require.NoError(t, err)
+	assert.Equal(t, `{"table":"t1","op":"insert","after":{"data":"YWJj","doc":{"k":1},"id":1,"name":"a"}}
+{"table":"t1","op":"delete","before":{"data":null,"doc":null,"id":2,"name":null}}
+`, string(buf))
+
+	buf, err = encodeSinkEvents(sinkFormatColumnar, sinkTestEvents())
+	require.NoError(t, err)
+	assert.Equal(t, `{"table":"t1","rows":2,"ops":["insert","delete"],"columns":[`+
+		`{"name":"id","type":"INT64","values":[1,2]},`+
+		`{"name":"name","type":"VARCHAR","values":["a",null]},`+
+		`{"name":"data","type":"BLOB","values":["YWJj",null]},`+
+		`{"name":"doc","type":"JSON","values":[{"k":1},null]}]}
+`, string(buf))
+}
+
+func TestFileSink(t *testing.T) {
+	dir := t.TempDir()
+	_, err := newSink(&binlogdatapb.Sink{Type: "file"}, SinkParams{Workflow: "wf", StreamID: 1})
+	require.ErrorContains(t, err, "the dir of the file sink is required")
+	_, err = newSink(&binlogdatapb.Sink{Type: "file", Params: map[string]string{"dir": dir, "format": "parquet"}}, SinkParams{Workflow: "wf", StreamID: 1})
+	require.ErrorContains(t, err, `unknown sink format "parquet"`)
+	_, err = newSink(&binlogdatapb.Sink{Type: "kafka"}, SinkParams{Workflow: "wf", StreamID: 1})
+	require.ErrorContains(t, err, `unknown sink type "kafka", the registered types are [file http]`)
+
+	sink, err := newSink(&binlogdatapb.Sink{Type: "file", Params: map[string]string{"dir": dir}}, SinkParams{Workflow: "wf", StreamID: 1})
+	require.NoError(t, err)
+	events := sinkTestEvents()
+	require.NoError(t, sink.Write(context.Background(), events[:1]))
+	require.NoError(t, sink.Write(context.Background(), events[1:]))
+	require.NoError(t, sink.Close())
+
+	buf, err := os.ReadFile(filepath.Join(dir, "wf.1.json.jsonl"))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(buf)), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"op":"insert"`)
+	assert.Contains(t, lines[1], `"op":"delete"`)
+}
+
+func TestHTTPSink(t *testing.T) {
+	var (
+		bodies  []string
+		headers []http.Header
+		status  = http.StatusOK
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		headers = append(headers, r.Header)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("bad batch"))
+	}))
+	defer server.Close()
+
+	sink, err := newSink(&binlogdatapb.Sink{Type: "http", Params: map[string]string{"url": server.URL, "format": "columnar"}}, SinkParams{Workflow: "wf", StreamID: 3})
+	require.NoError(t, err)
+	defer sink.Close()
+
+	require.NoError(t, sink.Write(context.Background(), sinkTestEvents()))
+	require.Len(t, bodies, 1)
+	assert.Contains(t, bodies[0], `"ops":["insert","delete"]`)
+	assert.Equal(t, "wf", headers[0].Get("X-Vitess-Workflow"))
+	assert.Equal(t, "3", headers[0].Get("X-Vitess-Stream-Id"))
+	assert.Equal(t, "application/x-ndjson", headers[0].Get("Content-Type"))
+
+	status = http.StatusServiceUnavailable
+	err = sink.Write(context.Background(), sinkTestEvents())
+	require.ErrorContains(t, err, "503 Service Unavailable: bad batch")
+}
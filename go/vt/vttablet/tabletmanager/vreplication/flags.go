@@ -38,8 +38,9 @@ var (
 
 	vreplicationHeartbeatUpdateInterval = 1
 
-	vreplicationStoreCompressedGTID   = false
-	vreplicationParallelInsertWorkers = 1
+	vreplicationStoreCompressedGTID        = false
+	vreplicationParallelInsertWorkers      = 1
+	vreplicationParallelReplicationWorkers = 1
 )
 
 func registerVReplicationFlags(fs *pflag.FlagSet) {
@@ -70,6 +71,7 @@ func registerVReplicationFlags(fs *pflag.FlagSet) {
 	fs.Duration("vreplication_healthcheck_timeout", 1*time.Minute, "healthcheck retry delay")
 
 	fs.IntVar(&vreplicationParallelInsertWorkers, "vreplication-parallel-insert-workers", vreplicationParallelInsertWorkers, "Number of parallel insertion workers to use during copy phase. Set <= 1 to disable parallelism, or > 1 to enable concurrent insertion during copy phase.")
+	fs.IntVar(&vreplicationParallelReplicationWorkers, "vreplication-parallel-replication-workers", vreplicationParallelReplicationWorkers, "Number of parallel workers to apply the transactions of a stream with during the replication phase. Transactions that change disjoint sets of primary keys are applied concurrently and committed in order. Set <= 1 to disable parallelism.")
 }
 
 func init() {
@@ -207,6 +207,9 @@ type TablePlan struct {
 	FieldsToSkip            map[string]bool
 	ConvertCharset          map[string](*binlogdatapb.CharsetConversion)
 	HasExtraSourcePkColumns bool
+	// HasUniqueOrForeignKeys is true if the target table has unique secondary
+	// keys or foreign keys, or is referenced by foreign keys.
+	HasUniqueOrForeignKeys bool
 
 	TablePlanBuilder *tablePlanBuilder
 	// PartialInserts is a dynamically generated cache of insert ParsedQueries, which update only some columns.
@@ -0,0 +1,211 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vreplication
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+const (
+	// sinkFormatJSON writes a JSON object per row.
+	sinkFormatJSON = "json"
+	// sinkFormatColumnar writes a JSON object per table of a batch, with the
+	// values of each column of the rows in an array.
+	sinkFormatColumnar = "columnar"
+)
+
+func init() {
+	RegisterSink("file", newFileSink)
+}
+
+// fileSink is a Sink that appends the rows to a file, as newline-delimited
+// JSON. Its params are:
+//   - "dir": the directory of the file, which is named after the workflow
+//     and the stream id.
+//   - "format": "json" (the default) to write a line per row, or "columnar"
+//     to write a line per table of each batch.
+type fileSink struct {
+	format string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+func newFileSink(params SinkParams) (Sink, error) {
+	dir := params.Params["dir"]
+	if dir == "" {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "the dir of the file sink is required")
+	}
+	format, err := sinkFormat(params.Params)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	name := filepath.Join(dir, fmt.Sprintf("%s.%d.%s.jsonl", params.Workflow, params.StreamID, format))
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{
+		format: format,
+		file:   file,
+	}, nil
+}
+
+// Write is part of the Sink interface.
+func (fs *fileSink) Write(ctx context.Context, events []*SinkEvent) error {
+	buf, err := encodeSinkEvents(fs.format, events)
+	if err != nil {
+		return err
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, err := fs.file.Write(buf); err != nil {
+		return err
+	}
+	return fs.file.Sync()
+}
+
+// Close is part of the Sink interface.
+func (fs *fileSink) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.file.Close()
+}
+
+func sinkFormat(params map[string]string) (string, error) {
+	switch format := params["format"]; format {
+	case "", sinkFormatJSON:
+		return sinkFormatJSON, nil
+	case sinkFormatColumnar:
+		return format, nil
+	default:
+		return "", vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "unknown sink format %q, the formats are %q and %q", format, sinkFormatJSON, sinkFormatColumnar)
+	}
+}
+
+type sinkRow struct {
+	Table  string         `json:"table"`
+	Op     SinkOp         `json:"op"`
+	Before map[string]any `json:"before,omitempty"`
+	After  map[string]any `json:"after,omitempty"`
+}
+
+type sinkColumn struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Values []any  `json:"values"`
+}
+
+// sinkBatch is the rows of a table in the columnar format. The values of
+// a row are those after the copy, insert or update, or before the delete.
+type sinkBatch struct {
+	Table   string        `json:"table"`
+	Rows    int           `json:"rows"`
+	Ops     []SinkOp      `json:"ops"`
+	Columns []*sinkColumn `json:"columns"`
+}
+
+// encodeSinkEvents encodes a batch of rows as newline-delimited JSON.
+func encodeSinkEvents(format string, events []*SinkEvent) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if format == sinkFormatColumnar {
+		var batches []*sinkBatch
+		tableBatches := make(map[string]*sinkBatch)
+		for _, event := range events {
+			batch, ok := tableBatches[event.Table]
+			if !ok {
+				batch = &sinkBatch{Table: event.Table}
+				for _, field := range event.Fields {
+					batch.Columns = append(batch.Columns, &sinkColumn{Name: field.Name, Type: field.Type.String()})
+				}
+				tableBatches[event.Table] = batch
+				batches = append(batches, batch)
+			}
+			row := event.After
+			if event.Op == SinkOpDelete {
+				row = event.Before
+			}
+			batch.Rows++
+			batch.Ops = append(batch.Ops, event.Op)
+			for i, col := range batch.Columns {
+				col.Values = append(col.Values, sinkValue(event.Fields[i], row[i]))
+			}
+		}
+		for _, batch := range batches {
+			if err := enc.Encode(batch); err != nil {
+				return nil, err
+			}
+		}
+		return buf.Bytes(), nil
+	}
+	for _, event := range events {
+		if err := enc.Encode(&sinkRow{
+			Table:  event.Table,
+			Op:     event.Op,
+			Before: sinkRowValues(event.Fields, event.Before),
+			After:  sinkRowValues(event.Fields, event.After),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func sinkRowValues(fields []*querypb.Field, row []sqltypes.Value) map[string]any {
+	if row == nil {
+		return nil
+	}
+	values := make(map[string]any, len(fields))
+	for i, field := range fields {
+		values[field.Name] = sinkValue(field, row[i])
+	}
+	return values
+}
+
+// sinkValue returns the JSON value of a column: numbers are JSON numbers,
+// binary values are base64 strings, JSON values are embedded as is, and the
+// other values are strings.
+func sinkValue(field *querypb.Field, val sqltypes.Value) any {
+	switch {
+	case val.IsNull():
+		return nil
+	case sqltypes.IsNumber(field.Type):
+		return json.Number(val.ToString())
+	case field.Type == querypb.Type_JSON && json.Valid(val.Raw()):
+		return json.RawMessage(val.Raw())
+	case sqltypes.IsBinary(field.Type) || field.Type == querypb.Type_BIT:
+		return val.Raw()
+	default:
+		return val.ToString()
+	}
+}
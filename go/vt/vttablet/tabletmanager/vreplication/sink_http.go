@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vreplication
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"vitess.io/vitess/go/vt/vterrors"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+const defaultHTTPSinkTimeout = 30 * time.Second
+
+func init() {
+	RegisterSink("http", newHTTPSink)
+}
+
+// httpSink is a Sink that posts each batch of rows to a webhook, as
+// newline-delimited JSON. A batch that is not acknowledged with a 2xx
+// status fails the stream, which retries it. Its params are:
+//   - "url": the URL of the webhook.
+//   - "format": "json" (the default) or "columnar", as for the file sink.
+//   - "timeout": the timeout of a request, e.g. "10s". Defaults to 30s.
+//
+// The requests have the X-Vitess-Workflow and X-Vitess-Stream-Id headers.
+type httpSink struct {
+	url      string
+	format   string
+	workflow string
+	streamID int32
+	client   *http.Client
+}
+
+func newHTTPSink(params SinkParams) (Sink, error) {
+	sinkURL := params.Params["url"]
+	if sinkURL == "" {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "the url of the http sink is required")
+	}
+	if _, err := url.ParseRequestURI(sinkURL); err != nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid url of the http sink: %v", err)
+	}
+	format, err := sinkFormat(params.Params)
+	if err != nil {
+		return nil, err
+	}
+	timeout := defaultHTTPSinkTimeout
+	if value := params.Params["timeout"]; value != "" {
+		if timeout, err = time.ParseDuration(value); err != nil {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid timeout of the http sink: %v", err)
+		}
+	}
+	return &httpSink{
+		url:      sinkURL,
+		format:   format,
+		workflow: params.Workflow,
+		streamID: params.StreamID,
+		client:   &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Write is part of the Sink interface.
+func (hs *httpSink) Write(ctx context.Context, events []*SinkEvent) error {
+	buf, err := encodeSinkEvents(hs.format, events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hs.url, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("X-Vitess-Workflow", hs.workflow)
+	req.Header.Set("X-Vitess-Stream-Id", strconv.Itoa(int(hs.streamID)))
+	resp, err := hs.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("http sink %s returned %s: %s", hs.url, resp.Status, bytes.TrimSpace(body))
+	}
+	// Drain the body so that the connection is reused.
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Close is part of the Sink interface.
+func (hs *httpSink) Close() error {
+	hs.client.CloseIdleConnections()
+	return nil
+}
@@ -343,10 +343,14 @@ func (tpb *tablePlanBuilder) generate() *TablePlan {
 	bvf := &bindvarFormatter{}
 
 	fieldsToSkip := make(map[string]bool)
+	hasUniqueOrForeignKeys := false
 	for _, colInfo := range tpb.colInfos {
 		if colInfo.IsGenerated {
 			fieldsToSkip[colInfo.Name] = true
 		}
+		if colInfo.IsUniqueKey || colInfo.IsForeignKey {
+			hasUniqueOrForeignKeys = true
+		}
 	}
 
 	return &TablePlan{
@@ -363,6 +367,7 @@ func (tpb *tablePlanBuilder) generate() *TablePlan {
 		Stats:                   tpb.stats,
 		FieldsToSkip:            fieldsToSkip,
 		HasExtraSourcePkColumns: len(tpb.extraSourcePkCols) > 0,
+		HasUniqueOrForeignKeys:  hasUniqueOrForeignKeys,
 		TablePlanBuilder:        tpb,
 		PartialInserts:          make(map[string]*sqlparser.ParsedQuery, 0),
 		PartialUpdates:          make(map[string]*sqlparser.ParsedQuery, 0),
@@ -27,7 +27,6 @@ import (
 
 	"google.golang.org/protobuf/encoding/prototext"
 
-	"vitess.io/vitess/go/bytes2"
 	"vitess.io/vitess/go/mysql/replication"
 	"vitess.io/vitess/go/pools"
 	"vitess.io/vitess/go/sqltypes"
@@ -130,11 +129,11 @@ type vcopierCopyWorkQueue struct {
 // goroutine.
 type vcopierCopyWorker struct {
 	*vdbClient
+	applier         applier
 	closeDbClient   bool
 	copyStateInsert *sqlparser.ParsedQuery
 	isOpen          bool
 	pkfields        []*querypb.Field
-	sqlbuffer       bytes2.Buffer
 	tablePlan       *TablePlan
 }
 
@@ -203,8 +202,10 @@ func newVCopierCopyWorkQueue(
 func newVCopierCopyWorker(
 	closeDbClient bool,
 	vdbClient *vdbClient,
+	applier applier,
 ) *vcopierCopyWorker {
 	return &vcopierCopyWorker{
+		applier:       applier,
 		closeDbClient: closeDbClient,
 		vdbClient:     vdbClient,
 	}
@@ -725,6 +726,7 @@ func (vc *vcopier) newCopyWorkerFactory(parallelism int) func(context.Context) (
 			return newVCopierCopyWorker(
 				true, /* close db client */
 				dbClient,
+				vc.vr.newApplier(dbClient, nil),
 			), nil
 		}
 	}
@@ -732,6 +734,7 @@ func (vc *vcopier) newCopyWorkerFactory(parallelism int) func(context.Context) (
 		return newVCopierCopyWorker(
 			false, /* close db client */
 			vc.vr.dbClient,
+			vc.vr.newApplier(vc.vr.dbClient, nil),
 		), nil
 	}
 }
@@ -1167,13 +1170,7 @@ func (vbc *vcopierCopyWorker) insertCopyState(ctx context.Context, lastpk *query
 }
 
 func (vbc *vcopierCopyWorker) insertRows(ctx context.Context, rows []*querypb.Row) (*sqltypes.Result, error) {
-	return vbc.tablePlan.applyBulkInsert(
-		&vbc.sqlbuffer,
-		rows,
-		func(sql string) (*sqltypes.Result, error) {
-			return vbc.vdbClient.ExecuteWithRetry(ctx, sql)
-		},
-	)
+	return vbc.applier.applyRows(ctx, vbc.tablePlan, rows)
 }
 
 // open the vcopierCopyWorker. The provided arguments are used to generate
@@ -46,6 +46,8 @@ type vplayer struct {
 
 	replicatorPlan *ReplicatorPlan
 	tablePlans     map[string]*TablePlan
+	// applier writes the row changes to the target.
+	applier applier
 
 	pos replication.Position
 	// unsavedEvent is set any time we skip an event without
@@ -104,7 +106,7 @@ func newVPlayer(vr *vreplicator, settings binlogplayer.VRSettings, copyState map
 		settings.StopPos = pausePos
 		saveStop = false
 	}
-	return &vplayer{
+	vp := &vplayer{
 		vr:               vr,
 		startPos:         settings.StartPos,
 		pos:              settings.StartPos,
@@ -116,6 +118,17 @@ func newVPlayer(vr *vreplicator, settings binlogplayer.VRSettings, copyState map
 		phase:            phase,
 		throttlerAppName: throttlerapp.VCopierName.ConcatenateString(vr.throttlerAppName()),
 	}
+	vp.applier = vr.newApplier(vr.dbClient, vp.recordQuery)
+	return vp
+}
+
+// recordQuery records the stats of a query that applied a row change.
+func (vp *vplayer) recordQuery(sql string, start time.Time) {
+	vp.vr.stats.QueryCount.Add(vp.phase, 1)
+	vp.vr.stats.QueryTimings.Record(vp.phase, start)
+	stats := NewVrLogStats("ROWCHANGE")
+	stats.StartTime = start
+	stats.Send(sql)
 }
 
 // play is the entry point for playing binlogs.
@@ -248,6 +261,9 @@ func (vp *vplayer) applyStmtEvent(ctx context.Context, event *binlogdatapb.VEven
 	if sql == "" {
 		sql = event.Dml
 	}
+	if vp.vr.sink != nil {
+		return fmt.Errorf("statements cannot be written to a sink: %v", sql)
+	}
 	if event.Type == binlogdatapb.VEventType_SAVEPOINT || vp.canAcceptStmtEvents {
 		start := time.Now()
 		_, err := vp.vr.dbClient.ExecuteWithRetry(ctx, sql)
@@ -266,21 +282,7 @@ func (vp *vplayer) applyRowEvent(ctx context.Context, rowEvent *binlogdatapb.Row
 	if tplan == nil {
 		return fmt.Errorf("unexpected event on table %s", rowEvent.TableName)
 	}
-	for _, change := range rowEvent.RowChanges {
-		_, err := tplan.applyChange(change, func(sql string) (*sqltypes.Result, error) {
-			stats := NewVrLogStats("ROWCHANGE")
-			start := time.Now()
-			qr, err := vp.vr.dbClient.ExecuteWithRetry(ctx, sql)
-			vp.vr.stats.QueryCount.Add(vp.phase, 1)
-			vp.vr.stats.QueryTimings.Record(vp.phase, start)
-			stats.Send(sql)
-			return qr, err
-		})
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return vp.applier.applyChanges(ctx, tplan, rowEvent.RowChanges)
 }
 
 func (vp *vplayer) updatePos(ts int64) (posReached bool, err error) {
@@ -368,6 +370,9 @@ func (vp *vplayer) recordHeartbeat() error {
 // TODO(sougou): we can look at recognizing self-generated events and find a better
 // way to handle them.
 func (vp *vplayer) applyEvents(ctx context.Context, relay *relayLog) error {
+	if workers := vp.parallelWorkers(); workers > 1 {
+		return vp.applyEventsParallel(ctx, relay, workers)
+	}
 	defer vp.vr.dbClient.Rollback()
 
 	// If we're not running, set ReplicationLagSeconds to be very high.
@@ -0,0 +1,515 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vreplication
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/mysql/collations/colldata"
+	"vitess.io/vitess/go/mysql/replication"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/throttle/throttlerapp"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+)
+
+const setReadCommittedQuery = "set @@session.transaction_isolation='READ-COMMITTED'"
+
+// parallelWorkers returns the number of workers the player applies the
+// transactions with. Only the replication phase of a stream without a stop
+// position or a sink is applied in parallel: the other phases and streams
+// rely on the serial application of the events.
+func (vp *vplayer) parallelWorkers() int {
+	if vreplicationParallelReplicationWorkers <= 1 || vp.phase != "replicate" || !vp.stopPos.IsZero() || vp.vr.sink != nil {
+		return 1
+	}
+	return vreplicationParallelReplicationWorkers
+}
+
+// parallelTxn is a transaction applied by a parallel worker.
+type parallelTxn struct {
+	// seq is the order of the transaction in the stream, starting at 1.
+	seq int64
+	// writeset are the keys of the rows changed by the transaction.
+	writeset  []string
+	rowEvents []*parallelRowEvent
+	pos       replication.Position
+	timestamp int64
+}
+
+type parallelRowEvent struct {
+	tplan    *TablePlan
+	rowEvent *binlogdatapb.RowEvent
+}
+
+// parallelApplier applies the transactions of a vplayer with several workers,
+// each with its own connection to the target. Transactions whose writesets,
+// the primary keys of the rows they change, are disjoint are applied
+// concurrently. The workers commit the transactions in the order of the
+// stream, each along with the update of the position of the stream, so the
+// saved position always covers a prefix of the stream.
+//
+// The writesets only account for the primary keys of the tables, so the
+// transactions that change tables with unique secondary keys or foreign keys,
+// whose order of application matters, are applied serially.
+type parallelApplier struct {
+	vp   *vplayer
+	work chan *parallelTxn
+	wg   sync.WaitGroup
+	// stop stops failing the applier when the context is done.
+	stop func() bool
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// err is the first error of a worker, or the error of the context.
+	err error
+	// keys counts the in-flight transactions that change each key.
+	keys map[string]int
+	// inflight is the number of dispatched transactions not committed yet.
+	inflight int
+	// lastSeq is the sequence of the last dispatched transaction.
+	lastSeq int64
+	// committedSeq is the sequence of the last committed transaction.
+	committedSeq int64
+}
+
+func newParallelApplier(ctx context.Context, vp *vplayer, workers int) (*parallelApplier, error) {
+	pa := &parallelApplier{
+		vp:   vp,
+		work: make(chan *parallelTxn),
+		keys: make(map[string]int),
+	}
+	pa.cond = sync.NewCond(&pa.mu)
+	dbClients := make([]*vdbClient, 0, workers)
+	for i := 0; i < workers; i++ {
+		dbClient, err := vp.vr.newClientConnection(ctx)
+		if err == nil {
+			_, err = dbClient.Execute(setReadCommittedQuery)
+		}
+		if err != nil {
+			for _, dbClient := range dbClients {
+				dbClient.Close()
+			}
+			return nil, fmt.Errorf("failed to create a parallel worker: %v", err)
+		}
+		dbClients = append(dbClients, dbClient)
+	}
+	pa.stop = context.AfterFunc(ctx, func() {
+		pa.fail(io.EOF)
+	})
+	for _, dbClient := range dbClients {
+		w := &parallelWorker{
+			pa:       pa,
+			dbClient: dbClient,
+			applier:  vp.vr.newApplier(dbClient, vp.recordQuery),
+		}
+		pa.wg.Add(1)
+		go func() {
+			defer pa.wg.Done()
+			w.run(ctx)
+		}()
+	}
+	return pa, nil
+}
+
+// close stops the workers and waits for them to exit.
+func (pa *parallelApplier) close() {
+	pa.stop()
+	pa.fail(io.EOF)
+	close(pa.work)
+	pa.wg.Wait()
+}
+
+// fail records the error that stops the applier, and wakes up the goroutines
+// waiting on it. io.EOF stops the applier without an error.
+func (pa *parallelApplier) fail(err error) {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+	if pa.err == nil {
+		pa.err = err
+		if err != io.EOF {
+			pa.vp.vr.stats.ErrorCounts.Add([]string{"Apply"}, 1)
+			log.Errorf("Error applying transaction in parallel: %s", err.Error())
+		}
+	}
+	pa.cond.Broadcast()
+}
+
+// dispatch waits for the transactions that conflict with txn to commit, and
+// hands txn over to a worker.
+func (pa *parallelApplier) dispatch(ctx context.Context, txn *parallelTxn) error {
+	pa.mu.Lock()
+	for pa.err == nil && pa.conflicts(txn.writeset) {
+		pa.cond.Wait()
+	}
+	if pa.err != nil {
+		err := pa.err
+		pa.mu.Unlock()
+		return err
+	}
+	for _, key := range txn.writeset {
+		pa.keys[key]++
+	}
+	pa.inflight++
+	pa.lastSeq++
+	txn.seq = pa.lastSeq
+	pa.mu.Unlock()
+
+	select {
+	case pa.work <- txn:
+		return nil
+	case <-ctx.Done():
+		return io.EOF
+	}
+}
+
+func (pa *parallelApplier) conflicts(writeset []string) bool {
+	for _, key := range writeset {
+		if pa.keys[key] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// wait waits for all the dispatched transactions to commit.
+func (pa *parallelApplier) wait() error {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+	for pa.err == nil && pa.inflight > 0 {
+		pa.cond.Wait()
+	}
+	return pa.err
+}
+
+// waitTurn waits for the transactions before seq to commit.
+func (pa *parallelApplier) waitTurn(seq int64) error {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+	for pa.err == nil && pa.committedSeq != seq-1 {
+		pa.cond.Wait()
+	}
+	return pa.err
+}
+
+func (pa *parallelApplier) committed(txn *parallelTxn) {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+	for _, key := range txn.writeset {
+		if pa.keys[key]--; pa.keys[key] == 0 {
+			delete(pa.keys, key)
+		}
+	}
+	pa.inflight--
+	pa.committedSeq = txn.seq
+	pa.cond.Broadcast()
+}
+
+// parallelWorker applies transactions on its own connection.
+type parallelWorker struct {
+	pa       *parallelApplier
+	dbClient *vdbClient
+	applier  applier
+	// foreignKeyChecksEnabled is the foreign_key_checks setting of the
+	// session, nil until it's set by the first row event.
+	foreignKeyChecksEnabled *bool
+}
+
+func (w *parallelWorker) run(ctx context.Context) {
+	defer w.dbClient.Close()
+	for txn := range w.pa.work {
+		if err := w.apply(ctx, txn); err != nil {
+			_ = w.dbClient.Rollback()
+			w.pa.fail(err)
+		}
+	}
+}
+
+func (w *parallelWorker) apply(ctx context.Context, txn *parallelTxn) error {
+	vr := w.pa.vp.vr
+	if err := w.dbClient.Begin(); err != nil {
+		return err
+	}
+	for _, re := range txn.rowEvents {
+		if err := w.updateFKCheck(ctx, re.rowEvent.Flags); err != nil {
+			return err
+		}
+		if err := w.applier.applyChanges(ctx, re.tplan, re.rowEvent.RowChanges); err != nil {
+			return err
+		}
+	}
+	// The transactions are committed in the order of the stream.
+	if err := w.pa.waitTurn(txn.seq); err != nil {
+		return err
+	}
+	update := binlogplayer.GenerateUpdatePos(vr.id, txn.pos, time.Now().Unix(), txn.timestamp, vr.stats.CopyRowCount.Get(), vreplicationStoreCompressedGTID)
+	if _, err := w.dbClient.Execute(update); err != nil {
+		return fmt.Errorf("error %v updating position", err)
+	}
+	if err := w.dbClient.Commit(); err != nil {
+		return err
+	}
+	vr.stats.SetLastPosition(txn.pos)
+	w.pa.committed(txn)
+	return nil
+}
+
+// updateFKCheck is vplayer.updateFKCheck for the session of the worker.
+func (w *parallelWorker) updateFKCheck(ctx context.Context, flags2 uint32) error {
+	enabled := flags2&NoForeignKeyCheckFlagBitmask != NoForeignKeyCheckFlagBitmask
+	if w.foreignKeyChecksEnabled != nil && *w.foreignKeyChecksEnabled == enabled {
+		return nil
+	}
+	if _, err := w.dbClient.ExecuteWithRetry(ctx, "set @@session.foreign_key_checks="+strconv.FormatBool(enabled)); err != nil {
+		return fmt.Errorf("failed to set session foreign_key_checks: %w", err)
+	}
+	w.foreignKeyChecksEnabled = &enabled
+	return nil
+}
+
+// applyEventsParallel is applyEvents for a player with several workers. It
+// groups the events into transactions: the transactions that only change
+// rows are dispatched to the workers, and the other events are applied
+// serially, once all the dispatched transactions are committed.
+func (vp *vplayer) applyEventsParallel(ctx context.Context, relay *relayLog, workers int) error {
+	defer vp.vr.dbClient.Rollback()
+	defer vp.vr.stats.ReplicationLagSeconds.Store(math.MaxInt64)
+	defer vp.vr.stats.VReplicationLags.Add(strconv.Itoa(int(vp.vr.id)), math.MaxInt64)
+
+	log.Infof("Applying the events of stream %d with %d parallel workers", vp.vr.id, workers)
+	pa, err := newParallelApplier(ctx, vp, workers)
+	if err != nil {
+		return err
+	}
+	defer pa.close()
+
+	var (
+		sbm    int64 = -1
+		events []*binlogdatapb.VEvent
+	)
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// check throttler.
+		if !vp.vr.vre.throttlerClient.ThrottleCheckOKOrWaitAppName(ctx, throttlerapp.Name(vp.throttlerAppName)) {
+			_ = vp.vr.updateTimeThrottled(throttlerapp.VPlayerName)
+			continue
+		}
+
+		items, err := relay.Fetch()
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			behind := time.Now().UnixNano() - vp.lastTimestampNs - vp.timeOffsetNs
+			vp.vr.stats.ReplicationLagSeconds.Store(behind / 1e9)
+			vp.vr.stats.VReplicationLags.Add(strconv.Itoa(int(vp.vr.id)), time.Duration(behind/1e9)*time.Second)
+		}
+		// The position of an unsaved empty transaction follows the positions
+		// of the dispatched transactions, so they must be committed first.
+		if time.Since(vp.timeLastSaved) >= idleTimeout && vp.unsavedEvent != nil {
+			if err := pa.wait(); err != nil {
+				return err
+			}
+			if _, err := vp.updatePos(vp.unsavedEvent.Timestamp); err != nil {
+				return err
+			}
+		}
+		for _, batch := range items {
+			for _, event := range batch {
+				if event.Timestamp != 0 {
+					vp.lastTimestampNs = event.Timestamp * 1e9
+					vp.timeOffsetNs = time.Now().UnixNano() - event.CurrentTime
+					sbm = event.CurrentTime/1e9 - event.Timestamp
+				}
+				if event.Type == binlogdatapb.VEventType_HEARTBEAT {
+					if err := vp.applyEvent(ctx, event, false); err != nil {
+						return err
+					}
+					continue
+				}
+				events = append(events, event)
+				switch event.Type {
+				case binlogdatapb.VEventType_COMMIT, binlogdatapb.VEventType_DDL,
+					binlogdatapb.VEventType_OTHER, binlogdatapb.VEventType_JOURNAL:
+					if err := vp.applyTxnParallel(ctx, pa, events); err != nil {
+						return err
+					}
+					events = nil
+				}
+			}
+		}
+
+		if sbm >= 0 {
+			vp.vr.stats.ReplicationLagSeconds.Store(sbm)
+			vp.vr.stats.VReplicationLags.Add(strconv.Itoa(int(vp.vr.id)), time.Duration(sbm)*time.Second)
+		}
+	}
+}
+
+// applyTxnParallel dispatches the events of a transaction to the workers if
+// it only changes rows, or else applies them serially.
+func (vp *vplayer) applyTxnParallel(ctx context.Context, pa *parallelApplier, events []*binlogdatapb.VEvent) error {
+	txn, err := vp.buildParallelTxn(events)
+	if err != nil {
+		return err
+	}
+	if txn != nil {
+		// The position of the transaction supersedes the unsaved one.
+		vp.pos = txn.pos
+		vp.unsavedEvent = nil
+		vp.timeLastSaved = time.Now()
+		vp.numAccumulatedHeartbeats = 0
+		return pa.dispatch(ctx, txn)
+	}
+	// An empty transaction is only remembered as the unsaved event, the
+	// other events save the position and must follow the dispatched ones.
+	if !isEmptyTxn(events) {
+		if err := pa.wait(); err != nil {
+			return err
+		}
+	}
+	for _, event := range events {
+		if err := vp.applyEvent(ctx, event, false); err != nil {
+			if err != io.EOF {
+				vp.vr.stats.ErrorCounts.Add([]string{"Apply"}, 1)
+				log.Errorf("Error applying event: %s", err.Error())
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// isEmptyTxn returns true if the events are a transaction that changes
+// nothing on the target.
+func isEmptyTxn(events []*binlogdatapb.VEvent) bool {
+	for _, event := range events {
+		switch event.Type {
+		case binlogdatapb.VEventType_GTID, binlogdatapb.VEventType_BEGIN, binlogdatapb.VEventType_COMMIT:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// buildParallelTxn returns the transaction of the events for the workers,
+// or nil if the events must be applied serially: they do more than change
+// rows, change no row, change rows of a table without a primary key or with
+// unique secondary keys or foreign keys, or change partial rows.
+func (vp *vplayer) buildParallelTxn(events []*binlogdatapb.VEvent) (*parallelTxn, error) {
+	txn := &parallelTxn{}
+	var hasGTID bool
+	for _, event := range events {
+		switch event.Type {
+		case binlogdatapb.VEventType_BEGIN:
+		case binlogdatapb.VEventType_GTID:
+			pos, err := binlogplayer.DecodePosition(event.Gtid)
+			if err != nil {
+				return nil, err
+			}
+			txn.pos = pos
+			hasGTID = true
+		case binlogdatapb.VEventType_FIELD:
+			tplan, err := vp.replicatorPlan.buildExecutionPlan(event.FieldEvent)
+			if err != nil {
+				return nil, err
+			}
+			vp.tablePlans[event.FieldEvent.TableName] = tplan
+		case binlogdatapb.VEventType_ROW:
+			tplan := vp.tablePlans[event.RowEvent.TableName]
+			if tplan == nil {
+				return nil, fmt.Errorf("unexpected event on table %s", event.RowEvent.TableName)
+			}
+			if tplan.HasUniqueOrForeignKeys {
+				return nil, nil
+			}
+			writeset, ok := rowEventWriteset(tplan, event.RowEvent)
+			if !ok {
+				return nil, nil
+			}
+			txn.writeset = append(txn.writeset, writeset...)
+			txn.rowEvents = append(txn.rowEvents, &parallelRowEvent{tplan: tplan, rowEvent: event.RowEvent})
+		case binlogdatapb.VEventType_COMMIT:
+			txn.timestamp = event.Timestamp
+		default:
+			return nil, nil
+		}
+	}
+	if !hasGTID || len(txn.rowEvents) == 0 {
+		return nil, nil
+	}
+	return txn, nil
+}
+
+// rowEventWriteset returns the keys of the rows changed by a row event: the
+// table and the weight strings of the primary key values, before and after
+// the change. It returns false if the keys are unknown.
+func rowEventWriteset(tplan *TablePlan, rowEvent *binlogdatapb.RowEvent) ([]string, bool) {
+	var pkIndexes []int
+	for i, field := range tplan.Fields {
+		for _, pk := range tplan.PKReferences {
+			if field.Name == pk {
+				pkIndexes = append(pkIndexes, i)
+				break
+			}
+		}
+	}
+	if len(pkIndexes) == 0 || len(pkIndexes) != len(tplan.PKReferences) {
+		return nil, false
+	}
+	var writeset []string
+	appendKey := func(row []sqltypes.Value) {
+		key := []byte(tplan.TargetName)
+		for _, i := range pkIndexes {
+			field := tplan.Fields[i]
+			val := row[i].Raw()
+			if sqltypes.IsText(field.Type) {
+				if coll := colldata.Lookup(collations.ID(field.Charset)); coll != nil {
+					val = coll.WeightString(nil, val, 0)
+				}
+			}
+			key = binary.AppendUvarint(key, uint64(len(val)))
+			key = append(key, val...)
+		}
+		writeset = append(writeset, string(key))
+	}
+	for _, change := range rowEvent.RowChanges {
+		if tplan.isPartial(change) {
+			return nil, false
+		}
+		if change.Before != nil {
+			appendKey(sqltypes.MakeRowTrusted(tplan.Fields, change.Before))
+		}
+		if change.After != nil {
+			appendKey(sqltypes.MakeRowTrusted(tplan.Fields, change.After))
+		}
+	}
+	return writeset, true
+}
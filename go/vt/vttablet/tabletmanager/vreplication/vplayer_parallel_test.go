@@ -0,0 +1,446 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vreplication
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	qh "vitess.io/vitess/go/vt/vttablet/tabletmanager/vreplication/queryhistory"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+func parallelTestPlan() *TablePlan {
+	fields := sqltypes.MakeTestFields("id|name|val", "int64|varchar|varchar")
+	fields[1].Charset = uint32(collations.CollationUtf8mb4ID)
+	return &TablePlan{
+		TargetName:   "t1",
+		Fields:       fields,
+		PKReferences: []string{"id", "name"},
+	}
+}
+
+func parallelTestRow(tplan *TablePlan, values string) *querypb.Row {
+	return sqltypes.RowToProto3(sqltypes.MakeTestResult(tplan.Fields, values).Rows[0])
+}
+
+func TestRowEventWriteset(t *testing.T) {
+	tplan := parallelTestPlan()
+	writeset := func(changes ...*binlogdatapb.RowChange) []string {
+		keys, ok := rowEventWriteset(tplan, &binlogdatapb.RowEvent{TableName: "t1", RowChanges: changes})
+		require.True(t, ok)
+		return keys
+	}
+
+	insert := writeset(&binlogdatapb.RowChange{After: parallelTestRow(tplan, "1|a|x")})
+	require.Len(t, insert, 1)
+	// The values of the other columns are not part of the keys.
+	assert.Equal(t, insert, writeset(&binlogdatapb.RowChange{Before: parallelTestRow(tplan, "1|a|y")}))
+	// The keys of text values are their weight strings.
+	assert.Equal(t, insert, writeset(&binlogdatapb.RowChange{After: parallelTestRow(tplan, "1|A|x")}))
+	assert.NotEqual(t, insert, writeset(&binlogdatapb.RowChange{After: parallelTestRow(tplan, "1|b|x")}))
+	assert.NotEqual(t, insert, writeset(&binlogdatapb.RowChange{After: parallelTestRow(tplan, "11|a|x")}))
+
+	// An update that changes the primary key has the keys of both rows.
+	update := writeset(&binlogdatapb.RowChange{
+		Before: parallelTestRow(tplan, "1|a|x"),
+		After:  parallelTestRow(tplan, "2|a|x"),
+	})
+	require.Len(t, update, 2)
+	assert.Equal(t, insert[0], update[0])
+
+	// The keys are unknown without a primary key.
+	tplan.PKReferences = nil
+	_, ok := rowEventWriteset(tplan, &binlogdatapb.RowEvent{TableName: "t1", RowChanges: []*binlogdatapb.RowChange{{After: parallelTestRow(tplan, "1|a|x")}}})
+	assert.False(t, ok)
+}
+
+func TestBuildParallelTxn(t *testing.T) {
+	tplan := parallelTestPlan()
+	vp := &vplayer{tablePlans: map[string]*TablePlan{"t1": tplan}}
+	gtid := &binlogdatapb.VEvent{Type: binlogdatapb.VEventType_GTID, Gtid: "MySQL56/7b04699f-f5e9-11e9-bf88-9cb6d089e1c3:1-10"}
+	begin := &binlogdatapb.VEvent{Type: binlogdatapb.VEventType_BEGIN}
+	commit := &binlogdatapb.VEvent{Type: binlogdatapb.VEventType_COMMIT, Timestamp: 100}
+	row := func(table string) *binlogdatapb.VEvent {
+		return &binlogdatapb.VEvent{Type: binlogdatapb.VEventType_ROW, RowEvent: &binlogdatapb.RowEvent{
+			TableName:  table,
+			RowChanges: []*binlogdatapb.RowChange{{After: parallelTestRow(tplan, "1|a|x")}, {After: parallelTestRow(tplan, "2|a|x")}},
+		}}
+	}
+
+	txn, err := vp.buildParallelTxn([]*binlogdatapb.VEvent{gtid, begin, row("t1"), row("t1"), commit})
+	require.NoError(t, err)
+	require.NotNil(t, txn)
+	assert.Len(t, txn.rowEvents, 2)
+	assert.Len(t, txn.writeset, 4)
+	assert.EqualValues(t, 100, txn.timestamp)
+	assert.Equal(t, "7b04699f-f5e9-11e9-bf88-9cb6d089e1c3:1-10", txn.pos.GTIDSet.String())
+
+	_, err = vp.buildParallelTxn([]*binlogdatapb.VEvent{gtid, begin, row("t2"), commit})
+	require.ErrorContains(t, err, "unexpected event on table t2")
+
+	// The transactions that don't only change rows are applied serially.
+	testcases := []struct {
+		name   string
+		events []*binlogdatapb.VEvent
+	}{{
+		name:   "empty",
+		events: []*binlogdatapb.VEvent{gtid, begin, commit},
+	}, {
+		name:   "no gtid",
+		events: []*binlogdatapb.VEvent{begin, row("t1"), commit},
+	}, {
+		name:   "ddl",
+		events: []*binlogdatapb.VEvent{gtid, {Type: binlogdatapb.VEventType_DDL, Statement: "alter table t1 add column c int"}},
+	}, {
+		name:   "statement",
+		events: []*binlogdatapb.VEvent{gtid, begin, {Type: binlogdatapb.VEventType_INSERT, Statement: "insert into t1 values (1)"}, commit},
+	}}
+	for _, tcase := range testcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			txn, err := vp.buildParallelTxn(tcase.events)
+			require.NoError(t, err)
+			assert.Nil(t, txn)
+		})
+	}
+
+	// So are the transactions that change tables with unique secondary keys
+	// or foreign keys.
+	tplan.HasUniqueOrForeignKeys = true
+	txn, err = vp.buildParallelTxn([]*binlogdatapb.VEvent{gtid, begin, row("t1"), commit})
+	require.NoError(t, err)
+	assert.Nil(t, txn)
+	tplan.HasUniqueOrForeignKeys = false
+
+	assert.True(t, isEmptyTxn([]*binlogdatapb.VEvent{gtid, begin, commit}))
+	assert.False(t, isEmptyTxn([]*binlogdatapb.VEvent{gtid, begin, row("t1"), commit}))
+}
+
+func TestParallelApplierConflicts(t *testing.T) {
+	pa := &parallelApplier{keys: make(map[string]int)}
+	pa.cond = sync.NewCond(&pa.mu)
+	txn1 := &parallelTxn{seq: 1, writeset: []string{"a", "b"}}
+	txn2 := &parallelTxn{seq: 2, writeset: []string{"b", "c"}}
+	for _, txn := range []*parallelTxn{txn1, txn2} {
+		for _, key := range txn.writeset {
+			pa.keys[key]++
+		}
+		pa.inflight++
+	}
+
+	assert.True(t, pa.conflicts([]string{"d", "a"}))
+	assert.False(t, pa.conflicts([]string{"d", "e"}))
+
+	pa.committed(txn1)
+	assert.False(t, pa.conflicts([]string{"a"}))
+	assert.True(t, pa.conflicts([]string{"b"}))
+	assert.EqualValues(t, 1, pa.committedSeq)
+	require.NoError(t, pa.waitTurn(2))
+
+	pa.committed(txn2)
+	assert.Empty(t, pa.keys)
+	require.NoError(t, pa.wait())
+}
+
+// setParallelReplicationWorkers sets the number of parallel workers of the
+// streams started by the test.
+func setParallelReplicationWorkers(t *testing.T, workers int) {
+	saved := vreplicationParallelReplicationWorkers
+	vreplicationParallelReplicationWorkers = workers
+	t.Cleanup(func() {
+		vreplicationParallelReplicationWorkers = saved
+	})
+}
+
+// lockTargetRow locks a row of the target table on a connection of its own,
+// which blocks the transactions that change it until unlock is called.
+func lockTargetRow(t *testing.T, query string) (unlock func()) {
+	vconn := &realDBClient{nolog: true}
+	require.NoError(t, vconn.Connect())
+	_, err := vconn.ExecuteFetch("begin", 1)
+	require.NoError(t, err)
+	_, err = vconn.ExecuteFetch(query, 1)
+	require.NoError(t, err)
+	var once sync.Once
+	unlock = func() {
+		once.Do(func() {
+			_, _ = vconn.ExecuteFetch("rollback", 1)
+			vconn.Close()
+		})
+	}
+	t.Cleanup(unlock)
+	return unlock
+}
+
+// savedPosition returns the position saved for the stream.
+func savedPosition(t *testing.T, id int) string {
+	qr, err := env.Mysqld.FetchSuperQuery(context.Background(), fmt.Sprintf("select pos from _vt.vreplication where id = %d", id))
+	require.NoError(t, err)
+	require.Len(t, qr.Rows, 1)
+	return qr.Rows[0][0].ToString()
+}
+
+// TestPlayerParallelCommitOrder tests that the transactions applied by the
+// parallel workers are committed in the order of the stream, and that the
+// saved position never covers a transaction that isn't committed.
+func TestPlayerParallelCommitOrder(t *testing.T) {
+	defer deleteTablet(addTablet(100))
+	setParallelReplicationWorkers(t, 4)
+
+	execStatements(t, []string{
+		"create table t1(id int, val varbinary(128), primary key(id))",
+		fmt.Sprintf("create table %s.t1(id int, val varbinary(128), primary key(id))", vrepldb),
+	})
+	defer execStatements(t, []string{
+		"drop table t1",
+		fmt.Sprintf("drop table %s.t1", vrepldb),
+	})
+	env.SchemaEngine.Reload(context.Background())
+
+	filter := &binlogdatapb.Filter{
+		Rules: []*binlogdatapb.Rule{{
+			Match: "/.*",
+		}},
+	}
+	bls := &binlogdatapb.BinlogSource{
+		Keyspace: env.KeyspaceName,
+		Shard:    env.ShardName,
+		Filter:   filter,
+		OnDdl:    binlogdatapb.OnDDLAction_IGNORE,
+	}
+	cancel, id := startVReplication(t, bls, "")
+	defer cancel()
+
+	execStatements(t, []string{"insert into t1 values(1, 'aaa')"})
+	expectDBClientQueries(t, qh.Expect(
+		"begin",
+		"insert into t1(id,val) values (1,'aaa')",
+		"/update _vt.vreplication set pos=",
+		"commit",
+	))
+	pos := savedPosition(t, id)
+
+	// The first transaction is blocked by the lock, while the next ones,
+	// which change other rows, are applied by the other workers.
+	unlock := lockTargetRow(t, "update t1 set val='bbb' where id=1")
+	execStatements(t, []string{
+		"update t1 set val='ccc' where id=1",
+		"insert into t1 values(2, 'aaa')",
+		"insert into t1 values(3, 'aaa')",
+	})
+	expectDBClientQueries(t, qh.Expect(
+		"begin",
+	).Then(func(expect qh.ExpectationSequencer) qh.ExpectationSequencer {
+		expect.Then(qh.Eventually("begin"))
+		expect.Then(qh.Eventually("begin"))
+		expect.Then(qh.Eventually("insert into t1(id,val) values (2,'aaa')"))
+		return expect.Then(qh.Eventually("insert into t1(id,val) values (3,'aaa')"))
+	}))
+
+	// The applied transactions wait for the blocked one to commit, and so
+	// does the saved position.
+	assert.Equal(t, pos, savedPosition(t, id))
+	expectData(t, "t1", [][]string{
+		{"1", "aaa"},
+	})
+
+	unlock()
+	expectDBClientQueries(t, qh.Expect(
+		"update t1 set val='ccc' where id=1",
+		"/update _vt.vreplication set pos=",
+		"commit",
+		"/update _vt.vreplication set pos=",
+		"commit",
+		"/update _vt.vreplication set pos=",
+		"commit",
+	))
+	expectData(t, "t1", [][]string{
+		{"1", "ccc"},
+		{"2", "aaa"},
+		{"3", "aaa"},
+	})
+	assert.NotEqual(t, pos, savedPosition(t, id))
+}
+
+// TestPlayerParallelDDL tests that a DDL is applied once the transactions
+// before it are committed, and before the transactions after it are applied.
+func TestPlayerParallelDDL(t *testing.T) {
+	defer deleteTablet(addTablet(100))
+	setParallelReplicationWorkers(t, 4)
+
+	execStatements(t, []string{
+		"create table t1(id int, val varbinary(128), primary key(id))",
+		fmt.Sprintf("create table %s.t1(id int, val varbinary(128), primary key(id))", vrepldb),
+		"create table t2(id int, val varbinary(128), primary key(id))",
+		fmt.Sprintf("create table %s.t2(id int, val varbinary(128), primary key(id))", vrepldb),
+	})
+	defer execStatements(t, []string{
+		"drop table t1",
+		fmt.Sprintf("drop table %s.t1", vrepldb),
+		"drop table t2",
+		fmt.Sprintf("drop table %s.t2", vrepldb),
+	})
+	env.SchemaEngine.Reload(context.Background())
+
+	filter := &binlogdatapb.Filter{
+		Rules: []*binlogdatapb.Rule{{
+			Match: "/.*",
+		}},
+	}
+	bls := &binlogdatapb.BinlogSource{
+		Keyspace: env.KeyspaceName,
+		Shard:    env.ShardName,
+		Filter:   filter,
+		OnDdl:    binlogdatapb.OnDDLAction_EXEC,
+	}
+	cancel, _ := startVReplication(t, bls, "")
+	defer cancel()
+
+	execStatements(t, []string{"insert into t1 values(1, 'aaa')"})
+	expectDBClientQueries(t, qh.Expect(
+		"begin",
+		"insert into t1(id,val) values (1,'aaa')",
+		"/update _vt.vreplication set pos=",
+		"commit",
+	))
+
+	unlock := lockTargetRow(t, "update t1 set val='bbb' where id=1")
+	execStatements(t, []string{
+		"update t1 set val='ccc' where id=1",
+		"alter table t1 add column val2 varbinary(128)",
+		"insert into t2 values(1, 'aaa')",
+	})
+	// Neither the DDL nor the transaction after it is applied while the
+	// transaction before it is blocked.
+	expectDBClientQueries(t, qh.Expect(
+		"begin",
+	))
+
+	unlock()
+	expectDBClientQueries(t, qh.Expect(
+		"update t1 set val='ccc' where id=1",
+		"/update _vt.vreplication set pos=",
+		"commit",
+		"alter table t1 add column val2 varbinary(128)",
+		"/update _vt.vreplication set pos=",
+		"begin",
+		"insert into t2(id,val) values (1,'aaa')",
+		"/update _vt.vreplication set pos=",
+		"commit",
+		// The apply of the DDL on the target generates an "other" event.
+		"/update _vt.vreplication set pos=",
+	))
+	expectData(t, "t1", [][]string{
+		{"1", "ccc", ""},
+	})
+	expectData(t, "t2", [][]string{
+		{"1", "aaa"},
+	})
+}
+
+// TestPlayerParallelUniqueAndForeignKeys tests that the transactions that
+// change tables with unique secondary keys or foreign keys are applied
+// serially, even if they change other rows.
+func TestPlayerParallelUniqueAndForeignKeys(t *testing.T) {
+	testcases := []struct {
+		name   string
+		tables []string
+	}{{
+		name:   "unique key",
+		tables: []string{"create table %st1(id int, val varbinary(128), pid int, primary key(id), unique key(pid))"},
+	}, {
+		name: "foreign key",
+		tables: []string{
+			"create table %sfk_parent(id int, primary key(id))",
+			"create table %st1(id int, val varbinary(128), pid int, primary key(id), foreign key(pid) references fk_parent(id))",
+		},
+	}}
+	for _, tcase := range testcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			defer deleteTablet(addTablet(100))
+			setParallelReplicationWorkers(t, 4)
+
+			var create, drop []string
+			for _, table := range tcase.tables {
+				create = append(create, fmt.Sprintf(table, ""), fmt.Sprintf(table, vrepldb+"."))
+			}
+			for _, table := range []string{"t1", "fk_parent"} {
+				drop = append(drop, "drop table if exists "+table, fmt.Sprintf("drop table if exists %s.%s", vrepldb, table))
+			}
+			execStatements(t, create)
+			defer execStatements(t, drop)
+			env.SchemaEngine.Reload(context.Background())
+
+			filter := &binlogdatapb.Filter{
+				Rules: []*binlogdatapb.Rule{{
+					Match: "/.*",
+				}},
+			}
+			bls := &binlogdatapb.BinlogSource{
+				Keyspace: env.KeyspaceName,
+				Shard:    env.ShardName,
+				Filter:   filter,
+				OnDdl:    binlogdatapb.OnDDLAction_IGNORE,
+			}
+			cancel, _ := startVReplication(t, bls, "")
+			defer cancel()
+
+			execStatements(t, []string{"insert into t1 values(1, 'aaa', null)"})
+			expectDBClientQueries(t, qh.Expect(
+				"begin",
+				"insert into t1(id,val,pid) values (1,'aaa',null)",
+				"/update _vt.vreplication set pos=",
+				"commit",
+			))
+
+			unlock := lockTargetRow(t, "update t1 set val='bbb' where id=1")
+			execStatements(t, []string{
+				"update t1 set val='ccc' where id=1",
+				"insert into t1 values(2, 'aaa', null)",
+			})
+			// The second transaction changes another row, but it's not
+			// applied until the first one is committed.
+			expectDBClientQueries(t, qh.Expect(
+				"begin",
+			))
+
+			unlock()
+			expectDBClientQueries(t, qh.Expect(
+				"update t1 set val='ccc' where id=1",
+				"/update _vt.vreplication set pos=",
+				"commit",
+				"begin",
+				"insert into t1(id,val,pid) values (2,'aaa',null)",
+				"/update _vt.vreplication set pos=",
+				"commit",
+			))
+			expectData(t, "t1", [][]string{
+				{"1", "ccc", ""},
+				{"2", "aaa", ""},
+			})
+		})
+	}
+}
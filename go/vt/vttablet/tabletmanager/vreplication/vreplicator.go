@@ -113,6 +113,10 @@ type vreplicator struct {
 	WorkflowName    string
 
 	throttleUpdatesRateLimiter *timer.RateLimiter
+
+	// sink, if the stream has one, is where the rows are written instead of
+	// the target tables.
+	sink Sink
 }
 
 // newVReplicator creates a new vreplicator. The valid fields from the source are:
@@ -244,6 +248,7 @@ func (vr *vreplicator) replicate(ctx context.Context) error {
 
 	vr.throttleUpdatesRateLimiter = timer.NewRateLimiter(time.Second)
 	defer vr.throttleUpdatesRateLimiter.Stop()
+	defer vr.closeSink()
 
 	for {
 		select {
@@ -263,6 +268,9 @@ func (vr *vreplicator) replicate(ctx context.Context) error {
 		if err := vr.validateBinlogRowImage(); err != nil {
 			return err
 		}
+		if err := vr.openSink(); err != nil {
+			return err
+		}
 
 		// If any of the operations below changed state to Stopped or Error, we should return.
 		if settings.State == binlogdatapb.VReplicationWorkflowState_Stopped || settings.State == binlogdatapb.VReplicationWorkflowState_Error {
@@ -326,6 +334,11 @@ type ColumnInfo struct {
 	ColumnType  string
 	IsPK        bool
 	IsGenerated bool
+	// IsUniqueKey is true if the column is part of a unique secondary key.
+	IsUniqueKey bool
+	// IsForeignKey is true if the column is part of a foreign key, or is
+	// referenced by one.
+	IsForeignKey bool
 }
 
 func (vr *vreplicator) buildColInfoMap(ctx context.Context) (map[string][]*ColumnInfo, error) {
@@ -334,6 +347,7 @@ func (vr *vreplicator) buildColInfoMap(ctx context.Context) (map[string][]*Colum
 	if err != nil {
 		return nil, err
 	}
+	uniqueKeyCols, foreignKeyCols := keyConstrainedColumns(schema.TableDefinitions)
 	queryTemplate := "select character_set_name, collation_name, column_name, data_type, column_type, extra from information_schema.columns where table_schema=%s and table_name=%s;"
 	colInfoMap := make(map[string][]*ColumnInfo)
 	for _, td := range schema.TableDefinitions {
@@ -398,13 +412,15 @@ func (vr *vreplicator) buildColInfoMap(ctx context.Context) (map[string][]*Colum
 				isGenerated = true
 			}
 			colInfo = append(colInfo, &ColumnInfo{
-				Name:        columnName,
-				CharSet:     charSet,
-				Collation:   collation,
-				DataType:    dataType,
-				ColumnType:  columnType,
-				IsPK:        isPK,
-				IsGenerated: isGenerated,
+				Name:         columnName,
+				CharSet:      charSet,
+				Collation:    collation,
+				DataType:     dataType,
+				ColumnType:   columnType,
+				IsPK:         isPK,
+				IsGenerated:  isGenerated,
+				IsUniqueKey:  uniqueKeyCols[td.Name][strings.ToLower(columnName)],
+				IsForeignKey: foreignKeyCols[td.Name][strings.ToLower(columnName)],
 			})
 		}
 		colInfoMap[td.Name] = colInfo
@@ -412,6 +428,62 @@ func (vr *vreplicator) buildColInfoMap(ctx context.Context) (map[string][]*Colum
 	return colInfoMap, nil
 }
 
+// keyConstrainedColumns returns, for each table, the lower cased columns
+// that are part of a unique secondary key and those that are part of a
+// foreign key or referenced by one. The columns of a table whose schema
+// can't be parsed are all considered part of both.
+func keyConstrainedColumns(tds []*tabletmanagerdatapb.TableDefinition) (uniqueKeyCols, foreignKeyCols map[string]map[string]bool) {
+	uniqueKeyCols = make(map[string]map[string]bool)
+	foreignKeyCols = make(map[string]map[string]bool)
+	add := func(cols map[string]map[string]bool, table, column string) {
+		if cols[table] == nil {
+			cols[table] = make(map[string]bool)
+		}
+		cols[table][strings.ToLower(column)] = true
+	}
+	for _, td := range tds {
+		if td.Schema == "" {
+			continue
+		}
+		stmt, err := sqlparser.ParseStrictDDL(td.Schema)
+		if err != nil {
+			log.Warningf("Could not parse the schema of table %s, all its columns are considered part of unique and foreign keys: %v", td.Name, err)
+			for _, column := range td.Columns {
+				add(uniqueKeyCols, td.Name, column)
+				add(foreignKeyCols, td.Name, column)
+			}
+			continue
+		}
+		createTable, ok := stmt.(*sqlparser.CreateTable)
+		if !ok || createTable.GetTableSpec() == nil {
+			// Views have no keys.
+			continue
+		}
+		for _, index := range createTable.GetTableSpec().Indexes {
+			if index.Info.Type != sqlparser.IndexTypeUnique {
+				continue
+			}
+			for _, column := range index.Columns {
+				add(uniqueKeyCols, td.Name, column.Column.String())
+			}
+		}
+		for _, constraint := range createTable.GetTableSpec().Constraints {
+			fk, ok := constraint.Details.(*sqlparser.ForeignKeyDefinition)
+			if !ok {
+				continue
+			}
+			for _, column := range fk.Source {
+				add(foreignKeyCols, td.Name, column.String())
+			}
+			referenced := fk.ReferenceDefinition.ReferencedTable.Name.String()
+			for _, column := range fk.ReferenceDefinition.ReferencedColumns {
+				add(foreignKeyCols, referenced, column.String())
+			}
+		}
+	}
+	return uniqueKeyCols, foreignKeyCols
+}
+
 // Same as readSettings, but stores some of the results on this vr.
 func (vr *vreplicator) loadSettings(ctx context.Context, dbClient *vdbClient) (settings binlogplayer.VRSettings, numTablesToCopy int64, err error) {
 	settings, numTablesToCopy, err = vr.readSettings(ctx, dbClient)
@@ -556,6 +628,41 @@ func (vr *vreplicator) setSQLMode(ctx context.Context, dbClient *vdbClient) (fun
 	return resetFunc, nil
 }
 
+// openSink opens the sink of the stream, if it has one and it's not open yet.
+func (vr *vreplicator) openSink() error {
+	if vr.sink != nil || vr.source.Sink == nil {
+		return nil
+	}
+	sink, err := newSink(vr.source.Sink, SinkParams{
+		Workflow: vr.WorkflowName,
+		StreamID: vr.id,
+	})
+	if err != nil {
+		return vterrors.Wrapf(err, "cannot open the %s sink", vr.source.Sink.Type)
+	}
+	vr.sink = sink
+	return nil
+}
+
+func (vr *vreplicator) closeSink() {
+	if vr.sink == nil {
+		return
+	}
+	if err := vr.sink.Close(); err != nil {
+		log.Warningf("Error closing the sink of stream %d: %v", vr.id, err)
+	}
+	vr.sink = nil
+}
+
+// newApplier returns the applier of the rows of the stream. The rows are
+// written to the sink of the stream, or to the target tables with dbClient.
+func (vr *vreplicator) newApplier(dbClient *vdbClient, onQuery func(query string, start time.Time)) applier {
+	if vr.sink != nil {
+		return &sinkApplier{sink: vr.sink}
+	}
+	return newDBApplier(dbClient, onQuery)
+}
+
 // throttlerAppName returns the app name to be used by throttlerClient for this particular workflow
 // example results:
 //   - "vreplication" for most flows
@@ -86,6 +86,33 @@ func TestRecalculatePKColsInfoByColumnNames(t *testing.T) {
 	}
 }
 
+func TestKeyConstrainedColumns(t *testing.T) {
+	tds := []*tabletmanagerdatapb.TableDefinition{{
+		Name:   "parent",
+		Schema: "create table parent (id int, code varchar(10), name varchar(10), primary key (id), unique key code_idx (code), key name_idx (name))",
+	}, {
+		Name:   "child",
+		Schema: "create table child (id int, parent_id int, primary key (id), constraint child_fk foreign key (parent_id) references parent (id))",
+	}, {
+		Name:   "plain",
+		Schema: "create table plain (id int, val int, primary key (id), key val_idx (val))",
+	}, {
+		Name:    "unparsable",
+		Columns: []string{"id", "Val"},
+		Schema:  "create table unparsable (",
+	}}
+	uniqueKeyCols, foreignKeyCols := keyConstrainedColumns(tds)
+	assert.Equal(t, map[string]map[string]bool{
+		"parent":     {"code": true},
+		"unparsable": {"id": true, "val": true},
+	}, uniqueKeyCols)
+	assert.Equal(t, map[string]map[string]bool{
+		"parent":     {"id": true},
+		"child":      {"parent_id": true},
+		"unparsable": {"id": true, "val": true},
+	}, foreignKeyCols)
+}
+
 func TestPrimaryKeyEquivalentColumns(t *testing.T) {
 	ctx := context.Background()
 	tests := []struct {
@@ -0,0 +1,193 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package adaptivelimiter limits the number of queries executed at the same
+// time by a tablet. The limit is not static: it follows the gradient of the
+// MySQL latency, like the TCP Vegas congestion control. When the recent
+// latency rises above its long term average, MySQL is queueing work and the
+// limit shrinks. When the latency recovers, the limit grows again.
+package adaptivelimiter
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/tabletenv"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+const (
+	// minSamples is the number of latency samples a window needs to update
+	// the limit. The windows with fewer samples are extended.
+	minSamples = 10
+	// longWindowAlpha is the weight of a window in the long term average of
+	// the latency, which covers about 60 windows.
+	longWindowAlpha = 2.0 / 61
+	// smoothing is the weight of a new limit over the previous one.
+	smoothing = 0.2
+	// minGradient bounds how much the limit can shrink in a window.
+	minGradient = 0.5
+)
+
+// Limiter admits the queries of a tablet up to an adaptive concurrency
+// limit. A nil Limiter admits all the queries.
+type Limiter struct {
+	dryRun    bool
+	minLimit  float64
+	maxLimit  float64
+	tolerance float64
+	window    time.Duration
+	now       func() time.Time
+
+	mu sync.Mutex
+	// limit is the current limit, which is kept as a float so that it can
+	// move by less than a query per window.
+	limit float64
+	// inFlight is the number of queries admitted and not yet released, and
+	// maxInFlight its maximum over the current window.
+	inFlight    int
+	maxInFlight int
+	// windowStart, samples and total describe the latency of the queries
+	// released in the current window. longLatency is the long term average
+	// of the latency.
+	windowStart time.Time
+	samples     int
+	total       time.Duration
+	longLatency float64
+
+	rejections *stats.Counter
+}
+
+// New creates the Limiter configured for the tablet, or returns nil if the
+// adaptive concurrency limit is disabled.
+func New(env tabletenv.Env) *Limiter {
+	cfg := env.Config().AdaptiveConcurrencyLimit
+	if cfg.Mode != tabletenv.Enable && cfg.Mode != tabletenv.Dryrun {
+		return nil
+	}
+	return newLimiter(env, cfg, time.Now)
+}
+
+func newLimiter(env tabletenv.Env, cfg tabletenv.AdaptiveConcurrencyLimitConfig, now func() time.Time) *Limiter {
+	l := &Limiter{
+		dryRun:      cfg.Mode == tabletenv.Dryrun,
+		minLimit:    float64(cfg.MinLimit),
+		maxLimit:    float64(cfg.MaxLimit),
+		tolerance:   cfg.LatencyTolerance,
+		window:      cfg.Window,
+		now:         now,
+		limit:       float64(cfg.InitialLimit),
+		windowStart: now(),
+		rejections:  env.Exporter().NewCounter("AdaptiveConcurrencyLimitRejections", "Queries rejected by the adaptive concurrency limit"),
+	}
+	env.Exporter().NewGaugeFunc("AdaptiveConcurrencyLimit", "Current adaptive concurrency limit", func() int64 {
+		return int64(l.Limit())
+	})
+	env.Exporter().NewGaugeFunc("AdaptiveConcurrencyLimitInFlight", "Queries in flight under the adaptive concurrency limit", func() int64 {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return int64(l.inFlight)
+	})
+	return l
+}
+
+// Limit returns the current number of queries allowed in flight.
+func (l *Limiter) Limit() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// Acquire admits a query, or returns an UNAVAILABLE error if the limit of
+// queries in flight is reached, so that vtgate retries the query on another
+// tablet. In dry run mode, the query is counted as rejected but admitted.
+// Release must be called once an admitted query is done.
+func (l *Limiter) Acquire() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inFlight >= int(l.limit) {
+		l.rejections.Add(1)
+		if !l.dryRun {
+			return vterrors.Errorf(vtrpcpb.Code_UNAVAILABLE, "adaptive concurrency limit of %d queries exceeded", int(l.limit))
+		}
+	}
+	l.inFlight++
+	if l.inFlight > l.maxInFlight {
+		l.maxInFlight = l.inFlight
+	}
+	return nil
+}
+
+// Release returns a query admitted by Acquire, with the time MySQL took to
+// execute it. A zero latency, for example for a failed query, is not used to
+// update the limit.
+func (l *Limiter) Release(latency time.Duration) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	if latency > 0 {
+		l.samples++
+		l.total += latency
+	}
+	now := l.now()
+	if now.Sub(l.windowStart) < l.window || l.samples < minSamples {
+		return
+	}
+	l.updateLocked()
+	l.windowStart = now
+	l.samples = 0
+	l.total = 0
+	l.maxInFlight = l.inFlight
+}
+
+// updateLocked moves the limit by the gradient between the long term and the
+// recent latency.
+func (l *Limiter) updateLocked() {
+	short := float64(l.total) / float64(l.samples)
+	if l.longLatency == 0 {
+		l.longLatency = short
+	} else {
+		l.longLatency += longWindowAlpha * (short - l.longLatency)
+	}
+	// After a sustained drop of the latency, the long term average is too
+	// high to detect a new rise: make it converge faster.
+	if l.longLatency/short > 2 {
+		l.longLatency *= 0.95
+	}
+
+	gradient := math.Max(minGradient, math.Min(1, l.tolerance*l.longLatency/short))
+	newLimit := l.limit*gradient + math.Sqrt(l.limit)
+	// Do not grow a limit the queries are far from using: it would not be
+	// backed by any latency measurement.
+	if newLimit > l.limit && float64(l.maxInFlight) < l.limit/2 {
+		return
+	}
+	l.limit = l.limit*(1-smoothing) + newLimit*smoothing
+	l.limit = math.Max(l.minLimit, math.Min(l.maxLimit, l.limit))
+}
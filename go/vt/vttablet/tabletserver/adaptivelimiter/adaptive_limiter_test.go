@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adaptivelimiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/tabletenv"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestLimiter(t *testing.T, mode string) (*Limiter, *fakeClock) {
+	t.Helper()
+	cfg := tabletenv.NewDefaultConfig().AdaptiveConcurrencyLimit
+	cfg.Mode = mode
+	cfg.InitialLimit = 20
+	cfg.MinLimit = 5
+	cfg.MaxLimit = 100
+	clock := &fakeClock{now: time.Now()}
+	return newLimiter(tabletenv.NewEnv(nil, t.Name()), cfg, clock.Now), clock
+}
+
+// runWindow runs a window of enough queries to update the limit, with the
+// given number in flight at the same time, each taking the given latency.
+// The window ends with the last query.
+func runWindow(t *testing.T, l *Limiter, clock *fakeClock, concurrency int, latency time.Duration) {
+	t.Helper()
+	rounds := (minSamples + concurrency - 1) / concurrency
+	for r := 0; r < rounds; r++ {
+		for i := 0; i < concurrency; i++ {
+			require.NoError(t, l.Acquire())
+		}
+		for i := 0; i < concurrency; i++ {
+			if r == rounds-1 && i == concurrency-1 {
+				clock.now = clock.now.Add(time.Second)
+			}
+			l.Release(latency)
+		}
+	}
+}
+
+func TestLimiterGradient(t *testing.T) {
+	l, clock := newTestLimiter(t, tabletenv.Enable)
+	assert.Equal(t, 20, l.Limit())
+
+	// A stable latency grows the limit, as long as the queries use it.
+	for i := 0; i < 5; i++ {
+		runWindow(t, l, clock, l.Limit(), 10*time.Millisecond)
+	}
+	grown := l.Limit()
+	assert.Greater(t, grown, 20)
+
+	// The limit does not grow when the queries do not use it.
+	for i := 0; i < 5; i++ {
+		runWindow(t, l, clock, grown/4, 10*time.Millisecond)
+	}
+	assert.Equal(t, grown, l.Limit())
+
+	// A rise of the latency shrinks the limit.
+	for i := 0; i < 5; i++ {
+		runWindow(t, l, clock, l.Limit(), 50*time.Millisecond)
+	}
+	assert.Less(t, l.Limit(), grown)
+
+	// A latency that keeps rising shrinks it down to its minimum.
+	latency := 50 * time.Millisecond
+	for i := 0; i < 60; i++ {
+		latency = latency * 6 / 5
+		runWindow(t, l, clock, l.Limit(), latency)
+	}
+	assert.Equal(t, 5, l.Limit())
+
+	// The limit grows again once the latency recovers.
+	for i := 0; i < 20; i++ {
+		runWindow(t, l, clock, l.Limit(), 10*time.Millisecond)
+	}
+	assert.Greater(t, l.Limit(), 10)
+}
+
+func TestLimiterWindow(t *testing.T) {
+	l, clock := newTestLimiter(t, tabletenv.Enable)
+	runWindow(t, l, clock, l.Limit(), 10*time.Millisecond)
+	limit := l.Limit()
+
+	// The limit is not updated before the end of a window, nor with too few
+	// latency samples.
+	for i := 0; i < minSamples-1; i++ {
+		require.NoError(t, l.Acquire())
+		l.Release(time.Second)
+	}
+	assert.Equal(t, limit, l.Limit())
+	clock.now = clock.now.Add(time.Second)
+	require.NoError(t, l.Acquire())
+	l.Release(0)
+	assert.Equal(t, limit, l.Limit())
+
+	// The window is extended until it has enough samples.
+	require.NoError(t, l.Acquire())
+	l.Release(time.Second)
+	assert.Less(t, l.Limit(), limit)
+}
+
+func TestLimiterRejections(t *testing.T) {
+	l, _ := newTestLimiter(t, tabletenv.Enable)
+	for i := 0; i < 20; i++ {
+		require.NoError(t, l.Acquire())
+	}
+	err := l.Acquire()
+	assert.EqualError(t, err, "adaptive concurrency limit of 20 queries exceeded")
+	assert.Equal(t, vtrpcpb.Code_UNAVAILABLE, vterrors.Code(err))
+	assert.EqualValues(t, 1, l.rejections.Get())
+
+	l.Release(0)
+	assert.NoError(t, l.Acquire())
+}
+
+func TestLimiterDryRun(t *testing.T) {
+	l, _ := newTestLimiter(t, tabletenv.Dryrun)
+	for i := 0; i < 25; i++ {
+		require.NoError(t, l.Acquire())
+	}
+	assert.EqualValues(t, 5, l.rejections.Get())
+}
+
+func TestNilLimiter(t *testing.T) {
+	cfg := tabletenv.NewDefaultConfig()
+	l := New(tabletenv.NewEnv(cfg, t.Name()))
+	require.Nil(t, l)
+	assert.NoError(t, l.Acquire())
+	l.Release(time.Second)
+	assert.Equal(t, 0, l.Limit())
+}
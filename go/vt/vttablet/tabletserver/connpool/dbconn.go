@@ -57,6 +57,10 @@ type DBConn struct {
 	setting      string
 	resetSetting string
 
+	// workloadClass is the workload class the connection is accounted to
+	// while it is in use.
+	workloadClass string
+
 	// err will be set if a query is killed through a Kill.
 	errmu sync.Mutex
 	err   error
@@ -398,6 +402,7 @@ func (dbc *DBConn) Recycle() {
 	case dbc.pool == nil:
 		dbc.Close()
 	case dbc.conn.IsClosed():
+		dbc.pool.releaseWorkloadClass(dbc)
 		dbc.pool.Put(nil)
 	default:
 		dbc.pool.Put(dbc)
@@ -409,6 +414,7 @@ func (dbc *DBConn) Taint() {
 	if dbc.pool == nil {
 		return
 	}
+	dbc.pool.releaseWorkloadClass(dbc)
 	dbc.pool.Put(nil)
 	dbc.pool = nil
 }
@@ -443,6 +449,29 @@ func (dbc *DBConn) Kill(reason string, elapsed time.Duration) error {
 	return nil
 }
 
+// KillQuery kills the currently executing query on the MySQL side, but
+// unlike Kill it leaves the connection open, so that the transaction or
+// the reserved session which owns it survives.
+func (dbc *DBConn) KillQuery(reason string, elapsed time.Duration) error {
+	dbc.stats.KillCounters.Add("Queries", 1)
+	log.Infof("Due to %s, elapsed time: %v, killing the statement of query ID %v %s", reason, elapsed, dbc.conn.ID(), dbc.CurrentForLogging())
+
+	killConn, err := dbc.dbaPool.Get(context.TODO())
+	if err != nil {
+		log.Warningf("Failed to get conn from dba pool: %v", err)
+		return err
+	}
+	defer killConn.Recycle()
+	sql := fmt.Sprintf("kill query %d", dbc.conn.ID())
+	_, err = killConn.ExecuteFetch(sql, 10000, false)
+	if err != nil {
+		log.Errorf("Could not kill the statement of query ID %v %s: %v", dbc.conn.ID(),
+			dbc.CurrentForLogging(), err)
+		return err
+	}
+	return nil
+}
+
 // Current returns the currently executing query.
 func (dbc *DBConn) Current() string {
 	return dbc.current.Load().(string)
@@ -291,6 +291,28 @@ func TestDBConnKill(t *testing.T) {
 	}
 }
 
+func TestDBConnKillQuery(t *testing.T) {
+	db := fakesqldb.New(t)
+	defer db.Close()
+	connPool := newPool()
+	connPool.Open(db.ConnParams(), db.ConnParams(), db.ConnParams())
+	defer connPool.Close()
+	dbConn, err := NewDBConn(context.Background(), connPool, db.ConnParams())
+	if dbConn != nil {
+		defer dbConn.Close()
+	}
+	require.NoError(t, err)
+
+	db.AddQuery(fmt.Sprintf("kill query %d", dbConn.ID()), &sqltypes.Result{})
+	require.NoError(t, dbConn.KillQuery("test kill", 0))
+	// Only the statement is killed, the connection remains usable.
+	require.False(t, dbConn.IsClosed())
+	require.NoError(t, dbConn.Err())
+
+	db.AddRejectedQuery(fmt.Sprintf("kill query %d", dbConn.ID()), errors.New("rejected"))
+	require.ErrorContains(t, dbConn.KillQuery("test kill", 0), "rejected")
+}
+
 // TestDBConnClose tests that an Exec returns immediately if a connection
 // is asynchronously killed (and closed) in the middle of an execution.
 func TestDBConnClose(t *testing.T) {
@@ -36,6 +36,7 @@ import (
 	"vitess.io/vitess/go/vt/servenv"
 	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/tabletenv"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/workloadclass"
 
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 )
@@ -69,6 +70,7 @@ type Pool struct {
 	dbaPool         *dbconnpool.ConnectionPool
 	appDebugParams  dbconfigs.Connector
 	getConnTime     *servenv.TimingsWrapper
+	classes         *workloadclass.Gate
 }
 
 // NewPool creates a new Pool. The name is used
@@ -89,6 +91,7 @@ func NewPool(env tabletenv.Env, name string, cfg tabletenv.ConnPoolConfig) *Pool
 	if name == "" {
 		return cp
 	}
+	cp.classes = workloadclass.NewGate(env, name, cfg.Size, cfg.WorkloadClasses)
 	env.Exporter().NewGaugeFunc(name+"Capacity", "Tablet server conn pool capacity", cp.Capacity)
 	env.Exporter().NewGaugeFunc(name+"Available", "Tablet server conn pool available", cp.Available)
 	env.Exporter().NewGaugeFunc(name+"Active", "Tablet server conn pool active", cp.Active)
@@ -205,8 +208,13 @@ func (cp *Pool) Get(ctx context.Context, setting *pools.Setting) (*DBConn, error
 	}
 
 	start := time.Now()
+	class, err := cp.classes.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
 	r, err := p.Get(ctx, setting)
 	if err != nil {
+		cp.classes.Release(class)
 		return nil, err
 	}
 	if cp.getConnTime != nil {
@@ -216,7 +224,9 @@ func (cp *Pool) Get(ctx context.Context, setting *pools.Setting) (*DBConn, error
 			cp.getConnTime.Record(getWithS, start)
 		}
 	}
-	return r.(*DBConn), nil
+	conn := r.(*DBConn)
+	conn.workloadClass = class
+	return conn, nil
 }
 
 // Put puts a connection into the pool.
@@ -228,10 +238,18 @@ func (cp *Pool) Put(conn *DBConn) {
 	if conn == nil {
 		p.Put(nil)
 	} else {
+		cp.releaseWorkloadClass(conn)
 		p.Put(conn)
 	}
 }
 
+// releaseWorkloadClass returns the connection to the capacity of the
+// workload class it was accounted to by Get.
+func (cp *Pool) releaseWorkloadClass(conn *DBConn) {
+	cp.classes.Release(conn.workloadClass)
+	conn.workloadClass = ""
+}
+
 // SetCapacity alters the size of the pool at runtime.
 func (cp *Pool) SetCapacity(capacity int) (err error) {
 	cp.mu.Lock()
@@ -243,6 +261,7 @@ func (cp *Pool) SetCapacity(capacity int) (err error) {
 		}
 	}
 	cp.capacity = capacity
+	cp.classes.SetCapacity(capacity)
 	return nil
 }
 
@@ -31,6 +31,7 @@ import (
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/callerid"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/tabletenv"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/workloadclass"
 )
 
 func TestConnPoolGet(t *testing.T) {
@@ -72,6 +73,45 @@ func TestConnPoolTimeout(t *testing.T) {
 	assert.EqualError(t, err, "resource pool timed out")
 }
 
+func TestConnPoolWorkloadClasses(t *testing.T) {
+	db := fakesqldb.New(t)
+	defer db.Close()
+
+	cfg := tabletenv.ConnPoolConfig{
+		Size: 2,
+		WorkloadClasses: map[string]tabletenv.WorkloadClassConfig{
+			"oltp": {Reserved: 1},
+		},
+	}
+	_ = cfg.TimeoutSeconds.Set("100ms")
+	connPool := NewPool(tabletenv.NewEnv(nil, "PoolTest"), "TestPool", cfg)
+	connPool.Open(db.ConnParams(), db.ConnParams(), db.ConnParams())
+	defer connPool.Close()
+
+	// The only connection that is not reserved for oltp is taken.
+	dbConn, err := connPool.Get(context.Background(), nil)
+	require.NoError(t, err)
+	_, err = connPool.Get(context.Background(), nil)
+	assert.EqualError(t, err, "TestPool: workload class default exceeded its capacity: context deadline exceeded")
+
+	oltpCtx := workloadclass.NewContext(context.Background(), "oltp")
+	oltpConn, err := connPool.Get(oltpCtx, nil)
+	require.NoError(t, err)
+	oltpConn.Recycle()
+
+	// The connections return to their workload class when they are closed
+	// or tainted too.
+	dbConn.Close()
+	dbConn.Recycle()
+	dbConn, err = connPool.Get(context.Background(), nil)
+	require.NoError(t, err)
+	dbConn.Taint()
+	dbConn.Close()
+	dbConn, err = connPool.Get(context.Background(), nil)
+	require.NoError(t, err)
+	dbConn.Recycle()
+}
+
 func TestConnPoolMaxWaiters(t *testing.T) {
 	db := fakesqldb.New(t)
 	defer db.Close()
@@ -394,6 +394,38 @@ func (hs *healthStreamer) reload(full map[string]*schema.Table, created, altered
 	return nil
 }
 
+// UnresolvedTransactions signals the vtgates that this tablet has
+// distributed transactions that need to be resolved.
+func (hs *healthStreamer) UnresolvedTransactions() {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	// Only the primary is responsible for the transactions it coordinates.
+	if !hs.isServingPrimary {
+		return
+	}
+
+	hs.state.RealtimeStats.TxUnresolved = true
+	shr := hs.state.CloneVT()
+	hs.broadCastToClients(shr)
+	hs.state.RealtimeStats.TxUnresolved = false
+}
+
+// TableStatisticsChanged signals the vtgates that the statistics of the
+// tables have changed, so that they fetch them again.
+func (hs *healthStreamer) TableStatisticsChanged() {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	// The vtgates only track the schema of the primary.
+	if !hs.isServingPrimary {
+		return
+	}
+
+	hs.state.RealtimeStats.TableStatisticsChanged = true
+	shr := hs.state.CloneVT()
+	hs.broadCastToClients(shr)
+	hs.state.RealtimeStats.TableStatisticsChanged = false
+}
+
 func (hs *healthStreamer) reloadTables(ctx context.Context, conn *connpool.DBConn, tableNames []string) error {
 	if len(tableNames) == 0 {
 		return nil
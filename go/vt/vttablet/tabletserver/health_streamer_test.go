@@ -194,6 +194,87 @@ func TestHealthStreamerBroadcast(t *testing.T) {
 	assert.Truef(t, proto.Equal(want, shr), "want: %v, got: %v", want, shr)
 }
 
+func TestHealthStreamerUnresolvedTransactions(t *testing.T) {
+	db := fakesqldb.New(t)
+	defer db.Close()
+	config := newConfig(db)
+	config.SignalWhenSchemaChange = false
+
+	env := tabletenv.NewEnv(config, "TestUnresolvedTransactions")
+	alias := &topodatapb.TabletAlias{
+		Cell: "cell",
+		Uid:  1,
+	}
+	blpFunc = testBlpFunc
+	hs := newHealthStreamer(env, alias, &schema.Engine{})
+	target := &querypb.Target{TabletType: topodatapb.TabletType_PRIMARY}
+	hs.InitDBConfig(target, db.ConnParams())
+	hs.Open()
+	defer hs.Close()
+
+	ch, cancel := testStream(hs)
+	defer cancel()
+	<-ch
+
+	// Nothing is sent when the tablet is not the serving primary.
+	hs.UnresolvedTransactions()
+	select {
+	case shr := <-ch:
+		t.Fatalf("unexpected health response: %v", shr)
+	default:
+	}
+
+	hs.MakePrimary(true)
+	hs.UnresolvedTransactions()
+	shr := <-ch
+	assert.True(t, shr.RealtimeStats.TxUnresolved)
+
+	// The signal is only sent once.
+	hs.ChangeState(topodatapb.TabletType_PRIMARY, time.Now(), 0, nil, true)
+	shr = <-ch
+	assert.False(t, shr.RealtimeStats.TxUnresolved)
+}
+
+func TestHealthStreamerTableStatisticsChanged(t *testing.T) {
+	db := fakesqldb.New(t)
+	defer db.Close()
+	config := newConfig(db)
+	config.SignalWhenSchemaChange = false
+
+	env := tabletenv.NewEnv(config, "TestTableStatisticsChanged")
+	alias := &topodatapb.TabletAlias{
+		Cell: "cell",
+		Uid:  1,
+	}
+	blpFunc = testBlpFunc
+	hs := newHealthStreamer(env, alias, &schema.Engine{})
+	target := &querypb.Target{TabletType: topodatapb.TabletType_PRIMARY}
+	hs.InitDBConfig(target, db.ConnParams())
+	hs.Open()
+	defer hs.Close()
+
+	ch, cancel := testStream(hs)
+	defer cancel()
+	<-ch
+
+	// The vtgates only track the statistics of the primary.
+	hs.TableStatisticsChanged()
+	select {
+	case shr := <-ch:
+		t.Fatalf("unexpected health response: %v", shr)
+	default:
+	}
+
+	hs.MakePrimary(true)
+	hs.TableStatisticsChanged()
+	shr := <-ch
+	assert.True(t, shr.RealtimeStats.TableStatisticsChanged)
+
+	hs.ChangeState(topodatapb.TabletType_PRIMARY, time.Now(), 0, nil, true)
+	shr = <-ch
+	assert.False(t, shr.RealtimeStats.TableStatisticsChanged)
+}
+
 func TestReloadSchema(t *testing.T) {
 	testcases := []struct {
 		name               string
@@ -75,6 +75,7 @@ func analyzeUpdate(upd *sqlparser.Update, tables map[string]*schema.Table) (plan
 		buf := sqlparser.NewTrackedBuffer(nil)
 		buf.Myprintf("%v", upd.Where)
 		plan.WhereClause = buf.ParsedQuery()
+		plan.PKWhereClause = pkWhereClause(upd.Where, plan.Table)
 	}
 
 	// Situations when we pass-through:
@@ -93,6 +94,51 @@ func analyzeUpdate(upd *sqlparser.Update, tables map[string]*schema.Table) (plan
 	return plan, nil
 }
 
+// pkWhereClause returns the equality conditions of a WHERE clause on the
+// primary key columns of the table, in the order of the primary key, or nil
+// if some primary key column has no such condition.
+func pkWhereClause(where *sqlparser.Where, table *schema.Table) *sqlparser.ParsedQuery {
+	if table == nil || !table.HasPrimary() {
+		return nil
+	}
+	values := make(map[string]sqlparser.Expr)
+	for _, expr := range sqlparser.SplitAndExpression(nil, where.Expr) {
+		comp, ok := expr.(*sqlparser.ComparisonExpr)
+		if !ok || comp.Operator != sqlparser.EqualOp {
+			continue
+		}
+		col, val := comp.Left, comp.Right
+		if _, ok := col.(*sqlparser.ColName); !ok {
+			col, val = val, col
+		}
+		colName, ok := col.(*sqlparser.ColName)
+		if !ok {
+			continue
+		}
+		switch val.(type) {
+		case *sqlparser.Literal, *sqlparser.Argument:
+			values[colName.Name.Lowered()] = val
+		}
+	}
+
+	conds := make([]sqlparser.Expr, 0, len(table.PKColumns))
+	for i := range table.PKColumns {
+		name := table.GetPKColumn(i).Name
+		val, ok := values[strings.ToLower(name)]
+		if !ok {
+			return nil
+		}
+		conds = append(conds, &sqlparser.ComparisonExpr{
+			Operator: sqlparser.EqualOp,
+			Left:     sqlparser.NewColName(name),
+			Right:    val,
+		})
+	}
+	buf := sqlparser.NewTrackedBuffer(nil)
+	buf.Myprintf("%v", sqlparser.NewWhere(sqlparser.WhereClause, sqlparser.AndExpressions(conds...)))
+	return buf.ParsedQuery()
+}
+
 // analyzeDelete code is almost identical to analyzeUpdate.
 func analyzeDelete(del *sqlparser.Delete, tables map[string]*schema.Table) (plan *Plan, err error) {
 	plan = &Plan{
@@ -104,6 +150,7 @@ func analyzeDelete(del *sqlparser.Delete, tables map[string]*schema.Table) (plan
 		buf := sqlparser.NewTrackedBuffer(nil)
 		buf.Myprintf("%v", del.Where)
 		plan.WhereClause = buf.ParsedQuery()
+		plan.PKWhereClause = pkWhereClause(del.Where, plan.Table)
 	}
 
 	if PassthroughDMLs || plan.Table == nil || del.Limit != nil {
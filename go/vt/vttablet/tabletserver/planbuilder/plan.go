@@ -173,6 +173,13 @@ type Plan struct {
 	// to serialize e.g. UPDATEs going to the same row.
 	WhereClause *sqlparser.ParsedQuery
 
+	// PKWhereClause is set for DMLs which target a single row, i.e. whose
+	// WHERE clause has an equality condition for each primary key column. It
+	// contains only these conditions, in the order of the primary key, so
+	// that the hot row protection serializes the DMLs by row, regardless of
+	// the rest of their WHERE clause.
+	PKWhereClause *sqlparser.ParsedQuery
+
 	// FullStmt can be used when the query does not operate on tables
 	FullStmt sqlparser.Statement
 
@@ -48,13 +48,15 @@ func (p *Plan) MarshalJSON() ([]byte, error) {
 		FullQuery         *sqlparser.ParsedQuery `json:",omitempty"`
 		NextCount         string                 `json:",omitempty"`
 		WhereClause       *sqlparser.ParsedQuery `json:",omitempty"`
+		PKWhereClause     *sqlparser.ParsedQuery `json:",omitempty"`
 		NeedsReservedConn bool                   `json:",omitempty"`
 	}{
-		PlanID:      p.PlanID,
-		TableName:   p.TableName(),
-		Permissions: p.Permissions,
-		FullQuery:   p.FullQuery,
-		WhereClause: p.WhereClause,
+		PlanID:        p.PlanID,
+		TableName:     p.TableName(),
+		Permissions:   p.Permissions,
+		FullQuery:     p.FullQuery,
+		WhereClause:   p.WhereClause,
+		PKWhereClause: p.PKWhereClause,
 	}
 	if p.NextCount != nil {
 		mplan.NextCount = evalengine.FormatExpr(p.NextCount)
@@ -295,6 +295,7 @@ func NewQueryEngine(env tabletenv.Env, se *schema.Engine) *QueryEngine {
 	qe.queryErrorCountsWithCode = env.Exporter().NewCountersWithMultiLabels("QueryErrorCountsWithCode", "query error counts with error code", []string{"Table", "Plan", "Code"})
 
 	env.Exporter().HandleFunc("/debug/hotrows", qe.txSerializer.ServeHTTP)
+	env.Exporter().HandleFunc("/debug/hotkeys", qe.txSerializer.ServeHotKeysHTTP)
 	env.Exporter().HandleFunc("/debug/tablet_plans", qe.handleHTTPQueryPlans)
 	env.Exporter().HandleFunc("/debug/query_stats", qe.handleHTTPQueryStats)
 	env.Exporter().HandleFunc("/debug/query_rules", qe.handleHTTPQueryRules)
@@ -45,6 +45,8 @@ import (
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/rules"
 	eschema "vitess.io/vitess/go/vt/vttablet/tabletserver/schema"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/tabletenv"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/txserializer"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/workloadclass"
 
 	querypb "vitess.io/vitess/go/vt/proto/query"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
@@ -148,9 +150,38 @@ func (qre *QueryExecutor) Execute() (reply *sqltypes.Result, err error) {
 		qre.tsv.Stats().ResultHistogram.Add(int64(len(reply.Rows)))
 	}(time.Now())
 
-	if err = qre.checkPermissions(); err != nil {
+	release, err := qre.checkPermissions()
+	if err != nil {
 		return nil, err
 	}
+	defer release()
+	qre.setWorkloadClass()
+
+	if qre.connID == 0 && qre.tsv.enableHotRowProtection {
+		done, err := qre.waitForSameRowAutocommits()
+		if err != nil {
+			return nil, err
+		}
+		if done != nil {
+			defer done()
+		}
+	}
+
+	// The queries of transactions and reserved connections are not limited:
+	// rejecting them would fail the whole transaction, and they already hold
+	// a connection. The internal queries are not limited either.
+	if qre.connID == 0 && !tabletenv.IsLocalContext(qre.ctx) {
+		if err = qre.tsv.adaptiveLimiter.Acquire(); err != nil {
+			return nil, err
+		}
+		defer func() {
+			var latency time.Duration
+			if err == nil {
+				latency = qre.logStats.MysqlResponseTime
+			}
+			qre.tsv.adaptiveLimiter.Release(latency)
+		}()
+	}
 
 	if qre.plan.PlanID == p.PlanNextval {
 		return qre.execNextval()
@@ -317,9 +348,12 @@ func (qre *QueryExecutor) Stream(callback StreamCallback) error {
 		qre.recordUserQuery("Stream", int64(time.Since(start)))
 	}(time.Now())
 
-	if err := qre.checkPermissions(); err != nil {
+	release, err := qre.checkPermissions()
+	if err != nil {
 		return err
 	}
+	defer release()
+	qre.setWorkloadClass()
 
 	switch qre.plan.PlanID {
 	case p.PlanSelectStream:
@@ -407,9 +441,11 @@ func (qre *QueryExecutor) MessageStream(callback StreamCallback) error {
 		qre.recordUserQuery("MessageStream", int64(time.Since(start)))
 	}(time.Now())
 
-	if err := qre.checkPermissions(); err != nil {
+	release, err := qre.checkPermissions()
+	if err != nil {
 		return err
 	}
+	defer release()
 
 	done, err := qre.tsv.messager.Subscribe(qre.ctx, qre.plan.TableName().String(), func(r *sqltypes.Result) error {
 		select {
@@ -426,12 +462,49 @@ func (qre *QueryExecutor) MessageStream(callback StreamCallback) error {
 	return nil
 }
 
+// waitForSameRowAutocommits serializes an autocommit UPDATE or DELETE with
+// the other DMLs in flight for the same row (range), like BeginExecute does
+// for the first DML of a transaction. The returned func is not nil if the
+// query was queued, and must be called once it is done.
+func (qre *QueryExecutor) waitForSameRowAutocommits() (txserializer.DoneFunc, error) {
+	key, table := txSerializerKey(qre.plan, qre.query, qre.bindVars)
+	if key == "" {
+		// Query is not subject to tx serialization/hot row protection.
+		return nil, nil
+	}
+
+	startTime := time.Now()
+	done, waited, err := qre.tsv.qe.txSerializer.Wait(qre.ctx, key, table)
+	if waited {
+		qre.tsv.stats.WaitTimings.Record("TxSerializer", startTime)
+	}
+	return done, err
+}
+
+// setWorkloadClass sets the workload class of the query when it matches a
+// query rule, which takes precedence over the workload class of the request.
+func (qre *QueryExecutor) setWorkloadClass() {
+	remoteAddr := ""
+	username := ""
+	ci, ok := callinfo.FromContext(qre.ctx)
+	if ok {
+		remoteAddr = ci.RemoteAddr()
+		username = ci.Username()
+	}
+	if class := qre.plan.Rules.GetWorkloadClass(remoteAddr, username, qre.bindVars, qre.marginComments); class != "" {
+		qre.ctx = workloadclass.NewContext(qre.ctx, class)
+	}
+}
+
 // checkPermissions returns an error if the query does not pass all checks
-// (denied query, table ACL).
-func (qre *QueryExecutor) checkPermissions() error {
+// (denied query, rate or concurrency limit, table ACL). The returned function
+// must be called once the query is done, to release its slot in the limit of
+// the query rule it matched.
+func (qre *QueryExecutor) checkPermissions() (release func(), err error) {
+	release = func() {}
 	// Skip permissions check if the context is local.
 	if tabletenv.IsLocalContext(qre.ctx) {
-		return nil
+		return release, nil
 	}
 
 	// Check if the query relates to a table that is in the denylist.
@@ -443,16 +516,16 @@ func (qre *QueryExecutor) checkPermissions() error {
 		username = ci.Username()
 	}
 
-	action, ruleCancelCtx, timeout, desc := qre.plan.Rules.GetAction(remoteAddr, username, qre.bindVars, qre.marginComments)
+	action, ruleCancelCtx, timeout, desc, limiter := qre.plan.Rules.GetAction(remoteAddr, username, qre.bindVars, qre.marginComments)
 
 	bufferingTimeoutCtx, cancel := context.WithTimeout(qre.ctx, timeout) // aborts buffering at given timeout
 	defer cancel()
 
 	switch action {
 	case rules.QRFail:
-		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "disallowed due to rule: %s", desc)
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "disallowed due to rule: %s", desc)
 	case rules.QRFailRetry:
-		return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "disallowed due to rule: %s", desc)
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "disallowed due to rule: %s", desc)
 	case rules.QRBuffer:
 		if ruleCancelCtx != nil {
 			// We buffer up to some timeout. The timeout is determined by ctx.Done().
@@ -462,44 +535,58 @@ func (qre *QueryExecutor) checkPermissions() error {
 				// good! We have buffered the query, and buffering is completed
 			case <-bufferingTimeoutCtx.Done():
 				// Sorry, timeout while waiting for buffering to complete
-				return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "buffer timeout after %v in rule: %s", timeout, desc)
+				return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "buffer timeout after %v in rule: %s", timeout, desc)
 			}
 		}
+	case rules.QRRateLimit, rules.QRConcurrencyLimit:
+		var limitRelease func()
+		limitRelease, err = limiter.Acquire(qre.ctx, timeout, desc)
+		if err != nil {
+			qre.tsv.Stats().QueryRuleLimited.Add(desc, 1)
+			return nil, err
+		}
+		// The slot is released right away when the query is not allowed.
+		defer func() {
+			if err != nil {
+				limitRelease()
+			}
+		}()
+		release = limitRelease
 	default:
 		// no rules against this query. Good to proceed
 	}
 	// Skip ACL check for queries against the dummy dual table
 	if qre.plan.TableName().String() == "dual" {
-		return nil
+		return release, nil
 	}
 
 	// Skip the ACL check if the connecting user is an exempted superuser.
 	if qre.tsv.qe.exemptACL != nil && qre.tsv.qe.exemptACL.IsMember(&querypb.VTGateCallerID{Username: username}) {
 		qre.tsv.qe.tableaclExemptCount.Add(1)
-		return nil
+		return release, nil
 	}
 
 	callerID := callerid.ImmediateCallerIDFromContext(qre.ctx)
 	if callerID == nil {
 		if qre.tsv.qe.strictTableACL {
-			return vterrors.Errorf(vtrpcpb.Code_UNAUTHENTICATED, "missing caller id")
+			return nil, vterrors.Errorf(vtrpcpb.Code_UNAUTHENTICATED, "missing caller id")
 		}
-		return nil
+		return release, nil
 	}
 
 	// Skip the ACL check if the caller id is an exempted superuser.
 	if qre.tsv.qe.exemptACL != nil && qre.tsv.qe.exemptACL.IsMember(callerID) {
 		qre.tsv.qe.tableaclExemptCount.Add(1)
-		return nil
+		return release, nil
 	}
 
 	for i, auth := range qre.plan.Authorized {
 		if err := qre.checkAccess(auth, qre.plan.Permissions[i].TableName, callerID); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	return nil
+	return release, nil
 }
 
 func (qre *QueryExecutor) checkAccess(authorized *tableacl.ACLResult, tableName string, callerID *querypb.VTGateCallerID) error {
@@ -1070,6 +1157,7 @@ func (qre *QueryExecutor) execDBConn(conn *connpool.DBConn, sql string, wantfiel
 	defer qre.logStats.AddRewrittenSQL(sql, time.Now())
 
 	qd := NewQueryDetail(qre.logStats.Ctx, conn)
+	qd.sessionUUID = qre.options.GetSessionUuid()
 	qre.tsv.statelessql.Add(qd)
 	defer qre.tsv.statelessql.Remove(qd)
 
@@ -1083,6 +1171,7 @@ func (qre *QueryExecutor) execStatefulConn(conn *StatefulConnection, sql string,
 	defer qre.logStats.AddRewrittenSQL(sql, time.Now())
 
 	qd := NewQueryDetail(qre.logStats.Ctx, conn)
+	qd.sessionUUID = qre.options.GetSessionUuid()
 	qre.tsv.statefulql.Add(qd)
 	defer qre.tsv.statefulql.Remove(qd)
 
@@ -1106,6 +1195,7 @@ func (qre *QueryExecutor) execStreamSQL(conn *connpool.DBConn, isTransaction boo
 	// This change will ensure that long-running streaming stateful queries get gracefully shutdown during ServingTypeChange
 	// once their grace period is over.
 	qd := NewQueryDetail(qre.logStats.Ctx, conn)
+	qd.sessionUUID = qre.options.GetSessionUuid()
 	if isTransaction {
 		qre.tsv.statefulql.Add(qd)
 		defer qre.tsv.statefulql.Remove(qd)
@@ -1134,6 +1224,8 @@ func (qre *QueryExecutor) GetSchemaDefinitions(tableType querypb.SchemaTableType
 		return qre.getTableDefinitions(tableNames, callback)
 	case querypb.SchemaTableType_ALL:
 		return qre.getAllDefinitions(tableNames, callback)
+	case querypb.SchemaTableType_STATISTICS:
+		return callback(&querypb.GetSchemaResponse{TableStatistics: qre.tsv.se.GetTableStatistics(tableNames)})
 	}
 	return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid table type %v", tableType)
 }
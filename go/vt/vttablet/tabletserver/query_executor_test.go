@@ -36,6 +36,7 @@ import (
 	"vitess.io/vitess/go/vt/callinfo"
 	"vitess.io/vitess/go/vt/callinfo/fakecallinfo"
 	"vitess.io/vitess/go/vt/sidecardb"
+	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/tableacl"
 	"vitess.io/vitess/go/vt/tableacl/simpleacl"
 	"vitess.io/vitess/go/vt/topo/memorytopo"
@@ -45,6 +46,7 @@ import (
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/tabletenv"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/tx"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/txthrottler"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/workloadclass"
 
 	querypb "vitess.io/vitess/go/vt/proto/query"
 	tableaclpb "vitess.io/vitess/go/vt/proto/tableacl"
@@ -1238,6 +1240,176 @@ func TestQueryExecutorDenyListQRRetry(t *testing.T) {
 	}
 }
 
+func TestQueryExecutorConcurrencyLimitRule(t *testing.T) {
+	db := setUpQueryExecutorTest(t)
+	defer db.Close()
+	query := "select * from test_table limit 1000"
+	db.AddQuery(query, &sqltypes.Result{
+		Fields: getTestTableFields(),
+	})
+
+	db.AddQuery("select * from test_table where 1 != 1", &sqltypes.Result{
+		Fields: getTestTableFields(),
+	})
+
+	limitedUser := "x"
+
+	limitRule := rules.NewQueryRule("limit test_table", "limit test_table", rules.QRConcurrencyLimit)
+	limitRule.SetUserCond(limitedUser)
+	limitRule.AddTableCond("test_table")
+	require.NoError(t, limitRule.SetLimit(1, 0))
+
+	rulesName := "concurrencyLimitRules"
+	qrs := rules.New()
+	qrs.Add(limitRule)
+
+	callInfo := &fakecallinfo.FakeCallInfo{
+		Remote: "127.0.0.1",
+		User:   limitedUser,
+	}
+	ctx := callinfo.NewContext(context.Background(), callInfo)
+	tsv := newTestTabletServer(ctx, noFlags, db)
+	tsv.qe.queryRuleSources.UnRegisterSource(rulesName)
+	tsv.qe.queryRuleSources.RegisterSource(rulesName)
+	defer tsv.qe.queryRuleSources.UnRegisterSource(rulesName)
+	require.NoError(t, tsv.qe.queryRuleSources.SetRules(rulesName, qrs))
+	defer tsv.StopService()
+
+	// The slot of a query is released once it is done.
+	for i := 0; i < 2; i++ {
+		_, err := newTestQueryExecutor(ctx, tsv, query, 0).Execute()
+		require.NoError(t, err)
+	}
+
+	// Another query holds the only slot of the rule.
+	_, _, _, _, limiter := qrs.GetAction("127.0.0.1", limitedUser, nil, sqlparser.MarginComments{})
+	release, err := limiter.Acquire(ctx, 0, "limit test_table")
+	require.NoError(t, err)
+	_, err = newTestQueryExecutor(ctx, tsv, query, 0).Execute()
+	assert.EqualError(t, err, "concurrency limit of 1 queries exceeded in rule: limit test_table")
+	assert.Equal(t, vtrpcpb.Code_RESOURCE_EXHAUSTED, vterrors.Code(err))
+	assert.EqualValues(t, 1, tsv.Stats().QueryRuleLimited.Counts()["limit test_table"])
+
+	release()
+	_, err = newTestQueryExecutor(ctx, tsv, query, 0).Execute()
+	require.NoError(t, err)
+}
+
+func TestQueryExecutorConcurrencyLimitRuleTableAclNoPermission(t *testing.T) {
+	aclName := fmt.Sprintf("simpleacl-test-%d", rand.Int63())
+	tableacl.Register(aclName, &simpleacl.Factory{})
+	tableacl.SetDefaultACL(aclName)
+	db := setUpQueryExecutorTest(t)
+	defer db.Close()
+	query := "select * from test_table limit 1000"
+	db.AddQuery(query, &sqltypes.Result{
+		Fields: getTestTableFields(),
+	})
+
+	limitRule := rules.NewQueryRule("limit test_table", "limit test_table", rules.QRConcurrencyLimit)
+	limitRule.AddTableCond("test_table")
+	require.NoError(t, limitRule.SetLimit(1, 0))
+
+	rulesName := "concurrencyLimitRules"
+	qrs := rules.New()
+	qrs.Add(limitRule)
+
+	config := &tableaclpb.Config{
+		TableGroups: []*tableaclpb.TableGroupSpec{{
+			Name:                 "group02",
+			TableNamesOrPrefixes: []string{"test_table"},
+			Readers:              []string{"superuser"},
+		}},
+	}
+	require.NoError(t, tableacl.InitFromProto(config))
+
+	ctx := callerid.NewContext(context.Background(), nil, &querypb.VTGateCallerID{Username: "u2"})
+	tsv := newTestTabletServer(ctx, enableStrictTableACL, db)
+	tsv.qe.queryRuleSources.UnRegisterSource(rulesName)
+	tsv.qe.queryRuleSources.RegisterSource(rulesName)
+	defer tsv.qe.queryRuleSources.UnRegisterSource(rulesName)
+	require.NoError(t, tsv.qe.queryRuleSources.SetRules(rulesName, qrs))
+	defer tsv.StopService()
+
+	// The slot of a query denied by the table ACL after it acquired it is
+	// released, so that the next query is denied by the table ACL again.
+	limited := tsv.Stats().QueryRuleLimited.Counts()["limit test_table"]
+	for i := 0; i < 2; i++ {
+		_, err := newTestQueryExecutor(ctx, tsv, query, 0).Execute()
+		require.Error(t, err)
+		assert.Equal(t, vtrpcpb.Code_PERMISSION_DENIED, vterrors.Code(err))
+	}
+	assert.Equal(t, limited, tsv.Stats().QueryRuleLimited.Counts()["limit test_table"])
+}
+
+func TestQueryExecutorWorkloadClassRule(t *testing.T) {
+	db := setUpQueryExecutorTest(t)
+	defer db.Close()
+	query := "select * from test_table limit 1000"
+	db.AddQuery(query, &sqltypes.Result{
+		Fields: getTestTableFields(),
+	})
+
+	classRule := rules.NewQueryRule("batch test_table", "batch test_table", rules.QRWorkloadClass)
+	classRule.AddTableCond("test_table")
+	require.NoError(t, classRule.SetWorkloadClass("batch"))
+
+	rulesName := "workloadClassRules"
+	qrs := rules.New()
+	qrs.Add(classRule)
+
+	ctx := context.Background()
+	tsv := newTestTabletServer(ctx, batchWorkloadClass, db)
+	tsv.qe.queryRuleSources.UnRegisterSource(rulesName)
+	tsv.qe.queryRuleSources.RegisterSource(rulesName)
+	defer tsv.qe.queryRuleSources.UnRegisterSource(rulesName)
+	require.NoError(t, tsv.qe.queryRuleSources.SetRules(rulesName, qrs))
+	defer tsv.StopService()
+
+	_, err := newTestQueryExecutor(ctx, tsv, query, 0).Execute()
+	require.NoError(t, err)
+
+	// Another batch query holds the only connection of the class.
+	conn, err := tsv.qe.conns.Get(workloadclass.NewContext(ctx, "batch"), nil)
+	require.NoError(t, err)
+	_, err = newTestQueryExecutor(ctx, tsv, query, 0).Execute()
+	assert.ErrorContains(t, err, "ConnPool: workload class batch exceeded its capacity")
+
+	conn.Recycle()
+	_, err = newTestQueryExecutor(ctx, tsv, query, 0).Execute()
+	require.NoError(t, err)
+}
+
+func TestQueryExecutorAdaptiveConcurrencyLimit(t *testing.T) {
+	db := setUpQueryExecutorTest(t)
+	defer db.Close()
+	query := "select * from test_table limit 1000"
+	db.AddQuery(query, &sqltypes.Result{
+		Fields: getTestTableFields(),
+	})
+
+	ctx := context.Background()
+	tsv := newTestTabletServer(ctx, adaptiveConcurrencyLimit, db)
+	defer tsv.StopService()
+
+	_, err := newTestQueryExecutor(ctx, tsv, query, 0).Execute()
+	require.NoError(t, err)
+
+	// Another query holds the only query allowed in flight.
+	require.NoError(t, tsv.adaptiveLimiter.Acquire())
+	_, err = newTestQueryExecutor(ctx, tsv, query, 0).Execute()
+	assert.EqualError(t, err, "adaptive concurrency limit of 1 queries exceeded")
+	assert.Equal(t, vtrpcpb.Code_UNAVAILABLE, vterrors.Code(err))
+
+	// The internal queries are not limited.
+	_, err = newTestQueryExecutor(tabletenv.LocalContext(), tsv, query, 0).Execute()
+	require.NoError(t, err)
+
+	tsv.adaptiveLimiter.Release(0)
+	_, err = newTestQueryExecutor(ctx, tsv, query, 0).Execute()
+	require.NoError(t, err)
+}
+
 func TestReplaceSchemaName(t *testing.T) {
 	db := setUpQueryExecutorTest(t)
 	defer db.Close()
@@ -1445,6 +1617,8 @@ const (
 	smallResultSize
 	disableOnlineDDL
 	enableConsolidator
+	batchWorkloadClass
+	adaptiveConcurrencyLimit
 )
 
 // newTestQueryExecutor uses a package level variable testTabletServer defined in tabletserver_test.go
@@ -1485,6 +1659,17 @@ func newTestTabletServer(ctx context.Context, flags executorFlags, db *fakesqldb
 	} else {
 		config.Consolidator = tabletenv.Disable
 	}
+	if flags&batchWorkloadClass > 0 {
+		_ = config.OltpReadPool.TimeoutSeconds.Set("100ms")
+		config.OltpReadPool.WorkloadClasses = map[string]tabletenv.WorkloadClassConfig{
+			"batch": {Burst: 1},
+		}
+	}
+	if flags&adaptiveConcurrencyLimit > 0 {
+		config.AdaptiveConcurrencyLimit.Mode = tabletenv.Enable
+		config.AdaptiveConcurrencyLimit.MinLimit = 1
+		config.AdaptiveConcurrencyLimit.InitialLimit = 1
+	}
 	dbconfigs := newDBConfigs(db)
 	config.DB = dbconfigs
 	tsv := NewTabletServer(ctx, "TabletServerTest", config, memorytopo.NewServer(ctx, ""), &topodatapb.TabletAlias{})
@@ -35,12 +35,15 @@ type QueryDetail struct {
 	conn   killable
 	connID int64
 	start  time.Time
+	// sessionUUID is the UUID of the vtgate session which sent the query, if any.
+	sessionUUID string
 }
 
 type killable interface {
 	Current() string
 	ID() int64
 	Kill(message string, elapsed time.Duration) error
+	KillQuery(message string, elapsed time.Duration) error
 }
 
 // NewQueryDetail creates a new QueryDetail
@@ -114,6 +117,26 @@ func (ql *QueryList) Terminate(connID int64) bool {
 	return true
 }
 
+// TerminateSession kills all the queries sent by the vtgate session with the
+// given UUID, and returns how many were killed. Only the statements are
+// killed: the connections, and so the transactions and reserved connections
+// of the session, are kept.
+func (ql *QueryList) TerminateSession(sessionUUID string) int {
+	ql.mu.Lock()
+	defer ql.mu.Unlock()
+	count := 0
+	for _, qds := range ql.queryDetails {
+		for _, qd := range qds {
+			if qd.sessionUUID != sessionUUID {
+				continue
+			}
+			_ = qd.conn.KillQuery("QueryList.TerminateSession()", time.Since(qd.start))
+			count++
+		}
+	}
+	return count
+}
+
 // TerminateAll terminates all queries and kills the MySQL connections
 func (ql *QueryList) TerminateAll() {
 	ql.mu.Lock()
@@ -25,9 +25,10 @@ import (
 )
 
 type testConn struct {
-	id     int64
-	query  string
-	killed bool
+	id          int64
+	query       string
+	killed      bool
+	queryKilled bool
 }
 
 func (tc *testConn) Current() string { return tc.query }
@@ -39,6 +40,11 @@ func (tc *testConn) Kill(string, time.Duration) error {
 	return nil
 }
 
+func (tc *testConn) KillQuery(string, time.Duration) error {
+	tc.queryKilled = true
+	return nil
+}
+
 func (tc *testConn) IsKilled() bool {
 	return tc.killed
 }
@@ -90,3 +96,28 @@ func TestQueryListChangeConnIDInMiddle(t *testing.T) {
 	require.Equal(t, qd1, ql.queryDetails[1][0])
 	require.NotEqual(t, qd2, ql.queryDetails[1][0])
 }
+
+func TestQueryListTerminateSession(t *testing.T) {
+	ql := NewQueryList("test")
+	conns := []*testConn{{id: 1}, {id: 2}, {id: 3}}
+	for i, sessionUUID := range []string{"session1", "session2", "session1"} {
+		qd := NewQueryDetail(context.Background(), conns[i])
+		qd.sessionUUID = sessionUUID
+		ql.Add(qd)
+	}
+	// A query which was not sent by a vtgate session.
+	other := &testConn{id: 4}
+	ql.Add(NewQueryDetail(context.Background(), other))
+
+	require.Equal(t, 2, ql.TerminateSession("session1"))
+	require.True(t, conns[0].queryKilled)
+	require.False(t, conns[1].queryKilled)
+	require.True(t, conns[2].queryKilled)
+	require.False(t, other.queryKilled)
+	// the connections themselves are kept
+	for _, conn := range conns {
+		require.False(t, conn.IsKilled())
+	}
+
+	require.Zero(t, ql.TerminateSession("session3"))
+}
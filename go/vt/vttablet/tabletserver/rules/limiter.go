@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
+
+	"vitess.io/vitess/go/vt/vterrors"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// Limiter caps the rate or the concurrency of the queries matching a
+// QRRateLimit or a QRConcurrencyLimit rule. It is shared by all the copies of
+// the rule, so the limit applies to the queries of all the plans the rule is
+// filtered into. A new limiter is created when the rules are reloaded.
+type Limiter struct {
+	act   Action
+	limit int
+
+	rate        *rate.Limiter
+	concurrency *semaphore.Weighted
+}
+
+// newLimiter creates the limiter of a rule.
+func newLimiter(act Action, limit int) (*Limiter, error) {
+	if limit <= 0 {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want a positive Limit, got %d", limit)
+	}
+	l := &Limiter{act: act, limit: limit}
+	switch act {
+	case QRRateLimit:
+		// The queries are spread over the second, with bursts of up to
+		// limit queries.
+		l.rate = rate.NewLimiter(rate.Limit(limit), limit)
+	case QRConcurrencyLimit:
+		l.concurrency = semaphore.NewWeighted(int64(limit))
+	default:
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "a Limit is only valid for the RATE_LIMIT and CONCURRENCY_LIMIT actions")
+	}
+	return l, nil
+}
+
+// Acquire returns once the query is allowed to run by the limit. When the
+// limit is exceeded, it waits for up to timeout for the query to be allowed,
+// or fails right away when timeout is zero. The returned function must be
+// called once the query is done.
+func (l *Limiter) Acquire(ctx context.Context, timeout time.Duration, desc string) (release func(), err error) {
+	switch {
+	case l.rate != nil && timeout == 0:
+		if !l.rate.Allow() {
+			return nil, vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "rate limit of %d queries per second exceeded in rule: %s", l.limit, desc)
+		}
+		return func() {}, nil
+	case l.rate != nil:
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		// Wait fails right away when the query cannot be allowed before
+		// the timeout.
+		if err := l.rate.Wait(ctx); err != nil {
+			return nil, vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "rate limit of %d queries per second exceeded after %v in rule: %s", l.limit, timeout, desc)
+		}
+		return func() {}, nil
+	case timeout == 0:
+		if !l.concurrency.TryAcquire(1) {
+			return nil, vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "concurrency limit of %d queries exceeded in rule: %s", l.limit, desc)
+		}
+		return func() { l.concurrency.Release(1) }, nil
+	default:
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		if err := l.concurrency.Acquire(ctx, 1); err != nil {
+			return nil, vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "concurrency limit of %d queries exceeded after %v in rule: %s", l.limit, timeout, desc)
+		}
+		return func() { l.concurrency.Release(1) }, nil
+	}
+}
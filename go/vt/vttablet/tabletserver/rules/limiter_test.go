@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/vterrors"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+func TestLimiterConcurrency(t *testing.T) {
+	ctx := context.Background()
+	l, err := newLimiter(QRConcurrencyLimit, 2)
+	require.NoError(t, err)
+
+	release1, err := l.Acquire(ctx, 0, "r1")
+	require.NoError(t, err)
+	release2, err := l.Acquire(ctx, 0, "r1")
+	require.NoError(t, err)
+
+	_, err = l.Acquire(ctx, 0, "r1")
+	assert.EqualError(t, err, "concurrency limit of 2 queries exceeded in rule: r1")
+	assert.Equal(t, vtrpcpb.Code_RESOURCE_EXHAUSTED, vterrors.Code(err))
+	_, err = l.Acquire(ctx, 10*time.Millisecond, "r1")
+	assert.EqualError(t, err, "concurrency limit of 2 queries exceeded after 10ms in rule: r1")
+
+	// A queued query runs once a running one is done.
+	done := make(chan error)
+	go func() {
+		release, err := l.Acquire(ctx, 10*time.Second, "r1")
+		if err == nil {
+			release()
+		}
+		done <- err
+	}()
+	release1()
+	require.NoError(t, <-done)
+	release2()
+
+	release, err := l.Acquire(ctx, 0, "r1")
+	require.NoError(t, err)
+	release()
+}
+
+func TestLimiterRate(t *testing.T) {
+	ctx := context.Background()
+	l, err := newLimiter(QRRateLimit, 2)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		release, err := l.Acquire(ctx, 0, "r1")
+		require.NoError(t, err)
+		release()
+	}
+	_, err = l.Acquire(ctx, 0, "r1")
+	assert.EqualError(t, err, "rate limit of 2 queries per second exceeded in rule: r1")
+	assert.Equal(t, vtrpcpb.Code_RESOURCE_EXHAUSTED, vterrors.Code(err))
+	// The next query is allowed in 500ms.
+	_, err = l.Acquire(ctx, 10*time.Millisecond, "r1")
+	assert.EqualError(t, err, "rate limit of 2 queries per second exceeded after 10ms in rule: r1")
+	_, err = l.Acquire(ctx, 10*time.Second, "r1")
+	require.NoError(t, err)
+}
+
+func TestNewLimiter(t *testing.T) {
+	_, err := newLimiter(QRRateLimit, 0)
+	assert.EqualError(t, err, "want a positive Limit, got 0")
+	_, err = newLimiter(QRFail, 1)
+	assert.EqualError(t, err, "a Limit is only valid for the RATE_LIMIT and CONCURRENCY_LIMIT actions")
+}
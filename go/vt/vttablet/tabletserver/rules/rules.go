@@ -171,6 +171,8 @@ func (qrs *Rules) FilterByPlan(query string, planid planbuilder.PlanType, tableN
 }
 
 // GetAction runs the input against the rules engine and returns the action to be performed.
+// The limiter is only returned for the QRRateLimit and QRConcurrencyLimit actions.
+// The QRWorkloadClass rules are skipped, see GetWorkloadClass.
 func (qrs *Rules) GetAction(
 	ip,
 	user string,
@@ -180,13 +182,37 @@ func (qrs *Rules) GetAction(
 	action Action,
 	cancelCtx context.Context,
 	timeout time.Duration,
-	desc string) {
+	desc string,
+	limiter *Limiter) {
 	for _, qr := range qrs.rules {
+		if qr.act == QRWorkloadClass {
+			continue
+		}
+		if act := qr.GetAction(ip, user, bindVars, marginComments); act != QRContinue {
+			return act, qr.cancelCtx, qr.timeout, qr.Description, qr.limiter
+		}
+	}
+	return QRContinue, nil, 0, "", nil
+}
+
+// GetWorkloadClass runs the input against the QRWorkloadClass rules and
+// returns the workload class of the first matching rule, or an empty string
+// if none matches.
+func (qrs *Rules) GetWorkloadClass(
+	ip,
+	user string,
+	bindVars map[string]*querypb.BindVariable,
+	marginComments sqlparser.MarginComments,
+) string {
+	for _, qr := range qrs.rules {
+		if qr.act != QRWorkloadClass {
+			continue
+		}
 		if act := qr.GetAction(ip, user, bindVars, marginComments); act != QRContinue {
-			return act, qr.cancelCtx, qr.timeout, qr.Description
+			return qr.workloadClass
 		}
 	}
-	return QRContinue, nil, 0, ""
+	return ""
 }
 
 //-----------------------------------------------
@@ -225,6 +251,13 @@ type Rule struct {
 
 	// a rule can timeout.
 	timeout time.Duration
+
+	// a rate or concurrency limit rule caps the matching queries, and
+	// queues them for up to timeout when it is exceeded.
+	limiter *Limiter
+
+	// a workload class rule sets the workload class of the matching queries.
+	workloadClass string
 }
 
 type namedRegexp struct {
@@ -270,6 +303,8 @@ func (qr *Rule) Equal(other *Rule) bool {
 		qr.leadingComment.Equal(other.leadingComment) &&
 		qr.trailingComment.Equal(other.trailingComment) &&
 		qr.timeout == other.timeout &&
+		qr.Limit() == other.Limit() &&
+		qr.workloadClass == other.workloadClass &&
 		reflect.DeepEqual(qr.plans, other.plans) &&
 		reflect.DeepEqual(qr.tableNames, other.tableNames) &&
 		reflect.DeepEqual(qr.bindVarConds, other.bindVarConds) &&
@@ -289,6 +324,8 @@ func (qr *Rule) Copy() (newqr *Rule) {
 		act:             qr.act,
 		cancelCtx:       qr.cancelCtx,
 		timeout:         qr.timeout,
+		limiter:         qr.limiter,
+		workloadClass:   qr.workloadClass,
 	}
 	if qr.plans != nil {
 		newqr.plans = make([]planbuilder.PlanType, len(qr.plans))
@@ -340,10 +377,60 @@ func (qr *Rule) MarshalJSON() ([]byte, error) {
 	if qr.timeout != 0 {
 		safeEncode(b, `,"Timeout":`, qr.timeout)
 	}
+	if qr.limiter != nil {
+		safeEncode(b, `,"Limit":`, qr.limiter.limit)
+	}
+	if qr.workloadClass != "" {
+		safeEncode(b, `,"WorkloadClass":`, qr.workloadClass)
+	}
 	_, _ = b.WriteString("}")
 	return b.Bytes(), nil
 }
 
+// SetLimit sets the number of matching queries a QRRateLimit rule allows per
+// second, or a QRConcurrencyLimit rule allows to run at once. The queries
+// exceeding the limit wait for up to timeout for their turn, or fail right
+// away when timeout is zero.
+func (qr *Rule) SetLimit(limit int, timeout time.Duration) (err error) {
+	if timeout < 0 {
+		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want a non negative Timeout, got %v", timeout)
+	}
+	qr.limiter, err = newLimiter(qr.act, limit)
+	if err != nil {
+		return err
+	}
+	qr.timeout = timeout
+	return nil
+}
+
+// Limit returns the limit of a QRRateLimit or QRConcurrencyLimit rule, or
+// zero for the other rules.
+func (qr *Rule) Limit() int {
+	if qr.limiter == nil {
+		return 0
+	}
+	return qr.limiter.limit
+}
+
+// SetWorkloadClass sets the workload class a QRWorkloadClass rule assigns to
+// the matching queries.
+func (qr *Rule) SetWorkloadClass(class string) error {
+	if qr.act != QRWorkloadClass {
+		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "a WorkloadClass is only valid for the WORKLOAD_CLASS action")
+	}
+	if class == "" {
+		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want a non empty WorkloadClass")
+	}
+	qr.workloadClass = class
+	return nil
+}
+
+// WorkloadClass returns the workload class of a QRWorkloadClass rule, or an
+// empty string for the other rules.
+func (qr *Rule) WorkloadClass() string {
+	return qr.workloadClass
+}
+
 // SetIPCond adds a regular expression condition for the client IP.
 // It has to be a full match (not substring).
 func (qr *Rule) SetIPCond(pattern string) (err error) {
@@ -574,6 +661,9 @@ const (
 	QRFail
 	QRFailRetry
 	QRBuffer
+	QRRateLimit
+	QRConcurrencyLimit
+	QRWorkloadClass
 )
 
 // MarshalJSON marshals to JSON.
@@ -587,6 +677,12 @@ func (act Action) MarshalJSON() ([]byte, error) {
 		str = "FAIL_RETRY"
 	case QRBuffer:
 		str = "BUFFER"
+	case QRRateLimit:
+		str = "RATE_LIMIT"
+	case QRConcurrencyLimit:
+		str = "CONCURRENCY_LIMIT"
+	case QRWorkloadClass:
+		str = "WORKLOAD_CLASS"
 	default:
 		str = "INVALID"
 	}
@@ -872,16 +968,45 @@ func MapStrOperator(strop string) (op Operator, err error) {
 // BuildQueryRule builds a query rule from a ruleInfo.
 func BuildQueryRule(ruleInfo map[string]any) (qr *Rule, err error) {
 	qr = NewQueryRule("", "", QRFail)
+	var (
+		limit         int64
+		timeout       time.Duration
+		workloadClass string
+	)
 	for k, v := range ruleInfo {
 		var sv string
 		var lv []any
 		var ok bool
 		switch k {
-		case "Name", "Description", "RequestIP", "User", "Query", "Action", "LeadingComment", "TrailingComment":
+		case "Name", "Description", "RequestIP", "User", "Query", "Action", "LeadingComment", "TrailingComment", "WorkloadClass":
 			sv, ok = v.(string)
 			if !ok {
 				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want string for %s", k)
 			}
+		case "Limit":
+			nv, ok := v.(json.Number)
+			if !ok {
+				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want int for Limit")
+			}
+			if limit, err = nv.Int64(); err != nil {
+				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want int for Limit")
+			}
+		case "Timeout":
+			// The timeout is either a duration like "500ms", or a number of
+			// nanoseconds as in the marshaled rules.
+			switch tv := v.(type) {
+			case string:
+				timeout, err = time.ParseDuration(tv)
+			case json.Number:
+				var ns int64
+				ns, err = tv.Int64()
+				timeout = time.Duration(ns)
+			default:
+				err = fmt.Errorf("%T", v)
+			}
+			if err != nil {
+				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want duration for Timeout")
+			}
 		case "Plans", "BindVarConds", "TableNames":
 			lv, ok = v.([]any)
 			if !ok {
@@ -920,6 +1045,8 @@ func BuildQueryRule(ruleInfo map[string]any) (qr *Rule, err error) {
 			if err != nil {
 				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "could not set TrailingComment condition: %v", sv)
 			}
+		case "WorkloadClass":
+			workloadClass = sv
 		case "Plans":
 			for _, p := range lv {
 				pv, ok := p.(string)
@@ -959,11 +1086,30 @@ func BuildQueryRule(ruleInfo map[string]any) (qr *Rule, err error) {
 				qr.act = QRFailRetry
 			case "BUFFER":
 				qr.act = QRBuffer
+			case "RATE_LIMIT":
+				qr.act = QRRateLimit
+			case "CONCURRENCY_LIMIT":
+				qr.act = QRConcurrencyLimit
+			case "WORKLOAD_CLASS":
+				qr.act = QRWorkloadClass
 			default:
 				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid Action %s", sv)
 			}
 		}
 	}
+	switch {
+	case qr.act == QRRateLimit || qr.act == QRConcurrencyLimit:
+		if err := qr.SetLimit(int(limit), timeout); err != nil {
+			return nil, err
+		}
+	case limit != 0 || timeout != 0:
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Limit and Timeout are only valid for the RATE_LIMIT and CONCURRENCY_LIMIT actions")
+	}
+	if qr.act == QRWorkloadClass || workloadClass != "" {
+		if err := qr.SetWorkloadClass(workloadClass); err != nil {
+			return nil, err
+		}
+	}
 	return qr, nil
 }
 
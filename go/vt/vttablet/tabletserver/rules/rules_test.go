@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/sqlparser"
@@ -543,23 +544,23 @@ func TestAction(t *testing.T) {
 		Trailing: "other trailing comments",
 	}
 
-	action, cancelCtx, timeout, desc := qrs.GetAction("123", "user1", bv, mc)
+	action, cancelCtx, timeout, desc, _ := qrs.GetAction("123", "user1", bv, mc)
 	assert.Equalf(t, action, QRFail, "expected fail, got %v", action)
 	assert.Equalf(t, timeout, time.Duration(0), "expected zero timeout")
 	assert.Equalf(t, desc, "rule 1", "want rule 1, got %s", desc)
 	assert.Nil(t, cancelCtx)
 
-	action, cancelCtx, timeout, desc = qrs.GetAction("1234", "user", bv, mc)
+	action, cancelCtx, timeout, desc, _ = qrs.GetAction("1234", "user", bv, mc)
 	assert.Equalf(t, action, QRFailRetry, "want fail_retry, got: %s", action)
 	assert.Equalf(t, timeout, time.Duration(0), "expected zero timeout")
 	assert.Equalf(t, desc, "rule 2", "want rule 2, got %s", desc)
 	assert.Nil(t, cancelCtx)
 
-	action, _, _, _ = qrs.GetAction("1234", "user1", bv, mc)
+	action, _, _, _, _ = qrs.GetAction("1234", "user1", bv, mc)
 	assert.Equalf(t, action, QRContinue, "want continue, got %s", action)
 
 	bv["a"] = sqltypes.Uint64BindVariable(1)
-	action, _, _, desc = qrs.GetAction("1234", "user1", bv, mc)
+	action, _, _, desc, _ = qrs.GetAction("1234", "user1", bv, mc)
 	assert.Equalf(t, action, QRFail, "want fail, got %s", action)
 	assert.Equalf(t, desc, "rule 3", "want rule 3, got %s", desc)
 
@@ -572,7 +573,7 @@ func TestAction(t *testing.T) {
 	newQrs := qrs.Copy()
 	newQrs.Add(qr4)
 
-	action, _, _, desc = newQrs.GetAction("1234", "user1", bv, mc)
+	action, _, _, desc, _ = newQrs.GetAction("1234", "user1", bv, mc)
 	assert.Equalf(t, action, QRFail, "want fail, got %s", action)
 	assert.Equalf(t, desc, "rule 4", "want rule 4, got %s", desc)
 
@@ -581,7 +582,7 @@ func TestAction(t *testing.T) {
 
 	newQrs = qrs.Copy()
 	newQrs.Add(qr5)
-	action, _, _, desc = newQrs.GetAction("1234", "user1", bv, mc)
+	action, _, _, desc, _ = newQrs.GetAction("1234", "user1", bv, mc)
 	assert.Equalf(t, action, QRFail, "want fail, got %s", action)
 	assert.Equalf(t, desc, "rule 5", "want rule 5, got %s", desc)
 }
@@ -734,6 +735,13 @@ var invalidjsons = []InvalidJSONCase{
 	{`[{"BindVarConds": [{"Name": "a", "OnAbsent": true, "OnMismatch": true, "Operator": "NOMATCH", "Value": "["}]}]`, "processing [: error parsing regexp: missing closing ]: `[$`"},
 	{`[{"Action": 1 }]`, "want string for Action"},
 	{`[{"Action": "foo" }]`, "invalid Action foo"},
+	{`[{"Action": "RATE_LIMIT" }]`, "want a positive Limit, got 0"},
+	{`[{"Action": "CONCURRENCY_LIMIT", "Limit": "1" }]`, "want int for Limit"},
+	{`[{"Action": "CONCURRENCY_LIMIT", "Limit": 1, "Timeout": "1" }]`, "want duration for Timeout"},
+	{`[{"Action": "CONCURRENCY_LIMIT", "Limit": 1, "Timeout": "-1s" }]`, "want a non negative Timeout, got -1s"},
+	{`[{"Action": "FAIL", "Limit": 1 }]`, "Limit and Timeout are only valid for the RATE_LIMIT and CONCURRENCY_LIMIT actions"},
+	{`[{"Action": "WORKLOAD_CLASS" }]`, "want a non empty WorkloadClass"},
+	{`[{"Action": "FAIL", "WorkloadClass": "batch" }]`, "a WorkloadClass is only valid for the WORKLOAD_CLASS action"},
 }
 
 func TestInvalidJSON(t *testing.T) {
@@ -771,6 +779,72 @@ func TestBuildQueryRuleActionFail(t *testing.T) {
 	}
 }
 
+func TestBuildQueryRuleLimit(t *testing.T) {
+	qrs := New()
+	err := qrs.UnmarshalJSON([]byte(`[{
+		"Name": "r1",
+		"TableNames": ["t1"],
+		"Action": "RATE_LIMIT",
+		"Limit": 100
+	},{
+		"Name": "r2",
+		"User": "batch",
+		"Action": "CONCURRENCY_LIMIT",
+		"Limit": 4,
+		"Timeout": "1.5s"
+	}]`))
+	require.NoError(t, err)
+
+	r1 := qrs.Find("r1")
+	assert.Equal(t, QRRateLimit, r1.act)
+	assert.Equal(t, 100, r1.Limit())
+	assert.Zero(t, r1.timeout)
+	r2 := qrs.Find("r2")
+	assert.Equal(t, QRConcurrencyLimit, r2.act)
+	assert.Equal(t, 4, r2.Limit())
+	assert.Equal(t, 1500*time.Millisecond, r2.timeout)
+
+	// The marshaled rules are unmarshaled to the same rules.
+	want := `[{"Description":"","Name":"r1","TableNames":["t1"],"Action":"RATE_LIMIT","Limit":100},` +
+		`{"Description":"","Name":"r2","User":"batch","Action":"CONCURRENCY_LIMIT","Timeout":1500000000,"Limit":4}]`
+	assert.Equal(t, want, marshalled(qrs))
+	newQrs := New()
+	require.NoError(t, newQrs.UnmarshalJSON([]byte(want)))
+	assert.True(t, qrs.Equal(newQrs))
+
+	// The rules filtered by plan share the limiter of the rule.
+	action, _, timeout, desc, limiter := qrs.FilterByPlan("select * from t2", planbuilder.PlanSelect, "t2").GetAction("", "batch", nil, sqlparser.MarginComments{})
+	assert.Equal(t, QRConcurrencyLimit, action)
+	assert.Equal(t, 1500*time.Millisecond, timeout)
+	assert.Equal(t, "", desc)
+	assert.Same(t, r2.limiter, limiter)
+}
+
+func TestWorkloadClassRule(t *testing.T) {
+	qrs := New()
+	err := qrs.UnmarshalJSON([]byte(`[{
+		"Name": "r1",
+		"User": "report.*",
+		"Action": "WORKLOAD_CLASS",
+		"WorkloadClass": "analytics"
+	},{
+		"Name": "r2",
+		"User": "reporter",
+		"Action": "FAIL"
+	}]`))
+	require.NoError(t, err)
+	assert.Equal(t, "analytics", qrs.Find("r1").WorkloadClass())
+	assert.Equal(t, `[{"Description":"","Name":"r1","User":"report.*","Action":"WORKLOAD_CLASS","WorkloadClass":"analytics"},`+
+		`{"Description":"","Name":"r2","User":"reporter","Action":"FAIL"}]`, marshalled(qrs))
+	assert.True(t, qrs.Equal(qrs.Copy()))
+
+	// The workload class rules don't hide the rules after them.
+	action, _, _, _, _ := qrs.GetAction("", "reporter", nil, sqlparser.MarginComments{})
+	assert.Equal(t, QRFail, action)
+	assert.Equal(t, "analytics", qrs.GetWorkloadClass("", "reporter", nil, sqlparser.MarginComments{}))
+	assert.Equal(t, "", qrs.GetWorkloadClass("", "user", nil, sqlparser.MarginComments{}))
+}
+
 func TestBadAddBindVarCond(t *testing.T) {
 	qr1 := NewQueryRule("rule 1", "r1", QRFail)
 	err := qr1.AddBindVarCond("a", true, false, QRMatch, uint64(1))
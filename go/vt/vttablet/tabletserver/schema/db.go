@@ -86,6 +86,13 @@ where table_schema = database() and table_name in ::viewNames`
 
 	// fetchTablesAndViews queries fetches all information about tables and views
 	fetchTablesAndViews = `select table_name, create_statement from %s.tables where table_schema = database() union select table_name, create_statement from %s.views where table_schema = database()`
+
+	// fetchTableRowCounts retrieves the estimated row counts of the tables from information_schema.tables.
+	fetchTableRowCounts = `select table_name, table_rows from information_schema.tables where table_schema = database() and table_type = 'BASE TABLE'`
+
+	// fetchIndexStatistics retrieves the columns and cardinalities of the indexes from information_schema.statistics.
+	fetchIndexStatistics = `select table_name, index_name, non_unique, column_name, cardinality from information_schema.statistics
+where table_schema = database() order by table_name, index_name, seq_in_index`
 )
 
 // reloadTablesDataInDB reloads teh tables information we have stored in our database we use for schema-tracking.
@@ -50,6 +50,7 @@ import (
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/tabletenv"
 
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 )
@@ -82,6 +83,13 @@ type Engine struct {
 	// SkipMetaCheck skips the metadata about the database and table information
 	SkipMetaCheck bool
 
+	// collectStatistics stores if the statistics of the tables are collected
+	// when the schema is reloaded with stats. statistics and statisticsNotifier
+	// are protected by mu.
+	collectStatistics  bool
+	statistics         map[string]*querypb.TableStatistics
+	statisticsNotifier func()
+
 	historian *historian
 
 	conns         *connpool.Pool
@@ -111,6 +119,7 @@ func NewEngine(env tabletenv.Env) *Engine {
 		ticks: timer.NewTimer(reloadTime),
 	}
 	se.schemaCopy = env.Config().SignalWhenSchemaChange
+	se.collectStatistics = env.Config().EnableTableStatistics
 	_ = env.Exporter().NewGaugeDurationFunc("SchemaReloadTime", "vttablet keeps table schemas in its own memory and periodically refreshes it from MySQL. This config controls the reload time.", se.ticks.Interval)
 	se.tableFileSizeGauge = env.Exporter().NewGaugesWithSingleLabel("TableFileSize", "tracks table file size", "Table")
 	se.tableAllocatedSizeGauge = env.Exporter().NewGaugesWithSingleLabel("TableAllocatedSize", "tracks table allocated size", "Table")
@@ -312,6 +321,7 @@ func (se *Engine) closeLocked() {
 	se.conns.Close()
 
 	se.tables = make(map[string]*Table)
+	se.statistics = nil
 	se.lastChange = 0
 	se.notifiers = make(map[string]notifier)
 	se.isOpen = false
@@ -549,6 +559,9 @@ func (se *Engine) reload(ctx context.Context, includeStats bool) error {
 		log.Infof("schema engine created %v, altered %v, dropped %v", extractNamesFromTablesList(created), extractNamesFromTablesList(altered), extractNamesFromTablesList(dropped))
 	}
 	se.broadcast(created, altered, dropped)
+	if includeStats && se.collectStatistics {
+		se.reloadStatistics(ctx, conn)
+	}
 	return nil
 }
 
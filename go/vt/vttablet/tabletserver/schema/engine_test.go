@@ -933,6 +933,63 @@ func TestEngineUpdateInnoDBRowsRead(t *testing.T) {
 	}
 }
 
+// TestEngineReloadStatistics tests the collection of the table statistics and the notification of their changes.
+func TestEngineReloadStatistics(t *testing.T) {
+	db := fakesqldb.New(t)
+	conn, err := connpool.NewDBConnNoPool(context.Background(), db.ConnParams(), nil, nil)
+	require.NoError(t, err)
+	se := &Engine{}
+	notified := 0
+	se.SetTableStatisticsNotifier(func() { notified++ })
+
+	tableFields := sqltypes.MakeTestFields("table_name|table_rows", "varchar|uint64")
+	indexFields := sqltypes.MakeTestFields("table_name|index_name|non_unique|column_name|cardinality", "varchar|varchar|int64|varchar|uint64")
+	db.AddQuery(fetchTableRowCounts, sqltypes.MakeTestResult(tableFields, "t1|1000", "t2|10"))
+	db.AddQuery(fetchIndexStatistics, sqltypes.MakeTestResult(indexFields,
+		"t1|PRIMARY|0|id|1000",
+		"t1|k|1|a|100",
+		"t1|k|1|b|500",
+		"t2|k|1|a|null",
+		"t3|PRIMARY|0|id|5",
+	))
+	se.reloadStatistics(context.Background(), conn)
+	require.Equal(t, 1, notified)
+	want := map[string]*querypb.TableStatistics{
+		"t1": {
+			RowCount: 1000,
+			Indexes: []*querypb.IndexStatistics{
+				{Name: "PRIMARY", Columns: []string{"id"}, Cardinality: []uint64{1000}, Unique: true},
+				{Name: "k", Columns: []string{"a", "b"}, Cardinality: []uint64{100, 500}},
+			},
+		},
+		"t2": {
+			RowCount: 10,
+			Indexes: []*querypb.IndexStatistics{
+				{Name: "k", Columns: []string{"a"}, Cardinality: []uint64{0}},
+			},
+		},
+	}
+	utils.MustMatch(t, want, se.GetTableStatistics(nil))
+	utils.MustMatch(t, map[string]*querypb.TableStatistics{"t2": want["t2"]}, se.GetTableStatistics([]string{"t2", "t3"}))
+
+	// The small changes of the estimates are not published.
+	db.AddQuery(fetchTableRowCounts, sqltypes.MakeTestResult(tableFields, "t1|1100", "t2|11"))
+	se.reloadStatistics(context.Background(), conn)
+	require.Equal(t, 1, notified)
+	require.EqualValues(t, 1000, se.GetTableStatistics([]string{"t1"})["t1"].RowCount)
+
+	db.AddQuery(fetchTableRowCounts, sqltypes.MakeTestResult(tableFields, "t1|2000", "t2|11"))
+	se.reloadStatistics(context.Background(), conn)
+	require.Equal(t, 2, notified)
+	require.EqualValues(t, 2000, se.GetTableStatistics([]string{"t1"})["t1"].RowCount)
+
+	// The statistics are kept when they can't be loaded.
+	db.AddRejectedQuery(fetchIndexStatistics, errors.New("some error in MySQL"))
+	se.reloadStatistics(context.Background(), conn)
+	require.Equal(t, 2, notified)
+	require.Len(t, se.GetTableStatistics(nil), 2)
+}
+
 // TestEngineGetTableData tests the functionality of getTableData function
 func TestEngineGetTableData(t *testing.T) {
 	db := fakesqldb.New(t)
@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"context"
+	"math"
+	"slices"
+
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/connpool"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+const (
+	// maxIndexColumnCount is the maximum number of index columns read
+	// from information_schema.statistics.
+	maxIndexColumnCount = 10 * maxTableCount
+
+	// statisticsChangeThreshold is the relative change of a row count or
+	// cardinality above which the statistics of a table have changed.
+	// The estimates of InnoDB vary all the time, so the small changes are
+	// not published.
+	statisticsChangeThreshold = 0.2
+)
+
+// SetTableStatisticsNotifier sets the function called when the statistics
+// of the tables have changed.
+func (se *Engine) SetTableStatisticsNotifier(f func()) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	se.statisticsNotifier = f
+}
+
+// GetTableStatistics returns the statistics of the tables, or of all the
+// tables if tableNames is empty. The tables whose statistics are not known
+// are not in the result.
+func (se *Engine) GetTableStatistics(tableNames []string) map[string]*querypb.TableStatistics {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	stats := make(map[string]*querypb.TableStatistics)
+	if len(tableNames) == 0 {
+		for name, ts := range se.statistics {
+			stats[name] = ts.CloneVT()
+		}
+		return stats
+	}
+	for _, name := range tableNames {
+		if ts, ok := se.statistics[name]; ok {
+			stats[name] = ts.CloneVT()
+		}
+	}
+	return stats
+}
+
+// reloadStatistics reloads the statistics of the tables and calls the
+// notifier if they have changed. The errors are only logged: the schema
+// is usable without statistics.
+func (se *Engine) reloadStatistics(ctx context.Context, conn *connpool.DBConn) {
+	stats, err := loadTableStatistics(ctx, conn)
+	if err != nil {
+		log.Warningf("failed to load the table statistics: %v", err)
+		return
+	}
+	if !tableStatisticsChanged(se.statistics, stats) {
+		return
+	}
+	se.statistics = stats
+	if se.statisticsNotifier != nil {
+		se.statisticsNotifier()
+	}
+}
+
+func loadTableStatistics(ctx context.Context, conn *connpool.DBConn) (map[string]*querypb.TableStatistics, error) {
+	tables, err := conn.Exec(ctx, fetchTableRowCounts, maxTableCount, false)
+	if err != nil {
+		return nil, err
+	}
+	stats := make(map[string]*querypb.TableStatistics, len(tables.Rows))
+	for _, row := range tables.Rows {
+		rowCount, _ := row[1].ToCastUint64()
+		stats[row[0].ToString()] = &querypb.TableStatistics{RowCount: rowCount}
+	}
+
+	indexes, err := conn.Exec(ctx, fetchIndexStatistics, maxIndexColumnCount, false)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		tableName string
+		index     *querypb.IndexStatistics
+	)
+	for _, row := range indexes.Rows {
+		name := row[0].ToString()
+		ts, ok := stats[name]
+		if !ok {
+			continue
+		}
+		indexName := row[1].ToString()
+		// The rows are ordered by table and index, so a new index starts
+		// whenever the table or the index name changes.
+		if index == nil || tableName != name || index.Name != indexName {
+			tableName = name
+			nonUnique, _ := row[2].ToCastInt64()
+			index = &querypb.IndexStatistics{Name: indexName, Unique: nonUnique == 0}
+			ts.Indexes = append(ts.Indexes, index)
+		}
+		// The cardinality is NULL when it has never been estimated.
+		cardinality, _ := row[4].ToCastUint64()
+		index.Columns = append(index.Columns, row[3].ToString())
+		index.Cardinality = append(index.Cardinality, cardinality)
+	}
+	return stats, nil
+}
+
+// tableStatisticsChanged returns true if the tables or their indexes are
+// not the same, or if one of the estimates has changed significantly.
+func tableStatisticsChanged(old, cur map[string]*querypb.TableStatistics) bool {
+	if old == nil || len(old) != len(cur) {
+		return true
+	}
+	for name, ts := range cur {
+		prev, ok := old[name]
+		if !ok || estimateChanged(prev.RowCount, ts.RowCount) || len(prev.Indexes) != len(ts.Indexes) {
+			return true
+		}
+		for i, index := range ts.Indexes {
+			prevIndex := prev.Indexes[i]
+			if prevIndex.Name != index.Name || prevIndex.Unique != index.Unique || !slices.Equal(prevIndex.Columns, index.Columns) {
+				return true
+			}
+			for j, cardinality := range index.Cardinality {
+				if estimateChanged(prevIndex.Cardinality[j], cardinality) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func estimateChanged(old, cur uint64) bool {
+	return math.Abs(float64(cur)-float64(old)) > statisticsChangeThreshold*math.Max(float64(old), 1)
+}
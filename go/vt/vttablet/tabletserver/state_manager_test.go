@@ -397,6 +397,11 @@ func (k *killableConn) Kill(message string, elapsed time.Duration) error {
 	return nil
 }
 
+func (k *killableConn) KillQuery(message string, elapsed time.Duration) error {
+	k.killed.Store(true)
+	return nil
+}
+
 func TestStateManagerShutdownGracePeriod(t *testing.T) {
 	sm := newTestStateManager(t)
 	defer sm.StopService()
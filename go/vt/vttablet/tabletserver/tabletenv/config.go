@@ -64,18 +64,20 @@ var (
 	StatsLogger = streamlog.New[*LogStats]("TabletServer", 50)
 
 	// The following vars are used for custom initialization of Tabletconfig.
-	enableHotRowProtection       bool
-	enableHotRowProtectionDryRun bool
-	enableConsolidator           bool
-	enableConsolidatorReplicas   bool
-	enableHeartbeat              bool
-	heartbeatInterval            time.Duration
-	heartbeatOnDemandDuration    time.Duration
-	healthCheckInterval          time.Duration
-	degradedThreshold            time.Duration
-	unhealthyThreshold           time.Duration
-	transitionGracePeriod        time.Duration
-	enableReplicationReporter    bool
+	enableHotRowProtection               bool
+	enableHotRowProtectionDryRun         bool
+	enableAdaptiveConcurrencyLimit       bool
+	enableAdaptiveConcurrencyLimitDryRun bool
+	enableConsolidator                   bool
+	enableConsolidatorReplicas           bool
+	enableHeartbeat                      bool
+	heartbeatInterval                    time.Duration
+	heartbeatOnDemandDuration            time.Duration
+	healthCheckInterval                  time.Duration
+	degradedThreshold                    time.Duration
+	unhealthyThreshold                   time.Duration
+	transitionGracePeriod                time.Duration
+	enableReplicationReporter            bool
 )
 
 func init() {
@@ -171,9 +173,9 @@ func registerTabletEnvFlags(fs *pflag.FlagSet) {
 	fs.BoolVar(&currentConfig.WatchReplication, "watch_replication_stream", false, "When enabled, vttablet will stream the MySQL replication stream from the local server, and use it to update schema when it sees a DDL.")
 	fs.BoolVar(&currentConfig.TrackSchemaVersions, "track_schema_versions", false, "When enabled, vttablet will store versions of schemas at each position that a DDL is applied and allow retrieval of the schema corresponding to a position")
 	fs.Int64Var(&currentConfig.SchemaVersionMaxAgeSeconds, "schema-version-max-age-seconds", 0, "max age of schema version records to kept in memory by the vreplication historian")
-	fs.BoolVar(&currentConfig.TwoPCEnable, "twopc_enable", defaultConfig.TwoPCEnable, "if the flag is on, 2pc is enabled. Other 2pc flags must be supplied.")
-	fs.StringVar(&currentConfig.TwoPCCoordinatorAddress, "twopc_coordinator_address", defaultConfig.TwoPCCoordinatorAddress, "address of the (VTGate) process(es) that will be used to notify of abandoned transactions.")
-	SecondsVar(fs, &currentConfig.TwoPCAbandonAge, "twopc_abandon_age", defaultConfig.TwoPCAbandonAge, "time in seconds. Any unresolved transaction older than this time will be sent to the coordinator to be resolved.")
+	fs.BoolVar(&currentConfig.TwoPCEnable, "twopc_enable", defaultConfig.TwoPCEnable, "if the flag is on, 2pc is enabled. The 2pc abandon age must also be supplied.")
+	fs.StringVar(&currentConfig.TwoPCCoordinatorAddress, "twopc_coordinator_address", defaultConfig.TwoPCCoordinatorAddress, "optional address of the (VTGate) process(es) that will be used to notify of abandoned transactions. Abandoned transactions are always signaled to the vtgates through the health stream.")
+	SecondsVar(fs, &currentConfig.TwoPCAbandonAge, "twopc_abandon_age", defaultConfig.TwoPCAbandonAge, "time in seconds. Any unresolved transaction older than this time will be resolved by a vtgate.")
 	// Tx throttler config
 	flagutil.DualFormatBoolVar(fs, &currentConfig.EnableTxThrottler, "enable_tx_throttler", defaultConfig.EnableTxThrottler, "If true replication-lag-based throttling on transactions will be enabled.")
 	flagutil.DualFormatVar(fs, currentConfig.TxThrottlerConfig, "tx_throttler_config", "The configuration of the transaction throttler as a text-formatted throttlerdata.Configuration protocol buffer message.")
@@ -183,12 +185,20 @@ func registerTabletEnvFlags(fs *pflag.FlagSet) {
 	fs.BoolVar(&currentConfig.TxThrottlerDryRun, "tx-throttler-dry-run", defaultConfig.TxThrottlerDryRun, "If present, the transaction throttler only records metrics about requests received and throttled, but does not actually throttle any requests.")
 	fs.DurationVar(&currentConfig.TxThrottlerTopoRefreshInterval, "tx-throttler-topo-refresh-interval", time.Minute*5, "The rate that the transaction throttler will refresh the topology to find cells.")
 
-	fs.BoolVar(&enableHotRowProtection, "enable_hot_row_protection", false, "If true, incoming transactions for the same row (range) will be queued and cannot consume all txpool slots.")
+	fs.BoolVar(&enableHotRowProtection, "enable_hot_row_protection", false, "If true, incoming transactions and autocommit DMLs for the same row (range) will be queued and cannot consume all txpool slots.")
 	fs.BoolVar(&enableHotRowProtectionDryRun, "enable_hot_row_protection_dry_run", false, "If true, hot row protection is not enforced but logs if transactions would have been queued.")
 	fs.IntVar(&currentConfig.HotRowProtection.MaxQueueSize, "hot_row_protection_max_queue_size", defaultConfig.HotRowProtection.MaxQueueSize, "Maximum number of BeginExecute RPCs which will be queued for the same row (range).")
 	fs.IntVar(&currentConfig.HotRowProtection.MaxGlobalQueueSize, "hot_row_protection_max_global_queue_size", defaultConfig.HotRowProtection.MaxGlobalQueueSize, "Global queue limit across all row (ranges). Useful to prevent that the queue can grow unbounded.")
 	fs.IntVar(&currentConfig.HotRowProtection.MaxConcurrency, "hot_row_protection_concurrent_transactions", defaultConfig.HotRowProtection.MaxConcurrency, "Number of concurrent transactions let through to the txpool/MySQL for the same hot row. Should be > 1 to have enough 'ready' transactions in MySQL and benefit from a pipelining effect.")
 
+	fs.BoolVar(&enableAdaptiveConcurrencyLimit, "enable-adaptive-concurrency-limit", false, "If true, the number of queries executed at the same time outside of transactions is limited, and the limit adapts to the latency of MySQL: it shrinks when the latency rises, and grows back when it recovers. The queries over the limit fail with a retryable error.")
+	fs.BoolVar(&enableAdaptiveConcurrencyLimitDryRun, "enable-adaptive-concurrency-limit-dry-run", false, "If true, the adaptive concurrency limit is computed and the queries over it are counted, but they are not rejected.")
+	fs.IntVar(&currentConfig.AdaptiveConcurrencyLimit.InitialLimit, "adaptive-concurrency-limit-initial", defaultConfig.AdaptiveConcurrencyLimit.InitialLimit, "Initial number of queries allowed to execute at the same time by the adaptive concurrency limit.")
+	fs.IntVar(&currentConfig.AdaptiveConcurrencyLimit.MinLimit, "adaptive-concurrency-limit-min", defaultConfig.AdaptiveConcurrencyLimit.MinLimit, "Minimum number of queries allowed to execute at the same time by the adaptive concurrency limit.")
+	fs.IntVar(&currentConfig.AdaptiveConcurrencyLimit.MaxLimit, "adaptive-concurrency-limit-max", defaultConfig.AdaptiveConcurrencyLimit.MaxLimit, "Maximum number of queries allowed to execute at the same time by the adaptive concurrency limit.")
+	fs.Float64Var(&currentConfig.AdaptiveConcurrencyLimit.LatencyTolerance, "adaptive-concurrency-limit-latency-tolerance", defaultConfig.AdaptiveConcurrencyLimit.LatencyTolerance, "How many times higher than its long term average the recent MySQL latency can be before the adaptive concurrency limit shrinks.")
+	fs.DurationVar(&currentConfig.AdaptiveConcurrencyLimit.Window, "adaptive-concurrency-limit-window", defaultConfig.AdaptiveConcurrencyLimit.Window, "How often the adaptive concurrency limit is updated from the MySQL latency of the queries.")
+
 	fs.BoolVar(&currentConfig.EnableTransactionLimit, "enable_transaction_limit", defaultConfig.EnableTransactionLimit, "If true, limit on number of transactions open at the same time will be enforced for all users. User trying to open a new transaction after exhausting their limit will receive an error immediately, regardless of whether there are available slots or not.")
 	fs.BoolVar(&currentConfig.EnableTransactionLimitDryRun, "enable_transaction_limit_dry_run", defaultConfig.EnableTransactionLimitDryRun, "If true, limit on number of transactions open at the same time will be tracked for all users, but not enforced.")
 	fs.Float64Var(&currentConfig.TransactionLimitPerUser, "transaction_limit_per_user", defaultConfig.TransactionLimitPerUser, "Maximum number of transactions a single user is allowed to use at any time, represented as fraction of -transaction_cap.")
@@ -225,6 +235,7 @@ func registerTabletEnvFlags(fs *pflag.FlagSet) {
 	fs.Int64Var(&currentConfig.RowStreamer.MaxMySQLReplLagSecs, "vreplication_copy_phase_max_mysql_replication_lag", 43200, "The maximum MySQL replication lag (in seconds) that can exist on a vstreamer (source) before starting another round of copying rows. This helps to limit the impact on the source tablet.")
 
 	fs.BoolVar(&currentConfig.EnableViews, "queryserver-enable-views", false, "Enable views support in vttablet.")
+	fs.BoolVar(&currentConfig.EnableTableStatistics, "queryserver-enable-table-statistics", false, "Collect the row estimates and index cardinalities of the tables when the schema is reloaded, and publish them to the vtgates for cost-based query planning.")
 
 	fs.BoolVar(&currentConfig.EnablePerWorkloadTableMetrics, "enable-per-workload-table-metrics", defaultConfig.EnablePerWorkloadTableMetrics, "If true, query counts and query error metrics include a label that identifies the workload")
 }
@@ -253,6 +264,16 @@ func Init() {
 		currentConfig.HotRowProtection.Mode = Disable
 	}
 
+	if enableAdaptiveConcurrencyLimit {
+		if enableAdaptiveConcurrencyLimitDryRun {
+			currentConfig.AdaptiveConcurrencyLimit.Mode = Dryrun
+		} else {
+			currentConfig.AdaptiveConcurrencyLimit.Mode = Enable
+		}
+	} else {
+		currentConfig.AdaptiveConcurrencyLimit.Mode = Disable
+	}
+
 	switch {
 	case enableConsolidatorReplicas:
 		currentConfig.Consolidator = NotOnPrimary
@@ -320,6 +341,8 @@ type TabletConfig struct {
 	Oltp             OltpConfig             `json:"oltp,omitempty"`
 	HotRowProtection HotRowProtectionConfig `json:"hotRowProtection,omitempty"`
 
+	AdaptiveConcurrencyLimit AdaptiveConcurrencyLimitConfig `json:"adaptiveConcurrencyLimit,omitempty"`
+
 	Healthcheck  HealthcheckConfig  `json:"healthcheck,omitempty"`
 	GracePeriods GracePeriodsConfig `json:"gracePeriods,omitempty"`
 
@@ -347,6 +370,10 @@ type TabletConfig struct {
 
 	ExternalConnections map[string]*dbconfigs.DBConfigs `json:"externalConnections,omitempty"`
 
+	// WorkloadClassUsers maps the usernames of the immediate callers to
+	// their workload class. See ConnPoolConfig.WorkloadClasses.
+	WorkloadClassUsers map[string]string `json:"workloadClassUsers,omitempty"`
+
 	SanitizeLogMessages     bool    `json:"-"`
 	StrictTableACL          bool    `json:"-"`
 	EnableTableACLDryRun    bool    `json:"-"`
@@ -375,6 +402,8 @@ type TabletConfig struct {
 
 	EnableViews bool `json:"-"`
 
+	EnableTableStatistics bool `json:"-"`
+
 	EnablePerWorkloadTableMetrics bool `json:"-"`
 }
 
@@ -409,6 +438,25 @@ type ConnPoolConfig struct {
 	MaxLifetimeSeconds flagutil.DeprecatedFloat64Seconds `json:"maxLifetimeSeconds,omitempty"`
 	PrefillParallelism int                               `json:"prefillParallelism,omitempty"`
 	MaxWaiters         int                               `json:"maxWaiters,omitempty"`
+
+	// WorkloadClasses are the capacities of the pool reserved for, and
+	// usable by, each workload class. The queries of the classes that
+	// are not listed use the "default" class, which can be listed too.
+	WorkloadClasses map[string]WorkloadClassConfig `json:"workloadClasses,omitempty"`
+}
+
+// WorkloadClassConfig contains the capacities of a conn pool for a workload
+// class. The class of a query is picked, in order, by the WORKLOAD_CLASS
+// action of a query rule, by the WORKLOAD_NAME query comment directive when
+// it names a configured class, and by TabletConfig.WorkloadClassUsers.
+type WorkloadClassConfig struct {
+	// Reserved is the number of connections that only the queries of the
+	// class can use.
+	Reserved int `json:"reserved,omitempty"`
+	// Burst is the maximum number of connections the queries of the class
+	// can use, out of their reserved connections and of the connections
+	// not reserved by any class. Zero means no maximum.
+	Burst int `json:"burst,omitempty"`
 }
 
 func (cfg *ConnPoolConfig) MarshalJSON() ([]byte, error) {
@@ -499,6 +547,58 @@ type HotRowProtectionConfig struct {
 	MaxConcurrency     int    `json:"maxConcurrency,omitempty"`
 }
 
+// AdaptiveConcurrencyLimitConfig contains the config for the adaptive
+// concurrency limit of the queries.
+type AdaptiveConcurrencyLimitConfig struct {
+	// Mode can be disable, dryRun or enable. Default is disable.
+	Mode             string        `json:"mode,omitempty"`
+	InitialLimit     int           `json:"initialLimit,omitempty"`
+	MinLimit         int           `json:"minLimit,omitempty"`
+	MaxLimit         int           `json:"maxLimit,omitempty"`
+	LatencyTolerance float64       `json:"latencyTolerance,omitempty"`
+	Window           time.Duration `json:"window,omitempty"`
+}
+
+func (cfg *AdaptiveConcurrencyLimitConfig) MarshalJSON() ([]byte, error) {
+	type Proxy AdaptiveConcurrencyLimitConfig
+
+	tmp := struct {
+		Proxy
+		Window string `json:"window,omitempty"`
+	}{
+		Proxy: Proxy(*cfg),
+	}
+
+	if d := cfg.Window; d != 0 {
+		tmp.Window = d.String()
+	}
+
+	return json.Marshal(&tmp)
+}
+
+func (cfg *AdaptiveConcurrencyLimitConfig) UnmarshalJSON(data []byte) (err error) {
+	type Proxy AdaptiveConcurrencyLimitConfig
+
+	tmp := struct {
+		*Proxy
+		Window string `json:"window,omitempty"`
+	}{
+		Proxy: (*Proxy)(cfg),
+	}
+
+	if err = json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+
+	if tmp.Window != "" {
+		if cfg.Window, err = time.ParseDuration(tmp.Window); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // HealthcheckConfig contains the config for healthcheck.
 type HealthcheckConfig struct {
 	IntervalSeconds           flagutil.DeprecatedFloat64Seconds `json:"intervalSeconds,omitempty"`
@@ -660,6 +760,12 @@ func (c *TabletConfig) Verify() error {
 	if err := c.verifyTransactionLimitConfig(); err != nil {
 		return err
 	}
+	if err := c.verifyWorkloadClasses(); err != nil {
+		return err
+	}
+	if err := c.verifyAdaptiveConcurrencyLimitConfig(); err != nil {
+		return err
+	}
 	if err := c.verifyTxThrottlerConfig(); err != nil {
 		return err
 	}
@@ -708,6 +814,70 @@ func (c *TabletConfig) verifyTransactionLimitConfig() error {
 	return nil
 }
 
+// verifyWorkloadClasses checks the workload classes of the conn pools for
+// sanity.
+func (c *TabletConfig) verifyWorkloadClasses() error {
+	for _, pool := range []struct {
+		name string
+		cfg  *ConnPoolConfig
+	}{
+		{"oltpReadPool", &c.OltpReadPool},
+		{"olapReadPool", &c.OlapReadPool},
+		{"txPool", &c.TxPool},
+	} {
+		reserved := 0
+		for class, cfg := range pool.cfg.WorkloadClasses {
+			if class == "" {
+				return fmt.Errorf("%s: workload classes must have a name", pool.name)
+			}
+			if cfg.Reserved < 0 || cfg.Burst < 0 {
+				return fmt.Errorf("%s: the capacities of workload class %s must be >= 0", pool.name, class)
+			}
+			if cfg.Burst != 0 && cfg.Burst < cfg.Reserved {
+				return fmt.Errorf("%s: the burst capacity of workload class %s must be >= its reserved capacity (%v < %v)", pool.name, class, cfg.Burst, cfg.Reserved)
+			}
+			reserved += cfg.Reserved
+		}
+		if reserved > pool.cfg.Size {
+			return fmt.Errorf("%s: the reserved capacities of the workload classes must add up to at most the pool size (%v > %v)", pool.name, reserved, pool.cfg.Size)
+		}
+	}
+	return nil
+}
+
+// verifyAdaptiveConcurrencyLimitConfig checks AdaptiveConcurrencyLimitConfig
+// for sanity.
+func (c *TabletConfig) verifyAdaptiveConcurrencyLimitConfig() error {
+	cfg := c.AdaptiveConcurrencyLimit
+	if cfg.Mode == Disable {
+		return nil
+	}
+	if cfg.MinLimit <= 0 {
+		return fmt.Errorf("--adaptive-concurrency-limit-min must be > 0 (specified value: %v)", cfg.MinLimit)
+	}
+	if cfg.MinLimit > cfg.InitialLimit || cfg.InitialLimit > cfg.MaxLimit {
+		return fmt.Errorf("the adaptive concurrency limits must be ordered: --adaptive-concurrency-limit-min <= --adaptive-concurrency-limit-initial <= --adaptive-concurrency-limit-max (%v, %v, %v)", cfg.MinLimit, cfg.InitialLimit, cfg.MaxLimit)
+	}
+	if cfg.LatencyTolerance < 1 {
+		return fmt.Errorf("--adaptive-concurrency-limit-latency-tolerance must be >= 1 (specified value: %v)", cfg.LatencyTolerance)
+	}
+	if cfg.Window <= 0 {
+		return fmt.Errorf("--adaptive-concurrency-limit-window must be > 0 (specified value: %v)", cfg.Window)
+	}
+	return nil
+}
+
+// IsWorkloadClass returns true if the workload class has capacities in one of
+// the query, stream or transaction pools.
+func (c *TabletConfig) IsWorkloadClass(class string) bool {
+	for _, cfg := range []*ConnPoolConfig{&c.OltpReadPool, &c.OlapReadPool, &c.TxPool} {
+		if _, ok := cfg.WorkloadClasses[class]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 // verifyTxThrottlerConfig checks the TxThrottler related config for sanity.
 func (c *TabletConfig) verifyTxThrottlerConfig() error {
 	if !c.EnableTxThrottler {
@@ -800,6 +970,15 @@ var defaultConfig = TabletConfig{
 		// of them ready in MySQL and profit from a pipelining effect.
 		MaxConcurrency: 5,
 	},
+
+	AdaptiveConcurrencyLimit: AdaptiveConcurrencyLimitConfig{
+		Mode:             Disable,
+		InitialLimit:     50,
+		MinLimit:         5,
+		MaxLimit:         500,
+		LatencyTolerance: 1.5,
+		Window:           time.Second,
+	},
 	Consolidator:                Enable,
 	ConsolidatorStreamTotalSize: 128 * 1024 * 1024,
 	ConsolidatorStreamQuerySize: 2 * 1024 * 1024,
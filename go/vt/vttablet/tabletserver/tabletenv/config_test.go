@@ -62,7 +62,8 @@ func TestConfigParse(t *testing.T) {
 
 	gotBytes, err := yaml2.Marshal(&cfg)
 	require.NoError(t, err)
-	wantBytes := `db:
+	wantBytes := `adaptiveConcurrencyLimit: {}
+db:
   allprivs:
     password: '****'
   app:
@@ -124,7 +125,14 @@ oltpReadPool:
 func TestDefaultConfig(t *testing.T) {
 	gotBytes, err := yaml2.Marshal(NewDefaultConfig())
 	require.NoError(t, err)
-	want := `consolidator: enable
+	want := `adaptiveConcurrencyLimit:
+  initialLimit: 50
+  latencyTolerance: 1.5
+  maxLimit: 500
+  minLimit: 5
+  mode: disable
+  window: 1s
+consolidator: enable
 consolidatorStreamQuerySize: 2097152
 consolidatorStreamTotalSize: 134217728
 gracePeriods: {}
@@ -242,6 +250,26 @@ func TestFlags(t *testing.T) {
 	want.HotRowProtection.Mode = Disable
 	assert.Equal(t, want, currentConfig)
 
+	enableAdaptiveConcurrencyLimit = true
+	enableAdaptiveConcurrencyLimitDryRun = true
+	Init()
+	want.AdaptiveConcurrencyLimit.Mode = Dryrun
+	assert.Equal(t, want, currentConfig)
+
+	enableAdaptiveConcurrencyLimit = true
+	enableAdaptiveConcurrencyLimitDryRun = false
+	Init()
+	want.AdaptiveConcurrencyLimit.Mode = Enable
+	assert.Equal(t, want, currentConfig)
+
+	enableAdaptiveConcurrencyLimit = false
+	enableAdaptiveConcurrencyLimitDryRun = true
+	Init()
+	want.AdaptiveConcurrencyLimit.Mode = Disable
+	assert.Equal(t, want, currentConfig)
+
+	enableAdaptiveConcurrencyLimitDryRun = false
+
 	enableConsolidator = true
 	enableConsolidatorReplicas = true
 	Init()
@@ -451,3 +479,111 @@ func TestVerifyTxThrottlerConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifyWorkloadClasses(t *testing.T) {
+	yamlConfig := `
+oltpReadPool:
+  size: 10
+  workloadClasses:
+    oltp:
+      reserved: 6
+    batch:
+      burst: 2
+txPool:
+  size: 5
+  workloadClasses:
+    default:
+      reserved: 1
+      burst: 4
+workloadClassUsers:
+  batch_user: batch
+`
+	var config TabletConfig
+	require.NoError(t, yaml2.Unmarshal([]byte(yamlConfig), &config))
+	assert.Equal(t, map[string]WorkloadClassConfig{"oltp": {Reserved: 6}, "batch": {Burst: 2}}, config.OltpReadPool.WorkloadClasses)
+	assert.Equal(t, map[string]string{"batch_user": "batch"}, config.WorkloadClassUsers)
+	require.NoError(t, config.verifyWorkloadClasses())
+	assert.True(t, config.IsWorkloadClass("batch"))
+	assert.True(t, config.IsWorkloadClass("default"))
+	assert.False(t, config.IsWorkloadClass("analytics"))
+
+	tests := []struct {
+		classes map[string]WorkloadClassConfig
+		err     string
+	}{{
+		classes: map[string]WorkloadClassConfig{"": {Reserved: 1}},
+		err:     "olapReadPool: workload classes must have a name",
+	}, {
+		classes: map[string]WorkloadClassConfig{"batch": {Reserved: -1}},
+		err:     "olapReadPool: the capacities of workload class batch must be >= 0",
+	}, {
+		classes: map[string]WorkloadClassConfig{"batch": {Reserved: 2, Burst: 1}},
+		err:     "olapReadPool: the burst capacity of workload class batch must be >= its reserved capacity (1 < 2)",
+	}, {
+		classes: map[string]WorkloadClassConfig{"oltp": {Reserved: 150}, "batch": {Reserved: 51}},
+		err:     "olapReadPool: the reserved capacities of the workload classes must add up to at most the pool size (201 > 200)",
+	}}
+	for _, test := range tests {
+		config := NewDefaultConfig()
+		config.OlapReadPool.WorkloadClasses = test.classes
+		assert.EqualError(t, config.verifyWorkloadClasses(), test.err)
+	}
+}
+
+func TestVerifyAdaptiveConcurrencyLimitConfig(t *testing.T) {
+	config := NewDefaultConfig()
+	config.AdaptiveConcurrencyLimit.MinLimit = 0
+	require.NoError(t, config.verifyAdaptiveConcurrencyLimitConfig(), "a disabled limit is not verified")
+
+	tests := []struct {
+		update func(cfg *AdaptiveConcurrencyLimitConfig)
+		err    string
+	}{{
+		update: func(cfg *AdaptiveConcurrencyLimitConfig) {},
+	}, {
+		update: func(cfg *AdaptiveConcurrencyLimitConfig) { cfg.MinLimit = 0 },
+		err:    "--adaptive-concurrency-limit-min must be > 0 (specified value: 0)",
+	}, {
+		update: func(cfg *AdaptiveConcurrencyLimitConfig) { cfg.InitialLimit = 1000 },
+		err:    "the adaptive concurrency limits must be ordered: --adaptive-concurrency-limit-min <= --adaptive-concurrency-limit-initial <= --adaptive-concurrency-limit-max (5, 1000, 500)",
+	}, {
+		update: func(cfg *AdaptiveConcurrencyLimitConfig) { cfg.LatencyTolerance = 0.5 },
+		err:    "--adaptive-concurrency-limit-latency-tolerance must be >= 1 (specified value: 0.5)",
+	}, {
+		update: func(cfg *AdaptiveConcurrencyLimitConfig) { cfg.Window = 0 },
+		err:    "--adaptive-concurrency-limit-window must be > 0 (specified value: 0s)",
+	}}
+	for _, test := range tests {
+		config := NewDefaultConfig()
+		config.AdaptiveConcurrencyLimit.Mode = Enable
+		test.update(&config.AdaptiveConcurrencyLimit)
+		err := config.verifyAdaptiveConcurrencyLimitConfig()
+		if test.err == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, test.err)
+		}
+	}
+}
+
+func TestAdaptiveConcurrencyLimitConfigParse(t *testing.T) {
+	inBytes := []byte(`adaptiveConcurrencyLimit:
+  mode: enable
+  initialLimit: 20
+  window: 500ms
+`)
+	cfg := NewDefaultConfig()
+	require.NoError(t, yaml2.Unmarshal(inBytes, cfg))
+	want := NewDefaultConfig().AdaptiveConcurrencyLimit
+	want.Mode = Enable
+	want.InitialLimit = 20
+	want.Window = 500 * time.Millisecond
+	assert.Equal(t, want, cfg.AdaptiveConcurrencyLimit)
+
+	// The config dump can be parsed back.
+	gotBytes, err := yaml2.Marshal(&cfg.AdaptiveConcurrencyLimit)
+	require.NoError(t, err)
+	var got AdaptiveConcurrencyLimitConfig
+	require.NoError(t, yaml2.Unmarshal(gotBytes, &got))
+	assert.Equal(t, want, got)
+}
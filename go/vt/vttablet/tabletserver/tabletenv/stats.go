@@ -34,7 +34,7 @@ type Stats struct {
 	ErrorCounters          *stats.CountersWithSingleLabel
 	InternalErrors         *stats.CountersWithSingleLabel
 	Warnings               *stats.CountersWithSingleLabel
-	Unresolved             *stats.GaugesWithSingleLabel   // For now, only Prepares are tracked
+	Unresolved             *stats.GaugesWithSingleLabel   // Prepares and distributed Transactions
 	UserTableQueryCount    *stats.CountersWithMultiLabels // Per CallerID/table counts
 	UserTableQueryTimesNs  *stats.CountersWithMultiLabels // Per CallerID/table latencies
 	UserTransactionCount   *stats.CountersWithMultiLabels // Per CallerID transaction counts
@@ -43,6 +43,7 @@ type Stats struct {
 	TableaclAllowed        *stats.CountersWithMultiLabels // Number of allows
 	TableaclDenied         *stats.CountersWithMultiLabels // Number of denials
 	TableaclPseudoDenied   *stats.CountersWithMultiLabels // Number of pseudo denials
+	QueryRuleLimited       *stats.CountersWithSingleLabel // Per query rule rate and concurrency limit rejections
 
 	UserActiveReservedCount *stats.CountersWithSingleLabel // Per CallerID active reserved connection counts
 	UserReservedCount       *stats.CountersWithSingleLabel // Per CallerID reserved connection counts
@@ -81,9 +82,9 @@ func NewStats(exporter *servenv.Exporter) *Stats {
 			vtrpcpb.Code_DATA_LOSS.String(),
 			vtrpcpb.Code_CLUSTER_EVENT.String(),
 		),
-		InternalErrors:         exporter.NewCountersWithSingleLabel("InternalErrors", "Internal component errors", "type", "Task", "StrayTransactions", "Panic", "HungQuery", "Schema", "TwopcCommit", "TwopcResurrection", "WatchdogFail", "Messages"),
+		InternalErrors:         exporter.NewCountersWithSingleLabel("InternalErrors", "Internal component errors", "type", "Task", "StrayTransactions", "Panic", "HungQuery", "Schema", "TwopcCommit", "TwopcResurrection", "RedoPreparedFail", "WatchdogFail", "Messages"),
 		Warnings:               exporter.NewCountersWithSingleLabel("Warnings", "Warnings", "type", "ResultsExceeded"),
-		Unresolved:             exporter.NewGaugesWithSingleLabel("Unresolved", "Unresolved items", "item_type", "Prepares"),
+		Unresolved:             exporter.NewGaugesWithSingleLabel("Unresolved", "Unresolved items", "item_type", "Prepares", "Transactions"),
 		UserTableQueryCount:    exporter.NewCountersWithMultiLabels("UserTableQueryCount", "Queries received for each CallerID/table combination", []string{"TableName", "CallerID", "Type"}),
 		UserTableQueryTimesNs:  exporter.NewCountersWithMultiLabels("UserTableQueryTimesNs", "Total latency for each CallerID/table combination", []string{"TableName", "CallerID", "Type"}),
 		UserTransactionCount:   exporter.NewCountersWithMultiLabels("UserTransactionCount", "transactions received for each CallerID", []string{"CallerID", "Conclusion"}),
@@ -92,6 +93,7 @@ func NewStats(exporter *servenv.Exporter) *Stats {
 		TableaclAllowed:        exporter.NewCountersWithMultiLabels("TableACLAllowed", "ACL acceptances", []string{"TableName", "TableGroup", "PlanID", "Username"}),
 		TableaclDenied:         exporter.NewCountersWithMultiLabels("TableACLDenied", "ACL denials", []string{"TableName", "TableGroup", "PlanID", "Username"}),
 		TableaclPseudoDenied:   exporter.NewCountersWithMultiLabels("TableACLPseudoDenied", "ACL pseudodenials", []string{"TableName", "TableGroup", "PlanID", "Username"}),
+		QueryRuleLimited:       exporter.NewCountersWithSingleLabel("QueryRuleLimited", "Queries rejected by the rate or concurrency limit of a query rule", "Rule"),
 
 		UserActiveReservedCount: exporter.NewCountersWithSingleLabel("UserActiveReservedCount", "active reserved connection for each CallerID", "CallerID"),
 		UserReservedCount:       exporter.NewCountersWithSingleLabel("UserReservedCount", "reserved connection received for each CallerID", "CallerID"),
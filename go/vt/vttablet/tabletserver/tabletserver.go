@@ -55,6 +55,7 @@ import (
 	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vttablet/onlineddl"
 	"vitess.io/vitess/go/vt/vttablet/queryservice"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/adaptivelimiter"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/gc"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/messager"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/planbuilder"
@@ -67,6 +68,7 @@ import (
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/txserializer"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/txthrottler"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/vstreamer"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/workloadclass"
 
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
 	querypb "vitess.io/vitess/go/vt/proto/query"
@@ -103,21 +105,22 @@ type TabletServer struct {
 	topoServer             *topo.Server
 
 	// These are sub-components of TabletServer.
-	statelessql  *QueryList
-	statefulql   *QueryList
-	olapql       *QueryList
-	se           *schema.Engine
-	rt           *repltracker.ReplTracker
-	vstreamer    *vstreamer.Engine
-	tracker      *schema.Tracker
-	watcher      *BinlogWatcher
-	qe           *QueryEngine
-	txThrottler  txthrottler.TxThrottler
-	te           *TxEngine
-	messager     *messager.Engine
-	hs           *healthStreamer
-	lagThrottler *throttle.Throttler
-	tableGC      *gc.TableGC
+	statelessql     *QueryList
+	statefulql      *QueryList
+	olapql          *QueryList
+	se              *schema.Engine
+	rt              *repltracker.ReplTracker
+	vstreamer       *vstreamer.Engine
+	tracker         *schema.Tracker
+	watcher         *BinlogWatcher
+	qe              *QueryEngine
+	txThrottler     txthrottler.TxThrottler
+	adaptiveLimiter *adaptivelimiter.Limiter
+	te              *TxEngine
+	messager        *messager.Engine
+	hs              *healthStreamer
+	lagThrottler    *throttle.Throttler
+	tableGC         *gc.TableGC
 
 	// sm manages state transitions.
 	sm                *stateManager
@@ -177,6 +180,7 @@ func NewTabletServer(ctx context.Context, name string, config *tabletenv.TabletC
 	tsv.olapql = NewQueryList("olap")
 	tsv.se = schema.NewEngine(tsv)
 	tsv.hs = newHealthStreamer(tsv, alias, tsv.se)
+	tsv.se.SetTableStatisticsNotifier(tsv.hs.TableStatisticsChanged)
 	tsv.rt = repltracker.NewReplTracker(tsv, alias)
 	tsv.lagThrottler = throttle.NewThrottler(tsv, srvTopoServer, topoServer, alias.Cell, tsv.rt.HeartbeatWriter(), tabletTypeFunc)
 	tsv.vstreamer = vstreamer.NewEngine(tsv, srvTopoServer, tsv.se, tsv.lagThrottler, alias.Cell)
@@ -184,7 +188,8 @@ func NewTabletServer(ctx context.Context, name string, config *tabletenv.TabletC
 	tsv.watcher = NewBinlogWatcher(tsv, tsv.vstreamer, tsv.config)
 	tsv.qe = NewQueryEngine(tsv, tsv.se)
 	tsv.txThrottler = txthrottler.NewTxThrottler(tsv, topoServer)
-	tsv.te = NewTxEngine(tsv)
+	tsv.adaptiveLimiter = adaptivelimiter.New(tsv)
+	tsv.te = NewTxEngine(tsv, tsv.hs.UnresolvedTransactions)
 	tsv.messager = messager.NewEngine(tsv, tsv.se, tsv.vstreamer)
 
 	tsv.onlineDDLExecutor = onlineddl.NewExecutor(tsv, alias, topoServer, tsv.lagThrottler, tabletTypeFunc, tsv.onlineDDLExecutorToggleTableBuffer)
@@ -550,6 +555,19 @@ func (tsv *TabletServer) getPriorityFromOptions(options *querypb.ExecuteOptions)
 	return optionsPriority
 }
 
+// withWorkloadClass returns a context carrying the workload class of a
+// request: the class named by its WORKLOAD_NAME query comment directive if it
+// is configured, or else the class of its immediate caller.
+func (tsv *TabletServer) withWorkloadClass(ctx context.Context, options *querypb.ExecuteOptions) context.Context {
+	if class := options.GetWorkloadName(); class != "" && tsv.config.IsWorkloadClass(class) {
+		return workloadclass.NewContext(ctx, class)
+	}
+	if class, ok := tsv.config.WorkloadClassUsers[callerid.GetUsername(callerid.ImmediateCallerIDFromContext(ctx))]; ok {
+		return workloadclass.NewContext(ctx, class)
+	}
+	return ctx
+}
+
 // Commit commits the specified transaction.
 func (tsv *TabletServer) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (newReservedID int64, err error) {
 	err = tsv.execRequest(
@@ -744,6 +762,26 @@ func (tsv *TabletServer) ReadTransaction(ctx context.Context, target *querypb.Ta
 	return metadata, err
 }
 
+// UnresolvedTransactions returns the distributed transactions that are
+// older than abandonAgeSeconds, or than the configured abandon age if zero.
+func (tsv *TabletServer) UnresolvedTransactions(ctx context.Context, target *querypb.Target, abandonAgeSeconds int64) (transactions []*querypb.TransactionMetadata, err error) {
+	err = tsv.execRequest(
+		ctx, tsv.loadQueryTimeout(),
+		"UnresolvedTransactions", "unresolved_transactions", nil,
+		target, nil, true, /* allowOnShutdown */
+		func(ctx context.Context, logStats *tabletenv.LogStats) error {
+			txe := &TxExecutor{
+				ctx:      ctx,
+				logStats: logStats,
+				te:       tsv.te,
+			}
+			transactions, err = txe.UnresolvedTransactions(time.Duration(abandonAgeSeconds) * time.Second)
+			return err
+		},
+	)
+	return transactions, err
+}
+
 // Execute executes the query and returns the result as response.
 func (tsv *TabletServer) Execute(ctx context.Context, target *querypb.Target, sql string, bindVariables map[string]*querypb.BindVariable, transactionID, reservedID int64, options *querypb.ExecuteOptions) (result *sqltypes.Result, err error) {
 	span, ctx := trace.NewSpan(ctx, "TabletServer.Execute")
@@ -1015,7 +1053,13 @@ func (tsv *TabletServer) computeTxSerializerKey(ctx context.Context, logStats *t
 		logComputeRowSerializerKey.Errorf("failed to get plan for query: %v err: %v", sql, err)
 		return "", ""
 	}
+	return txSerializerKey(plan, sql, bindVariables)
+}
 
+// txSerializerKey is like computeTxSerializerKey, for a query already planned.
+// The DMLs which target a single row are keyed by the primary key of the row,
+// and the others by their WHERE clause.
+func txSerializerKey(plan *TabletPlan, sql string, bindVariables map[string]*querypb.BindVariable) (string, string) {
 	switch plan.PlanID {
 	// Serialize only UPDATE or DELETE queries.
 	case planbuilder.PlanUpdate, planbuilder.PlanUpdateLimit,
@@ -1030,7 +1074,11 @@ func (tsv *TabletServer) computeTxSerializerKey(ctx context.Context, logStats *t
 		return "", ""
 	}
 
-	where, err := plan.WhereClause.GenerateQuery(bindVariables, nil)
+	whereClause := plan.WhereClause
+	if plan.PKWhereClause != nil {
+		whereClause = plan.PKWhereClause
+	}
+	where, err := whereClause.GenerateQuery(bindVariables, nil)
 	if err != nil {
 		logComputeRowSerializerKey.Errorf("failed to substitute bind vars in where clause: %v query: %v bind vars: %v", err, sql, bindVariables)
 		return "", ""
@@ -1399,6 +1447,23 @@ func (tsv *TabletServer) Release(ctx context.Context, target *querypb.Target, tr
 	)
 }
 
+// KillQueries kills the queries in flight of the vtgate session with the given
+// UUID, and returns how many were killed.
+func (tsv *TabletServer) KillQueries(ctx context.Context, target *querypb.Target, sessionUUID string) (count int64, err error) {
+	if sessionUUID == "" {
+		return 0, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "session UUID is required to kill queries")
+	}
+	// The target is only verified, so that the queries can also be killed
+	// while the tablet is not serving, e.g. during its shutdown grace period.
+	if err := tsv.sm.VerifyTarget(ctx, target); err != nil {
+		return 0, err
+	}
+	for _, ql := range []*QueryList{tsv.statelessql, tsv.statefulql, tsv.olapql} {
+		count += int64(ql.TerminateSession(sessionUUID))
+	}
+	return count, nil
+}
+
 func (tsv *TabletServer) executeWithSettings(ctx context.Context, target *querypb.Target, settings []string, sql string, bindVariables map[string]*querypb.BindVariable, transactionID int64, options *querypb.ExecuteOptions) (result *sqltypes.Result, err error) {
 	span, ctx := trace.NewSpan(ctx, "TabletServer.ExecuteWithSettings")
 	trace.AnnotateSQL(span, sqlparser.Preview(sql))
@@ -1477,6 +1542,8 @@ func (tsv *TabletServer) execRequest(
 
 	defer span.Finish()
 
+	ctx = tsv.withWorkloadClass(ctx, options)
+
 	logStats := tabletenv.NewLogStats(ctx, requestName)
 	logStats.Target = target
 	logStats.OriginalSQL = sql
@@ -51,6 +51,7 @@ import (
 	"vitess.io/vitess/go/vt/topo/memorytopo"
 	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/tabletenv"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/txserializer"
 
 	querypb "vitess.io/vitess/go/vt/proto/query"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
@@ -173,18 +174,20 @@ func TestTabletServerPrimaryToReplica(t *testing.T) {
 		ch <- true
 	}()
 
-	// SetServingType must rollback the prepared transaction,
-	// but it must wait for the unprepared (txid2) to become non-busy.
+	// SetServingType must wait for the unprepared (txid2) to become non-busy,
+	// and roll back the prepared transaction last.
 	select {
 	case <-ch:
 		t.Fatal("ch should not fire")
 	case <-time.After(10 * time.Millisecond):
 	}
-	require.EqualValues(t, 1, tsv.te.txPool.scp.active.Size(), "tsv.te.txPool.scp.active.Size()")
+	require.EqualValues(t, 2, tsv.te.txPool.scp.active.Size(), "tsv.te.txPool.scp.active.Size()")
+	require.EqualValues(t, 1, tsv.te.preparedPool.Size(), "tsv.te.preparedPool.Size()")
 
 	// Concluding conn2 will allow the transition to go through.
 	tsv.te.txPool.RollbackAndRelease(ctx, conn2)
 	<-ch
+	require.EqualValues(t, 0, tsv.te.preparedPool.Size(), "tsv.te.preparedPool.Size()")
 }
 
 func TestTabletServerRedoLogIsKeptBetweenRestarts(t *testing.T) {
@@ -259,12 +262,14 @@ func TestTabletServerRedoLogIsKeptBetweenRestarts(t *testing.T) {
 			sqltypes.NewVarBinary("unused"),
 		}},
 	})
+	// The transaction that can't be prepared again is marked as failed.
+	db.AddQuery("update _vt.redo_state set state = 0 where dtid = 'bogus'", &sqltypes.Result{})
 	turnOnTxEngine()
 	assert.EqualValues(t, 1, len(tsv.te.preparedPool.conns), "len(tsv.te.preparedPool.conns)")
 	got = tsv.te.preparedPool.conns["a:b:10"].TxProperties().Queries
 	want = []string{"update test_table set `name` = 2 where pk = 1 limit 10001"}
 	utils.MustMatch(t, want, got, "Prepared queries")
-	wantFailed := map[string]error{"a:b:20": errPrepFailed}
+	wantFailed := map[string]error{"a:b:20": errPrepFailed, "bogus": errPrepFailed}
 	utils.MustMatch(t, tsv.te.preparedPool.reserved, wantFailed, fmt.Sprintf("Failed dtids: %v, want %v", tsv.te.preparedPool.reserved, wantFailed))
 	// Verify last id got adjusted.
 	assert.EqualValues(t, 20, tsv.te.txPool.scp.lastID.Load(), "tsv.te.txPool.lastID.Get()")
@@ -438,6 +443,46 @@ func TestTabletServerConcludeTransaction(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestTabletServerWorkloadClasses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	config := tabletenv.NewDefaultConfig()
+	config.TxPool.Size = 3
+	_ = config.TxPool.TimeoutSeconds.Set("100ms")
+	config.TxPool.WorkloadClasses = map[string]tabletenv.WorkloadClassConfig{
+		"batch": {Burst: 1},
+	}
+	config.WorkloadClassUsers = map[string]string{"batch_user": "batch"}
+	db, tsv := setupTabletServerTestCustom(t, ctx, config, "")
+	defer tsv.StopService()
+	defer db.Close()
+
+	target := querypb.Target{TabletType: topodatapb.TabletType_PRIMARY}
+	batchCtx := callerid.NewContext(ctx, nil, callerid.NewImmediateCallerID("batch_user"))
+	state, err := tsv.Begin(batchCtx, &target, nil)
+	require.NoError(t, err)
+
+	// The batch class can only use one connection, whether its queries are
+	// picked by caller ID or by the WORKLOAD_NAME directive.
+	_, err = tsv.Begin(batchCtx, &target, nil)
+	require.ErrorContains(t, err, "workload class batch exceeded its capacity")
+	_, err = tsv.Begin(ctx, &target, &querypb.ExecuteOptions{WorkloadName: "batch"})
+	require.ErrorContains(t, err, "workload class batch exceeded its capacity")
+
+	// The other queries can still use the pool.
+	otherState, err := tsv.Begin(ctx, &target, &querypb.ExecuteOptions{WorkloadName: "app"})
+	require.NoError(t, err)
+	_, err = tsv.Rollback(ctx, &target, otherState.TransactionID)
+	require.NoError(t, err)
+
+	_, err = tsv.Rollback(ctx, &target, state.TransactionID)
+	require.NoError(t, err)
+	state, err = tsv.Begin(batchCtx, &target, nil)
+	require.NoError(t, err)
+	_, err = tsv.Rollback(ctx, &target, state.TransactionID)
+	require.NoError(t, err)
+}
+
 func TestTabletServerBeginFail(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -649,6 +694,63 @@ func TestTabletServerReleaseNonExistentConnection(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestTabletServerKillQueries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	db, tsv := setupTabletServerTest(t, ctx, "")
+	defer tsv.StopService()
+	defer db.Close()
+
+	db.AddQueryPattern(".*", &sqltypes.Result{})
+	target := querypb.Target{TabletType: topodatapb.TabletType_PRIMARY}
+
+	_, err := tsv.KillQueries(ctx, &target, "")
+	require.EqualError(t, err, "session UUID is required to kill queries")
+
+	// The session UUID of the running queries is recorded in the query lists.
+	var sessionUUIDs []string
+	db.AddQuery("select 42 from dual limit 10001", &sqltypes.Result{})
+	db.SetBeforeFunc("select 42 from dual limit 10001", func() {
+		tsv.statelessql.mu.Lock()
+		defer tsv.statelessql.mu.Unlock()
+		for _, qds := range tsv.statelessql.queryDetails {
+			for _, qd := range qds {
+				sessionUUIDs = append(sessionUUIDs, qd.sessionUUID)
+			}
+		}
+	})
+	_, err = tsv.Execute(ctx, &target, "select 42 from dual", nil, 0, 0, &querypb.ExecuteOptions{SessionUuid: "session1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"session1"}, sessionUUIDs)
+
+	conns := []*testConn{{id: 1}, {id: 2}, {id: 3}, {id: 4}}
+	for i, ql := range []*QueryList{tsv.statelessql, tsv.statefulql, tsv.olapql, tsv.olapql} {
+		qd := NewQueryDetail(ctx, conns[i])
+		qd.sessionUUID = "session1"
+		if i == 3 {
+			qd.sessionUUID = "session2"
+		}
+		ql.Add(qd)
+		defer ql.Remove(qd)
+	}
+	count, err := tsv.KillQueries(ctx, &target, "session1")
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, count)
+	assert.True(t, conns[0].queryKilled)
+	assert.True(t, conns[1].queryKilled)
+	assert.True(t, conns[2].queryKilled)
+	assert.False(t, conns[3].queryKilled)
+
+	// The queries can still be killed once the tablet stopped serving.
+	conns[0].queryKilled = false
+	err = tsv.SetServingType(topodatapb.TabletType_PRIMARY, time.Time{}, false, "")
+	require.NoError(t, err)
+	count, err = tsv.KillQueries(ctx, &target, "session1")
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, count)
+	assert.True(t, conns[0].queryKilled)
+}
+
 func TestMakeSureToCloseDbConnWhenBeginQueryFails(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -912,7 +1014,7 @@ func TestSerializeTransactionsSameRow(t *testing.T) {
 	db.SetBeforeFunc("update test_table set name_string = 'tx1' where pk = 1 and `name` = 1 limit 10001",
 		func() {
 			close(tx1Started)
-			if err := waitForTxSerializationPendingQueries(tsv, "test_table where pk = 1 and `name` = 1", 2); err != nil {
+			if err := waitForTxSerializationPendingQueries(tsv, "test_table where pk = 1", 2); err != nil {
 				t.Fatal(err)
 			}
 		})
@@ -1110,7 +1212,7 @@ func TestSerializeTransactionsSameRow_ConcurrentTransactions(t *testing.T) {
 	// transactions via db.SetBeforeFunc() for the same reason as mentioned
 	// in TestSerializeTransactionsSameRow: The MySQL C client does not seem
 	// to allow more than connection attempt at a time.
-	err := waitForTxSerializationPendingQueries(tsv, "test_table where pk = 1 and `name` = 1", 3)
+	err := waitForTxSerializationPendingQueries(tsv, "test_table where pk = 1", 3)
 	require.NoError(t, err)
 	close(allQueriesPending)
 
@@ -1123,6 +1225,66 @@ func TestSerializeTransactionsSameRow_ConcurrentTransactions(t *testing.T) {
 	}
 }
 
+func TestSerializeAutocommitSameRow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// An autocommit UPDATE is serialized with a transaction which updates the
+	// same row, even with a different WHERE clause.
+	config := tabletenv.NewDefaultConfig()
+	config.HotRowProtection.Mode = tabletenv.Enable
+	config.HotRowProtection.MaxConcurrency = 1
+	db, tsv := setupTabletServerTestCustom(t, ctx, config, "")
+	defer tsv.StopService()
+	defer db.Close()
+
+	target := querypb.Target{TabletType: topodatapb.TabletType_PRIMARY}
+	countStart := tsv.stats.WaitTimings.Counts()["TabletServerTest.TxSerializer"]
+
+	q1 := "update test_table set name_string = 'tx1' where pk = :pk and `name` = :name"
+	q2 := "update test_table set name_string = 'tx2' where `name` = :name and pk = :pk"
+	bvTx1 := map[string]*querypb.BindVariable{
+		"pk":   sqltypes.Int64BindVariable(1),
+		"name": sqltypes.Int64BindVariable(1),
+	}
+	bvTx2 := map[string]*querypb.BindVariable{
+		"pk":   sqltypes.Int64BindVariable(1),
+		"name": sqltypes.Int64BindVariable(1),
+	}
+	db.AddQuery("update test_table set name_string = 'tx2' where `name` = 1 and pk = 1 limit 10001", &sqltypes.Result{RowsAffected: 1})
+
+	tx1Started := make(chan struct{})
+	db.SetBeforeFunc("update test_table set name_string = 'tx1' where pk = 1 and `name` = 1 limit 10001",
+		func() {
+			close(tx1Started)
+			if err := waitForTxSerializationPendingQueries(tsv, "test_table where pk = 1", 2); err != nil {
+				t.Error(err)
+			}
+		})
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		state1, _, err := tsv.BeginExecute(ctx, &target, nil, q1, bvTx1, 0, nil)
+		if err != nil {
+			t.Errorf("failed to execute query: %s: %s", q1, err)
+		}
+		if _, err := tsv.Commit(ctx, &target, state1.TransactionID); err != nil {
+			t.Errorf("call TabletServer.Commit failed: %v", err)
+		}
+	}()
+
+	<-tx1Started
+	_, err := tsv.Execute(ctx, &target, q2, bvTx2, 0, 0, nil)
+	require.NoError(t, err)
+	wg.Wait()
+
+	got := tsv.stats.WaitTimings.Counts()["TabletServerTest.TxSerializer"]
+	assert.Equal(t, countStart+1, got, "the autocommit UPDATE must have waited")
+	assert.Equal(t, []txserializer.HotKey{{Key: "test_table where pk = 1", Count: 2}}, tsv.qe.txSerializer.HotKeys(10))
+}
+
 func waitForTxSerializationPendingQueries(tsv *TabletServer, key string, i int) error {
 	start := time.Now()
 	for {
@@ -1206,7 +1368,7 @@ func TestSerializeTransactionsSameRow_TooManyPendingRequests(t *testing.T) {
 
 		<-tx1Started
 		_, _, err := tsv.BeginExecute(ctx, &target, nil, q2, bvTx2, 0, nil)
-		if err == nil || vterrors.Code(err) != vtrpcpb.Code_RESOURCE_EXHAUSTED || err.Error() != "hot row protection: too many queued transactions (1 >= 1) for the same row (table + WHERE clause: 'test_table where pk = 1 and `name` = 1')" {
+		if err == nil || vterrors.Code(err) != vtrpcpb.Code_RESOURCE_EXHAUSTED || err.Error() != "hot row protection: too many queued transactions (1 >= 1) for the same row (table + WHERE clause: 'test_table where pk = 1')" {
 			t.Errorf("tx2 should have failed because there are too many pending requests: %v", err)
 		}
 		// No commit necessary because the Begin failed.
@@ -1311,7 +1473,7 @@ func TestSerializeTransactionsSameRow_RequestCanceled(t *testing.T) {
 		defer wg.Done()
 
 		// Wait until tx1 and tx2 are pending to make the test deterministic.
-		if err := waitForTxSerializationPendingQueries(tsv, "test_table where pk = 1 and `name` = 1", 2); err != nil {
+		if err := waitForTxSerializationPendingQueries(tsv, "test_table where pk = 1", 2); err != nil {
 			t.Error(err)
 		}
 
@@ -1326,7 +1488,7 @@ func TestSerializeTransactionsSameRow_RequestCanceled(t *testing.T) {
 	}()
 
 	// Wait until tx1, 2 and 3 are pending.
-	err := waitForTxSerializationPendingQueries(tsv, "test_table where pk = 1 and `name` = 1", 3)
+	err := waitForTxSerializationPendingQueries(tsv, "test_table where pk = 1", 3)
 	require.NoError(t, err)
 	// Now unblock tx2 and cancel it.
 	cancelTx2()
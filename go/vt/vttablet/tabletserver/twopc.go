@@ -58,6 +58,12 @@ const (
 	from %s.dt_state t
   join %s.dt_participant p on t.dtid = p.dtid
 	order by t.dtid, p.id`
+
+	sqlReadUnresolvedTransactions = `select t.dtid, t.state, t.time_created, p.keyspace, p.shard
+	from %s.dt_state t
+  join %s.dt_participant p on t.dtid = p.dtid
+	where t.time_created < %a
+	order by t.dtid, p.id`
 )
 
 // TwoPC performs 2PC metadata management (MM) functions.
@@ -81,6 +87,7 @@ type TwoPC struct {
 	readParticipants    *sqlparser.ParsedQuery
 	readAbandoned       *sqlparser.ParsedQuery
 	readAllTransactions string
+	readUnresolved      *sqlparser.ParsedQuery
 }
 
 // NewTwoPC creates a TwoPC variable.
@@ -132,6 +139,7 @@ func NewTwoPC(readPool *connpool.Pool) *TwoPC {
 		"select dtid, time_created from %s.dt_state where time_created < %a",
 		dbname, ":time_created")
 	tpc.readAllTransactions = fmt.Sprintf(sqlReadAllTransactions, dbname, dbname)
+	tpc.readUnresolved = sqlparser.BuildParsedQuery(sqlReadUnresolvedTransactions, dbname, dbname, ":time_created")
 	return tpc
 }
 
@@ -411,6 +419,51 @@ func (tpc *TwoPC) ReadAbandoned(ctx context.Context, abandonTime time.Time) (map
 	return txs, nil
 }
 
+// UnresolvedTransactions returns the metadata of the distributed
+// transactions created before abandonTime, along with their participants.
+func (tpc *TwoPC) UnresolvedTransactions(ctx context.Context, abandonTime time.Time) ([]*querypb.TransactionMetadata, error) {
+	conn, err := tpc.readPool.Get(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Recycle()
+
+	bindVars := map[string]*querypb.BindVariable{
+		"time_created": sqltypes.Int64BindVariable(abandonTime.UnixNano()),
+	}
+	qr, err := tpc.read(ctx, conn, tpc.readUnresolved, bindVars)
+	if err != nil {
+		return nil, err
+	}
+
+	var curTx *querypb.TransactionMetadata
+	var txs []*querypb.TransactionMetadata
+	for _, row := range qr.Rows {
+		dtid := row[0].ToString()
+		if curTx == nil || dtid != curTx.Dtid {
+			st, err := row[1].ToCastInt64()
+			if err != nil {
+				return nil, vterrors.Wrapf(err, "error parsing state for dtid %s", dtid)
+			}
+			// A failure in time parsing will show up as a very old time,
+			// which is harmless.
+			tm, _ := row[2].ToCastInt64()
+			curTx = &querypb.TransactionMetadata{
+				Dtid:        dtid,
+				State:       querypb.TransactionState(st),
+				TimeCreated: tm,
+			}
+			txs = append(txs, curTx)
+		}
+		curTx.Participants = append(curTx.Participants, &querypb.Target{
+			Keyspace:   row[3].ToString(),
+			Shard:      row[4].ToString(),
+			TabletType: topodatapb.TabletType_PRIMARY,
+		})
+	}
+	return txs, nil
+}
+
 // ReadAllTransactions returns info about all distributed transactions.
 func (tpc *TwoPC) ReadAllTransactions(ctx context.Context) ([]*tx.DistributedTx, error) {
 	conn, err := tpc.readPool.Get(ctx, nil)
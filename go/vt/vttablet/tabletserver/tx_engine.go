@@ -90,24 +90,32 @@ type TxEngine struct {
 	preparedPool *TxPreparedPool
 	twoPC        *TwoPC
 	twoPCReady   sync.WaitGroup
+	// twoPCResurrected is closed once the transactions prepared by the
+	// previous primary have been prepared again from the redo log.
+	twoPCResurrected chan struct{}
+
+	// dxNotifier is called by the watchdog when it finds distributed
+	// transactions that need to be resolved.
+	dxNotifier func()
 }
 
-// NewTxEngine creates a new TxEngine.
-func NewTxEngine(env tabletenv.Env) *TxEngine {
+// NewTxEngine creates a new TxEngine. dxNotifier is called whenever
+// unresolved distributed transactions are found, so that they can be
+// resolved by a vtgate.
+func NewTxEngine(env tabletenv.Env, dxNotifier func()) *TxEngine {
 	config := env.Config()
 	te := &TxEngine{
 		env:                 env,
 		shutdownGracePeriod: config.GracePeriods.ShutdownSeconds.Get(),
 		reservedConnStats:   env.Exporter().NewTimings("ReservedConnections", "Reserved connections stats", "operation"),
+		dxNotifier:          dxNotifier,
 	}
 	limiter := txlimiter.New(env)
 	te.txPool = NewTxPool(env, limiter)
 	te.twopcEnabled = config.TwoPCEnable
 	if te.twopcEnabled {
-		if config.TwoPCCoordinatorAddress == "" {
-			log.Error("Coordinator address not specified: Disabling 2PC")
-			te.twopcEnabled = false
-		}
+		// The coordinator address is optional: unresolved transactions are
+		// advertised on the health stream, and resolved by the vtgates.
 		if config.TwoPCAbandonAge <= 0 {
 			log.Error("2PC abandon age not specified: Disabling 2PC")
 			te.twopcEnabled = false
@@ -170,9 +178,12 @@ func (te *TxEngine) transition(state txEngineState) {
 		// than blocking everything for the sake of a few transactions.
 		// We do this async; so we do not end up blocking writes on
 		// failover for our setup tasks if using semi-sync replication.
+		resurrected := make(chan struct{})
+		te.twoPCResurrected = resurrected
 		te.twoPCReady.Add(1)
 		go func() {
 			defer te.twoPCReady.Done()
+			defer close(resurrected)
 			if err := te.twoPC.Open(te.env.Config().DB); err != nil {
 				te.env.Stats().InternalErrors.Add("TwopcOpen", 1)
 				log.Errorf("Could not open TwoPC engine: %v", err)
@@ -237,7 +248,23 @@ func (te *TxEngine) Begin(ctx context.Context, savepointQueries []string, reserv
 	}
 
 	defer te.beginRequests.Done()
-	conn, beginSQL, sessionStateChanges, err := te.txPool.Begin(ctx, options, te.state == AcceptingReadOnly, reservedID, savepointQueries, setting)
+	te.stateLock.Lock()
+	readOnly := te.state == AcceptingReadOnly
+	var resurrected chan struct{}
+	if te.twopcEnabled && te.state == AcceptingReadAndWrite {
+		resurrected = te.twoPCResurrected
+	}
+	te.stateLock.Unlock()
+	if resurrected != nil {
+		// The transactions prepared by the previous primary must be
+		// resurrected before anything else can lock the same rows.
+		select {
+		case <-resurrected:
+		case <-ctx.Done():
+			return 0, "", "", vterrors.Wrap(ctx.Err(), "waiting for the prepared transactions to be resurrected")
+		}
+	}
+	conn, beginSQL, sessionStateChanges, err := te.txPool.Begin(ctx, options, readOnly, reservedID, savepointQueries, setting)
 	if err != nil {
 		return 0, "", "", err
 	}
@@ -335,15 +362,20 @@ func (te *TxEngine) shutdownLocked() {
 		// If not immediate, we start with shutting down non-tx (reserved)
 		// connections.
 		te.txPool.scp.ShutdownNonTx()
+		// The prepared transactions are rolled back last, once the other
+		// transactions have concluded and can't write the rows they lock
+		// anymore. They are durable in the redo log, which replicates to the
+		// new primary where they get prepared again.
+		activeDone := make(chan struct{})
+		go func() {
+			defer close(activeDone)
+			te.waitForActiveTransactions(poolEmpty)
+		}()
 		if te.shutdownGracePeriod <= 0 {
 			// No grace period was specified. Wait indefinitely for transactions to be concluded.
-			// TODO(sougou): invoking rollbackPrepared is incorrect here. Prepared statements should
-			// actually be rolled back last. But this will cause the shutdown to hang because the
-			// tx pool will never become empty, because the prepared pool is holding on to connections
-			// from the tx pool. But we plan to deprecate this approach to 2PC. So, this
-			// should eventually be deleted.
-			te.rollbackPrepared()
 			log.Info("No grace period specified: performing normal wait.")
+			<-activeDone
+			te.rollbackPrepared()
 			return
 		}
 		tmr := time.NewTimer(te.shutdownGracePeriod)
@@ -352,9 +384,10 @@ func (te *TxEngine) shutdownLocked() {
 		case <-tmr.C:
 			log.Info("Grace period exceeded: rolling back now.")
 			te.shutdownTransactions()
-		case <-poolEmpty:
-			// The pool cleared before the timer kicked in. Just return.
+		case <-activeDone:
+			// The transactions concluded before the timer kicked in.
 			log.Info("Transactions completed before grace period: shutting down.")
+			te.rollbackPrepared()
 		}
 	}()
 	log.Infof("TxEngine - waiting for empty txPool")
@@ -405,6 +438,7 @@ outer:
 			if err != nil {
 				allErr.RecordError(err)
 				te.txPool.RollbackAndRelease(ctx, conn)
+				te.markRedoFailed(ctx, preparedTx.Dtid)
 				continue outer
 			}
 		}
@@ -413,6 +447,8 @@ outer:
 		err = te.preparedPool.Put(conn, preparedTx.Dtid)
 		if err != nil {
 			allErr.RecordError(err)
+			te.txPool.RollbackAndRelease(ctx, conn)
+			te.markRedoFailed(ctx, preparedTx.Dtid)
 			continue
 		}
 	}
@@ -431,6 +467,29 @@ outer:
 	return allErr.Error()
 }
 
+// markRedoFailed marks a transaction that could not be prepared again
+// from the redo log as failed, so that it's not silently lost: it
+// then needs to be resolved manually.
+func (te *TxEngine) markRedoFailed(ctx context.Context, dtid string) {
+	te.env.Stats().InternalErrors.Add("RedoPreparedFail", 1)
+	te.preparedPool.SetFailed(dtid)
+	conn, _, _, err := te.txPool.Begin(ctx, &querypb.ExecuteOptions{}, false, 0, nil, nil)
+	if err != nil {
+		log.Errorf("markRedoFailed: Begin failed for dtid %s: %v", dtid, err)
+		return
+	}
+	defer te.txPool.RollbackAndRelease(ctx, conn)
+
+	if err = te.twoPC.UpdateRedo(ctx, conn, dtid, RedoStateFailed); err != nil {
+		log.Errorf("markRedoFailed: UpdateRedo failed for dtid %s: %v", dtid, err)
+		return
+	}
+
+	if _, err = te.txPool.Commit(ctx, conn); err != nil {
+		log.Errorf("markRedoFailed: Commit failed for dtid %s: %v", dtid, err)
+	}
+}
+
 // shutdownTransactions rolls back all open transactions
 // including the prepared ones.
 // This is used for transitioning from a primary to a non-primary
@@ -445,6 +504,19 @@ func (te *TxEngine) shutdownTransactions() {
 	te.txPool.Shutdown(ctx)
 }
 
+// waitForActiveTransactions returns once the only transactions left in the
+// tx pool are the prepared ones, which hold on to their connections, or when
+// done is closed.
+func (te *TxEngine) waitForActiveTransactions(done <-chan bool) {
+	for te.txPool.scp.active.Size() > int64(te.preparedPool.Size()) {
+		select {
+		case <-done:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
 func (te *TxEngine) rollbackPrepared() {
 	ctx := tabletenv.LocalContext()
 	for _, conn := range te.preparedPool.FetchAll() {
@@ -465,7 +537,7 @@ func (te *TxEngine) startWatchdog() {
 		count, err := te.twoPC.CountUnresolvedRedo(ctx, time.Now().Add(-te.abandonAge*5))
 		if err != nil {
 			te.env.Stats().InternalErrors.Add("WatchdogFail", 1)
-			log.Errorf("Error reading unresolved prepares: '%v': %v", te.coordinatorAddress, err)
+			log.Errorf("Error reading unresolved prepares: %v", err)
 		}
 		te.env.Stats().Unresolved.Set("Prepares", count)
 
@@ -476,10 +548,19 @@ func (te *TxEngine) startWatchdog() {
 			log.Errorf("Error reading transactions for 2pc watchdog: %v", err)
 			return
 		}
+		te.env.Stats().Unresolved.Set("Transactions", int64(len(txs)))
 		if len(txs) == 0 {
 			return
 		}
 
+		// Let the vtgates know through the health stream.
+		if te.dxNotifier != nil {
+			te.dxNotifier()
+		}
+		if te.coordinatorAddress == "" {
+			return
+		}
+
 		coordConn, err := vtgateconn.Dial(ctx, te.coordinatorAddress)
 		if err != nil {
 			te.env.Stats().InternalErrors.Add("WatchdogFail", 1)
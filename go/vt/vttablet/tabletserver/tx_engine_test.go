@@ -48,7 +48,7 @@ func TestTxEngineClose(t *testing.T) {
 	config.TxPool.Size = 10
 	_ = config.Oltp.TxTimeoutSeconds.Set("100ms")
 	_ = config.GracePeriods.ShutdownSeconds.Set("0s")
-	te := NewTxEngine(tabletenv.NewEnv(config, "TabletServerTest"))
+	te := NewTxEngine(tabletenv.NewEnv(config, "TabletServerTest"), nil)
 
 	// Normal close.
 	te.AcceptReadWrite()
@@ -143,6 +143,86 @@ func TestTxEngineClose(t *testing.T) {
 	assert.EqualValues(t, 1, te.txPool.env.Stats().KillCounters.Counts()["ReservedConnection"])
 }
 
+func TestTxEngineDemoteWithPreparedTransactions(t *testing.T) {
+	db := setUpQueryExecutorTest(t)
+	defer db.Close()
+	ctx := context.Background()
+	config := tabletenv.NewDefaultConfig()
+	config.DB = newDBConfigs(db)
+	config.TxPool.Size = 10
+	_ = config.GracePeriods.ShutdownSeconds.Set("5s")
+	te := NewTxEngine(tabletenv.NewEnv(config, "TabletServerTest"), nil)
+
+	te.AcceptReadWrite()
+	c, _, _, err := te.txPool.Begin(ctx, &querypb.ExecuteOptions{}, false, 0, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, te.preparedPool.Put(c, "aa"))
+	c.Unlock()
+
+	// The prepared transaction is durable in the redo log, so the demotion
+	// doesn't wait for the grace period to roll it back.
+	start := time.Now()
+	te.AcceptReadOnly()
+	assert.Greater(t, int64(time.Second), int64(time.Since(start)))
+	assert.Empty(t, te.preparedPool.FetchAll())
+
+	// The prepared transactions are rolled back last, once the other
+	// transactions have concluded.
+	te.AcceptReadWrite()
+	c, _, _, err = te.txPool.Begin(ctx, &querypb.ExecuteOptions{}, false, 0, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, te.preparedPool.Put(c, "bb"))
+	c.Unlock()
+	active, _, _, err := te.txPool.Begin(ctx, &querypb.ExecuteOptions{}, false, 0, nil, nil)
+	require.NoError(t, err)
+	active.Unlock()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		assert.EqualValues(t, 1, te.preparedPool.Size())
+		_, err := te.txPool.GetAndLock(active.ReservedID(), "return")
+		assert.NoError(t, err)
+		te.txPool.RollbackAndRelease(ctx, active)
+	}()
+	start = time.Now()
+	te.AcceptReadOnly()
+	assert.Less(t, int64(50*time.Millisecond), int64(time.Since(start)))
+	assert.Greater(t, int64(time.Second), int64(time.Since(start)))
+	assert.Zero(t, te.preparedPool.Size())
+	te.Close()
+}
+
+func TestTxEngineBeginWaitsForResurrection(t *testing.T) {
+	db := setUpQueryExecutorTest(t)
+	defer db.Close()
+	db.AddQueryPattern(".*", &sqltypes.Result{})
+	config := tabletenv.NewDefaultConfig()
+	config.DB = newDBConfigs(db)
+	te := NewTxEngine(tabletenv.NewEnv(config, "TabletServerTest"), nil)
+	te.AcceptReadWrite()
+	defer te.Close()
+
+	// The transactions prepared by the previous primary are still being
+	// resurrected: Begin waits for them, but not beyond its context.
+	resurrected := make(chan struct{})
+	te.stateLock.Lock()
+	te.twopcEnabled = true
+	te.twoPCResurrected = resurrected
+	te.stateLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, _, err := te.Begin(ctx, nil, 0, nil, &querypb.ExecuteOptions{})
+	assert.ErrorContains(t, err, "waiting for the prepared transactions to be resurrected")
+
+	close(resurrected)
+	txID, _, _, err := te.Begin(context.Background(), nil, 0, nil, &querypb.ExecuteOptions{})
+	require.NoError(t, err)
+	_, err = te.Rollback(context.Background(), txID)
+	require.NoError(t, err)
+	te.stateLock.Lock()
+	te.twopcEnabled = false
+	te.stateLock.Unlock()
+}
+
 func TestTxEngineBegin(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -151,7 +231,7 @@ func TestTxEngineBegin(t *testing.T) {
 	db.AddQueryPattern(".*", &sqltypes.Result{})
 	config := tabletenv.NewDefaultConfig()
 	config.DB = newDBConfigs(db)
-	te := NewTxEngine(tabletenv.NewEnv(config, "TabletServerTest"))
+	te := NewTxEngine(tabletenv.NewEnv(config, "TabletServerTest"), nil)
 
 	for _, exec := range []func() (int64, string, error){
 		func() (int64, string, error) {
@@ -197,7 +277,7 @@ func TestTxEngineRenewFails(t *testing.T) {
 	db.AddQueryPattern(".*", &sqltypes.Result{})
 	config := tabletenv.NewDefaultConfig()
 	config.DB = newDBConfigs(db)
-	te := NewTxEngine(tabletenv.NewEnv(config, "TabletServerTest"))
+	te := NewTxEngine(tabletenv.NewEnv(config, "TabletServerTest"), nil)
 	te.AcceptReadOnly()
 	options := &querypb.ExecuteOptions{}
 	connID, _, err := te.ReserveBegin(ctx, options, nil, nil)
@@ -535,7 +615,7 @@ func setupTxEngine(db *fakesqldb.DB) *TxEngine {
 	config.TxPool.Size = 10
 	config.Oltp.TxTimeoutSeconds.Set("100ms")
 	_ = config.GracePeriods.ShutdownSeconds.Set("0s")
-	te := NewTxEngine(tabletenv.NewEnv(config, "TabletServerTest"))
+	te := NewTxEngine(tabletenv.NewEnv(config, "TabletServerTest"), nil)
 	return te
 }
 
@@ -567,7 +647,7 @@ func TestTxEngineFailReserve(t *testing.T) {
 	db.AddQueryPattern(".*", &sqltypes.Result{})
 	config := tabletenv.NewDefaultConfig()
 	config.DB = newDBConfigs(db)
-	te := NewTxEngine(tabletenv.NewEnv(config, "TabletServerTest"))
+	te := NewTxEngine(tabletenv.NewEnv(config, "TabletServerTest"), nil)
 
 	options := &querypb.ExecuteOptions{}
 	_, err := te.Reserve(ctx, options, 0, nil)
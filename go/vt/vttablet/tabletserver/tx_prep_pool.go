@@ -121,6 +121,13 @@ func (pp *TxPreparedPool) Forget(dtid string) {
 	delete(pp.reserved, dtid)
 }
 
+// Size returns the number of prepared transactions in the pool.
+func (pp *TxPreparedPool) Size() int {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	return len(pp.conns)
+}
+
 // FetchAll removes all connections and returns them as a list.
 // It also forgets all reserved dtids.
 func (pp *TxPreparedPool) FetchAll() []*StatefulConnection {
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package txserializer
+
+import (
+	"sort"
+	"time"
+)
+
+// HotKey is a row (range) key and the number of transactions which contended
+// for it over the hot keys window.
+type HotKey struct {
+	Key   string
+	Count int64
+}
+
+// hotKeys counts the transactions which contended for each key over a sliding
+// window. The window is divided in buckets, and the oldest bucket is dropped
+// as the window slides.
+// NOTE: hotKeys is not thread-safe. It is guarded by TxSerializer.mu.
+type hotKeys struct {
+	bucketDuration time.Duration
+	now            func() time.Time
+
+	// buckets is a ring of counts per key. current is the index of the most
+	// recent bucket, which started at currentStart.
+	buckets      []map[string]int64
+	current      int
+	currentStart time.Time
+}
+
+func newHotKeys(window time.Duration, numBuckets int, now func() time.Time) *hotKeys {
+	return &hotKeys{
+		bucketDuration: window / time.Duration(numBuckets),
+		now:            now,
+		buckets:        make([]map[string]int64, numBuckets),
+		currentStart:   now(),
+	}
+}
+
+// record counts a transaction which contended for the key.
+func (hk *hotKeys) record(key string) {
+	hk.advance()
+	if hk.buckets[hk.current] == nil {
+		hk.buckets[hk.current] = make(map[string]int64)
+	}
+	hk.buckets[hk.current][key]++
+}
+
+// top returns the n keys with the most contention over the window, sorted by
+// descending count.
+func (hk *hotKeys) top(n int) []HotKey {
+	hk.advance()
+	counts := make(map[string]int64)
+	for _, bucket := range hk.buckets {
+		for key, count := range bucket {
+			counts[key] += count
+		}
+	}
+	keys := make([]HotKey, 0, len(counts))
+	for key, count := range counts {
+		keys = append(keys, HotKey{Key: key, Count: count})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Count != keys[j].Count {
+			return keys[i].Count > keys[j].Count
+		}
+		return keys[i].Key < keys[j].Key
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
+
+// advance drops the buckets which slid out of the window.
+func (hk *hotKeys) advance() {
+	elapsed := int(hk.now().Sub(hk.currentStart) / hk.bucketDuration)
+	if elapsed <= 0 {
+		return
+	}
+	if elapsed > len(hk.buckets) {
+		elapsed = len(hk.buckets)
+	}
+	for i := 0; i < elapsed; i++ {
+		hk.current = (hk.current + 1) % len(hk.buckets)
+		hk.buckets[hk.current] = nil
+	}
+	hk.currentStart = hk.currentStart.Add(time.Duration(elapsed) * hk.bucketDuration)
+	// After a long idle period, start the current bucket now.
+	if hk.now().Sub(hk.currentStart) >= hk.bucketDuration {
+		hk.currentStart = hk.now()
+	}
+}
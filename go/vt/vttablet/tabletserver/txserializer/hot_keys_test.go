@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package txserializer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/streamlog"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/tabletenv"
+)
+
+func TestHotKeys(t *testing.T) {
+	now := time.Now()
+	hk := newHotKeys(time.Minute, 6, func() time.Time { return now })
+	assert.Empty(t, hk.top(10))
+
+	for i := 0; i < 3; i++ {
+		hk.record("t1 where id = 1")
+	}
+	hk.record("t1 where id = 2")
+	now = now.Add(25 * time.Second)
+	hk.record("t1 where id = 2")
+	hk.record("t2 where id = 1")
+	assert.Equal(t, []HotKey{
+		{Key: "t1 where id = 1", Count: 3},
+		{Key: "t1 where id = 2", Count: 2},
+		{Key: "t2 where id = 1", Count: 1},
+	}, hk.top(10))
+	assert.Equal(t, []HotKey{{Key: "t1 where id = 1", Count: 3}}, hk.top(1))
+
+	// The first records slide out of the window.
+	now = now.Add(40 * time.Second)
+	assert.Equal(t, []HotKey{
+		{Key: "t1 where id = 2", Count: 1},
+		{Key: "t2 where id = 1", Count: 1},
+	}, hk.top(10))
+
+	// After an idle period longer than the window, all records are dropped.
+	now = now.Add(time.Hour)
+	assert.Empty(t, hk.top(10))
+	hk.record("t1 where id = 3")
+	assert.Equal(t, []HotKey{{Key: "t1 where id = 3", Count: 1}}, hk.top(10))
+}
+
+func TestTxSerializerHotKeys(t *testing.T) {
+	config := tabletenv.NewDefaultConfig()
+	config.HotRowProtection.MaxConcurrency = 1
+	txs := New(tabletenv.NewEnv(config, "TxSerializerTest"))
+
+	// A single transaction per row is not contention.
+	done, _, err := txs.Wait(context.Background(), "t1 where id = 2", "t1")
+	require.NoError(t, err)
+	done()
+
+	done1, _, err := txs.Wait(context.Background(), "t1 where id = 1", "t1")
+	require.NoError(t, err)
+	waited := make(chan struct{})
+	go func() {
+		defer close(waited)
+		done2, _, err := txs.Wait(context.Background(), "t1 where id = 1", "t1")
+		require.NoError(t, err)
+		done2()
+	}()
+	require.NoError(t, waitForPending(txs, "t1 where id = 1", 2))
+	done1()
+	<-waited
+
+	assert.Equal(t, []HotKey{{Key: "t1 where id = 1", Count: 2}}, txs.HotKeys(10))
+
+	req, err := http.NewRequest("GET", "/debug/hotkeys", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	txs.ServeHotKeysHTTP(rr, req)
+	assert.Equal(t, "Window: 1m0s\nLength: 1\n2: t1 where id = 1\n", rr.Body.String())
+
+	streamlog.SetRedactDebugUIQueries(true)
+	defer streamlog.SetRedactDebugUIQueries(false)
+	rr = httptest.NewRecorder()
+	txs.ServeHotKeysHTTP(rr, req)
+	assert.Contains(t, rr.Body.String(), "/debug/hotkeys has been redacted for your protection")
+}
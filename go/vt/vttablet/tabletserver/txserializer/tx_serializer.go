@@ -38,8 +38,19 @@ import (
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 )
 
+const (
+	// hotKeysWindow is the sliding window over which the hottest row (range)
+	// keys are listed at /debug/hotkeys and in the TxSerializerHotKeys stats.
+	hotKeysWindow  = time.Minute
+	hotKeysBuckets = 6
+	// hotKeysStatsSize is the number of keys in the TxSerializerHotKeys stats.
+	hotKeysStatsSize = 10
+	// hotKeysDebugSize is the number of keys listed at /debug/hotkeys.
+	hotKeysDebugSize = 100
+)
+
 // TxSerializer serializes incoming transactions which target the same row range
-// i.e. table name and WHERE clause are identical.
+// i.e. table name and WHERE clause, or primary key, are identical.
 // Additional transactions are queued and woken up in arrival order.
 //
 // This implementation has some parallels to the sync2.Consolidator class.
@@ -90,12 +101,15 @@ type TxSerializer struct {
 	mu         sync.Mutex
 	queues     map[string]*queue
 	globalSize int
+	// hotKeys counts the transactions which contended for each row (range)
+	// over the last hotKeysWindow.
+	hotKeys *hotKeys
 }
 
 // New returns a TxSerializer object.
 func New(env tabletenv.Env) *TxSerializer {
 	config := env.Config()
-	return &TxSerializer{
+	txs := &TxSerializer{
 		env:                    env,
 		ConsolidatorCache:      sync2.NewConsolidatorCache(1000),
 		dryRun:                 config.HotRowProtection.Mode == tabletenv.Dryrun,
@@ -130,8 +144,24 @@ func New(env tabletenv.Env) *TxSerializer {
 		logQueueExceededDryRun:       logutil.NewThrottledLogger("HotRowProtection QueueExceeded DryRun", 5*time.Second),
 		logGlobalQueueExceededDryRun: logutil.NewThrottledLogger("HotRowProtection GlobalQueueExceeded DryRun", 5*time.Second),
 		queues:                       make(map[string]*queue),
+		hotKeys:                      newHotKeys(hotKeysWindow, hotKeysBuckets, time.Now),
 	}
-
+	env.Exporter().NewGaugesFuncWithMultiLabels(
+		"TxSerializerHotKeys",
+		"Number of transactions which contended for the hottest rows (ranges) over the last minute",
+		[]string{"key"},
+		func() map[string]int64 {
+			counts := make(map[string]int64)
+			for _, hk := range txs.HotKeys(hotKeysStatsSize) {
+				key := hk.Key
+				if config.SanitizeLogMessages {
+					key = txs.sanitizeKey(key)
+				}
+				counts[key] += hk.Count
+			}
+			return counts
+		})
+	return txs
 }
 
 // DoneFunc is returned by Wait() and must be called by the caller.
@@ -212,6 +242,7 @@ func (txs *TxSerializer) lockLocked(ctx context.Context, key, table string) (boo
 		// Include first transaction in the count at /debug/hotrows. (It was not
 		// recorded on purpose because it did not wait.)
 		txs.Record(key)
+		txs.hotKeys.record(key)
 	}
 
 	txs.globalSize++
@@ -220,8 +251,9 @@ func (txs *TxSerializer) lockLocked(ctx context.Context, key, table string) (boo
 	if q.size > q.max {
 		q.max = q.size
 	}
-	// Publish the number of waits at /debug/hotrows.
+	// Publish the number of waits at /debug/hotrows and /debug/hotkeys.
 	txs.Record(key)
+	txs.hotKeys.record(key)
 
 	if txs.dryRun {
 		txs.waitsDryRun.Add(table, 1)
@@ -323,23 +355,18 @@ func (txs *TxSerializer) Pending(key string) int {
 	return q.size
 }
 
+// HotKeys returns the n row (range) keys which the most transactions contended
+// for over the last minute, sorted by descending count.
+func (txs *TxSerializer) HotKeys(n int) []HotKey {
+	txs.mu.Lock()
+	defer txs.mu.Unlock()
+
+	return txs.hotKeys.top(n)
+}
+
 // ServeHTTP lists the most recent, cached queries and their count.
 func (txs *TxSerializer) ServeHTTP(response http.ResponseWriter, request *http.Request) {
-	if streamlog.GetRedactDebugUIQueries() {
-		response.Write([]byte(`
-	<!DOCTYPE html>
-	<html>
-	<body>
-	<h1>Redacted</h1>
-	<p>/debug/hotrows has been redacted for your protection</p>
-	</body>
-	</html>
-		`))
-		return
-	}
-
-	if err := acl.CheckAccessHTTP(request, acl.DEBUGGING); err != nil {
-		acl.SendError(response, err)
+	if !checkDebugAccess(response, request, "/debug/hotrows") {
 		return
 	}
 	items := txs.Items()
@@ -354,6 +381,48 @@ func (txs *TxSerializer) ServeHTTP(response http.ResponseWriter, request *http.R
 	}
 }
 
+// ServeHotKeysHTTP lists the row (range) keys which the most transactions
+// contended for over the last minute, and their count.
+func (txs *TxSerializer) ServeHotKeysHTTP(response http.ResponseWriter, request *http.Request) {
+	if !checkDebugAccess(response, request, "/debug/hotkeys") {
+		return
+	}
+	hotKeys := txs.HotKeys(hotKeysDebugSize)
+	response.Header().Set("Content-Type", "text/plain")
+	response.Write([]byte(fmt.Sprintf("Window: %v\n", hotKeysWindow)))
+	if len(hotKeys) == 0 {
+		response.Write([]byte("empty\n"))
+		return
+	}
+	response.Write([]byte(fmt.Sprintf("Length: %d\n", len(hotKeys))))
+	for _, hk := range hotKeys {
+		response.Write([]byte(fmt.Sprintf("%v: %s\n", hk.Count, hk.Key)))
+	}
+}
+
+// checkDebugAccess returns true if the debug page at path can be served. If
+// not, it writes the response.
+func checkDebugAccess(response http.ResponseWriter, request *http.Request, path string) bool {
+	if streamlog.GetRedactDebugUIQueries() {
+		response.Write([]byte(fmt.Sprintf(`
+	<!DOCTYPE html>
+	<html>
+	<body>
+	<h1>Redacted</h1>
+	<p>%s has been redacted for your protection</p>
+	</body>
+	</html>
+		`, path)))
+		return false
+	}
+
+	if err := acl.CheckAccessHTTP(request, acl.DEBUGGING); err != nil {
+		acl.SendError(response, err)
+		return false
+	}
+	return true
+}
+
 // queue represents the local queue for a particular row (range).
 //
 // Note that we don't use a dedicated queue structure for all waiting
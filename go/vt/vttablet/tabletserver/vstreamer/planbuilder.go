@@ -59,6 +59,10 @@ type Plan struct {
 	// Filters is the list of filters to be applied to the columns
 	// of the table.
 	Filters []Filter
+
+	// hasExprs is set if any of the Filters or ColExprs need the
+	// evalengine to be evaluated.
+	hasExprs bool
 }
 
 // Opcode enumerates the operators supported in a where clause
@@ -81,6 +85,9 @@ const (
 	NotEqual
 	// IsNotNull is used to filter a column if it is NULL
 	IsNotNull
+	// Expression is used to filter a row on any expression that
+	// the evalengine can evaluate, like LIKE, IN or IS NULL
+	Expression
 )
 
 // Filter contains opcodes for filtering.
@@ -97,6 +104,10 @@ type Filter struct {
 	Vindex        vindexes.Vindex
 	VindexColumns []int
 	KeyRange      *topodatapb.KeyRange
+
+	// Expr is evaluated against the columns of the table for the
+	// Expression opcode. The row matches if the result is true.
+	Expr evalengine.Expr
 }
 
 // ColExpr represents a column expression.
@@ -111,6 +122,10 @@ type ColExpr struct {
 	Vindex        vindexes.Vindex
 	VindexColumns []int
 
+	// Expr, if set, is evaluated against the columns of the table
+	// to generate the value. If so, ColNum is ignored.
+	Expr evalengine.Expr
+
 	Field *querypb.Field
 
 	FixedValue sqltypes.Value
@@ -216,8 +231,21 @@ func (plan *Plan) filter(values, result []sqltypes.Value, charsets []collations.
 	if len(result) != len(plan.ColExprs) {
 		return false, fmt.Errorf("expected %d values in result slice", len(plan.ColExprs))
 	}
+	var env *evalengine.ExpressionEnv
+	if plan.hasExprs {
+		env = evalengine.EmptyExpressionEnv()
+		env.Row = values
+	}
 	for _, filter := range plan.Filters {
 		switch filter.Opcode {
+		case Expression:
+			res, err := env.Evaluate(filter.Expr)
+			if err != nil {
+				return false, err
+			}
+			if !res.ToBoolean() {
+				return false, nil
+			}
 		case VindexMatch:
 			ksid, err := getKeyspaceID(values, filter.Vindex, filter.VindexColumns, plan.Table.Fields)
 			if err != nil {
@@ -241,6 +269,14 @@ func (plan *Plan) filter(values, result []sqltypes.Value, charsets []collations.
 		}
 	}
 	for i, colExpr := range plan.ColExprs {
+		if colExpr.Expr != nil {
+			res, err := env.Evaluate(colExpr.Expr)
+			if err != nil {
+				return false, err
+			}
+			result[i] = res.Value(collations.ID(colExpr.Field.Charset))
+			continue
+		}
 		if colExpr.ColNum == -1 {
 			result[i] = colExpr.FixedValue
 			continue
@@ -514,11 +550,17 @@ func (plan *Plan) analyzeWhere(vschema *localVSchema, where *sqlparser.Where) er
 		case *sqlparser.ComparisonExpr:
 			opcode, err := getOpcode(expr)
 			if err != nil {
-				return err
+				if err := plan.analyzeExprFilter(expr); err != nil {
+					return err
+				}
+				continue
 			}
 			qualifiedName, ok := expr.Left.(*sqlparser.ColName)
 			if !ok {
-				return fmt.Errorf("unexpected: %v", sqlparser.String(expr))
+				if err := plan.analyzeExprFilter(expr); err != nil {
+					return err
+				}
+				continue
 			}
 			if !qualifiedName.Qualifier.IsEmpty() {
 				return fmt.Errorf("unsupported qualifier for column: %v", sqlparser.String(qualifiedName))
@@ -528,12 +570,12 @@ func (plan *Plan) analyzeWhere(vschema *localVSchema, where *sqlparser.Where) er
 				return err
 			}
 			val, ok := expr.Right.(*sqlparser.Literal)
-			if !ok {
-				return fmt.Errorf("unexpected: %v", sqlparser.String(expr))
-			}
 			//StrVal is varbinary, we do not support varchar since we would have to implement all collation types
-			if val.Type != sqlparser.IntVal && val.Type != sqlparser.StrVal {
-				return fmt.Errorf("unexpected: %v", sqlparser.String(expr))
+			if !ok || (val.Type != sqlparser.IntVal && val.Type != sqlparser.StrVal) {
+				if err := plan.analyzeExprFilter(expr); err != nil {
+					return err
+				}
+				continue
 			}
 			pv, err := evalengine.Translate(val, nil)
 			if err != nil {
@@ -551,18 +593,21 @@ func (plan *Plan) analyzeWhere(vschema *localVSchema, where *sqlparser.Where) er
 			})
 		case *sqlparser.FuncExpr:
 			if !expr.Name.EqualString("in_keyrange") {
-				return fmt.Errorf("unsupported constraint: %v", sqlparser.String(expr))
+				if err := plan.analyzeExprFilter(expr); err != nil {
+					return err
+				}
+				continue
 			}
 			if err := plan.analyzeInKeyRange(vschema, expr.Exprs); err != nil {
 				return err
 			}
 		case *sqlparser.IsExpr: // Needed for CreateLookupVindex with ignore_nulls
-			if expr.Right != sqlparser.IsNotNullOp {
-				return fmt.Errorf("unsupported constraint: %v", sqlparser.String(expr))
-			}
 			qualifiedName, ok := expr.Left.(*sqlparser.ColName)
-			if !ok {
-				return fmt.Errorf("unexpected: %v", sqlparser.String(expr))
+			if !ok || expr.Right != sqlparser.IsNotNullOp {
+				if err := plan.analyzeExprFilter(expr); err != nil {
+					return err
+				}
+				continue
 			}
 			if !qualifiedName.Qualifier.IsEmpty() {
 				return fmt.Errorf("unsupported qualifier for column: %v", sqlparser.String(qualifiedName))
@@ -576,12 +621,79 @@ func (plan *Plan) analyzeWhere(vschema *localVSchema, where *sqlparser.Where) er
 				ColNum: colnum,
 			})
 		default:
-			return fmt.Errorf("unsupported constraint: %v", sqlparser.String(expr))
+			if err := plan.analyzeExprFilter(expr); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
+// analyzeExprFilter compiles a constraint that cannot be expressed with
+// the other opcodes, so that the evalengine evaluates it for every row.
+func (plan *Plan) analyzeExprFilter(expr sqlparser.Expr) error {
+	evalExpr, err := plan.translateExpr(expr)
+	if err != nil {
+		return fmt.Errorf("unsupported constraint: %v: %s", sqlparser.String(expr), err)
+	}
+	plan.Filters = append(plan.Filters, Filter{
+		Opcode: Expression,
+		Expr:   evalExpr,
+	})
+	return nil
+}
+
+// analyzeExprColumn compiles a select expression that is not a plain column,
+// so that the evalengine generates its value for every row.
+func (plan *Plan) analyzeExprColumn(aliased *sqlparser.AliasedExpr) (ColExpr, error) {
+	evalExpr, err := plan.translateExpr(aliased.Expr)
+	if err != nil {
+		return ColExpr{}, fmt.Errorf("unsupported: %v: %s", sqlparser.String(aliased.Expr), err)
+	}
+	typ, flags, err := evalengine.EmptyExpressionEnv().TypeOf(evalExpr, plan.Table.Fields)
+	if err != nil {
+		return ColExpr{}, fmt.Errorf("unsupported: %v: %s", sqlparser.String(aliased.Expr), err)
+	}
+	var collation collations.ID = collations.CollationBinaryID
+	if sqltypes.IsText(typ) {
+		collation = collations.Default()
+	}
+	fieldFlags := mysql.FlagsForColumn(typ, collation)
+	if !sqltypes.IsNull(typ) && !flags.Nullable() {
+		fieldFlags |= uint32(querypb.MySqlFlag_NOT_NULL_FLAG)
+	}
+	return ColExpr{
+		ColNum: -1,
+		Expr:   evalExpr,
+		Field: &querypb.Field{
+			Name:    aliased.ColumnName(),
+			Type:    typ,
+			Charset: uint32(collation),
+			Flags:   fieldFlags,
+		},
+	}, nil
+}
+
+// translateExpr compiles the expression with the evalengine, resolving the
+// columns to their position in the table.
+func (plan *Plan) translateExpr(expr sqlparser.Expr) (evalengine.Expr, error) {
+	evalExpr, err := evalengine.Translate(expr, &evalengine.Config{
+		ResolveColumn: func(col *sqlparser.ColName) (int, error) {
+			if !col.Qualifier.IsEmpty() {
+				return 0, fmt.Errorf("unsupported qualifier for column: %v", sqlparser.String(col))
+			}
+			return findColumn(plan.Table, col.Name)
+		},
+		ResolveType: evalengine.FieldResolver(plan.Table.Fields).Type,
+		Collation:   collations.Default(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	plan.hasExprs = true
+	return evalExpr, nil
+}
+
 // splitAndExpression breaks up the Expr into AND-separated conditions
 // and appends them to filters, which can be shuffled and recombined
 // as needed.
@@ -702,19 +814,20 @@ func (plan *Plan) analyzeExpr(vschema *localVSchema, selExpr sqlparser.SelectExp
 				Field:  field,
 			}, nil
 		default:
-			return ColExpr{}, fmt.Errorf("unsupported function: %v", sqlparser.String(inner))
+			return plan.analyzeExprColumn(aliased)
 		}
 	case *sqlparser.Literal:
-		//allow only intval 1
+		// The integer literal 1 is sent as a fixed value, any other
+		// literal is evaluated like any other expression.
 		if inner.Type != sqlparser.IntVal {
-			return ColExpr{}, fmt.Errorf("only integer literals are supported")
+			return plan.analyzeExprColumn(aliased)
 		}
 		num, err := strconv.ParseInt(string(inner.Val), 0, 64)
 		if err != nil {
 			return ColExpr{}, err
 		}
 		if num != 1 {
-			return ColExpr{}, fmt.Errorf("only the integer literal 1 is supported")
+			return plan.analyzeExprColumn(aliased)
 		}
 		return ColExpr{
 			Field: &querypb.Field{
@@ -757,8 +870,7 @@ func (plan *Plan) analyzeExpr(vschema *localVSchema, selExpr sqlparser.SelectExp
 			Field:  field,
 		}, nil
 	default:
-		log.Infof("Unsupported expression: %v", inner)
-		return ColExpr{}, fmt.Errorf("unsupported: %v", sqlparser.String(aliased.Expr))
+		return plan.analyzeExprColumn(aliased)
 	}
 }
 
@@ -576,7 +576,7 @@ func TestPlanBuilder(t *testing.T) {
 	}, {
 		inTable: t1,
 		inRule:  &binlogdatapb.Rule{Match: "t1", Filter: "select id, val from t1 where max(id)"},
-		outErr:  `unsupported constraint: max(id)`,
+		outErr:  `unsupported constraint: max(id): expr cannot be translated, not supported: max(id)`,
 	}, {
 		inTable: t1,
 		inRule:  &binlogdatapb.Rule{Match: "t1", Filter: "select id, val from t1 where in_keyrange(id)"},
@@ -616,8 +616,8 @@ func TestPlanBuilder(t *testing.T) {
 		outErr:  `unsupported function: max(val)`,
 	}, {
 		inTable: t1,
-		inRule:  &binlogdatapb.Rule{Match: "t1", Filter: "select id+1, val from t1"},
-		outErr:  `unsupported: id + 1`,
+		inRule:  &binlogdatapb.Rule{Match: "t1", Filter: "select id+none, val from t1"},
+		outErr:  "unsupported: id + `none`: column `none` not found in table t1",
 	}, {
 		inTable: t1,
 		inRule:  &binlogdatapb.Rule{Match: "t1", Filter: "select t1.id, val from t1"},
@@ -781,3 +781,112 @@ func TestCompare(t *testing.T) {
 		})
 	}
 }
+
+func TestPlanBuilderExpressions(t *testing.T) {
+	t1 := &Table{
+		Name: "t1",
+		Fields: []*querypb.Field{{
+			Name:    "id",
+			Type:    sqltypes.Int64,
+			Charset: collations.CollationBinaryID,
+			Flags:   uint32(querypb.MySqlFlag_BINARY_FLAG | querypb.MySqlFlag_NUM_FLAG),
+		}, {
+			Name:    "val",
+			Type:    sqltypes.VarChar,
+			Charset: uint32(collations.Default()),
+		}, {
+			Name:    "doc",
+			Type:    sqltypes.TypeJSON,
+			Charset: collations.CollationBinaryID,
+		}, {
+			Name:    "created",
+			Type:    sqltypes.Datetime,
+			Charset: collations.CollationBinaryID,
+			Flags:   uint32(querypb.MySqlFlag_BINARY_FLAG),
+		}},
+	}
+	rows := [][]sqltypes.Value{
+		{sqltypes.NewInt64(1), sqltypes.NewVarChar("apple"), sqltypes.MakeTrusted(sqltypes.TypeJSON, []byte(`{"color": "red"}`)), sqltypes.NewDatetime("2024-01-01 10:00:00")},
+		{sqltypes.NewInt64(2), sqltypes.NewVarChar("banana"), sqltypes.MakeTrusted(sqltypes.TypeJSON, []byte(`{"color": "yellow"}`)), sqltypes.NewDatetime("2024-02-01 10:00:00")},
+		{sqltypes.NewInt64(3), sqltypes.NULL, sqltypes.NULL, sqltypes.NewDatetime("2024-03-01 10:00:00")},
+	}
+
+	testcases := []struct {
+		filter string
+		want   []string
+	}{{
+		filter: "select id from t1 where val like 'b%'",
+		want:   []string{`[INT64(2)]`},
+	}, {
+		filter: "select id from t1 where id in (1, 3)",
+		want:   []string{`[INT64(1)]`, `[INT64(3)]`},
+	}, {
+		filter: "select id from t1 where val is null",
+		want:   []string{`[INT64(3)]`},
+	}, {
+		filter: "select id from t1 where id = 1 or val = 'banana'",
+		want:   []string{`[INT64(1)]`, `[INT64(2)]`},
+	}, {
+		filter: "select id from t1 where json_unquote(json_extract(doc, '$.color')) = 'yellow'",
+		want:   []string{`[INT64(2)]`},
+	}, {
+		filter: "select id from t1 where created + interval 1 month < '2024-03-01'",
+		want:   []string{`[INT64(1)]`},
+	}, {
+		filter: "select id, id * 10 as id10, upper(val) as uval from t1 where id < 3",
+		want:   []string{`[INT64(1) INT64(10) VARCHAR("APPLE")]`, `[INT64(2) INT64(20) VARCHAR("BANANA")]`},
+	}, {
+		filter: "select id, concat(val, '!') as v from t1 where id = 3",
+		want:   []string{`[INT64(3) NULL]`},
+	}}
+	for _, tcase := range testcases {
+		t.Run(tcase.filter, func(t *testing.T) {
+			plan, err := buildPlan(t1, testLocalVSchema, &binlogdatapb.Filter{
+				Rules: []*binlogdatapb.Rule{{Match: "t1", Filter: tcase.filter}},
+			})
+			require.NoError(t, err)
+
+			charsets := make([]collations.ID, len(t1.Fields))
+			for i, field := range t1.Fields {
+				charsets[i] = collations.ID(field.Charset)
+			}
+			var got []string
+			for _, row := range rows {
+				result := make([]sqltypes.Value, len(plan.ColExprs))
+				ok, err := plan.filter(row, result, charsets)
+				require.NoError(t, err)
+				if ok {
+					got = append(got, fmt.Sprintf("%v", result))
+				}
+			}
+			assert.Equal(t, tcase.want, got)
+		})
+	}
+}
+
+func TestPlanBuilderExpressionFields(t *testing.T) {
+	t1 := &Table{
+		Name: "t1",
+		Fields: []*querypb.Field{{
+			Name:    "id",
+			Type:    sqltypes.Int64,
+			Charset: collations.CollationBinaryID,
+			Flags:   uint32(querypb.MySqlFlag_BINARY_FLAG | querypb.MySqlFlag_NUM_FLAG),
+		}, {
+			Name:    "val",
+			Type:    sqltypes.VarChar,
+			Charset: uint32(collations.Default()),
+		}},
+	}
+	plan, err := buildPlan(t1, testLocalVSchema, &binlogdatapb.Filter{
+		Rules: []*binlogdatapb.Rule{{Match: "t1", Filter: "select id, id + 1 as next_id, lower(val) from t1"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, plan.ColExprs, 3)
+
+	assert.Equal(t, "next_id", plan.ColExprs[1].Field.Name)
+	assert.Equal(t, sqltypes.Int64, plan.ColExprs[1].Field.Type)
+	assert.Equal(t, "lower(val)", plan.ColExprs[2].Field.Name)
+	assert.Equal(t, sqltypes.VarChar, plan.ColExprs[2].Field.Type)
+	assert.Equal(t, uint32(collations.Default()), plan.ColExprs[2].Field.Charset)
+}
@@ -0,0 +1,400 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vstreamer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+// rowImage is the before or after image of a row, with one value per column
+// of the table. present tells which columns the image has. The columns dropped
+// from an image keep their values, so that the filter of the stream can still
+// use them.
+type rowImage struct {
+	values   []sqltypes.Value
+	charsets []collations.ID
+	present  []bool
+}
+
+func newRowImage(values []sqltypes.Value, charsets []collations.ID, dataColumns mysql.Bitmap) *rowImage {
+	img := &rowImage{
+		values:   values,
+		charsets: charsets,
+		present:  make([]bool, len(values)),
+	}
+	for i := range values {
+		img.present[i] = dataColumns.Bit(i)
+	}
+	return img
+}
+
+func (img *rowImage) set(fields []*querypb.Field, col int, value sqltypes.Value) {
+	img.values[col] = value
+	img.present[col] = true
+	if !value.IsNull() {
+		img.charsets[col] = collations.ID(fields[col].Charset)
+	}
+}
+
+func (img *rowImage) drop(col int) {
+	img.present[col] = false
+}
+
+// has returns true if the image has all the columns set in cols.
+func (img *rowImage) has(cols []bool) bool {
+	for col, want := range cols {
+		if want && !img.present[col] {
+			return false
+		}
+	}
+	return true
+}
+
+func (img *rowImage) complete() bool {
+	for _, present := range img.present {
+		if !present {
+			return false
+		}
+	}
+	return true
+}
+
+// keyColumns returns the primary key columns of a table, or all its columns if it has no primary key.
+func keyColumns(fields []*querypb.Field) []bool {
+	key := make([]bool, len(fields))
+	found := false
+	for i, field := range fields {
+		if field.Flags&uint32(querypb.MySqlFlag_PRI_KEY_FLAG) != 0 {
+			key[i] = true
+			found = true
+		}
+	}
+	if !found {
+		for i := range key {
+			key[i] = true
+		}
+	}
+	return key
+}
+
+// isBlobColumn returns true for the columns that binlog_row_image=noblob leaves out.
+func isBlobColumn(field *querypb.Field) bool {
+	switch field.Type {
+	case sqltypes.Blob, sqltypes.Text, sqltypes.TypeJSON, sqltypes.Geometry:
+		return true
+	}
+	return false
+}
+
+func sameValue(a, b sqltypes.Value) bool {
+	return a.Type() == b.Type() && bytes.Equal(a.Raw(), b.Raw())
+}
+
+// shapeRowImages shapes the images of a row change to a RowImage. before is nil
+// for an insert and after is nil for a delete. The columns the images need and
+// the binlog did not log are taken from the other image when they did not change,
+// or read from the current row with lookup. lookup returns nil if the row does
+// not exist anymore. The values of a column whose previous value was not logged
+// are lost, so such a column stays missing from the before image, as do the
+// missing columns of a deleted row.
+func shapeRowImages(fields []*querypb.Field, image binlogdatapb.RowImage, before, after *rowImage, lookup func(key *rowImage) ([]sqltypes.Value, error)) error {
+	key := keyColumns(fields)
+	update := before != nil && after != nil
+
+	changed := make([]bool, len(fields))
+	for col := range fields {
+		switch {
+		case after == nil:
+		case !update:
+			changed[col] = true
+		default:
+			changed[col] = after.present[col] && !(before.present[col] && sameValue(before.values[col], after.values[col]))
+		}
+	}
+	wantBefore := func(col int) bool {
+		switch image {
+		case binlogdatapb.RowImage_MINIMAL:
+			return key[col]
+		case binlogdatapb.RowImage_NOBLOB:
+			return key[col] || !isBlobColumn(fields[col])
+		}
+		return true
+	}
+	wantAfter := func(col int) bool {
+		switch image {
+		case binlogdatapb.RowImage_MINIMAL:
+			return key[col] || changed[col]
+		case binlogdatapb.RowImage_NOBLOB:
+			return key[col] || !isBlobColumn(fields[col]) || changed[col]
+		}
+		return true
+	}
+
+	// The columns missing from the after image of an update did not change.
+	if update {
+		for col := range fields {
+			if !after.present[col] && before.present[col] && wantAfter(col) {
+				after.set(fields, col, before.values[col])
+			}
+		}
+	}
+
+	var missing []int
+	for col := range fields {
+		switch {
+		case after != nil && !after.present[col] && wantAfter(col):
+			missing = append(missing, col)
+		case update && !before.present[col] && !changed[col] && wantBefore(col):
+			missing = append(missing, col)
+		}
+	}
+	// The row cannot be looked up without its key.
+	if len(missing) != 0 && after != nil && after.has(key) {
+		current, err := lookup(after)
+		if err != nil {
+			return err
+		}
+		if current != nil {
+			for _, col := range missing {
+				if !after.present[col] {
+					after.set(fields, col, current[col])
+				}
+			}
+		}
+	}
+	if update {
+		for col := range fields {
+			if !before.present[col] && !changed[col] && after.present[col] && wantBefore(col) {
+				before.set(fields, col, after.values[col])
+			}
+		}
+	}
+
+	for col := range fields {
+		if before != nil && before.present[col] && !wantBefore(col) {
+			before.drop(col)
+		}
+		if after != nil && after.present[col] && !wantAfter(col) {
+			after.drop(col)
+		}
+	}
+	return nil
+}
+
+// filterRowImage applies the plan to a row image. It returns false if the row
+// does not match the filter, and the bitmap of the columns present in the result
+// if some are missing.
+func filterRowImage(plan *Plan, img *rowImage) (bool, []sqltypes.Value, *binlogdatapb.RowChange_Bitmap, error) {
+	result := make([]sqltypes.Value, len(plan.ColExprs))
+	ok, err := plan.filter(img.values, result, img.charsets)
+	if err != nil || !ok {
+		return false, nil, nil, err
+	}
+	complete := img.complete()
+	if complete {
+		return true, result, nil, nil
+	}
+	columns := mysql.NewServerBitmap(len(plan.ColExprs))
+	for i, colExpr := range plan.ColExprs {
+		present := false
+		switch {
+		case colExpr.Expr != nil:
+			present = complete
+		case colExpr.ColNum == -1:
+			present = true
+		case colExpr.Vindex != nil:
+			present = true
+			for _, col := range colExpr.VindexColumns {
+				present = present && img.present[col]
+			}
+		default:
+			present = img.present[colExpr.ColNum]
+		}
+		if !present {
+			result[i] = sqltypes.NULL
+		}
+		columns.Set(i, present)
+	}
+	return true, result, &binlogdatapb.RowChange_Bitmap{
+		Count: int64(columns.Count()),
+		Cols:  columns.Bits(),
+	}, nil
+}
+
+// buildImageRowChange builds the change of a row for a table streamed with a RowImage.
+// It returns nil if the row does not match the filter.
+func (vs *vstreamer) buildImageRowChange(plan *streamerPlan, image binlogdatapb.RowImage, rows mysql.Rows, row mysql.Row) (*binlogdatapb.RowChange, error) {
+	fields := plan.Table.Fields
+	var before, after *rowImage
+	if len(row.Identify) != 0 {
+		values, charsets, _, err := extractRow(plan, row.Identify, rows.IdentifyColumns, row.NullIdentifyColumns)
+		if err != nil {
+			return nil, err
+		}
+		before = newRowImage(values, charsets, rows.IdentifyColumns)
+	}
+	if len(row.Data) != 0 {
+		values, charsets, _, err := extractRow(plan, row.Data, rows.DataColumns, row.NullColumns)
+		if err != nil {
+			return nil, err
+		}
+		after = newRowImage(values, charsets, rows.DataColumns)
+	}
+	if before == nil && after == nil {
+		return nil, nil
+	}
+	err := shapeRowImages(fields, image, before, after, func(key *rowImage) ([]sqltypes.Value, error) {
+		return vs.lookupRow(plan, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rowChange := &binlogdatapb.RowChange{}
+	if before != nil {
+		ok, values, columns, err := filterRowImage(plan.Plan, before)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			rowChange.Before = sqltypes.RowToProto3(values)
+			rowChange.BeforeColumns = columns
+		}
+	}
+	if after != nil {
+		ok, values, columns, err := filterRowImage(plan.Plan, after)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			rowChange.After = sqltypes.RowToProto3(values)
+			rowChange.DataColumns = columns
+		}
+	}
+	if rowChange.Before == nil && rowChange.After == nil {
+		return nil, nil
+	}
+	return rowChange, nil
+}
+
+// lookupRow reads the current values of the columns of a row, by the values of
+// its key columns in key. The values are returned the way the binlog encodes
+// them, or nil if the row does not exist.
+func (vs *vstreamer) lookupRow(plan *streamerPlan, key *rowImage) ([]sqltypes.Value, error) {
+	conn, err := vs.getRowConn()
+	if err != nil {
+		return nil, err
+	}
+	fields := plan.Table.Fields
+	buf := sqlparser.NewTrackedBuffer(nil)
+	buf.Myprintf("select ")
+	for i, field := range fields {
+		if i > 0 {
+			buf.Myprintf(", ")
+		}
+		switch field.Type {
+		case sqltypes.Enum, sqltypes.Set:
+			// The binlog has the numeric values of enums and sets.
+			buf.Myprintf("%v+0", sqlparser.NewIdentifierCI(field.Name))
+		default:
+			buf.Myprintf("%v", sqlparser.NewIdentifierCI(field.Name))
+		}
+	}
+	buf.Myprintf(" from %v.%v where ", sqlparser.NewIdentifierCS(vs.cp.DBName()), sqlparser.NewIdentifierCS(plan.Table.Name))
+	prefix := ""
+	for col, isKey := range keyColumns(fields) {
+		if !isKey {
+			continue
+		}
+		value := key.values[col]
+		if fields[col].Type == sqltypes.Set && !value.IsNull() {
+			value = sqltypes.NewUint64(setBits(value.Raw()))
+		}
+		buf.Myprintf("%s%v <=> ", prefix, sqlparser.NewIdentifierCI(fields[col].Name))
+		value.EncodeSQL(buf)
+		prefix = " and "
+	}
+	buf.Myprintf(" limit 1")
+	qr, err := conn.ExecuteFetch(buf.String(), 1, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(qr.Rows) == 0 {
+		return nil, nil
+	}
+	values := make([]sqltypes.Value, len(fields))
+	for col, value := range qr.Rows[0] {
+		if value.IsNull() {
+			values[col] = sqltypes.NULL
+			continue
+		}
+		raw := value.Raw()
+		if fields[col].Type == sqltypes.Set {
+			bits, err := value.ToUint64()
+			if err != nil {
+				return nil, err
+			}
+			raw = binary.LittleEndian.AppendUint64(nil, bits)[:plan.TableMap.Metadata[col]&0xff]
+		}
+		values[col] = sqltypes.MakeTrusted(fields[col].Type, raw)
+	}
+	return values, nil
+}
+
+// setBits returns the numeric value of a set, as encoded in the binlog.
+func setBits(raw []byte) uint64 {
+	var bits uint64
+	for i := len(raw) - 1; i >= 0; i-- {
+		bits = bits<<8 | uint64(raw[i])
+	}
+	return bits
+}
+
+func (vs *vstreamer) getRowConn() (*mysql.Conn, error) {
+	if vs.rowConn != nil {
+		return vs.rowConn, nil
+	}
+	conn, err := vs.cp.Connect(vs.ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The binlog has the values of the timestamps in UTC.
+	if _, err := conn.ExecuteFetch("set @@session.time_zone = '+00:00'", 0, false); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("cannot prepare the connection to look up rows: %v", err)
+	}
+	vs.rowConn = conn
+	return conn, nil
+}
+
+func (vs *vstreamer) closeRowConn() {
+	if vs.rowConn == nil {
+		return
+	}
+	vs.rowConn.Close()
+	vs.rowConn = nil
+}
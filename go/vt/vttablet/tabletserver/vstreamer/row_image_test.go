@@ -0,0 +1,202 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vstreamer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+var rowImageFields = []*querypb.Field{{
+	Name:  "id",
+	Type:  sqltypes.Int64,
+	Flags: uint32(querypb.MySqlFlag_PRI_KEY_FLAG | querypb.MySqlFlag_NOT_NULL_FLAG),
+}, {
+	Name:    "name",
+	Type:    sqltypes.VarChar,
+	Charset: collations.CollationUtf8mb4ID,
+}, {
+	Name: "data",
+	Type: sqltypes.Blob,
+}, {
+	Name: "price",
+	Type: sqltypes.Int64,
+}}
+
+// testRowImage returns an image with the given values. nil values are missing from the image.
+func testRowImage(values ...any) *rowImage {
+	img := &rowImage{
+		values:   make([]sqltypes.Value, len(values)),
+		charsets: make([]collations.ID, len(values)),
+		present:  make([]bool, len(values)),
+	}
+	for i, v := range values {
+		switch v := v.(type) {
+		case nil:
+			continue
+		case int:
+			img.values[i] = sqltypes.NewInt64(int64(v))
+		case string:
+			img.values[i] = sqltypes.NewVarChar(v)
+		case []byte:
+			img.values[i] = sqltypes.MakeTrusted(sqltypes.Blob, v)
+		}
+		img.present[i] = true
+	}
+	return img
+}
+
+func imageValues(img *rowImage) []any {
+	var values []any
+	for i, present := range img.present {
+		if !present {
+			values = append(values, nil)
+			continue
+		}
+		values = append(values, img.values[i].ToString())
+	}
+	return values
+}
+
+func TestShapeRowImages(t *testing.T) {
+	current := []sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewVarChar("apple"), sqltypes.MakeTrusted(sqltypes.Blob, []byte("blob")), sqltypes.NewInt64(20)}
+	testcases := []struct {
+		name          string
+		image         binlogdatapb.RowImage
+		before, after *rowImage
+		row           []sqltypes.Value
+		wantBefore    []any
+		wantAfter     []any
+		wantLookup    bool
+	}{{
+		name:       "full update from a minimal binlog",
+		image:      binlogdatapb.RowImage_FULL,
+		before:     testRowImage(1, nil, nil, nil),
+		after:      testRowImage(nil, nil, nil, 20),
+		row:        current,
+		wantBefore: []any{"1", "apple", "blob", nil},
+		wantAfter:  []any{"1", "apple", "blob", "20"},
+		wantLookup: true,
+	}, {
+		name:       "full update from a noblob binlog",
+		image:      binlogdatapb.RowImage_FULL,
+		before:     testRowImage(1, "apple", nil, 10),
+		after:      testRowImage(1, "apple", nil, 20),
+		row:        current,
+		wantBefore: []any{"1", "apple", "blob", "10"},
+		wantAfter:  []any{"1", "apple", "blob", "20"},
+		wantLookup: true,
+	}, {
+		name:       "full update from a full binlog",
+		image:      binlogdatapb.RowImage_FULL,
+		before:     testRowImage(1, "apple", []byte("blob"), 10),
+		after:      testRowImage(1, "apple", []byte("blob"), 20),
+		wantBefore: []any{"1", "apple", "blob", "10"},
+		wantAfter:  []any{"1", "apple", "blob", "20"},
+	}, {
+		name:       "full delete from a minimal binlog",
+		image:      binlogdatapb.RowImage_FULL,
+		before:     testRowImage(1, nil, nil, nil),
+		wantBefore: []any{"1", nil, nil, nil},
+	}, {
+		name:       "full insert of a row that was deleted since",
+		image:      binlogdatapb.RowImage_FULL,
+		after:      testRowImage(1, "apple", nil, nil),
+		wantAfter:  []any{"1", "apple", nil, nil},
+		wantLookup: true,
+	}, {
+		name:       "minimal update from a full binlog",
+		image:      binlogdatapb.RowImage_MINIMAL,
+		before:     testRowImage(1, "apple", []byte("blob"), 10),
+		after:      testRowImage(1, "apple", []byte("blob"), 20),
+		wantBefore: []any{"1", nil, nil, nil},
+		wantAfter:  []any{"1", nil, nil, "20"},
+	}, {
+		name:       "minimal update from a minimal binlog",
+		image:      binlogdatapb.RowImage_MINIMAL,
+		before:     testRowImage(1, nil, nil, nil),
+		after:      testRowImage(nil, nil, nil, 20),
+		wantBefore: []any{"1", nil, nil, nil},
+		wantAfter:  []any{"1", nil, nil, "20"},
+	}, {
+		name:      "minimal insert",
+		image:     binlogdatapb.RowImage_MINIMAL,
+		after:     testRowImage(1, "apple", []byte("blob"), 20),
+		wantAfter: []any{"1", "apple", "blob", "20"},
+	}, {
+		name:       "noblob update of a blob",
+		image:      binlogdatapb.RowImage_NOBLOB,
+		before:     testRowImage(1, "apple", []byte("old"), 10),
+		after:      testRowImage(1, "apple", []byte("blob"), 10),
+		wantBefore: []any{"1", "apple", nil, "10"},
+		wantAfter:  []any{"1", "apple", "blob", "10"},
+	}, {
+		name:       "noblob update from a minimal binlog",
+		image:      binlogdatapb.RowImage_NOBLOB,
+		before:     testRowImage(1, nil, nil, nil),
+		after:      testRowImage(nil, nil, nil, 20),
+		row:        current,
+		wantBefore: []any{"1", "apple", nil, nil},
+		wantAfter:  []any{"1", "apple", nil, "20"},
+		wantLookup: true,
+	}}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			looked := false
+			err := shapeRowImages(rowImageFields, tc.image, tc.before, tc.after, func(key *rowImage) ([]sqltypes.Value, error) {
+				looked = true
+				assert.Equal(t, "1", key.values[0].ToString())
+				return tc.row, nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantLookup, looked)
+			if tc.wantBefore != nil {
+				assert.Equal(t, tc.wantBefore, imageValues(tc.before))
+			}
+			if tc.wantAfter != nil {
+				assert.Equal(t, tc.wantAfter, imageValues(tc.after))
+			}
+		})
+	}
+}
+
+func TestFilterRowImage(t *testing.T) {
+	plan, err := buildREPlan(&Table{Name: "t1", Fields: rowImageFields}, nil, "")
+	require.NoError(t, err)
+
+	ok, values, columns, err := filterRowImage(plan, testRowImage(1, "apple", []byte("blob"), 10))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Len(t, values, 4)
+	assert.Nil(t, columns)
+
+	img := testRowImage(1, "apple", []byte("blob"), 10)
+	img.drop(2)
+	ok, values, columns, err = filterRowImage(plan, img)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewVarChar("apple"), sqltypes.NULL, sqltypes.NewInt64(10)}, values)
+	assert.Equal(t, &binlogdatapb.RowChange_Bitmap{Count: 4, Cols: []byte{0b1011}}, columns)
+}
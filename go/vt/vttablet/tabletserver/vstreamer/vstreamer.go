@@ -82,6 +82,10 @@ type vstreamer struct {
 
 	phase string
 	vse   *Engine
+
+	// rowConn is the connection used to read the rows missing columns
+	// for the tables streamed with a RowImage. It is opened on demand.
+	rowConn *mysql.Conn
 }
 
 // streamerPlan extends the original plan to also include
@@ -189,6 +193,7 @@ func (vs *vstreamer) replicate(ctx context.Context) error {
 		return wrapError(err, vs.pos, vs.vse)
 	}
 	defer conn.Close()
+	defer vs.closeRowConn()
 
 	events, errs, err := conn.StartBinlogDumpFromPosition(vs.ctx, "", vs.pos)
 	if err != nil {
@@ -906,7 +911,18 @@ nextrow:
 
 func (vs *vstreamer) processRowEvent(vevents []*binlogdatapb.VEvent, plan *streamerPlan, rows mysql.Rows) ([]*binlogdatapb.VEvent, error) {
 	rowChanges := make([]*binlogdatapb.RowChange, 0, len(rows.Rows))
+	image := vs.filter.GetRowImages()[plan.Table.Name]
 	for _, row := range rows.Rows {
+		if image != binlogdatapb.RowImage_AS_LOGGED {
+			rowChange, err := vs.buildImageRowChange(plan, image, rows, row)
+			if err != nil {
+				return nil, err
+			}
+			if rowChange != nil {
+				rowChanges = append(rowChanges, rowChange)
+			}
+			continue
+		}
 		beforeOK, beforeValues, _, err := vs.extractRowAndFilter(plan, row.Identify, rows.IdentifyColumns, row.NullIdentifyColumns)
 		if err != nil {
 			return nil, err
@@ -980,6 +996,22 @@ func (vs *vstreamer) extractRowAndFilter(plan *streamerPlan, data []byte, dataCo
 	if len(data) == 0 {
 		return false, nil, false, nil
 	}
+	values, charsets, partial, err := extractRow(plan, data, dataColumns, nullColumns)
+	if err != nil {
+		return false, nil, false, err
+	}
+	if partial && vttablet.VReplicationExperimentalFlags /**/ & /**/ vttablet.VReplicationExperimentalFlagAllowNoBlobBinlogRowImage == 0 {
+		return false, nil, false, fmt.Errorf("partial row image encountered: ensure binlog_row_image is set to 'full'")
+	}
+	filtered := make([]sqltypes.Value, len(plan.ColExprs))
+	ok, err := plan.filter(values, filtered, charsets)
+	return ok, filtered, partial, err
+}
+
+// extractRow decodes the data of a row image from the binlog events. It returns
+// one value per column, the charsets of the values, and true if the image was
+// partial. The values of the columns missing from a partial image are NULL.
+func extractRow(plan *streamerPlan, data []byte, dataColumns, nullColumns mysql.Bitmap) ([]sqltypes.Value, []collations.ID, bool, error) {
 	values := make([]sqltypes.Value, dataColumns.Count())
 	charsets := make([]collations.ID, len(values))
 	valueIndex := 0
@@ -987,11 +1019,7 @@ func (vs *vstreamer) extractRowAndFilter(plan *streamerPlan, data []byte, dataCo
 	partial := false
 	for colNum := 0; colNum < dataColumns.Count(); colNum++ {
 		if !dataColumns.Bit(colNum) {
-			if vttablet.VReplicationExperimentalFlags /**/ & /**/ vttablet.VReplicationExperimentalFlagAllowNoBlobBinlogRowImage == 0 {
-				return false, nil, false, fmt.Errorf("partial row image encountered: ensure binlog_row_image is set to 'full'")
-			} else {
-				partial = true
-			}
+			partial = true
 			continue
 		}
 		if nullColumns.Bit(valueIndex) {
@@ -1000,9 +1028,9 @@ func (vs *vstreamer) extractRowAndFilter(plan *streamerPlan, data []byte, dataCo
 		}
 		value, l, err := mysqlbinlog.CellValue(data, pos, plan.TableMap.Types[colNum], plan.TableMap.Metadata[colNum], plan.Table.Fields[colNum])
 		if err != nil {
-			log.Errorf("extractRowAndFilter: %s, table: %s, colNum: %d, fields: %+v, current values: %+v",
+			log.Errorf("extractRow: %s, table: %s, colNum: %d, fields: %+v, current values: %+v",
 				err, plan.Table.Name, colNum, plan.Table.Fields, values)
-			return false, nil, false, err
+			return nil, nil, false, err
 		}
 		pos += l
 
@@ -1010,9 +1038,7 @@ func (vs *vstreamer) extractRowAndFilter(plan *streamerPlan, data []byte, dataCo
 		values[colNum] = value
 		valueIndex++
 	}
-	filtered := make([]sqltypes.Value, len(plan.ColExprs))
-	ok, err := plan.filter(values, filtered, charsets)
-	return ok, filtered, partial, err
+	return values, charsets, partial, nil
 }
 
 func wrapError(err error, stopPos replication.Position, vse *Engine) error {
@@ -818,6 +818,53 @@ func TestFilteredInt(t *testing.T) {
 	runCases(t, filter, testcases, "", nil)
 }
 
+func TestFilteredExpression(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	engine.se.Reload(context.Background())
+
+	execStatements(t, []string{
+		"create table t1(id1 int, id2 int, val varbinary(128), primary key(id1))",
+	})
+	defer execStatements(t, []string{
+		"drop table t1",
+	})
+	engine.se.Reload(context.Background())
+
+	filter := &binlogdatapb.Filter{
+		Rules: []*binlogdatapb.Rule{{
+			Match:  "t1",
+			Filter: "select id1, val from t1 where id2 in (200, 300) and val like 'b%'",
+		}},
+	}
+
+	testcases := []testcase{{
+		input: []string{
+			"begin",
+			"insert into t1 values (1, 100, 'aaa')",
+			"insert into t1 values (2, 200, 'bbb')",
+			"insert into t1 values (3, 300, 'bcd')",
+			"insert into t1 values (4, 200, 'abc')",
+			"update t1 set id2 = 300 where id1 = 1",
+			"update t1 set val = 'bzz' where id1 = 4",
+			"update t1 set id2 = 100 where id1 = 2",
+			"commit",
+		},
+		output: [][]string{{
+			`begin`,
+			`type:FIELD field_event:{table_name:"t1" fields:{name:"id1" type:INT32 table:"t1" org_table:"t1" database:"vttest" org_name:"id1" column_length:11 charset:63 column_type:"int(11)"} fields:{name:"val" type:VARBINARY table:"t1" org_table:"t1" database:"vttest" org_name:"val" column_length:128 charset:63 column_type:"varbinary(128)"}}`,
+			`type:ROW row_event:{table_name:"t1" row_changes:{after:{lengths:1 lengths:3 values:"2bbb"}}}`,
+			`type:ROW row_event:{table_name:"t1" row_changes:{after:{lengths:1 lengths:3 values:"3bcd"}}}`,
+			`type:ROW row_event:{table_name:"t1" row_changes:{after:{lengths:1 lengths:3 values:"4bzz"}}}`,
+			`type:ROW row_event:{table_name:"t1" row_changes:{before:{lengths:1 lengths:3 values:"2bbb"}}}`,
+			`gtid`,
+			`commit`,
+		}},
+	}}
+	runCases(t, filter, testcases, "", nil)
+}
+
 func TestSavepoint(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
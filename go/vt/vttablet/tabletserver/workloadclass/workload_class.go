@@ -0,0 +1,188 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package workloadclass shares the connections of a pool between workload
+// classes, such as OLTP, batch or analytics queries. Each class has a number
+// of connections reserved for its queries, and a maximum number of
+// connections it can use, so that the queries of a class cannot starve the
+// queries of the others.
+package workloadclass
+
+import (
+	"context"
+	"sync"
+
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/tabletenv"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// DefaultClass is the class of the queries that have no class, or whose
+// class has no capacities in a pool.
+const DefaultClass = "default"
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the workload class of a request.
+func NewContext(ctx context.Context, class string) context.Context {
+	return context.WithValue(ctx, contextKey{}, class)
+}
+
+// FromContext returns the workload class of a request, or an empty string if
+// it has none.
+func FromContext(ctx context.Context) string {
+	class, _ := ctx.Value(contextKey{}).(string)
+	return class
+}
+
+// Gate admits the queries of the workload classes to a conn pool, based on
+// the capacities of the classes. A query of a class first uses the
+// connections reserved for the class, then the connections not reserved by
+// any class, up to the burst capacity of the class. A nil Gate admits all
+// the queries.
+type Gate struct {
+	name string
+
+	mu       sync.Mutex
+	capacity int
+	// reserved is the number of connections reserved by all the classes,
+	// and shared the number of connections in use beyond the reserved
+	// connections of their class.
+	reserved int
+	shared   int
+	classes  map[string]*class
+	// released is closed and replaced when a connection is released, to
+	// wake up the waiting queries.
+	released chan struct{}
+
+	inUse             *stats.GaugesWithSingleLabel
+	waits, rejections *stats.CountersWithSingleLabel
+}
+
+type class struct {
+	name     string
+	reserved int
+	burst    int
+	inUse    int
+}
+
+// NewGate creates the Gate of a conn pool of the given capacity, or returns
+// nil if the pool has no workload classes. The name is used to publish stats
+// only.
+func NewGate(env tabletenv.Env, name string, capacity int, classes map[string]tabletenv.WorkloadClassConfig) *Gate {
+	if len(classes) == 0 {
+		return nil
+	}
+	g := &Gate{
+		name:       name,
+		capacity:   capacity,
+		classes:    make(map[string]*class, len(classes)+1),
+		released:   make(chan struct{}),
+		inUse:      env.Exporter().NewGaugesWithSingleLabel(name+"WorkloadClassInUse", "Connections in use per workload class", "WorkloadClass"),
+		waits:      env.Exporter().NewCountersWithSingleLabel(name+"WorkloadClassWaits", "Queries that waited for the capacity of their workload class", "WorkloadClass"),
+		rejections: env.Exporter().NewCountersWithSingleLabel(name+"WorkloadClassRejections", "Queries rejected for exceeding the capacity of their workload class", "WorkloadClass"),
+	}
+	for name, cfg := range classes {
+		g.classes[name] = &class{name: name, reserved: cfg.Reserved, burst: cfg.Burst}
+		g.reserved += cfg.Reserved
+	}
+	if _, ok := g.classes[DefaultClass]; !ok {
+		g.classes[DefaultClass] = &class{name: DefaultClass}
+	}
+	return g
+}
+
+// Acquire waits until the pool has a connection for the workload class of
+// the context, and returns the class the connection is accounted to. Release
+// must be called with it once the connection is returned to the pool.
+func (g *Gate) Acquire(ctx context.Context) (string, error) {
+	if g == nil {
+		return "", nil
+	}
+	c, ok := g.classes[FromContext(ctx)]
+	if !ok {
+		c = g.classes[DefaultClass]
+	}
+	waited := false
+	for {
+		g.mu.Lock()
+		if g.tryAcquireLocked(c) {
+			g.mu.Unlock()
+			return c.name, nil
+		}
+		released := g.released
+		g.mu.Unlock()
+
+		if !waited {
+			g.waits.Add(c.name, 1)
+			waited = true
+		}
+		select {
+		case <-released:
+		case <-ctx.Done():
+			g.rejections.Add(c.name, 1)
+			return "", vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "%s: workload class %s exceeded its capacity: %v", g.name, c.name, ctx.Err())
+		}
+	}
+}
+
+func (g *Gate) tryAcquireLocked(c *class) bool {
+	switch {
+	case c.inUse < c.reserved:
+	case c.burst != 0 && c.inUse >= c.burst:
+		return false
+	case g.shared >= g.capacity-g.reserved:
+		return false
+	default:
+		g.shared++
+	}
+	c.inUse++
+	g.inUse.Set(c.name, int64(c.inUse))
+	return true
+}
+
+// Release returns a connection of the workload class returned by Acquire.
+func (g *Gate) Release(name string) {
+	if g == nil || name == "" {
+		return
+	}
+	c := g.classes[name]
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if c.inUse > c.reserved {
+		g.shared--
+	}
+	c.inUse--
+	g.inUse.Set(c.name, int64(c.inUse))
+	close(g.released)
+	g.released = make(chan struct{})
+}
+
+// SetCapacity changes the capacity of the pool. The connections reserved by
+// the classes are not changed, so they take a larger share of a smaller pool.
+func (g *Gate) SetCapacity(capacity int) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.capacity = capacity
+	close(g.released)
+	g.released = make(chan struct{})
+}
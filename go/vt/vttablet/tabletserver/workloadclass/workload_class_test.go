@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloadclass
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/tabletenv"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+func acquireNow(t *testing.T, g *Gate, class string) (string, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(NewContext(context.Background(), class), 10*time.Millisecond)
+	defer cancel()
+	return g.Acquire(ctx)
+}
+
+func TestGate(t *testing.T) {
+	// Out of 10 connections, 4 are reserved for oltp and 1 for batch, which
+	// can use at most 3.
+	g := NewGate(tabletenv.NewEnv(nil, "GateTest"), "TestGate", 10, map[string]tabletenv.WorkloadClassConfig{
+		"oltp":  {Reserved: 4},
+		"batch": {Reserved: 1, Burst: 3},
+	})
+
+	for i := 0; i < 3; i++ {
+		class, err := acquireNow(t, g, "batch")
+		require.NoError(t, err)
+		assert.Equal(t, "batch", class)
+	}
+	_, err := acquireNow(t, g, "batch")
+	assert.EqualError(t, err, "TestGate: workload class batch exceeded its capacity: context deadline exceeded")
+	assert.Equal(t, vtrpcpb.Code_RESOURCE_EXHAUSTED, vterrors.Code(err))
+	assert.EqualValues(t, 1, g.rejections.Counts()["batch"])
+
+	// The queries without a class, or of an unknown class, use the
+	// default class, and the 3 remaining shared connections.
+	for _, class := range []string{"", "unknown", DefaultClass} {
+		got, err := acquireNow(t, g, class)
+		require.NoError(t, err)
+		assert.Equal(t, DefaultClass, got)
+	}
+	_, err = acquireNow(t, g, "")
+	assert.EqualError(t, err, "TestGate: workload class default exceeded its capacity: context deadline exceeded")
+
+	// The connections reserved for oltp are still available, but not more.
+	for i := 0; i < 4; i++ {
+		_, err := acquireNow(t, g, "oltp")
+		require.NoError(t, err)
+	}
+	_, err = acquireNow(t, g, "oltp")
+	assert.Error(t, err)
+	assert.EqualValues(t, 4, g.inUse.Counts()["oltp"])
+
+	// A waiting query gets the connection released by another class.
+	done := make(chan error)
+	go func() {
+		_, err := g.Acquire(NewContext(context.Background(), "oltp"))
+		done <- err
+	}()
+	g.Release(DefaultClass)
+	require.NoError(t, <-done)
+	assert.EqualValues(t, 5, g.inUse.Counts()["oltp"])
+	assert.EqualValues(t, 2, g.inUse.Counts()[DefaultClass])
+
+	// A class using more than its reserved connections frees a shared
+	// connection, which the other classes can use.
+	g.Release("batch")
+	_, err = acquireNow(t, g, "")
+	require.NoError(t, err)
+	_, err = acquireNow(t, g, "batch")
+	assert.Error(t, err)
+
+	// Growing the pool adds shared connections.
+	g.SetCapacity(11)
+	_, err = acquireNow(t, g, "")
+	require.NoError(t, err)
+}
+
+func TestNilGate(t *testing.T) {
+	g := NewGate(tabletenv.NewEnv(nil, "GateTest"), "TestNilGate", 10, nil)
+	require.Nil(t, g)
+	class, err := g.Acquire(NewContext(context.Background(), "batch"))
+	require.NoError(t, err)
+	assert.Equal(t, "", class)
+	g.Release(class)
+	g.SetCapacity(5)
+}